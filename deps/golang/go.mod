@@ -3,6 +3,7 @@ module gitlab.com/grchive/grchive-v2
 go 1.14
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/denisenkom/go-mssqldb v0.0.0-20200831201914-36b6ff1bbc10
 	github.com/go-ldap/ldap/v3 v3.2.3
 	github.com/go-sql-driver/mysql v1.5.0
@@ -13,7 +14,10 @@ require (
 	github.com/machinebox/graphql v0.2.2
 	github.com/onsi/gomega v1.10.1
 	github.com/testcontainers/testcontainers-go v0.7.0
+	go.mongodb.org/mongo-driver v1.4.2
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/net v0.0.0-20200813134508-3edf25e44fcc
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
 	google.golang.org/api v0.30.0
+	gopkg.in/yaml.v2 v2.3.0
 )
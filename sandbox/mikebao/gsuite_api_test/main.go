@@ -14,7 +14,14 @@ func main() {
 	flag.StringVar(&credentialFname, "cred", "", "Google OAuth Client credentials")
 	flag.Parse()
 
-	ts, err := auth_utility.CreateGoogleOAuthTokenSource(credentialFname, "mike@grchive.com", admin.AdminDirectoryUserReadonlyScope)
+	ts, err := auth_utility.CreateGoogleOAuthTokenSource(
+		credentialFname,
+		"mike@grchive.com",
+		admin.AdminDirectoryUserReadonlyScope,
+		admin.AdminDirectoryGroupReadonlyScope,
+		admin.AdminDirectoryRolemanagementReadonlyScope,
+		admin.AdminDirectoryUserSecurityScope,
+	)
 	if err != nil {
 		fmt.Printf("Create Token Source Error: %s\n", err.Error())
 		return
@@ -12,7 +12,7 @@ var tenant string
 var clientId string
 var clientSecret string
 
-func setupTokenSource(resource string, scopes ...string) (oauth2.TokenSource, error) {
+func setupTokenSources(scopes ...string) (map[string]oauth2.TokenSource, error) {
 	config := auth_utility.AzureOAuthSetup{
 		Tenant:       tenant,
 		ClientId:     clientId,
@@ -37,7 +37,7 @@ func setupTokenSource(resource string, scopes ...string) (oauth2.TokenSource, er
 		return nil, err
 	}
 
-	return tokenSources[resource], nil
+	return tokenSources, nil
 }
 
 func main() {
@@ -46,18 +46,24 @@ func main() {
 	flag.StringVar(&clientSecret, "secret", "", "Azure App OAuth Client Secret")
 	flag.Parse()
 
-	graphTs, err := setupTokenSource(auth_utility.AzureGraphResource,
+	tokenSources, err := setupTokenSources(
 		"offline_access",
 		"https://graph.microsoft.com/user.read.all",
 		"https://graph.microsoft.com/rolemanagement.read.directory",
+		"https://graph.microsoft.com/group.read.all",
+		"https://graph.microsoft.com/sites.read.all",
+		"https://graph.microsoft.com/files.read.all",
+		"https://outlook.office365.com/Exchange.Manage",
 	)
 	if err != nil {
-		fmt.Printf("Graph Token Error: %s\n", err.Error())
+		fmt.Printf("Token Error: %s\n", err.Error())
 		return
 	}
 
 	connector, err := office365.CreateOffice365Connector(&office365.EtlOffice365Options{
-		Client: auth_utility.CreateAzureHttpClient(graphTs),
+		Client:         auth_utility.CreateAzureHttpClient(tokenSources[auth_utility.AzureGraphResource]),
+		ExchangeClient: auth_utility.CreateAzureHttpClient(tokenSources[auth_utility.AzureExchangeResource]),
+		TenantId:       tenant,
 	})
 	if err != nil {
 		fmt.Printf("Create Connector Error: %s\n", err.Error())
@@ -0,0 +1,160 @@
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/utility/ssh"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DatabaseType string
+
+const (
+	DatabaseTypePostgres DatabaseType = "postgres"
+	DatabaseTypeMysql    DatabaseType = "mysql"
+	DatabaseTypeMariadb  DatabaseType = "mariadb"
+	DatabaseTypeMssql    DatabaseType = "mssql"
+	DatabaseTypeOracle   DatabaseType = "oracle"
+	DatabaseTypeDb2      DatabaseType = "db2"
+	DatabaseTypeRedshift DatabaseType = "redshift"
+)
+
+var defaultPorts = map[DatabaseType]int{
+	DatabaseTypePostgres: 5432,
+	DatabaseTypeMysql:    3306,
+	DatabaseTypeMariadb:  3306,
+	DatabaseTypeMssql:    1433,
+	DatabaseTypeOracle:   1521,
+	DatabaseTypeDb2:      50000,
+	DatabaseTypeRedshift: 5439,
+}
+
+// TlsMode follows the naming of PostgreSQL's sslmode.
+type TlsMode string
+
+const (
+	// Don't use TLS.
+	TlsModeDisable TlsMode = "disable"
+	// Use TLS but don't verify the server's certificate.
+	TlsModeRequire TlsMode = "require"
+	// Use TLS and verify that the server's certificate was signed by a trusted CA.
+	TlsModeVerifyCa TlsMode = "verify-ca"
+	// Use TLS, verify the server's certificate and verify that it's for the host being connected to.
+	TlsModeVerifyFull TlsMode = "verify-full"
+)
+
+type TlsConfig struct {
+	Mode TlsMode `json:"mode"`
+	// PEM bundle of the CAs to trust. The system's CAs are used if this is empty. Oracle
+	// expects the directory of a wallet containing the CAs instead.
+	CaFile string `json:"caFile"`
+	// Overrides the host name expected in the server's certificate.
+	ServerName string `json:"serverName"`
+}
+
+func (c TlsConfig) enabled() bool {
+	return c.Mode != "" && c.Mode != TlsModeDisable
+}
+
+func (c TlsConfig) verify() bool {
+	return c.Mode == TlsModeVerifyCa || c.Mode == TlsModeVerifyFull
+}
+
+type CredentialSource string
+
+const (
+	// The password is stored in the config itself.
+	CredentialSourcePlain CredentialSource = "plain"
+	// The password is stored in an environment variable.
+	CredentialSourceEnv CredentialSource = "env"
+	// The password is stored in a file (e.g. a mounted secret).
+	CredentialSourceFile CredentialSource = "file"
+)
+
+type Credentials struct {
+	Username string           `json:"username"`
+	Source   CredentialSource `json:"source"`
+	// The password itself, the name of the environment variable or the path to the file that holds
+	// the password depending on Source.
+	Password string `json:"password"`
+}
+
+func (c Credentials) resolvePassword() (string, error) {
+	switch c.Source {
+	case "", CredentialSourcePlain:
+		return c.Password, nil
+	case CredentialSourceEnv:
+		password, ok := os.LookupEnv(c.Password)
+		if !ok {
+			return "", fmt.Errorf("Environment variable %s is not set.", c.Password)
+		}
+		return password, nil
+	case CredentialSourceFile:
+		data, err := ioutil.ReadFile(c.Password)
+		if err != nil {
+			return "", err
+		}
+		// Secrets written to files tend to have a trailing newline.
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return "", errors.New("Unsupported credential source: " + string(c.Source))
+}
+
+type Config struct {
+	Type DatabaseType `json:"type"`
+	Host string       `json:"host"`
+	// Uses the default port for the database type if 0.
+	Port int `json:"port"`
+	// The database to connect to. This is the service name for Oracle.
+	Database    string      `json:"database"`
+	Tls         TlsConfig   `json:"tls"`
+	Credentials Credentials `json:"credentials"`
+
+	ConnectTimeout time.Duration `json:"connectTimeout"`
+	// The maximum time a query (including reading its results) may take. No limit if 0. Only applies to
+	// the queries made through databases.Query (see Connection.QueryRows), not to transactions.
+	QueryTimeout time.Duration `json:"queryTimeout"`
+
+	// The maximum number of open connections. Unlimited if 0.
+	MaxOpenConns int `json:"maxOpenConns"`
+	// The maximum number of idle connections kept for reuse. Uses database/sql's default (2) if 0.
+	MaxIdleConns int `json:"maxIdleConns"`
+	// How long a connection may be reused. Forever if 0.
+	ConnMaxLifetime time.Duration `json:"connMaxLifetime"`
+
+	// Additional driver specific DSN parameters.
+	Options map[string]string `json:"options"`
+
+	// Connects to the database through SSH (e.g. a bastion host) if set. Host and Port are
+	// then resolved by the last hop.
+	Ssh *ssh_utility.SshTunnelConfig `json:"ssh"`
+}
+
+func (c Config) port() int {
+	if c.Port != 0 {
+		return c.Port
+	}
+	return defaultPorts[c.Type]
+}
+
+func (c Config) address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.port()))
+}
+
+func (c Config) serverName() string {
+	if c.Tls.ServerName != "" {
+		return c.Tls.ServerName
+	}
+	return c.Host
+}
+
+// WithDatabase returns a copy of the config that connects to a different database on the same server.
+func (c Config) WithDatabase(database string) Config {
+	c.Database = database
+	return c
+}
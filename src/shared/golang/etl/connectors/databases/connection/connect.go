@@ -0,0 +1,238 @@
+package connection
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	_ "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/godror/godror"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/utility/ssh"
+	"io/ioutil"
+	"time"
+)
+
+// Connection is a databases.SqlxLike that applies the configured query timeout to the queries made through
+// databases.Query (and so databases.DB.LoggedQuery).
+type Connection struct {
+	*sqlx.DB
+	queryTimeout time.Duration
+	tunnel       *ssh_utility.SshTunnel
+	// Releases what the driver needed to connect through the tunnel.
+	releaseTunnel func()
+}
+
+// Close closes the database and the SSH tunnel it was reached through.
+func (c *Connection) Close() error {
+	err := c.DB.Close()
+	if c.releaseTunnel != nil {
+		c.releaseTunnel()
+	}
+
+	if c.tunnel != nil {
+		tunnelErr := c.tunnel.Close()
+		if err == nil {
+			err = tunnelErr
+		}
+	}
+	return err
+}
+
+// timeoutRows releases the context of the query once the rows are closed.
+type timeoutRows struct {
+	*sqlx.Rows
+	cancel context.CancelFunc
+}
+
+func (r timeoutRows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
+
+// QueryRows applies the query timeout to the query and to reading its rows. Queries made with Queryx or
+// in transactions (MustBegin) aren't bound by the timeout.
+func (c *Connection) QueryRows(query string, args ...interface{}) (databases.Rows, error) {
+	if c.queryTimeout <= 0 {
+		rows, err := c.DB.Queryx(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		return rows, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	rows, err := c.DB.QueryxContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return timeoutRows{Rows: rows, cancel: cancel}, nil
+}
+
+// createTlsConfig builds the TLS config for drivers that take a *tls.Config rather than file paths.
+func createTlsConfig(cfg Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: cfg.serverName(),
+	}
+
+	if cfg.Tls.CaFile != "" {
+		pem, err := ioutil.ReadFile(cfg.Tls.CaFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("Failed to parse CA bundle: " + cfg.Tls.CaFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.Tls.Mode == TlsModeVerifyCa {
+		// Go always checks the host name so do the chain verification manually instead.
+		roots := tlsConfig.RootCAs
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyCertificateChain(rawCerts, roots)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func verifyCertificateChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("Server did not present a certificate.")
+	}
+
+	certs := []*x509.Certificate{}
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// Connect opens a connection to the database described by the config and makes sure it's reachable.
+func Connect(cfg Config) (*Connection, error) {
+	password, err := cfg.Credentials.resolvePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	var tunnel *ssh_utility.SshTunnel
+	if cfg.Ssh != nil {
+		tunnel, err = ssh_utility.OpenSshTunnel(*cfg.Ssh)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := connect(cfg, password, tunnel)
+	if err != nil {
+		if tunnel != nil {
+			tunnel.Close()
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+func connect(cfg Config, password string, tunnel *ssh_utility.SshTunnel) (*Connection, error) {
+	var err error
+	if tunnel != nil && usesForwardedPort(cfg.Type) {
+		cfg, err = forwardThroughTunnel(cfg, tunnel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	driver, dsn, err := BuildDsn(cfg, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver == mysqlDriver && cfg.Tls.verify() {
+		tlsConfig, err := createTlsConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		err = mysql.RegisterTLSConfig(mysqlTlsConfigName(cfg), tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var db *sqlx.DB
+	releaseTunnel := func() {}
+	if tunnel != nil {
+		db, releaseTunnel, err = openTunnelledDb(cfg, driver, dsn, tunnel)
+	} else {
+		db, err = sqlx.Open(driver, dsn)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Setting 0 would disable pooling idle connections so the driver defaults are kept instead.
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+
+	ctx := context.Background()
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ConnectTimeout)
+		defer cancel()
+	}
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		releaseTunnel()
+		return nil, err
+	}
+
+	return &Connection{
+		DB:            db,
+		queryTimeout:  cfg.QueryTimeout,
+		tunnel:        tunnel,
+		releaseTunnel: releaseTunnel,
+	}, nil
+}
+
+// DatabaseConnectFn returns a function that connects to other databases on the same server
+// (e.g. for psql.CreatePsqlClusterConnector).
+func DatabaseConnectFn(cfg Config) func(string) (databases.SqlxLike, error) {
+	return func(database string) (databases.SqlxLike, error) {
+		return Connect(cfg.WithDatabase(database))
+	}
+}
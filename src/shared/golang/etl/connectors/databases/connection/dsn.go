@@ -0,0 +1,233 @@
+package connection
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	postgresDriver = "postgres"
+	mysqlDriver    = "mysql"
+	mssqlDriver    = "sqlserver"
+	oracleDriver   = "godror"
+	// github.com/ibmdb/go_ibm_db needs the Db2 CLI driver to build so it isn't linked in here. Programs
+	// connecting to Db2 need to import it themselves to register the driver.
+	db2Driver = "go_ibm_db"
+)
+
+// BuildDsn returns the name of the driver and the DSN to connect to the database described by the
+// config using the given password.
+func BuildDsn(cfg Config, password string) (string, string, error) {
+	if cfg.Host == "" {
+		return "", "", errors.New("No database host specified.")
+	}
+
+	switch cfg.Type {
+	case DatabaseTypePostgres, DatabaseTypeRedshift:
+		return postgresDriver, buildPostgresDsn(cfg, password), nil
+	case DatabaseTypeMysql, DatabaseTypeMariadb:
+		return mysqlDriver, buildMysqlDsn(cfg, password), nil
+	case DatabaseTypeMssql:
+		return mssqlDriver, buildMssqlDsn(cfg, password), nil
+	case DatabaseTypeOracle:
+		return oracleDriver, buildOracleDsn(cfg, password), nil
+	case DatabaseTypeDb2:
+		return db2Driver, buildDb2Dsn(cfg, password), nil
+	}
+	return "", "", errors.New("Unsupported database type: " + string(cfg.Type))
+}
+
+func setOptions(params url.Values, options map[string]string) {
+	for k, v := range options {
+		params.Set(k, v)
+	}
+}
+
+func buildPostgresDsn(cfg Config, password string) string {
+	params := url.Values{}
+
+	mode := cfg.Tls.Mode
+	if mode == "" {
+		mode = TlsModeDisable
+	}
+	params.Set("sslmode", string(mode))
+
+	if cfg.Tls.enabled() && cfg.Tls.CaFile != "" {
+		params.Set("sslrootcert", cfg.Tls.CaFile)
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		params.Set("connect_timeout", strconv.Itoa(int(cfg.ConnectTimeout.Seconds())))
+	}
+
+	setOptions(params, cfg.Options)
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.Credentials.Username, password),
+		Host:     cfg.address(),
+		Path:     "/" + cfg.Database,
+		RawQuery: params.Encode(),
+	}
+	return dsn.String()
+}
+
+// mysqlTlsConfigName is the name the custom TLS config for the connection is registered under
+// with the MySQL driver. The registry is global so the name covers everything the TLS config is
+// built from so that connections to the same server with different TLS settings don't share it.
+func mysqlTlsConfigName(cfg Config) string {
+	settings := sha256.Sum256([]byte(strings.Join([]string{
+		string(cfg.Tls.Mode),
+		cfg.Tls.CaFile,
+		cfg.serverName(),
+	}, "\x00")))
+	return fmt.Sprintf("grchive-%s-%d-%s", cfg.Host, cfg.port(), hex.EncodeToString(settings[:8]))
+}
+
+func buildMysqlDsn(cfg Config, password string) string {
+	mcfg := mysql.NewConfig()
+	mcfg.User = cfg.Credentials.Username
+	mcfg.Passwd = password
+	mcfg.Net = "tcp"
+	mcfg.Addr = cfg.address()
+	mcfg.DBName = cfg.Database
+	mcfg.Timeout = cfg.ConnectTimeout
+	mcfg.ReadTimeout = cfg.QueryTimeout
+	mcfg.WriteTimeout = cfg.QueryTimeout
+	mcfg.Params = cfg.Options
+
+	switch cfg.Tls.Mode {
+	case TlsModeRequire:
+		mcfg.TLSConfig = "skip-verify"
+	case TlsModeVerifyCa, TlsModeVerifyFull:
+		mcfg.TLSConfig = mysqlTlsConfigName(cfg)
+	}
+
+	return mcfg.FormatDSN()
+}
+
+func buildMssqlDsn(cfg Config, password string) string {
+	params := url.Values{}
+	if cfg.Database != "" {
+		params.Set("database", cfg.Database)
+	}
+
+	if cfg.Tls.enabled() {
+		params.Set("encrypt", "true")
+		params.Set("TrustServerCertificate", strconv.FormatBool(!cfg.Tls.verify()))
+
+		if cfg.Tls.CaFile != "" {
+			params.Set("certificate", cfg.Tls.CaFile)
+		}
+
+		if cfg.Tls.Mode == TlsModeVerifyFull {
+			params.Set("hostNameInCertificate", cfg.serverName())
+		}
+	} else {
+		params.Set("encrypt", "disable")
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		params.Set("dial timeout", strconv.Itoa(int(cfg.ConnectTimeout.Seconds())))
+	}
+
+	setOptions(params, cfg.Options)
+
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(cfg.Credentials.Username, password),
+		Host:     cfg.address(),
+		RawQuery: params.Encode(),
+	}
+	return dsn.String()
+}
+
+// buildOracleDsn uses an Easy Connect Plus string (Oracle 19c clients) so that TLS and the connect
+// timeout can be configured without a tnsnames.ora.
+func buildOracleDsn(cfg Config, password string) string {
+	// Easy Connect parameters aren't URL encoded.
+	params := []string{}
+	protocol := ""
+	if cfg.Tls.enabled() {
+		protocol = "tcps://"
+		params = append(params, fmt.Sprintf("ssl_server_dn_match=%t", cfg.Tls.Mode == TlsModeVerifyFull))
+		if cfg.Tls.CaFile != "" {
+			params = append(params, "wallet_location="+cfg.Tls.CaFile)
+		}
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		params = append(params, fmt.Sprintf("connect_timeout=%d", int(cfg.ConnectTimeout.Seconds())))
+	}
+
+	connectString := fmt.Sprintf("%s%s/%s", protocol, cfg.address(), cfg.Database)
+	if len(params) > 0 {
+		connectString = connectString + "?" + strings.Join(params, "&")
+	}
+
+	parts := []string{
+		fmt.Sprintf("user=%s", strconv.Quote(cfg.Credentials.Username)),
+		fmt.Sprintf("password=%s", strconv.Quote(password)),
+		fmt.Sprintf("connectString=%s", strconv.Quote(connectString)),
+	}
+
+	for _, k := range sortedKeys(cfg.Options) {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, strconv.Quote(cfg.Options[k])))
+	}
+	return strings.Join(parts, " ")
+}
+
+// db2Value wraps values that contain a semicolon in braces so they don't end the keyword.
+func db2Value(v string) string {
+	if strings.ContainsAny(v, ";{}") {
+		return "{" + v + "}"
+	}
+	return v
+}
+
+func buildDb2Dsn(cfg Config, password string) string {
+	parts := []string{
+		"HOSTNAME=" + db2Value(cfg.Host),
+		"PORT=" + strconv.Itoa(cfg.port()),
+		"DATABASE=" + db2Value(cfg.Database),
+		"UID=" + db2Value(cfg.Credentials.Username),
+		"PWD=" + db2Value(password),
+		"PROTOCOL=TCPIP",
+	}
+
+	if cfg.Tls.enabled() {
+		parts = append(parts, "SECURITY=SSL")
+		if cfg.Tls.CaFile != "" {
+			parts = append(parts, "SSLServerCertificate="+db2Value(cfg.Tls.CaFile))
+		}
+
+		if cfg.Tls.Mode == TlsModeVerifyFull {
+			parts = append(parts, "SSLClientHostnameValidation=Basic")
+		}
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		parts = append(parts, "ConnectTimeout="+strconv.Itoa(int(cfg.ConnectTimeout.Seconds())))
+	}
+
+	for _, k := range sortedKeys(cfg.Options) {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, db2Value(cfg.Options[k])))
+	}
+	return strings.Join(parts, ";") + ";"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
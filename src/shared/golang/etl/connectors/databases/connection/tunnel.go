@@ -0,0 +1,161 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gitlab.com/grchive/grchive-v3/shared/utility/ssh"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// pqTunnelConnector opens PostgreSQL connections through an SSH tunnel.
+type pqTunnelConnector struct {
+	dsn    string
+	tunnel *ssh_utility.SshTunnel
+}
+
+func (c pqTunnelConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return pq.DialOpen(c.tunnel, c.dsn)
+}
+
+func (c pqTunnelConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// The MySQL driver only takes custom dialers through a global registry that dialers can't be removed
+// from. Each registered network is bound to one open tunnel at a time and is reused for another tunnel
+// once the connection using it is closed.
+type mysqlTunnelRegistry struct {
+	mutex   sync.Mutex
+	tunnels map[string]*ssh_utility.SshTunnel
+	free    []string
+}
+
+var mysqlTunnels = &mysqlTunnelRegistry{
+	tunnels: map[string]*ssh_utility.SshTunnel{},
+}
+
+// register binds the tunnel to a network and returns the network's name.
+func (r *mysqlTunnelRegistry) register(tunnel *ssh_utility.SshTunnel) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var network string
+	if len(r.free) > 0 {
+		network = r.free[len(r.free)-1]
+		r.free = r.free[:len(r.free)-1]
+	} else {
+		network = fmt.Sprintf("grchive-ssh-%d", len(r.tunnels)+1)
+		mysql.RegisterDialContext(network, func(ctx context.Context, addr string) (net.Conn, error) {
+			return r.dial(ctx, network, addr)
+		})
+	}
+
+	r.tunnels[network] = tunnel
+	return network
+}
+
+func (r *mysqlTunnelRegistry) dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	r.mutex.Lock()
+	tunnel, ok := r.tunnels[network]
+	r.mutex.Unlock()
+
+	if !ok {
+		return nil, errors.New("SSH tunnel is closed.")
+	}
+	return tunnel.DialContext(ctx, "tcp", addr)
+}
+
+// release unbinds the network from its tunnel so that it can be reused.
+func (r *mysqlTunnelRegistry) release(network string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.tunnels[network]; ok {
+		delete(r.tunnels, network)
+		r.free = append(r.free, network)
+	}
+}
+
+// usesForwardedPort returns whether the driver for the database type can't be given a dialer
+// (the Oracle and Db2 drivers are wrappers around C client libraries) and has to connect to a
+// local port that's forwarded through the tunnel instead.
+func usesForwardedPort(typ DatabaseType) bool {
+	return typ == DatabaseTypeOracle || typ == DatabaseTypeDb2
+}
+
+// forwardThroughTunnel points the config at a local port that's forwarded to the database
+// through the tunnel.
+func forwardThroughTunnel(cfg Config, tunnel *ssh_utility.SshTunnel) (Config, error) {
+	// The client library would check the certificate against the local address.
+	if cfg.Tls.Mode == TlsModeVerifyFull {
+		return cfg, fmt.Errorf("TLS mode %s is not supported through an SSH tunnel for %s, use %s instead.", TlsModeVerifyFull, cfg.Type, TlsModeVerifyCa)
+	}
+
+	listener, err := tunnel.Forward(cfg.address())
+	if err != nil {
+		return cfg, err
+	}
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.Host = host
+	cfg.Port, err = strconv.Atoi(port)
+	return cfg, err
+}
+
+// openTunnelledDb opens a database whose connections are made through the tunnel. The returned function
+// must be called once the database is closed.
+func openTunnelledDb(cfg Config, driverName string, dsn string, tunnel *ssh_utility.SshTunnel) (*sqlx.DB, func(), error) {
+	var connector driver.Connector
+	release := func() {}
+
+	switch driverName {
+	case postgresDriver:
+		connector = pqTunnelConnector{dsn: dsn, tunnel: tunnel}
+	case mysqlDriver:
+		mcfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		network := mysqlTunnels.register(tunnel)
+		release = func() {
+			mysqlTunnels.release(network)
+		}
+
+		mcfg.Net = network
+		connector, err = mysql.NewConnector(mcfg)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+	case mssqlDriver:
+		mssqlConnector, err := mssql.NewConnector(dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mssqlConnector.Dialer = tunnel
+		connector = mssqlConnector
+	default:
+		if usesForwardedPort(cfg.Type) {
+			db, err := sqlx.Open(driverName, dsn)
+			return db, release, err
+		}
+		return nil, nil, errors.New("Unsupported database type for SSH tunnels: " + string(cfg.Type))
+	}
+
+	return sqlx.NewDb(sql.OpenDB(connector), driverName), release, nil
+}
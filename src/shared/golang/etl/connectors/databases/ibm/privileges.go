@@ -0,0 +1,240 @@
+package ibm
+
+import (
+	"fmt"
+	"strings"
+)
+
+const grantOptionSuffix = " WITH GRANT OPTION"
+
+// Values of the *AUTH columns in the SYSCAT.*AUTH views.
+const (
+	IBM_AUTH_HELD              string = "Y"
+	IBM_AUTH_HELD_GRANT_OPTION        = "G"
+)
+
+type ibmAuthColumn struct {
+	Privilege string
+	Column    string
+}
+
+// Authorities held on the database as a whole. CREATESECUREAUTH requires Db2 10.1 or newer.
+var ibmDatabaseAuthColumns = []ibmAuthColumn{
+	{"ACCESSCTRL", "ACCESSCTRLAUTH"},
+	{"BINDADD", "BINDADDAUTH"},
+	{"CONNECT", "CONNECTAUTH"},
+	{"CREATETAB", "CREATETABAUTH"},
+	{"CREATE_EXTERNAL_ROUTINE", "EXTERNALROUTINEAUTH"},
+	{"CREATE_NOT_FENCED_ROUTINE", "NOFENCEAUTH"},
+	{"CREATE_SECURE_OBJECT", "CREATESECUREAUTH"},
+	{"DATAACCESS", "DATAACCESSAUTH"},
+	{"DBADM", "DBADMAUTH"},
+	{"EXPLAIN", "EXPLAINAUTH"},
+	{"IMPLICIT_SCHEMA", "IMPLSCHEMAAUTH"},
+	{"LOAD", "LOADAUTH"},
+	{"QUIESCE_CONNECT", "QUIESCECONNECTAUTH"},
+	{"SECADM", "SECURITYADMAUTH"},
+	{"SQLADM", "SQLADMAUTH"},
+	{"WLMADM", "WLMADMAUTH"},
+}
+
+var ibmSchemaAuthColumns = []ibmAuthColumn{
+	{"ALTERIN", "ALTERINAUTH"},
+	{"CREATEIN", "CREATEINAUTH"},
+	{"DROPIN", "DROPINAUTH"},
+}
+
+var ibmTableAuthColumns = []ibmAuthColumn{
+	{"CONTROL", "CONTROLAUTH"},
+	{"ALTER", "ALTERAUTH"},
+	{"DELETE", "DELETEAUTH"},
+	{"INDEX", "INDEXAUTH"},
+	{"INSERT", "INSERTAUTH"},
+	{"REFERENCES", "REFAUTH"},
+	{"SELECT", "SELECTAUTH"},
+	{"UPDATE", "UPDATEAUTH"},
+}
+
+var ibmRoutineAuthColumns = []ibmAuthColumn{
+	{"EXECUTE", "EXECUTEAUTH"},
+}
+
+// Object types in SYSIBMADM.PRIVILEGES that are read from the SYSCAT.*AUTH views instead so that
+// grant options are reported consistently.
+var ibmCatalogObjectTypes = []string{
+	"DATABASE",
+	"SCHEMA",
+	"TABLE",
+	"VIEW",
+	"NICKNAME",
+	"FUNCTION",
+	"PROCEDURE",
+	"METHOD",
+}
+
+// privilegeWithGrantOption returns the SQL that appends the grant option marker to the privilege
+// when the auth column indicates it was granted WITH GRANT OPTION.
+func privilegeWithGrantOption(privilege string, grantable string) string {
+	return fmt.Sprintf(
+		`%s CONCAT CASE WHEN %s THEN '%s' ELSE '' END`,
+		privilege,
+		grantable,
+		grantOptionSuffix,
+	)
+}
+
+// unpivotAuthColumns turns one row of a SYSCAT.*AUTH view (one column per privilege) into a row per
+// privilege held by the grantee.
+func unpivotAuthColumns(view string, object string, columns []ibmAuthColumn) string {
+	values := []string{}
+	for _, c := range columns {
+		values = append(values, fmt.Sprintf("('%s', a.%s)", c.Privilege, c.Column))
+	}
+
+	return fmt.Sprintf(`
+			SELECT
+				a.GRANTEE AS AUTHID,
+				a.GRANTEETYPE AS AUTHIDTYPE,
+				%s AS PRIVILEGE,
+				%s AS OBJECT
+			FROM %s a, TABLE (VALUES %s) AS p (PRIVILEGE, AUTH)
+			WHERE p.AUTH IN ('%s', '%s')`,
+		privilegeWithGrantOption("p.PRIVILEGE", fmt.Sprintf("p.AUTH = '%s'", IBM_AUTH_HELD_GRANT_OPTION)),
+		object,
+		view,
+		strings.Join(values, ", "),
+		IBM_AUTH_HELD,
+		IBM_AUTH_HELD_GRANT_OPTION,
+	)
+}
+
+func quotedList(values []string) string {
+	quoted := []string{}
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("'%s'", v))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// ibmPrivilegesCte gathers every privilege as (AUTHID, AUTHIDTYPE, PRIVILEGE, OBJECT) rows. The type (U, G
+// or R) is kept since a user, a group and a role can share the same name:
+//   - Database authorities (SYSCAT.DBAUTH) on DATABASE.
+//   - Schema, table, column and routine privileges (SYSCAT.SCHEMAAUTH, TABAUTH, COLAUTH, ROUTINEAUTH).
+//   - Privileges on every other type of object (SYSIBMADM.PRIVILEGES).
+//   - LBAC security label access and exemptions (SYSCAT.SECURITYLABELACCESS, SECURITYPOLICYEXEMPTIONS).
+//   - Trusted context usage (SYSCAT.CONTEXTS, SURROGATEAUTHIDS) and SETSESSIONUSER.
+var ibmPrivilegesCte = strings.Join([]string{
+	unpivotAuthColumns("SYSCAT.DBAUTH", "'DATABASE'", ibmDatabaseAuthColumns),
+	unpivotAuthColumns("SYSCAT.SCHEMAAUTH", "'SCHEMA::' CONCAT a.SCHEMANAME", ibmSchemaAuthColumns),
+	unpivotAuthColumns("SYSCAT.TABAUTH", "'TABLE::' CONCAT a.TABSCHEMA CONCAT '.' CONCAT a.TABNAME", ibmTableAuthColumns),
+	unpivotAuthColumns(
+		"SYSCAT.ROUTINEAUTH",
+		`CASE a.ROUTINETYPE
+					WHEN 'F' THEN 'FUNCTION'
+					WHEN 'P' THEN 'PROCEDURE'
+					ELSE 'METHOD'
+				END CONCAT '::' CONCAT a.SCHEMA CONCAT '.' CONCAT COALESCE(a.SPECIFICNAME, '*')`,
+		ibmRoutineAuthColumns,
+	),
+	fmt.Sprintf(`
+			SELECT
+				GRANTEE AS AUTHID,
+				GRANTEETYPE AS AUTHIDTYPE,
+				%s AS PRIVILEGE,
+				'COLUMN::' CONCAT TABSCHEMA CONCAT '.' CONCAT TABNAME CONCAT '.' CONCAT COLNAME AS OBJECT
+			FROM SYSCAT.COLAUTH`,
+		privilegeWithGrantOption(`CASE PRIVTYPE
+					WHEN 'R' THEN 'REFERENCES'
+					WHEN 'U' THEN 'UPDATE'
+				END`, fmt.Sprintf("GRANTABLE = '%s'", IBM_AUTH_HELD_GRANT_OPTION)),
+	),
+	fmt.Sprintf(`
+			SELECT
+				AUTHID,
+				AUTHIDTYPE,
+				%s AS PRIVILEGE,
+				OBJECTTYPE CONCAT '::' CONCAT OBJECTSCHEMA CONCAT '.' CONCAT OBJECTNAME AS OBJECT
+			FROM SYSIBMADM.PRIVILEGES
+			WHERE OBJECTTYPE NOT IN (%s)`,
+		privilegeWithGrantOption("PRIVILEGE", "GRANTABLE = 'Y'"),
+		quotedList(ibmCatalogObjectTypes),
+	),
+	`
+			SELECT
+				a.GRANTEE AS AUTHID,
+				a.GRANTEETYPE AS AUTHIDTYPE,
+				'READ' AS PRIVILEGE,
+				'SECURITY_LABEL::' CONCAT p.SECPOLICYNAME CONCAT '.' CONCAT l.SECLABELNAME AS OBJECT
+			FROM SYSCAT.SECURITYLABELACCESS a
+			INNER JOIN SYSCAT.SECURITYLABELS l
+				ON l.SECLABELID = a.SECLABELID AND l.SECPOLICYID = a.SECPOLICYID
+			INNER JOIN SYSCAT.SECURITYPOLICIES p
+				ON p.SECPOLICYID = a.SECPOLICYID
+			WHERE a.ACCESSTYPE IN ('B', 'R')`,
+	`
+			SELECT
+				a.GRANTEE AS AUTHID,
+				a.GRANTEETYPE AS AUTHIDTYPE,
+				'WRITE' AS PRIVILEGE,
+				'SECURITY_LABEL::' CONCAT p.SECPOLICYNAME CONCAT '.' CONCAT l.SECLABELNAME AS OBJECT
+			FROM SYSCAT.SECURITYLABELACCESS a
+			INNER JOIN SYSCAT.SECURITYLABELS l
+				ON l.SECLABELID = a.SECLABELID AND l.SECPOLICYID = a.SECPOLICYID
+			INNER JOIN SYSCAT.SECURITYPOLICIES p
+				ON p.SECPOLICYID = a.SECPOLICYID
+			WHERE a.ACCESSTYPE IN ('B', 'W')`,
+	`
+			SELECT
+				e.GRANTEE AS AUTHID,
+				e.GRANTEETYPE AS AUTHIDTYPE,
+				'EXEMPTION ' CONCAT e.ACCESSRULENAME AS PRIVILEGE,
+				'SECURITY_POLICY::' CONCAT p.SECPOLICYNAME AS OBJECT
+			FROM SYSCAT.SECURITYPOLICYEXEMPTIONS e
+			INNER JOIN SYSCAT.SECURITYPOLICIES p
+				ON p.SECPOLICYID = e.SECPOLICYID`,
+	`
+			SELECT
+				c.SYSTEMAUTHID AS AUTHID,
+				'U' AS AUTHIDTYPE,
+				'SYSTEM AUTHID' AS PRIVILEGE,
+				'TRUSTED_CONTEXT::' CONCAT c.CONTEXTNAME AS OBJECT
+			FROM SYSCAT.CONTEXTS c
+			WHERE c.ENABLED = 'Y'`,
+	`
+			SELECT
+				c.SYSTEMAUTHID AS AUTHID,
+				'U' AS AUTHIDTYPE,
+				'ROLE ' CONCAT c.DEFAULTCONTEXTROLE AS PRIVILEGE,
+				'TRUSTED_CONTEXT::' CONCAT c.CONTEXTNAME AS OBJECT
+			FROM SYSCAT.CONTEXTS c
+			WHERE c.ENABLED = 'Y' AND c.DEFAULTCONTEXTROLE IS NOT NULL`,
+	`
+			SELECT
+				s.SURROGATEAUTHID AS AUTHID,
+				s.AUTHIDTYPE,
+				'USE' AS PRIVILEGE,
+				'TRUSTED_CONTEXT::' CONCAT c.CONTEXTNAME AS OBJECT
+			FROM SYSCAT.SURROGATEAUTHIDS s
+			INNER JOIN SYSCAT.CONTEXTS c
+				ON c.CONTEXTNAME = s.TRUSTEDID
+			WHERE s.TRUSTEDIDTYPE = 'C' AND c.ENABLED = 'Y'`,
+	`
+			SELECT
+				s.SURROGATEAUTHID AS AUTHID,
+				s.AUTHIDTYPE,
+				'ROLE ' CONCAT COALESCE(s.CONTEXTROLE, c.DEFAULTCONTEXTROLE) AS PRIVILEGE,
+				'TRUSTED_CONTEXT::' CONCAT c.CONTEXTNAME AS OBJECT
+			FROM SYSCAT.SURROGATEAUTHIDS s
+			INNER JOIN SYSCAT.CONTEXTS c
+				ON c.CONTEXTNAME = s.TRUSTEDID
+			WHERE s.TRUSTEDIDTYPE = 'C' AND c.ENABLED = 'Y'
+				AND COALESCE(s.CONTEXTROLE, c.DEFAULTCONTEXTROLE) IS NOT NULL`,
+	`
+			SELECT
+				s.TRUSTEDID AS AUTHID,
+				s.TRUSTEDIDTYPE AS AUTHIDTYPE,
+				'SETSESSIONUSER' AS PRIVILEGE,
+				'SESSION_USER::' CONCAT s.SURROGATEAUTHID AS OBJECT
+			FROM SYSCAT.SURROGATEAUTHIDS s
+			WHERE s.TRUSTEDIDTYPE <> 'C'`,
+}, "\n\t\t\tUNION\n")
@@ -24,9 +24,15 @@ type ibmPrivilege struct {
 type ibmPrivilegeArray []ibmPrivilege
 type ibmAuthId struct {
 	AuthId     string
+	AuthIdType string
 	Privileges ibmPrivilegeArray
 }
 
+// Users, groups and roles can share the same name so they're identified by their type and name.
+func ibmAuthKey(authId string, authIdType string) string {
+	return authIdType + ":" + authId
+}
+
 func (arr ibmPrivilegeArray) toPermissionMap() types.PermissionMap {
 	ret := types.PermissionMap{}
 	for _, v := range arr {
@@ -75,28 +81,18 @@ func (c *EtlIBMConnectorUser) getAuthIds(types ...string) ([]ibmAuthId, *connect
 	src := connectors.CreateSourceInfo()
 	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
 		WITH privs AS (
-			SELECT
-				AUTHID,
-				PRIVILEGE,
-				OBJECTTYPE CONCAT '::' CONCAT OBJECTSCHEMA CONCAT '.' OBJECTNAME AS OBJECT
-			FROM SYSIBMADM.PRIVILEGES
-			UNION
-			SELECT
-				GRANTEE AS AUTHID,
-				CASE PRIVTYPE
-					WHEN 'R' THEN 'REFERENCE'
-					WHEN 'U' THEN 'UPDATE'
-				END AS PRIVILEGE,
-				'COLUMN::' CONCAT TABSCHEMA CONCAT '.' CONCAT TABNAME CONCAT '.' CONCAT COLNAME
-			FROM SYSCAT.COLAUTH
+			%s
 		)
 		SELECT
-			auth.AUTHID
+			auth.AUTHID,
+			auth.AUTHIDTYPE,
+			privs.PRIVILEGE,
+			privs.OBJECT
 		FROM SYSIBMADM.AUTHORIZATIONIDS auth
 		LEFT JOIN privs
-			ON privs.AUTHID = auth.AUTHID
+			ON privs.AUTHID = auth.AUTHID AND privs.AUTHIDTYPE = auth.AUTHIDTYPE
 		WHERE auth.AUTHIDTYPE IN (%s)
-	`, strings.Join(strings_utility.Map(types, func(s string) string {
+	`, ibmPrivilegesCte, strings.Join(strings_utility.Map(types, func(s string) string {
 		return fmt.Sprintf("'%s'", s)
 	}), ",")))
 	if err != nil {
@@ -106,42 +102,48 @@ func (c *EtlIBMConnectorUser) getAuthIds(types ...string) ([]ibmAuthId, *connect
 	src.AddCommand(cmd)
 
 	allAuths := map[string]*ibmAuthId{}
+	authKeys := []string{}
 	for rows.Next() {
 		auth := ibmAuthId{}
 		priv := ibmPrivilege{}
 
-		err = rows.Scan(&auth.AuthId)
+		err = rows.Scan(&auth.AuthId, &auth.AuthIdType, &priv.Privilege, &priv.Object)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		modAuth, ok := allAuths[auth.AuthId]
+		key := ibmAuthKey(auth.AuthId, auth.AuthIdType)
+		modAuth, ok := allAuths[key]
 		if !ok {
 			modAuth = &auth
+			authKeys = append(authKeys, key)
 		}
 		modAuth.Privileges = append(modAuth.Privileges, priv)
-		allAuths[auth.AuthId] = modAuth
+		allAuths[key] = modAuth
 	}
 
 	retAuths := []ibmAuthId{}
-	for _, auth := range allAuths {
-		retAuths = append(retAuths, *auth)
+	for _, key := range authKeys {
+		retAuths = append(retAuths, *allAuths[key])
 	}
 
 	return retAuths, src, nil
 }
 
+// getParentGroupRoleAuthIds returns the keys (see ibmAuthKey) of the groups and roles the auth ID belongs to.
 func (c *EtlIBMConnectorUser) getParentGroupRoleAuthIds(authId string, authType string) ([]string, *connectors.EtlSourceInfo, error) {
 	src := connectors.CreateSourceInfo()
 	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
 		SELECT 
-			GROUP AS AUTHID
+			GROUP AS AUTHID,
+			'%s' AS AUTHIDTYPE
 		FROM TABLE (SYSPROC.AUTH_LIST_GROUPS_FOR_AUTHID('%s')) AS T
 		UNION
 		SELECT 
-			ROLENAME AS AUTHID
+			ROLENAME AS AUTHID,
+			'%s' AS AUTHIDTYPE
 		FROM TABLE (SYSPROC.AUTH_LIST_ROLES_FOR_AUTHID('%s', '%s')) AS T
-	`, authId, authId, authType))
+	`, IBM_AUTHID_GROUP, authId, IBM_AUTHID_ROLE, authId, authType))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -151,11 +153,12 @@ func (c *EtlIBMConnectorUser) getParentGroupRoleAuthIds(authId string, authType
 	retAuths := []string{}
 	for rows.Next() {
 		auth := ""
-		err = rows.Scan(&auth)
+		authType := ""
+		err = rows.Scan(&auth, &authType)
 		if err != nil {
 			return nil, nil, err
 		}
-		retAuths = append(retAuths, auth)
+		retAuths = append(retAuths, ibmAuthKey(auth, authType))
 	}
 
 	return retAuths, src, nil
@@ -186,8 +189,7 @@ func (c *EtlIBMConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.Et
 
 	allRoles := map[string]*types.EtlRole{}
 	for _, gr := range ibmGroupsRoles {
-		role := gr.toEtlRole()
-		allRoles[role.Name] = role
+		allRoles[ibmAuthKey(gr.AuthId, gr.AuthIdType)] = gr.toEtlRole()
 	}
 
 	for _, u := range ibmUsers {
@@ -0,0 +1,111 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/tls"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net"
+	"time"
+)
+
+type MongoOptions struct {
+	// Uri is a standard connection string (mongodb:// or mongodb+srv://) with the credentials and any
+	// other driver options.
+	Uri string
+	// Uses TLS if set. This overrides any TLS options in the URI.
+	TlsConfig *tls.Config
+	// Limits how long connecting and each command may take. No limit if 0.
+	Timeout time.Duration
+	// Connects through another transport (e.g. an SSH tunnel) if set.
+	Dial func(network string, address string) (net.Conn, error)
+}
+
+type mongoDialer func(network string, address string) (net.Conn, error)
+
+func (d mongoDialer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	return d(network, address)
+}
+
+// MongoClient runs the commands with the official driver.
+type MongoClient struct {
+	client  *mongo.Client
+	timeout time.Duration
+}
+
+func (c *MongoClient) context() (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(context.Background(), c.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func DialMongo(opts MongoOptions) (*MongoClient, error) {
+	clientOpts := options.Client().ApplyURI(opts.Uri)
+	if opts.TlsConfig != nil {
+		clientOpts.SetTLSConfig(opts.TlsConfig)
+	}
+
+	if opts.Timeout > 0 {
+		clientOpts.SetConnectTimeout(opts.Timeout)
+		clientOpts.SetServerSelectionTimeout(opts.Timeout)
+	}
+
+	if opts.Dial != nil {
+		clientOpts.SetDialer(mongoDialer(opts.Dial))
+	}
+
+	client := &MongoClient{
+		timeout: opts.Timeout,
+	}
+
+	ctx, cancel := client.context()
+	defer cancel()
+
+	var err error
+	client.client, err = mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connect doesn't talk to the server so check the connection and credentials up front.
+	err = client.client.Ping(ctx, nil)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (c *MongoClient) Close() error {
+	ctx, cancel := c.context()
+	defer cancel()
+	return c.client.Disconnect(ctx)
+}
+
+// toBsonCommand converts the command into an ordered document. Nested commands are converted as well.
+func toBsonCommand(command MongoCommand) bson.D {
+	doc := bson.D{}
+	for _, f := range command {
+		value := f.Value
+		if nested, ok := value.(MongoCommand); ok {
+			value = toBsonCommand(nested)
+		}
+		doc = append(doc, bson.E{Key: f.Key, Value: value})
+	}
+	return doc
+}
+
+func (c *MongoClient) RunCommand(database string, command MongoCommand) ([]byte, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	reply, err := c.client.Database(database).RunCommand(ctx, toBsonCommand(command)).DecodeBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.MarshalExtJSON(reply, false, false)
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"encoding/json"
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+)
+
+// MongoCommandField is a single field of a command. Commands are ordered documents since the first
+// field is the name of the command.
+type MongoCommandField struct {
+	Key   string
+	Value interface{}
+}
+
+type MongoCommand []MongoCommandField
+
+func (c MongoCommand) Name() string {
+	if len(c) == 0 {
+		return ""
+	}
+	return c[0].Key
+}
+
+// MongoCommandRunner runs database commands. This keeps the connector independent of the driver.
+// MongoClient implements it with the official driver.
+type MongoCommandRunner interface {
+	// RunCommand runs the command against the database and returns the reply as relaxed extended JSON.
+	RunCommand(database string, command MongoCommand) ([]byte, error)
+}
+
+type mongoReply struct {
+	Ok     float64 `json:"ok"`
+	ErrMsg string  `json:"errmsg"`
+}
+
+// runCommand runs the command and decodes the reply into out.
+func runCommand(runner MongoCommandRunner, database string, command MongoCommand, out interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	params := map[string]interface{}{
+		"database": database,
+	}
+	for _, f := range command {
+		params[f.Key] = f.Value
+	}
+
+	raw, err := runner.RunCommand(database, command)
+	if err != nil {
+		return nil, err
+	}
+
+	source.AddCommand(&connectors.EtlCommandInfo{
+		Command:    command.Name(),
+		Parameters: params,
+		RawData:    string(raw),
+	})
+
+	reply := mongoReply{}
+	err = json.Unmarshal(raw, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Ok != 1 {
+		return nil, errors.New("MongoDB command " + command.Name() + " failed: " + reply.ErrMsg)
+	}
+
+	err = json.Unmarshal(raw, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
@@ -0,0 +1,27 @@
+package mongodb
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+)
+
+type EtlMongodbConnector struct {
+	runner MongoCommandRunner
+	users  *EtlMongodbConnectorUser
+}
+
+func (c *EtlMongodbConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateMongodbConnector(runner MongoCommandRunner) (*EtlMongodbConnector, error) {
+	var err error
+	ret := EtlMongodbConnector{
+		runner: runner,
+	}
+	ret.users, err = createMongodbConnectorUser(runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,264 @@
+package mongodb
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+	"strings"
+)
+
+// Users, and the roles that are granted to them, are stored in the admin database.
+const mongoAdminDatabase = "admin"
+
+const MongoClusterResource = "CLUSTER"
+const MongoAnyResource = "ANY_RESOURCE"
+
+const (
+	MongoAttributeDatabase   = "database"
+	MongoAttributeMechanisms = "mechanisms"
+)
+
+type EtlMongodbConnectorUser struct {
+	runner MongoCommandRunner
+}
+
+func createMongodbConnectorUser(runner MongoCommandRunner) (*EtlMongodbConnectorUser, error) {
+	return &EtlMongodbConnectorUser{
+		runner: runner,
+	}, nil
+}
+
+type mongoRoleRef struct {
+	Role string `json:"role"`
+	Db   string `json:"db"`
+}
+
+// Users and roles are identified by the database they're defined in and their name.
+func (r mongoRoleRef) id() string {
+	return r.Db + "." + r.Role
+}
+
+type mongoResource struct {
+	Db          string `json:"db"`
+	Collection  string `json:"collection"`
+	Cluster     bool   `json:"cluster"`
+	AnyResource bool   `json:"anyResource"`
+}
+
+func (r mongoResource) object() string {
+	if r.AnyResource {
+		return MongoAnyResource
+	}
+
+	if r.Cluster {
+		return MongoClusterResource
+	}
+
+	// An empty database or collection matches every database or (non-system) collection.
+	db := r.Db
+	if db == "" {
+		db = "*"
+	}
+
+	collection := r.Collection
+	if collection == "" {
+		collection = "*"
+	}
+
+	return "COLLECTION::" + db + "." + collection
+}
+
+type mongoPrivilege struct {
+	Resource mongoResource `json:"resource"`
+	Actions  []string      `json:"actions"`
+}
+
+type mongoRole struct {
+	Role       string           `json:"role"`
+	Db         string           `json:"db"`
+	Privileges []mongoPrivilege `json:"privileges"`
+}
+
+func (r mongoRole) toEtlRole() *types.EtlRole {
+	role := &types.EtlRole{
+		Name:        mongoRoleRef{Role: r.Role, Db: r.Db}.id(),
+		Permissions: map[string][]string{},
+	}
+
+	// Only the privileges granted to the role itself. Privileges of inherited roles are listed with those roles.
+	for _, p := range r.Privileges {
+		object := p.Resource.object()
+		role.Permissions[object] = append(role.Permissions[object], p.Actions...)
+	}
+	return role
+}
+
+type mongoUser struct {
+	Id             string         `json:"_id"`
+	User           string         `json:"user"`
+	Db             string         `json:"db"`
+	Roles          []mongoRoleRef `json:"roles"`
+	InheritedRoles []mongoRoleRef `json:"inheritedRoles"`
+	Mechanisms     []string       `json:"mechanisms"`
+}
+
+// allRoles returns the roles granted to the user directly or through other roles.
+func (u mongoUser) allRoles() []mongoRoleRef {
+	if u.InheritedRoles != nil {
+		return u.InheritedRoles
+	}
+	return u.Roles
+}
+
+func (u mongoUser) ref() map[string]string {
+	return map[string]string{
+		"user": u.User,
+		"db":   u.Db,
+	}
+}
+
+// listUsers lists the users defined in every database.
+func (c *EtlMongodbConnectorUser) listUsers() ([]mongoUser, *connectors.EtlSourceInfo, error) {
+	reply := struct {
+		Users []mongoUser `json:"users"`
+	}{}
+
+	source, err := runCommand(c.runner, mongoAdminDatabase, MongoCommand{
+		{Key: "usersInfo", Value: map[string]interface{}{"forAllDBs": true}},
+	}, &reply)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reply.Users, source, nil
+}
+
+// listUserPrivileges lists the users along with all the roles they inherit. The privileges can only be shown
+// when the users are listed explicitly and the command needs to run against the database they're defined in.
+func (c *EtlMongodbConnectorUser) listUserPrivileges(db string, users []mongoUser) ([]mongoUser, *connectors.EtlSourceInfo, error) {
+	refs := []map[string]string{}
+	for _, u := range users {
+		refs = append(refs, u.ref())
+	}
+
+	reply := struct {
+		Users []mongoUser `json:"users"`
+	}{}
+
+	source, err := runCommand(c.runner, db, MongoCommand{
+		{Key: "usersInfo", Value: refs},
+		{Key: "showPrivileges", Value: true},
+	}, &reply)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reply.Users, source, nil
+}
+
+// listRoles lists the built-in and custom roles of the database with their privileges.
+func (c *EtlMongodbConnectorUser) listRoles(db string) ([]mongoRole, *connectors.EtlSourceInfo, error) {
+	reply := struct {
+		Roles []mongoRole `json:"roles"`
+	}{}
+
+	source, err := runCommand(c.runner, db, MongoCommand{
+		{Key: "rolesInfo", Value: 1},
+		{Key: "showPrivileges", Value: true},
+		{Key: "showBuiltinRoles", Value: true},
+	}, &reply)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reply.Roles, source, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Obtain users and what roles/privileges they have.
+// Users are granted roles (built-in or custom) which can inherit other roles. Every role a user has (directly
+// or through another role) is listed with the actions that the role grants on resources (collections, the
+// cluster, etc.). Users can't be granted privileges directly.
+func (c *EtlMongodbConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	users, source, err := c.listUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	usersByDb := map[string][]mongoUser{}
+	for _, u := range users {
+		usersByDb[u.Db] = append(usersByDb[u.Db], u)
+	}
+
+	dbsWithUsers := map[string]bool{}
+	for db := range usersByDb {
+		dbsWithUsers[db] = true
+	}
+
+	detailedUsers := []mongoUser{}
+	for _, db := range sortedKeys(dbsWithUsers) {
+		dbUsers, source, err := c.listUserPrivileges(db, usersByDb[db])
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+		detailedUsers = append(detailedUsers, dbUsers...)
+	}
+
+	// Roles are defined per database so get the roles of every database a user has a role in.
+	roleDbs := map[string]bool{}
+	for _, u := range detailedUsers {
+		for _, r := range u.allRoles() {
+			roleDbs[r.Db] = true
+		}
+	}
+
+	roles := map[string]*types.EtlRole{}
+	for _, db := range sortedKeys(roleDbs) {
+		dbRoles, source, err := c.listRoles(db)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+
+		for _, r := range dbRoles {
+			role := r.toEtlRole()
+			roles[role.Name] = role
+		}
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range detailedUsers {
+		user := &types.EtlUser{
+			Username: u.Id,
+			Roles:    map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				MongoAttributeDatabase:   u.Db,
+				MongoAttributeMechanisms: strings.Join(u.Mechanisms, ","),
+			},
+		}
+
+		for _, ref := range u.allRoles() {
+			role, ok := roles[ref.id()]
+			if !ok {
+				role = &types.EtlRole{
+					Name:        ref.id(),
+					Permissions: map[string][]string{},
+				}
+			}
+			user.Roles[role.Name] = role
+		}
+
+		retUsers = append(retUsers, user)
+	}
+
+	return retUsers, finalSource, nil
+}
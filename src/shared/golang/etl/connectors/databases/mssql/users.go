@@ -13,6 +13,12 @@ import (
 
 const GUEST_SID string = "AA"
 
+// Marker roles given to database users that don't map back to a server login.
+const (
+	CONTAINED_USER_ROLE string = "Contained Database User"
+	ORPHANED_USER_ROLE         = "Orphaned Database User"
+)
+
 const (
 	SERVER_PRINCIPAL_TABLE     string = "sys.server_principals"
 	SERVER_ROLE_MEMBER_TABLE          = "sys.server_role_members"
@@ -36,6 +42,15 @@ const (
 	DATABASE_PRINCIPAL_WINDOWS_GROUP                 = "G"
 )
 
+// Values of sys.database_principals.authentication_type.
+const (
+	AUTHENTICATION_TYPE_NONE     int32 = 0
+	AUTHENTICATION_TYPE_INSTANCE       = 1
+	AUTHENTICATION_TYPE_DATABASE       = 2
+	AUTHENTICATION_TYPE_WINDOWS        = 3
+	AUTHENTICATION_TYPE_EXTERNAL       = 4
+)
+
 const (
 	PERMISSION_DENY_STATE              string = "D"
 	PERMISSION_REVOKE_STATE                   = "R"
@@ -54,7 +69,23 @@ type mssqlPrincipal struct {
 	PrincipalId int32
 	Sid         []byte
 	CreateDate  time.Time
-	Permissions []mssqlPermission
+	// Only set for database principals.
+	AuthenticationType sql.NullInt32
+	Permissions        []mssqlPermission
+}
+
+// hasPermission checks whether the principal was directly granted the permission.
+func (p mssqlPrincipal) hasPermission(name string) bool {
+	for _, perm := range p.Permissions {
+		if !perm.PermissionName.Valid || !perm.State.Valid {
+			continue
+		}
+
+		if perm.PermissionName.String == name && (perm.State.String == PERMISSION_GRANT_STATE || perm.State.String == PERMISSION_GRANT_WITH_OPTION_STATE) {
+			return true
+		}
+	}
+	return false
 }
 
 func (p mssqlPrincipal) toEtlUser() *types.EtlUser {
@@ -97,6 +128,28 @@ type mssqlRoleMember struct {
 	MemberPrincipalId int32
 }
 
+type mssqlDatabase struct {
+	Name string
+}
+
+// Database users from every database are reported alongside each other so the user name needs to be tagged with the database.
+func databaseUsername(database string, name string) string {
+	return fmt.Sprintf("%s::%s", database, name)
+}
+
+// quoteName mimics QUOTENAME so that a database name can be used to qualify a table.
+func quoteName(name string) string {
+	return fmt.Sprintf("[%s]", strings.ReplaceAll(name, "]", "]]"))
+}
+
+// qualifyTable returns the table in the given database. An empty database refers to the database the connection is using.
+func qualifyTable(database string, table string) string {
+	if database == "" {
+		return table
+	}
+	return fmt.Sprintf("%s.%s", quoteName(database), table)
+}
+
 type EtlMssqlConnectorUser struct {
 	db *databases.DB
 }
@@ -107,17 +160,26 @@ func createMssqlConnectorUser(db *databases.DB) (*EtlMssqlConnectorUser, error)
 	}, nil
 }
 
-// Retrieves the server principals along with their corresponding granted permissions.
-func (c *EtlMssqlConnectorUser) getPrincipals(principalTable string, permissionTable string, types ...string) ([]mssqlPrincipal, *connectors.EtlSourceInfo, error) {
+// Retrieves the server or database principals along with their corresponding granted permissions. Database principals are
+// retrieved from the given database (or the current database if empty).
+func (c *EtlMssqlConnectorUser) getPrincipals(database string, principalTable string, permissionTable string, types ...string) ([]mssqlPrincipal, *connectors.EtlSourceInfo, error) {
 	source := connectors.CreateSourceInfo()
 	allPrincipals := map[int32]*mssqlPrincipal{}
 
+	// Only database principals have an authentication type.
+	authenticationType := "NULL"
+	if principalTable == DATABASE_PRINCIPAL_TABLE {
+		authenticationType = "prin.authentication_type"
+	}
+
+	// Object permissions reference the object (and thus its schema) while schema permissions reference the schema directly.
 	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
 		SELECT 
 			prin.name,
 			prin.principal_id,
 			prin.sid, 
 			prin.create_date,
+			%s AS authentication_type,
 			perm.permission_name,
 			perm.state,
 			CONCAT(
@@ -125,21 +187,31 @@ func (c *EtlMssqlConnectorUser) getPrincipals(principalTable string, permissionT
 				'::',
 				o.type_desc COLLATE DATABASE_DEFAULT,
 				'::',
-				s.name COLLATE DATABASE_DEFAULT,
+				COALESCE(s.name, ps.name) COLLATE DATABASE_DEFAULT,
 				'.',
 				o.name COLLATE DATABASE_DEFAULT
 			) AS object
 		FROM %s AS prin
 		LEFT JOIN %s AS perm
 			ON perm.grantee_principal_id = prin.principal_id
-		LEFT JOIN sys.objects AS o
-			ON perm.major_id = o.object_id
-		LEFT JOIN sys.schemas AS s
+		LEFT JOIN %s AS o
+			ON perm.class = 1 AND perm.major_id = o.object_id
+		LEFT JOIN %s AS s
 			ON s.schema_id = o.schema_id
+		LEFT JOIN %s AS ps
+			ON perm.class = 3 AND perm.major_id = ps.schema_id
 		WHERE prin.type IN (%s) AND prin.sid IS NOT NULL
-	`, principalTable, permissionTable, strings.Join(strings_utility.Map(types, func(s string) string {
-		return fmt.Sprintf("'%s'", s)
-	}), ",")))
+	`,
+		authenticationType,
+		qualifyTable(database, principalTable),
+		qualifyTable(database, permissionTable),
+		qualifyTable(database, "sys.objects"),
+		qualifyTable(database, "sys.schemas"),
+		qualifyTable(database, "sys.schemas"),
+		strings.Join(strings_utility.Map(types, func(s string) string {
+			return fmt.Sprintf("'%s'", s)
+		}), ","),
+	))
 
 	if err != nil {
 		return nil, nil, err
@@ -156,6 +228,7 @@ func (c *EtlMssqlConnectorUser) getPrincipals(principalTable string, permissionT
 			&prin.PrincipalId,
 			&prin.Sid,
 			&prin.CreateDate,
+			&prin.AuthenticationType,
 			&perm.PermissionName,
 			&perm.State,
 			&perm.Object,
@@ -180,11 +253,11 @@ func (c *EtlMssqlConnectorUser) getPrincipals(principalTable string, permissionT
 	return principals, source, nil
 }
 
-func (c *EtlMssqlConnectorUser) getRoleMembers(table string) ([]mssqlRoleMember, *connectors.EtlSourceInfo, error) {
+func (c *EtlMssqlConnectorUser) getRoleMembers(database string, table string) ([]mssqlRoleMember, *connectors.EtlSourceInfo, error) {
 	source := connectors.CreateSourceInfo()
 	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
 		SELECT role_principal_id, member_principal_id FROM %s
-	`, table))
+	`, qualifyTable(database, table)))
 
 	if err != nil {
 		return nil, nil, err
@@ -240,15 +313,80 @@ func getEtlUsersAndRolesFromMssqlPrincipalsAndRoles(logins []mssqlPrincipal, rol
 	return sidToUser, nil
 }
 
+// Retrieves all the online databases on the instance that the connection is able to access.
+func (c *EtlMssqlConnectorUser) getDatabases() ([]mssqlDatabase, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(`
+		SELECT name
+		FROM sys.databases
+		WHERE state_desc = 'ONLINE' AND HAS_DBACCESS(name) = 1
+		ORDER BY name
+	`)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	source.AddCommand(cmd)
+
+	dbs := []mssqlDatabase{}
+	for rows.Next() {
+		db := mssqlDatabase{}
+		err = rows.Scan(&db.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, source, nil
+}
+
+// Retrieves the users of the database along with their database roles. The returned users are keyed by SID and are
+// tagged with the name of the database.
+func (c *EtlMssqlConnectorUser) getDatabaseUsers(database string) ([]mssqlPrincipal, map[string]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	databaseLogins, src, err := c.getPrincipals(database, DATABASE_PRINCIPAL_TABLE, DATABASE_PERMISSIONS_TABLE, DATABASE_PRINCIPAL_EXTERNAL_USER_FROM_AD, DATABASE_PRINCIPAL_SQL_USER, DATABASE_PRINCIPAL_WINDOWS_USER, DATABASE_PRINCIPAL_WINDOWS_GROUP, DATABASE_PRINCIPAL_EXTERNAL_GROUP_FROM_AD)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	databaseRoles, roleSrc, err := c.getPrincipals(database, DATABASE_PRINCIPAL_TABLE, DATABASE_PERMISSIONS_TABLE, DATABASE_PRINCIPAL_DATABASE_ROLE, DATABASE_PRINCIPAL_APPLICATION_ROLE)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	finalSource.MergeWith(roleSrc)
+
+	members, memberSrc, err := c.getRoleMembers(database, DATABASE_ROLE_MEMBER_TABLE)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	finalSource.MergeWith(memberSrc)
+
+	etlUsers, err := getEtlUsersAndRolesFromMssqlPrincipalsAndRoles(databaseLogins, databaseRoles, members)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, u := range etlUsers {
+		u.Username = databaseUsername(database, u.Username)
+	}
+
+	return databaseLogins, etlUsers, finalSource, nil
+}
+
 func (c *EtlMssqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
 	// Step 1: Get all Server Logins (all the users who can login to the database) -> map each to an EtlUser (with a Self role)
 	// Step 2: Get all Server Roles -> map each to an EtlRole
 	// Step 3: Get all Server Permissions -> add permissions to each role as necessary.
 	// Step 4: Get mapping from server roles to server logins
-	// Step 5: Get all Database Users (along with their mapping to Server Logins) -> store as a nested EtlUser fro the server login
-	// Step 6: Get all Database Roles
-	// Step 7: Get all Database Permissions
-	// Step 8: Get mapping from database roles to database users
+	// Step 5: Get all the databases on the instance. For each database:
+	//	Step 5a: Get all Database Users (along with their mapping to Server Logins) -> store as a nested EtlUser fro the server login
+	//	Step 5b: Get all Database Roles
+	//	Step 5c: Get all Database Permissions
+	//	Step 5d: Get mapping from database roles to database users
+	//	Step 5e: Report database users that don't map to a server login (contained and orphaned users) as their own users.
 
 	finalSource := connectors.CreateSourceInfo()
 
@@ -257,19 +395,19 @@ func (c *EtlMssqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.
 	sidToUser := map[string]*types.EtlUser{}
 
 	{
-		serverLogins, src, err := c.getPrincipals(SERVER_PRINCIPAL_TABLE, SERVER_PERMISSIONS_TABLE, SERVER_PRINCIPAL_SQL_LOGIN, SERVER_PRINCIPAL_WINDOWS_LOGIN, SERVER_PRINCIPAL_WINDOWS_GROUP)
+		serverLogins, src, err := c.getPrincipals("", SERVER_PRINCIPAL_TABLE, SERVER_PERMISSIONS_TABLE, SERVER_PRINCIPAL_SQL_LOGIN, SERVER_PRINCIPAL_WINDOWS_LOGIN, SERVER_PRINCIPAL_WINDOWS_GROUP)
 		if err != nil {
 			return nil, nil, err
 		}
 		finalSource.MergeWith(src)
 
-		serverRoles, roleSrc, err := c.getPrincipals(SERVER_PRINCIPAL_TABLE, SERVER_PERMISSIONS_TABLE, SERVER_PRINCIPAL_SERVER_ROLE)
+		serverRoles, roleSrc, err := c.getPrincipals("", SERVER_PRINCIPAL_TABLE, SERVER_PERMISSIONS_TABLE, SERVER_PRINCIPAL_SERVER_ROLE)
 		if err != nil {
 			return nil, nil, err
 		}
 		finalSource.MergeWith(roleSrc)
 
-		members, memberSrc, err := c.getRoleMembers(SERVER_ROLE_MEMBER_TABLE)
+		members, memberSrc, err := c.getRoleMembers("", SERVER_ROLE_MEMBER_TABLE)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -285,40 +423,57 @@ func (c *EtlMssqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.
 		}
 	}
 
-	{
-		databaseLogins, src, err := c.getPrincipals(DATABASE_PRINCIPAL_TABLE, DATABASE_PERMISSIONS_TABLE, DATABASE_PRINCIPAL_EXTERNAL_USER_FROM_AD, DATABASE_PRINCIPAL_SQL_USER, DATABASE_PRINCIPAL_WINDOWS_USER, DATABASE_PRINCIPAL_WINDOWS_GROUP, DATABASE_PRINCIPAL_EXTERNAL_GROUP_FROM_AD)
-		if err != nil {
-			return nil, nil, err
-		}
-		finalSource.MergeWith(src)
+	// Users in databases that aren't tied to any server login.
+	unmappedUsers := []*types.EtlUser{}
 
-		databaseRoles, roleSrc, err := c.getPrincipals(DATABASE_PRINCIPAL_TABLE, DATABASE_PERMISSIONS_TABLE, DATABASE_PRINCIPAL_DATABASE_ROLE, DATABASE_PRINCIPAL_APPLICATION_ROLE)
-		if err != nil {
-			return nil, nil, err
-		}
-		finalSource.MergeWith(roleSrc)
+	dbs, dbSrc, err := c.getDatabases()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(dbSrc)
 
-		members, memberSrc, err := c.getRoleMembers(DATABASE_ROLE_MEMBER_TABLE)
+	for _, db := range dbs {
+		databaseLogins, etlUsers, src, err := c.getDatabaseUsers(db.Name)
 		if err != nil {
 			return nil, nil, err
 		}
-		finalSource.MergeWith(memberSrc)
+		finalSource.MergeWith(src)
 
-		etlUsers, err := getEtlUsersAndRolesFromMssqlPrincipalsAndRoles(databaseLogins, databaseRoles, members)
-		if err != nil {
-			return nil, nil, err
-		}
+		for _, p := range databaseLogins {
+			k := SidToString(p.Sid)
+			v := etlUsers[k]
 
-		for k, v := range etlUsers {
 			parentUser, ok := sidToUser[k]
 			if ok {
 				parentUser.NestedUsers[v.Username] = v
 			} else if k == GUEST_SID {
-				// Guest user can  and should be given to all logins.
-				for _, p := range sidToUser {
-					p.NestedUsers[v.Username] = v
+				// Guest user can  and should be given to all logins as long as it's enabled in this database.
+				if !p.hasPermission("CONNECT") {
+					continue
+				}
+
+				for _, login := range sidToUser {
+					login.NestedUsers[v.Username] = v
+				}
+			} else if !p.AuthenticationType.Valid {
+				continue
+			} else if p.AuthenticationType.Int32 == AUTHENTICATION_TYPE_DATABASE || p.AuthenticationType.Int32 == AUTHENTICATION_TYPE_EXTERNAL {
+				// Contained database users authenticate at the database so they won't have a server login.
+				v.Roles[CONTAINED_USER_ROLE] = &types.EtlRole{
+					Name:        CONTAINED_USER_ROLE,
+					Permissions: types.PermissionMap{},
+				}
+				unmappedUsers = append(unmappedUsers, v)
+			} else if p.AuthenticationType.Int32 == AUTHENTICATION_TYPE_INSTANCE {
+				// Users that were mapped to a server login that no longer exists.
+				v.Roles[ORPHANED_USER_ROLE] = &types.EtlRole{
+					Name:        ORPHANED_USER_ROLE,
+					Permissions: types.PermissionMap{},
 				}
+				unmappedUsers = append(unmappedUsers, v)
 			} else {
+				// Users created WITHOUT LOGIN (AUTHENTICATION_TYPE_NONE) can't connect and Windows users without a login
+				// connect through a Windows group login instead.
 				continue
 			}
 		}
@@ -328,6 +483,7 @@ func (c *EtlMssqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.
 	for _, u := range sidToUser {
 		retUsers = append(retUsers, u)
 	}
+	retUsers = append(retUsers, unmappedUsers...)
 
 	return retUsers, finalSource, nil
 }
@@ -0,0 +1,284 @@
+package account
+
+import (
+	"database/sql"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys used in EtlUser.Attributes.
+const (
+	AttributeHost                = "host"
+	AttributeAccountLocked       = "account_locked"
+	AttributePasswordExpired     = "password_expired"
+	AttributePasswordLastChanged = "password_last_changed"
+	AttributePasswordLifetime    = "password_lifetime"
+	AttributeAuthPlugin          = "plugin"
+)
+
+// Codes used in EtlFinding.
+const (
+	FindingAnonymousUser = "AnonymousUser"
+	FindingWildcardHost  = "WildcardHost"
+	FindingEmptyPassword = "EmptyPassword"
+)
+
+// Authentication plugins that check a password stored in mysql.user. Other plugins (e.g. auth_socket)
+// don't use the stored password so an empty one isn't a problem.
+var passwordPlugins = map[string]bool{
+	"":                      true,
+	"mysql_native_password": true,
+	"mysql_old_password":    true,
+	"sha256_password":       true,
+	"caching_sha2_password": true,
+}
+
+type MysqlAccount struct {
+	Host                string
+	User                string
+	IsRole              bool
+	AccountLocked       *bool
+	PasswordExpired     *bool
+	PasswordLastChanged *time.Time
+	// Number of days a password is valid for. Nil if the account uses the server's default_password_lifetime.
+	PasswordLifetime *int64
+	Plugin           string
+	EmptyPassword    bool
+}
+
+func (a MysqlAccount) hasWildcardHost() bool {
+	return a.Host == "" || strings.ContainsAny(a.Host, "%_")
+}
+
+// canLogin is false for roles and locked accounts since nobody can authenticate as them directly.
+func (a MysqlAccount) canLogin() bool {
+	return !a.IsRole && (a.AccountLocked == nil || !*a.AccountLocked)
+}
+
+func (a MysqlAccount) Attributes() map[string]string {
+	ret := map[string]string{
+		AttributeHost:       a.Host,
+		AttributeAuthPlugin: a.Plugin,
+	}
+
+	if a.AccountLocked != nil {
+		ret[AttributeAccountLocked] = strconv.FormatBool(*a.AccountLocked)
+	}
+
+	if a.PasswordExpired != nil {
+		ret[AttributePasswordExpired] = strconv.FormatBool(*a.PasswordExpired)
+	}
+
+	if a.PasswordLastChanged != nil {
+		ret[AttributePasswordLastChanged] = a.PasswordLastChanged.UTC().Format(time.RFC3339)
+	}
+
+	if a.PasswordLifetime != nil {
+		ret[AttributePasswordLifetime] = strconv.FormatInt(*a.PasswordLifetime, 10)
+	}
+
+	return ret
+}
+
+func (a MysqlAccount) Findings() []*types.EtlFinding {
+	ret := []*types.EtlFinding{}
+	if !a.canLogin() {
+		return ret
+	}
+
+	if a.User == "" {
+		ret = append(ret, &types.EtlFinding{
+			Code:        FindingAnonymousUser,
+			Description: fmt.Sprintf("Anyone connecting from '%s' can log in without a username.", a.Host),
+		})
+	}
+
+	if a.hasWildcardHost() {
+		ret = append(ret, &types.EtlFinding{
+			Code:        FindingWildcardHost,
+			Description: fmt.Sprintf("Account can be used to connect from any host matching '%s'.", a.Host),
+		})
+	}
+
+	if a.EmptyPassword && passwordPlugins[a.Plugin] {
+		ret = append(ret, &types.EtlFinding{
+			Code:        FindingEmptyPassword,
+			Description: "Account does not have a password.",
+		})
+	}
+
+	return ret
+}
+
+// Apply adds the account's attributes and findings to the user.
+func (a MysqlAccount) Apply(user *types.EtlUser) {
+	user.Attributes = a.Attributes()
+	user.Findings = a.Findings()
+}
+
+func boolColumn(columns map[string]bool, column string) string {
+	if !columns[column] {
+		return "NULL"
+	}
+	return fmt.Sprintf("u.%s = 'Y'", column)
+}
+
+// ObtainAccounts returns the lifecycle and authentication attributes of every account in mysql.user. The
+// columns in mysql.user differ between MySQL 5.6, 5.7, 8 and MariaDB so the query is built from
+// the columns that actually exist. MariaDB 10.4+ only exposes some of the attributes through the JSON
+// stored in mysql.global_priv. The password hashes themselves are never selected.
+func ObtainAccounts(db *databases.DB) ([]*MysqlAccount, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	userColumns := map[string]bool{}
+	hasGlobalPriv := false
+	{
+		rows, cmd, err := db.LoggedQuery(`
+			SELECT LOWER(TABLE_NAME) AS tbl, LOWER(COLUMN_NAME) AS col
+			FROM information_schema.COLUMNS
+			WHERE TABLE_SCHEMA = 'mysql' AND TABLE_NAME IN ('user', 'global_priv')
+		`)
+		if err != nil {
+			return nil, nil, err
+		}
+		source.AddCommand(cmd)
+		defer rows.Close()
+
+		for rows.Next() {
+			tbl := ""
+			col := ""
+			err = rows.Scan(&tbl, &col)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if tbl == "global_priv" {
+				hasGlobalPriv = true
+			} else {
+				userColumns[col] = true
+			}
+		}
+	}
+
+	isRole := "FALSE"
+	if userColumns["is_role"] {
+		isRole = "u.is_role = 'Y'"
+	}
+
+	plugin := "''"
+	if userColumns["plugin"] {
+		plugin = "COALESCE(u.plugin, '')"
+	}
+
+	// MySQL 5.6 and MariaDB store the hash in Password, MySQL 5.7+ in authentication_string, and
+	// MariaDB may use either depending on the plugin.
+	emptyPasswordChecks := []string{}
+	for _, col := range []string{"password", "authentication_string"} {
+		if userColumns[col] {
+			emptyPasswordChecks = append(emptyPasswordChecks, fmt.Sprintf("COALESCE(u.%s, '') = ''", col))
+		}
+	}
+
+	emptyPassword := "FALSE"
+	if len(emptyPasswordChecks) > 0 {
+		emptyPassword = strings.Join(emptyPasswordChecks, " AND ")
+	}
+
+	accountLocked := boolColumn(userColumns, "account_locked")
+	passwordExpired := boolColumn(userColumns, "password_expired")
+
+	passwordLastChanged := "NULL"
+	if userColumns["password_last_changed"] {
+		passwordLastChanged = "UNIX_TIMESTAMP(u.password_last_changed)"
+	}
+
+	passwordLifetime := "NULL"
+	if userColumns["password_lifetime"] {
+		passwordLifetime = "u.password_lifetime"
+	}
+
+	join := ""
+	if hasGlobalPriv {
+		join = "LEFT JOIN mysql.global_priv AS gp ON gp.Host = u.Host AND gp.User = u.User"
+		accountLocked = "COALESCE(JSON_VALUE(gp.Priv, '$.account_locked'), 'false') = 'true'"
+		passwordLastChanged = "JSON_VALUE(gp.Priv, '$.password_last_changed')"
+		passwordLifetime = "JSON_VALUE(gp.Priv, '$.password_lifetime')"
+	}
+
+	rows, cmd, err := db.LoggedQuery(fmt.Sprintf(`
+		SELECT
+			u.Host AS host,
+			u.User AS user,
+			%s AS is_role,
+			%s AS account_locked,
+			%s AS password_expired,
+			%s AS password_last_changed,
+			%s AS password_lifetime,
+			%s AS plugin,
+			%s AS empty_password
+		FROM mysql.user AS u
+		%s
+	`, isRole, accountLocked, passwordExpired, passwordLastChanged, passwordLifetime, plugin, emptyPassword, join))
+	if err != nil {
+		return nil, nil, err
+	}
+	source.AddCommand(cmd)
+	defer rows.Close()
+
+	accounts := []*MysqlAccount{}
+	for rows.Next() {
+		type Result struct {
+			Host                string        `db:"host"`
+			User                string        `db:"user"`
+			IsRole              bool          `db:"is_role"`
+			AccountLocked       sql.NullBool  `db:"account_locked"`
+			PasswordExpired     sql.NullBool  `db:"password_expired"`
+			PasswordLastChanged sql.NullInt64 `db:"password_last_changed"`
+			PasswordLifetime    sql.NullInt64 `db:"password_lifetime"`
+			Plugin              string        `db:"plugin"`
+			EmptyPassword       bool          `db:"empty_password"`
+		}
+
+		result := Result{}
+		err = rows.StructScan(&result)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		account := MysqlAccount{
+			Host:          result.Host,
+			User:          result.User,
+			IsRole:        result.IsRole,
+			Plugin:        result.Plugin,
+			EmptyPassword: result.EmptyPassword,
+		}
+
+		if result.AccountLocked.Valid {
+			account.AccountLocked = &result.AccountLocked.Bool
+		}
+
+		if result.PasswordExpired.Valid {
+			account.PasswordExpired = &result.PasswordExpired.Bool
+		}
+
+		// MariaDB marks expired passwords with a last changed time of 0.
+		if result.PasswordLastChanged.Valid && result.PasswordLastChanged.Int64 > 0 {
+			lastChanged := time.Unix(result.PasswordLastChanged.Int64, 0).UTC()
+			account.PasswordLastChanged = &lastChanged
+		}
+
+		// MariaDB uses -1 to mean the server default.
+		if result.PasswordLifetime.Valid && result.PasswordLifetime.Int64 >= 0 {
+			account.PasswordLifetime = &result.PasswordLifetime.Int64
+		}
+
+		accounts = append(accounts, &account)
+	}
+
+	return accounts, source, nil
+}
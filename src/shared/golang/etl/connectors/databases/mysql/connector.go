@@ -1,13 +1,13 @@
 package mysql
 
 import (
-	"errors"
+	"fmt"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mariadb"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/v5"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/v8"
-	"strconv"
-	"strings"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
 )
 
 type EtlMysqlInterfaceFactory interface {
@@ -16,72 +16,67 @@ type EtlMysqlInterfaceFactory interface {
 
 type EtlMysqlConnector struct {
 	db    databases.SqlxLike
-	users connectors.EtlConnectorUserInterface
+	users *EtlMysqlConnectorUser
 }
 
-type MysqlVersion struct {
-	MajorVersion int
-	MinorVersion int
-	AuxVersion   string
+// EtlMysqlConnectorUser wraps the version specific user interface so that the detected version is
+// recorded along with the rest of the source information.
+type EtlMysqlConnectorUser struct {
+	version MysqlVersion
+	users   connectors.EtlConnectorUserInterface
 }
 
-func (c *EtlMysqlConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
-	return c.users, nil
-}
-
-func ObtainMysqlVersion(db databases.SqlxLike) (*MysqlVersion, error) {
-	rows, err := db.Queryx("SELECT VERSION()")
+func (c *EtlMysqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	users, source, err := c.users.GetUserListing()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer rows.Close()
-	rows.Next()
 
-	version := ""
-	err = rows.Scan(&version)
-	if err != nil {
-		return nil, err
-	}
+	finalSource := connectors.CreateSourceInfo()
+	finalSource.AddCommand(c.version.toCommandInfo())
+	finalSource.MergeWith(source)
+	return users, finalSource, nil
+}
 
-	splitVersion := strings.Split(version, ".")
+func (c *EtlMysqlConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
 
-	majorVersion, err := strconv.Atoi(splitVersion[0])
-	if err != nil {
-		return nil, err
+// Aurora and Percona Server are compatible with the MySQL version they report so they're handled
+// the same as MySQL. MariaDB has diverged enough that it needs its own connector.
+func getVersionFactory(version MysqlVersion) (EtlMysqlInterfaceFactory, error) {
+	if version.Flavor == MysqlFlavorMariadb {
+		return &mariadb.InterfaceFactory{}, nil
 	}
 
-	minorVersion, err := strconv.Atoi(splitVersion[1])
-	if err != nil {
-		return nil, err
+	if version.MajorVersion == 8 {
+		return &v8.InterfaceFactory{}, nil
+	} else if version.MajorVersion == 5 {
+		return &v5.InterfaceFactory{}, nil
 	}
 
-	return &MysqlVersion{
-		MajorVersion: majorVersion,
-		MinorVersion: minorVersion,
-		AuxVersion:   splitVersion[2],
-	}, nil
+	flavor := version.Flavor
+	if flavor == "" {
+		flavor = MysqlFlavorMysql
+	}
+	return nil, fmt.Errorf("Unsupported %s version: %d.%d.", flavor, version.MajorVersion, version.MinorVersion)
 }
 
 func CreateMysqlConnector(db databases.SqlxLike, version MysqlVersion) (*EtlMysqlConnector, error) {
 	var err error
 	ret := EtlMysqlConnector{
 		db: db,
+		users: &EtlMysqlConnectorUser{
+			version: version,
+		},
 	}
 
-	var versionFactory EtlMysqlInterfaceFactory
+	versionFactory, err := getVersionFactory(version)
 	if err != nil {
 		return nil, err
 	}
 
-	if version.MajorVersion == 8 {
-		versionFactory = &v8.InterfaceFactory{}
-	} else if version.MajorVersion == 5 {
-		versionFactory = &v5.InterfaceFactory{}
-	} else {
-		return nil, errors.New("Unsupported MySQL version.")
-	}
-
-	ret.users, err = versionFactory.CreateUserInterface(&databases.DB{
+	ret.users.users, err = versionFactory.CreateUserInterface(&databases.DB{
 		SqlxLike: db,
 	})
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/account"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
 )
 
@@ -666,6 +667,22 @@ func (c *EtlMysqlV8ConnectorUser) GetUserListing() ([]*types.EtlUser, *connector
 		}
 	}
 
+	// Lastly attach the lifecycle and authentication attributes of each account.
+	{
+		accounts, accountSource, err := account.ObtainAccounts(c.db)
+		if err != nil {
+			return nil, nil, err
+		}
+		source.MergeWith(accountSource)
+
+		for _, a := range accounts {
+			user, ok := allUsers[fmt.Sprintf("%s@%s", a.User, a.Host)]
+			if ok {
+				a.Apply(user)
+			}
+		}
+	}
+
 	retUsers := []*types.EtlUser{}
 	for _, v := range allUsers {
 		retUsers = append(retUsers, v)
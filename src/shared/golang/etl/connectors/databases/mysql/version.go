@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"encoding/json"
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type MysqlFlavor string
+
+const (
+	MysqlFlavorMysql   MysqlFlavor = "MySQL"
+	MysqlFlavorMariadb MysqlFlavor = "MariaDB"
+	MysqlFlavorPercona MysqlFlavor = "Percona Server"
+	MysqlFlavorAurora  MysqlFlavor = "Aurora MySQL"
+)
+
+const mysqlVersionQuery = "SELECT VERSION() AS version, @@version_comment AS comment"
+
+// Aurora MySQL 3 reports a plain MySQL 8 version so the Aurora specific variable needs to be checked as well.
+// SHOW VARIABLES returns no rows (rather than an error) when the variable doesn't exist.
+const auroraVersionQuery = "SHOW VARIABLES LIKE 'aurora_version'"
+
+// MariaDB prefixes the version with this to stay compatible with old MySQL replication clients.
+const mariadbReplicationVersionPrefix = "5.5.5-"
+
+var mysqlVersionRegex = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(.*))?$`)
+
+type MysqlVersion struct {
+	MajorVersion int
+	MinorVersion int
+	AuxVersion   string
+
+	Flavor MysqlFlavor
+	// The unparsed output of VERSION(), @@version_comment and @@aurora_version.
+	Raw           string
+	Comment       string
+	AuroraVersion string
+}
+
+// ParseMysqlVersion determines the flavor and version of the server from VERSION(), @@version_comment and
+// @@aurora_version (empty if it doesn't exist). Examples of what each flavor reports:
+//
+//	MySQL: 5.6.49, 8.0.21
+//	MariaDB: 10.5.8-MariaDB-1:10.5.8+maria~focal, 5.5.5-10.3.27-MariaDB-log
+//	Aurora: 5.7.mysql_aurora.2.10.2, 5.6.10 (with aurora_version set)
+//	Percona: 8.0.22-13, 5.7.31-34-log (with "Percona Server" in the version comment)
+func ParseMysqlVersion(raw string, comment string, auroraVersion string) (*MysqlVersion, error) {
+	version := strings.TrimSpace(raw)
+	lowerVersion := strings.ToLower(version)
+	lowerComment := strings.ToLower(comment)
+
+	flavor := MysqlFlavorMysql
+	if strings.Contains(lowerVersion, "mariadb") || strings.Contains(lowerComment, "mariadb") {
+		flavor = MysqlFlavorMariadb
+		version = strings.TrimPrefix(version, mariadbReplicationVersionPrefix)
+	} else if strings.Contains(lowerVersion, "mysql_aurora") || auroraVersion != "" {
+		flavor = MysqlFlavorAurora
+	} else if strings.Contains(lowerVersion, "percona") || strings.Contains(lowerComment, "percona") {
+		flavor = MysqlFlavorPercona
+	}
+
+	matches := mysqlVersionRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return nil, errors.New("Unrecognized MySQL version: " + raw)
+	}
+
+	majorVersion, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return nil, err
+	}
+
+	minorVersion, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return &MysqlVersion{
+		MajorVersion:  majorVersion,
+		MinorVersion:  minorVersion,
+		AuxVersion:    matches[3],
+		Flavor:        flavor,
+		Raw:           raw,
+		Comment:       comment,
+		AuroraVersion: auroraVersion,
+	}, nil
+}
+
+func ObtainMysqlVersion(db databases.SqlxLike) (*MysqlVersion, error) {
+	raw := ""
+	comment := ""
+	{
+		rows, err := databases.Query(db, mysqlVersionQuery)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		if !rows.Next() {
+			return nil, errors.New("Failed to obtain MySQL version.")
+		}
+
+		var nullableComment *string
+		err = rows.Scan(&raw, &nullableComment)
+		if err != nil {
+			return nil, err
+		}
+
+		if nullableComment != nil {
+			comment = *nullableComment
+		}
+	}
+
+	auroraVersion := ""
+	{
+		rows, err := databases.Query(db, auroraVersionQuery)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		if rows.Next() {
+			name := ""
+			err = rows.Scan(&name, &auroraVersion)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return ParseMysqlVersion(raw, comment, auroraVersion)
+}
+
+// toCommandInfo records the detected version as evidence of which flavor of MySQL the users were obtained from.
+func (v MysqlVersion) toCommandInfo() *connectors.EtlCommandInfo {
+	rawData, _ := json.Marshal(map[string]string{
+		"version":         v.Raw,
+		"version_comment": v.Comment,
+		"aurora_version":  v.AuroraVersion,
+	})
+
+	return &connectors.EtlCommandInfo{
+		Command: mysqlVersionQuery,
+		Parameters: map[string]interface{}{
+			"flavor":       string(v.Flavor),
+			"majorVersion": v.MajorVersion,
+			"minorVersion": v.MinorVersion,
+			"auxVersion":   v.AuxVersion,
+		},
+		RawData: string(rawData),
+	}
+}
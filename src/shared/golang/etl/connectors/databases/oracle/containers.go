@@ -0,0 +1,136 @@
+package oracle
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"strconv"
+)
+
+const cdbRootConId int64 = 1
+const pdbSeedName = "PDB$SEED"
+
+// ORA-02003: invalid USERENV parameter. Databases older than 12c don't have the CON_ID and CON_NAME parameters.
+const oraInvalidUserenvParameter = 2003
+
+// isOracleError checks the ORA-xxxxx code of an error returned by the driver (godror's *OraErr).
+func isOracleError(err error, code int) bool {
+	var oraErr interface{ Code() int }
+	return errors.As(err, &oraErr) && oraErr.Code() == code
+}
+
+type oracleContainer struct {
+	Id   int64  `db:"CON_ID"`
+	Name string `db:"NAME"`
+}
+
+func (c oracleContainer) qualifyName(name string) string {
+	return fmt.Sprintf("%s::%s", c.Name, name)
+}
+
+// oracleViews picks between the CDB_* views, which contain the data of every open container when queried
+// from CDB$ROOT, and the DBA_* views, which only contain the data of the current container.
+type oracleViews struct {
+	cdb bool
+	// The CON_ID to tag the results from the DBA_* views with.
+	currentConId int64
+	// Whether the database is new enough (12c+) to have a COMMON column.
+	multitenant bool
+}
+
+func (v oracleViews) view(name string) string {
+	if v.cdb {
+		return "CDB_" + name
+	}
+	return "DBA_" + name
+}
+
+func (v oracleViews) conId(alias string) string {
+	if v.cdb {
+		return alias + ".CON_ID"
+	}
+	return strconv.FormatInt(v.currentConId, 10)
+}
+
+func (v oracleViews) common(alias string) string {
+	if v.multitenant {
+		return alias + ".COMMON"
+	}
+	return "'NO'"
+}
+
+// detectContainers determines which containers the user listing should cover. The first container returned
+// is the root (or the only container).
+func (c *EtlOracleConnectorUser) detectContainers() (oracleViews, []oracleContainer, *connectors.EtlSourceInfo, error) {
+	src := connectors.CreateSourceInfo()
+
+	current := oracleContainer{}
+	{
+		rows, cmd, err := c.db.LoggedQuery(`
+			SELECT
+				SYS_CONTEXT('USERENV', 'CON_ID') AS CON_ID,
+				SYS_CONTEXT('USERENV', 'CON_NAME') AS NAME
+			FROM DUAL
+		`)
+
+		// Databases older than 12c don't know about containers.
+		if isOracleError(err, oraInvalidUserenvParameter) {
+			return oracleViews{}, []oracleContainer{current}, src, nil
+		} else if err != nil {
+			return oracleViews{}, nil, nil, err
+		}
+		defer rows.Close()
+		src.AddCommand(cmd)
+
+		if rows.Next() {
+			conId := ""
+			err = rows.Scan(&conId, &current.Name)
+			if err != nil {
+				return oracleViews{}, nil, nil, err
+			}
+
+			current.Id, err = strconv.ParseInt(conId, 10, 64)
+			if err != nil {
+				return oracleViews{}, nil, nil, err
+			}
+		}
+	}
+
+	views := oracleViews{
+		cdb:          current.Id == cdbRootConId,
+		currentConId: current.Id,
+		multitenant:  true,
+	}
+
+	if !views.cdb {
+		return views, []oracleContainer{current}, src, nil
+	}
+
+	rows, cmd, err := c.db.LoggedQuery(`
+		SELECT CON_ID, NAME
+		FROM V$CONTAINERS
+		WHERE NAME != :1
+	`, pdbSeedName)
+	if err != nil {
+		return oracleViews{}, nil, nil, err
+	}
+	defer rows.Close()
+	src.AddCommand(cmd)
+
+	containers := []oracleContainer{}
+	for rows.Next() {
+		ct := oracleContainer{}
+		err = rows.Scan(&ct.Id, &ct.Name)
+		if err != nil {
+			return oracleViews{}, nil, nil, err
+		}
+		containers = append(containers, ct)
+	}
+
+	sortContainers(containers, cdbRootConId)
+	if len(containers) == 0 || containers[0].Id != cdbRootConId {
+		containers = append([]oracleContainer{current}, containers...)
+	}
+
+	return views, containers, src, nil
+}
@@ -0,0 +1,98 @@
+package oracle
+
+import (
+	"database/sql"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+)
+
+const proxyRoleName = "Proxy Authentication"
+
+const (
+	proxyConnectPermission      = "CONNECT THROUGH"
+	proxyAllRolesPermission     = "ACTIVATE ALL ROLES"
+	proxyActivateRolePermission = "ACTIVATE ROLE"
+)
+
+// Values of DBA_PROXIES.AUTHORIZATION_CONSTRAINT.
+const (
+	proxyMayActivateAllRoles = "PROXY MAY ACTIVATE ALL CLIENT ROLES"
+	proxyMayActivateRole     = "PROXY MAY ACTIVATE ROLE"
+	proxyMayNotActivateRole  = "PROXY MAY NOT ACTIVATE ROLE"
+)
+
+// oracleProxy is a user (PROXY) that is allowed to connect on behalf of another user (CLIENT).
+type oracleProxy struct {
+	ConId                   int64          `db:"CON_ID"`
+	Proxy                   string         `db:"PROXY"`
+	Client                  string         `db:"CLIENT"`
+	AuthorizationConstraint sql.NullString `db:"AUTHORIZATION_CONSTRAINT"`
+	Role                    sql.NullString `db:"ROLE"`
+}
+
+func proxyClientObject(client string) string {
+	return fmt.Sprintf("USER::%s", client)
+}
+
+// createProxyRoles returns a role for each proxy user that lists the clients it can connect as. If the
+// proxy is only allowed to use some of the client's roles, those roles are listed as well.
+func createProxyRoles(proxies []oracleProxy) map[string]*types.EtlRole {
+	roles := map[string]*types.EtlRole{}
+	for _, p := range proxies {
+		role, ok := roles[p.Proxy]
+		if !ok {
+			role = &types.EtlRole{
+				Name:        proxyRoleName,
+				Permissions: types.PermissionMap{},
+				Denied:      types.PermissionMap{},
+			}
+			roles[p.Proxy] = role
+		}
+
+		object := proxyClientObject(p.Client)
+		if _, ok := role.Permissions[object]; !ok {
+			role.Permissions[object] = []string{proxyConnectPermission}
+		}
+
+		switch p.AuthorizationConstraint.String {
+		case proxyMayActivateAllRoles:
+			role.Permissions[object] = append(role.Permissions[object], proxyAllRolesPermission)
+		case proxyMayActivateRole:
+			role.Permissions[object] = append(role.Permissions[object], fmt.Sprintf("%s %s", proxyActivateRolePermission, p.Role.String))
+		case proxyMayNotActivateRole:
+			role.Denied[object] = append(role.Denied[object], fmt.Sprintf("%s %s", proxyActivateRolePermission, p.Role.String))
+		}
+	}
+	return roles
+}
+
+func (c *EtlOracleConnectorUser) listProxies(v oracleViews) ([]oracleProxy, *connectors.EtlSourceInfo, error) {
+	src := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
+		SELECT
+			%s AS CON_ID,
+			p.PROXY,
+			p.CLIENT,
+			p.AUTHORIZATION_CONSTRAINT,
+			p.ROLE
+		FROM %s p
+	`, v.conId("p"), v.view("PROXIES")))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	src.AddCommand(cmd)
+
+	proxies := []oracleProxy{}
+	for rows.Next() {
+		proxy := oracleProxy{}
+		err = rows.Scan(&proxy.ConId, &proxy.Proxy, &proxy.Client, &proxy.AuthorizationConstraint, &proxy.Role)
+		if err != nil {
+			return nil, nil, err
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	return proxies, src, nil
+}
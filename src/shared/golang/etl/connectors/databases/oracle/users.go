@@ -6,28 +6,53 @@ import (
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
-const permissionCte = `
+// Keys used in EtlUser.Attributes. Password limits from the user's profile are stored using the
+// lowercase resource name (e.g. failed_login_attempts).
+const (
+	AttributeConId         = "con_id"
+	AttributeContainer     = "container"
+	AttributeCommon        = "common"
+	AttributeAccountStatus = "account_status"
+	AttributeProfile       = "profile"
+)
+
+const defaultProfile = "DEFAULT"
+const defaultProfileLimit = "DEFAULT"
+
+func permissionCte(v oracleViews) string {
+	return fmt.Sprintf(`
     SELECT
+        %s AS CON_ID,
         col.GRANTEE,
         'COLUMN::' || col.TABLE_NAME || '.' || col.COLUMN_NAME AS OBJECT,
         col.PRIVILEGE
-    FROM DBA_COL_PRIVS col
+    FROM %s col
     UNION
     SELECT
+        %s AS CON_ID,
         tab.GRANTEE,
         'TABLE::' || tab.TABLE_NAME AS OBJECT,
         tab.PRIVILEGE
-    FROM DBA_TAB_PRIVS tab
+    FROM %s tab
     UNION
     SELECT
+        %s AS CON_ID,
         sys.GRANTEE,
         'SYSTEM' AS OBJECT,
         sys.PRIVILEGE
-    FROM DBA_SYS_PRIVS sys
-`
+    FROM %s sys
+`,
+		v.conId("col"), v.view("COL_PRIVS"),
+		v.conId("tab"), v.view("TAB_PRIVS"),
+		v.conId("sys"), v.view("SYS_PRIVS"),
+	)
+}
 
 type oraclePrivilege struct {
 	Object    sql.NullString `db:"OBJECT"`
@@ -54,25 +79,40 @@ func (arr oraclePrivilegeArray) toPermissionMap() types.PermissionMap {
 }
 
 type oracleUser struct {
-	Username   string    `db:"USERNAME"`
-	Created    time.Time `db:"CREATED"`
-	Privileges oraclePrivilegeArray
+	ConId         int64          `db:"CON_ID"`
+	Username      string         `db:"USERNAME"`
+	Created       time.Time      `db:"CREATED"`
+	Profile       sql.NullString `db:"PROFILE"`
+	AccountStatus sql.NullString `db:"ACCOUNT_STATUS"`
+	Common        sql.NullString `db:"COMMON"`
+	Privileges    oraclePrivilegeArray
+}
+
+func (u oracleUser) isCommon() bool {
+	return u.Common.Valid && u.Common.String == "YES"
 }
 
-func (u oracleUser) toEtlUser() *types.EtlUser {
+func (u oracleUser) toEtlUser(username string) *types.EtlUser {
 	return &types.EtlUser{
-		Username:    u.Username,
+		Username:    username,
 		CreatedTime: &u.Created,
 		Roles: map[string]*types.EtlRole{
-			u.Username: &types.EtlRole{
-				Name:        u.Username,
+			username: &types.EtlRole{
+				Name:        username,
 				Permissions: u.Privileges.toPermissionMap(),
 			},
 		},
+		Attributes: map[string]string{
+			AttributeConId:         strconv.FormatInt(u.ConId, 10),
+			AttributeCommon:        strconv.FormatBool(u.isCommon()),
+			AttributeAccountStatus: u.AccountStatus.String,
+			AttributeProfile:       u.Profile.String,
+		},
 	}
 }
 
 type oracleRole struct {
+	ConId      int64  `db:"CON_ID"`
 	Role       string `db:"ROLE"`
 	Privileges oraclePrivilegeArray
 }
@@ -85,10 +125,18 @@ func (r oracleRole) toEtlRole() *types.EtlRole {
 }
 
 type oracleRolePriv struct {
+	ConId       int64  `db:"CON_ID"`
 	Grantee     string `db:"GRANTEE"`
 	GrantedRole string `db:"GRANTED_ROLE"`
 }
 
+type oracleProfileLimit struct {
+	ConId        int64  `db:"CON_ID"`
+	Profile      string `db:"PROFILE"`
+	ResourceName string `db:"RESOURCE_NAME"`
+	Limit        string `db:"LIMIT"`
+}
+
 type EtlOracleConnectorUser struct {
 	db *databases.DB
 }
@@ -99,104 +147,129 @@ func createOracleConnectorUser(db *databases.DB) (*EtlOracleConnectorUser, error
 	}, nil
 }
 
-func (c *EtlOracleConnectorUser) listDbaUsers() ([]oracleUser, *connectors.EtlSourceInfo, error) {
+func (c *EtlOracleConnectorUser) listDbaUsers(v oracleViews) ([]oracleUser, *connectors.EtlSourceInfo, error) {
 	src := connectors.CreateSourceInfo()
 	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
 		WITH perm AS (
 			%s
 		)
 		SELECT 
+			%s AS CON_ID,
 			u.USERNAME,
 			u.CREATED,
+			u.PROFILE,
+			u.ACCOUNT_STATUS,
+			%s AS COMMON,
 			perm.OBJECT,
 			perm.PRIVILEGE
-		FROM DBA_USERS u
+		FROM %s u
 		LEFT JOIN perm
 			ON perm.GRANTEE = u.USERNAME
-	`, permissionCte))
+				AND perm.CON_ID = %s
+	`, permissionCte(v), v.conId("u"), v.common("u"), v.view("USERS"), v.conId("u")))
 	if err != nil {
 		return nil, nil, err
 	}
 	defer rows.Close()
 	src.AddCommand(cmd)
 
-	allUsers := map[string]*oracleUser{}
+	type userKey struct {
+		conId    int64
+		username string
+	}
+
+	allUsers := map[userKey]*oracleUser{}
+	keys := []userKey{}
 	for rows.Next() {
 		user := oracleUser{}
 		priv := oraclePrivilege{}
 
-		err = rows.Scan(&user.Username, &user.Created, &priv.Object, &priv.Privilege)
+		err = rows.Scan(&user.ConId, &user.Username, &user.Created, &user.Profile, &user.AccountStatus, &user.Common, &priv.Object, &priv.Privilege)
 		if err != nil {
 			return nil, nil, err
 		}
-		mapUser, ok := allUsers[user.Username]
+
+		key := userKey{conId: user.ConId, username: user.Username}
+		mapUser, ok := allUsers[key]
 		if !ok {
 			mapUser = &user
+			keys = append(keys, key)
 		}
 		mapUser.Privileges = append(mapUser.Privileges, priv)
-		allUsers[user.Username] = mapUser
+		allUsers[key] = mapUser
 	}
 
 	retUsers := []oracleUser{}
-	for _, v := range allUsers {
-		retUsers = append(retUsers, *v)
+	for _, k := range keys {
+		retUsers = append(retUsers, *allUsers[k])
 	}
 	return retUsers, src, nil
 }
 
-func (c *EtlOracleConnectorUser) listDbaRoles() ([]oracleRole, *connectors.EtlSourceInfo, error) {
+func (c *EtlOracleConnectorUser) listDbaRoles(v oracleViews) ([]oracleRole, *connectors.EtlSourceInfo, error) {
 	src := connectors.CreateSourceInfo()
 	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
 		WITH perm AS (
 			%s
 		)
 		SELECT 
+			%s AS CON_ID,
 			r.ROLE,
 			perm.OBJECT,
 			perm.PRIVILEGE
-		FROM DBA_ROLES r
+		FROM %s r
 		LEFT JOIN perm
 			ON perm.GRANTEE = r.ROLE
-	`, permissionCte))
+				AND perm.CON_ID = %s
+	`, permissionCte(v), v.conId("r"), v.view("ROLES"), v.conId("r")))
 	if err != nil {
 		return nil, nil, err
 	}
 	defer rows.Close()
 	src.AddCommand(cmd)
 
-	allRoles := map[string]*oracleRole{}
+	type roleKey struct {
+		conId int64
+		role  string
+	}
+
+	allRoles := map[roleKey]*oracleRole{}
+	keys := []roleKey{}
 	for rows.Next() {
 		role := oracleRole{}
 		priv := oraclePrivilege{}
 
-		err = rows.Scan(&role.Role, &priv.Object, &priv.Privilege)
+		err = rows.Scan(&role.ConId, &role.Role, &priv.Object, &priv.Privilege)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		mapRole, ok := allRoles[role.Role]
+		key := roleKey{conId: role.ConId, role: role.Role}
+		mapRole, ok := allRoles[key]
 		if !ok {
 			mapRole = &role
+			keys = append(keys, key)
 		}
 		mapRole.Privileges = append(mapRole.Privileges, priv)
-		allRoles[role.Role] = mapRole
+		allRoles[key] = mapRole
 	}
 
 	retRoles := []oracleRole{}
-	for _, v := range allRoles {
-		retRoles = append(retRoles, *v)
+	for _, k := range keys {
+		retRoles = append(retRoles, *allRoles[k])
 	}
 	return retRoles, src, nil
 }
 
-func (c *EtlOracleConnectorUser) listDbaRolePrivs() ([]oracleRolePriv, *connectors.EtlSourceInfo, error) {
+func (c *EtlOracleConnectorUser) listDbaRolePrivs(v oracleViews) ([]oracleRolePriv, *connectors.EtlSourceInfo, error) {
 	src := connectors.CreateSourceInfo()
-	rows, cmd, err := c.db.LoggedQuery(`
+	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
 		SELECT 
-			GRANTEE,
-			GRANTED_ROLE
-		FROM DBA_ROLE_PRIVS
-	`)
+			%s AS CON_ID,
+			rp.GRANTEE,
+			rp.GRANTED_ROLE
+		FROM %s rp
+	`, v.conId("rp"), v.view("ROLE_PRIVS")))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -206,7 +279,7 @@ func (c *EtlOracleConnectorUser) listDbaRolePrivs() ([]oracleRolePriv, *connecto
 	allPrivs := []oracleRolePriv{}
 	for rows.Next() {
 		priv := oracleRolePriv{}
-		err = rows.Scan(&priv.Grantee, &priv.GrantedRole)
+		err = rows.Scan(&priv.ConId, &priv.Grantee, &priv.GrantedRole)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -216,45 +289,224 @@ func (c *EtlOracleConnectorUser) listDbaRolePrivs() ([]oracleRolePriv, *connecto
 	return allPrivs, src, nil
 }
 
+// listPasswordLimits returns the password related limits (e.g. FAILED_LOGIN_ATTEMPTS, PASSWORD_LIFE_TIME,
+// PASSWORD_REUSE_MAX) of every profile.
+func (c *EtlOracleConnectorUser) listPasswordLimits(v oracleViews) ([]oracleProfileLimit, *connectors.EtlSourceInfo, error) {
+	src := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(fmt.Sprintf(`
+		SELECT
+			%s AS CON_ID,
+			p.PROFILE,
+			p.RESOURCE_NAME,
+			p.LIMIT
+		FROM %s p
+		WHERE p.RESOURCE_TYPE = 'PASSWORD'
+	`, v.conId("p"), v.view("PROFILES")))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	src.AddCommand(cmd)
+
+	limits := []oracleProfileLimit{}
+	for rows.Next() {
+		limit := oracleProfileLimit{}
+		err = rows.Scan(&limit.ConId, &limit.Profile, &limit.ResourceName, &limit.Limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		limits = append(limits, limit)
+	}
+
+	return limits, src, nil
+}
+
+// oracleContainerListing is everything obtained about the users of a single container.
+type oracleContainerListing struct {
+	container oracleContainer
+	users     []oracleUser
+	roles     []oracleRole
+	rolePrivs []oracleRolePriv
+	proxies   []oracleProxy
+	// Profile -> Resource Name -> Limit
+	profiles map[string]map[string]string
+}
+
+func (l *oracleContainerListing) profileLimits(profile string) map[string]string {
+	limits := map[string]string{}
+	for resource, limit := range l.profiles[profile] {
+		// A limit of DEFAULT means the limit is inherited from the DEFAULT profile.
+		if limit == defaultProfileLimit {
+			limit = l.profiles[defaultProfile][resource]
+		}
+		limits[resource] = limit
+	}
+	return limits
+}
+
+func (l *oracleContainerListing) toEtlUsers(prefixUsernames bool) []*types.EtlUser {
+	roleTree := CreateUserRoleTree(l.users, l.roles, l.rolePrivs)
+
+	allRoles := map[string]*types.EtlRole{}
+	for _, r := range l.roles {
+		etlRole := r.toEtlRole()
+		allRoles[etlRole.Name] = etlRole
+	}
+
+	proxyRoles := createProxyRoles(l.proxies)
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range l.users {
+		username := u.Username
+		if prefixUsernames {
+			username = l.container.qualifyName(u.Username)
+		}
+
+		etlUser := u.toEtlUser(username)
+		etlUser.Attributes[AttributeContainer] = l.container.Name
+		for resource, limit := range l.profileLimits(u.Profile.String) {
+			etlUser.Attributes[strings.ToLower(resource)] = limit
+		}
+
+		roleNames := roleTree.FindUserParentRoleNames(u.Username)
+		for _, rn := range roleNames {
+			etlUser.Roles[rn] = allRoles[rn]
+		}
+
+		if role, ok := proxyRoles[u.Username]; ok {
+			etlUser.Roles[role.Name] = role
+		}
+
+		retUsers = append(retUsers, etlUser)
+	}
+	return retUsers
+}
+
+// GetUserListing returns the users of every open container when connected to the root of a multitenant
+// database (CDB$ROOT) and the users of the current container otherwise. Users in the root (or in a
+// non-CDB) are returned as is. Users in a pluggable database are returned as PDB::USERNAME: common users
+// are nested under the same user in the root while local users are returned separately.
 func (c *EtlOracleConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
 	finalSource := connectors.CreateSourceInfo()
 
-	oracleUsers, usersSrc, err := c.listDbaUsers()
+	views, containers, containerSrc, err := c.detectContainers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(containerSrc)
+
+	listings := map[int64]*oracleContainerListing{}
+	for _, ct := range containers {
+		listings[ct.Id] = &oracleContainerListing{
+			container: ct,
+			profiles:  map[string]map[string]string{},
+		}
+	}
+
+	oracleUsers, usersSrc, err := c.listDbaUsers(views)
 	if err != nil {
 		return nil, nil, err
 	}
 	finalSource.MergeWith(usersSrc)
 
-	oracleRoles, rolesSrc, err := c.listDbaRoles()
+	for _, u := range oracleUsers {
+		if l, ok := listings[u.ConId]; ok {
+			l.users = append(l.users, u)
+		}
+	}
+
+	oracleRoles, rolesSrc, err := c.listDbaRoles(views)
 	if err != nil {
 		return nil, nil, err
 	}
 	finalSource.MergeWith(rolesSrc)
 
-	roleAssignments, assignmentSrc, err := c.listDbaRolePrivs()
+	for _, r := range oracleRoles {
+		if l, ok := listings[r.ConId]; ok {
+			l.roles = append(l.roles, r)
+		}
+	}
+
+	roleAssignments, assignmentSrc, err := c.listDbaRolePrivs(views)
 	if err != nil {
 		return nil, nil, err
 	}
 	finalSource.MergeWith(assignmentSrc)
 
-	roleTree := CreateUserRoleTree(oracleUsers, oracleRoles, roleAssignments)
+	for _, rp := range roleAssignments {
+		if l, ok := listings[rp.ConId]; ok {
+			l.rolePrivs = append(l.rolePrivs, rp)
+		}
+	}
 
-	allRoles := map[string]*types.EtlRole{}
-	for _, r := range oracleRoles {
-		etlRole := r.toEtlRole()
-		allRoles[etlRole.Name] = etlRole
+	proxies, proxySrc, err := c.listProxies(views)
+	if err != nil {
+		return nil, nil, err
 	}
+	finalSource.MergeWith(proxySrc)
 
-	retUsers := []*types.EtlUser{}
-	for _, u := range oracleUsers {
-		etlUser := u.toEtlUser()
+	for _, p := range proxies {
+		if l, ok := listings[p.ConId]; ok {
+			l.proxies = append(l.proxies, p)
+		}
+	}
 
-		roleNames := roleTree.FindUserParentRoleNames(u.Username)
-		for _, rn := range roleNames {
-			etlUser.Roles[rn] = allRoles[rn]
+	limits, limitsSrc, err := c.listPasswordLimits(views)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(limitsSrc)
+
+	for _, lm := range limits {
+		l, ok := listings[lm.ConId]
+		if !ok {
+			continue
 		}
 
-		retUsers = append(retUsers, etlUser)
+		profile, ok := l.profiles[lm.Profile]
+		if !ok {
+			profile = map[string]string{}
+			l.profiles[lm.Profile] = profile
+		}
+		profile[lm.ResourceName] = lm.Limit
 	}
+
+	rootId := containers[0].Id
+	rootUsers := map[string]*types.EtlUser{}
+	retUsers := []*types.EtlUser{}
+	for _, u := range listings[rootId].toEtlUsers(false) {
+		rootUsers[u.Username] = u
+		retUsers = append(retUsers, u)
+	}
+
+	for _, ct := range containers[1:] {
+		listing := listings[ct.Id]
+		etlUsers := listing.toEtlUsers(true)
+
+		for idx, u := range etlUsers {
+			oracleUser := listing.users[idx]
+			rootUser, ok := rootUsers[oracleUser.Username]
+			if !ok || !oracleUser.isCommon() {
+				retUsers = append(retUsers, u)
+				continue
+			}
+
+			if rootUser.NestedUsers == nil {
+				rootUser.NestedUsers = map[string]*types.EtlUser{}
+			}
+			rootUser.NestedUsers[u.Username] = u
+		}
+	}
+
 	return retUsers, finalSource, nil
 }
+
+// sortContainers puts the root container first followed by the pluggable databases in CON_ID order.
+func sortContainers(containers []oracleContainer, rootId int64) {
+	sort.SliceStable(containers, func(i, j int) bool {
+		if containers[i].Id == rootId || containers[j].Id == rootId {
+			return containers[i].Id == rootId && containers[j].Id != rootId
+		}
+		return containers[i].Id < containers[j].Id
+	})
+}
@@ -15,6 +15,12 @@ func (c *EtlPsqlConnector) GetUserInterface() (connectors.EtlConnectorUserInterf
 }
 
 func CreatePsqlConnector(db databases.SqlxLike) (*EtlPsqlConnector, error) {
+	return CreatePsqlClusterConnector(db, nil)
+}
+
+// CreatePsqlClusterConnector creates a connector that also checks every other database in the cluster
+// by using connect to open a connection to each database.
+func CreatePsqlClusterConnector(db databases.SqlxLike, connect PsqlDatabaseConnectFn) (*EtlPsqlConnector, error) {
 	var err error
 	ret := EtlPsqlConnector{
 		db: db,
@@ -25,6 +31,7 @@ func CreatePsqlConnector(db databases.SqlxLike) (*EtlPsqlConnector, error) {
 	if err != nil {
 		return nil, err
 	}
+	ret.users.connect = connect
 
 	return &ret, nil
 }
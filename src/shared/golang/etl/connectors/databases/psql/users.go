@@ -2,18 +2,35 @@ package psql
 
 import (
 	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"io"
+	"strings"
 )
 
 const PsqlSuperPermission = "rolsuper"
 const PsqlCreateRolePermission = "rolcreaterole"
 const PsqlCreateDbPermission = "rolcreatedb"
 const PsqlReplicationPermission = "rolreplication"
+const PsqlBypassRlsPermission = "rolbypassrls"
+
+// PsqlOwnerPermission is given to a role for every object (database, schema, table, routine, etc.) it owns.
+const PsqlOwnerPermission = "OWNER"
+
+// PsqlHbaRole holds the pg_hba.conf rules that apply to the user. Each object is a rule and the permission
+// is the authentication method used.
+const PsqlHbaRole = "pg_hba"
+
+// PsqlDatabaseConnectFn opens a connection to another database in the same cluster. This is necessary because
+// PostgreSQL doesn't allow querying the catalog of a database other than the one the connection is using.
+type PsqlDatabaseConnectFn func(database string) (databases.SqlxLike, error)
 
 type EtlPsqlConnectorUser struct {
-	db *databases.DB
+	db      *databases.DB
+	connect PsqlDatabaseConnectFn
 }
 
 func createPsqlConnectorUser(db *databases.DB) (*EtlPsqlConnectorUser, error) {
@@ -22,14 +39,215 @@ func createPsqlConnectorUser(db *databases.DB) (*EtlPsqlConnectorUser, error) {
 	}, nil
 }
 
-// Obtain users and what roles/permissions they have.
-// We define a "user" as a role that can login.
-// Permissions in this case would be grants. We also need to also account for the boolean permissions of
-// rolsuper/rolcreaterole/rolcreatedb/rolbypassrls/rolreplication.
-func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
-	// Get all users, roles they're a part of, and grants they have all in one SQL query.
+// Permissions that are stored in the shared catalog and thus are the same no matter which database we're connected to.
+const psqlClusterPermissions = `
+	SELECT
+		pg_get_userbyid(acl.grantee)::text AS grantee,
+		CONCAT('DATABASE::', d.datname) AS object,
+		acl.privilege_type::text AS permission
+	FROM pg_database AS d
+	CROSS JOIN LATERAL aclexplode(d.datacl) AS acl
+	WHERE acl.grantee <> 0
+
+	UNION
+
+	SELECT
+		pg_get_userbyid(d.datdba)::text AS grantee,
+		CONCAT('DATABASE::', d.datname) AS object,
+		'OWNER' AS permission
+	FROM pg_database AS d
+`
+
+// Permissions that are stored in the catalog of the database we're connected to.
+const psqlDatabasePermissions = `
+	SELECT
+		tp.grantee::text AS grantee,
+		CASE WHEN tp.grantee IS NULL THEN ''
+			 ELSE CONCAT('TBL::', tp.table_catalog, '.', tp.table_schema, '.', tp.table_name)
+		END AS object,
+		COALESCE(tp.privilege_type, '')::text as permission
+	FROM information_schema.table_privileges AS tp
+
+	UNION
+
+	SELECT
+		cp.grantee::text AS grantee,
+		CASE WHEN cp.grantee IS NULL THEN ''
+			 ELSE CONCAT('COLUMN::', cp.table_catalog, '.', cp.table_schema, '.', cp.table_name, '.', cp.column_name)
+		END AS object,
+		COALESCE(cp.privilege_type, '')::text as permission
+	FROM information_schema.column_privileges AS cp
+
+	UNION
+
+	SELECT
+		rp.grantee::text AS grantee,
+		CASE WHEN rp.grantee IS NULL THEN ''
+			 ELSE CONCAT('ROUTINE::', rp.specific_catalog, '.', rp.specific_schema, '.', rp.specific_name)
+		END AS object,
+		COALESCE(rp.privilege_type, '')::text as permission
+	FROM information_schema.routine_privileges AS rp
+
+	UNION
+
+	SELECT
+		up.grantee::text AS grantee,
+		CASE WHEN up.grantee IS NULL THEN ''
+			 ELSE CONCAT(up.object_type, '::', up.object_catalog, '.', up.object_schema, '.', up.object_name)
+		END AS object,
+		COALESCE(up.privilege_type, '')::text as permission
+	FROM information_schema.usage_privileges AS up
+
+	UNION
+
+	SELECT
+		pg_get_userbyid(acl.grantee)::text AS grantee,
+		CONCAT('SCHEMA::', current_database(), '.', n.nspname) AS object,
+		acl.privilege_type::text AS permission
+	FROM pg_namespace AS n
+	CROSS JOIN LATERAL aclexplode(n.nspacl) AS acl
+	WHERE acl.grantee <> 0
+
+	UNION
+
+	SELECT
+		pg_get_userbyid(acl.grantee)::text AS grantee,
+		CONCAT(
+			'DEFAULT::',
+			current_database(),
+			'.',
+			COALESCE(n.nspname, '*'),
+			'.',
+			CASE da.defaclobjtype
+				WHEN 'r' THEN 'TABLES'
+				WHEN 'S' THEN 'SEQUENCES'
+				WHEN 'f' THEN 'FUNCTIONS'
+				WHEN 'T' THEN 'TYPES'
+				WHEN 'n' THEN 'SCHEMAS'
+				ELSE da.defaclobjtype::text
+			END,
+			'::',
+			pg_get_userbyid(da.defaclrole)
+		) AS object,
+		acl.privilege_type::text AS permission
+	FROM pg_default_acl AS da
+	LEFT JOIN pg_namespace AS n
+		ON n.oid = da.defaclnamespace
+	CROSS JOIN LATERAL aclexplode(da.defaclacl) AS acl
+	WHERE acl.grantee <> 0
+
+	UNION
+
+	SELECT
+		pr.rolname::text AS grantee,
+		CONCAT('POLICY::', current_database(), '.', pol.schemaname, '.', pol.tablename, '.', pol.policyname) AS object,
+		pol.cmd::text AS permission
+	FROM pg_policies AS pol
+	INNER JOIN pg_roles AS pr
+		ON pr.rolname = ANY(pol.roles)
+
+	UNION
+
+	SELECT
+		pg_get_userbyid(c.relowner)::text AS grantee,
+		CONCAT(
+			CASE WHEN c.relkind = 'S' THEN 'SEQUENCE' ELSE 'TBL' END,
+			'::',
+			current_database(),
+			'.',
+			n.nspname,
+			'.',
+			c.relname
+		) AS object,
+		'OWNER' AS permission
+	FROM pg_class AS c
+	INNER JOIN pg_namespace AS n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'v', 'm', 'S', 'f', 'p')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_toast%'
+		AND n.nspname NOT LIKE 'pg_temp%'
+
+	UNION
+
+	SELECT
+		pg_get_userbyid(n.nspowner)::text AS grantee,
+		CONCAT('SCHEMA::', current_database(), '.', n.nspname) AS object,
+		'OWNER' AS permission
+	FROM pg_namespace AS n
+	WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_toast%'
+		AND n.nspname NOT LIKE 'pg_temp%'
+
+	UNION
+
+	SELECT
+		pg_get_userbyid(p.proowner)::text AS grantee,
+		CONCAT('ROUTINE::', current_database(), '.', n.nspname, '.', p.proname, '_', p.oid) AS object,
+		'OWNER' AS permission
+	FROM pg_proc AS p
+	INNER JOIN pg_namespace AS n
+		ON n.oid = p.pronamespace
+	WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+`
+
+type psqlUserListing struct {
+	// Need to keep a map of all users see that we can easily
+	// aggregate all the permissions into a single user object.
+	userMap  map[string]*types.EtlUser
+	allUsers []*types.EtlUser
+}
+
+type psqlDatabase struct {
+	Name    string `db:"Name"`
+	Current bool   `db:"Current"`
+}
+
+type psqlHbaRule struct {
+	LineNumber int    `db:"LineNumber"`
+	Type       string `db:"Type"`
+	Databases  string `db:"Databases"`
+	Users      string `db:"Users"`
+	Address    string `db:"Address"`
+	Netmask    string `db:"Netmask"`
+	AuthMethod string `db:"AuthMethod"`
+}
+
+func (r psqlHbaRule) object() string {
+	address := r.Address
+	if r.Netmask != "" {
+		address = fmt.Sprintf("%s/%s", r.Address, r.Netmask)
+	}
+	return fmt.Sprintf("HBA::%d::%s::%s::%s", r.LineNumber, r.Type, r.Databases, address)
+}
+
+// appliesTo determines whether the rule matches the user. Users can be specified by name, by group membership (+group) or "all".
+// References to files (@file) can't be resolved and are ignored.
+func (r psqlHbaRule) appliesTo(user *types.EtlUser) bool {
+	for _, u := range strings.Split(r.Users, ",") {
+		if u == "all" || u == user.Username {
+			return true
+		}
+
+		if strings.HasPrefix(u, "+") {
+			group := strings.TrimPrefix(u, "+")
+			if group == user.Username {
+				return true
+			}
+
+			_, ok := user.Roles[group]
+			if ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Get all users, roles they're a part of, and the given permissions they have all in one SQL query.
+func (c *EtlPsqlConnectorUser) getUserPermissions(db *databases.DB, permissions string, listing *psqlUserListing) (*connectors.EtlSourceInfo, error) {
 	source := connectors.CreateSourceInfo()
-	rows, cmd, err := c.db.LoggedQuery(`
+	rows, cmd, err := db.LoggedQuery(fmt.Sprintf(`
 		WITH RECURSIVE parents AS (
 			SELECT
 				u.rolname as child_role,
@@ -53,43 +271,7 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 			INNER JOIN parents AS p
 				ON p.parent_role = pr.rolname
 		), permissions AS (
-			SELECT
-				tp.grantee,
-				CASE WHEN tp.grantee IS NULL THEN ''
-					 ELSE CONCAT('TBL::', tp.table_catalog, '.', tp.table_schema, '.', tp.table_name)
-				END AS object,
-				COALESCE(tp.privilege_type, '') as permission
-			FROM information_schema.table_privileges AS tp
-
-			UNION
-
-			SELECT
-				cp.grantee,
-				CASE WHEN cp.grantee IS NULL THEN ''
-					 ELSE CONCAT('COLUMN::', cp.table_catalog, '.', cp.table_schema, '.', cp.table_name, '.', cp.column_name)
-				END AS object,
-				COALESCE(cp.privilege_type, '') as permission
-			FROM information_schema.column_privileges AS cp
-
-			UNION
-
-			SELECT
-				rp.grantee,
-				CASE WHEN rp.grantee IS NULL THEN ''
-					 ELSE CONCAT('ROUTINE::', rp.specific_catalog, '.', rp.specific_schema, '.', rp.specific_name)
-				END AS object,
-				COALESCE(rp.privilege_type, '') as permission
-			FROM information_schema.routine_privileges AS rp
-
-			UNION
-
-			SELECT
-				up.grantee,
-				CASE WHEN up.grantee IS NULL THEN ''
-					 ELSE CONCAT(up.object_type, '::', up.object_catalog, '.', up.object_schema, '.', up.object_name)
-				END AS object,
-				COALESCE(up.privilege_type, '') as permission
-			FROM information_schema.usage_privileges AS up
+			%s
 		), users AS (
 			SELECT
 				pr.oid,
@@ -97,7 +279,8 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 				pr.rolsuper,
 				pr.rolcreaterole,
 				pr.rolcreatedb,
-				pr.rolreplication
+				pr.rolreplication,
+				pr.rolbypassrls
 			FROM pg_roles AS pr
 			WHERE pr.rolcanlogin = true
 		) 
@@ -107,6 +290,7 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 			u.rolcreaterole AS "CreateRole",
 			u.rolcreatedb AS "CreateDb",
 			u.rolreplication AS "Replication",
+			u.rolbypassrls AS "BypassRls",
 			'Self' AS "ParentRole",
 			p.object AS "Object",
 			p.permission AS "Permission"
@@ -121,6 +305,7 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 			u.rolcreaterole AS "CreateRole",
 			u.rolcreatedb AS "CreateDb",
 			u.rolreplication AS "Replication",
+			u.rolbypassrls AS "BypassRls",
 			par.parent_role AS "ParentRole",
 			p.object AS "Object",
 			p.permission AS "Permission"
@@ -129,18 +314,13 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 			ON par.child_role = u.rolname
 		LEFT JOIN permissions AS p
 			ON p.grantee = par.parent_role
-	`)
+	`, permissions))
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	source.AddCommand(cmd)
 
-	// Need to keep a map of all users see that we can easily
-	// aggregate all the permissions into a single user object.
-	userMap := map[string]*types.EtlUser{}
-	allUsers := []*types.EtlUser{}
-
 	defer rows.Close()
 	for rows.Next() {
 		type Result struct {
@@ -149,6 +329,7 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 			CreateRole  bool           `db:"CreateRole"`
 			CreateDb    bool           `db:"CreateDb"`
 			Replication bool           `db:"Replication"`
+			BypassRls   bool           `db:"BypassRls"`
 			ParentRole  string         `db:"ParentRole"`
 			Object      sql.NullString `db:"Object"`
 			Permission  sql.NullString `db:"Permission"`
@@ -156,19 +337,19 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 		result := Result{}
 		err = rows.StructScan(&result)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
-		user, ok := userMap[result.Username]
+		user, ok := listing.userMap[result.Username]
 		if !ok {
 			user = &types.EtlUser{
 				Username: result.Username,
 				Roles:    map[string]*types.EtlRole{},
 			}
-			userMap[result.Username] = user
-			allUsers = append(allUsers, user)
+			listing.userMap[result.Username] = user
+			listing.allUsers = append(listing.allUsers, user)
 
-			// Only handle the Super/CreateRole/CreateDb/Replication
+			// Only handle the Super/CreateRole/CreateDb/Replication/BypassRls
 			// permissions once as they'll be present in every row.
 			permissions := []string{}
 
@@ -188,6 +369,10 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 				permissions = append(permissions, PsqlReplicationPermission)
 			}
 
+			if result.BypassRls {
+				permissions = append(permissions, PsqlBypassRlsPermission)
+			}
+
 			user.Roles["Self"] = &types.EtlRole{
 				Name: "Self",
 				Permissions: map[string][]string{
@@ -216,5 +401,178 @@ func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.E
 		}
 	}
 
-	return allUsers, source, nil
+	return source, nil
+}
+
+func (c *EtlPsqlConnectorUser) getDatabases() ([]psqlDatabase, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(`
+		SELECT
+			datname AS "Name",
+			datname = current_database() AS "Current"
+		FROM pg_database
+		WHERE datallowconn = true AND datistemplate = false
+		ORDER BY datname
+	`)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	source.AddCommand(cmd)
+
+	dbs := []psqlDatabase{}
+	for rows.Next() {
+		db := psqlDatabase{}
+		err = rows.StructScan(&db)
+		if err != nil {
+			return nil, nil, err
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs, source, nil
+}
+
+// SQLSTATE codes for errors that mean the pg_hba.conf rules aren't available rather than that the query failed.
+const (
+	psqlInsufficientPrivilege pq.ErrorCode = "42501"
+	psqlUndefinedTable        pq.ErrorCode = "42P01"
+)
+
+func isPsqlError(err error, codes ...pq.ErrorCode) bool {
+	pqErr, ok := err.(*pq.Error)
+	if !ok {
+		return false
+	}
+
+	for _, c := range codes {
+		if pqErr.Code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Retrieves the parsed pg_hba.conf rules. This is only available in PostgreSQL 10+ and is generally only readable by
+// superusers so this returns no rules (rather than an error) if the view doesn't exist or can't be read. Any other
+// error is returned.
+func (c *EtlPsqlConnectorUser) getHbaRules() ([]psqlHbaRule, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(`
+		SELECT
+			line_number AS "LineNumber",
+			type AS "Type",
+			array_to_string(database, ',') AS "Databases",
+			array_to_string(user_name, ',') AS "Users",
+			COALESCE(address, '') AS "Address",
+			COALESCE(netmask, '') AS "Netmask",
+			COALESCE(auth_method, '') AS "AuthMethod"
+		FROM pg_hba_file_rules
+		WHERE error IS NULL
+		ORDER BY line_number
+	`)
+
+	if isPsqlError(err, psqlInsufficientPrivilege, psqlUndefinedTable) {
+		return []psqlHbaRule{}, source, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	source.AddCommand(cmd)
+
+	rules := []psqlHbaRule{}
+	for rows.Next() {
+		r := psqlHbaRule{}
+		err = rows.StructScan(&r)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, source, nil
+}
+
+// Obtain users and what roles/permissions they have.
+// We define a "user" as a role that can login.
+// Permissions in this case would be grants (on databases, schemas, tables, columns, routines, etc.), default privileges,
+// row level security policies and ownership of objects. We also need to also account for the boolean permissions of
+// rolsuper/rolcreaterole/rolcreatedb/rolbypassrls/rolreplication.
+// Every database in the cluster is checked if the connector is able to connect to other databases.
+func (c *EtlPsqlConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+	listing := psqlUserListing{
+		userMap:  map[string]*types.EtlUser{},
+		allUsers: []*types.EtlUser{},
+	}
+
+	// Step 1: Get the permissions that apply to the entire cluster.
+	src, err := c.getUserPermissions(c.db, psqlClusterPermissions, &listing)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	// Step 2: Get the permissions within each database.
+	dbs, src, err := c.getDatabases()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	for _, d := range dbs {
+		if d.Current {
+			src, err = c.getUserPermissions(c.db, psqlDatabasePermissions, &listing)
+			if err != nil {
+				return nil, nil, err
+			}
+			finalSource.MergeWith(src)
+			continue
+		}
+
+		if c.connect == nil {
+			continue
+		}
+
+		conn, err := c.connect(d.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		src, err = c.getUserPermissions(&databases.DB{SqlxLike: conn}, psqlDatabasePermissions, &listing)
+		if closer, ok := conn.(io.Closer); ok {
+			closer.Close()
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(src)
+	}
+
+	// Step 3: Determine how each user is allowed to authenticate.
+	rules, src, err := c.getHbaRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	for _, u := range listing.allUsers {
+		hba := &types.EtlRole{
+			Name:        PsqlHbaRole,
+			Permissions: map[string][]string{},
+		}
+
+		for _, r := range rules {
+			if !r.appliesTo(u) {
+				continue
+			}
+			hba.Permissions[r.object()] = []string{r.AuthMethod}
+		}
+
+		if len(hba.Permissions) > 0 {
+			u.Roles[hba.Name] = hba
+		}
+	}
+
+	return listing.allUsers, finalSource, nil
 }
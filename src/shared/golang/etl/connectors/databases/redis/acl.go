@@ -0,0 +1,221 @@
+package redis
+
+import (
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strconv"
+	"strings"
+)
+
+const RedisExecutePermission = "EXECUTE"
+const RedisReadPermission = "READ"
+const RedisWritePermission = "WRITE"
+const RedisChannelPermission = "ACCESS"
+
+const (
+	redisKeyPrefix     = "KEY::"
+	redisChannelPrefix = "CHANNEL::"
+	redisCommandPrefix = "COMMAND::"
+)
+
+// redisAclRules holds the effect of a list of ACL rules (either the user's own or those of a selector).
+type redisAclRules struct {
+	role *types.EtlRole
+}
+
+func createRedisAclRules(name string) *redisAclRules {
+	return &redisAclRules{
+		role: &types.EtlRole{
+			Name:        name,
+			Permissions: map[string][]string{},
+			Denied:      map[string][]string{},
+		},
+	}
+}
+
+func (r *redisAclRules) resetPrefix(prefix string) {
+	for object := range r.role.Permissions {
+		if strings.HasPrefix(object, prefix) {
+			delete(r.role.Permissions, object)
+		}
+	}
+
+	for object := range r.role.Denied {
+		if strings.HasPrefix(object, prefix) {
+			delete(r.role.Denied, object)
+		}
+	}
+}
+
+func (r *redisAclRules) addKeyPattern(pattern string, permissions ...string) {
+	object := redisKeyPrefix + pattern
+	existing := r.role.Permissions[object]
+
+PERM:
+	for _, p := range permissions {
+		for _, e := range existing {
+			if e == p {
+				continue PERM
+			}
+		}
+		existing = append(existing, p)
+	}
+	r.role.Permissions[object] = existing
+}
+
+// applyCommand applies a +/- command rule. Rules are applied in order so a later rule overrides an earlier
+// one for the same command or category.
+func (r *redisAclRules) applyCommand(allow bool, command string) {
+	command = strings.ToLower(command)
+
+	// Allowing or denying every command makes all earlier command rules irrelevant.
+	if command == "@all" {
+		r.resetPrefix(redisCommandPrefix)
+	}
+
+	object := redisCommandPrefix + command
+	if allow {
+		delete(r.role.Denied, object)
+		r.role.Permissions[object] = []string{RedisExecutePermission}
+	} else {
+		delete(r.role.Permissions, object)
+		r.role.Denied[object] = []string{RedisExecutePermission}
+	}
+}
+
+func (r *redisAclRules) apply(rule string) {
+	switch {
+	case rule == "allkeys":
+		r.addKeyPattern("*", RedisReadPermission, RedisWritePermission)
+	case rule == "resetkeys":
+		r.resetPrefix(redisKeyPrefix)
+	case rule == "allchannels":
+		r.role.Permissions[redisChannelPrefix+"*"] = []string{RedisChannelPermission}
+	case rule == "resetchannels":
+		r.resetPrefix(redisChannelPrefix)
+	case rule == "allcommands":
+		r.applyCommand(true, "@all")
+	case rule == "nocommands":
+		r.applyCommand(false, "@all")
+	case strings.HasPrefix(rule, "~"):
+		r.addKeyPattern(rule[1:], RedisReadPermission, RedisWritePermission)
+	case strings.HasPrefix(rule, "%"):
+		// %R~pattern, %W~pattern or %RW~pattern (Redis 7+).
+		idx := strings.Index(rule, "~")
+		if idx == -1 {
+			return
+		}
+
+		permissions := []string{}
+		flags := strings.ToUpper(rule[1:idx])
+		if strings.Contains(flags, "R") {
+			permissions = append(permissions, RedisReadPermission)
+		}
+
+		if strings.Contains(flags, "W") {
+			permissions = append(permissions, RedisWritePermission)
+		}
+		r.addKeyPattern(rule[idx+1:], permissions...)
+	case strings.HasPrefix(rule, "&"):
+		r.role.Permissions[redisChannelPrefix+rule[1:]] = []string{RedisChannelPermission}
+	case strings.HasPrefix(rule, "+"):
+		r.applyCommand(true, rule[1:])
+	case strings.HasPrefix(rule, "-"):
+		r.applyCommand(false, rule[1:])
+	}
+}
+
+type redisAclUser struct {
+	Name          string
+	Enabled       bool
+	NoPass        bool
+	PasswordCount int
+	Rules         *redisAclRules
+	Selectors     []*redisAclRules
+}
+
+// splitAclRules splits the rules on spaces while keeping selectors (rules in parentheses) together.
+func splitAclRules(line string) ([]string, error) {
+	rules := []string{}
+	current := strings.Builder{}
+	depth := 0
+
+	for _, ch := range line {
+		switch {
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("Unbalanced parentheses in ACL rules: " + line)
+			}
+		case ch == ' ' && depth == 0:
+			if current.Len() > 0 {
+				rules = append(rules, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(ch)
+	}
+
+	if depth != 0 {
+		return nil, errors.New("Unbalanced parentheses in ACL rules: " + line)
+	}
+
+	if current.Len() > 0 {
+		rules = append(rules, current.String())
+	}
+	return rules, nil
+}
+
+// parseAclListLine parses a line of ACL LIST (e.g. "user default on nopass ~* &* +@all").
+func parseAclListLine(line string) (*redisAclUser, error) {
+	rules, err := splitAclRules(line)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) < 2 || rules[0] != "user" {
+		return nil, errors.New("Invalid ACL LIST line: " + line)
+	}
+
+	user := &redisAclUser{
+		Name:      rules[1],
+		Rules:     createRedisAclRules("Self"),
+		Selectors: []*redisAclRules{},
+	}
+
+	for _, rule := range rules[2:] {
+		switch {
+		case rule == "on":
+			user.Enabled = true
+		case rule == "off":
+			user.Enabled = false
+		case rule == "nopass":
+			user.NoPass = true
+			user.PasswordCount = 0
+		case rule == "resetpass":
+			user.NoPass = false
+			user.PasswordCount = 0
+		case strings.HasPrefix(rule, "#") || strings.HasPrefix(rule, ">"):
+			user.NoPass = false
+			user.PasswordCount++
+		case strings.HasPrefix(rule, "(") && strings.HasSuffix(rule, ")"):
+			selector := createRedisAclRules("Selector " + strconv.Itoa(len(user.Selectors)+1))
+			selectorRules, err := splitAclRules(rule[1 : len(rule)-1])
+			if err != nil {
+				return nil, err
+			}
+
+			for _, r := range selectorRules {
+				selector.apply(r)
+			}
+			user.Selectors = append(user.Selectors, selector)
+		default:
+			user.Rules.apply(rule)
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisCommandRunner runs a command and returns the reply. Replies are strings (simple and bulk strings),
+// int64s, nil or []interface{} of those. Errors returned by the server are returned as errors.
+type RedisCommandRunner interface {
+	Do(args ...string) (interface{}, error)
+}
+
+type RedisOptions struct {
+	Address string
+	// Uses the legacy single password AUTH if empty.
+	Username string
+	Password string
+	// Uses TLS if set.
+	TlsConfig *tls.Config
+	// Limits how long connecting and each command may take. No limit if 0.
+	Timeout time.Duration
+	// Connects through another transport (e.g. an SSH tunnel) if set.
+	Dial func(network string, address string) (net.Conn, error)
+}
+
+// RedisClient is a minimal RESP2 client which is all that's needed to read the ACLs.
+type RedisClient struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+func DialRedis(opts RedisOptions) (*RedisClient, error) {
+	var conn net.Conn
+	var err error
+
+	if opts.Dial != nil {
+		conn, err = opts.Dial("tcp", opts.Address)
+	} else {
+		conn, err = net.DialTimeout("tcp", opts.Address, opts.Timeout)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.TlsConfig != nil {
+		conn = tls.Client(conn, opts.TlsConfig)
+	}
+
+	client := &RedisClient{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		timeout: opts.Timeout,
+	}
+
+	if opts.Password != "" {
+		args := []string{"AUTH", opts.Password}
+		if opts.Username != "" {
+			args = []string{"AUTH", opts.Username, opts.Password}
+		}
+
+		_, err = client.Do(args...)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (c *RedisClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *RedisClient) Do(args ...string) (interface{}, error) {
+	if c.timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+		defer c.conn.SetDeadline(time.Time{})
+	}
+
+	_, err := c.conn.Write(encodeRespCommand(args))
+	if err != nil {
+		return nil, err
+	}
+
+	return readRespReply(c.reader)
+}
+
+func encodeRespCommand(args []string) []byte {
+	buffer := []byte(fmt.Sprintf("*%d\r\n", len(args)))
+	for _, a := range args {
+		buffer = append(buffer, fmt.Sprintf("$%d\r\n%s\r\n", len(a), a)...)
+	}
+	return buffer
+}
+
+func readRespLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errors.New("Invalid RESP line.")
+	}
+	return line[:len(line)-2], nil
+}
+
+func readRespReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := readRespLine(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, errors.New("Empty RESP reply.")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if length < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, length+2)
+		_, err = io.ReadFull(reader, data)
+		if err != nil {
+			return nil, err
+		}
+		return string(data[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if length < 0 {
+			return nil, nil
+		}
+
+		values := make([]interface{}, length)
+		for i := range values {
+			values[i], err = readRespReply(reader)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	return nil, errors.New("Unsupported RESP reply: " + line)
+}
@@ -0,0 +1,27 @@
+package redis
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+)
+
+type EtlRedisConnector struct {
+	runner RedisCommandRunner
+	users  *EtlRedisConnectorUser
+}
+
+func (c *EtlRedisConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateRedisConnector(runner RedisCommandRunner) (*EtlRedisConnector, error) {
+	var err error
+	ret := EtlRedisConnector{
+		runner: runner,
+	}
+	ret.users, err = createRedisConnectorUser(runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
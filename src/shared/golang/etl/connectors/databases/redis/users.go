@@ -0,0 +1,216 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strconv"
+	"strings"
+)
+
+const (
+	RedisAttributeEnabled       = "enabled"
+	RedisAttributeNoPass        = "nopass"
+	RedisAttributePasswordCount = "password_count"
+	RedisAttributeFlags         = "flags"
+)
+
+// FindingNoPassword is reported for enabled users that can authenticate with any password.
+const FindingNoPassword = "REDIS_NOPASS"
+
+type EtlRedisConnectorUser struct {
+	runner RedisCommandRunner
+}
+
+func createRedisConnectorUser(runner RedisCommandRunner) (*EtlRedisConnectorUser, error) {
+	return &EtlRedisConnectorUser{
+		runner: runner,
+	}, nil
+}
+
+func (c *EtlRedisConnectorUser) runCommand(args ...string) (interface{}, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	params := map[string]interface{}{}
+	for i, a := range args[1:] {
+		params[strconv.Itoa(i+1)] = a
+	}
+
+	reply, err := c.runner.Do(args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := json.Marshal(redactReply(args, reply))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	source.AddCommand(&connectors.EtlCommandInfo{
+		Command:    strings.Join(args, " "),
+		Parameters: params,
+		RawData:    string(raw),
+	})
+	return reply, source, nil
+}
+
+// isPasswordRule is true for the rules that add or remove a password (>, <) or its SHA-256 hash (#, !).
+func isPasswordRule(rule string) bool {
+	return strings.HasPrefix(rule, ">") || strings.HasPrefix(rule, "<") || strings.HasPrefix(rule, "#") || strings.HasPrefix(rule, "!")
+}
+
+// redactReply returns a copy of the reply without the password hashes so they aren't recorded with the
+// rest of the source data. The ACL LIST lines drop their password rules and ACL GETUSER drops the values
+// of its passwords field. Other replies are returned as is.
+func redactReply(args []string, reply interface{}) interface{} {
+	if len(args) < 2 || !strings.EqualFold(args[0], "ACL") {
+		return reply
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok {
+		return reply
+	}
+
+	redacted := make([]interface{}, len(values))
+	copy(redacted, values)
+
+	switch strings.ToUpper(args[1]) {
+	case "LIST":
+		for i, v := range redacted {
+			line, ok := v.(string)
+			if !ok {
+				continue
+			}
+
+			kept := []string{}
+			for _, rule := range strings.Fields(line) {
+				if !isPasswordRule(rule) {
+					kept = append(kept, rule)
+				}
+			}
+			redacted[i] = strings.Join(kept, " ")
+		}
+	case "GETUSER":
+		for i := 0; i+1 < len(redacted); i += 2 {
+			if redacted[i] == "passwords" {
+				redacted[i+1] = []interface{}{}
+			}
+		}
+	}
+	return redacted
+}
+
+func replyToStrings(reply interface{}) ([]string, error) {
+	values, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected an array reply but got %T.", reply)
+	}
+
+	ret := []string{}
+	for _, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("Expected a string reply but got %T.", v)
+		}
+		ret = append(ret, str)
+	}
+	return ret, nil
+}
+
+// getUserFlags reads the flags (e.g. on, nopass, sanitize-payload) from ACL GETUSER which replies with
+// alternating field names and values.
+func (c *EtlRedisConnectorUser) getUserFlags(username string) ([]string, *connectors.EtlSourceInfo, error) {
+	reply, source, err := c.runCommand("ACL", "GETUSER", username)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The user was deleted after it was listed.
+	if reply == nil {
+		return []string{}, source, nil
+	}
+
+	fields, ok := reply.([]interface{})
+	if !ok || len(fields)%2 != 0 {
+		return nil, nil, errors.New("Unexpected ACL GETUSER reply for " + username)
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		if fields[i] != "flags" {
+			continue
+		}
+
+		flags, err := replyToStrings(fields[i+1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return flags, source, nil
+	}
+
+	return []string{}, source, nil
+}
+
+// Obtain users and what they're allowed to do.
+// Redis doesn't have roles so the user's own ACL rules are listed as the "Self" role: key patterns with the
+// READ/WRITE permissions, channel patterns and the commands/categories that are allowed (Permissions) or
+// not (Denied). Selectors (Redis 7+) grant additional access and are listed as separate roles.
+func (c *EtlRedisConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	reply, source, err := c.runCommand("ACL", "LIST")
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	lines, err := replyToStrings(reply)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, line := range lines {
+		aclUser, err := parseAclListLine(line)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		flags, source, err := c.getUserFlags(aclUser.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+
+		user := &types.EtlUser{
+			Username: aclUser.Name,
+			Roles: map[string]*types.EtlRole{
+				aclUser.Rules.role.Name: aclUser.Rules.role,
+			},
+			Attributes: map[string]string{
+				RedisAttributeEnabled:       strconv.FormatBool(aclUser.Enabled),
+				RedisAttributeNoPass:        strconv.FormatBool(aclUser.NoPass),
+				RedisAttributePasswordCount: strconv.Itoa(aclUser.PasswordCount),
+				RedisAttributeFlags:         strings.Join(flags, ","),
+			},
+			Findings: []*types.EtlFinding{},
+		}
+
+		for _, s := range aclUser.Selectors {
+			user.Roles[s.role.Name] = s.role
+		}
+
+		if aclUser.Enabled && aclUser.NoPass {
+			user.Findings = append(user.Findings, &types.EtlFinding{
+				Code:        FindingNoPassword,
+				Description: "The user is enabled and can authenticate with any password.",
+			})
+		}
+
+		retUsers = append(retUsers, user)
+	}
+
+	return retUsers, finalSource, nil
+}
@@ -0,0 +1,30 @@
+package redshift
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+)
+
+type EtlRedshiftConnector struct {
+	db    databases.SqlxLike
+	users *EtlRedshiftConnectorUser
+}
+
+func (c *EtlRedshiftConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateRedshiftConnector(db databases.SqlxLike) (*EtlRedshiftConnector, error) {
+	var err error
+	ret := EtlRedshiftConnector{
+		db: db,
+	}
+	ret.users, err = createRedshiftConnectorUser(&databases.DB{
+		SqlxLike: db,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,402 @@
+package redshift
+
+import (
+	"database/sql"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strconv"
+)
+
+const RedshiftSuperPermission = "usesuper"
+const RedshiftCreateDbPermission = "usecreatedb"
+
+// RedshiftOwnerPermission is given to a user for every schema, table and view it owns.
+const RedshiftOwnerPermission = "OWNER"
+
+// RedshiftSystemObject is the object that system privileges (e.g. CREATE USER) granted to roles are listed under.
+const RedshiftSystemObject = "SYSTEM"
+
+// RedshiftPublicRole holds the privileges granted to PUBLIC which every user has.
+const RedshiftPublicRole = "PUBLIC"
+
+// RedshiftGroupRolePrefix is prepended to the names of groups so that they can't be confused with roles
+// that have the same name.
+const RedshiftGroupRolePrefix = "GROUP::"
+
+const (
+	RedshiftAttributeUserId     = "user_id"
+	RedshiftAttributeValidUntil = "valid_until"
+)
+
+const (
+	redshiftIdentityUser   = "user"
+	redshiftIdentityGroup  = "group"
+	redshiftIdentityRole   = "role"
+	redshiftIdentityPublic = "public"
+)
+
+type EtlRedshiftConnectorUser struct {
+	db *databases.DB
+}
+
+func createRedshiftConnectorUser(db *databases.DB) (*EtlRedshiftConnectorUser, error) {
+	return &EtlRedshiftConnectorUser{
+		db: db,
+	}, nil
+}
+
+type redshiftUser struct {
+	Username   string         `db:"Username"`
+	UserId     int64          `db:"UserId"`
+	Super      bool           `db:"Super"`
+	CreateDb   bool           `db:"CreateDb"`
+	ValidUntil sql.NullString `db:"ValidUntil"`
+}
+
+type redshiftMembership struct {
+	Member string `db:"Member"`
+	Parent string `db:"Parent"`
+}
+
+type redshiftPrivilege struct {
+	IdentityType string `db:"IdentityType"`
+	IdentityName string `db:"IdentityName"`
+	Object       string `db:"Object"`
+	Permission   string `db:"Permission"`
+	AdminOption  bool   `db:"AdminOption"`
+}
+
+// The privileges granted to each user, group and role keyed by the identity type and then the name.
+type redshiftPrivilegeMap map[string]map[string]types.PermissionMap
+
+func (m redshiftPrivilegeMap) add(p redshiftPrivilege) {
+	// There's only one PUBLIC.
+	if p.IdentityType == redshiftIdentityPublic {
+		p.IdentityName = ""
+	}
+
+	identities, ok := m[p.IdentityType]
+	if !ok {
+		identities = map[string]types.PermissionMap{}
+		m[p.IdentityType] = identities
+	}
+
+	permissions, ok := identities[p.IdentityName]
+	if !ok {
+		permissions = types.PermissionMap{}
+		identities[p.IdentityName] = permissions
+	}
+
+	permission := p.Permission
+	if p.AdminOption {
+		permission = permission + " WITH GRANT OPTION"
+	}
+	permissions[p.Object] = append(permissions[p.Object], permission)
+}
+
+func (m redshiftPrivilegeMap) get(identityType string, name string) types.PermissionMap {
+	permissions, ok := m[identityType][name]
+	if !ok {
+		return types.PermissionMap{}
+	}
+	return permissions
+}
+
+const redshiftPrivilegesQuery = `
+	SELECT
+		identity_type AS "IdentityType",
+		identity_name AS "IdentityName",
+		'DATABASE::' || database_name AS "Object",
+		privilege_type AS "Permission",
+		admin_option AS "AdminOption"
+	FROM svv_database_privileges
+
+	UNION ALL
+
+	SELECT
+		identity_type AS "IdentityType",
+		identity_name AS "IdentityName",
+		'SCHEMA::' || current_database() || '.' || namespace_name AS "Object",
+		privilege_type AS "Permission",
+		admin_option AS "AdminOption"
+	FROM svv_schema_privileges
+
+	UNION ALL
+
+	SELECT
+		identity_type AS "IdentityType",
+		identity_name AS "IdentityName",
+		'TBL::' || current_database() || '.' || namespace_name || '.' || relation_name AS "Object",
+		privilege_type AS "Permission",
+		admin_option AS "AdminOption"
+	FROM svv_relation_privileges
+
+	UNION ALL
+
+	SELECT
+		identity_type AS "IdentityType",
+		identity_name AS "IdentityName",
+		'ROUTINE::' || current_database() || '.' || namespace_name || '.' || function_name || '(' || argument_types || ')' AS "Object",
+		privilege_type AS "Permission",
+		admin_option AS "AdminOption"
+	FROM svv_function_privileges
+`
+
+const redshiftOwnersQuery = `
+	SELECT
+		'user' AS "IdentityType",
+		pg_get_userbyid(n.nspowner) AS "IdentityName",
+		'SCHEMA::' || current_database() || '.' || n.nspname AS "Object",
+		'OWNER' AS "Permission",
+		false AS "AdminOption"
+	FROM pg_namespace AS n
+	WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_%'
+
+	UNION ALL
+
+	SELECT
+		'user' AS "IdentityType",
+		pg_get_userbyid(c.relowner) AS "IdentityName",
+		'TBL::' || current_database() || '.' || n.nspname || '.' || c.relname AS "Object",
+		'OWNER' AS "Permission",
+		false AS "AdminOption"
+	FROM pg_class AS c
+	INNER JOIN pg_namespace AS n
+		ON n.oid = c.relnamespace
+	WHERE c.relkind IN ('r', 'v')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_%'
+`
+
+// System privileges granted to roles.
+const redshiftSystemPrivilegesQuery = `
+	SELECT
+		identity_type AS "IdentityType",
+		identity_name AS "IdentityName",
+		'SYSTEM' AS "Object",
+		system_privilege AS "Permission",
+		false AS "AdminOption"
+	FROM svv_system_privileges
+`
+
+func (c *EtlRedshiftConnectorUser) listUsers() ([]redshiftUser, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(`
+		SELECT
+			usename AS "Username",
+			usesysid AS "UserId",
+			usesuper AS "Super",
+			usecreatedb AS "CreateDb",
+			valuntil::varchar AS "ValidUntil"
+		FROM pg_user
+		ORDER BY usename
+	`)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	source.AddCommand(cmd)
+
+	users := []redshiftUser{}
+	for rows.Next() {
+		u := redshiftUser{}
+		err = rows.StructScan(&u)
+		if err != nil {
+			return nil, nil, err
+		}
+		users = append(users, u)
+	}
+	return users, source, nil
+}
+
+func (c *EtlRedshiftConnectorUser) listMemberships(query string) ([]redshiftMembership, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	source.AddCommand(cmd)
+
+	memberships := []redshiftMembership{}
+	for rows.Next() {
+		m := redshiftMembership{}
+		err = rows.StructScan(&m)
+		if err != nil {
+			return nil, nil, err
+		}
+		memberships = append(memberships, m)
+	}
+	return memberships, source, nil
+}
+
+func (c *EtlRedshiftConnectorUser) listPrivileges(query string, privileges redshiftPrivilegeMap) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	rows, cmd, err := c.db.LoggedQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	source.AddCommand(cmd)
+
+	for rows.Next() {
+		p := redshiftPrivilege{}
+		err = rows.StructScan(&p)
+		if err != nil {
+			return nil, err
+		}
+		privileges.add(p)
+	}
+	return source, nil
+}
+
+func groupMemberships(memberships []redshiftMembership) map[string][]string {
+	ret := map[string][]string{}
+	for _, m := range memberships {
+		ret[m.Member] = append(ret[m.Member], m.Parent)
+	}
+	return ret
+}
+
+// Obtain users and what groups/roles/privileges they have.
+// Privileges can be granted to users, groups, roles (which can be granted to other roles) and PUBLIC.
+// Every group and role the user has (directly or through another role) is listed with the privileges
+// granted to it. Ownership of schemas, tables and views is listed along with the user's own privileges.
+// Requires a cluster with role-based access control (the svv_*_privileges views, roles and system privileges).
+func (c *EtlRedshiftConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	users, source, err := c.listUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	groups, source, err := c.listMemberships(`
+		SELECT
+			u.usename AS "Member",
+			g.groname AS "Parent"
+		FROM pg_group AS g
+		INNER JOIN pg_user AS u
+			ON u.usesysid = ANY(g.grolist)
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	// The svv_*_privileges views, roles and system privileges were all added with role-based access control so
+	// clusters older than that aren't supported.
+	privileges := redshiftPrivilegeMap{}
+	for _, query := range []string{redshiftPrivilegesQuery, redshiftOwnersQuery} {
+		source, err = c.listPrivileges(query, privileges)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+	}
+
+	source, err = c.listPrivileges(redshiftSystemPrivilegesQuery, privileges)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	userRoles, source, err := c.listMemberships(`
+		SELECT
+			user_name AS "Member",
+			role_name AS "Parent"
+		FROM svv_user_grants
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	roleRoles, source, err := c.listMemberships(`
+		SELECT
+			role_name AS "Member",
+			granted_role_name AS "Parent"
+		FROM svv_role_grants
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	userGroupMap := groupMemberships(groups)
+	userRoleMap := groupMemberships(userRoles)
+	roleRoleMap := groupMemberships(roleRoles)
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range users {
+		user := &types.EtlUser{
+			Username: u.Username,
+			Roles:    map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				RedshiftAttributeUserId: strconv.FormatInt(u.UserId, 10),
+			},
+		}
+
+		if u.ValidUntil.Valid {
+			user.Attributes[RedshiftAttributeValidUntil] = u.ValidUntil.String
+		}
+
+		selfPermissions := []string{}
+		if u.Super {
+			selfPermissions = append(selfPermissions, RedshiftSuperPermission)
+		}
+
+		if u.CreateDb {
+			selfPermissions = append(selfPermissions, RedshiftCreateDbPermission)
+		}
+
+		self := &types.EtlRole{
+			Name: "Self",
+			Permissions: map[string][]string{
+				"Self": selfPermissions,
+			},
+		}
+
+		for object, permissions := range privileges.get(redshiftIdentityUser, u.Username) {
+			self.Permissions[object] = permissions
+		}
+		user.Roles["Self"] = self
+
+		user.Roles[RedshiftPublicRole] = &types.EtlRole{
+			Name:        RedshiftPublicRole,
+			Permissions: privileges.get(redshiftIdentityPublic, ""),
+		}
+
+		for _, group := range userGroupMap[u.Username] {
+			name := RedshiftGroupRolePrefix + group
+			user.Roles[name] = &types.EtlRole{
+				Name:        name,
+				Permissions: privileges.get(redshiftIdentityGroup, group),
+			}
+		}
+
+		queue := append([]string{}, userRoleMap[u.Username]...)
+		for len(queue) > 0 {
+			role := queue[0]
+			queue = queue[1:]
+
+			if _, ok := user.Roles[role]; ok {
+				continue
+			}
+
+			user.Roles[role] = &types.EtlRole{
+				Name:        role,
+				Permissions: privileges.get(redshiftIdentityRole, role),
+			}
+			queue = append(queue, roleRoleMap[role]...)
+		}
+
+		retUsers = append(retUsers, user)
+	}
+
+	return retUsers, finalSource, nil
+}
@@ -0,0 +1,30 @@
+package snowflake
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+)
+
+type EtlSnowflakeConnector struct {
+	db    databases.SqlxLike
+	users *EtlSnowflakeConnectorUser
+}
+
+func (c *EtlSnowflakeConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateSnowflakeConnector(db databases.SqlxLike) (*EtlSnowflakeConnector, error) {
+	var err error
+	ret := EtlSnowflakeConnector{
+		db: db,
+	}
+	ret.users, err = createSnowflakeConnectorUser(&databases.DB{
+		SqlxLike: db,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,92 @@
+package snowflake
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"strings"
+	"time"
+)
+
+// SHOW commands don't allow picking the columns that are returned and Snowflake adds columns to
+// them over time so rows are read into maps rather than structs.
+type showRow map[string]interface{}
+
+func (r showRow) String(column string) string {
+	switch v := r[column].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func (r showRow) Bool(column string) bool {
+	switch v := r[column].(type) {
+	case bool:
+		return v
+	default:
+		return strings.EqualFold(r.String(column), "true")
+	}
+}
+
+func (r showRow) Time(column string) *time.Time {
+	switch v := r[column].(type) {
+	case time.Time:
+		return &v
+	default:
+		str := r.String(column)
+		if str == "" {
+			return nil
+		}
+
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700"} {
+			t, err := time.Parse(layout, str)
+			if err == nil {
+				return &t
+			}
+		}
+		return nil
+	}
+}
+
+// quoteIdentifier quotes the name so that it's used as is (i.e. case sensitive) since SHOW
+// commands don't take bind parameters.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func runShowCommand(db *databases.DB, command string) ([]showRow, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	rows, cmd, err := db.LoggedQuery(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	source.AddCommand(cmd)
+
+	results := []showRow{}
+	for rows.Next() {
+		row := map[string]interface{}{}
+		err = rows.MapScan(row)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Column names are lower case but normalize them in case the session is configured to
+		// quote identifiers differently.
+		normalized := showRow{}
+		for k, v := range row {
+			normalized[strings.ToLower(k)] = v
+		}
+		results = append(results, normalized)
+	}
+
+	return results, source, rows.Err()
+}
@@ -0,0 +1,192 @@
+package snowflake
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+)
+
+// SnowflakePublicRole is granted to every user implicitly.
+const SnowflakePublicRole = "PUBLIC"
+
+const (
+	SnowflakeAttributeLoginName        = "login_name"
+	SnowflakeAttributeDisabled         = "disabled"
+	SnowflakeAttributeDefaultRole      = "default_role"
+	SnowflakeAttributeHasPassword      = "has_password"
+	SnowflakeAttributeHasRsaPublicKey  = "has_rsa_public_key"
+	SnowflakeAttributeMfa              = "ext_authn_duo"
+	SnowflakeAttributeLastSuccessLogin = "last_success_login"
+	SnowflakeAttributeLockedUntil      = "locked_until_time"
+	SnowflakeAttributeExpiresAt        = "expires_at_time"
+)
+
+var snowflakeUserAttributes = []string{
+	SnowflakeAttributeLoginName,
+	SnowflakeAttributeDisabled,
+	SnowflakeAttributeDefaultRole,
+	SnowflakeAttributeHasPassword,
+	SnowflakeAttributeHasRsaPublicKey,
+	SnowflakeAttributeMfa,
+	SnowflakeAttributeLastSuccessLogin,
+	SnowflakeAttributeLockedUntil,
+	SnowflakeAttributeExpiresAt,
+}
+
+type EtlSnowflakeConnectorUser struct {
+	db *databases.DB
+}
+
+func createSnowflakeConnectorUser(db *databases.DB) (*EtlSnowflakeConnectorUser, error) {
+	return &EtlSnowflakeConnectorUser{
+		db: db,
+	}, nil
+}
+
+type snowflakeRole struct {
+	role *types.EtlRole
+	// The roles that were granted to this role (i.e. whose privileges this role inherits).
+	grantedRoles []string
+}
+
+func (c *EtlSnowflakeConnectorUser) listRoles() (map[string]*snowflakeRole, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+	rows, source, err := runShowCommand(c.db, "SHOW ROLES")
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	roles := map[string]*snowflakeRole{}
+	for _, r := range rows {
+		name := r.String("name")
+		roles[name] = &snowflakeRole{
+			role: &types.EtlRole{
+				Name:        name,
+				Permissions: map[string][]string{},
+			},
+			grantedRoles: []string{},
+		}
+	}
+
+	names := []string{}
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		role := roles[name]
+		grants, source, err := runShowCommand(c.db, "SHOW GRANTS TO ROLE "+quoteIdentifier(name))
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+
+		for _, g := range grants {
+			grantedOn := g.String("granted_on")
+			privilege := g.String("privilege")
+			object := g.String("name")
+
+			// Granting a role to another role is reported as the USAGE privilege on the granted role.
+			if grantedOn == "ROLE" && privilege == "USAGE" {
+				role.grantedRoles = append(role.grantedRoles, object)
+				continue
+			}
+
+			if g.Bool("grant_option") {
+				privilege = privilege + " WITH GRANT OPTION"
+			}
+
+			key := grantedOn + "::" + object
+			role.role.Permissions[key] = append(role.role.Permissions[key], privilege)
+		}
+	}
+
+	return roles, finalSource, nil
+}
+
+func (c *EtlSnowflakeConnectorUser) listUserRoles(username string) ([]string, *connectors.EtlSourceInfo, error) {
+	rows, source, err := runShowCommand(c.db, "SHOW GRANTS TO USER "+quoteIdentifier(username))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roles := []string{}
+	for _, r := range rows {
+		roles = append(roles, r.String("role"))
+	}
+	return roles, source, nil
+}
+
+// addRoles adds the roles and every role they inherit from to the user.
+func addRoles(user *types.EtlUser, roleNames []string, roles map[string]*snowflakeRole) {
+	queue := append([]string{}, roleNames...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if _, ok := user.Roles[name]; ok {
+			continue
+		}
+
+		role, ok := roles[name]
+		if !ok {
+			continue
+		}
+
+		user.Roles[name] = role.role
+		queue = append(queue, role.grantedRoles...)
+	}
+}
+
+// Obtain users and what roles/privileges they have.
+// Users are granted roles which in turn can be granted other roles. Every role a user has (directly or
+// through another role) is listed with the privileges that were granted to it on objects (account,
+// databases, schemas, tables, warehouses, etc.). Snowflake doesn't allow granting privileges on objects
+// to users directly.
+func (c *EtlSnowflakeConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	rows, source, err := runShowCommand(c.db, "SHOW USERS")
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	roles, source, err := c.listRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	retUsers := []*types.EtlUser{}
+	for _, r := range rows {
+		user := &types.EtlUser{
+			Username:    r.String("name"),
+			FullName:    r.String("display_name"),
+			Email:       r.String("email"),
+			CreatedTime: r.Time("created_on"),
+			Roles:       map[string]*types.EtlRole{},
+			Attributes:  map[string]string{},
+		}
+
+		for _, attr := range snowflakeUserAttributes {
+			if _, ok := r[attr]; ok {
+				user.Attributes[attr] = r.String(attr)
+			}
+		}
+
+		userRoles, source, err := c.listUserRoles(user.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+
+		addRoles(user, append(userRoles, SnowflakePublicRole), roles)
+		retUsers = append(retUsers, user)
+	}
+
+	return retUsers, finalSource, nil
+}
@@ -11,11 +11,40 @@ type SqlxLike interface {
 	MustBegin() *sqlx.Tx
 }
 
+// Rows is what connectors need to read the results of a query. *sqlx.Rows implements it.
+type Rows interface {
+	Next() bool
+	Scan(...interface{}) error
+	StructScan(interface{}) error
+	MapScan(map[string]interface{}) error
+	Err() error
+	Close() error
+}
+
+// RowsQueryer is implemented by databases that bound how long a query (including reading its results) may
+// take such as connection.Connection. The rows need to be closed to release what bounds the query.
+type RowsQueryer interface {
+	QueryRows(string, ...interface{}) (Rows, error)
+}
+
+// Query runs the query through QueryRows if the database implements it and Queryx otherwise.
+func Query(db SqlxLike, query string, args ...interface{}) (Rows, error) {
+	if queryer, ok := db.(RowsQueryer); ok {
+		return queryer.QueryRows(query, args...)
+	}
+
+	rows, err := db.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
 type DB struct {
 	SqlxLike
 }
 
-func (d *DB) LoggedQuery(query string, args ...interface{}) (*sqlx.Rows, *connectors.EtlCommandInfo, error) {
+func (d *DB) LoggedQuery(query string, args ...interface{}) (Rows, *connectors.EtlCommandInfo, error) {
 	params := map[string]interface{}{}
 	cmd := connectors.EtlCommandInfo{
 		Command:    query,
@@ -26,6 +55,6 @@ func (d *DB) LoggedQuery(query string, args ...interface{}) (*sqlx.Rows, *connec
 		params[strconv.Itoa(i+1)] = val
 	}
 
-	rows, err := d.Queryx(query, args...)
+	rows, err := Query(d.SqlxLike, query, args...)
 	return rows, &cmd, err
 }
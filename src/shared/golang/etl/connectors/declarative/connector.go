@@ -0,0 +1,88 @@
+package declarative
+
+import (
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/auth"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+// EtlDeclarativeCredentials holds the secrets for the spec's authentication type.
+type EtlDeclarativeCredentials struct {
+	// The token for bearer or the key for api_key.
+	Token string
+	// For basic.
+	Username string
+	Password string
+	// For oauth2_client_credentials.
+	ClientId     string
+	ClientSecret string
+}
+
+type EtlDeclarativeOptions struct {
+	Spec        *EtlDeclarativeSpec
+	Credentials EtlDeclarativeCredentials
+	// Used instead of creating a client from the spec's authentication type if set.
+	Client http_utility.HttpClient
+}
+
+func (o EtlDeclarativeOptions) apiBaseUrl() string {
+	return strings.TrimSuffix(o.Spec.BaseUrl, "/")
+}
+
+func createDeclarativeHttpClient(spec EtlDeclarativeAuthSpec, creds EtlDeclarativeCredentials) (http_utility.HttpClient, error) {
+	switch spec.Type {
+	case "", AuthTypeNone:
+		return http_utility.CreateHeaderInjectionClient(map[string]string{}, nil), nil
+	case AuthTypeBearer:
+		return auth_utility.CreateBearerTokenHttpClient(creds.Token), nil
+	case AuthTypeApiKey:
+		return auth_utility.CreateApiKeyHttpClient(spec.Header, spec.Prefix, creds.Token), nil
+	case AuthTypeBasic:
+		return auth_utility.CreateBasicAuthHttpClient(creds.Username, creds.Password), nil
+	case AuthTypeOAuth2ClientCredentials:
+		ts := auth_utility.CreateClientCredentialsTokenSource(spec.TokenUrl, creds.ClientId, creds.ClientSecret, spec.Scopes...)
+		return http_utility.CreateOAuth2AuthorizedClient(ts), nil
+	}
+
+	return nil, errors.New("Unknown authentication type: " + spec.Type)
+}
+
+type EtlDeclarativeConnector struct {
+	opts  *EtlDeclarativeOptions
+	users *EtlDeclarativeConnectorUser
+}
+
+func (c *EtlDeclarativeConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateDeclarativeConnector(opts *EtlDeclarativeOptions) (*EtlDeclarativeConnector, error) {
+	if opts.Spec == nil {
+		return nil, errors.New("The declarative connector needs a spec.")
+	}
+
+	err := opts.Spec.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Client == nil {
+		opts.Client, err = createDeclarativeHttpClient(opts.Spec.Auth, opts.Credentials)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ret := EtlDeclarativeConnector{
+		opts: opts,
+	}
+	ret.users, err = createDeclarativeConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,220 @@
+package declarative
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type jsonPathStepKind int
+
+const (
+	jsonPathField jsonPathStepKind = iota
+	jsonPathIndex
+	jsonPathWildcard
+)
+
+type jsonPathStep struct {
+	kind  jsonPathStepKind
+	field string
+	index int
+}
+
+// jsonPath is the subset of JSONPath that's needed to pick values out of API responses: $ for the root,
+// .field or ['field'] for fields, [n] for array indices (negative from the end) and [*] or .* for every
+// element/value.
+type jsonPath struct {
+	expr  string
+	steps []jsonPathStep
+}
+
+func isJsonPath(expr string) bool {
+	return strings.HasPrefix(expr, "$")
+}
+
+func compileJsonPath(expr string) (*jsonPath, error) {
+	if !isJsonPath(expr) {
+		return nil, errors.New("JSONPath must start with $: " + expr)
+	}
+
+	path := &jsonPath{
+		expr:  expr,
+		steps: []jsonPathStep{},
+	}
+
+	invalid := func(reason string) error {
+		return fmt.Errorf("Invalid JSONPath %s: %s", expr, reason)
+	}
+
+	rest := expr[1:]
+	for len(rest) > 0 {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, invalid("recursive descent isn't supported")
+		case strings.HasPrefix(rest, ".*"):
+			path.steps = append(path.steps, jsonPathStep{kind: jsonPathWildcard})
+			rest = rest[2:]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+
+			field := rest[1 : end+1]
+			if field == "" {
+				return nil, invalid("empty field name")
+			}
+
+			path.steps = append(path.steps, jsonPathStep{kind: jsonPathField, field: field})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, invalid("missing ]")
+			}
+
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			switch {
+			case inner == "*":
+				path.steps = append(path.steps, jsonPathStep{kind: jsonPathWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path.steps = append(path.steps, jsonPathStep{kind: jsonPathField, field: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, invalid("unsupported subscript " + inner)
+				}
+				path.steps = append(path.steps, jsonPathStep{kind: jsonPathIndex, index: index})
+			}
+		default:
+			return nil, invalid("unexpected " + rest)
+		}
+	}
+
+	return path, nil
+}
+
+// evaluate returns every value that the path matches. Missing fields and out of range indices match nothing.
+func (p *jsonPath) evaluate(root interface{}) []interface{} {
+	current := []interface{}{root}
+
+	for _, step := range p.steps {
+		next := []interface{}{}
+		for _, value := range current {
+			switch step.kind {
+			case jsonPathField:
+				if obj, ok := value.(map[string]interface{}); ok {
+					if v, ok := obj[step.field]; ok {
+						next = append(next, v)
+					}
+				}
+			case jsonPathIndex:
+				if arr, ok := value.([]interface{}); ok {
+					idx := step.index
+					if idx < 0 {
+						idx += len(arr)
+					}
+
+					if idx >= 0 && idx < len(arr) {
+						next = append(next, arr[idx])
+					}
+				}
+			case jsonPathWildcard:
+				switch v := value.(type) {
+				case []interface{}:
+					next = append(next, v...)
+				case map[string]interface{}:
+					keys := sortedKeys(v)
+					for _, k := range keys {
+						next = append(next, v[k])
+					}
+				}
+			}
+		}
+		current = next
+	}
+
+	return current
+}
+
+// resolveValues returns the values that the expression picks out of the data. Expressions that aren't a
+// JSONPath (don't start with $) are literals.
+func resolveValues(expr string, data interface{}) ([]interface{}, error) {
+	if expr == "" {
+		return []interface{}{}, nil
+	}
+
+	if !isJsonPath(expr) {
+		return []interface{}{expr}, nil
+	}
+
+	path, err := compileJsonPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{}
+	for _, v := range path.evaluate(data) {
+		// A missing value and an explicit null are treated the same.
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// resolveStrings is like resolveValues but converts the values to strings.
+func resolveStrings(expr string, data interface{}) ([]string, error) {
+	values, err := resolveValues(expr, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []string{}
+	for _, v := range values {
+		ret = append(ret, formatValue(v))
+	}
+	return ret, nil
+}
+
+// resolveString returns the first value that the expression picks out of the data or an empty string.
+func resolveString(expr string, data interface{}) (string, error) {
+	values, err := resolveStrings(expr, data)
+	if err != nil {
+		return "", err
+	}
+
+	if len(values) == 0 {
+		return "", nil
+	}
+	return values[0], nil
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case []interface{}:
+		values := []string{}
+		for _, vv := range v {
+			values = append(values, formatValue(vv))
+		}
+		return strings.Join(values, ",")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,278 @@
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+)
+
+const (
+	AuthTypeNone                    = "none"
+	AuthTypeBearer                  = "bearer"
+	AuthTypeApiKey                  = "api_key"
+	AuthTypeBasic                   = "basic"
+	AuthTypeOAuth2ClientCredentials = "oauth2_client_credentials"
+)
+
+const (
+	PaginationTypeNone   = "none"
+	PaginationTypeLink   = "link"
+	PaginationTypeCursor = "cursor"
+	PaginationTypePage   = "page"
+	PaginationTypeOffset = "offset"
+)
+
+// TimeFormatUnix is used for times that are given as seconds since the epoch.
+const TimeFormatUnix = "unix"
+
+const defaultMaxPages = 1000
+
+// EtlDeclarativeSpec describes how to get users from a REST API. Specs are written in YAML (or JSON) and every
+// value that picks something out of a response is a JSONPath (e.g. $.data[*]); values that don't start with $
+// are used as is.
+type EtlDeclarativeSpec struct {
+	Name    string `yaml:"name"`
+	BaseUrl string `yaml:"baseUrl"`
+	// Sent with every request (e.g. an API version).
+	Headers map[string]string      `yaml:"headers"`
+	Auth    EtlDeclarativeAuthSpec `yaml:"auth"`
+	Users   EtlDeclarativeUserSpec `yaml:"users"`
+}
+
+// EtlDeclarativeAuthSpec says how to authenticate. The secrets themselves are passed in separately (see
+// EtlDeclarativeCredentials) so that specs can be shared.
+type EtlDeclarativeAuthSpec struct {
+	// One of the AuthType constants. Defaults to none.
+	Type string `yaml:"type"`
+	// The header and value prefix for api_key (e.g. X-API-Key or Authorization with "Token ").
+	Header string `yaml:"header"`
+	Prefix string `yaml:"prefix"`
+	// For oauth2_client_credentials.
+	TokenUrl string   `yaml:"tokenUrl"`
+	Scopes   []string `yaml:"scopes"`
+}
+
+type EtlDeclarativePaginationSpec struct {
+	// One of the PaginationType constants. Defaults to none.
+	//	- link: follows the next link in the Link header.
+	//	- cursor: passes the cursor from the previous response in the param until there's none.
+	//	- page: increments the page number in the param (starting at Start which defaults to 1).
+	//	- offset: increments the offset in the param (starting at 0) by the number of items received.
+	Type  string `yaml:"type"`
+	Param string `yaml:"param"`
+	// The query parameter for the page size and the size to request.
+	SizeParam string `yaml:"sizeParam"`
+	Size      int    `yaml:"size"`
+	// The first page number for APIs whose pages don't start at 1.
+	Start *int `yaml:"start"`
+	// The JSONPath of the next cursor.
+	Cursor string `yaml:"cursor"`
+	// The JSONPath of the total number of pages (page) or items (offset). Otherwise paging stops once a page
+	// has fewer than Size items.
+	Total string `yaml:"total"`
+	// Guards against APIs that never stop returning pages. Defaults to 1000.
+	MaxPages int `yaml:"maxPages"`
+}
+
+type EtlDeclarativeEndpointSpec struct {
+	// Relative to the base URL. Endpoints that are requested per user can use JSONPaths relative to the user
+	// in braces (e.g. /users/{$.id}/roles).
+	Path  string            `yaml:"path"`
+	Query map[string]string `yaml:"query"`
+	// The JSONPath of the items in the response. An array that's matched is flattened so $.data and $.data[*]
+	// are the same. Defaults to the whole response.
+	Items      string                       `yaml:"items"`
+	Pagination EtlDeclarativePaginationSpec `yaml:"pagination"`
+}
+
+type EtlDeclarativeUserFieldSpec struct {
+	Username       string `yaml:"username"`
+	FullName       string `yaml:"fullName"`
+	Email          string `yaml:"email"`
+	CreatedTime    string `yaml:"createdTime"`
+	LastChangeTime string `yaml:"lastChangeTime"`
+	// The Go layout of the times or unix. Defaults to RFC 3339.
+	TimeFormat string            `yaml:"timeFormat"`
+	Attributes map[string]string `yaml:"attributes"`
+}
+
+type EtlDeclarativePermissionSpec struct {
+	// The JSONPath of the permissions relative to the role. Defaults to the role itself.
+	Items string `yaml:"items"`
+	// What the permissions apply to (e.g. $.resource or Self) and the permissions (e.g. $.actions[*]).
+	Object  string `yaml:"object"`
+	Actions string `yaml:"actions"`
+	// Lists the permissions as denied instead of allowed.
+	Denied bool `yaml:"denied"`
+}
+
+type EtlDeclarativeRoleSpec struct {
+	// The JSONPath of the roles relative to the user (e.g. $.roles[*]). Ignored if the roles are requested from
+	// an endpoint in which case the endpoint's items are the roles.
+	Items    string                      `yaml:"items"`
+	Endpoint *EtlDeclarativeEndpointSpec `yaml:"endpoint"`
+	// The role name relative to the role (e.g. $.name or $ for roles that are strings).
+	Name        string                         `yaml:"name"`
+	Permissions []EtlDeclarativePermissionSpec `yaml:"permissions"`
+}
+
+type EtlDeclarativeUserSpec struct {
+	EtlDeclarativeEndpointSpec `yaml:",inline"`
+	Fields                     EtlDeclarativeUserFieldSpec `yaml:"fields"`
+	Roles                      []EtlDeclarativeRoleSpec    `yaml:"roles"`
+}
+
+func ParseDeclarativeSpec(data []byte) (*EtlDeclarativeSpec, error) {
+	spec := EtlDeclarativeSpec{}
+	err := yaml.UnmarshalStrict(data, &spec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = spec.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+func LoadDeclarativeSpec(fname string) (*EtlDeclarativeSpec, error) {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeclarativeSpec(data)
+}
+
+func validateExpressions(exprs ...string) error {
+	for _, e := range exprs {
+		if !isJsonPath(e) {
+			continue
+		}
+
+		_, err := compileJsonPath(e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s EtlDeclarativePaginationSpec) validate() error {
+	switch s.Type {
+	case "", PaginationTypeNone, PaginationTypeLink:
+	case PaginationTypeCursor:
+		if s.Param == "" || !isJsonPath(s.Cursor) {
+			return errors.New("Cursor pagination needs a param and the JSONPath of the cursor.")
+		}
+	case PaginationTypePage, PaginationTypeOffset:
+		if s.Param == "" {
+			return fmt.Errorf("%s pagination needs a param.", s.Type)
+		}
+
+		if s.Total == "" && s.Size <= 0 {
+			return fmt.Errorf("%s pagination needs either the page size or the JSONPath of the total.", s.Type)
+		}
+	default:
+		return errors.New("Unknown pagination type: " + s.Type)
+	}
+
+	return validateExpressions(s.Cursor, s.Total)
+}
+
+func (s EtlDeclarativeEndpointSpec) validate() error {
+	if s.Path == "" {
+		return errors.New("Endpoints need a path.")
+	}
+
+	err := s.Pagination.validate()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pathPlaceholders.FindAllStringSubmatch(s.Path, -1) {
+		err = validateExpressions(p[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return validateExpressions(s.Items)
+}
+
+func (s EtlDeclarativeSpec) validate() error {
+	if s.BaseUrl == "" {
+		return errors.New("The spec needs a base URL.")
+	}
+
+	switch s.Auth.Type {
+	case "", AuthTypeNone, AuthTypeBearer, AuthTypeBasic:
+	case AuthTypeApiKey:
+		if s.Auth.Header == "" {
+			return errors.New("API key authentication needs a header.")
+		}
+	case AuthTypeOAuth2ClientCredentials:
+		if s.Auth.TokenUrl == "" {
+			return errors.New("OAuth2 client credentials authentication needs a token URL.")
+		}
+	default:
+		return errors.New("Unknown authentication type: " + s.Auth.Type)
+	}
+
+	err := s.Users.EtlDeclarativeEndpointSpec.validate()
+	if err != nil {
+		return err
+	}
+
+	fields := s.Users.Fields
+	if fields.Username == "" {
+		return errors.New("The spec needs a username field.")
+	}
+
+	err = validateExpressions(fields.Username, fields.FullName, fields.Email, fields.CreatedTime, fields.LastChangeTime)
+	if err != nil {
+		return err
+	}
+
+	for _, expr := range fields.Attributes {
+		err = validateExpressions(expr)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, role := range s.Users.Roles {
+		if role.Name == "" {
+			return errors.New("Roles need a name.")
+		}
+
+		if role.Endpoint != nil {
+			err = role.Endpoint.validate()
+		} else if role.Items == "" {
+			err = errors.New("Roles need either items or an endpoint.")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = validateExpressions(role.Items, role.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, perm := range role.Permissions {
+			if perm.Object == "" || perm.Actions == "" {
+				return errors.New("Permissions need an object and actions.")
+			}
+
+			err = validateExpressions(perm.Items, perm.Object, perm.Actions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
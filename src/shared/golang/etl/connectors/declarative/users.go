@@ -0,0 +1,282 @@
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"strconv"
+	"time"
+)
+
+type EtlDeclarativeConnectorUser struct {
+	opts *EtlDeclarativeOptions
+}
+
+func createDeclarativeConnectorUser(opts *EtlDeclarativeOptions) (*EtlDeclarativeConnectorUser, error) {
+	return &EtlDeclarativeConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlDeclarativeConnectorUser) getItems(spec EtlDeclarativeEndpointSpec, data interface{}) ([]interface{}, *connectors.EtlSourceInfo, error) {
+	path, err := expandPath(spec.Path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s%s", c.opts.apiBaseUrl(), path)
+	return declarativePaginatedGet(c.opts.Client, c.opts.Spec.Headers, endpoint, spec)
+}
+
+func parseTime(format string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var tm time.Time
+	var err error
+
+	switch format {
+	case "":
+		tm, err = time.Parse(time.RFC3339, value)
+	case TimeFormatUnix:
+		var seconds float64
+		seconds, err = strconv.ParseFloat(value, 64)
+		tm = time.Unix(int64(seconds), 0).UTC()
+	default:
+		tm, err = time.Parse(format, value)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &tm, nil
+}
+
+func (c *EtlDeclarativeConnectorUser) createUser(item interface{}) (*types.EtlUser, error) {
+	fields := c.opts.Spec.Users.Fields
+	user := &types.EtlUser{
+		Roles:      map[string]*types.EtlRole{},
+		Attributes: map[string]string{},
+	}
+
+	var err error
+	user.Username, err = resolveString(fields.Username, item)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Username == "" {
+		return nil, errors.New("No username for user: " + formatValue(item))
+	}
+
+	user.FullName, err = resolveString(fields.FullName, item)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Email, err = resolveString(fields.Email, item)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range []struct {
+		expr string
+		out  **time.Time
+	}{
+		{fields.CreatedTime, &user.CreatedTime},
+		{fields.LastChangeTime, &user.LastChangeTime},
+	} {
+		value, err := resolveString(t.expr, item)
+		if err != nil {
+			return nil, err
+		}
+
+		*t.out, err = parseTime(fields.TimeFormat, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for name, expr := range fields.Attributes {
+		values, err := resolveValues(expr, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(values) == 1 {
+			user.Attributes[name] = formatValue(values[0])
+		} else if len(values) > 1 {
+			user.Attributes[name] = formatValue(values)
+		}
+	}
+
+	return user, nil
+}
+
+func addPermissions(permissions types.PermissionMap, object string, actions []string) {
+	existing := permissions[object]
+
+ACTION:
+	for _, a := range actions {
+		for _, e := range existing {
+			if e == a {
+				continue ACTION
+			}
+		}
+		existing = append(existing, a)
+	}
+	permissions[object] = existing
+}
+
+// addRoles adds the roles that are picked out of the role items to the user. Roles with the same name
+// (e.g. from different role specs) are merged.
+func addRoles(user *types.EtlUser, spec EtlDeclarativeRoleSpec, items []interface{}) error {
+	for _, item := range items {
+		name, err := resolveString(spec.Name, item)
+		if err != nil {
+			return err
+		}
+
+		if name == "" {
+			continue
+		}
+
+		role, ok := user.Roles[name]
+		if !ok {
+			role = &types.EtlRole{
+				Name:        name,
+				Permissions: map[string][]string{},
+			}
+			user.Roles[name] = role
+		}
+
+		for _, permSpec := range spec.Permissions {
+			permItems := []interface{}{item}
+			if permSpec.Items != "" {
+				permItems, err = resolveValues(permSpec.Items, item)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, p := range permItems {
+				objects, err := resolveStrings(permSpec.Object, p)
+				if err != nil {
+					return err
+				}
+
+				actions, err := resolveStrings(permSpec.Actions, p)
+				if err != nil {
+					return err
+				}
+
+				if len(actions) == 0 {
+					continue
+				}
+
+				target := role.Permissions
+				if permSpec.Denied {
+					if role.Denied == nil {
+						role.Denied = map[string][]string{}
+					}
+					target = role.Denied
+				}
+
+				for _, o := range objects {
+					addPermissions(target, o, actions)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+type declarativeGetRolesJob struct {
+	// Input
+	User      interface{}
+	Spec      EtlDeclarativeEndpointSpec
+	Connector *EtlDeclarativeConnectorUser
+
+	// Output
+	Roles  []interface{}
+	Source *connectors.EtlSourceInfo
+}
+
+func (j *declarativeGetRolesJob) Do() error {
+	var err error
+	j.Roles, j.Source, err = j.Connector.getItems(j.Spec, j.User)
+	return err
+}
+
+// Obtain users and their roles as described by the spec.
+// Users are listed by the users endpoint. Roles are either part of the users or listed by an endpoint per user
+// with their permissions picked out of each role.
+func (c *EtlDeclarativeConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+	spec := c.opts.Spec.Users
+
+	items, source, err := c.getItems(spec.EtlDeclarativeEndpointSpec, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	retUsers := []*types.EtlUser{}
+	for _, item := range items {
+		user, err := c.createUser(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		retUsers = append(retUsers, user)
+	}
+
+	for _, roleSpec := range spec.Roles {
+		if roleSpec.Endpoint == nil {
+			for idx, item := range items {
+				roleItems, err := resolveValues(roleSpec.Items, item)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				err = addRoles(retUsers[idx], roleSpec, roleItems)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			continue
+		}
+
+		jobs := []*declarativeGetRolesJob{}
+		pool := mt.NewTaskPool(10)
+		for _, item := range items {
+			job := &declarativeGetRolesJob{
+				User:      item,
+				Spec:      *roleSpec.Endpoint,
+				Connector: c,
+			}
+			jobs = append(jobs, job)
+			pool.AddJob(job)
+		}
+
+		err = pool.SyncExecute()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Merged in order so that the commands are in the same order as the users.
+		for idx, job := range jobs {
+			finalSource.MergeWith(job.Source)
+
+			err = addRoles(retUsers[idx], roleSpec, job.Roles)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return retUsers, finalSource, nil
+}
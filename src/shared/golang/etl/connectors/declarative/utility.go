@@ -0,0 +1,256 @@
+package declarative
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"gitlab.com/grchive/grchive-v3/shared/utility/linkheader"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var pathPlaceholders = regexp.MustCompile(`\{(\$[^}]*)\}`)
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// expandPath replaces the JSONPaths in braces with the (escaped) values from the data.
+func expandPath(path string, data interface{}) (string, error) {
+	var err error
+	expanded := pathPlaceholders.ReplaceAllStringFunc(path, func(match string) string {
+		value, resolveErr := resolveString(match[1:len(match)-1], data)
+		if resolveErr != nil {
+			err = resolveErr
+		} else if value == "" {
+			err = errors.New("No value for " + match + " in " + path)
+		}
+		return url.PathEscape(value)
+	})
+
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+func declarativeGet(client http_utility.HttpClient, endpoint string, headers map[string]string) (*http.Response, interface{}, *connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, nil, errors.New("API Error: " + string(bodyData))
+	}
+
+	// Numbers are kept as is so that large ids don't lose precision.
+	var body interface{}
+	decoder := json.NewDecoder(bytes.NewReader(bodyData))
+	decoder.UseNumber()
+	err = decoder.Decode(&body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return resp, body, source, nil
+}
+
+// getItems returns the items in the response. An array that the path matches is flattened.
+func getItems(itemsPath string, body interface{}) ([]interface{}, error) {
+	if itemsPath == "" {
+		itemsPath = "$"
+	}
+
+	values, err := resolveValues(itemsPath, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 1 {
+		if arr, ok := values[0].([]interface{}); ok {
+			return arr, nil
+		}
+	}
+	return values, nil
+}
+
+// resolveInt returns the first value that the expression picks out of the data as an integer, if there's one.
+func resolveInt(expr string, data interface{}) (int, bool, error) {
+	value, err := resolveString(expr, data)
+	if err != nil || value == "" {
+		return 0, false, err
+	}
+
+	num, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("Expected a number for %s but got %s.", expr, value)
+	}
+	return int(num), true, nil
+}
+
+// declarativePaginatedGet gets every item of the endpoint by paging through it the way the spec says.
+func declarativePaginatedGet(client http_utility.HttpClient, headers map[string]string, baseEndpoint string, spec EtlDeclarativeEndpointSpec) ([]interface{}, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	items := []interface{}{}
+	pagination := spec.Pagination
+
+	maxPages := pagination.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+
+	baseUrl, err := url.Parse(baseEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := baseUrl.Query()
+	for k, v := range spec.Query {
+		query.Set(k, v)
+	}
+
+	if pagination.SizeParam != "" && pagination.Size > 0 {
+		query.Set(pagination.SizeParam, strconv.Itoa(pagination.Size))
+	}
+
+	// Offsets start at 0 and page numbers at 1 unless the spec says otherwise.
+	page := 0
+	if pagination.Type == PaginationTypePage {
+		page = 1
+		if pagination.Start != nil {
+			page = *pagination.Start
+		}
+	}
+
+	switch pagination.Type {
+	case PaginationTypePage, PaginationTypeOffset:
+		query.Set(pagination.Param, strconv.Itoa(page))
+	}
+
+	baseUrl.RawQuery = query.Encode()
+	endpoint := baseUrl.String()
+
+	for numPages := 1; ; numPages++ {
+		if numPages > maxPages {
+			return nil, nil, fmt.Errorf("Stopped paging through %s after %d pages.", baseEndpoint, maxPages)
+		}
+
+		resp, body, cmdSrc, err := declarativeGet(client, endpoint, headers)
+		if err != nil {
+			return nil, nil, err
+		}
+		source.MergeWith(cmdSrc)
+
+		pageItems, err := getItems(spec.Items, body)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, pageItems...)
+
+		current, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = current.Query()
+
+		switch pagination.Type {
+		case PaginationTypeLink:
+			next := linkheader.ParseLinkHeaderFromHttpResponse(resp).FindLinkWithRel("next")
+			if next == nil || next.Uri == "" {
+				return items, source, nil
+			}
+
+			nextUrl, err := current.Parse(next.Uri)
+			if err != nil {
+				return nil, nil, err
+			}
+			endpoint = nextUrl.String()
+			continue
+		case PaginationTypeCursor:
+			cursor, err := resolveString(pagination.Cursor, body)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if cursor == "" || cursor == query.Get(pagination.Param) {
+				return items, source, nil
+			}
+			query.Set(pagination.Param, cursor)
+		case PaginationTypePage, PaginationTypeOffset:
+			if len(pageItems) == 0 {
+				return items, source, nil
+			}
+
+			total, hasTotal, err := resolveInt(pagination.Total, body)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if pagination.Type == PaginationTypePage {
+				// The total is the number of pages.
+				if hasTotal && numPages >= total {
+					return items, source, nil
+				}
+				page += 1
+			} else {
+				page += len(pageItems)
+				if hasTotal && page >= total {
+					return items, source, nil
+				}
+			}
+
+			if !hasTotal && len(pageItems) < pagination.Size {
+				return items, source, nil
+			}
+			query.Set(pagination.Param, strconv.Itoa(page))
+		default:
+			return items, source, nil
+		}
+
+		current.RawQuery = query.Encode()
+		endpoint = current.String()
+	}
+}
@@ -0,0 +1,41 @@
+package linux
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+)
+
+type EtlLinuxOptions struct {
+	Host LinuxHost
+	// The files sshd reads authorized keys from (i.e. AuthorizedKeysFile in sshd_config). Uses
+	// .ssh/authorized_keys and .ssh/authorized_keys2 in the home directory if empty.
+	AuthorizedKeysFiles []string
+}
+
+func (o EtlLinuxOptions) authorizedKeysFiles() []string {
+	if len(o.AuthorizedKeysFiles) == 0 {
+		return defaultAuthorizedKeysFiles
+	}
+	return o.AuthorizedKeysFiles
+}
+
+type EtlLinuxConnector struct {
+	opts  *EtlLinuxOptions
+	users *EtlLinuxConnectorUser
+}
+
+func (c *EtlLinuxConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateLinuxConnector(opts *EtlLinuxOptions) (*EtlLinuxConnector, error) {
+	var err error
+	ret := EtlLinuxConnector{
+		opts: opts,
+	}
+	ret.users, err = createLinuxConnectorUser(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,242 @@
+package linux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type passwdEntry struct {
+	Name     string
+	Password string
+	Uid      int
+	Gid      int
+	Gecos    string
+	Home     string
+	Shell    string
+}
+
+type shadowEntry struct {
+	Name     string
+	Password string
+	// Days since the epoch. Nil for empty fields.
+	LastChange *int
+	MinDays    *int
+	MaxDays    *int
+	WarnDays   *int
+	Inactive   *int
+	Expire     *int
+}
+
+type groupEntry struct {
+	Name    string
+	Gid     int
+	Members []string
+}
+
+// splitColonFile returns the fields of every line that isn't empty, a comment or a NIS compat
+// entry (+/-) along with its line number.
+func splitColonFile(data string, fname string, numFields int) ([][]string, []int, error) {
+	ret := [][]string{}
+	lines := []int{}
+	for idx, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < numFields {
+			return nil, nil, fmt.Errorf("Invalid %s entry on line %d.", fname, idx+1)
+		}
+		ret = append(ret, fields)
+		lines = append(lines, idx+1)
+	}
+	return ret, lines, nil
+}
+
+func parsePasswd(data string) ([]*passwdEntry, error) {
+	entries, lines, err := splitColonFile(data, "passwd", 7)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*passwdEntry{}
+	for idx, fields := range entries {
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid passwd UID on line %d: %s", lines[idx], fields[2])
+		}
+
+		gid, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid passwd GID on line %d: %s", lines[idx], fields[3])
+		}
+
+		ret = append(ret, &passwdEntry{
+			Name:     fields[0],
+			Password: fields[1],
+			Uid:      uid,
+			Gid:      gid,
+			Gecos:    fields[4],
+			Home:     fields[5],
+			Shell:    fields[6],
+		})
+	}
+	return ret, nil
+}
+
+func parseOptionalDays(value string, line int) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid shadow field on line %d: %s", line, value)
+	}
+	return &days, nil
+}
+
+func parseShadow(data string) (map[string]*shadowEntry, error) {
+	entries, lines, err := splitColonFile(data, "shadow", 8)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]*shadowEntry{}
+	for idx, fields := range entries {
+		entry := shadowEntry{
+			Name:     fields[0],
+			Password: fields[1],
+		}
+
+		for i, days := range []**int{&entry.LastChange, &entry.MinDays, &entry.MaxDays, &entry.WarnDays, &entry.Inactive, &entry.Expire} {
+			*days, err = parseOptionalDays(fields[i+2], lines[idx])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ret[entry.Name] = &entry
+	}
+	return ret, nil
+}
+
+func parseGroup(data string) ([]*groupEntry, error) {
+	entries, lines, err := splitColonFile(data, "group", 4)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*groupEntry{}
+	for idx, fields := range entries {
+		gid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid group GID on line %d: %s", lines[idx], fields[2])
+		}
+
+		members := []string{}
+		for _, m := range strings.Split(fields[3], ",") {
+			m = strings.TrimSpace(m)
+			if m != "" {
+				members = append(members, m)
+			}
+		}
+
+		ret = append(ret, &groupEntry{
+			Name:    fields[0],
+			Gid:     gid,
+			Members: members,
+		})
+	}
+	return ret, nil
+}
+
+func daysToTime(days int) time.Time {
+	return time.Unix(int64(days)*24*60*60, 0).UTC()
+}
+
+// Shadow uses 99999 to mean the password never expires.
+const shadowNeverExpires = 99999
+
+// Password states reported in the password_status attribute.
+const (
+	PasswordStatusSet      = "set"
+	PasswordStatusLocked   = "locked"
+	PasswordStatusDisabled = "disabled"
+	PasswordStatusEmpty    = "empty"
+	PasswordStatusUnknown  = "unknown"
+)
+
+// passwordStatus distinguishes a password hash (set), a hash that was locked with passwd -l or
+// usermod -L (locked), a placeholder such as * or !! that no password can match (disabled) and no
+// password at all (empty).
+func passwordStatus(p *passwdEntry, s *shadowEntry) string {
+	hash := p.Password
+	if hash == passwdShadowedHash {
+		if s == nil {
+			return PasswordStatusUnknown
+		}
+		hash = s.Password
+	}
+	return hashStatus(hash)
+}
+
+func hashStatus(hash string) string {
+	switch {
+	case hash == "":
+		return PasswordStatusEmpty
+	case strings.HasPrefix(hash, "!") && len(strings.TrimLeft(hash, "!")) > 1:
+		return PasswordStatusLocked
+	case strings.HasPrefix(hash, "!") || strings.HasPrefix(hash, "*"):
+		return PasswordStatusDisabled
+	}
+	return PasswordStatusSet
+}
+
+// Replaces password hashes in the passwd and shadow files before they're recorded.
+const redactedHash = "*REDACTED*"
+
+// Passwd uses x in the password field to say that the hash is in the shadow file.
+const passwdShadowedHash = "x"
+
+// redactPasswordHashes replaces every password hash in the passwd or shadow file so the hashes aren't
+// stored with the rest of the source data. Lock markers (!) and placeholders such as * and x are kept
+// since the password status is based on them.
+func redactPasswordHashes(data string) string {
+	lines := strings.Split(data, "\n")
+	for idx, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) < 2 || fields[1] == passwdShadowedHash {
+			continue
+		}
+
+		switch hashStatus(fields[1]) {
+		case PasswordStatusSet:
+			fields[1] = redactedHash
+		case PasswordStatusLocked:
+			fields[1] = strings.Repeat("!", len(fields[1])-len(strings.TrimLeft(fields[1], "!"))) + redactedHash
+		default:
+			continue
+		}
+		lines[idx] = strings.Join(fields, ":")
+	}
+	return strings.Join(lines, "\n")
+}
+
+var nologinShells = map[string]bool{
+	"/sbin/nologin":     true,
+	"/usr/sbin/nologin": true,
+	"/bin/false":        true,
+	"/usr/bin/false":    true,
+}
+
+func hasLoginShell(p *passwdEntry) bool {
+	return !nologinShells[p.Shell]
+}
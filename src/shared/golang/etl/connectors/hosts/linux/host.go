@@ -0,0 +1,140 @@
+package linux
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/ssh"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LinuxHost gives the connector access to the files on the host. Files that don't exist are reported
+// with an error that satisfies os.IsNotExist and files that can't be read with one that satisfies
+// os.IsPermission.
+type LinuxHost interface {
+	ReadFile(path string) (string, *connectors.EtlCommandInfo, error)
+	// ListDir returns the names of the entries in the directory in sorted order.
+	ListDir(path string) ([]string, *connectors.EtlCommandInfo, error)
+}
+
+// LinuxFilesystemHost reads the files from a copy of the host's filesystem that's mounted at Root
+// (e.g. a snapshot of the root volume).
+type LinuxFilesystemHost struct {
+	Root string
+}
+
+func (h LinuxFilesystemHost) hostPath(p string) string {
+	return filepath.Join(h.Root, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+func (h LinuxFilesystemHost) ReadFile(p string) (string, *connectors.EtlCommandInfo, error) {
+	fname := h.hostPath(p)
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(data), &connectors.EtlCommandInfo{
+		Command: "READ " + fname,
+		Parameters: map[string]interface{}{
+			"path": p,
+		},
+		RawData: string(data),
+	}, nil
+}
+
+func (h LinuxFilesystemHost) ListDir(p string) ([]string, *connectors.EtlCommandInfo, error) {
+	fname := h.hostPath(p)
+	infos, err := ioutil.ReadDir(fname)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+
+	return names, &connectors.EtlCommandInfo{
+		Command: "LIST " + fname,
+		Parameters: map[string]interface{}{
+			"path": p,
+		},
+		RawData: strings.Join(names, "\n"),
+	}, nil
+}
+
+// LinuxSshHost reads the files by running commands on the host over SSH.
+type LinuxSshHost struct {
+	tunnel *ssh_utility.SshTunnel
+	sudo   bool
+}
+
+// OpenLinuxSshHost connects to the last hop in the config. If sudo is set the commands are run with
+// "sudo -n" so an unprivileged account with a NOPASSWD rule for cat and ls can read /etc/shadow and
+// the sudoers files.
+func OpenLinuxSshHost(cfg ssh_utility.SshTunnelConfig, sudo bool) (*LinuxSshHost, error) {
+	tunnel, err := ssh_utility.OpenSshTunnel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LinuxSshHost{
+		tunnel: tunnel,
+		sudo:   sudo,
+	}, nil
+}
+
+func (h *LinuxSshHost) Close() error {
+	return h.tunnel.Close()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func (h *LinuxSshHost) run(op string, p string, command string) (string, *connectors.EtlCommandInfo, error) {
+	if h.sudo {
+		command = "sudo -n " + command
+	}
+
+	out, err := h.tunnel.RunCommand(command)
+	if cmdErr, ok := err.(*ssh_utility.SshCommandError); ok && strings.Contains(cmdErr.Stderr, "No such file or directory") {
+		return "", nil, &os.PathError{Op: op, Path: p, Err: os.ErrNotExist}
+	} else if ok && strings.Contains(cmdErr.Stderr, "Permission denied") {
+		return "", nil, &os.PathError{Op: op, Path: p, Err: os.ErrPermission}
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	return string(out), &connectors.EtlCommandInfo{
+		Command: command,
+		Parameters: map[string]interface{}{
+			"path": p,
+		},
+		RawData: string(out),
+	}, nil
+}
+
+func (h *LinuxSshHost) ReadFile(p string) (string, *connectors.EtlCommandInfo, error) {
+	return h.run("read", p, "cat -- "+shellQuote(p))
+}
+
+func (h *LinuxSshHost) ListDir(p string) ([]string, *connectors.EtlCommandInfo, error) {
+	out, cmd, err := h.run("list", p, "ls -1A -- "+shellQuote(p))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(out, "\n") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cmd, nil
+}
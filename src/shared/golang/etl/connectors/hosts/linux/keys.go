@@ -0,0 +1,65 @@
+package linux
+
+import (
+	"golang.org/x/crypto/ssh"
+	"path"
+	"strings"
+)
+
+var defaultAuthorizedKeysFiles = []string{
+	".ssh/authorized_keys",
+	".ssh/authorized_keys2",
+}
+
+// authorizedKeysPath expands the tokens that sshd supports in AuthorizedKeysFile (%h for the home
+// directory, %u for the username and %% for a literal %). Relative paths are relative to the home
+// directory.
+func authorizedKeysPath(pattern string, user *passwdEntry) string {
+	replacer := strings.NewReplacer("%%", "%", "%h", user.Home, "%u", user.Name)
+	p := replacer.Replace(pattern)
+	if !path.IsAbs(p) {
+		p = path.Join(user.Home, p)
+	}
+	return p
+}
+
+type authorizedKey struct {
+	Type        string
+	Fingerprint string
+	Comment     string
+	Options     []string
+}
+
+func (k authorizedKey) String() string {
+	ret := k.Type + " " + k.Fingerprint
+	if k.Comment != "" {
+		ret += " " + k.Comment
+	}
+
+	if len(k.Options) > 0 {
+		ret += " [" + strings.Join(k.Options, ",") + "]"
+	}
+	return ret
+}
+
+// parseAuthorizedKeys returns the valid keys in the file. Lines that aren't valid keys are skipped
+// the same way sshd skips them.
+func parseAuthorizedKeys(data string) []authorizedKey {
+	ret := []authorizedKey{}
+	rest := []byte(data)
+	for len(rest) > 0 {
+		key, comment, options, next, err := ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			break
+		}
+
+		ret = append(ret, authorizedKey{
+			Type:        key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Comment:     comment,
+			Options:     options,
+		})
+		rest = next
+	}
+	return ret
+}
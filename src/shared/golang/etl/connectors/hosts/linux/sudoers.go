@@ -0,0 +1,669 @@
+package linux
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The parser handles the parts of the sudoers grammar that determine who can run what: aliases,
+// user specifications (with runas lists, tags and negation) and include directives. Defaults
+// lines are skipped.
+
+type sudoItem struct {
+	Negated bool
+	Value   string
+}
+
+type sudoRule struct {
+	Users []sudoItem
+	Hosts []sudoItem
+	// Nil if the command spec doesn't have a runas list, in which case the command runs as root.
+	RunasUsers  []sudoItem
+	RunasGroups []sudoItem
+	HasRunas    bool
+	Command     sudoItem
+	NoPasswd    bool
+	// File and line the rule was defined on.
+	Source string
+}
+
+type sudoInclude struct {
+	Path string
+	Dir  bool
+}
+
+type sudoers struct {
+	userAliases  map[string][]sudoItem
+	runasAliases map[string][]sudoItem
+	hostAliases  map[string][]sudoItem
+	cmndAliases  map[string][]sudoItem
+	rules        []*sudoRule
+}
+
+func createSudoers() *sudoers {
+	return &sudoers{
+		userAliases:  map[string][]sudoItem{},
+		runasAliases: map[string][]sudoItem{},
+		hostAliases:  map[string][]sudoItem{},
+		cmndAliases:  map[string][]sudoItem{},
+		rules:        []*sudoRule{},
+	}
+}
+
+type sudoToken struct {
+	Text string
+	// One of ,:=()! for punctuation, 0 for words.
+	Special     byte
+	SpaceBefore bool
+}
+
+func (t sudoToken) is(special byte) bool {
+	return t.Special == special
+}
+
+func (t sudoToken) String() string {
+	if t.Special != 0 {
+		return string(t.Special)
+	}
+	return t.Text
+}
+
+// stripSudoComment removes everything after a # that doesn't start a numeric ID (e.g. #1000 or %#1000).
+func stripSudoComment(line string) string {
+	inQuote := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case c == '#' && !inQuote:
+			if i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9' {
+				continue
+			}
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func tokenizeSudoLine(line string) []sudoToken {
+	tokens := []sudoToken{}
+	word := strings.Builder{}
+	inWord := false
+	spaceBefore := false
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, sudoToken{Text: word.String(), SpaceBefore: spaceBefore})
+			word.Reset()
+			inWord = false
+			spaceBefore = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t':
+			flush()
+			spaceBefore = true
+		case c == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == '"':
+			end := strings.IndexByte(line[i+1:], '"')
+			if end == -1 {
+				end = len(line) - i - 1
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			inWord = true
+			i += end + 1
+		case c == ',' || c == ':' || c == '=' || c == '(' || c == ')' || (c == '!' && !inWord):
+			flush()
+			tokens = append(tokens, sudoToken{Special: c, SpaceBefore: spaceBefore})
+			spaceBefore = false
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+	return tokens
+}
+
+type sudoLineParser struct {
+	tokens []sudoToken
+	pos    int
+}
+
+func (p *sudoLineParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *sudoLineParser) peek() sudoToken {
+	if p.done() {
+		return sudoToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sudoLineParser) peekIs(offset int, special byte) bool {
+	return p.pos+offset < len(p.tokens) && p.tokens[p.pos+offset].is(special)
+}
+
+func (p *sudoLineParser) next() sudoToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *sudoLineParser) expect(special byte) error {
+	if p.done() || !p.peek().is(special) {
+		return fmt.Errorf("Expected '%c' but found '%s'.", special, p.peek().String())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *sudoLineParser) item() (sudoItem, error) {
+	item := sudoItem{}
+	for p.peek().is('!') {
+		p.next()
+		item.Negated = !item.Negated
+	}
+
+	t := p.next()
+	if t.Special != 0 || t.Text == "" {
+		return item, fmt.Errorf("Expected a name but found '%s'.", t.String())
+	}
+	item.Value = t.Text
+	return item, nil
+}
+
+// list parses comma separated items. Items are single words so a word that isn't preceded by a
+// comma ends the list.
+func (p *sudoLineParser) list() ([]sudoItem, error) {
+	items := []sudoItem{}
+	for {
+		item, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if !p.peek().is(',') {
+			return items, nil
+		}
+		p.next()
+	}
+}
+
+var sudoTags = map[string]bool{
+	"NOPASSWD":     true,
+	"PASSWD":       true,
+	"NOEXEC":       true,
+	"EXEC":         true,
+	"SETENV":       true,
+	"NOSETENV":     true,
+	"LOG_INPUT":    true,
+	"NOLOG_INPUT":  true,
+	"LOG_OUTPUT":   true,
+	"NOLOG_OUTPUT": true,
+	"MAIL":         true,
+	"NOMAIL":       true,
+	"FOLLOW":       true,
+	"NOFOLLOW":     true,
+	"INTERCEPT":    true,
+	"NOINTERCEPT":  true,
+}
+
+var sudoOptions = map[string]bool{
+	"ROLE":             true,
+	"TYPE":             true,
+	"TIMEOUT":          true,
+	"CWD":              true,
+	"CHROOT":           true,
+	"NOTBEFORE":        true,
+	"NOTAFTER":         true,
+	"APPARMOR_PROFILE": true,
+	"PRIVS":            true,
+	"LIMITPRIVS":       true,
+}
+
+var sudoDigests = map[string]bool{
+	"sha224": true,
+	"sha256": true,
+	"sha384": true,
+	"sha512": true,
+}
+
+// command parses a command along with its arguments up to the next unescaped comma or colon.
+func (p *sudoLineParser) command() (sudoItem, error) {
+	item := sudoItem{}
+	for p.peek().is('!') {
+		p.next()
+		item.Negated = !item.Negated
+	}
+
+	// Digest specs (e.g. sha256:abc...) restrict the binary that may be run but don't change what
+	// the rule grants.
+	for !p.done() && sudoDigests[p.peek().Text] && p.peekIs(1, ':') {
+		p.pos += 3
+	}
+
+	text := strings.Builder{}
+	for !p.done() && !p.peek().is(',') && !p.peek().is(':') {
+		t := p.next()
+		if t.SpaceBefore && text.Len() > 0 {
+			text.WriteByte(' ')
+		}
+		text.WriteString(t.String())
+	}
+
+	if text.Len() == 0 {
+		return item, fmt.Errorf("Expected a command but found '%s'.", p.peek().String())
+	}
+	item.Value = text.String()
+	return item, nil
+}
+
+func (p *sudoLineParser) runas(rule *sudoRule) error {
+	err := p.expect('(')
+	if err != nil {
+		return err
+	}
+
+	rule.HasRunas = true
+	rule.RunasUsers = nil
+	rule.RunasGroups = nil
+
+	if !p.peek().is(':') && !p.peek().is(')') {
+		rule.RunasUsers, err = p.list()
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.peek().is(':') {
+		p.next()
+		if !p.peek().is(')') {
+			rule.RunasGroups, err = p.list()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return p.expect(')')
+}
+
+func (s *sudoers) parseUserSpec(p *sudoLineParser, source string) error {
+	users, err := p.list()
+	if err != nil {
+		return err
+	}
+
+	for {
+		hosts, err := p.list()
+		if err != nil {
+			return err
+		}
+
+		err = p.expect('=')
+		if err != nil {
+			return err
+		}
+
+		// The runas list and tags carry over to the following commands until they're replaced.
+		current := sudoRule{
+			Users:  users,
+			Hosts:  hosts,
+			Source: source,
+		}
+
+		for {
+			if p.peek().is('(') {
+				err = p.runas(&current)
+				if err != nil {
+					return err
+				}
+			}
+
+			for !p.done() {
+				t := p.peek()
+				if sudoOptions[t.Text] && p.peekIs(1, '=') {
+					p.pos += 3
+				} else if sudoTags[t.Text] && p.peekIs(1, ':') {
+					p.pos += 2
+					if t.Text == "NOPASSWD" {
+						current.NoPasswd = true
+					} else if t.Text == "PASSWD" {
+						current.NoPasswd = false
+					}
+				} else {
+					break
+				}
+			}
+
+			cmd, err := p.command()
+			if err != nil {
+				return err
+			}
+
+			rule := current
+			rule.Command = cmd
+			s.rules = append(s.rules, &rule)
+
+			if !p.peek().is(',') {
+				break
+			}
+			p.next()
+		}
+
+		if p.done() {
+			return nil
+		}
+
+		// Another host list for the same users.
+		err = p.expect(':')
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *sudoLineParser) commandList() ([]sudoItem, error) {
+	items := []sudoItem{}
+	for {
+		item, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if !p.peek().is(',') {
+			return items, nil
+		}
+		p.next()
+	}
+}
+
+// parseAliases parses one or more colon separated alias definitions (NAME = item, ...). Command
+// aliases contain commands with arguments rather than single words.
+func (s *sudoers) parseAliases(p *sudoLineParser, aliases map[string][]sudoItem, commands bool) error {
+	for {
+		name := p.next()
+		if name.Special != 0 || name.Text == "" {
+			return fmt.Errorf("Expected an alias name but found '%s'.", name.String())
+		}
+
+		err := p.expect('=')
+		if err != nil {
+			return err
+		}
+
+		var items []sudoItem
+		if commands {
+			items, err = p.commandList()
+		} else {
+			items, err = p.list()
+		}
+
+		if err != nil {
+			return err
+		}
+		aliases[name.Text] = items
+
+		if p.done() {
+			return nil
+		}
+
+		err = p.expect(':')
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sudoLines joins lines that end with a backslash and returns each logical line with the number
+// of the line it starts on.
+func sudoLines(data string) ([]string, []int) {
+	lines := []string{}
+	numbers := []int{}
+
+	current := strings.Builder{}
+	start := 0
+	for idx, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if current.Len() == 0 {
+			start = idx + 1
+		}
+
+		if strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\"))
+			current.WriteByte(' ')
+			continue
+		}
+
+		current.WriteString(line)
+		lines = append(lines, current.String())
+		numbers = append(numbers, start)
+		current.Reset()
+	}
+
+	if current.Len() > 0 {
+		lines = append(lines, current.String())
+		numbers = append(numbers, start)
+	}
+	return lines, numbers
+}
+
+func parseIncludeDirective(line string, fname string) (*sudoInclude, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return nil, false
+	}
+
+	include := sudoInclude{}
+	switch fields[0] {
+	case "#include", "@include":
+	case "#includedir", "@includedir":
+		include.Dir = true
+	default:
+		return nil, false
+	}
+
+	include.Path = fields[1]
+	if !path.IsAbs(include.Path) {
+		include.Path = path.Join(path.Dir(fname), include.Path)
+	}
+	return &include, true
+}
+
+// Parse adds the aliases and rules in the file and returns the files it includes in order.
+func (s *sudoers) Parse(data string, fname string) ([]sudoInclude, error) {
+	includes := []sudoInclude{}
+	lines, numbers := sudoLines(data)
+	for idx, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if include, ok := parseIncludeDirective(trimmed, fname); ok {
+			includes = append(includes, *include)
+			continue
+		}
+
+		trimmed = strings.TrimSpace(stripSudoComment(trimmed))
+		if trimmed == "" {
+			continue
+		}
+
+		source := fname + ":" + strconv.Itoa(numbers[idx])
+		p := sudoLineParser{tokens: tokenizeSudoLine(trimmed)}
+
+		var err error
+		switch keyword := p.peek().Text; {
+		case strings.HasPrefix(keyword, "Defaults"):
+			continue
+		case keyword == "User_Alias":
+			p.next()
+			err = s.parseAliases(&p, s.userAliases, false)
+		case keyword == "Runas_Alias":
+			p.next()
+			err = s.parseAliases(&p, s.runasAliases, false)
+		case keyword == "Host_Alias":
+			p.next()
+			err = s.parseAliases(&p, s.hostAliases, false)
+		case keyword == "Cmnd_Alias" || keyword == "Cmd_Alias":
+			p.next()
+			err = s.parseAliases(&p, s.cmndAliases, true)
+		default:
+			err = s.parseUserSpec(&p, source)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse %s: %s", source, err.Error())
+		}
+	}
+	return includes, nil
+}
+
+// Files in an included directory are skipped if they end in ~ or contain a dot so editor backups
+// and package manager leftovers (e.g. foo.rpmsave) are ignored.
+func includeDirFile(name string) bool {
+	return !strings.HasSuffix(name, "~") && !strings.Contains(name, ".")
+}
+
+func isSudoAlias(name string) bool {
+	if name == "" || name == "ALL" || !unicode.IsUpper(rune(name[0])) {
+		return false
+	}
+
+	for _, c := range name {
+		if !unicode.IsUpper(c) && !unicode.IsDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// sudoSubject is a user along with the groups it's in by name and GID.
+type sudoSubject struct {
+	Name   string
+	Uid    int
+	Groups map[string]bool
+	Gids   map[int]string
+}
+
+// matchUser follows the sudoers semantics where the last item in the list that matches decides
+// whether the user matches (i.e. a negated item excludes users that an earlier item included). It
+// also returns the group the user matched through or an empty string if the user matched directly.
+func (s *sudoers) matchUser(items []sudoItem, subject *sudoSubject, visited map[string]bool) (bool, string) {
+	matched := false
+	via := ""
+	for _, item := range items {
+		ok, itemVia := s.matchUserItem(item.Value, subject, visited)
+		if !ok {
+			continue
+		}
+
+		matched = !item.Negated
+		via = itemVia
+		if !matched {
+			via = ""
+		}
+	}
+	return matched, via
+}
+
+func (s *sudoers) matchUserItem(value string, subject *sudoSubject, visited map[string]bool) (bool, string) {
+	switch {
+	case value == "ALL":
+		return true, ""
+	case strings.HasPrefix(value, "%#"):
+		gid, err := strconv.Atoi(value[2:])
+		if err != nil {
+			return false, ""
+		}
+
+		name, ok := subject.Gids[gid]
+		return ok, name
+	case strings.HasPrefix(value, "%:") || strings.HasPrefix(value, "+"):
+		// Non-Unix groups and netgroups can't be resolved from the files on the host.
+		return false, ""
+	case strings.HasPrefix(value, "%"):
+		return subject.Groups[value[1:]], value[1:]
+	case strings.HasPrefix(value, "#"):
+		uid, err := strconv.Atoi(value[1:])
+		return err == nil && uid == subject.Uid, ""
+	}
+
+	if items, ok := s.userAliases[value]; ok && isSudoAlias(value) {
+		if visited[value] {
+			return false, ""
+		}
+
+		visited[value] = true
+		defer delete(visited, value)
+		return s.matchUser(items, subject, visited)
+	}
+	return value == subject.Name, ""
+}
+
+// expandAliases replaces the aliases in the list with their members. Negating an alias negates
+// each of its members.
+func expandAliases(aliases map[string][]sudoItem, items []sudoItem, visited map[string]bool) []sudoItem {
+	ret := []sudoItem{}
+	for _, item := range items {
+		members, ok := aliases[item.Value]
+		if !ok || !isSudoAlias(item.Value) || visited[item.Value] {
+			ret = append(ret, item)
+			continue
+		}
+
+		visited[item.Value] = true
+		for _, m := range expandAliases(aliases, members, visited) {
+			ret = append(ret, sudoItem{
+				Negated: m.Negated != item.Negated,
+				Value:   m.Value,
+			})
+		}
+		delete(visited, item.Value)
+	}
+	return ret
+}
+
+func formatSudoItems(items []sudoItem) string {
+	values := []string{}
+	for _, item := range items {
+		if item.Negated {
+			values = append(values, "!"+item.Value)
+		} else {
+			values = append(values, item.Value)
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+func (s *sudoers) hosts(rule *sudoRule) string {
+	return formatSudoItems(expandAliases(s.hostAliases, rule.Hosts, map[string]bool{}))
+}
+
+// runas returns who the command can be run as in the same form as the runas list (i.e. users:groups).
+func (s *sudoers) runas(rule *sudoRule) string {
+	if !rule.HasRunas || (len(rule.RunasUsers) == 0 && len(rule.RunasGroups) == 0) {
+		return "root"
+	}
+
+	ret := formatSudoItems(expandAliases(s.runasAliases, rule.RunasUsers, map[string]bool{}))
+	if len(rule.RunasGroups) > 0 {
+		ret += ":" + formatSudoItems(expandAliases(s.runasAliases, rule.RunasGroups, map[string]bool{}))
+	}
+	return ret
+}
+
+func (s *sudoers) commands(rule *sudoRule) []sudoItem {
+	return expandAliases(s.cmndAliases, []sudoItem{rule.Command}, map[string]bool{})
+}
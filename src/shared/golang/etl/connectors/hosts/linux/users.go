@@ -0,0 +1,362 @@
+package linux
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LinuxAttributeUid                = "uid"
+	LinuxAttributeGid                = "gid"
+	LinuxAttributeHome               = "home"
+	LinuxAttributeShell              = "shell"
+	LinuxAttributePasswordStatus     = "password_status"
+	LinuxAttributePasswordExpires    = "password_expires"
+	LinuxAttributeAccountExpires     = "account_expires"
+	LinuxAttributeAccountExpired     = "account_expired"
+	LinuxAttributeAuthorizedKeyCount = "authorized_key_count"
+	LinuxAttributeAuthorizedKeys     = "authorized_keys"
+)
+
+// Codes used in EtlFinding.
+const (
+	FindingEmptyPassword    = "EmptyPassword"
+	FindingNonRootUidZero   = "NonRootUidZero"
+	FindingPasswordlessSudo = "PasswordlessSudo"
+	// The authorized keys couldn't be checked since the file isn't readable (e.g. a home directory on
+	// root squashed NFS).
+	FindingUnreadableAuthorizedKeys = "UnreadableAuthorizedKeys"
+)
+
+const (
+	passwdFile  = "/etc/passwd"
+	shadowFile  = "/etc/shadow"
+	groupFile   = "/etc/group"
+	sudoersFile = "/etc/sudoers"
+)
+
+// Sudo refuses to follow includes that are nested more deeply than this.
+const maxSudoIncludeDepth = 128
+
+const linuxDateFormat = "2006-01-02"
+
+type EtlLinuxConnectorUser struct {
+	opts *EtlLinuxOptions
+}
+
+func createLinuxConnectorUser(opts *EtlLinuxOptions) (*EtlLinuxConnectorUser, error) {
+	return &EtlLinuxConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (l *EtlLinuxConnectorUser) readFile(p string, source *connectors.EtlSourceInfo) (string, error) {
+	data, cmd, err := l.opts.Host.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+
+	source.AddCommand(cmd)
+	return data, nil
+}
+
+// readPasswordFile is readFile for the passwd and shadow files. The password hashes are removed from the
+// recorded command.
+func (l *EtlLinuxConnectorUser) readPasswordFile(p string, source *connectors.EtlSourceInfo) (string, error) {
+	data, cmd, err := l.opts.Host.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+
+	cmd.RawData = redactPasswordHashes(cmd.RawData)
+	source.AddCommand(cmd)
+	return data, nil
+}
+
+func (l *EtlLinuxConnectorUser) readSudoers(s *sudoers, p string, depth int, source *connectors.EtlSourceInfo) error {
+	if depth > maxSudoIncludeDepth {
+		return fmt.Errorf("Too many nested sudoers includes in %s.", p)
+	}
+
+	data, err := l.readFile(p, source)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	includes, err := s.Parse(data, p)
+	if err != nil {
+		return err
+	}
+
+	for _, include := range includes {
+		if !include.Dir {
+			err = l.readSudoers(s, include.Path, depth+1, source)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		names, cmd, err := l.opts.Host.ListDir(include.Path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		source.AddCommand(cmd)
+
+		for _, name := range names {
+			if !includeDirFile(name) {
+				continue
+			}
+
+			err = l.readSudoers(s, path.Join(include.Path, name), depth+1, source)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readAuthorizedKeys returns the keys in the user's authorized keys files. Files that can't be read are
+// reported as a finding on the user instead of failing the listing.
+func (l *EtlLinuxConnectorUser) readAuthorizedKeys(p *passwdEntry, user *types.EtlUser, source *connectors.EtlSourceInfo) ([]authorizedKey, error) {
+	keys := []authorizedKey{}
+	for _, pattern := range l.opts.authorizedKeysFiles() {
+		fname := authorizedKeysPath(pattern, p)
+		data, err := l.readFile(fname, source)
+		if os.IsNotExist(err) {
+			continue
+		} else if os.IsPermission(err) {
+			user.Findings = append(user.Findings, &types.EtlFinding{
+				Code:        FindingUnreadableAuthorizedKeys,
+				Description: fmt.Sprintf("Permission denied reading %s so the keys that can log in are unknown.", fname),
+			})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, parseAuthorizedKeys(data)...)
+	}
+	return keys, nil
+}
+
+func addPermission(permissions types.PermissionMap, object string, permission string) {
+	for _, p := range permissions[object] {
+		if p == permission {
+			return
+		}
+	}
+	permissions[object] = append(permissions[object], permission)
+}
+
+func getRole(user *types.EtlUser, name string) *types.EtlRole {
+	role, ok := user.Roles[name]
+	if !ok {
+		role = &types.EtlRole{
+			Name:        name,
+			Permissions: map[string][]string{},
+			Denied:      map[string][]string{},
+		}
+		user.Roles[name] = role
+	}
+	return role
+}
+
+// applySudoRules adds the commands the user can run with sudo to the role for the group the rule
+// applies to or Self for rules that apply to the user directly. Permission objects identify the
+// hosts and the users the commands can be run as (e.g. sudo::ALL::ALL:ALL).
+func applySudoRules(user *types.EtlUser, subject *sudoSubject, s *sudoers) {
+	for _, rule := range s.rules {
+		matched, via := s.matchUser(rule.Users, subject, map[string]bool{})
+		if !matched {
+			continue
+		}
+
+		roleName := via
+		if roleName == "" {
+			roleName = "Self"
+		}
+		role := getRole(user, roleName)
+
+		object := "sudo::" + s.hosts(rule) + "::" + s.runas(rule)
+		for _, cmd := range s.commands(rule) {
+			if cmd.Negated {
+				addPermission(role.Denied, object, cmd.Value)
+				continue
+			}
+
+			if !rule.NoPasswd {
+				addPermission(role.Permissions, object, cmd.Value)
+				continue
+			}
+
+			addPermission(role.Permissions, object, "NOPASSWD: "+cmd.Value)
+			if cmd.Value == "ALL" {
+				user.Findings = append(user.Findings, &types.EtlFinding{
+					Code:        FindingPasswordlessSudo,
+					Description: fmt.Sprintf("Can run any command with sudo without a password (%s).", rule.Source),
+				})
+			}
+		}
+	}
+}
+
+func applyShadow(user *types.EtlUser, p *passwdEntry, s *shadowEntry, now time.Time) {
+	status := passwordStatus(p, s)
+	user.Attributes[LinuxAttributePasswordStatus] = status
+
+	expired := false
+	if s != nil {
+		if s.LastChange != nil && *s.LastChange > 0 {
+			lastChange := daysToTime(*s.LastChange)
+			user.LastChangeTime = &lastChange
+
+			if s.MaxDays != nil && *s.MaxDays < shadowNeverExpires {
+				user.Attributes[LinuxAttributePasswordExpires] = daysToTime(*s.LastChange + *s.MaxDays).Format(linuxDateFormat)
+			}
+		}
+
+		if s.Expire != nil {
+			expires := daysToTime(*s.Expire)
+			expired = !now.Before(expires)
+			user.Attributes[LinuxAttributeAccountExpires] = expires.Format(linuxDateFormat)
+			user.Attributes[LinuxAttributeAccountExpired] = strconv.FormatBool(expired)
+		}
+	}
+
+	if status == PasswordStatusEmpty && hasLoginShell(p) && !expired {
+		user.Findings = append(user.Findings, &types.EtlFinding{
+			Code:        FindingEmptyPassword,
+			Description: "Account can log in without a password.",
+		})
+	}
+}
+
+func (l *EtlLinuxConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	data, err := l.readPasswordFile(passwdFile, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	passwd, err := parsePasswd(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = l.readPasswordFile(shadowFile, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shadow, err := parseShadow(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = l.readFile(groupFile, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups, err := parseGroup(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sudo := createSudoers()
+	err = l.readSudoers(sudo, sudoersFile, 0, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groupNames := map[int]string{}
+	memberOf := map[string][]*groupEntry{}
+	for _, g := range groups {
+		if _, ok := groupNames[g.Gid]; !ok {
+			groupNames[g.Gid] = g.Name
+		}
+
+		for _, m := range g.Members {
+			memberOf[m] = append(memberOf[m], g)
+		}
+	}
+
+	now := time.Now()
+	users := []*types.EtlUser{}
+	for _, p := range passwd {
+		user := types.EtlUser{
+			Username: p.Name,
+			FullName: strings.TrimSpace(strings.Split(p.Gecos, ",")[0]),
+			Roles:    map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				LinuxAttributeUid:   strconv.Itoa(p.Uid),
+				LinuxAttributeGid:   strconv.Itoa(p.Gid),
+				LinuxAttributeHome:  p.Home,
+				LinuxAttributeShell: p.Shell,
+			},
+			Findings: []*types.EtlFinding{},
+		}
+
+		subject := sudoSubject{
+			Name:   p.Name,
+			Uid:    p.Uid,
+			Groups: map[string]bool{},
+			Gids:   map[int]string{},
+		}
+
+		if name, ok := groupNames[p.Gid]; ok {
+			subject.Groups[name] = true
+			subject.Gids[p.Gid] = name
+		}
+
+		for _, g := range memberOf[p.Name] {
+			subject.Groups[g.Name] = true
+			subject.Gids[g.Gid] = g.Name
+		}
+
+		for name := range subject.Groups {
+			getRole(&user, name)
+		}
+
+		applyShadow(&user, p, shadow[p.Name], now)
+		applySudoRules(&user, &subject, sudo)
+
+		if p.Uid == 0 && p.Name != "root" {
+			user.Findings = append(user.Findings, &types.EtlFinding{
+				Code:        FindingNonRootUidZero,
+				Description: "Account has UID 0 and so has the same privileges as root.",
+			})
+		}
+
+		keys, err := l.readAuthorizedKeys(p, &user, source)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keyStrings := []string{}
+		for _, k := range keys {
+			keyStrings = append(keyStrings, k.String())
+		}
+		user.Attributes[LinuxAttributeAuthorizedKeyCount] = strconv.Itoa(len(keys))
+		if len(keys) > 0 {
+			user.Attributes[LinuxAttributeAuthorizedKeys] = strings.Join(keyStrings, "; ")
+		}
+
+		users = append(users, &user)
+	}
+
+	return users, source, nil
+}
@@ -82,11 +82,14 @@ func (u *azureUser) toEtlUser() *types.EtlUser {
 		email = u.OtherMails[0]
 	}
 
+	// Copy the time so the user doesn't point into a (possibly reused) loop variable.
+	createdTime := u.CreatedDateTime
+
 	return &types.EtlUser{
 		Username:    u.UserPrincipalName,
 		Email:       email,
 		FullName:    u.DisplayName,
-		CreatedTime: &u.CreatedDateTime,
+		CreatedTime: &createdTime,
 		Roles:       map[string]*types.EtlRole{},
 	}
 }
@@ -0,0 +1,35 @@
+package digitalocean
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+type EtlDigitalOceanOptions struct {
+	Client http_utility.HttpClient
+}
+
+type EtlDigitalOceanConnector struct {
+	opts  *EtlDigitalOceanOptions
+	users *EtlDigitalOceanConnectorUser
+}
+
+const apiUrl string = "https://api.digitalocean.com/v2"
+
+func (c *EtlDigitalOceanConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateDigitalOceanConnector(opts *EtlDigitalOceanOptions) (*EtlDigitalOceanConnector, error) {
+	var err error
+	ret := EtlDigitalOceanConnector{
+		opts: opts,
+	}
+	ret.users, err = createDigitalOceanConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
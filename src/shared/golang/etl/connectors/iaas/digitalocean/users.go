@@ -0,0 +1,87 @@
+package digitalocean
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strconv"
+)
+
+const (
+	DigitalOceanAttributeUuid          = "uuid"
+	DigitalOceanAttributeStatus        = "status"
+	DigitalOceanAttributeEmailVerified = "email_verified"
+	DigitalOceanAttributeTeam          = "team"
+)
+
+type digitalOceanTeam struct {
+	Uuid string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+type digitalOceanAccount struct {
+	Uuid          string           `json:"uuid"`
+	Email         string           `json:"email"`
+	EmailVerified bool             `json:"email_verified"`
+	Status        string           `json:"status"`
+	Team          digitalOceanTeam `json:"team"`
+}
+
+func teamName(team digitalOceanTeam) string {
+	if team.Name == "" {
+		return team.Uuid
+	}
+	return team.Name
+}
+
+func (a digitalOceanAccount) toEtlUser() *types.EtlUser {
+	return &types.EtlUser{
+		Username: a.Email,
+		Email:    a.Email,
+		Roles:    map[string]*types.EtlRole{},
+		Attributes: map[string]string{
+			DigitalOceanAttributeUuid:          a.Uuid,
+			DigitalOceanAttributeStatus:        a.Status,
+			DigitalOceanAttributeEmailVerified: strconv.FormatBool(a.EmailVerified),
+			DigitalOceanAttributeTeam:          teamName(a.Team),
+		},
+	}
+}
+
+type EtlDigitalOceanConnectorUser struct {
+	opts *EtlDigitalOceanOptions
+}
+
+func createDigitalOceanConnectorUser(opts *EtlDigitalOceanOptions) (*EtlDigitalOceanConnectorUser, error) {
+	return &EtlDigitalOceanConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+// getAccountInfo returns the account the token belongs to along with the team the token is scoped to.
+func (c *EtlDigitalOceanConnectorUser) getAccountInfo() (*digitalOceanAccount, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/account", apiUrl)
+
+	type ResponseBody struct {
+		Account digitalOceanAccount `json:"account"`
+	}
+
+	body := ResponseBody{}
+	source, err := digitalOceanGet(c.opts.Client, endpoint, &body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &body.Account, source, nil
+}
+
+// GetUserListing returns the account that owns the API token. The public v2 API has no endpoint to list the
+// other members of a team or the team's API tokens so those aren't included.
+func (c *EtlDigitalOceanConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	account, source, err := c.getAccountInfo()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []*types.EtlUser{account.toEtlUser()}, source, nil
+}
@@ -0,0 +1,13 @@
+package digitalocean
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/iaas/utility"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+const digitalOceanProvider = "DigitalOcean"
+
+func digitalOceanGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	return iaas_utility.RestGet(client, digitalOceanProvider, endpoint, output)
+}
@@ -0,0 +1,42 @@
+package kubernetes
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+type EtlKubernetesOptions struct {
+	// The client needs to authenticate with the API server (e.g. a bearer token for a service account
+	// that can list the RBAC resources) and trust its certificate.
+	Client http_utility.HttpClient
+	// The URL of the API server (e.g. https://10.0.0.1:6443).
+	ApiServer string
+}
+
+func (o EtlKubernetesOptions) apiBaseUrl() string {
+	return strings.TrimSuffix(o.ApiServer, "/") + "/apis/rbac.authorization.k8s.io/v1"
+}
+
+type EtlKubernetesConnector struct {
+	opts  *EtlKubernetesOptions
+	users *EtlKubernetesConnectorUser
+}
+
+func (c *EtlKubernetesConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateKubernetesConnector(opts *EtlKubernetesOptions) (*EtlKubernetesConnector, error) {
+	var err error
+	ret := EtlKubernetesConnector{
+		opts: opts,
+	}
+	ret.users, err = createKubernetesConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,222 @@
+package kubernetes
+
+import (
+	"sort"
+	"strings"
+)
+
+// KubernetesClusterNamespace is the namespace that permissions granted cluster-wide (through a
+// ClusterRoleBinding) are listed under.
+const KubernetesClusterNamespace = "*"
+
+// KubernetesNonResourcePrefix is prepended to non-resource URLs (e.g. /healthz) which can only be granted
+// cluster-wide.
+const KubernetesNonResourcePrefix = "NONRESOURCE::"
+
+const (
+	kubernetesKindRole        = "Role"
+	kubernetesKindClusterRole = "ClusterRole"
+)
+
+type kubernetesObjectMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+type kubernetesPolicyRule struct {
+	Verbs           []string `json:"verbs"`
+	ApiGroups       []string `json:"apiGroups"`
+	Resources       []string `json:"resources"`
+	ResourceNames   []string `json:"resourceNames"`
+	NonResourceUrls []string `json:"nonResourceURLs"`
+}
+
+// objects returns the resources the rule applies to in the given namespace (namespace/resource[.group][/name]).
+func (r kubernetesPolicyRule) objects(namespace string) []string {
+	ret := []string{}
+
+	for _, group := range r.ApiGroups {
+		for _, resource := range r.Resources {
+			// Resources in the core group (e.g. pods) have no group.
+			qualified := resource
+			if group != "" {
+				// Subresources (e.g. pods/log) are qualified by the group of the resource.
+				parts := strings.SplitN(resource, "/", 2)
+				parts[0] = parts[0] + "." + group
+				qualified = strings.Join(parts, "/")
+			}
+
+			if len(r.ResourceNames) == 0 {
+				ret = append(ret, namespace+"/"+qualified)
+				continue
+			}
+
+			for _, name := range r.ResourceNames {
+				ret = append(ret, namespace+"/"+qualified+"/"+name)
+			}
+		}
+	}
+
+	// Non-resource URLs only have an effect when granted cluster-wide.
+	if namespace == KubernetesClusterNamespace {
+		for _, u := range r.NonResourceUrls {
+			ret = append(ret, KubernetesNonResourcePrefix+u)
+		}
+	}
+
+	return ret
+}
+
+type kubernetesLabelSelectorRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+func (r kubernetesLabelSelectorRequirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+
+	switch r.Operator {
+	case "In":
+		if !ok {
+			return false
+		}
+
+		for _, v := range r.Values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	case "NotIn":
+		if !ok {
+			return true
+		}
+
+		for _, v := range r.Values {
+			if v == value {
+				return false
+			}
+		}
+		return true
+	case "Exists":
+		return ok
+	case "DoesNotExist":
+		return !ok
+	}
+
+	return false
+}
+
+type kubernetesLabelSelector struct {
+	MatchLabels      map[string]string                    `json:"matchLabels"`
+	MatchExpressions []kubernetesLabelSelectorRequirement `json:"matchExpressions"`
+}
+
+// matches returns whether every label and expression matches. An empty selector matches nothing when used
+// for aggregation.
+func (s kubernetesLabelSelector) matches(labels map[string]string) bool {
+	if len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0 {
+		return false
+	}
+
+	for k, v := range s.MatchLabels {
+		if labels[k] != v {
+			return false
+		}
+	}
+
+	for _, e := range s.MatchExpressions {
+		if !e.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+type kubernetesAggregationRule struct {
+	ClusterRoleSelectors []kubernetesLabelSelector `json:"clusterRoleSelectors"`
+}
+
+type kubernetesRole struct {
+	Metadata        kubernetesObjectMeta       `json:"metadata"`
+	Rules           []kubernetesPolicyRule     `json:"rules"`
+	AggregationRule *kubernetesAggregationRule `json:"aggregationRule"`
+}
+
+type kubernetesRoleRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type kubernetesSubject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+type kubernetesRoleBinding struct {
+	Metadata kubernetesObjectMeta `json:"metadata"`
+	RoleRef  kubernetesRoleRef    `json:"roleRef"`
+	Subjects []kubernetesSubject  `json:"subjects"`
+}
+
+// resolveAggregatedClusterRoles returns the rules of every ClusterRole keyed by name. Aggregated ClusterRoles
+// get the rules of every ClusterRole that one of their selectors matches (which may be aggregated themselves)
+// in addition to their own. The controller manager normally fills these in but it may lag behind or not be
+// running at all.
+func resolveAggregatedClusterRoles(clusterRoles []kubernetesRole) map[string][]kubernetesPolicyRule {
+	byName := map[string]kubernetesRole{}
+	names := []string{}
+	for _, r := range clusterRoles {
+		byName[r.Metadata.Name] = r
+		names = append(names, r.Metadata.Name)
+	}
+	sort.Strings(names)
+
+	resolved := map[string][]kubernetesPolicyRule{}
+
+	var resolve func(name string, visiting map[string]bool) []kubernetesPolicyRule
+	resolve = func(name string, visiting map[string]bool) []kubernetesPolicyRule {
+		if rules, ok := resolved[name]; ok {
+			return rules
+		}
+
+		role := byName[name]
+		rules := append([]kubernetesPolicyRule{}, role.Rules...)
+
+		// Aggregated ClusterRoles that (indirectly) select each other only contribute their own rules.
+		if visiting[name] {
+			return rules
+		}
+
+		if role.AggregationRule == nil {
+			resolved[name] = rules
+			return rules
+		}
+		visiting[name] = true
+
+		for _, other := range names {
+			if other == name {
+				continue
+			}
+
+			for _, selector := range role.AggregationRule.ClusterRoleSelectors {
+				if selector.matches(byName[other].Metadata.Labels) {
+					rules = append(rules, resolve(other, visiting)...)
+					break
+				}
+			}
+		}
+
+		delete(visiting, name)
+		resolved[name] = rules
+		return rules
+	}
+
+	for _, name := range names {
+		resolve(name, map[string]bool{})
+	}
+	return resolved
+}
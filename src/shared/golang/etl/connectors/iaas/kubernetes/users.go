@@ -0,0 +1,228 @@
+package kubernetes
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+)
+
+const (
+	KubernetesSubjectUser           = "User"
+	KubernetesSubjectGroup          = "Group"
+	KubernetesSubjectServiceAccount = "ServiceAccount"
+)
+
+// KubernetesGroupPrefix is prepended to the names of groups so that they can't be confused with users that
+// have the same name.
+const KubernetesGroupPrefix = "Group::"
+
+const (
+	KubernetesAttributeKind      = "kind"
+	KubernetesAttributeNamespace = "namespace"
+)
+
+type EtlKubernetesConnectorUser struct {
+	opts *EtlKubernetesOptions
+}
+
+func createKubernetesConnectorUser(opts *EtlKubernetesOptions) (*EtlKubernetesConnectorUser, error) {
+	return &EtlKubernetesConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlKubernetesConnectorUser) listRoles(resource string) ([]kubernetesRole, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/%s", c.opts.apiBaseUrl(), resource)
+
+	type ResponseBody struct {
+		Metadata kubernetesListMeta `json:"metadata"`
+		Items    []kubernetesRole   `json:"items"`
+	}
+
+	responses := []ResponseBody{}
+	source, err := kubernetesPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retRoles := []kubernetesRole{}
+	for _, resp := range responses {
+		retRoles = append(retRoles, resp.Items...)
+	}
+	return retRoles, source, nil
+}
+
+func (c *EtlKubernetesConnectorUser) listRoleBindings(resource string) ([]kubernetesRoleBinding, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/%s", c.opts.apiBaseUrl(), resource)
+
+	type ResponseBody struct {
+		Metadata kubernetesListMeta      `json:"metadata"`
+		Items    []kubernetesRoleBinding `json:"items"`
+	}
+
+	responses := []ResponseBody{}
+	source, err := kubernetesPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retBindings := []kubernetesRoleBinding{}
+	for _, resp := range responses {
+		retBindings = append(retBindings, resp.Items...)
+	}
+	return retBindings, source, nil
+}
+
+// subjectUser creates the user for the subject of a binding. Service accounts are named after the username
+// they authenticate as and default to the namespace of the binding.
+func subjectUser(subject kubernetesSubject, bindingNamespace string) *types.EtlUser {
+	user := &types.EtlUser{
+		Username: subject.Name,
+		Roles:    map[string]*types.EtlRole{},
+		Attributes: map[string]string{
+			KubernetesAttributeKind: subject.Kind,
+		},
+	}
+
+	switch subject.Kind {
+	case KubernetesSubjectGroup:
+		user.Username = KubernetesGroupPrefix + subject.Name
+	case KubernetesSubjectServiceAccount:
+		namespace := subject.Namespace
+		if namespace == "" {
+			namespace = bindingNamespace
+		}
+
+		user.Username = fmt.Sprintf("system:serviceaccount:%s:%s", namespace, subject.Name)
+		user.Attributes[KubernetesAttributeNamespace] = namespace
+	}
+
+	return user
+}
+
+// createRole creates the role for rules that are granted in a namespace (or cluster-wide). The verbs that are
+// allowed are listed for every namespace/resource.
+func createRole(name string, namespace string, rules []kubernetesPolicyRule) *types.EtlRole {
+	role := &types.EtlRole{
+		Name:        name,
+		Permissions: map[string][]string{},
+	}
+
+	for _, r := range rules {
+		for _, object := range r.objects(namespace) {
+		VERB:
+			for _, verb := range r.Verbs {
+				for _, existing := range role.Permissions[object] {
+					if existing == verb {
+						continue VERB
+					}
+				}
+				role.Permissions[object] = append(role.Permissions[object], verb)
+			}
+		}
+	}
+
+	return role
+}
+
+// Obtain the subjects (users, groups and service accounts) that roles are bound to and what they're allowed to do.
+// Every binding is listed as a role:
+//   - ClusterRoleBindings as ClusterRole::name with permissions in every namespace (*/resource).
+//   - RoleBindings as Role::namespace/name or ClusterRole::namespace/name with permissions in the namespace
+//     of the binding.
+//
+// Aggregated ClusterRoles include the rules of the ClusterRoles they select. Subjects are only listed if
+// they're bound to a role since Kubernetes doesn't store users or groups.
+func (c *EtlKubernetesConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	clusterRoles, source, err := c.listRoles("clusterroles")
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	roles, source, err := c.listRoles("roles")
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	clusterRoleBindings, source, err := c.listRoleBindings("clusterrolebindings")
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	roleBindings, source, err := c.listRoleBindings("rolebindings")
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	clusterRoleRules := resolveAggregatedClusterRoles(clusterRoles)
+
+	// Roles are keyed by namespace/name.
+	roleRules := map[string][]kubernetesPolicyRule{}
+	for _, r := range roles {
+		roleRules[r.Metadata.Namespace+"/"+r.Metadata.Name] = r.Rules
+	}
+
+	users := map[string]*types.EtlUser{}
+	addBinding := func(binding kubernetesRoleBinding, clusterWide bool) {
+		namespace := binding.Metadata.Namespace
+		if clusterWide {
+			namespace = KubernetesClusterNamespace
+		}
+
+		var name string
+		var rules []kubernetesPolicyRule
+
+		switch {
+		case binding.RoleRef.Kind == kubernetesKindClusterRole && clusterWide:
+			name = binding.RoleRef.Name
+			rules = clusterRoleRules[binding.RoleRef.Name]
+		case binding.RoleRef.Kind == kubernetesKindClusterRole:
+			name = namespace + "/" + binding.RoleRef.Name
+			rules = clusterRoleRules[binding.RoleRef.Name]
+		default:
+			name = namespace + "/" + binding.RoleRef.Name
+			rules = roleRules[name]
+		}
+
+		role := createRole(binding.RoleRef.Kind+"::"+name, namespace, rules)
+
+		for _, subject := range binding.Subjects {
+			user := subjectUser(subject, binding.Metadata.Namespace)
+			if existing, ok := users[user.Username]; ok {
+				user = existing
+			} else {
+				users[user.Username] = user
+			}
+
+			user.Roles[role.Name] = role
+		}
+	}
+
+	for _, b := range clusterRoleBindings {
+		addBinding(b, true)
+	}
+
+	for _, b := range roleBindings {
+		addBinding(b, false)
+	}
+
+	usernames := []string{}
+	for username := range users {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	retUsers := []*types.EtlUser{}
+	for _, username := range usernames {
+		retUsers = append(retUsers, users[username])
+	}
+
+	return retUsers, finalSource, nil
+}
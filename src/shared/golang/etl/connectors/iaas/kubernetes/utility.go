@@ -0,0 +1,115 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// The number of items to request per page. The API server may return fewer.
+const kubernetesPageSize = "500"
+
+type kubernetesListMeta struct {
+	Continue string `json:"continue"`
+}
+
+func kubernetesGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Kubernetes API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// kubernetesPaginatedGet lists every item by following the continue token that the API server returns
+// in the metadata of the list.
+func kubernetesPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	cont := ""
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	for {
+		params := url.Values{}
+		params.Set("limit", kubernetesPageSize)
+		if cont != "" {
+			params.Set("continue", cont)
+		}
+		endpoint := baseEndpoint + "?" + params.Encode()
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		cmdSrc, err := kubernetesGet(client, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		result := responseBodyValue.Elem()
+		meta := result.FieldByName("Metadata").Interface().(kubernetesListMeta)
+
+		if meta.Continue == "" {
+			break
+		}
+
+		cont = meta.Continue
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
@@ -1,105 +1,28 @@
 package linode
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/iaas/utility"
 	"gitlab.com/grchive/grchive-v3/shared/utility/http"
-	"golang.org/x/net/context"
-	"io/ioutil"
-	"net/http"
 	"reflect"
+	"strconv"
 )
 
-func linodeGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
-	ctx := context.Background()
-	source := connectors.CreateSourceInfo()
-
-	if reflect.TypeOf(output).Kind() != reflect.Ptr {
-		return nil, errors.New("output must be a pointer.")
-	}
-
-	reflectOutPtr := reflect.ValueOf(output)
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"GET",
-		endpoint,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	bodyData, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+const linodeProvider = "Linode"
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("Linode API Error: " + string(bodyData))
-	}
-
-	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
-	if err != nil {
-		return nil, err
-	}
+func linodeGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	return iaas_utility.RestGet(client, linodeProvider, endpoint, output)
+}
 
-	cmd := &connectors.EtlCommandInfo{
-		Command: endpoint,
-		RawData: string(bodyData),
+// linodeNextPage expects each page's response to have a Pages field with the total number of pages.
+func linodeNextPage(endpoint string, page int, response reflect.Value) string {
+	pages := response.FieldByName("Pages").Int()
+	if int64(page) >= pages {
+		return ""
 	}
-	source.AddCommand(cmd)
-	return source, nil
+	return iaas_utility.SetQueryParameter(endpoint, "page", strconv.Itoa(page+1))
 }
 
 func linodePaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
-	cursor := ""
-	source := connectors.CreateSourceInfo()
-
-	if reflect.TypeOf(output).Kind() != reflect.Ptr {
-		return nil, errors.New("output must be a pointer to a slice.")
-	}
-
-	reflectOutPtr := reflect.ValueOf(output)
-	reflectOutSlice := reflectOutPtr.Elem()
-
-	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
-
-	page := int64(1)
-
-	for {
-		endpoint := baseEndpoint
-		if cursor != "" {
-			endpoint = endpoint + fmt.Sprintf("?page=%d", page)
-		}
-
-		responseBodyValue := reflect.New(reflectBaseType)
-		cmdSrc, err := linodeGet(client, endpoint, responseBodyValue.Interface())
-		if err != nil {
-			return nil, err
-		}
-
-		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
-		source.MergeWith(cmdSrc)
-
-		result := responseBodyValue.Elem()
-		pages := result.FieldByName("Pages").Interface().(int64)
-
-		if page == pages {
-			break
-		}
-
-		page += 1
-	}
-
-	reflectOutPtr.Elem().Set(reflectOutSlice)
-	return source, nil
+	return iaas_utility.RestPaginatedGet(client, linodeProvider, baseEndpoint, output, linodeNextPage)
 }
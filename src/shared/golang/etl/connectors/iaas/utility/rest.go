@@ -0,0 +1,126 @@
+package iaas_utility
+
+import (
+	"encoding/json"
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Helpers for the simpler IaaS providers whose APIs are plain JSON over HTTP with some form of
+// pagination (Linode, Vultr, DigitalOcean, etc.). The provider name is only used for error messages.
+
+// NextPageFn is given the endpoint that was just retrieved, the (1-indexed) page number and the response
+// for that page. It returns the endpoint of the next page or an empty string if there are no more pages.
+type NextPageFn func(endpoint string, page int, response reflect.Value) string
+
+func RestGet(client http_utility.HttpClient, provider string, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(provider + " API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// RestPaginatedGet expects output to be a pointer to a slice of structs where each struct is the
+// response body for a single page. Each page's response is appended to the slice.
+func RestPaginatedGet(client http_utility.HttpClient, provider string, baseEndpoint string, output interface{}, next NextPageFn) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	// Guards against APIs that keep handing back the same page.
+	seen := map[string]bool{}
+
+	endpoint := baseEndpoint
+	page := 1
+	for endpoint != "" && !seen[endpoint] {
+		seen[endpoint] = true
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		cmdSrc, err := RestGet(client, provider, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		endpoint = next(endpoint, page, responseBodyValue.Elem())
+		page += 1
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
+
+// SetQueryParameter returns the endpoint with the query parameter set to the value. Any existing
+// value for the parameter is replaced.
+func SetQueryParameter(endpoint string, key string, value string) string {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		separator := "?"
+		if strings.Contains(endpoint, "?") {
+			separator = "&"
+		}
+		return endpoint + separator + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	}
+
+	query := parsed.Query()
+	query.Set(key, value)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
@@ -1,103 +1,27 @@
 package vultr
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/iaas/utility"
 	"gitlab.com/grchive/grchive-v3/shared/utility/http"
-	"golang.org/x/net/context"
-	"io/ioutil"
-	"net/http"
 	"reflect"
 )
 
-func vultrGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
-	ctx := context.Background()
-	source := connectors.CreateSourceInfo()
-
-	if reflect.TypeOf(output).Kind() != reflect.Ptr {
-		return nil, errors.New("output must be a pointer.")
-	}
-
-	reflectOutPtr := reflect.ValueOf(output)
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"GET",
-		endpoint,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	bodyData, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+const vultrProvider = "Vultr"
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("Vultr API Error: " + string(bodyData))
-	}
-
-	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
-	if err != nil {
-		return nil, err
-	}
+func vultrGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	return iaas_utility.RestGet(client, vultrProvider, endpoint, output)
+}
 
-	cmd := &connectors.EtlCommandInfo{
-		Command: endpoint,
-		RawData: string(bodyData),
+// vultrNextPage expects each page's response to have a Meta field whose next link is the cursor for the next page.
+func vultrNextPage(endpoint string, page int, response reflect.Value) string {
+	meta := response.FieldByName("Meta").Interface().(vultrMeta)
+	if meta.Links.Next == "" {
+		return ""
 	}
-	source.AddCommand(cmd)
-	return source, nil
+	return iaas_utility.SetQueryParameter(endpoint, "cursor", meta.Links.Next)
 }
 
 func vultrPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
-	cursor := ""
-	source := connectors.CreateSourceInfo()
-
-	if reflect.TypeOf(output).Kind() != reflect.Ptr {
-		return nil, errors.New("output must be a pointer to a slice.")
-	}
-
-	reflectOutPtr := reflect.ValueOf(output)
-	reflectOutSlice := reflectOutPtr.Elem()
-
-	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
-
-	for {
-		endpoint := baseEndpoint
-		if cursor != "" {
-			endpoint = endpoint + fmt.Sprintf("?cursor=%s", cursor)
-		}
-
-		responseBodyValue := reflect.New(reflectBaseType)
-		cmdSrc, err := vultrGet(client, endpoint, responseBodyValue.Interface())
-		if err != nil {
-			return nil, err
-		}
-
-		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
-		source.MergeWith(cmdSrc)
-
-		result := responseBodyValue.Elem()
-		meta := result.FieldByName("Meta").Interface().(vultrMeta)
-
-		if meta.Links.Next == "" {
-			break
-		}
-
-		cursor = meta.Links.Next
-	}
-
-	reflectOutPtr.Elem().Set(reflectOutSlice)
-	return source, nil
+	return iaas_utility.RestPaginatedGet(client, vultrProvider, baseEndpoint, output, vultrNextPage)
 }
@@ -0,0 +1,373 @@
+package auth0
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// directPermissionRoleName is the role used to hold permissions that are assigned to the user directly rather
+// than through a role.
+const directPermissionRoleName = "Self"
+
+const directPermissionSource = "DIRECT"
+
+type auth0Role struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type auth0Permission struct {
+	PermissionName           string `json:"permission_name"`
+	ResourceServerIdentifier string `json:"resource_server_identifier"`
+	Sources                  []struct {
+		SourceId   string `json:"source_id"`
+		SourceName string `json:"source_name"`
+		SourceType string `json:"source_type"`
+	} `json:"sources"`
+}
+
+// isDirect determines whether or not the permission was assigned directly to the user. Older versions of the
+// API only return direct permissions and don't include the source of the permission.
+func (p auth0Permission) isDirect() bool {
+	if len(p.Sources) == 0 {
+		return true
+	}
+
+	for _, s := range p.Sources {
+		if s.SourceType == directPermissionSource {
+			return true
+		}
+	}
+	return false
+}
+
+type auth0RoleMember struct {
+	UserId string `json:"user_id"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+}
+
+type auth0Organization struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+type auth0OrganizationMember struct {
+	UserId string      `json:"user_id"`
+	Email  string      `json:"email"`
+	Name   string      `json:"name"`
+	Roles  []auth0Role `json:"roles"`
+}
+
+func permissionsToMap(permissions []auth0Permission) types.PermissionMap {
+	ret := types.PermissionMap{}
+	for _, p := range permissions {
+		ret[p.ResourceServerIdentifier] = append(ret[p.ResourceServerIdentifier], p.PermissionName)
+	}
+	return ret
+}
+
+func organizationRoleName(org auth0Organization, role string) string {
+	if role == "" {
+		return fmt.Sprintf("Organization: %s", org.Name)
+	}
+	return fmt.Sprintf("Organization: %s / %s", org.Name, role)
+}
+
+func (c *EtlAuth0ConnectorUser) getRoles() ([]auth0Role, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/roles", c.opts.apiBaseUrl())
+
+	pages := [][]auth0Role{}
+	source, err := auth0PaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retRoles := []auth0Role{}
+	for _, page := range pages {
+		retRoles = append(retRoles, page...)
+	}
+	return retRoles, source, nil
+}
+
+func (c *EtlAuth0ConnectorUser) getRolePermissions(roleId string) ([]auth0Permission, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/roles/%s/permissions", c.opts.apiBaseUrl(), url.PathEscape(roleId))
+
+	pages := [][]auth0Permission{}
+	source, err := auth0PaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retPermissions := []auth0Permission{}
+	for _, page := range pages {
+		retPermissions = append(retPermissions, page...)
+	}
+	return retPermissions, source, nil
+}
+
+func (c *EtlAuth0ConnectorUser) getRoleMembers(roleId string) ([]auth0RoleMember, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/roles/%s/users", c.opts.apiBaseUrl(), url.PathEscape(roleId))
+
+	pages := [][]auth0RoleMember{}
+	source, err := auth0PaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retMembers := []auth0RoleMember{}
+	for _, page := range pages {
+		retMembers = append(retMembers, page...)
+	}
+	return retMembers, source, nil
+}
+
+func (c *EtlAuth0ConnectorUser) getUserPermissions(userId string) ([]auth0Permission, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/users/%s/permissions", c.opts.apiBaseUrl(), url.PathEscape(userId))
+
+	pages := [][]auth0Permission{}
+	source, err := auth0PaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retPermissions := []auth0Permission{}
+	for _, page := range pages {
+		retPermissions = append(retPermissions, page...)
+	}
+	return retPermissions, source, nil
+}
+
+// getOrganizations lists the tenant's organizations. Tenants without the Organizations feature (404) and tokens
+// without the read:organizations scope (403) are treated as having no organizations.
+func (c *EtlAuth0ConnectorUser) getOrganizations() ([]auth0Organization, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/organizations", c.opts.apiBaseUrl())
+
+	pages := [][]auth0Organization{}
+	source, err := auth0PaginatedGet(c.opts.Client, endpoint, &pages)
+	if isAuth0ApiError(err, http.StatusForbidden, http.StatusNotFound) {
+		return []auth0Organization{}, connectors.CreateSourceInfo(), nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	retOrgs := []auth0Organization{}
+	for _, page := range pages {
+		retOrgs = append(retOrgs, page...)
+	}
+	return retOrgs, source, nil
+}
+
+func (c *EtlAuth0ConnectorUser) getOrganizationMembers(orgId string) ([]auth0OrganizationMember, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/organizations/%s/members?fields=user_id,email,name,roles&include_fields=true", c.opts.apiBaseUrl(), url.PathEscape(orgId))
+
+	pages := [][]auth0OrganizationMember{}
+	source, err := auth0PaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retMembers := []auth0OrganizationMember{}
+	for _, page := range pages {
+		retMembers = append(retMembers, page...)
+	}
+	return retMembers, source, nil
+}
+
+type auth0RoleDetails struct {
+	Role        auth0Role
+	Permissions []auth0Permission
+	Members     []auth0RoleMember
+}
+
+type auth0GetRoleDetailsJob struct {
+	// Input
+	Connector *EtlAuth0ConnectorUser
+
+	// Input + Output
+	Details   *auth0RoleDetails
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *auth0GetRoleDetailsJob) Do() error {
+	permissions, source, err := j.Connector.getRolePermissions(j.Details.Role.Id)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+
+	members, source, err := j.Connector.getRoleMembers(j.Details.Role.Id)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+
+	j.Details.Permissions = permissions
+	j.Details.Members = members
+	return nil
+}
+
+type auth0GetUserPermissionsJob struct {
+	// Input
+	UserId    string
+	Connector *EtlAuth0ConnectorUser
+
+	// Output
+	Permissions *[]auth0Permission
+	OutSource   chan *connectors.EtlSourceInfo
+}
+
+func (j *auth0GetUserPermissionsJob) Do() error {
+	permissions, source, err := j.Connector.getUserPermissions(j.UserId)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Permissions = permissions
+	return nil
+}
+
+// getPerUserRoles finds every role assigned to each user. The roles come from three different places:
+//  1. RBAC roles assigned to the user at the tenant level.
+//  2. Permissions assigned directly to the user.
+//  3. Organizations the user is a member of along with the roles they have in each organization.
+//
+// The output is keyed by the Auth0 user id.
+func (c *EtlAuth0ConnectorUser) getPerUserRoles(userIds []string) (map[string][]*types.EtlRole, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+	perUserRoles := map[string][]*types.EtlRole{}
+
+	roles, src, err := c.getRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	orgs, src, err := c.getOrganizations()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSource, sourcesToMerge)
+
+	allRoleDetails := make([]*auth0RoleDetails, len(roles))
+	perUserPermissions := map[string]*[]auth0Permission{}
+	allOrgMembers := make([][]auth0OrganizationMember, len(orgs))
+	{
+		pool := mt.NewTaskPool(10)
+		for idx, r := range roles {
+			allRoleDetails[idx] = &auth0RoleDetails{
+				Role: r,
+			}
+
+			pool.AddJob(&auth0GetRoleDetailsJob{
+				Connector: c,
+				Details:   allRoleDetails[idx],
+				OutSource: sourcesToMerge,
+			})
+		}
+
+		for _, id := range userIds {
+			permissions := []auth0Permission{}
+			pool.AddJob(&auth0GetUserPermissionsJob{
+				UserId:      id,
+				Connector:   c,
+				Permissions: &permissions,
+				OutSource:   sourcesToMerge,
+			})
+			perUserPermissions[id] = &permissions
+		}
+
+		err = pool.SyncExecute()
+
+		// Organization members are small enough in number that we don't really need to parallelize this.
+		if err == nil {
+			for idx, org := range orgs {
+				var src *connectors.EtlSourceInfo
+				allOrgMembers[idx], src, err = c.getOrganizationMembers(org.Id)
+				if err != nil {
+					break
+				}
+				sourcesToMerge <- src
+			}
+		}
+
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rolePermissions := map[string]types.PermissionMap{}
+	for _, details := range allRoleDetails {
+		rolePermissions[details.Role.Id] = permissionsToMap(details.Permissions)
+
+		for _, m := range details.Members {
+			perUserRoles[m.UserId] = append(perUserRoles[m.UserId], &types.EtlRole{
+				Name:        details.Role.Name,
+				Permissions: permissionsToMap(details.Permissions),
+			})
+		}
+	}
+
+	for id, permissions := range perUserPermissions {
+		direct := []auth0Permission{}
+		for _, p := range *permissions {
+			if p.isDirect() {
+				direct = append(direct, p)
+			}
+		}
+
+		if len(direct) == 0 {
+			continue
+		}
+
+		perUserRoles[id] = append(perUserRoles[id], &types.EtlRole{
+			Name:        directPermissionRoleName,
+			Permissions: permissionsToMap(direct),
+		})
+	}
+
+	for idx, org := range orgs {
+		for _, m := range allOrgMembers[idx] {
+			perUserRoles[m.UserId] = append(perUserRoles[m.UserId], &types.EtlRole{
+				Name:        organizationRoleName(org, ""),
+				Permissions: types.PermissionMap{},
+			})
+
+			for _, r := range m.Roles {
+				permissions, ok := rolePermissions[r.Id]
+				if !ok {
+					permissions = types.PermissionMap{}
+				}
+
+				perUserRoles[m.UserId] = append(perUserRoles[m.UserId], &types.EtlRole{
+					Name:        organizationRoleName(org, r.Name),
+					Permissions: permissions,
+				})
+			}
+		}
+	}
+
+	return perUserRoles, finalSource, nil
+}
@@ -8,6 +8,7 @@ import (
 )
 
 type auth0User struct {
+	UserId    string    `json:"user_id"`
 	Name      string    `json:"name"`
 	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
@@ -33,7 +34,7 @@ func createAuth0ConnectorUser(opts *EtlAuth0Options) (*EtlAuth0ConnectorUser, er
 	}, nil
 }
 
-func (c *EtlAuth0ConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+func (c *EtlAuth0ConnectorUser) getUsers() ([]auth0User, *connectors.EtlSourceInfo, error) {
 	endpoint := fmt.Sprintf("%s/users", c.opts.apiBaseUrl())
 
 	pages := [][]auth0User{}
@@ -42,11 +43,48 @@ func (c *EtlAuth0ConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.
 		return nil, nil, err
 	}
 
-	retUsers := []*types.EtlUser{}
+	retUsers := []auth0User{}
 	for _, page := range pages {
-		for _, u := range page {
-			retUsers = append(retUsers, u.toEtlUser())
-		}
+		retUsers = append(retUsers, page...)
 	}
 	return retUsers, source, nil
 }
+
+// GetUserListing returns every user in the tenant along with their roles, directly assigned permissions
+// and organization memberships.
+//
+// This does NOT include the tenant's dashboard administrators (Settings > Tenant Members). They aren't
+// users of the tenant and the Management API has no endpoint that lists them, so they have to be
+// reviewed separately (e.g. from the dashboard).
+func (c *EtlAuth0ConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	// Step 1: Get all users.
+	users, src, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	userIds := []string{}
+	for _, u := range users {
+		userIds = append(userIds, u.UserId)
+	}
+
+	// Step 2: Get the roles and permissions of every user.
+	perUserRoles, src, err := c.getPerUserRoles(userIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range users {
+		etlUser := u.toEtlUser()
+		for _, r := range perUserRoles[u.UserId] {
+			etlUser.Roles[r.Name] = r
+		}
+		retUsers = append(retUsers, etlUser)
+	}
+	return retUsers, finalSource, nil
+}
@@ -10,8 +10,33 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strings"
 )
 
+// auth0ApiError is an error response from the Management API.
+type auth0ApiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *auth0ApiError) Error() string {
+	return "Auth0 API Error: " + e.Body
+}
+
+func isAuth0ApiError(err error, statusCodes ...int) bool {
+	apiErr, ok := err.(*auth0ApiError)
+	if !ok {
+		return false
+	}
+
+	for _, code := range statusCodes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
 func auth0Get(client http_utility.HttpClient, endpoint string, output interface{}) (*http.Response, *connectors.EtlSourceInfo, error) {
 	ctx := context.Background()
 	source := connectors.CreateSourceInfo()
@@ -44,7 +69,10 @@ func auth0Get(client http_utility.HttpClient, endpoint string, output interface{
 	}
 
 	if resp.StatusCode >= http.StatusBadRequest {
-		return nil, nil, errors.New("Auth0 API Error: " + string(bodyData))
+		return nil, nil, &auth0ApiError{
+			StatusCode: resp.StatusCode,
+			Body:       string(bodyData),
+		}
 	}
 
 	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
@@ -75,8 +103,13 @@ func auth0PaginatedGet(client http_utility.HttpClient, baseEndpoint string, outp
 	page := 0
 	perPage := 50
 
+	separator := "?"
+	if strings.Contains(baseEndpoint, "?") {
+		separator = "&"
+	}
+
 	for {
-		endpoint := fmt.Sprintf("%s?page=%d&per_page=%d", baseEndpoint, page, perPage)
+		endpoint := fmt.Sprintf("%s%spage=%d&per_page=%d", baseEndpoint, separator, page, perPage)
 
 		responseBodyValue := reflect.New(reflectBaseType)
 		_, cmdSrc, err := auth0Get(client, endpoint, responseBodyValue.Interface())
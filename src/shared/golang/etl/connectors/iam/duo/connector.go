@@ -0,0 +1,44 @@
+package duo
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+type EtlDuoOptions struct {
+	Client http_utility.HttpClient
+	// Host is the account's API hostname (e.g. api-xxxxxxxx.duosecurity.com).
+	Host string
+}
+
+func (o EtlDuoOptions) apiBaseUrl() string {
+	host := strings.TrimSuffix(o.Host, "/")
+	if !strings.HasPrefix(host, "https://") {
+		host = "https://" + host
+	}
+	return host + "/admin/v1"
+}
+
+type EtlDuoConnector struct {
+	opts  *EtlDuoOptions
+	users *EtlDuoConnectorUser
+}
+
+func (c *EtlDuoConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateDuoConnector(opts *EtlDuoOptions) (*EtlDuoConnector, error) {
+	var err error
+	ret := EtlDuoConnector{
+		opts: opts,
+	}
+	ret.users, err = createDuoConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,209 @@
+package duo
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DuoAttributeUserId     = "user_id"
+	DuoAttributeStatus     = "status"
+	DuoAttributeIsEnrolled = "is_enrolled"
+	DuoAttributeMfaFactors = "mfa_factors"
+	DuoAttributeLastLogin  = "last_login"
+)
+
+// Integrations that don't restrict access to any groups can be used by every user. They're put under this role.
+const duoAllUsersRoleName = "All Users"
+const duoAppAccessPermission = "access"
+
+const (
+	DuoFactorPhone    = "phone"
+	DuoFactorToken    = "token"
+	DuoFactorU2f      = "u2f"
+	DuoFactorWebAuthn = "webauthn"
+)
+
+type duoGroup struct {
+	GroupId string `json:"group_id"`
+	Name    string `json:"name"`
+}
+
+type duoUser struct {
+	UserId    string     `json:"user_id"`
+	Username  string     `json:"username"`
+	RealName  string     `json:"realname"`
+	Email     string     `json:"email"`
+	Status    string     `json:"status"`
+	Enrolled  bool       `json:"is_enrolled"`
+	Created   int64      `json:"created"`
+	LastLogin *int64     `json:"last_login"`
+	Groups    []duoGroup `json:"groups"`
+	// Only the number of each kind of factor matters so the entries themselves aren't parsed.
+	Phones              []struct{} `json:"phones"`
+	Tokens              []struct{} `json:"tokens"`
+	U2fTokens           []struct{} `json:"u2ftokens"`
+	WebAuthnCredentials []struct{} `json:"webauthncredentials"`
+}
+
+func (u duoUser) factors() []string {
+	factors := []string{}
+	if len(u.Phones) > 0 {
+		factors = append(factors, DuoFactorPhone)
+	}
+	if len(u.Tokens) > 0 {
+		factors = append(factors, DuoFactorToken)
+	}
+	if len(u.U2fTokens) > 0 {
+		factors = append(factors, DuoFactorU2f)
+	}
+	if len(u.WebAuthnCredentials) > 0 {
+		factors = append(factors, DuoFactorWebAuthn)
+	}
+	sort.Strings(factors)
+	return factors
+}
+
+func (u duoUser) toEtlUser() *types.EtlUser {
+	created := time.Unix(u.Created, 0)
+	user := &types.EtlUser{
+		Username:    u.Username,
+		FullName:    u.RealName,
+		Email:       u.Email,
+		CreatedTime: &created,
+		Roles:       map[string]*types.EtlRole{},
+		Attributes: map[string]string{
+			DuoAttributeUserId:     u.UserId,
+			DuoAttributeStatus:     u.Status,
+			DuoAttributeIsEnrolled: strconv.FormatBool(u.Enrolled),
+			DuoAttributeMfaFactors: strings.Join(u.factors(), ","),
+		},
+	}
+
+	if u.LastLogin != nil {
+		user.Attributes[DuoAttributeLastLogin] = time.Unix(*u.LastLogin, 0).UTC().Format(time.RFC3339)
+	}
+	return user
+}
+
+type duoIntegration struct {
+	IntegrationKey string     `json:"integration_key"`
+	Name           string     `json:"name"`
+	Type           string     `json:"type"`
+	GroupsAllowed  []duoGroup `json:"groups_allowed"`
+}
+
+type EtlDuoConnectorUser struct {
+	opts *EtlDuoOptions
+}
+
+func createDuoConnectorUser(opts *EtlDuoOptions) (*EtlDuoConnectorUser, error) {
+	return &EtlDuoConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlDuoConnectorUser) getUsers() ([]duoUser, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/users", c.opts.apiBaseUrl())
+	pages := [][]duoUser{}
+	source, err := duoPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := []duoUser{}
+	for _, p := range pages {
+		users = append(users, p...)
+	}
+	return users, source, nil
+}
+
+func (c *EtlDuoConnectorUser) getIntegrations() ([]duoIntegration, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/integrations", c.opts.apiBaseUrl())
+	pages := [][]duoIntegration{}
+	source, err := duoPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	integrations := []duoIntegration{}
+	for _, p := range pages {
+		integrations = append(integrations, p...)
+	}
+	return integrations, source, nil
+}
+
+// createDuoRoles returns a role for every group, keyed by the group ID, along with the role that holds
+// the integrations open to every user.
+func createDuoRoles(integrations []duoIntegration) (map[string]*types.EtlRole, *types.EtlRole) {
+	groupRoles := map[string]*types.EtlRole{}
+	allUsersRole := &types.EtlRole{
+		Name:        duoAllUsersRoleName,
+		Permissions: map[string][]string{},
+	}
+
+	for _, i := range integrations {
+		permission := fmt.Sprintf("app::%s", i.Name)
+		if len(i.GroupsAllowed) == 0 {
+			allUsersRole.Permissions[permission] = []string{duoAppAccessPermission}
+			continue
+		}
+
+		for _, g := range i.GroupsAllowed {
+			role, ok := groupRoles[g.GroupId]
+			if !ok {
+				role = &types.EtlRole{
+					Name:        g.Name,
+					Permissions: map[string][]string{},
+				}
+				groupRoles[g.GroupId] = role
+			}
+			role.Permissions[permission] = []string{duoAppAccessPermission}
+		}
+	}
+	return groupRoles, allUsersRole
+}
+
+func (c *EtlDuoConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSrc := connectors.CreateSourceInfo()
+
+	users, src, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	integrations, src, err := c.getIntegrations()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	groupRoles, allUsersRole := createDuoRoles(integrations)
+
+	retUsers := make([]*types.EtlUser, len(users))
+	for idx, u := range users {
+		retUsers[idx] = u.toEtlUser()
+
+		for _, g := range u.Groups {
+			role, ok := groupRoles[g.GroupId]
+			if !ok {
+				role = &types.EtlRole{
+					Name:        g.Name,
+					Permissions: map[string][]string{},
+				}
+			}
+			retUsers[idx].Roles[role.Name] = role
+		}
+
+		if len(allUsersRole.Permissions) > 0 {
+			retUsers[idx].Roles[allUsersRole.Name] = allUsersRole
+		}
+	}
+	return retUsers, finalSrc, nil
+}
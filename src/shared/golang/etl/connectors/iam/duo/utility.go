@@ -0,0 +1,156 @@
+package duo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const duoPageSize int = 300
+
+// Every Admin API response is wrapped in this envelope. Stat is "OK" on success and "FAIL" otherwise.
+type duoResponse struct {
+	Stat          string          `json:"stat"`
+	Response      json.RawMessage `json:"response"`
+	Code          int             `json:"code"`
+	Message       string          `json:"message"`
+	MessageDetail string          `json:"message_detail"`
+	Metadata      struct {
+		NextOffset *int `json:"next_offset"`
+	} `json:"metadata"`
+}
+
+// Listing integrations returns each integration's secret key. Those are removed before the response
+// is recorded so they don't end up stored with the rest of the source data.
+const duoSecretKeyField = "secret_key"
+
+func redactDuoSecretKeys(body []byte) string {
+	if !strings.Contains(string(body), duoSecretKeyField) {
+		return string(body)
+	}
+
+	parsed := map[string]interface{}{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return string(body)
+	}
+
+	objs, ok := parsed["response"].([]interface{})
+	if !ok {
+		return string(body)
+	}
+
+	for _, o := range objs {
+		if m, ok := o.(map[string]interface{}); ok {
+			delete(m, duoSecretKeyField)
+		}
+	}
+
+	redacted, err := json.Marshal(parsed)
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func duoGet(client http_utility.HttpClient, endpoint string, output interface{}) (*duoResponse, *connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, errors.New("Duo API Error: " + string(bodyData))
+	}
+
+	envelope := duoResponse{}
+	err = json.Unmarshal(bodyData, &envelope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if envelope.Stat != "OK" {
+		return nil, nil, fmt.Errorf("Duo API Error: %d %s %s", envelope.Code, envelope.Message, envelope.MessageDetail)
+	}
+
+	err = json.Unmarshal(envelope.Response, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: redactDuoSecretKeys(bodyData),
+	}
+	source.AddCommand(cmd)
+	return &envelope, source, nil
+}
+
+// duoPaginatedGet expects output to be a pointer to a slice of slices. Each page is appended to the slice.
+func duoPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	offset := 0
+	for {
+		endpoint := fmt.Sprintf("%s?limit=%d&offset=%d", baseEndpoint, duoPageSize, offset)
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		envelope, cmdSrc, err := duoGet(client, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		next := envelope.Metadata.NextOffset
+		if next == nil || *next <= offset {
+			break
+		}
+
+		offset = *next
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
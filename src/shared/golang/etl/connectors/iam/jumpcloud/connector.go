@@ -0,0 +1,35 @@
+package jumpcloud
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+const baseUrl = "https://console.jumpcloud.com/api"
+
+type EtlJumpCloudOptions struct {
+	Client http_utility.HttpClient
+}
+
+type EtlJumpCloudConnector struct {
+	opts  *EtlJumpCloudOptions
+	users *EtlJumpCloudConnectorUser
+}
+
+func (c *EtlJumpCloudConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateJumpCloudConnector(opts *EtlJumpCloudOptions) (*EtlJumpCloudConnector, error) {
+	var err error
+	ret := EtlJumpCloudConnector{
+		opts: opts,
+	}
+	ret.users, err = createJumpCloudConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
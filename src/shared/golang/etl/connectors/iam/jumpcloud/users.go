@@ -0,0 +1,310 @@
+package jumpcloud
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	JumpCloudAttributeUserId        = "user_id"
+	JumpCloudAttributeState         = "state"
+	JumpCloudAttributeActivated     = "activated"
+	JumpCloudAttributeSuspended     = "suspended"
+	JumpCloudAttributeAccountLocked = "account_locked"
+	JumpCloudAttributeMfaConfigured = "mfa_configured"
+	JumpCloudAttributeTotpEnabled   = "totp_enabled"
+	JumpCloudAttributeSudo          = "sudo"
+)
+
+const jumpCloudAppAccessPermission = "access"
+
+type jumpCloudUser struct {
+	Id            string    `json:"_id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	FirstName     string    `json:"firstname"`
+	LastName      string    `json:"lastname"`
+	DisplayName   string    `json:"displayname"`
+	State         string    `json:"state"`
+	Activated     bool      `json:"activated"`
+	Suspended     bool      `json:"suspended"`
+	AccountLocked bool      `json:"account_locked"`
+	TotpEnabled   bool      `json:"totp_enabled"`
+	Sudo          bool      `json:"sudo"`
+	Created       time.Time `json:"created"`
+	Mfa           struct {
+		Configured bool `json:"configured"`
+	} `json:"mfa"`
+}
+
+func (u jumpCloudUser) toEtlUser() *types.EtlUser {
+	fullName := u.DisplayName
+	if fullName == "" {
+		fullName = strings.TrimSpace(u.FirstName + " " + u.LastName)
+	}
+
+	return &types.EtlUser{
+		Username:    u.Username,
+		FullName:    fullName,
+		Email:       u.Email,
+		CreatedTime: &u.Created,
+		Roles:       map[string]*types.EtlRole{},
+		Attributes: map[string]string{
+			JumpCloudAttributeUserId:        u.Id,
+			JumpCloudAttributeState:         u.State,
+			JumpCloudAttributeActivated:     strconv.FormatBool(u.Activated),
+			JumpCloudAttributeSuspended:     strconv.FormatBool(u.Suspended),
+			JumpCloudAttributeAccountLocked: strconv.FormatBool(u.AccountLocked),
+			JumpCloudAttributeMfaConfigured: strconv.FormatBool(u.Mfa.Configured),
+			JumpCloudAttributeTotpEnabled:   strconv.FormatBool(u.TotpEnabled),
+			JumpCloudAttributeSudo:          strconv.FormatBool(u.Sudo),
+		},
+	}
+}
+
+type jumpCloudUserPage struct {
+	TotalCount int             `json:"totalCount"`
+	Results    []jumpCloudUser `json:"results"`
+}
+
+type jumpCloudApplication struct {
+	Id           string `json:"_id"`
+	Name         string `json:"name"`
+	DisplayLabel string `json:"displayLabel"`
+}
+
+func (a jumpCloudApplication) label() string {
+	if a.DisplayLabel != "" {
+		return a.DisplayLabel
+	}
+	return a.Name
+}
+
+type jumpCloudApplicationPage struct {
+	TotalCount int                    `json:"totalCount"`
+	Results    []jumpCloudApplication `json:"results"`
+}
+
+type jumpCloudUserGroup struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// jumpCloudGraphConnection is how the v2 API describes group members and the resources a group is bound to.
+type jumpCloudGraphConnection struct {
+	To struct {
+		Id   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"to"`
+}
+
+type EtlJumpCloudConnectorUser struct {
+	opts *EtlJumpCloudOptions
+}
+
+func createJumpCloudConnectorUser(opts *EtlJumpCloudOptions) (*EtlJumpCloudConnectorUser, error) {
+	return &EtlJumpCloudConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlJumpCloudConnectorUser) getUsers() ([]jumpCloudUser, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/systemusers", baseUrl)
+	pages := []jumpCloudUserPage{}
+	source, err := jumpCloudPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := []jumpCloudUser{}
+	for _, p := range pages {
+		users = append(users, p.Results...)
+	}
+	return users, source, nil
+}
+
+func (c *EtlJumpCloudConnectorUser) getApplications() (map[string]jumpCloudApplication, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/applications", baseUrl)
+	pages := []jumpCloudApplicationPage{}
+	source, err := jumpCloudPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apps := map[string]jumpCloudApplication{}
+	for _, p := range pages {
+		for _, a := range p.Results {
+			apps[a.Id] = a
+		}
+	}
+	return apps, source, nil
+}
+
+func (c *EtlJumpCloudConnectorUser) getUserGroups() ([]jumpCloudUserGroup, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/v2/usergroups", baseUrl)
+	pages := [][]jumpCloudUserGroup{}
+	source, err := jumpCloudPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := []jumpCloudUserGroup{}
+	for _, p := range pages {
+		groups = append(groups, p...)
+	}
+	return groups, source, nil
+}
+
+// getUserGroupConnections returns the IDs on the other end of the group's graph connections.
+func (c *EtlJumpCloudConnectorUser) getUserGroupConnections(endpoint string, connectionType string) ([]string, *connectors.EtlSourceInfo, error) {
+	pages := [][]jumpCloudGraphConnection{}
+	source, err := jumpCloudPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := []string{}
+	for _, p := range pages {
+		for _, conn := range p {
+			if conn.To.Type != connectionType {
+				continue
+			}
+			ids = append(ids, conn.To.Id)
+		}
+	}
+	return ids, source, nil
+}
+
+type jumpCloudUserGroupDetails struct {
+	MemberIds      []string
+	ApplicationIds []string
+}
+
+type jumpCloudGetUserGroupDetailsJob struct {
+	// Input
+	GroupId   string
+	Connector *EtlJumpCloudConnectorUser
+
+	// Output
+	Details   *jumpCloudUserGroupDetails
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *jumpCloudGetUserGroupDetailsJob) Do() error {
+	memberIds, source, err := j.Connector.getUserGroupConnections(
+		fmt.Sprintf("%s/v2/usergroups/%s/members", baseUrl, j.GroupId),
+		"user",
+	)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+
+	appIds, source, err := j.Connector.getUserGroupConnections(
+		fmt.Sprintf("%s/v2/usergroups/%s/associations?targets=application", baseUrl, j.GroupId),
+		"application",
+	)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+
+	j.Details.MemberIds = memberIds
+	j.Details.ApplicationIds = appIds
+	return nil
+}
+
+func createEtlRoleFromJumpCloud(group jumpCloudUserGroup, details *jumpCloudUserGroupDetails, apps map[string]jumpCloudApplication) *types.EtlRole {
+	role := &types.EtlRole{
+		Name:        group.Name,
+		Permissions: map[string][]string{},
+	}
+
+	for _, id := range details.ApplicationIds {
+		label := id
+		if app, ok := apps[id]; ok {
+			label = app.label()
+		}
+		role.Permissions[fmt.Sprintf("app::%s", label)] = []string{jumpCloudAppAccessPermission}
+	}
+	return role
+}
+
+func (c *EtlJumpCloudConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSrc := connectors.CreateSourceInfo()
+
+	users, src, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	apps, src, err := c.getApplications()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	groups, src, err := c.getUserGroups()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSrc, sourcesToMerge)
+
+	perGroupDetails := make([]*jumpCloudUserGroupDetails, len(groups))
+	{
+		pool := mt.NewTaskPool(10)
+		for idx, g := range groups {
+			details := jumpCloudUserGroupDetails{}
+			pool.AddJob(&jumpCloudGetUserGroupDetailsJob{
+				GroupId:   g.Id,
+				Connector: c,
+				Details:   &details,
+				OutSource: sourcesToMerge,
+			})
+			perGroupDetails[idx] = &details
+		}
+
+		err := pool.SyncExecute()
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	perUserRoles := map[string][]*types.EtlRole{}
+	for idx, g := range groups {
+		role := createEtlRoleFromJumpCloud(g, perGroupDetails[idx], apps)
+		for _, id := range perGroupDetails[idx].MemberIds {
+			perUserRoles[id] = append(perUserRoles[id], role)
+		}
+	}
+
+	retUsers := make([]*types.EtlUser, len(users))
+	for idx, u := range users {
+		retUsers[idx] = u.toEtlUser()
+		for _, r := range perUserRoles[u.Id] {
+			retUsers[idx].Roles[r.Name] = r
+		}
+	}
+	return retUsers, finalSrc, nil
+}
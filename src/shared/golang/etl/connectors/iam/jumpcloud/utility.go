@@ -0,0 +1,113 @@
+package jumpcloud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const jumpCloudPageSize int = 100
+
+func jumpCloudGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("JumpCloud API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// jumpCloudPaginatedGet expects output to be a pointer to a slice. Each page is appended to the slice.
+// The v2 API returns each page as a bare array so the page type can be a slice. The v1 API wraps
+// each page in an object so the page type must then be a struct with a Results slice.
+func jumpCloudPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	separator := "?"
+	if strings.Contains(baseEndpoint, "?") {
+		separator = "&"
+	}
+
+	skip := 0
+	for {
+		endpoint := fmt.Sprintf("%s%slimit=%d&skip=%d", baseEndpoint, separator, jumpCloudPageSize, skip)
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		cmdSrc, err := jumpCloudGet(client, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		page := responseBodyValue.Elem()
+		if page.Kind() == reflect.Struct {
+			page = page.FieldByName("Results")
+		}
+
+		if page.Len() < jumpCloudPageSize {
+			break
+		}
+
+		skip += page.Len()
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
@@ -0,0 +1,44 @@
+package onelogin
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+type EtlOneLoginOptions struct {
+	Client http_utility.HttpClient
+	// Subdomain is either the account's subdomain (e.g. "grchive" for grchive.onelogin.com) or its full hostname.
+	Subdomain string
+}
+
+func (o EtlOneLoginOptions) apiBaseUrl() string {
+	if strings.Contains(o.Subdomain, ".") {
+		return fmt.Sprintf("https://%s/api/2", o.Subdomain)
+	}
+	return fmt.Sprintf("https://%s.onelogin.com/api/2", o.Subdomain)
+}
+
+type EtlOneLoginConnector struct {
+	opts  *EtlOneLoginOptions
+	users *EtlOneLoginConnectorUser
+}
+
+func (c *EtlOneLoginConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateOneLoginConnector(opts *EtlOneLoginOptions) (*EtlOneLoginConnector, error) {
+	var err error
+	ret := EtlOneLoginConnector{
+		opts: opts,
+	}
+	ret.users, err = createOneLoginConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,362 @@
+package onelogin
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	OneLoginAttributeUserId      = "user_id"
+	OneLoginAttributeStatus      = "status"
+	OneLoginAttributeState       = "state"
+	OneLoginAttributeMfaEnrolled = "mfa_enrolled"
+	OneLoginAttributeMfaFactors  = "mfa_factors"
+	OneLoginAttributeLastLogin   = "last_login"
+)
+
+// Apps the user can sign in to that none of their roles give access to (i.e. assigned to the user directly)
+// are put under this role.
+const oneLoginDirectAppsRoleName = "Direct App Assignments"
+const oneLoginAppAccessPermission = "access"
+
+var oneLoginStatuses = map[int]string{
+	0: "unactivated",
+	1: "active",
+	2: "suspended",
+	3: "locked",
+	4: "password_expired",
+	5: "awaiting_password_reset",
+	7: "password_pending",
+	8: "security_questions_required",
+}
+
+var oneLoginStates = map[int]string{
+	0: "unapproved",
+	1: "approved",
+	2: "rejected",
+	3: "unlicensed",
+}
+
+type oneLoginUser struct {
+	Id        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstname"`
+	LastName  string    `json:"lastname"`
+	Status    int       `json:"status"`
+	State     int       `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	LastLogin string    `json:"last_login"`
+}
+
+type oneLoginRole struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type oneLoginApp struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type oneLoginDevice struct {
+	DeviceId       string `json:"device_id"`
+	AuthFactorName string `json:"auth_factor_name"`
+	Default        bool   `json:"default"`
+}
+
+func describeCode(codes map[int]string, code int) string {
+	if name, ok := codes[code]; ok {
+		return name
+	}
+	return strconv.Itoa(code)
+}
+
+func (u oneLoginUser) toEtlUser() *types.EtlUser {
+	username := u.Username
+	if username == "" {
+		username = u.Email
+	}
+
+	user := &types.EtlUser{
+		Username:       username,
+		FullName:       strings.TrimSpace(u.FirstName + " " + u.LastName),
+		Email:          u.Email,
+		CreatedTime:    &u.CreatedAt,
+		LastChangeTime: &u.UpdatedAt,
+		Roles:          map[string]*types.EtlRole{},
+		Attributes: map[string]string{
+			OneLoginAttributeUserId: strconv.FormatInt(u.Id, 10),
+			OneLoginAttributeStatus: describeCode(oneLoginStatuses, u.Status),
+			OneLoginAttributeState:  describeCode(oneLoginStates, u.State),
+		},
+	}
+
+	if u.LastLogin != "" {
+		user.Attributes[OneLoginAttributeLastLogin] = u.LastLogin
+	}
+	return user
+}
+
+type EtlOneLoginConnectorUser struct {
+	opts *EtlOneLoginOptions
+}
+
+func createOneLoginConnectorUser(opts *EtlOneLoginOptions) (*EtlOneLoginConnectorUser, error) {
+	return &EtlOneLoginConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlOneLoginConnectorUser) getUsers() ([]oneLoginUser, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/users", c.opts.apiBaseUrl())
+	pages := [][]oneLoginUser{}
+	source, err := oneLoginPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := []oneLoginUser{}
+	for _, p := range pages {
+		users = append(users, p...)
+	}
+	return users, source, nil
+}
+
+func (c *EtlOneLoginConnectorUser) getRoles() ([]oneLoginRole, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/roles", c.opts.apiBaseUrl())
+	pages := [][]oneLoginRole{}
+	source, err := oneLoginPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roles := []oneLoginRole{}
+	for _, p := range pages {
+		roles = append(roles, p...)
+	}
+	return roles, source, nil
+}
+
+// getRoleUsers returns the IDs of the users that have been given the role.
+func (c *EtlOneLoginConnectorUser) getRoleUsers(roleId int64) ([]int64, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/roles/%d/users", c.opts.apiBaseUrl(), roleId)
+	pages := [][]struct {
+		Id int64 `json:"id"`
+	}{}
+	source, err := oneLoginPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userIds := []int64{}
+	for _, p := range pages {
+		for _, u := range p {
+			userIds = append(userIds, u.Id)
+		}
+	}
+	return userIds, source, nil
+}
+
+// getRoleApps returns the apps the role gives access to.
+func (c *EtlOneLoginConnectorUser) getRoleApps(roleId int64) ([]oneLoginApp, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/roles/%d/apps", c.opts.apiBaseUrl(), roleId)
+	pages := [][]oneLoginApp{}
+	source, err := oneLoginPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apps := []oneLoginApp{}
+	for _, p := range pages {
+		apps = append(apps, p...)
+	}
+	return apps, source, nil
+}
+
+func (c *EtlOneLoginConnectorUser) getUserApps(userId int64) ([]oneLoginApp, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/users/%d/apps", c.opts.apiBaseUrl(), userId)
+	apps := []oneLoginApp{}
+	_, source, err := oneLoginGet(c.opts.Client, endpoint, &apps)
+	if err != nil {
+		return nil, nil, err
+	}
+	return apps, source, nil
+}
+
+func (c *EtlOneLoginConnectorUser) getUserDevices(userId int64) ([]oneLoginDevice, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/mfa/users/%d/devices", c.opts.apiBaseUrl(), userId)
+	devices := []oneLoginDevice{}
+	_, source, err := oneLoginGet(c.opts.Client, endpoint, &devices)
+	if err != nil {
+		return nil, nil, err
+	}
+	return devices, source, nil
+}
+
+type oneLoginUserAccess struct {
+	Apps    []oneLoginApp
+	Devices []oneLoginDevice
+}
+
+type oneLoginGetUserAccessJob struct {
+	// Input
+	UserId    int64
+	Connector *EtlOneLoginConnectorUser
+
+	// Output
+	Access    *oneLoginUserAccess
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *oneLoginGetUserAccessJob) Do() error {
+	apps, source, err := j.Connector.getUserApps(j.UserId)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+
+	devices, source, err := j.Connector.getUserDevices(j.UserId)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+
+	j.Access.Apps = apps
+	j.Access.Devices = devices
+	return nil
+}
+
+func appObject(app oneLoginApp) string {
+	return fmt.Sprintf("app::%s", app.Name)
+}
+
+func createEtlUserFromOneLogin(user oneLoginUser, roles []oneLoginRole, roleApps map[int64][]oneLoginApp, access *oneLoginUserAccess) *types.EtlUser {
+	retUser := user.toEtlUser()
+	grantedApps := map[int64]bool{}
+	for _, r := range roles {
+		role := &types.EtlRole{
+			Name:        r.Name,
+			Permissions: map[string][]string{},
+		}
+
+		for _, a := range roleApps[r.Id] {
+			role.Permissions[appObject(a)] = []string{oneLoginAppAccessPermission}
+			grantedApps[a.Id] = true
+		}
+		retUser.Roles[r.Name] = role
+	}
+
+	directApps := &types.EtlRole{
+		Name:        oneLoginDirectAppsRoleName,
+		Permissions: map[string][]string{},
+	}
+	for _, a := range access.Apps {
+		if !grantedApps[a.Id] {
+			directApps.Permissions[appObject(a)] = []string{oneLoginAppAccessPermission}
+		}
+	}
+
+	if len(directApps.Permissions) > 0 {
+		retUser.Roles[directApps.Name] = directApps
+	}
+
+	factors := []string{}
+	seen := map[string]bool{}
+	for _, d := range access.Devices {
+		if !seen[d.AuthFactorName] {
+			factors = append(factors, d.AuthFactorName)
+			seen[d.AuthFactorName] = true
+		}
+	}
+	sort.Strings(factors)
+
+	retUser.Attributes[OneLoginAttributeMfaEnrolled] = strconv.FormatBool(len(factors) > 0)
+	retUser.Attributes[OneLoginAttributeMfaFactors] = strings.Join(factors, ",")
+	return retUser
+}
+
+func (c *EtlOneLoginConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSrc := connectors.CreateSourceInfo()
+
+	users, src, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	// Roles are listed from the role's side since the user listing doesn't include them.
+	roles, src, err := c.getRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	perUserRoles := map[int64][]oneLoginRole{}
+	roleApps := map[int64][]oneLoginApp{}
+	for _, r := range roles {
+		userIds, src, err := c.getRoleUsers(r.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSrc.MergeWith(src)
+
+		for _, id := range userIds {
+			perUserRoles[id] = append(perUserRoles[id], r)
+		}
+
+		roleApps[r.Id], src, err = c.getRoleApps(r.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSrc.MergeWith(src)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSrc, sourcesToMerge)
+
+	perUserAccess := make([]*oneLoginUserAccess, len(users))
+	{
+		pool := mt.NewTaskPool(10)
+		for idx, u := range users {
+			access := oneLoginUserAccess{}
+			pool.AddJob(&oneLoginGetUserAccessJob{
+				UserId:    u.Id,
+				Connector: c,
+				Access:    &access,
+				OutSource: sourcesToMerge,
+			})
+			perUserAccess[idx] = &access
+		}
+
+		err := pool.SyncExecute()
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	retUsers := make([]*types.EtlUser, len(users))
+	for idx, u := range users {
+		retUsers[idx] = createEtlUserFromOneLogin(u, perUserRoles[u.Id], roleApps, perUserAccess[idx])
+	}
+	return retUsers, finalSrc, nil
+}
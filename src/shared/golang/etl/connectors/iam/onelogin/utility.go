@@ -0,0 +1,114 @@
+package onelogin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const oneLoginPageSize int = 100
+
+// The cursor for the next page is returned in this header. It's empty on the last page.
+const oneLoginAfterCursorHeader = "After-Cursor"
+
+func oneLoginGet(client http_utility.HttpClient, endpoint string, output interface{}) (*http.Response, *connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, errors.New("OneLogin API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return resp, source, nil
+}
+
+// oneLoginPaginatedGet expects output to be a pointer to a slice of slices. Each page is appended to the slice.
+func oneLoginPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	separator := "?"
+	if strings.Contains(baseEndpoint, "?") {
+		separator = "&"
+	}
+
+	cursor := ""
+	for {
+		endpoint := fmt.Sprintf("%s%slimit=%d", baseEndpoint, separator, oneLoginPageSize)
+		if cursor != "" {
+			endpoint = fmt.Sprintf("%s&cursor=%s", endpoint, url.QueryEscape(cursor))
+		}
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		resp, cmdSrc, err := oneLoginGet(client, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		next := resp.Header.Get(oneLoginAfterCursorHeader)
+		if next == "" || next == cursor {
+			break
+		}
+
+		cursor = next
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
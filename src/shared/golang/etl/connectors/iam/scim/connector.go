@@ -0,0 +1,72 @@
+package scim
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+// The schema of the enterprise user extension (RFC 7643 section 4.3).
+const ScimEnterpriseUserSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+const scimDefaultPageSize = 100
+
+// EtlScimExtensionAttribute copies an attribute of a schema extension (e.g. the enterprise extension's
+// department or a vendor specific one) into the attributes of the user.
+type EtlScimExtensionAttribute struct {
+	// Defaults to the enterprise user extension.
+	Schema string
+	// The path to the attribute in the extension with sub-attributes separated by '.' (e.g. manager.value).
+	Path string
+	// The name of the attribute on the user. Defaults to the path.
+	Name string
+}
+
+type EtlScimOptions struct {
+	// The client needs to authenticate with the bearer token (see auth_utility.CreateBearerTokenHttpClient).
+	Client http_utility.HttpClient
+	// The URL that the /Users and /Groups endpoints are relative to (e.g. https://api.slack.com/scim/v2).
+	BaseUrl string
+	// SCIM filters (e.g. `userName sw "j"`) to limit what users/groups are listed. Everything if empty.
+	UserFilter  string
+	GroupFilter string
+	// The number of users/groups to request per page. The server may return fewer.
+	PageSize int
+	// Some providers don't support the /Groups endpoint in which case only the groups listed on the users are used.
+	SkipGroups          bool
+	ExtensionAttributes []EtlScimExtensionAttribute
+}
+
+func (o EtlScimOptions) apiBaseUrl() string {
+	return strings.TrimSuffix(o.BaseUrl, "/")
+}
+
+func (o EtlScimOptions) pageSize() int {
+	if o.PageSize <= 0 {
+		return scimDefaultPageSize
+	}
+	return o.PageSize
+}
+
+type EtlScimConnector struct {
+	opts  *EtlScimOptions
+	users *EtlScimConnectorUser
+}
+
+func (c *EtlScimConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateScimConnector(opts *EtlScimOptions) (*EtlScimConnector, error) {
+	var err error
+	ret := EtlScimConnector{
+		opts: opts,
+	}
+	ret.users, err = createScimConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
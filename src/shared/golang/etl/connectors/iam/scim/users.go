@@ -0,0 +1,352 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ScimAttributeId         = "id"
+	ScimAttributeExternalId = "external_id"
+	ScimAttributeActive     = "active"
+	ScimAttributeUserType   = "user_type"
+)
+
+// The objects that the user's own roles and entitlements are listed under in the Self role.
+const (
+	ScimRolesObject        = "roles"
+	ScimEntitlementsObject = "entitlements"
+)
+
+type scimMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display"`
+	Type    string `json:"type"`
+	Primary bool   `json:"primary"`
+}
+
+func multiValues(values []scimMultiValue) []string {
+	ret := []string{}
+	for _, v := range values {
+		ret = append(ret, v.Value)
+	}
+	return ret
+}
+
+type scimMeta struct {
+	Created      *time.Time `json:"created"`
+	LastModified *time.Time `json:"lastModified"`
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted"`
+	GivenName  string `json:"givenName"`
+	FamilyName string `json:"familyName"`
+}
+
+type scimUser struct {
+	Id           string           `json:"id"`
+	ExternalId   string           `json:"externalId"`
+	UserName     string           `json:"userName"`
+	Name         scimName         `json:"name"`
+	DisplayName  string           `json:"displayName"`
+	UserType     string           `json:"userType"`
+	Active       *bool            `json:"active"`
+	Emails       []scimMultiValue `json:"emails"`
+	Groups       []scimMultiValue `json:"groups"`
+	Roles        []scimMultiValue `json:"roles"`
+	Entitlements []scimMultiValue `json:"entitlements"`
+	Meta         scimMeta         `json:"meta"`
+
+	// Every attribute, including those of schema extensions.
+	raw map[string]interface{}
+}
+
+func (u scimUser) fullName() string {
+	if u.Name.Formatted != "" {
+		return u.Name.Formatted
+	}
+
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", u.Name.GivenName, u.Name.FamilyName))
+}
+
+// email returns the primary email or the first one if none is marked as primary.
+func (u scimUser) email() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// active returns whether the user is active. Servers that don't support deactivating users may leave it out.
+func (u scimUser) active() bool {
+	return u.Active == nil || *u.Active
+}
+
+func formatScimValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		values := []string{}
+		for _, vv := range v {
+			values = append(values, formatScimValue(vv))
+		}
+		return strings.Join(values, ",")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// extensionAttribute returns the value of an attribute of a schema extension and whether the user has it.
+func (u scimUser) extensionAttribute(attr EtlScimExtensionAttribute) (string, bool) {
+	schema := attr.Schema
+	if schema == "" {
+		schema = ScimEnterpriseUserSchema
+	}
+
+	var current interface{} = u.raw[schema]
+	for _, part := range strings.Split(attr.Path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+
+		// Attribute names are case insensitive.
+		found := false
+		for k, v := range obj {
+			if strings.EqualFold(k, part) {
+				current = v
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return "", false
+		}
+	}
+
+	return formatScimValue(current), true
+}
+
+type scimGroup struct {
+	Id          string           `json:"id"`
+	DisplayName string           `json:"displayName"`
+	Members     []scimMultiValue `json:"members"`
+}
+
+type EtlScimConnectorUser struct {
+	opts *EtlScimOptions
+}
+
+func createScimConnectorUser(opts *EtlScimOptions) (*EtlScimConnectorUser, error) {
+	return &EtlScimConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlScimConnectorUser) getUsers() ([]scimUser, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/Users", c.opts.apiBaseUrl())
+	resources, source, err := scimPaginatedGet(c.opts.Client, endpoint, c.opts.UserFilter, c.opts.pageSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := []scimUser{}
+	for _, r := range resources {
+		u := scimUser{}
+		err = json.Unmarshal(r, &u)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = json.Unmarshal(r, &u.raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		users = append(users, u)
+	}
+	return users, source, nil
+}
+
+func (c *EtlScimConnectorUser) getGroups() ([]scimGroup, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/Groups", c.opts.apiBaseUrl())
+	resources, source, err := scimPaginatedGet(c.opts.Client, endpoint, c.opts.GroupFilter, c.opts.pageSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups := []scimGroup{}
+	for _, r := range resources {
+		g := scimGroup{}
+		err = json.Unmarshal(r, &g)
+		if err != nil {
+			return nil, nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, source, nil
+}
+
+// Obtain users and the groups they're a member of.
+// Every group the user is a member of (directly or through another group) is listed as a role without any
+// permissions. Memberships are taken from both the groups' members and the users' groups since servers
+// don't necessarily return both. The user's roles and entitlements are listed in the Self role.
+func (c *EtlScimConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	users, source, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	groups := []scimGroup{}
+	if !c.opts.SkipGroups {
+		groups, source, err = c.getGroups()
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+	}
+
+	groupNames := map[string]string{}
+	// The groups that each user/group is a direct member of keyed by the id of the member.
+	memberOf := map[string]map[string]bool{}
+	addMembership := func(member string, group string) {
+		if _, ok := memberOf[member]; !ok {
+			memberOf[member] = map[string]bool{}
+		}
+		memberOf[member][group] = true
+	}
+
+	for _, g := range groups {
+		groupNames[g.Id] = g.DisplayName
+		for _, m := range g.Members {
+			addMembership(m.Value, g.Id)
+		}
+	}
+
+	for _, u := range users {
+		for _, g := range u.Groups {
+			addMembership(u.Id, g.Value)
+			if _, ok := groupNames[g.Value]; !ok && g.Display != "" {
+				groupNames[g.Value] = g.Display
+			}
+		}
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range users {
+		user := &types.EtlUser{
+			Username:       u.UserName,
+			FullName:       u.fullName(),
+			Email:          u.email(),
+			CreatedTime:    u.Meta.Created,
+			LastChangeTime: u.Meta.LastModified,
+			Roles:          map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				ScimAttributeId:     u.Id,
+				ScimAttributeActive: strconv.FormatBool(u.active()),
+			},
+		}
+
+		if u.ExternalId != "" {
+			user.Attributes[ScimAttributeExternalId] = u.ExternalId
+		}
+
+		if u.UserType != "" {
+			user.Attributes[ScimAttributeUserType] = u.UserType
+		}
+
+		for _, attr := range c.opts.ExtensionAttributes {
+			value, ok := u.extensionAttribute(attr)
+			if !ok {
+				continue
+			}
+
+			name := attr.Name
+			if name == "" {
+				name = attr.Path
+			}
+			user.Attributes[name] = value
+		}
+
+		if len(u.Roles) > 0 || len(u.Entitlements) > 0 {
+			self := &types.EtlRole{
+				Name:        "Self",
+				Permissions: map[string][]string{},
+			}
+
+			if len(u.Roles) > 0 {
+				self.Permissions[ScimRolesObject] = multiValues(u.Roles)
+			}
+
+			if len(u.Entitlements) > 0 {
+				self.Permissions[ScimEntitlementsObject] = multiValues(u.Entitlements)
+			}
+			user.Roles[self.Name] = self
+		}
+
+		visited := map[string]bool{}
+		queue := []string{u.Id}
+		for len(queue) > 0 {
+			member := queue[0]
+			queue = queue[1:]
+
+			parents := []string{}
+			for g := range memberOf[member] {
+				parents = append(parents, g)
+			}
+			sort.Strings(parents)
+
+			for _, g := range parents {
+				if visited[g] {
+					continue
+				}
+				visited[g] = true
+				queue = append(queue, g)
+
+				name := groupNames[g]
+				if name == "" {
+					name = g
+				}
+
+				user.Roles[name] = &types.EtlRole{
+					Name:        name,
+					Permissions: map[string][]string{},
+				}
+			}
+		}
+
+		retUsers = append(retUsers, user)
+	}
+
+	return retUsers, finalSource, nil
+}
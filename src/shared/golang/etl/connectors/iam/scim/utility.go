@@ -0,0 +1,99 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type scimListResponse struct {
+	TotalResults int               `json:"totalResults"`
+	StartIndex   int               `json:"startIndex"`
+	ItemsPerPage int               `json:"itemsPerPage"`
+	Resources    []json.RawMessage `json:"Resources"`
+}
+
+func scimGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/scim+json, application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("SCIM API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, output)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// scimPaginatedGet lists every resource of an endpoint (e.g. /Users) that matches the filter. Pages are
+// requested with a 1-based startIndex and count until totalResults resources have been returned.
+func scimPaginatedGet(client http_utility.HttpClient, baseEndpoint string, filter string, pageSize int) ([]json.RawMessage, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	resources := []json.RawMessage{}
+	startIndex := 1
+
+	for {
+		params := url.Values{}
+		params.Set("startIndex", strconv.Itoa(startIndex))
+		params.Set("count", strconv.Itoa(pageSize))
+		if filter != "" {
+			params.Set("filter", filter)
+		}
+		endpoint := baseEndpoint + "?" + params.Encode()
+
+		page := scimListResponse{}
+		cmdSrc, err := scimGet(client, endpoint, &page)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resources = append(resources, page.Resources...)
+		source.MergeWith(cmdSrc)
+
+		// Some servers ignore the paging parameters and return everything at once.
+		if len(page.Resources) == 0 || len(resources) >= page.TotalResults {
+			break
+		}
+
+		startIndex += len(page.Resources)
+	}
+
+	return resources, source, nil
+}
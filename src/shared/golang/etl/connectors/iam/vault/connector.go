@@ -0,0 +1,42 @@
+package vault
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+type EtlVaultOptions struct {
+	// The client needs to send a token that can read sys/auth, sys/policies/acl, identity/ and the
+	// auth methods' users and roles (see auth_utility.CreateVaultHttpClient).
+	Client http_utility.HttpClient
+	// The address of the Vault server (e.g. https://vault.example.com:8200).
+	Address string
+}
+
+func (o EtlVaultOptions) apiBaseUrl() string {
+	return strings.TrimSuffix(o.Address, "/") + "/v1"
+}
+
+type EtlVaultConnector struct {
+	opts  *EtlVaultOptions
+	users *EtlVaultConnectorUser
+}
+
+func (c *EtlVaultConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateVaultConnector(opts *EtlVaultOptions) (*EtlVaultConnector, error) {
+	var err error
+	ret := EtlVaultConnector{
+		opts: opts,
+	}
+	ret.users, err = createVaultConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
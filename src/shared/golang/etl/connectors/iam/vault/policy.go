@@ -0,0 +1,373 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Policies are written in HCL (or the equivalent JSON). Only the subset of HCL that policies use is
+// parsed: blocks with string labels, attributes, strings, numbers, booleans, lists and objects.
+
+const (
+	VaultCapabilityCreate = "create"
+	VaultCapabilityRead   = "read"
+	VaultCapabilityUpdate = "update"
+	VaultCapabilityPatch  = "patch"
+	VaultCapabilityDelete = "delete"
+	VaultCapabilityList   = "list"
+	VaultCapabilitySudo   = "sudo"
+	VaultCapabilityDeny   = "deny"
+)
+
+// The root policy is built into Vault and can do everything.
+const vaultRootPolicy = "root"
+
+var vaultRootCapabilities = []string{
+	VaultCapabilityCreate,
+	VaultCapabilityRead,
+	VaultCapabilityUpdate,
+	VaultCapabilityPatch,
+	VaultCapabilityDelete,
+	VaultCapabilityList,
+	VaultCapabilitySudo,
+}
+
+// Capabilities for the deprecated policy = "..." field of a path.
+var vaultLegacyPolicyCapabilities = map[string][]string{
+	"deny":  []string{VaultCapabilityDeny},
+	"read":  []string{VaultCapabilityRead, VaultCapabilityList},
+	"write": []string{VaultCapabilityCreate, VaultCapabilityRead, VaultCapabilityUpdate, VaultCapabilityDelete, VaultCapabilityList},
+	"sudo":  []string{VaultCapabilityCreate, VaultCapabilityRead, VaultCapabilityUpdate, VaultCapabilityDelete, VaultCapabilityList, VaultCapabilitySudo},
+}
+
+type hclItem struct {
+	Key    string
+	Labels []string
+	// A string, []interface{} or []hclItem for objects and blocks.
+	Value interface{}
+}
+
+type hclToken struct {
+	// One of {}[]=,: for punctuation, 's' for quoted strings and 'w' for bare words.
+	Kind byte
+	Text string
+	Line int
+}
+
+func tokenizeHcl(data string) ([]hclToken, error) {
+	tokens := []hclToken{}
+	line := 1
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '#' || (c == '/' && i+1 < len(data) && data[i+1] == '/'):
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(data[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("Unterminated comment on line %d.", line)
+			}
+			line += strings.Count(data[i:i+2+end], "\n")
+			i += end + 3
+		case strings.IndexByte("{}[]=,:", c) != -1:
+			tokens = append(tokens, hclToken{Kind: c, Line: line})
+		case c == '"':
+			end := i + 1
+			for ; end < len(data) && data[end] != '"'; end++ {
+				if data[end] == '\\' {
+					end++
+				} else if data[end] == '\n' {
+					break
+				}
+			}
+
+			if end >= len(data) || data[end] != '"' {
+				return nil, fmt.Errorf("Unterminated string on line %d.", line)
+			}
+
+			value, err := strconv.Unquote(data[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid string on line %d: %s", line, err.Error())
+			}
+			tokens = append(tokens, hclToken{Kind: 's', Text: value, Line: line})
+			i = end
+		case c == '_' || c == '-' || c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			end := i
+			for end < len(data) && (data[end] == '_' || data[end] == '-' || data[end] == '.' || (data[end] >= '0' && data[end] <= '9') || (data[end] >= 'a' && data[end] <= 'z') || (data[end] >= 'A' && data[end] <= 'Z')) {
+				end++
+			}
+			tokens = append(tokens, hclToken{Kind: 'w', Text: data[i:end], Line: line})
+			i = end - 1
+		default:
+			return nil, fmt.Errorf("Unexpected '%c' on line %d.", c, line)
+		}
+	}
+	return tokens, nil
+}
+
+type hclParser struct {
+	tokens []hclToken
+	pos    int
+}
+
+func (p *hclParser) peek() *hclToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *hclParser) errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if t := p.peek(); t != nil {
+		return fmt.Errorf("%s (line %d)", msg, t.Line)
+	}
+	return fmt.Errorf("%s (end of policy)", msg)
+}
+
+func (p *hclParser) accept(kind byte) bool {
+	if t := p.peek(); t != nil && t.Kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// body parses items until the closing brace of a block/object or the end of the input.
+func (p *hclParser) body(closing bool) ([]hclItem, error) {
+	items := []hclItem{}
+	for {
+		t := p.peek()
+		if t == nil {
+			if closing {
+				return nil, p.errorf("Expected '}'")
+			}
+			return items, nil
+		}
+
+		if t.Kind == '}' && closing {
+			p.pos++
+			return items, nil
+		}
+
+		if t.Kind != 'w' && t.Kind != 's' {
+			return nil, p.errorf("Expected a key")
+		}
+		p.pos++
+
+		item := hclItem{
+			Key:    t.Text,
+			Labels: []string{},
+		}
+		for p.peek() != nil && p.peek().Kind == 's' {
+			item.Labels = append(item.Labels, p.peek().Text)
+			p.pos++
+		}
+
+		if p.accept('=') || p.accept(':') {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			item.Value = value
+		} else if p.accept('{') {
+			value, err := p.body(true)
+			if err != nil {
+				return nil, err
+			}
+			item.Value = value
+		} else {
+			return nil, p.errorf("Expected '=' or '{' after %s", t.Text)
+		}
+
+		items = append(items, item)
+		p.accept(',')
+	}
+}
+
+func (p *hclParser) value() (interface{}, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, p.errorf("Expected a value")
+	}
+	p.pos++
+
+	switch t.Kind {
+	case 's', 'w':
+		return t.Text, nil
+	case '{':
+		return p.body(true)
+	case '[':
+		values := []interface{}{}
+		for !p.accept(']') {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+
+			if !p.accept(',') {
+				if !p.accept(']') {
+					return nil, p.errorf("Expected ',' or ']'")
+				}
+				break
+			}
+		}
+		return values, nil
+	}
+
+	p.pos--
+	return nil, p.errorf("Unexpected '%c'", t.Kind)
+}
+
+func parseHcl(data string) ([]hclItem, error) {
+	tokens, err := tokenizeHcl(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p := hclParser{tokens: tokens}
+	return p.body(false)
+}
+
+type vaultPolicyPath struct {
+	Capabilities []string `json:"capabilities"`
+	Policy       string   `json:"policy"`
+}
+
+type vaultJsonPolicy struct {
+	Path map[string]vaultPolicyPath `json:"path"`
+}
+
+func hclStrings(value interface{}) ([]string, bool) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	ret := []string{}
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		ret = append(ret, s)
+	}
+	return ret, true
+}
+
+// parseVaultPolicyPaths returns the path rules in the order they're defined in.
+func parseVaultPolicyPaths(policy string) ([]string, map[string][]vaultPolicyPath, error) {
+	order := []string{}
+	paths := map[string][]vaultPolicyPath{}
+
+	if strings.HasPrefix(strings.TrimSpace(policy), "{") {
+		parsed := vaultJsonPolicy{}
+		err := json.Unmarshal([]byte(policy), &parsed)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for path, rule := range parsed.Path {
+			order = append(order, path)
+			paths[path] = []vaultPolicyPath{rule}
+		}
+		sort.Strings(order)
+		return order, paths, nil
+	}
+
+	items, err := parseHcl(policy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, item := range items {
+		if item.Key != "path" {
+			continue
+		}
+
+		body, ok := item.Value.([]hclItem)
+		if len(item.Labels) != 1 || !ok {
+			return nil, nil, fmt.Errorf("Invalid path block in Vault policy.")
+		}
+
+		rule := vaultPolicyPath{}
+		for _, attr := range body {
+			switch attr.Key {
+			case "capabilities":
+				rule.Capabilities, ok = hclStrings(attr.Value)
+				if !ok {
+					return nil, nil, fmt.Errorf("Invalid capabilities for %s in Vault policy.", item.Labels[0])
+				}
+			case "policy":
+				rule.Policy, ok = attr.Value.(string)
+				if !ok {
+					return nil, nil, fmt.Errorf("Invalid policy for %s in Vault policy.", item.Labels[0])
+				}
+			}
+		}
+
+		path := item.Labels[0]
+		if _, ok := paths[path]; !ok {
+			order = append(order, path)
+		}
+		paths[path] = append(paths[path], rule)
+	}
+	return order, paths, nil
+}
+
+// parseVaultPolicy converts a policy into path -> capabilities. Vault merges rules for the same path
+// and deny takes precedence over every other capability, so denied paths only show up in Denied.
+func parseVaultPolicy(name string, policy string) (*types.EtlRole, error) {
+	role := types.EtlRole{
+		Name:        name,
+		Permissions: map[string][]string{},
+		Denied:      map[string][]string{},
+	}
+
+	if name == vaultRootPolicy {
+		role.Permissions["*"] = append([]string{}, vaultRootCapabilities...)
+		return &role, nil
+	}
+
+	order, paths, err := parseVaultPolicyPaths(policy)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse Vault policy %s: %s", name, err.Error())
+	}
+
+	for _, path := range order {
+		capabilities := []string{}
+		seen := map[string]bool{}
+		for _, rule := range paths[path] {
+			ruleCapabilities := rule.Capabilities
+			if len(ruleCapabilities) == 0 && rule.Policy != "" {
+				ruleCapabilities = vaultLegacyPolicyCapabilities[rule.Policy]
+			}
+
+			for _, c := range ruleCapabilities {
+				if !seen[c] {
+					capabilities = append(capabilities, c)
+					seen[c] = true
+				}
+			}
+		}
+
+		if seen[VaultCapabilityDeny] {
+			role.Denied[path] = []string{VaultCapabilityDeny}
+		} else if len(capabilities) > 0 {
+			role.Permissions[path] = capabilities
+		}
+	}
+
+	return &role, nil
+}
@@ -0,0 +1,583 @@
+package vault
+
+import (
+	"encoding/json"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	VaultAttributeEntityId    = "entity_id"
+	VaultAttributeDisabled    = "disabled"
+	VaultAttributeAliases     = "aliases"
+	VaultAttributeGroups      = "groups"
+	VaultAttributeAuthType    = "auth_type"
+	VaultAttributeAuthMount   = "auth_mount"
+	VaultAttributeBoundCidrs  = "bound_cidrs"
+	VaultAttributeBoundClaims = "bound_claims"
+	VaultAttributeUserClaim   = "user_claim"
+)
+
+// Every token gets the default policy unless the role that created it opts out.
+const vaultDefaultPolicy = "default"
+
+type vaultAuthMount struct {
+	Type        string `json:"type"`
+	Accessor    string `json:"accessor"`
+	Description string `json:"description"`
+}
+
+type vaultAlias struct {
+	Id            string `json:"id"`
+	Name          string `json:"name"`
+	MountAccessor string `json:"mount_accessor"`
+	MountPath     string `json:"mount_path"`
+	MountType     string `json:"mount_type"`
+}
+
+type vaultEntity struct {
+	Id                string            `json:"id"`
+	Name              string            `json:"name"`
+	Disabled          bool              `json:"disabled"`
+	Policies          []string          `json:"policies"`
+	GroupIds          []string          `json:"group_ids"`
+	DirectGroupIds    []string          `json:"direct_group_ids"`
+	InheritedGroupIds []string          `json:"inherited_group_ids"`
+	Aliases           []vaultAlias      `json:"aliases"`
+	Metadata          map[string]string `json:"metadata"`
+	CreationTime      *time.Time        `json:"creation_time"`
+	LastUpdateTime    *time.Time        `json:"last_update_time"`
+}
+
+type vaultGroup struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Policies []string `json:"policies"`
+}
+
+// vaultStringList accepts either a list or a comma separated string since auth methods differ in
+// how they return lists (e.g. the groups of an LDAP user).
+type vaultStringList []string
+
+func (l *vaultStringList) UnmarshalJSON(data []byte) error {
+	list := []string{}
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+
+	str := ""
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+
+	*l = []string{}
+	for _, s := range strings.Split(str, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// vaultAuthRole contains the fields of the users, groups and roles of the supported auth methods.
+type vaultAuthRole struct {
+	Policies             vaultStringList        `json:"policies"`
+	TokenPolicies        vaultStringList        `json:"token_policies"`
+	TokenNoDefaultPolicy bool                   `json:"token_no_default_policy"`
+	TokenBoundCidrs      vaultStringList        `json:"token_bound_cidrs"`
+	SecretIdBoundCidrs   vaultStringList        `json:"secret_id_bound_cidrs"`
+	Groups               vaultStringList        `json:"groups"`
+	BoundClaims          map[string]interface{} `json:"bound_claims"`
+	UserClaim            string                 `json:"user_claim"`
+}
+
+type vaultAuthRoleResponse struct {
+	Data vaultAuthRole `json:"data"`
+}
+
+type vaultRoleIdResponse struct {
+	Data struct {
+		RoleId string `json:"role_id"`
+	} `json:"data"`
+}
+
+// Where each supported auth method keeps the things that can log in.
+var vaultAuthPrincipalKinds = map[string][]string{
+	"userpass": []string{"users"},
+	"ldap":     []string{"users", "groups"},
+	"oidc":     []string{"role"},
+	"jwt":      []string{"role"},
+	"approle":  []string{"role"},
+}
+
+// vaultPrincipal is a user, group or role of an auth method.
+type vaultPrincipal struct {
+	Mount string
+	Auth  vaultAuthMount
+	Kind  string
+	Name  string
+	Role  vaultAuthRole
+	// Only for AppRole. Entity aliases for AppRole use the role ID rather than the name.
+	RoleId string
+}
+
+func (p *vaultPrincipal) path() string {
+	return "auth/" + p.Mount + p.Kind + "/" + p.Name
+}
+
+func (p *vaultPrincipal) policies() []string {
+	policies := append(append([]string{}, p.Role.Policies...), p.Role.TokenPolicies...)
+	// LDAP groups only add policies to the ones of the user so the default policy comes from the user.
+	if !p.Role.TokenNoDefaultPolicy && p.Kind != "groups" {
+		policies = append(policies, vaultDefaultPolicy)
+	}
+	return policies
+}
+
+// matchesAlias returns whether an entity alias was created by logging in with the principal.
+func (p *vaultPrincipal) matchesAlias(alias vaultAlias) bool {
+	if alias.MountAccessor != p.Auth.Accessor {
+		return false
+	}
+
+	switch p.Auth.Type {
+	case "userpass":
+		return p.Kind == "users" && alias.Name == p.Name
+	case "ldap":
+		// The LDAP auth method ignores case in usernames by default.
+		return p.Kind == "users" && strings.EqualFold(alias.Name, p.Name)
+	case "approle":
+		return p.RoleId != "" && alias.Name == p.RoleId
+	}
+	return false
+}
+
+type EtlVaultConnectorUser struct {
+	opts *EtlVaultOptions
+}
+
+func createVaultConnectorUser(opts *EtlVaultOptions) (*EtlVaultConnectorUser, error) {
+	return &EtlVaultConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+type vaultGetJob struct {
+	// Input
+	Client   http_utility.HttpClient
+	Endpoint string
+
+	// Output
+	Output    interface{}
+	Found     *bool
+	OutSource **connectors.EtlSourceInfo
+}
+
+func (j *vaultGetJob) Do() error {
+	source, err := vaultGet(j.Client, j.Endpoint, j.Output)
+	if err == errVaultNotFound {
+		// Deleted since it was listed.
+		*j.OutSource = connectors.CreateSourceInfo()
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	*j.Found = true
+	*j.OutSource = source
+	return nil
+}
+
+// vaultGetAll requests every endpoint concurrently. The sources are merged in the order of the
+// endpoints and found is false for endpoints that no longer exist.
+func vaultGetAll(client http_utility.HttpClient, endpoints []string, outputs []interface{}) ([]bool, *connectors.EtlSourceInfo, error) {
+	found := make([]bool, len(endpoints))
+	sources := make([]*connectors.EtlSourceInfo, len(endpoints))
+
+	pool := mt.NewTaskPool(10)
+	for idx, endpoint := range endpoints {
+		pool.AddJob(&vaultGetJob{
+			Client:    client,
+			Endpoint:  endpoint,
+			Output:    outputs[idx],
+			Found:     &found[idx],
+			OutSource: &sources[idx],
+		})
+	}
+
+	err := pool.SyncExecute()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	finalSrc := connectors.CreateSourceInfo()
+	for _, s := range sources {
+		finalSrc.MergeWith(s)
+	}
+	return found, finalSrc, nil
+}
+
+func (v *EtlVaultConnectorUser) getAuthMounts() ([]string, map[string]vaultAuthMount, *connectors.EtlSourceInfo, error) {
+	resp := struct {
+		Data map[string]vaultAuthMount `json:"data"`
+	}{}
+
+	source, err := vaultGet(v.opts.Client, v.opts.apiBaseUrl()+"/sys/auth", &resp)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	paths := []string{}
+	for path := range resp.Data {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, resp.Data, source, nil
+}
+
+func (v *EtlVaultConnectorUser) getPolicies() (map[string]*types.EtlRole, *connectors.EtlSourceInfo, error) {
+	baseEndpoint := v.opts.apiBaseUrl() + "/sys/policies/acl"
+	names, source, err := vaultList(v.opts.Client, baseEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endpoints := []string{}
+	outputs := []interface{}{}
+	readNames := []string{}
+	for _, name := range names {
+		if name == vaultRootPolicy {
+			continue
+		}
+
+		readNames = append(readNames, name)
+		endpoints = append(endpoints, baseEndpoint+"/"+url.PathEscape(name))
+		outputs = append(outputs, &vaultPolicyResponse{})
+	}
+
+	found, getSource, err := vaultGetAll(v.opts.Client, endpoints, outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	source.MergeWith(getSource)
+
+	roles := map[string]*types.EtlRole{}
+	roles[vaultRootPolicy], _ = parseVaultPolicy(vaultRootPolicy, "")
+
+	for idx, name := range readNames {
+		if !found[idx] {
+			continue
+		}
+
+		resp := outputs[idx].(*vaultPolicyResponse)
+
+		roles[name], err = parseVaultPolicy(name, resp.Data.Policy)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return roles, source, nil
+}
+
+type vaultPolicyResponse struct {
+	Data struct {
+		Policy string `json:"policy"`
+	} `json:"data"`
+}
+
+type vaultEntityResponse struct {
+	Data vaultEntity `json:"data"`
+}
+
+type vaultGroupResponse struct {
+	Data vaultGroup `json:"data"`
+}
+
+func (v *EtlVaultConnectorUser) getEntities() ([]*vaultEntity, *connectors.EtlSourceInfo, error) {
+	baseEndpoint := v.opts.apiBaseUrl() + "/identity/entity/id"
+	ids, source, err := vaultList(v.opts.Client, baseEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endpoints := []string{}
+	outputs := []interface{}{}
+	for _, id := range ids {
+		endpoints = append(endpoints, baseEndpoint+"/"+url.PathEscape(id))
+		outputs = append(outputs, &vaultEntityResponse{})
+	}
+
+	found, getSource, err := vaultGetAll(v.opts.Client, endpoints, outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	source.MergeWith(getSource)
+
+	entities := []*vaultEntity{}
+	for idx := range ids {
+		if found[idx] {
+			entities = append(entities, &outputs[idx].(*vaultEntityResponse).Data)
+		}
+	}
+	return entities, source, nil
+}
+
+func (v *EtlVaultConnectorUser) getGroups() (map[string]*vaultGroup, *connectors.EtlSourceInfo, error) {
+	baseEndpoint := v.opts.apiBaseUrl() + "/identity/group/id"
+	ids, source, err := vaultList(v.opts.Client, baseEndpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endpoints := []string{}
+	outputs := []interface{}{}
+	for _, id := range ids {
+		endpoints = append(endpoints, baseEndpoint+"/"+url.PathEscape(id))
+		outputs = append(outputs, &vaultGroupResponse{})
+	}
+
+	found, getSource, err := vaultGetAll(v.opts.Client, endpoints, outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	source.MergeWith(getSource)
+
+	groups := map[string]*vaultGroup{}
+	for idx, id := range ids {
+		if found[idx] {
+			groups[id] = &outputs[idx].(*vaultGroupResponse).Data
+		}
+	}
+	return groups, source, nil
+}
+
+func (v *EtlVaultConnectorUser) getPrincipals(mount string, auth vaultAuthMount) ([]*vaultPrincipal, *connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+	principals := []*vaultPrincipal{}
+
+	for _, kind := range vaultAuthPrincipalKinds[auth.Type] {
+		baseEndpoint := v.opts.apiBaseUrl() + "/auth/" + mount + kind
+		names, listSource, err := vaultList(v.opts.Client, baseEndpoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		source.MergeWith(listSource)
+
+		endpoints := []string{}
+		outputs := []interface{}{}
+		for _, name := range names {
+			endpoints = append(endpoints, baseEndpoint+"/"+url.PathEscape(name))
+			outputs = append(outputs, &vaultAuthRoleResponse{})
+
+			if auth.Type == "approle" {
+				endpoints = append(endpoints, baseEndpoint+"/"+url.PathEscape(name)+"/role-id")
+				outputs = append(outputs, &vaultRoleIdResponse{})
+			}
+		}
+
+		found, getSource, err := vaultGetAll(v.opts.Client, endpoints, outputs)
+		if err != nil {
+			return nil, nil, err
+		}
+		source.MergeWith(getSource)
+
+		idx := 0
+		for _, name := range names {
+			principal := vaultPrincipal{
+				Mount: mount,
+				Auth:  auth,
+				Kind:  kind,
+				Name:  name,
+				Role:  outputs[idx].(*vaultAuthRoleResponse).Data,
+			}
+			ok := found[idx]
+			idx++
+
+			if auth.Type == "approle" {
+				principal.RoleId = outputs[idx].(*vaultRoleIdResponse).Data.RoleId
+				idx++
+			}
+
+			if ok {
+				principals = append(principals, &principal)
+			}
+		}
+	}
+	return principals, source, nil
+}
+
+func addRoles(user *types.EtlUser, policies []string, roles map[string]*types.EtlRole) {
+	for _, name := range policies {
+		if _, ok := user.Roles[name]; ok {
+			continue
+		}
+
+		role, ok := roles[name]
+		if !ok {
+			// Policies can be assigned before they're written.
+			role = &types.EtlRole{
+				Name:        name,
+				Permissions: map[string][]string{},
+				Denied:      map[string][]string{},
+			}
+		}
+		user.Roles[name] = role
+	}
+}
+
+func principalAttributes(p *vaultPrincipal) map[string]string {
+	attributes := map[string]string{
+		VaultAttributeAuthType:  p.Auth.Type,
+		VaultAttributeAuthMount: p.Mount,
+	}
+
+	cidrs := append(append([]string{}, p.Role.TokenBoundCidrs...), p.Role.SecretIdBoundCidrs...)
+	if len(cidrs) > 0 {
+		attributes[VaultAttributeBoundCidrs] = strings.Join(cidrs, ",")
+	}
+
+	if len(p.Role.BoundClaims) > 0 {
+		claims, _ := json.Marshal(p.Role.BoundClaims)
+		attributes[VaultAttributeBoundClaims] = string(claims)
+	}
+
+	if p.Role.UserClaim != "" {
+		attributes[VaultAttributeUserClaim] = p.Role.UserClaim
+	}
+	return attributes
+}
+
+func (v *EtlVaultConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSrc := connectors.CreateSourceInfo()
+
+	mountPaths, mounts, source, err := v.getAuthMounts()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(source)
+
+	roles, source, err := v.getPolicies()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(source)
+
+	groups, source, err := v.getGroups()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(source)
+
+	entities, source, err := v.getEntities()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(source)
+
+	principals := []*vaultPrincipal{}
+	ldapGroups := map[string]*vaultPrincipal{}
+	for _, path := range mountPaths {
+		mountPrincipals, source, err := v.getPrincipals(path, mounts[path])
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSrc.MergeWith(source)
+
+		for _, p := range mountPrincipals {
+			if p.Auth.Type == "ldap" && p.Kind == "groups" {
+				ldapGroups[p.Mount+strings.ToLower(p.Name)] = p
+			}
+		}
+		principals = append(principals, mountPrincipals...)
+	}
+
+	principalPolicies := func(p *vaultPrincipal) []string {
+		policies := p.policies()
+		for _, g := range p.Role.Groups {
+			if group, ok := ldapGroups[p.Mount+strings.ToLower(g)]; ok {
+				policies = append(policies, group.policies()...)
+			}
+		}
+		return policies
+	}
+
+	users := []*types.EtlUser{}
+	matched := map[*vaultPrincipal]bool{}
+	for _, e := range entities {
+		user := types.EtlUser{
+			Username:       e.Name,
+			Email:          e.Metadata["email"],
+			CreatedTime:    e.CreationTime,
+			LastChangeTime: e.LastUpdateTime,
+			Roles:          map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				VaultAttributeEntityId: e.Id,
+				VaultAttributeDisabled: strconv.FormatBool(e.Disabled),
+			},
+		}
+		addRoles(&user, e.Policies, roles)
+
+		groupIds := map[string]bool{}
+		for _, ids := range [][]string{e.GroupIds, e.DirectGroupIds, e.InheritedGroupIds} {
+			for _, id := range ids {
+				groupIds[id] = true
+			}
+		}
+
+		groupNames := []string{}
+		for id := range groupIds {
+			if group, ok := groups[id]; ok {
+				groupNames = append(groupNames, group.Name)
+				addRoles(&user, group.Policies, roles)
+			}
+		}
+		sort.Strings(groupNames)
+
+		aliases := []string{}
+		for _, a := range e.Aliases {
+			aliases = append(aliases, a.MountPath+a.Name)
+			for _, p := range principals {
+				if p.matchesAlias(a) {
+					matched[p] = true
+					addRoles(&user, principalPolicies(p), roles)
+				}
+			}
+		}
+
+		if len(groupNames) > 0 {
+			user.Attributes[VaultAttributeGroups] = strings.Join(groupNames, ",")
+		}
+
+		if len(aliases) > 0 {
+			user.Attributes[VaultAttributeAliases] = strings.Join(aliases, ",")
+		}
+		users = append(users, &user)
+	}
+
+	// Anything that can log in but hasn't yet (and so doesn't have an entity) is listed by its path.
+	for _, p := range principals {
+		if matched[p] {
+			continue
+		}
+
+		user := types.EtlUser{
+			Username:   p.path(),
+			Roles:      map[string]*types.EtlRole{},
+			Attributes: principalAttributes(p),
+		}
+		addRoles(&user, principalPolicies(p), roles)
+		users = append(users, &user)
+	}
+
+	return users, finalSrc, nil
+}
@@ -0,0 +1,80 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+)
+
+var errVaultNotFound = errors.New("Vault API Error: Not found.")
+
+func vaultGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errVaultNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Vault API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, output)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+type vaultListResponse struct {
+	Data struct {
+		Keys []string `json:"keys"`
+	} `json:"data"`
+}
+
+// vaultList returns the keys under the endpoint. Vault responds with a 404 rather than an empty list
+// when there's nothing there.
+func vaultList(client http_utility.HttpClient, endpoint string) ([]string, *connectors.EtlSourceInfo, error) {
+	resp := vaultListResponse{}
+	source, err := vaultGet(client, endpoint+"?list=true", &resp)
+	if err == errVaultNotFound {
+		return []string{}, connectors.CreateSourceInfo(), nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.Data.Keys == nil {
+		resp.Data.Keys = []string{}
+	}
+	return resp.Data.Keys, source, nil
+}
@@ -0,0 +1,245 @@
+package heroku
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// appRoleName is the name of the role that holds every app a user collaborates on. Permissions on the
+// role are keyed by the app's name.
+const appRoleName = "Apps"
+
+type herokuApp struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Owner struct {
+		Id    string `json:"id"`
+		Email string `json:"email"`
+	} `json:"owner"`
+	Team *struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"team"`
+}
+
+func (a herokuApp) isPersonal() bool {
+	return a.Team == nil
+}
+
+type herokuCollaborator struct {
+	Id          string     `json:"id"`
+	Role        string     `json:"role"`
+	CreatedAt   time.Time  `json:"created_at"`
+	User        herokuUser `json:"user"`
+	Permissions []struct {
+		Name string `json:"name"`
+	} `json:"permissions"`
+}
+
+// permissionNames returns the permission set (view, deploy, operate, manage) the collaborator has on the app.
+// Collaborators on personal apps don't have a permission set so we fall back to their role on the app
+// (owner or collaborator) instead.
+func (c herokuCollaborator) permissionNames() []string {
+	ret := []string{}
+	for _, p := range c.Permissions {
+		ret = append(ret, p.Name)
+	}
+
+	if len(ret) == 0 && c.Role != "" {
+		ret = append(ret, c.Role)
+	}
+	return ret
+}
+
+func (c herokuCollaborator) toEtlUser(app herokuApp) *types.EtlUser {
+	return &types.EtlUser{
+		Username: c.User.Email,
+		Email:    c.User.Email,
+		Roles: map[string]*types.EtlRole{
+			appRoleName: &types.EtlRole{
+				Name: appRoleName,
+				Permissions: map[string][]string{
+					app.Name: c.permissionNames(),
+				},
+			},
+		},
+	}
+}
+
+func (c *EtlHerokuConnectorUser) getTeamApps() ([]herokuApp, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/teams/%s/apps", apiUrl, c.opts.TeamName)
+
+	pages := [][]herokuApp{}
+	source, err := herokuPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retApps := []herokuApp{}
+	for _, apps := range pages {
+		retApps = append(retApps, apps...)
+	}
+	return retApps, source, nil
+}
+
+// getPersonalApps returns the apps that the authenticated user has access to that don't belong to a team.
+func (c *EtlHerokuConnectorUser) getPersonalApps() ([]herokuApp, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/apps", apiUrl)
+
+	pages := [][]herokuApp{}
+	source, err := herokuPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retApps := []herokuApp{}
+	for _, apps := range pages {
+		for _, a := range apps {
+			if !a.isPersonal() {
+				continue
+			}
+			retApps = append(retApps, a)
+		}
+	}
+	return retApps, source, nil
+}
+
+func (c *EtlHerokuConnectorUser) getAppCollaborators(app herokuApp) ([]herokuCollaborator, *connectors.EtlSourceInfo, error) {
+	// Team apps need to go through the team app endpoint otherwise the collaborators' permissions won't be returned.
+	endpoint := fmt.Sprintf("%s/teams/apps/%s/collaborators", apiUrl, url.PathEscape(app.Name))
+	if app.isPersonal() {
+		endpoint = fmt.Sprintf("%s/apps/%s/collaborators", apiUrl, url.PathEscape(app.Name))
+	}
+
+	pages := [][]herokuCollaborator{}
+	source, err := herokuPaginatedGet(c.opts.Client, endpoint, &pages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retCollaborators := []herokuCollaborator{}
+	for _, collaborators := range pages {
+		retCollaborators = append(retCollaborators, collaborators...)
+	}
+
+	// The owner of a personal app isn't necessarily listed as a collaborator.
+	if app.isPersonal() && app.Owner.Email != "" {
+		found := false
+		for _, collab := range retCollaborators {
+			if collab.User.Email == app.Owner.Email {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			retCollaborators = append(retCollaborators, herokuCollaborator{
+				Role: "owner",
+				User: herokuUser{
+					Id:    app.Owner.Id,
+					Email: app.Owner.Email,
+				},
+			})
+		}
+	}
+	return retCollaborators, source, nil
+}
+
+type herokuGetAppCollaboratorsJob struct {
+	// Input
+	App       herokuApp
+	Connector *EtlHerokuConnectorUser
+
+	// Output
+	Collaborators *[]herokuCollaborator
+	OutSource     chan *connectors.EtlSourceInfo
+}
+
+func (j *herokuGetAppCollaboratorsJob) Do() error {
+	collaborators, source, err := j.Connector.getAppCollaborators(j.App)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Collaborators = collaborators
+	return nil
+}
+
+// getAppUsers returns a user for every collaborator on every team app and personal app. Each user has a
+// single role that holds their per-app permissions.
+func (c *EtlHerokuConnectorUser) getAppUsers() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSrc := connectors.CreateSourceInfo()
+
+	teamApps, src, err := c.getTeamApps()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	personalApps, src, err := c.getPersonalApps()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	allApps := append(teamApps, personalApps...)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSrc, sourcesToMerge)
+
+	perAppCollaborators := make([]*[]herokuCollaborator, len(allApps))
+	{
+		pool := mt.NewTaskPool(10)
+		for idx, app := range allApps {
+			collaborators := []herokuCollaborator{}
+			pool.AddJob(&herokuGetAppCollaboratorsJob{
+				App:           app,
+				Connector:     c,
+				Collaborators: &collaborators,
+				OutSource:     sourcesToMerge,
+			})
+			perAppCollaborators[idx] = &collaborators
+		}
+
+		err := pool.SyncExecute()
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	allUsers := map[string]*types.EtlUser{}
+	retUsers := []*types.EtlUser{}
+	for idx, app := range allApps {
+		for _, collab := range *perAppCollaborators[idx] {
+			etlUser := collab.toEtlUser(app)
+
+			existing, ok := allUsers[etlUser.Username]
+			if !ok {
+				allUsers[etlUser.Username] = etlUser
+				retUsers = append(retUsers, etlUser)
+				continue
+			}
+
+			existing.Roles[appRoleName].Permissions[app.Name] = etlUser.Roles[appRoleName].Permissions[app.Name]
+		}
+	}
+
+	return retUsers, finalSrc, nil
+}
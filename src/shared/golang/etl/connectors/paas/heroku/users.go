@@ -44,7 +44,7 @@ func createHerokuConnectorUser(opts *EtlHerokuOptions) (*EtlHerokuConnectorUser,
 	}, nil
 }
 
-func (c *EtlHerokuConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+func (c *EtlHerokuConnectorUser) getTeamMembers() ([]herokuTeamMember, *connectors.EtlSourceInfo, error) {
 	endpoint := fmt.Sprintf("%s/teams/%s/members", apiUrl, c.opts.TeamName)
 
 	pages := [][]herokuTeamMember{}
@@ -53,12 +53,49 @@ func (c *EtlHerokuConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors
 		return nil, nil, err
 	}
 
-	retUsers := []*types.EtlUser{}
+	retMembers := []herokuTeamMember{}
 	for _, members := range pages {
-		for _, u := range members {
-			retUsers = append(retUsers, u.toEtlUser())
+		retMembers = append(retMembers, members...)
+	}
+	return retMembers, source, nil
+}
+
+func (c *EtlHerokuConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSrc := connectors.CreateSourceInfo()
+
+	// Step 1: Get team members and their role on the team.
+	members, src, err := c.getTeamMembers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	allUsers := map[string]*types.EtlUser{}
+	retUsers := []*types.EtlUser{}
+	for _, u := range members {
+		etlUser := u.toEtlUser()
+		allUsers[etlUser.Username] = etlUser
+		retUsers = append(retUsers, etlUser)
+	}
+
+	// Step 2: Get the collaborators on every app along with their permissions on the app. Collaborators
+	// don't need to be members of the team so they may not have been found in the previous step.
+	appUsers, src, err := c.getAppUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSrc.MergeWith(src)
+
+	for _, u := range appUsers {
+		existing, ok := allUsers[u.Username]
+		if !ok {
+			allUsers[u.Username] = u
+			retUsers = append(retUsers, u)
+			continue
 		}
+
+		existing.Roles[appRoleName] = u.Roles[appRoleName]
 	}
 
-	return retUsers, source, nil
+	return retUsers, finalSrc, nil
 }
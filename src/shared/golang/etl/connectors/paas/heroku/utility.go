@@ -31,6 +31,12 @@ func herokuGet(client http_utility.HttpClient, endpoint string, output interface
 		return nil, nil, err
 	}
 
+	for k, v := range addtlHeaders {
+		for _, vv := range v {
+			req.Header.Add(k, vv)
+		}
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, nil, err
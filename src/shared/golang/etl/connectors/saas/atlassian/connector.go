@@ -0,0 +1,59 @@
+package atlassian
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+type EtlAtlassianOptions struct {
+	Client http_utility.HttpClient
+	// Site is either the site name (e.g. "grchive" for grchive.atlassian.net), its hostname or its full URL.
+	Site string
+	// AdminGroups are the groups whose members are site admins. Defaults to the groups Atlassian creates.
+	AdminGroups []string
+}
+
+type EtlAtlassianConnector struct {
+	opts  *EtlAtlassianOptions
+	users *EtlAtlassianConnectorUser
+}
+
+var defaultAtlassianAdminGroups = []string{"site-admins", "org-admins"}
+
+func (o *EtlAtlassianOptions) apiBaseUrl() string {
+	site := strings.TrimSuffix(o.Site, "/")
+	if !strings.Contains(site, "://") {
+		if !strings.Contains(site, ".") {
+			site = site + ".atlassian.net"
+		}
+		site = "https://" + site
+	}
+	return fmt.Sprintf("%s/rest/api/3", site)
+}
+
+func (o *EtlAtlassianOptions) adminGroups() []string {
+	if len(o.AdminGroups) == 0 {
+		return defaultAtlassianAdminGroups
+	}
+	return o.AdminGroups
+}
+
+func (c *EtlAtlassianConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateAtlassianConnector(opts *EtlAtlassianOptions) (*EtlAtlassianConnector, error) {
+	var err error
+	ret := EtlAtlassianConnector{
+		opts: opts,
+	}
+	ret.users, err = createAtlassianConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,146 @@
+package atlassian
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"net/url"
+	"sync"
+)
+
+type atlassianGroup struct {
+	Name    string `json:"name"`
+	GroupId string `json:"groupId"`
+}
+
+// atlassianApplicationRole is a product on the site (e.g. Jira Software). Membership in any of its
+// groups is what gives a user access to the product.
+type atlassianApplicationRole struct {
+	Key    string   `json:"key"`
+	Name   string   `json:"name"`
+	Groups []string `json:"groups"`
+}
+
+func (c *EtlAtlassianConnectorUser) getGroups() ([]atlassianGroup, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		atlassianPageInfo
+		Values []atlassianGroup `json:"values"`
+	}
+
+	endpoint := fmt.Sprintf("%s/group/bulk", c.opts.apiBaseUrl())
+	responses := []ResponseBody{}
+	source, err := atlassianPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retGroups := []atlassianGroup{}
+	for _, resp := range responses {
+		retGroups = append(retGroups, resp.Values...)
+	}
+	return retGroups, source, nil
+}
+
+func (c *EtlAtlassianConnectorUser) getGroupMembers(groupId string) ([]atlassianUser, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		atlassianPageInfo
+		Values []atlassianUser `json:"values"`
+	}
+
+	endpoint := fmt.Sprintf("%s/group/member?groupId=%s&includeInactiveUsers=true", c.opts.apiBaseUrl(), url.QueryEscape(groupId))
+	responses := []ResponseBody{}
+	source, err := atlassianPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retMembers := []atlassianUser{}
+	for _, resp := range responses {
+		retMembers = append(retMembers, resp.Values...)
+	}
+	return retMembers, source, nil
+}
+
+type atlassianGetGroupMembersJob struct {
+	// Input
+	GroupId   string
+	Connector *EtlAtlassianConnectorUser
+
+	// Output
+	Members   *[]atlassianUser
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *atlassianGetGroupMembersJob) Do() error {
+	members, source, err := j.Connector.getGroupMembers(j.GroupId)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Members = members
+	return nil
+}
+
+// getGroupMemberships returns the groups each user is directly a member of keyed by account ID.
+func (c *EtlAtlassianConnectorUser) getGroupMemberships() (map[string][]atlassianGroup, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	groups, src, err := c.getGroups()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSource, sourcesToMerge)
+
+	perGroupMembers := make([]*[]atlassianUser, len(groups))
+	{
+		pool := mt.NewTaskPool(10)
+		for idx, g := range groups {
+			members := []atlassianUser{}
+			pool.AddJob(&atlassianGetGroupMembersJob{
+				GroupId:   g.GroupId,
+				Connector: c,
+				Members:   &members,
+				OutSource: sourcesToMerge,
+			})
+			perGroupMembers[idx] = &members
+		}
+
+		err := pool.SyncExecute()
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	memberships := map[string][]atlassianGroup{}
+	for idx, g := range groups {
+		for _, m := range *perGroupMembers[idx] {
+			memberships[m.AccountId] = append(memberships[m.AccountId], g)
+		}
+	}
+	return memberships, finalSource, nil
+}
+
+func (c *EtlAtlassianConnectorUser) getApplicationRoles() ([]atlassianApplicationRole, *connectors.EtlSourceInfo, error) {
+	endpoint := fmt.Sprintf("%s/applicationrole", c.opts.apiBaseUrl())
+	roles := []atlassianApplicationRole{}
+	source, err := atlassianGet(c.opts.Client, endpoint, &roles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return roles, source, nil
+}
@@ -0,0 +1,165 @@
+package atlassian
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+)
+
+const (
+	atlassianUserRoleActor  = "atlassian-user-role-actor"
+	atlassianGroupRoleActor = "atlassian-group-role-actor"
+)
+
+type atlassianProject struct {
+	Id   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type atlassianProjectRole struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Actors []struct {
+		Type      string `json:"type"`
+		ActorUser struct {
+			AccountId string `json:"accountId"`
+		} `json:"actorUser"`
+		ActorGroup struct {
+			Name    string `json:"name"`
+			GroupId string `json:"groupId"`
+		} `json:"actorGroup"`
+	} `json:"actors"`
+}
+
+func (c *EtlAtlassianConnectorUser) getProjects() ([]atlassianProject, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		atlassianPageInfo
+		Values []atlassianProject `json:"values"`
+	}
+
+	endpoint := fmt.Sprintf("%s/project/search", c.opts.apiBaseUrl())
+	responses := []ResponseBody{}
+	source, err := atlassianPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retProjects := []atlassianProject{}
+	for _, resp := range responses {
+		retProjects = append(retProjects, resp.Values...)
+	}
+	return retProjects, source, nil
+}
+
+// getProjectRoles returns every role in the project along with the users and groups in each role.
+// The role listing only has a link to each role so the actors need to be pulled one role at a time.
+func (c *EtlAtlassianConnectorUser) getProjectRoles(projectKey string) ([]atlassianProjectRole, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	projectEndpoint := fmt.Sprintf("%s/project/%s/role", c.opts.apiBaseUrl(), url.PathEscape(projectKey))
+	roleLinks := map[string]string{}
+	source, err := atlassianGet(c.opts.Client, projectEndpoint, &roleLinks)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(source)
+
+	roleIds := []string{}
+	for _, link := range roleLinks {
+		parsed, err := url.Parse(link)
+		if err != nil {
+			return nil, nil, err
+		}
+		roleIds = append(roleIds, path.Base(parsed.Path))
+	}
+	sort.Strings(roleIds)
+
+	retRoles := []atlassianProjectRole{}
+	for _, id := range roleIds {
+		role := atlassianProjectRole{}
+		source, err := atlassianGet(c.opts.Client, fmt.Sprintf("%s/%s", projectEndpoint, url.PathEscape(id)), &role)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+		retRoles = append(retRoles, role)
+	}
+	return retRoles, finalSource, nil
+}
+
+type atlassianGetProjectRolesJob struct {
+	// Input
+	ProjectKey string
+	Connector  *EtlAtlassianConnectorUser
+
+	// Output
+	Roles     *[]atlassianProjectRole
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *atlassianGetProjectRolesJob) Do() error {
+	roles, source, err := j.Connector.getProjectRoles(j.ProjectKey)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Roles = roles
+	return nil
+}
+
+// getAllProjectRoles returns the roles of every project keyed by the project key.
+func (c *EtlAtlassianConnectorUser) getAllProjectRoles() (map[string][]atlassianProjectRole, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	projects, src, err := c.getProjects()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSource, sourcesToMerge)
+
+	perProjectRoles := make([]*[]atlassianProjectRole, len(projects))
+	{
+		pool := mt.NewTaskPool(10)
+		for idx, p := range projects {
+			roles := []atlassianProjectRole{}
+			pool.AddJob(&atlassianGetProjectRolesJob{
+				ProjectKey: p.Key,
+				Connector:  c,
+				Roles:      &roles,
+				OutSource:  sourcesToMerge,
+			})
+			perProjectRoles[idx] = &roles
+		}
+
+		err := pool.SyncExecute()
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	retRoles := map[string][]atlassianProjectRole{}
+	for idx, p := range projects {
+		retRoles[p.Key] = *perProjectRoles[idx]
+	}
+	return retRoles, finalSource, nil
+}
@@ -0,0 +1,222 @@
+package atlassian
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	AtlassianAttributeAccountId   = "account_id"
+	AtlassianAttributeAccountType = "account_type"
+	AtlassianAttributeActive      = "active"
+	AtlassianAttributeSiteAdmin   = "site_admin"
+	AtlassianAttributeProducts    = "products"
+)
+
+const (
+	atlassianAccessPermission = "access"
+	atlassianAdminPermission  = "admin"
+	atlassianSiteObject       = "site"
+)
+
+// Customers are Jira Service Management portal users. They can only raise requests and have no
+// access to any product so they're left out of the listing.
+const atlassianCustomerAccountType = "customer"
+
+type atlassianUser struct {
+	AccountId    string `json:"accountId"`
+	AccountType  string `json:"accountType"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+	Active       bool   `json:"active"`
+}
+
+func productObject(key string) string {
+	return fmt.Sprintf("product::%s", key)
+}
+
+func projectObject(key string) string {
+	return fmt.Sprintf("project::%s", key)
+}
+
+type EtlAtlassianConnectorUser struct {
+	opts *EtlAtlassianOptions
+}
+
+func createAtlassianConnectorUser(opts *EtlAtlassianOptions) (*EtlAtlassianConnectorUser, error) {
+	return &EtlAtlassianConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+// getUsers pages through every user on the site. This endpoint returns a bare array rather than the
+// usual paginated response so we keep going until an empty page comes back.
+func (c *EtlAtlassianConnectorUser) getUsers() ([]atlassianUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+	retUsers := []atlassianUser{}
+
+	startAt := 0
+	for {
+		endpoint := fmt.Sprintf("%s/users/search?startAt=%d&maxResults=%d", c.opts.apiBaseUrl(), startAt, atlassianPageSize)
+		page := []atlassianUser{}
+		source, err := atlassianGet(c.opts.Client, endpoint, &page)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(source)
+
+		if len(page) == 0 {
+			break
+		}
+
+		retUsers = append(retUsers, page...)
+		startAt += len(page)
+	}
+	return retUsers, finalSource, nil
+}
+
+func (c *EtlAtlassianConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	// Step 1: Every user on the site.
+	users, src, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	// Step 2: The groups each user is in. Groups are what grant product access and admin rights.
+	memberships, src, err := c.getGroupMemberships()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	// Step 3: Which groups give access to which products.
+	products, src, err := c.getApplicationRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	// Step 4: Project roles which can be given to users directly or through groups.
+	projectRoles, src, err := c.getAllProjectRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	// Every group becomes a role that holds everything the group grants.
+	groupRoles := map[string]*types.EtlRole{}
+	groupRole := func(name string) *types.EtlRole {
+		role, ok := groupRoles[name]
+		if !ok {
+			role = &types.EtlRole{
+				Name:        name,
+				Permissions: map[string][]string{},
+			}
+			groupRoles[name] = role
+		}
+		return role
+	}
+
+	for _, p := range products {
+		for _, g := range p.Groups {
+			role := groupRole(g)
+			role.Permissions[productObject(p.Key)] = []string{atlassianAccessPermission}
+		}
+	}
+
+	adminGroups := map[string]bool{}
+	for _, g := range c.opts.adminGroups() {
+		adminGroups[g] = true
+		groupRole(g).Permissions[atlassianSiteObject] = []string{atlassianAdminPermission}
+	}
+
+	directProjectRoles := map[string][]*types.EtlRole{}
+	projectKeys := []string{}
+	for key := range projectRoles {
+		projectKeys = append(projectKeys, key)
+	}
+	sort.Strings(projectKeys)
+
+	for _, key := range projectKeys {
+		for _, r := range projectRoles[key] {
+			for _, actor := range r.Actors {
+				switch actor.Type {
+				case atlassianUserRoleActor:
+					directProjectRoles[actor.ActorUser.AccountId] = append(directProjectRoles[actor.ActorUser.AccountId], &types.EtlRole{
+						Name: fmt.Sprintf("%s / %s", key, r.Name),
+						Permissions: map[string][]string{
+							projectObject(key): []string{r.Name},
+						},
+					})
+				case atlassianGroupRoleActor:
+					role := groupRole(actor.ActorGroup.Name)
+					role.Permissions[projectObject(key)] = append(role.Permissions[projectObject(key)], r.Name)
+				}
+			}
+		}
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range users {
+		if u.AccountType == atlassianCustomerAccountType {
+			continue
+		}
+
+		etlUser := &types.EtlUser{
+			Username: u.AccountId,
+			FullName: u.DisplayName,
+			Email:    u.EmailAddress,
+			Roles:    map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				AtlassianAttributeAccountId:   u.AccountId,
+				AtlassianAttributeAccountType: u.AccountType,
+				AtlassianAttributeActive:      strconv.FormatBool(u.Active),
+			},
+		}
+
+		// Account IDs are opaque so use the email as the username when the user's profile makes it visible.
+		if u.EmailAddress != "" {
+			etlUser.Username = u.EmailAddress
+		}
+
+		siteAdmin := false
+		userProducts := map[string]bool{}
+		for _, g := range memberships[u.AccountId] {
+			role := groupRole(g.Name)
+			etlUser.Roles[role.Name] = role
+
+			if adminGroups[g.Name] {
+				siteAdmin = true
+			}
+
+			for obj := range role.Permissions {
+				if strings.HasPrefix(obj, productObject("")) {
+					userProducts[strings.TrimPrefix(obj, productObject(""))] = true
+				}
+			}
+		}
+
+		for _, role := range directProjectRoles[u.AccountId] {
+			etlUser.Roles[role.Name] = role
+		}
+
+		productKeys := []string{}
+		for key := range userProducts {
+			productKeys = append(productKeys, key)
+		}
+		sort.Strings(productKeys)
+
+		etlUser.Attributes[AtlassianAttributeSiteAdmin] = strconv.FormatBool(siteAdmin)
+		etlUser.Attributes[AtlassianAttributeProducts] = strings.Join(productKeys, ",")
+		retUsers = append(retUsers, etlUser)
+	}
+
+	return retUsers, finalSource, nil
+}
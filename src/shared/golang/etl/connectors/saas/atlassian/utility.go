@@ -0,0 +1,119 @@
+package atlassian
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const atlassianPageSize int = 50
+
+// atlassianPageInfo is embedded into the response of paginated endpoints that wrap their results
+// in a "values" array.
+type atlassianPageInfo struct {
+	StartAt    int  `json:"startAt"`
+	MaxResults int  `json:"maxResults"`
+	Total      int  `json:"total"`
+	IsLast     bool `json:"isLast"`
+}
+
+func atlassianGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Atlassian API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// atlassianPaginatedGet expects output to be a pointer to a slice of structs that each embed
+// atlassianPageInfo and have a Values slice. Each page's response is appended to the slice.
+func atlassianPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	separator := "?"
+	if strings.Contains(baseEndpoint, "?") {
+		separator = "&"
+	}
+
+	startAt := 0
+	for {
+		endpoint := fmt.Sprintf("%s%sstartAt=%d&maxResults=%d", baseEndpoint, separator, startAt, atlassianPageSize)
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		cmdSrc, err := atlassianGet(client, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		result := responseBodyValue.Elem()
+		numValues := result.FieldByName("Values").Len()
+		if result.FieldByName("IsLast").Bool() || numValues == 0 {
+			break
+		}
+
+		startAt += numValues
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
@@ -0,0 +1,248 @@
+package cloudflare
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+	"strings"
+)
+
+const accessDenyDecision = "deny"
+
+const accessEmailRule = "email"
+const accessGroupRule = "group"
+const accessEveryoneRule = "everyone"
+
+type cloudflareAccessApp struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Type   string `json:"type"`
+}
+
+func (a cloudflareAccessApp) resource() string {
+	if a.Domain != "" {
+		return a.Domain
+	}
+	return a.Name
+}
+
+// cloudflareAccessRule is a single include/exclude/require rule in an Access policy or group.
+// Each rule is an object with a single key (the type of the rule) that maps to the rule's parameters,
+// e.g. {"email": {"email": "test@grchive.com"}} or {"everyone": {}}.
+type cloudflareAccessRule map[string]map[string]interface{}
+
+type cloudflareAccessGroup struct {
+	Id      string                 `json:"id"`
+	Name    string                 `json:"name"`
+	Include []cloudflareAccessRule `json:"include"`
+}
+
+type cloudflareAccessPolicy struct {
+	Id       string                 `json:"id"`
+	Name     string                 `json:"name"`
+	Decision string                 `json:"decision"`
+	Include  []cloudflareAccessRule `json:"include"`
+	Exclude  []cloudflareAccessRule `json:"exclude"`
+	Require  []cloudflareAccessRule `json:"require"`
+}
+
+// toEtlUsers converts every rule into the principal that the rule refers to. Emails map directly to
+// users while every other type of rule (email domains, IP ranges, identity provider groups, etc.) is
+// turned into a pseudo-user named after the rule so that it still shows up in the review. Access groups
+// are resolved into a pseudo-user whose nested users are the group's own principals.
+func (r cloudflareAccessRule) toEtlUsers(groups map[string]cloudflareAccessGroup) []*types.EtlUser {
+	ret := []*types.EtlUser{}
+	for ruleType, params := range r {
+		switch ruleType {
+		case accessEmailRule:
+			email := fmt.Sprintf("%v", params["email"])
+			ret = append(ret, &types.EtlUser{
+				Username: email,
+				Email:    email,
+				Roles:    map[string]*types.EtlRole{},
+			})
+		case accessGroupRule:
+			groupId := fmt.Sprintf("%v", params["id"])
+			group, ok := groups[groupId]
+			if !ok {
+				group = cloudflareAccessGroup{
+					Id:   groupId,
+					Name: groupId,
+				}
+			}
+			ret = append(ret, group.toEtlUser(groups))
+		case accessEveryoneRule:
+			ret = append(ret, &types.EtlUser{
+				Username: ruleType,
+				Roles:    map[string]*types.EtlRole{},
+			})
+		default:
+			keys := []string{}
+			for k := range params {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			values := []string{}
+			for _, k := range keys {
+				values = append(values, fmt.Sprintf("%v", params[k]))
+			}
+
+			ret = append(ret, &types.EtlUser{
+				Username: fmt.Sprintf("%s:%s", ruleType, strings.Join(values, ",")),
+				Roles:    map[string]*types.EtlRole{},
+			})
+		}
+	}
+	return ret
+}
+
+func (g cloudflareAccessGroup) toEtlUser(groups map[string]cloudflareAccessGroup) *types.EtlUser {
+	nested := map[string]*types.EtlUser{}
+
+	// Remove the group from the lookup so that a group that (indirectly) includes itself can't recurse forever.
+	remaining := map[string]cloudflareAccessGroup{}
+	for id, other := range groups {
+		if id != g.Id {
+			remaining[id] = other
+		}
+	}
+
+	for _, rule := range g.Include {
+		for _, u := range rule.toEtlUsers(remaining) {
+			nested[u.Username] = u
+		}
+	}
+
+	return &types.EtlUser{
+		Username:    fmt.Sprintf("%s:%s", accessGroupRule, g.Name),
+		Roles:       map[string]*types.EtlRole{},
+		NestedUsers: nested,
+	}
+}
+
+func (c *EtlCloudflareConnectorUser) getAccessApps(accountId string) ([]cloudflareAccessApp, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		ResultInfo cloudflareResultInfo  `json:"result_info"`
+		Result     []cloudflareAccessApp `json:"result"`
+	}
+
+	endpoint := fmt.Sprintf("%s/accounts/%s/access/apps", baseUrl, accountId)
+	responses := []ResponseBody{}
+	source, err := cloudflarePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retApps := []cloudflareAccessApp{}
+	for _, resp := range responses {
+		retApps = append(retApps, resp.Result...)
+	}
+	return retApps, source, nil
+}
+
+func (c *EtlCloudflareConnectorUser) getAccessGroups(accountId string) (map[string]cloudflareAccessGroup, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		ResultInfo cloudflareResultInfo    `json:"result_info"`
+		Result     []cloudflareAccessGroup `json:"result"`
+	}
+
+	endpoint := fmt.Sprintf("%s/accounts/%s/access/groups", baseUrl, accountId)
+	responses := []ResponseBody{}
+	source, err := cloudflarePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retGroups := map[string]cloudflareAccessGroup{}
+	for _, resp := range responses {
+		for _, g := range resp.Result {
+			retGroups[g.Id] = g
+		}
+	}
+	return retGroups, source, nil
+}
+
+func (c *EtlCloudflareConnectorUser) getAccessPolicies(accountId string, appId string) ([]cloudflareAccessPolicy, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		ResultInfo cloudflareResultInfo     `json:"result_info"`
+		Result     []cloudflareAccessPolicy `json:"result"`
+	}
+
+	endpoint := fmt.Sprintf("%s/accounts/%s/access/apps/%s/policies", baseUrl, accountId, appId)
+	responses := []ResponseBody{}
+	source, err := cloudflarePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retPolicies := []cloudflareAccessPolicy{}
+	for _, resp := range responses {
+		retPolicies = append(retPolicies, resp.Result...)
+	}
+	return retPolicies, source, nil
+}
+
+// getAccessUsers returns every principal that is referenced by an Access policy. Each application the
+// principal is included in becomes a role where the application's domain maps to the policy decisions
+// (allow, bypass, etc.) that apply. Principals that are excluded from a policy or included in a deny
+// policy have the decision placed into the role's denied permissions instead. Require rules only
+// narrow down who gets access so they aren't treated as granting access to anyone.
+func (c *EtlCloudflareConnectorUser) getAccessUsers(accountId string, scope string) ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	apps, src, err := c.getAccessApps(accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	groups, src, err := c.getAccessGroups(accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	allUsers := map[string]*types.EtlUser{}
+	addUsers := func(app cloudflareAccessApp, rules []cloudflareAccessRule, decision string, denied bool) {
+		for _, rule := range rules {
+			for _, u := range rule.toEtlUsers(groups) {
+				role := &types.EtlRole{
+					Name:        scopedRoleName(scope, app.Name),
+					Permissions: map[string][]string{},
+					Denied:      map[string][]string{},
+				}
+
+				if denied {
+					role.Denied[app.resource()] = []string{decision}
+				} else {
+					role.Permissions[app.resource()] = []string{decision}
+				}
+
+				u.Roles[role.Name] = role
+				mergeEtlUser(allUsers, u)
+			}
+		}
+	}
+
+	for _, app := range apps {
+		policies, src, err := c.getAccessPolicies(accountId, app.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		finalSource.MergeWith(src)
+
+		for _, p := range policies {
+			addUsers(app, p.Include, p.Decision, p.Decision == accessDenyDecision)
+			addUsers(app, p.Exclude, p.Decision, true)
+		}
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range allUsers {
+		retUsers = append(retUsers, u)
+	}
+	return retUsers, finalSource, nil
+}
@@ -6,7 +6,8 @@ import (
 )
 
 type EtlCloudflareOptions struct {
-	Client    http_utility.HttpClient
+	Client http_utility.HttpClient
+	// AccountId is optional. If it isn't specified, every account the client has access to is listed.
 	AccountId string
 }
 
@@ -0,0 +1,105 @@
+package cloudflare
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"sort"
+)
+
+const apiTokenRoleName = "API Token"
+const apiTokenActiveStatus = "active"
+const apiTokenDenyEffect = "deny"
+
+type cloudflarePermissionGroup struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type cloudflareTokenPolicy struct {
+	Id               string                      `json:"id"`
+	Effect           string                      `json:"effect"`
+	Resources        map[string]interface{}      `json:"resources"`
+	PermissionGroups []cloudflarePermissionGroup `json:"permission_groups"`
+}
+
+type cloudflareApiToken struct {
+	Id       string                  `json:"id"`
+	Name     string                  `json:"name"`
+	Status   string                  `json:"status"`
+	Policies []cloudflareTokenPolicy `json:"policies"`
+}
+
+// flattenTokenResources returns the list of resources a token policy applies to. Resources are either a
+// direct mapping of resource to "*" or, for resources scoped to an account, a nested mapping of the
+// account to the resources within that account. Nested resources are returned as "parent/child".
+func flattenTokenResources(prefix string, resources map[string]interface{}) []string {
+	ret := []string{}
+	for key, value := range resources {
+		resource := key
+		if prefix != "" {
+			resource = fmt.Sprintf("%s/%s", prefix, key)
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			ret = append(ret, flattenTokenResources(resource, nested)...)
+		} else {
+			ret = append(ret, resource)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (t cloudflareApiToken) isActive() bool {
+	return t.Status == apiTokenActiveStatus
+}
+
+func (t cloudflareApiToken) toEtlUser(scope string) *types.EtlUser {
+	role := &types.EtlRole{
+		Name:        scopedRoleName(scope, apiTokenRoleName),
+		Permissions: map[string][]string{},
+		Denied:      map[string][]string{},
+	}
+
+	for _, policy := range t.Policies {
+		permissions := role.Permissions
+		if policy.Effect == apiTokenDenyEffect {
+			permissions = role.Denied
+		}
+
+		for _, resource := range flattenTokenResources("", policy.Resources) {
+			for _, group := range policy.PermissionGroups {
+				permissions[resource] = append(permissions[resource], group.Name)
+			}
+		}
+	}
+
+	return &types.EtlUser{
+		Username: t.Id,
+		FullName: t.Name,
+		Roles: map[string]*types.EtlRole{
+			role.Name: role,
+		},
+	}
+}
+
+func (c *EtlCloudflareConnectorUser) getApiTokens(accountId string) ([]cloudflareApiToken, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		ResultInfo cloudflareResultInfo `json:"result_info"`
+		Result     []cloudflareApiToken `json:"result"`
+	}
+
+	endpoint := fmt.Sprintf("%s/accounts/%s/tokens", baseUrl, accountId)
+	responses := []ResponseBody{}
+	source, err := cloudflarePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retTokens := []cloudflareApiToken{}
+	for _, resp := range responses {
+		retTokens = append(retTokens, resp.Result...)
+	}
+	return retTokens, source, nil
+}
@@ -1,14 +1,9 @@
 package cloudflare
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
-	"golang.org/x/net/context"
-	"io/ioutil"
-	"net/http"
 	"strings"
 )
 
@@ -16,6 +11,11 @@ const readPermission = "Read"
 const editPermission = "Edit"
 const writePermission = "Write"
 
+type cloudflareAccount struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type cloudflareUser struct {
 	User struct {
 		FirstName string `json:"first_name"`
@@ -32,11 +32,21 @@ type cloudflareUser struct {
 	} `json:"roles"`
 }
 
-func (g cloudflareUser) toEtlUser() *types.EtlUser {
+// scopedRoleName prefixes the role name with the account it came from. The scope is empty when
+// only a single account is being looked at so that role names stay the same as what's shown in
+// the Cloudflare dashboard.
+func scopedRoleName(scope string, name string) string {
+	if scope == "" {
+		return name
+	}
+	return fmt.Sprintf("%s / %s", scope, name)
+}
+
+func (g cloudflareUser) toEtlUser(scope string) *types.EtlUser {
 	roles := map[string]*types.EtlRole{}
 	for _, r := range g.Roles {
 		etlRole := &types.EtlRole{
-			Name:        r.Name,
+			Name:        scopedRoleName(scope, r.Name),
 			Permissions: map[string][]string{},
 		}
 
@@ -58,7 +68,7 @@ func (g cloudflareUser) toEtlUser() *types.EtlUser {
 			etlRole.Permissions[nm] = newPermissions
 		}
 
-		roles[r.Name] = etlRole
+		roles[etlRole.Name] = etlRole
 	}
 
 	return &types.EtlUser{
@@ -69,6 +79,61 @@ func (g cloudflareUser) toEtlUser() *types.EtlUser {
 	}
 }
 
+func mergePermissionMap(dst types.PermissionMap, src types.PermissionMap) types.PermissionMap {
+	if src == nil {
+		return dst
+	}
+
+	if dst == nil {
+		dst = types.PermissionMap{}
+	}
+
+	for obj, perms := range src {
+		dst[obj] = append(dst[obj], perms...)
+	}
+	return dst
+}
+
+// mergeEtlUser adds the user into the set of users. The same person can show up as a member of multiple
+// accounts as well as in multiple Access policies so we want to combine all their roles into a single user.
+func mergeEtlUser(users map[string]*types.EtlUser, user *types.EtlUser) {
+	existing, ok := users[user.Username]
+	if !ok {
+		users[user.Username] = user
+		return
+	}
+
+	if existing.Email == "" {
+		existing.Email = user.Email
+	}
+
+	if existing.FullName == "" {
+		existing.FullName = user.FullName
+	}
+
+	if existing.Roles == nil {
+		existing.Roles = map[string]*types.EtlRole{}
+	}
+
+	for key, role := range user.Roles {
+		existingRole, ok := existing.Roles[key]
+		if !ok {
+			existing.Roles[key] = role
+			continue
+		}
+
+		existingRole.Permissions = mergePermissionMap(existingRole.Permissions, role.Permissions)
+		existingRole.Denied = mergePermissionMap(existingRole.Denied, role.Denied)
+	}
+
+	for key, nested := range user.NestedUsers {
+		if existing.NestedUsers == nil {
+			existing.NestedUsers = map[string]*types.EtlUser{}
+		}
+		existing.NestedUsers[key] = nested
+	}
+}
+
 type EtlCloudflareConnectorUser struct {
 	opts *EtlCloudflareOptions
 }
@@ -79,96 +144,113 @@ func createCloudflareConnectorUser(opts *EtlCloudflareOptions) (*EtlCloudflareCo
 	}, nil
 }
 
+func (c *EtlCloudflareConnectorUser) getAccounts() ([]cloudflareAccount, *connectors.EtlSourceInfo, error) {
+	// Only look at the account we were explicitly told to look at if one was specified.
+	if c.opts.AccountId != "" {
+		return []cloudflareAccount{
+			cloudflareAccount{
+				Id: c.opts.AccountId,
+			},
+		}, connectors.CreateSourceInfo(), nil
+	}
+
+	type ResponseBody struct {
+		ResultInfo cloudflareResultInfo `json:"result_info"`
+		Result     []cloudflareAccount  `json:"result"`
+	}
+
+	endpoint := fmt.Sprintf("%s/accounts", baseUrl)
+	responses := []ResponseBody{}
+	source, err := cloudflarePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retAccounts := []cloudflareAccount{}
+	for _, resp := range responses {
+		retAccounts = append(retAccounts, resp.Result...)
+	}
+	return retAccounts, source, nil
+}
+
+func (c *EtlCloudflareConnectorUser) getAccountMembers(accountId string) ([]cloudflareUser, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		ResultInfo cloudflareResultInfo `json:"result_info"`
+		Result     []cloudflareUser     `json:"result"`
+	}
+
+	endpoint := fmt.Sprintf("%s/accounts/%s/members?direction=desc", baseUrl, accountId)
+	responses := []ResponseBody{}
+	source, err := cloudflarePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []cloudflareUser{}
+	for _, resp := range responses {
+		retUsers = append(retUsers, resp.Result...)
+	}
+	return retUsers, source, nil
+}
+
 func (c *EtlCloudflareConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
-	ctx := context.Background()
-	retUsers := []*types.EtlUser{}
-	source := connectors.CreateSourceInfo()
-	uniqueUsers := map[string]bool{}
-
-	page := 1
-
-	for {
-		endpoint := fmt.Sprintf(
-			"%s/accounts/%s/members?page=%d&per_page=50&direction=desc",
-			baseUrl,
-			c.opts.AccountId,
-			page,
-		)
-
-		req, err := http.NewRequestWithContext(
-			ctx,
-			"GET",
-			endpoint,
-			nil,
-		)
-		if err != nil {
-			return nil, nil, err
-		}
+	finalSource := connectors.CreateSourceInfo()
 
-		resp, err := c.opts.Client.Do(req)
-		if err != nil {
-			return nil, nil, err
+	// Step 1: Figure out which accounts we need to look at.
+	accounts, src, err := c.getAccounts()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	allUsers := map[string]*types.EtlUser{}
+	for _, account := range accounts {
+		scope := ""
+		if len(accounts) > 1 {
+			scope = account.Name
 		}
-		defer resp.Body.Close()
 
-		bodyData, err := ioutil.ReadAll(resp.Body)
+		// Step 2: Account members (administrators) and the roles they have on the account.
+		members, src, err := c.getAccountMembers(account.Id)
 		if err != nil {
 			return nil, nil, err
 		}
+		finalSource.MergeWith(src)
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, nil, errors.New("Cloudflare User Listing API Error: " + string(bodyData))
+		for _, m := range members {
+			mergeEtlUser(allUsers, m.toEtlUser(scope))
 		}
 
-		responseBody := struct {
-			Success    bool             `json:"success"`
-			Errors     []string         `json:"errors"`
-			Result     []cloudflareUser `json:"result"`
-			ResultInfo struct {
-				Page       int `json:"page"`
-				TotalPages int `json:"total_pages"`
-			} `json:"result_info"`
-		}{}
-		err = json.Unmarshal(bodyData, &responseBody)
+		// Step 3: Account owned API tokens.
+		tokens, src, err := c.getApiTokens(account.Id)
 		if err != nil {
 			return nil, nil, err
 		}
+		finalSource.MergeWith(src)
 
-		if !responseBody.Success {
-			return nil, nil, errors.New(strings.Join(responseBody.Errors, "\n"))
-		}
-
-		if len(responseBody.Result) == 0 {
-			break
-		}
-
-		added := 0
-		for _, u := range responseBody.Result {
-			if _, ok := uniqueUsers[u.User.Email]; ok {
+		for _, t := range tokens {
+			if !t.isActive() {
 				continue
 			}
-
-			retUsers = append(retUsers, u.toEtlUser())
-			uniqueUsers[u.User.Email] = true
-			added = added + 1
+			mergeEtlUser(allUsers, t.toEtlUser(scope))
 		}
 
-		if added == 0 {
-			break
+		// Step 4: Cloudflare Access (Zero Trust) applications and the end users that can get to them.
+		accessUsers, src, err := c.getAccessUsers(account.Id, scope)
+		if err != nil {
+			return nil, nil, err
 		}
+		finalSource.MergeWith(src)
 
-		cmd := connectors.EtlCommandInfo{
-			Command: endpoint,
-			RawData: string(bodyData),
+		for _, u := range accessUsers {
+			mergeEtlUser(allUsers, u)
 		}
-		source.AddCommand(&cmd)
-
-		page += 1
+	}
 
-		if responseBody.ResultInfo.Page == responseBody.ResultInfo.TotalPages {
-			break
-		}
+	retUsers := []*types.EtlUser{}
+	for _, u := range allUsers {
+		retUsers = append(retUsers, u)
 	}
 
-	return retUsers, source, nil
+	return retUsers, finalSource, nil
 }
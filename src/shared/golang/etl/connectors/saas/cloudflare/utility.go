@@ -0,0 +1,140 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const cloudflarePageSize int = 50
+
+type cloudflareError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type cloudflareResultInfo struct {
+	Page       int `json:"page"`
+	TotalPages int `json:"total_pages"`
+}
+
+// cloudflareStatus is the part of the response envelope shared by every Cloudflare v4 API call.
+type cloudflareStatus struct {
+	Success bool              `json:"success"`
+	Errors  []cloudflareError `json:"errors"`
+}
+
+func cloudflareGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Cloudflare API Error: " + string(bodyData))
+	}
+
+	status := cloudflareStatus{}
+	err = json.Unmarshal(bodyData, &status)
+	if err != nil {
+		return nil, err
+	}
+
+	if !status.Success {
+		messages := []string{}
+		for _, e := range status.Errors {
+			messages = append(messages, fmt.Sprintf("[%d] %s", e.Code, e.Message))
+		}
+		return nil, errors.New("Cloudflare API Error: " + strings.Join(messages, "\n"))
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// cloudflarePaginatedGet expects output to be a pointer to a slice of structs that each have a
+// ResultInfo field of type cloudflareResultInfo. Each page's response is appended to the slice.
+func cloudflarePaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	separator := "?"
+	if strings.Contains(baseEndpoint, "?") {
+		separator = "&"
+	}
+
+	page := 1
+	for {
+		endpoint := fmt.Sprintf("%s%spage=%d&per_page=%d", baseEndpoint, separator, page, cloudflarePageSize)
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		cmdSrc, err := cloudflareGet(client, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		result := responseBodyValue.Elem()
+		info := result.FieldByName("ResultInfo").Interface().(cloudflareResultInfo)
+
+		if info.TotalPages == 0 || info.Page >= info.TotalPages {
+			break
+		}
+
+		page += 1
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
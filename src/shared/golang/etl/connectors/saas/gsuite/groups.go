@@ -0,0 +1,250 @@
+package gsuite
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"net/url"
+	"sync"
+)
+
+const groupMemberType = "GROUP"
+const customerMemberType = "CUSTOMER"
+
+type gsuiteGroup struct {
+	Id    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type gsuiteGroupMember struct {
+	Id     string `json:"id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+// gsuiteMembership is a single edge from a member (user or group) to the group it's in.
+type gsuiteMembership struct {
+	Group gsuiteGroup
+	Role  string
+}
+
+func (c *EtlGSuiteConnectorUser) getGroups() ([]gsuiteGroup, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		Groups        []gsuiteGroup `json:"groups"`
+		NextPageToken *string       `json:"nextPageToken"`
+	}
+
+	endpoint := fmt.Sprintf("%s%s/groups?customer=%s", baseUrl, directoryUrl, url.QueryEscape(c.opts.CustomerId))
+	responses := []ResponseBody{}
+	source, err := gsuitePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retGroups := []gsuiteGroup{}
+	for _, resp := range responses {
+		retGroups = append(retGroups, resp.Groups...)
+	}
+	return retGroups, source, nil
+}
+
+func (c *EtlGSuiteConnectorUser) getGroupMembers(groupId string) ([]gsuiteGroupMember, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		Members       []gsuiteGroupMember `json:"members"`
+		NextPageToken *string             `json:"nextPageToken"`
+	}
+
+	endpoint := fmt.Sprintf("%s%s/groups/%s/members", baseUrl, directoryUrl, url.PathEscape(groupId))
+	responses := []ResponseBody{}
+	source, err := gsuitePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retMembers := []gsuiteGroupMember{}
+	for _, resp := range responses {
+		retMembers = append(retMembers, resp.Members...)
+	}
+	return retMembers, source, nil
+}
+
+type gsuiteGetGroupMembersJob struct {
+	// Input
+	GroupId   string
+	Connector *EtlGSuiteConnectorUser
+
+	// Output
+	Members   *[]gsuiteGroupMember
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *gsuiteGetGroupMembersJob) Do() error {
+	members, source, err := j.Connector.getGroupMembers(j.GroupId)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Members = members
+	return nil
+}
+
+type gsuiteGroupListing struct {
+	// Every group that each member (user or group) is directly a part of keyed by the member's ID.
+	Parents map[string][]gsuiteMembership
+	// The email of each member that isn't a group keyed by the member's ID.
+	Emails map[string]string
+}
+
+// rolesForMember walks up the group hierarchy and returns a role for every group the member is in either
+// directly or through nested groups. The permission on each role is the member's role in the group (e.g.
+// MEMBER, MANAGER, OWNER). When the membership is inherited from a nested group the nested group that
+// granted the membership is noted as well.
+func (l gsuiteGroupListing) rolesForMember(memberId string, inOrganization bool) []*types.EtlRole {
+	type queueItem struct {
+		Membership gsuiteMembership
+		Via        string
+	}
+
+	queue := []queueItem{}
+	for _, m := range l.Parents[memberId] {
+		queue = append(queue, queueItem{Membership: m})
+	}
+
+	// Groups that contain the entire organization contain every user in the organization.
+	if inOrganization {
+		for _, m := range l.Parents[customerMemberType] {
+			queue = append(queue, queueItem{Membership: m, Via: customerMemberType})
+		}
+	}
+
+	roles := map[string]*types.EtlRole{}
+	retRoles := []*types.EtlRole{}
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		permission := item.Membership.Role
+		if item.Via != "" {
+			permission = fmt.Sprintf("%s (via %s)", item.Membership.Role, item.Via)
+		}
+
+		group := item.Membership.Group
+		role, ok := roles[group.Id]
+		if ok {
+			role.Permissions[group.Email] = append(role.Permissions[group.Email], permission)
+			continue
+		}
+
+		role = &types.EtlRole{
+			Name: group.Email,
+			Permissions: map[string][]string{
+				group.Email: []string{permission},
+			},
+		}
+		roles[group.Id] = role
+		retRoles = append(retRoles, role)
+
+		for _, parent := range l.Parents[group.Id] {
+			queue = append(queue, queueItem{
+				Membership: parent,
+				Via:        group.Email,
+			})
+		}
+	}
+	return retRoles
+}
+
+// groupsForMember returns every group the member is in either directly or through nested groups.
+func (l gsuiteGroupListing) groupsForMember(memberId string, inOrganization bool) []gsuiteGroup {
+	queue := append([]gsuiteMembership{}, l.Parents[memberId]...)
+	if inOrganization {
+		queue = append(queue, l.Parents[customerMemberType]...)
+	}
+
+	seen := map[string]bool{}
+	retGroups := []gsuiteGroup{}
+	for len(queue) > 0 {
+		group := queue[0].Group
+		queue = queue[1:]
+
+		if seen[group.Id] {
+			continue
+		}
+		seen[group.Id] = true
+		retGroups = append(retGroups, group)
+		queue = append(queue, l.Parents[group.Id]...)
+	}
+	return retGroups
+}
+
+func (c *EtlGSuiteConnectorUser) getGroupListing() (*gsuiteGroupListing, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	groups, src, err := c.getGroups()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSource, sourcesToMerge)
+
+	perGroupMembers := make([]*[]gsuiteGroupMember, len(groups))
+	{
+		pool := mt.NewTaskPool(10)
+		for idx, g := range groups {
+			members := []gsuiteGroupMember{}
+			pool.AddJob(&gsuiteGetGroupMembersJob{
+				GroupId:   g.Id,
+				Connector: c,
+				Members:   &members,
+				OutSource: sourcesToMerge,
+			})
+			perGroupMembers[idx] = &members
+		}
+
+		err := pool.SyncExecute()
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	listing := gsuiteGroupListing{
+		Parents: map[string][]gsuiteMembership{},
+		Emails:  map[string]string{},
+	}
+
+	for idx, g := range groups {
+		for _, m := range *perGroupMembers[idx] {
+			memberId := m.Id
+			if m.Type == customerMemberType {
+				memberId = customerMemberType
+			} else if m.Type != groupMemberType {
+				listing.Emails[m.Id] = m.Email
+			}
+
+			listing.Parents[memberId] = append(listing.Parents[memberId], gsuiteMembership{
+				Group: g,
+				Role:  m.Role,
+			})
+		}
+	}
+
+	return &listing, finalSource, nil
+}
@@ -0,0 +1,123 @@
+package gsuite
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"net/url"
+)
+
+const orgUnitScope = "ORG_UNIT"
+
+type gsuiteRole struct {
+	RoleId         string `json:"roleId"`
+	RoleName       string `json:"roleName"`
+	RolePrivileges []struct {
+		PrivilegeName string `json:"privilegeName"`
+		ServiceId     string `json:"serviceId"`
+	} `json:"rolePrivileges"`
+	IsSystemRole     bool `json:"isSystemRole"`
+	IsSuperAdminRole bool `json:"isSuperAdminRole"`
+}
+
+type gsuiteRoleAssignment struct {
+	RoleAssignmentId string `json:"roleAssignmentId"`
+	RoleId           string `json:"roleId"`
+	AssignedTo       string `json:"assignedTo"`
+	ScopeType        string `json:"scopeType"`
+	OrgUnitId        string `json:"orgUnitId"`
+}
+
+// toEtlRole creates the role that's assigned to the user. Privileges are keyed by the ID of the service
+// the privilege belongs to. Roles that are only assigned within an organizational unit have the ID of the
+// organizational unit appended to their name since the same role can be assigned in multiple org units.
+func (r gsuiteRole) toEtlRole(assignment gsuiteRoleAssignment) *types.EtlRole {
+	name := r.RoleName
+	if assignment.ScopeType == orgUnitScope {
+		name = fmt.Sprintf("%s (%s)", r.RoleName, assignment.OrgUnitId)
+	}
+
+	permissions := map[string][]string{}
+	for _, p := range r.RolePrivileges {
+		permissions[p.ServiceId] = append(permissions[p.ServiceId], p.PrivilegeName)
+	}
+
+	return &types.EtlRole{
+		Name:        name,
+		Permissions: permissions,
+	}
+}
+
+func (c *EtlGSuiteConnectorUser) getRoles() (map[string]gsuiteRole, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		Items         []gsuiteRole `json:"items"`
+		NextPageToken *string      `json:"nextPageToken"`
+	}
+
+	endpoint := fmt.Sprintf("%s%s/customer/%s/roles", baseUrl, directoryUrl, url.PathEscape(c.opts.CustomerId))
+	responses := []ResponseBody{}
+	source, err := gsuitePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retRoles := map[string]gsuiteRole{}
+	for _, resp := range responses {
+		for _, r := range resp.Items {
+			retRoles[r.RoleId] = r
+		}
+	}
+	return retRoles, source, nil
+}
+
+func (c *EtlGSuiteConnectorUser) getRoleAssignments() ([]gsuiteRoleAssignment, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		Items         []gsuiteRoleAssignment `json:"items"`
+		NextPageToken *string                `json:"nextPageToken"`
+	}
+
+	endpoint := fmt.Sprintf("%s%s/customer/%s/roleassignments", baseUrl, directoryUrl, url.PathEscape(c.opts.CustomerId))
+	responses := []ResponseBody{}
+	source, err := gsuitePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retAssignments := []gsuiteRoleAssignment{}
+	for _, resp := range responses {
+		retAssignments = append(retAssignments, resp.Items...)
+	}
+	return retAssignments, source, nil
+}
+
+// getPerAssigneeAdminRoles returns the admin roles assigned to each user or group keyed by the ID of the
+// user or group.
+func (c *EtlGSuiteConnectorUser) getPerAssigneeAdminRoles() (map[string][]*types.EtlRole, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	roles, src, err := c.getRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	assignments, src, err := c.getRoleAssignments()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	perAssigneeRoles := map[string][]*types.EtlRole{}
+	for _, a := range assignments {
+		role, ok := roles[a.RoleId]
+		if !ok {
+			role = gsuiteRole{
+				RoleId:   a.RoleId,
+				RoleName: a.RoleId,
+			}
+		}
+
+		perAssigneeRoles[a.AssignedTo] = append(perAssigneeRoles[a.AssignedTo], role.toEtlRole(a))
+	}
+	return perAssigneeRoles, finalSource, nil
+}
@@ -0,0 +1,121 @@
+package gsuite
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"net/url"
+	"sync"
+)
+
+// oauthTokenRoleName is the role that holds the third-party applications the user has granted access to.
+// Permissions are keyed by the application and contain the OAuth scopes that were granted.
+const oauthTokenRoleName = "OAuth Tokens"
+
+type gsuiteToken struct {
+	ClientId    string   `json:"clientId"`
+	DisplayText string   `json:"displayText"`
+	Scopes      []string `json:"scopes"`
+	Anonymous   bool     `json:"anonymous"`
+	NativeApp   bool     `json:"nativeApp"`
+}
+
+func (t gsuiteToken) appName() string {
+	if t.DisplayText == "" {
+		return t.ClientId
+	}
+	return fmt.Sprintf("%s (%s)", t.DisplayText, t.ClientId)
+}
+
+func tokensToEtlRole(tokens []gsuiteToken) *types.EtlRole {
+	permissions := map[string][]string{}
+	for _, t := range tokens {
+		permissions[t.appName()] = append(permissions[t.appName()], t.Scopes...)
+	}
+
+	return &types.EtlRole{
+		Name:        oauthTokenRoleName,
+		Permissions: permissions,
+	}
+}
+
+func (c *EtlGSuiteConnectorUser) getUserTokens(userId string) ([]gsuiteToken, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		Items []gsuiteToken `json:"items"`
+	}
+
+	endpoint := fmt.Sprintf("%s%s/users/%s/tokens", baseUrl, directoryUrl, url.PathEscape(userId))
+	body := ResponseBody{}
+	source, err := gsuiteGet(c.opts.Client, endpoint, &body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body.Items, source, nil
+}
+
+type gsuiteGetUserTokensJob struct {
+	// Input
+	UserId    string
+	Connector *EtlGSuiteConnectorUser
+
+	// Output
+	Tokens    *[]gsuiteToken
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *gsuiteGetUserTokensJob) Do() error {
+	tokens, source, err := j.Connector.getUserTokens(j.UserId)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Tokens = tokens
+	return nil
+}
+
+// getPerUserTokens returns the OAuth tokens granted by each user keyed by the user's ID.
+func (c *EtlGSuiteConnectorUser) getPerUserTokens(userIds []string) (map[string][]gsuiteToken, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSource, sourcesToMerge)
+
+	perUserTokens := map[string]*[]gsuiteToken{}
+	{
+		pool := mt.NewTaskPool(10)
+		for _, id := range userIds {
+			tokens := []gsuiteToken{}
+			pool.AddJob(&gsuiteGetUserTokensJob{
+				UserId:    id,
+				Connector: c,
+				Tokens:    &tokens,
+				OutSource: sourcesToMerge,
+			})
+			perUserTokens[id] = &tokens
+		}
+
+		err := pool.SyncExecute()
+		close(sourcesToMerge)
+		wg.Wait()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ret := map[string][]gsuiteToken{}
+	for id, tokens := range perUserTokens {
+		ret[id] = *tokens
+	}
+	return ret, finalSource, nil
+}
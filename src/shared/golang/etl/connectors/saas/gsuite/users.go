@@ -1,14 +1,9 @@
 package gsuite
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
-	"golang.org/x/net/context"
-	"io/ioutil"
-	"net/http"
 	"time"
 )
 
@@ -65,76 +60,119 @@ func createGSuiteConnectorUser(opts *EtlGSuiteOptions) (*EtlGSuiteConnectorUser,
 	}, nil
 }
 
+func (c *EtlGSuiteConnectorUser) getUsers() ([]gsuiteUser, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		Kind          string       `json:"kind"`
+		Users         []gsuiteUser `json:"users"`
+		NextPageToken *string      `json:"nextPageToken"`
+	}
+
+	endpoint := fmt.Sprintf(
+		"%s%s/users?customer=%s",
+		baseUrl,
+		directoryUrl,
+		c.opts.CustomerId,
+	)
+
+	responses := []ResponseBody{}
+	source, err := gsuitePaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []gsuiteUser{}
+	for _, resp := range responses {
+		retUsers = append(retUsers, resp.Users...)
+	}
+	return retUsers, source, nil
+}
+
 func (c *EtlGSuiteConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
-	ctx := context.Background()
-	retUsers := []*types.EtlUser{}
+	finalSource := connectors.CreateSourceInfo()
 
-	emptyNextPageToken := ""
-	var nextPageToken *string
-	nextPageToken = &emptyNextPageToken
+	// Step 1: Get all users in the directory.
+	users, src, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
 
-	source := connectors.CreateSourceInfo()
+	userIds := []string{}
+	for _, u := range users {
+		userIds = append(userIds, u.Id)
+	}
 
-	for nextPageToken != nil {
-		endpoint := fmt.Sprintf(
-			"%s%s/users?customer=%s",
-			baseUrl,
-			directoryUrl,
-			c.opts.CustomerId,
-		)
+	// Step 2: Get the admin roles assigned to each user or group.
+	perAssigneeAdminRoles, src, err := c.getPerAssigneeAdminRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
 
-		if *nextPageToken != "" {
-			endpoint = endpoint + "&pageToken=" + *nextPageToken
-		}
+	// Step 3: Get group memberships (including nested groups).
+	groups, src, err := c.getGroupListing()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
 
-		req, err := http.NewRequestWithContext(
-			ctx,
-			"GET",
-			endpoint,
-			nil,
-		)
-		if err != nil {
-			return nil, nil, err
-		}
+	// Step 4: Get the OAuth tokens each user has granted to third-party applications.
+	perUserTokens, src, err := c.getPerUserTokens(userIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
 
-		resp, err := c.opts.Client.Do(req)
-		if err != nil {
-			return nil, nil, err
+	retUsers := []*types.EtlUser{}
+	directoryUsers := map[string]bool{}
+	for _, u := range users {
+		etlUser := u.toEtlUser()
+
+		for _, r := range perAssigneeAdminRoles[u.Id] {
+			etlUser.Roles[r.Name] = r
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, nil, errors.New("GSuite User Listing API Error.")
+		// Admin roles assigned to a group apply to every user in the group including through nested groups.
+		for _, g := range groups.groupsForMember(u.Id, true) {
+			for _, r := range perAssigneeAdminRoles[g.Id] {
+				name := fmt.Sprintf("%s (via %s)", r.Name, g.Email)
+				etlUser.Roles[name] = &types.EtlRole{
+					Name:        name,
+					Permissions: r.Permissions,
+				}
+			}
 		}
 
-		type responseBody struct {
-			Kind          string       `json:"kind"`
-			Users         []gsuiteUser `json:"users"`
-			NextPageToken *string      `json:"nextPageToken"`
+		for _, r := range groups.rolesForMember(u.Id, true) {
+			etlUser.Roles[r.Name] = r
 		}
 
-		bodyData, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, nil, err
+		if tokens := perUserTokens[u.Id]; len(tokens) > 0 {
+			role := tokensToEtlRole(tokens)
+			etlUser.Roles[role.Name] = role
 		}
 
-		body := responseBody{}
-		err = json.Unmarshal(bodyData, &body)
-		if err != nil {
-			return nil, nil, err
+		retUsers = append(retUsers, etlUser)
+		directoryUsers[u.Id] = true
+	}
+
+	// Groups can contain members from outside the organization which won't show up in the directory.
+	for id, email := range groups.Emails {
+		if _, ok := directoryUsers[id]; ok {
+			continue
 		}
 
-		for _, u := range body.Users {
-			retUsers = append(retUsers, u.toEtlUser())
+		etlUser := &types.EtlUser{
+			Username: email,
+			Email:    email,
+			Roles:    map[string]*types.EtlRole{},
 		}
 
-		nextPageToken = body.NextPageToken
-		cmd := connectors.EtlCommandInfo{
-			Command: endpoint,
-			RawData: string(bodyData),
+		for _, r := range groups.rolesForMember(id, false) {
+			etlUser.Roles[r.Name] = r
 		}
-		source.AddCommand(&cmd)
+		retUsers = append(retUsers, etlUser)
 	}
 
-	return retUsers, source, nil
+	return retUsers, finalSource, nil
 }
@@ -2,19 +2,26 @@ package office365
 
 import (
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
-	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/iaas/azure"
 	"gitlab.com/grchive/grchive-v3/shared/utility/http"
 )
 
 type EtlOffice365Options struct {
+	// Client is used to access Microsoft Graph.
 	Client http_utility.HttpClient
+	// ExchangeClient is optional and is used to access the Exchange Online admin API to determine mailbox delegation.
+	// Mailbox delegation (FullAccess/SendAs) isn't exposed through Microsoft Graph. TenantId must be set as well.
+	ExchangeClient http_utility.HttpClient
+	TenantId       string
 }
 
 type EtlOffice365Connector struct {
 	opts  *EtlOffice365Options
-	users connectors.EtlConnectorUserInterface
+	users *EtlOffice365ConnectorUser
 }
 
+const baseGraphUrl = "https://graph.microsoft.com/v1.0"
+const exchangeAdminUrl = "https://outlook.office365.com/adminapi/beta"
+
 func (c *EtlOffice365Connector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
 	return c.users, nil
 }
@@ -24,9 +31,7 @@ func CreateOffice365Connector(opts *EtlOffice365Options) (*EtlOffice365Connector
 	ret := EtlOffice365Connector{
 		opts: opts,
 	}
-	ret.users, err = azure.CreateAzureConnectorUser(&azure.EtlAzureOptions{
-		GraphClient: opts.Client,
-	})
+	ret.users, err = createOffice365ConnectorUser(opts)
 
 	if err != nil {
 		return nil, err
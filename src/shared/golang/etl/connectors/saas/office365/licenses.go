@@ -0,0 +1,54 @@
+package office365
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"net/url"
+)
+
+const disabledServicePlanStatus = "Disabled"
+
+type office365License struct {
+	SkuId         string `json:"skuId"`
+	SkuPartNumber string `json:"skuPartNumber"`
+	ServicePlans  []struct {
+		ServicePlanName    string `json:"servicePlanName"`
+		ProvisioningStatus string `json:"provisioningStatus"`
+	} `json:"servicePlans"`
+}
+
+// enabledServicePlans returns the services (Exchange, SharePoint, Teams, etc.) in the license that the user can actually use.
+func (l office365License) enabledServicePlans() []string {
+	ret := []string{}
+	for _, p := range l.ServicePlans {
+		if p.ProvisioningStatus == disabledServicePlanStatus {
+			continue
+		}
+		ret = append(ret, p.ServicePlanName)
+	}
+	return ret
+}
+
+func licenseRoleName(l office365License) string {
+	return fmt.Sprintf("License: %s", l.SkuPartNumber)
+}
+
+func (c *EtlOffice365ConnectorUser) getUserLicenses(userId string) ([]office365License, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextLink string             `json:"@odata.nextLink"`
+		Value    []office365License `json:"value"`
+	}
+
+	endpoint := fmt.Sprintf("%s/users/%s/licenseDetails", baseGraphUrl, url.PathEscape(userId))
+	responses := []ResponseBody{}
+	source, err := graphPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retLicenses := []office365License{}
+	for _, resp := range responses {
+		retLicenses = append(retLicenses, resp.Value...)
+	}
+	return retLicenses, source, nil
+}
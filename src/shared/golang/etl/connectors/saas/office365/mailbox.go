@@ -0,0 +1,108 @@
+package office365
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"net/url"
+)
+
+// mailboxRoleName is the role that holds the mailboxes a user has been delegated access to. Permissions are keyed by
+// the mailbox and contain the access rights (FullAccess, SendAs, etc.) the user has on the mailbox.
+const mailboxRoleName = "Mailbox Delegation"
+
+// Permissions granted to these principals are the mailbox owner's own access and aren't delegation.
+var ignoredMailboxPrincipals = map[string]bool{
+	"NT AUTHORITY\\SELF": true,
+}
+
+type exchangeCmdletInput struct {
+	CmdletInput struct {
+		CmdletName string            `json:"CmdletName"`
+		Parameters map[string]string `json:"Parameters"`
+	} `json:"CmdletInput"`
+}
+
+func createExchangeCmdletInput(cmdlet string, identity string) *exchangeCmdletInput {
+	input := exchangeCmdletInput{}
+	input.CmdletInput.CmdletName = cmdlet
+	input.CmdletInput.Parameters = map[string]string{
+		"Identity": identity,
+	}
+	return &input
+}
+
+// exchangeMailboxPermission is the union of the output of Get-MailboxPermission (User) and Get-RecipientPermission (Trustee).
+type exchangeMailboxPermission struct {
+	Identity     string   `json:"Identity"`
+	User         string   `json:"User"`
+	Trustee      string   `json:"Trustee"`
+	AccessRights []string `json:"AccessRights"`
+	IsInherited  bool     `json:"IsInherited"`
+	Deny         bool     `json:"Deny"`
+}
+
+func (p exchangeMailboxPermission) principal() string {
+	if p.User != "" {
+		return p.User
+	}
+	return p.Trustee
+}
+
+func (p exchangeMailboxPermission) isDelegation() bool {
+	if p.IsInherited {
+		return false
+	}
+
+	_, ok := ignoredMailboxPrincipals[p.principal()]
+	return !ok
+}
+
+func (c *EtlOffice365ConnectorUser) runExchangeCmdlet(cmdlet string, identity string) ([]exchangeMailboxPermission, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextLink string                      `json:"@odata.nextLink"`
+		Value    []exchangeMailboxPermission `json:"value"`
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/InvokeCommand", exchangeAdminUrl, url.PathEscape(c.opts.TenantId))
+	responses := []ResponseBody{}
+	source, err := office365PaginatedDo(c.opts.ExchangeClient, "POST", endpoint, createExchangeCmdletInput(cmdlet, identity), &responses)
+	if err == errGraphNotFound {
+		// Users without a mailbox.
+		return []exchangeMailboxPermission{}, connectors.CreateSourceInfo(), nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	retPermissions := []exchangeMailboxPermission{}
+	for _, resp := range responses {
+		retPermissions = append(retPermissions, resp.Value...)
+	}
+	return retPermissions, source, nil
+}
+
+// getMailboxPermissions returns the FullAccess (and similar) permissions as well as the SendAs permissions that other
+// users have on the given user's mailbox.
+func (c *EtlOffice365ConnectorUser) getMailboxPermissions(user office365User) ([]exchangeMailboxPermission, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	mailboxPermissions, src, err := c.runExchangeCmdlet("Get-MailboxPermission", user.UserPrincipalName)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	recipientPermissions, src, err := c.runExchangeCmdlet("Get-RecipientPermission", user.UserPrincipalName)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	retPermissions := []exchangeMailboxPermission{}
+	for _, p := range append(mailboxPermissions, recipientPermissions...) {
+		if !p.isDelegation() {
+			continue
+		}
+		retPermissions = append(retPermissions, p)
+	}
+	return retPermissions, finalSource, nil
+}
@@ -0,0 +1,116 @@
+package office365
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"net/url"
+)
+
+// siteRoleName is the role that holds the permissions a user has on SharePoint sites and OneDrives. Permissions
+// are keyed by the URL of the site (or OneDrive) and contain the roles (read, write, owner) the user has.
+const siteRoleName = "Site Permissions"
+
+type office365Site struct {
+	Id          string `json:"id"`
+	WebUrl      string `json:"webUrl"`
+	DisplayName string `json:"displayName"`
+}
+
+type office365Identity struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+}
+
+type office365IdentitySet struct {
+	User *office365Identity `json:"user"`
+}
+
+type office365DrivePermission struct {
+	Id                  string                 `json:"id"`
+	Roles               []string               `json:"roles"`
+	GrantedTo           *office365IdentitySet  `json:"grantedTo"`
+	GrantedToIdentities []office365IdentitySet `json:"grantedToIdentities"`
+	Link                *struct {
+		Scope string `json:"scope"`
+		Type  string `json:"type"`
+	} `json:"link"`
+}
+
+// principals returns the users that were granted the permission. Sharing links that can be used by anyone
+// (or anyone in the organization) are returned as a pseudo-user named after the scope of the link.
+func (p office365DrivePermission) principals() []office365Identity {
+	ret := []office365Identity{}
+	if p.GrantedTo != nil && p.GrantedTo.User != nil {
+		ret = append(ret, *p.GrantedTo.User)
+	}
+
+	for _, identity := range p.GrantedToIdentities {
+		if identity.User != nil {
+			ret = append(ret, *identity.User)
+		}
+	}
+
+	if len(ret) == 0 && p.Link != nil {
+		ret = append(ret, office365Identity{
+			Id:          fmt.Sprintf("link:%s", p.Link.Scope),
+			DisplayName: fmt.Sprintf("Sharing Link (%s)", p.Link.Scope),
+		})
+	}
+	return ret
+}
+
+func (c *EtlOffice365ConnectorUser) getSites() ([]office365Site, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextLink string          `json:"@odata.nextLink"`
+		Value    []office365Site `json:"value"`
+	}
+
+	endpoint := fmt.Sprintf("%s/sites?search=*", baseGraphUrl)
+	responses := []ResponseBody{}
+	source, err := graphPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retSites := []office365Site{}
+	for _, resp := range responses {
+		retSites = append(retSites, resp.Value...)
+	}
+	return retSites, source, nil
+}
+
+func (c *EtlOffice365ConnectorUser) getDriveRootPermissions(driveEndpoint string) ([]office365DrivePermission, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextLink string                     `json:"@odata.nextLink"`
+		Value    []office365DrivePermission `json:"value"`
+	}
+
+	endpoint := fmt.Sprintf("%s/root/permissions", driveEndpoint)
+	responses := []ResponseBody{}
+	source, err := graphPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err == errGraphNotFound {
+		// Not every site has a document library and not every user has a OneDrive.
+		return []office365DrivePermission{}, connectors.CreateSourceInfo(), nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	retPermissions := []office365DrivePermission{}
+	for _, resp := range responses {
+		retPermissions = append(retPermissions, resp.Value...)
+	}
+	return retPermissions, source, nil
+}
+
+func (c *EtlOffice365ConnectorUser) getSitePermissions(siteId string) ([]office365DrivePermission, *connectors.EtlSourceInfo, error) {
+	return c.getDriveRootPermissions(fmt.Sprintf("%s/sites/%s/drive", baseGraphUrl, url.PathEscape(siteId)))
+}
+
+func (c *EtlOffice365ConnectorUser) getOneDrivePermissions(userId string) ([]office365DrivePermission, *connectors.EtlSourceInfo, error) {
+	return c.getDriveRootPermissions(fmt.Sprintf("%s/users/%s/drive", baseGraphUrl, url.PathEscape(userId)))
+}
+
+func oneDriveResourceName(user office365User) string {
+	return fmt.Sprintf("OneDrive (%s)", user.UserPrincipalName)
+}
@@ -0,0 +1,64 @@
+package office365
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"net/url"
+)
+
+// teamRoleName is the role that holds the Microsoft Teams a user is in. Permissions are keyed by the name of the team
+// and contain whether the user is an owner or member of the team.
+const teamRoleName = "Teams"
+
+const teamOwnerPermission = "Owner"
+const teamMemberPermission = "Member"
+
+type office365Team struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+func (c *EtlOffice365ConnectorUser) getTeams() ([]office365Team, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextLink string          `json:"@odata.nextLink"`
+		Value    []office365Team `json:"value"`
+	}
+
+	endpoint := fmt.Sprintf(
+		"%s/groups?$filter=%s&$select=id,displayName",
+		baseGraphUrl,
+		url.QueryEscape("resourceProvisioningOptions/Any(x:x eq 'Team')"),
+	)
+	responses := []ResponseBody{}
+	source, err := graphPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retTeams := []office365Team{}
+	for _, resp := range responses {
+		retTeams = append(retTeams, resp.Value...)
+	}
+	return retTeams, source, nil
+}
+
+// getTeamUsers returns the directory objects that are either owners or members of the team depending on the relationship.
+func (c *EtlOffice365ConnectorUser) getTeamUsers(teamId string, relationship string) ([]office365User, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextLink string          `json:"@odata.nextLink"`
+		Value    []office365User `json:"value"`
+	}
+
+	endpoint := fmt.Sprintf("%s/groups/%s/%s", baseGraphUrl, url.PathEscape(teamId), relationship)
+	responses := []ResponseBody{}
+	source, err := graphPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []office365User{}
+	for _, resp := range responses {
+		retUsers = append(retUsers, resp.Value...)
+	}
+	return retUsers, source, nil
+}
@@ -0,0 +1,485 @@
+package office365
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/iaas/azure"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/utility/mt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type office365User struct {
+	Id                string `json:"id"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	DisplayName       string `json:"displayName"`
+	Mail              string `json:"mail"`
+}
+
+// Office365AttributeUnresolvedTrustee is set on mailbox delegates that Exchange listed by something other than a
+// user's UPN or object id.
+const Office365AttributeUnresolvedTrustee = "unresolved_trustee"
+
+type EtlOffice365ConnectorUser struct {
+	opts  *EtlOffice365Options
+	azure *azure.EtlAzureConnectorUser
+}
+
+func createOffice365ConnectorUser(opts *EtlOffice365Options) (*EtlOffice365ConnectorUser, error) {
+	// Azure AD provides the user listing and directory (admin) roles. Office 365 specific access is layered on top.
+	azureUser, err := azure.CreateAzureConnectorUser(&azure.EtlAzureOptions{
+		GraphClient: opts.Client,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &EtlOffice365ConnectorUser{
+		opts:  opts,
+		azure: azureUser,
+	}, nil
+}
+
+// office365Principal is anything that can be granted access in Office 365. Users in the directory are keyed by
+// their object id, everything else (e.g. sharing links, external users, mailbox delegates we couldn't resolve) by
+// a name unique to that principal.
+type office365Principal struct {
+	Key   string
+	User  *types.EtlUser
+	Roles map[string]*types.EtlRole
+}
+
+type office365PrincipalSet struct {
+	principals map[string]*office365Principal
+}
+
+func createOffice365PrincipalSet() *office365PrincipalSet {
+	return &office365PrincipalSet{
+		principals: map[string]*office365Principal{},
+	}
+}
+
+func (s *office365PrincipalSet) get(key string, createUser func() *types.EtlUser) *office365Principal {
+	p, ok := s.principals[key]
+	if !ok {
+		p = &office365Principal{
+			Key:   key,
+			User:  createUser(),
+			Roles: map[string]*types.EtlRole{},
+		}
+		s.principals[key] = p
+	}
+	return p
+}
+
+func (p *office365Principal) addPermissions(roleName string, resource string, permissions []string, denied bool) {
+	role, ok := p.Roles[roleName]
+	if !ok {
+		role = &types.EtlRole{
+			Name:        roleName,
+			Permissions: map[string][]string{},
+			Denied:      map[string][]string{},
+		}
+		p.Roles[roleName] = role
+	}
+
+	target := role.Permissions
+	if denied {
+		target = role.Denied
+	}
+
+	existing := map[string]bool{}
+	for _, perm := range target[resource] {
+		existing[perm] = true
+	}
+
+	merged := target[resource]
+	for _, perm := range permissions {
+		if existing[perm] {
+			continue
+		}
+		existing[perm] = true
+		merged = append(merged, perm)
+	}
+	sort.Strings(merged)
+	target[resource] = merged
+}
+
+func (c *EtlOffice365ConnectorUser) getAllUsers() ([]office365User, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextLink string          `json:"@odata.nextLink"`
+		Value    []office365User `json:"value"`
+	}
+
+	endpoint := fmt.Sprintf("%s/users?$select=id,userPrincipalName,displayName,mail", baseGraphUrl)
+	responses := []ResponseBody{}
+	source, err := graphPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []office365User{}
+	for _, resp := range responses {
+		retUsers = append(retUsers, resp.Value...)
+	}
+	return retUsers, source, nil
+}
+
+type office365UserAccess struct {
+	Licenses            []office365License
+	OneDrivePermissions []office365DrivePermission
+	MailboxPermissions  []exchangeMailboxPermission
+}
+
+type office365GetUserAccessJob struct {
+	// Input
+	User      office365User
+	Connector *EtlOffice365ConnectorUser
+
+	// Output
+	Access    *office365UserAccess
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *office365GetUserAccessJob) Do() error {
+	licenses, source, err := j.Connector.getUserLicenses(j.User.Id)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+	j.Access.Licenses = licenses
+
+	oneDrive, source, err := j.Connector.getOneDrivePermissions(j.User.Id)
+	if err != nil {
+		return err
+	}
+	j.OutSource <- source
+	j.Access.OneDrivePermissions = oneDrive
+
+	if j.Connector.opts.ExchangeClient != nil && j.Connector.opts.TenantId != "" {
+		mailbox, source, err := j.Connector.getMailboxPermissions(j.User)
+		if err != nil {
+			return err
+		}
+		j.OutSource <- source
+		j.Access.MailboxPermissions = mailbox
+	}
+	return nil
+}
+
+type office365GetSitePermissionsJob struct {
+	// Input
+	SiteId    string
+	Connector *EtlOffice365ConnectorUser
+
+	// Output
+	Permissions *[]office365DrivePermission
+	OutSource   chan *connectors.EtlSourceInfo
+}
+
+func (j *office365GetSitePermissionsJob) Do() error {
+	permissions, source, err := j.Connector.getSitePermissions(j.SiteId)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Permissions = permissions
+	return nil
+}
+
+type office365GetTeamUsersJob struct {
+	// Input
+	TeamId       string
+	Relationship string
+	Connector    *EtlOffice365ConnectorUser
+
+	// Output
+	Users     *[]office365User
+	OutSource chan *connectors.EtlSourceInfo
+}
+
+func (j *office365GetTeamUsersJob) Do() error {
+	users, source, err := j.Connector.getTeamUsers(j.TeamId, j.Relationship)
+	if err != nil {
+		return err
+	}
+
+	j.OutSource <- source
+	*j.Users = users
+	return nil
+}
+
+func (c *EtlOffice365ConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	// Step 1: Get the Azure AD users along with their directory roles.
+	azureUsers, src, err := c.azure.GetUserListing()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	etlUsersByUpn := map[string]*types.EtlUser{}
+	for _, u := range azureUsers {
+		etlUsersByUpn[u.Username] = u
+	}
+
+	// Step 2: Get the users again to be able to map object ids (which is what Graph uses to refer to users
+	// in permissions) and user principal names (which is what Exchange uses) to users.
+	users, src, err := c.getAllUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	usersById := map[string]office365User{}
+	usersByUpn := map[string]office365User{}
+	for _, u := range users {
+		usersById[u.Id] = u
+		usersByUpn[strings.ToLower(u.UserPrincipalName)] = u
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	sourcesToMerge := make(chan *connectors.EtlSourceInfo)
+	go func(source *connectors.EtlSourceInfo, input chan *connectors.EtlSourceInfo) {
+		defer wg.Done()
+		for s := range input {
+			source.MergeWith(s)
+		}
+	}(finalSource, sourcesToMerge)
+
+	// Step 3: Get the licenses, OneDrive permissions and mailbox delegation for every user.
+	perUserAccess := map[string]*office365UserAccess{}
+	{
+		pool := mt.NewTaskPool(10)
+		for _, u := range users {
+			access := office365UserAccess{}
+			pool.AddJob(&office365GetUserAccessJob{
+				User:      u,
+				Connector: c,
+				Access:    &access,
+				OutSource: sourcesToMerge,
+			})
+			perUserAccess[u.Id] = &access
+		}
+
+		err := pool.SyncExecute()
+		if err != nil {
+			close(sourcesToMerge)
+			wg.Wait()
+			return nil, nil, err
+		}
+	}
+
+	// Step 4: Get the permissions on every SharePoint site.
+	sites, src, err := c.getSites()
+	if err != nil {
+		close(sourcesToMerge)
+		wg.Wait()
+		return nil, nil, err
+	}
+	sourcesToMerge <- src
+
+	perSitePermissions := map[string]*[]office365DrivePermission{}
+	{
+		pool := mt.NewTaskPool(10)
+		for _, s := range sites {
+			permissions := []office365DrivePermission{}
+			pool.AddJob(&office365GetSitePermissionsJob{
+				SiteId:      s.Id,
+				Connector:   c,
+				Permissions: &permissions,
+				OutSource:   sourcesToMerge,
+			})
+			perSitePermissions[s.Id] = &permissions
+		}
+
+		err := pool.SyncExecute()
+		if err != nil {
+			close(sourcesToMerge)
+			wg.Wait()
+			return nil, nil, err
+		}
+	}
+
+	// Step 5: Get the owners and members of every team.
+	teams, src, err := c.getTeams()
+	if err != nil {
+		close(sourcesToMerge)
+		wg.Wait()
+		return nil, nil, err
+	}
+	sourcesToMerge <- src
+
+	perTeamOwners := map[string]*[]office365User{}
+	perTeamMembers := map[string]*[]office365User{}
+	{
+		pool := mt.NewTaskPool(10)
+		for _, t := range teams {
+			owners := []office365User{}
+			members := []office365User{}
+
+			pool.AddJob(&office365GetTeamUsersJob{
+				TeamId:       t.Id,
+				Relationship: "owners",
+				Connector:    c,
+				Users:        &owners,
+				OutSource:    sourcesToMerge,
+			})
+
+			pool.AddJob(&office365GetTeamUsersJob{
+				TeamId:       t.Id,
+				Relationship: "members",
+				Connector:    c,
+				Users:        &members,
+				OutSource:    sourcesToMerge,
+			})
+
+			perTeamOwners[t.Id] = &owners
+			perTeamMembers[t.Id] = &members
+		}
+
+		err := pool.SyncExecute()
+		if err != nil {
+			close(sourcesToMerge)
+			wg.Wait()
+			return nil, nil, err
+		}
+	}
+
+	close(sourcesToMerge)
+	wg.Wait()
+
+	// Step 6: Assign everything we collected to the principals that were granted the access.
+	principals := createOffice365PrincipalSet()
+	principalForUser := func(u office365User) *office365Principal {
+		return principals.get(u.Id, func() *types.EtlUser {
+			etlUser, ok := etlUsersByUpn[u.UserPrincipalName]
+			if ok {
+				return etlUser
+			}
+
+			return &types.EtlUser{
+				Username: u.UserPrincipalName,
+				FullName: u.DisplayName,
+				Email:    u.Mail,
+				Roles:    map[string]*types.EtlRole{},
+			}
+		})
+	}
+
+	principalForIdentity := func(identity office365Identity) *office365Principal {
+		u, ok := usersById[identity.Id]
+		if ok {
+			return principalForUser(u)
+		}
+
+		return principals.get(identity.Id, func() *types.EtlUser {
+			username := identity.Email
+			if username == "" {
+				username = identity.Id
+			}
+
+			return &types.EtlUser{
+				Username: username,
+				FullName: identity.DisplayName,
+				Email:    identity.Email,
+				Roles:    map[string]*types.EtlRole{},
+			}
+		})
+	}
+
+	// Exchange identifies trustees by their UPN or object id. Anything else (e.g. a display name, which doesn't have
+	// to be unique) is kept as its own principal rather than guessing which user it is.
+	principalForExchangeName := func(name string) *office365Principal {
+		u, ok := usersByUpn[strings.ToLower(name)]
+		if !ok {
+			u, ok = usersById[strings.ToLower(name)]
+		}
+
+		if ok {
+			return principalForUser(u)
+		}
+
+		return principals.get(fmt.Sprintf("exchange:%s", name), func() *types.EtlUser {
+			return &types.EtlUser{
+				Username: name,
+				Roles:    map[string]*types.EtlRole{},
+				Attributes: map[string]string{
+					Office365AttributeUnresolvedTrustee: "true",
+				},
+			}
+		})
+	}
+
+	for _, u := range users {
+		access := perUserAccess[u.Id]
+
+		principal := principalForUser(u)
+		for _, l := range access.Licenses {
+			principal.addPermissions(licenseRoleName(l), l.SkuPartNumber, l.enabledServicePlans(), false)
+		}
+
+		for _, p := range access.OneDrivePermissions {
+			for _, identity := range p.principals() {
+				principalForIdentity(identity).addPermissions(siteRoleName, oneDriveResourceName(u), p.Roles, false)
+			}
+		}
+
+		for _, p := range access.MailboxPermissions {
+			principalForExchangeName(p.principal()).addPermissions(mailboxRoleName, u.UserPrincipalName, p.AccessRights, p.Deny)
+		}
+	}
+
+	for _, s := range sites {
+		for _, p := range *perSitePermissions[s.Id] {
+			for _, identity := range p.principals() {
+				principalForIdentity(identity).addPermissions(siteRoleName, s.WebUrl, p.Roles, false)
+			}
+		}
+	}
+
+	for _, t := range teams {
+		for _, u := range *perTeamOwners[t.Id] {
+			principalForUser(u).addPermissions(teamRoleName, t.DisplayName, []string{teamOwnerPermission}, false)
+		}
+
+		for _, u := range *perTeamMembers[t.Id] {
+			principalForUser(u).addPermissions(teamRoleName, t.DisplayName, []string{teamMemberPermission}, false)
+		}
+	}
+
+	// Step 7: Merge all the Office 365 roles into the Azure AD users.
+	retUsers := []*types.EtlUser{}
+	retUsers = append(retUsers, azureUsers...)
+
+	seenUsers := map[*types.EtlUser]bool{}
+	for _, u := range azureUsers {
+		seenUsers[u] = true
+	}
+
+	principalKeys := []string{}
+	for key := range principals.principals {
+		principalKeys = append(principalKeys, key)
+	}
+	sort.Strings(principalKeys)
+
+	for _, key := range principalKeys {
+		p := principals.principals[key]
+		for name, role := range p.Roles {
+			p.User.Roles[name] = role
+		}
+
+		if !seenUsers[p.User] {
+			seenUsers[p.User] = true
+			retUsers = append(retUsers, p.User)
+		}
+	}
+
+	return retUsers, finalSource, nil
+}
@@ -0,0 +1,124 @@
+package office365
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+)
+
+// errGraphNotFound is returned when the requested resource doesn't exist, e.g. a user that doesn't have a OneDrive
+// or a site without a document library.
+var errGraphNotFound = errors.New("Office 365 resource not found.")
+
+func office365Do(client http_utility.HttpClient, method string, endpoint string, input interface{}, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	var body *bytes.Reader
+	if input != nil {
+		inputData, err := json.Marshal(input)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(inputData)
+	} else {
+		body = bytes.NewReader([]byte{})
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		method,
+		endpoint,
+		body,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errGraphNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Office 365 API Error: " + string(bodyData))
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command:    endpoint,
+		Parameters: input,
+		RawData:    string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// office365PaginatedDo expects output to be a pointer to a slice of structs that each have a NextLink field
+// (@odata.nextLink). Each page's response is appended to the slice.
+func office365PaginatedDo(client http_utility.HttpClient, method string, baseEndpoint string, input interface{}, output interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	endpoint := baseEndpoint
+	for {
+		responseBodyValue := reflect.New(reflectBaseType)
+		cmdSrc, err := office365Do(client, method, endpoint, input, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		nextLink := responseBodyValue.Elem().FieldByName("NextLink").Interface().(string)
+		if nextLink == "" {
+			break
+		}
+
+		endpoint = nextLink
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
+
+func graphPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	return office365PaginatedDo(client, "GET", baseEndpoint, nil, output)
+}
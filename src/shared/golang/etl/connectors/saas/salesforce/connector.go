@@ -0,0 +1,53 @@
+package salesforce
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"strings"
+)
+
+type EtlSalesforceOptions struct {
+	Client http_utility.HttpClient
+	// InstanceUrl is the org's URL (e.g. https://grchive.my.salesforce.com). It's returned along with the access token.
+	InstanceUrl string
+	// ApiVersion is optional and defaults to defaultSalesforceApiVersion.
+	ApiVersion string
+}
+
+type EtlSalesforceConnector struct {
+	opts  *EtlSalesforceOptions
+	users *EtlSalesforceConnectorUser
+}
+
+const defaultSalesforceApiVersion string = "v50.0"
+
+func (o *EtlSalesforceOptions) instanceUrl() string {
+	return strings.TrimSuffix(o.InstanceUrl, "/")
+}
+
+func (o *EtlSalesforceOptions) apiBaseUrl() string {
+	version := o.ApiVersion
+	if version == "" {
+		version = defaultSalesforceApiVersion
+	}
+	return fmt.Sprintf("%s/services/data/%s", o.instanceUrl(), version)
+}
+
+func (c *EtlSalesforceConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateSalesforceConnector(opts *EtlSalesforceOptions) (*EtlSalesforceConnector, error) {
+	var err error
+	ret := EtlSalesforceConnector{
+		opts: opts,
+	}
+	ret.users, err = createSalesforceConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
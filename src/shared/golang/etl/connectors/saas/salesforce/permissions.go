@@ -0,0 +1,258 @@
+package salesforce
+
+import (
+	"encoding/json"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strings"
+)
+
+const salesforceSystemObject = "system"
+
+type salesforceSystemPermission struct {
+	Field string
+	Label string
+}
+
+// System permissions that matter the most in an access review. The labels are what's shown in Setup.
+var salesforceSystemPermissions = []salesforceSystemPermission{
+	{"PermissionsModifyAllData", "Modify All Data"},
+	{"PermissionsViewAllData", "View All Data"},
+	{"PermissionsManageUsers", "Manage Users"},
+	{"PermissionsResetPasswords", "Reset User Passwords and Unlock Users"},
+	{"PermissionsCustomizeApplication", "Customize Application"},
+	{"PermissionsAuthorApex", "Author Apex"},
+	{"PermissionsViewSetup", "View Setup and Configuration"},
+	{"PermissionsApiEnabled", "API Enabled"},
+}
+
+type salesforceProfile struct {
+	Id          string `json:"Id"`
+	Name        string `json:"Name"`
+	UserType    string `json:"UserType"`
+	UserLicense *struct {
+		Name string `json:"Name"`
+	} `json:"UserLicense"`
+}
+
+type salesforcePermissionSet struct {
+	Id               string `json:"Id"`
+	Name             string `json:"Name"`
+	Label            string `json:"Label"`
+	IsOwnedByProfile bool   `json:"IsOwnedByProfile"`
+	ProfileId        string `json:"ProfileId"`
+
+	// The labels of the enabled salesforceSystemPermissions.
+	SystemPermissions []string `json:"-"`
+}
+
+func (p *salesforcePermissionSet) UnmarshalJSON(data []byte) error {
+	type rawPermissionSet salesforcePermissionSet
+	raw := rawPermissionSet{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	raw.SystemPermissions = []string{}
+	for _, perm := range salesforceSystemPermissions {
+		if enabled, ok := fields[perm.Field].(bool); ok && enabled {
+			raw.SystemPermissions = append(raw.SystemPermissions, perm.Label)
+		}
+	}
+
+	*p = salesforcePermissionSet(raw)
+	return nil
+}
+
+type salesforceObjectPermission struct {
+	ParentId                    string `json:"ParentId"`
+	SobjectType                 string `json:"SobjectType"`
+	PermissionsCreate           bool   `json:"PermissionsCreate"`
+	PermissionsRead             bool   `json:"PermissionsRead"`
+	PermissionsEdit             bool   `json:"PermissionsEdit"`
+	PermissionsDelete           bool   `json:"PermissionsDelete"`
+	PermissionsViewAllRecords   bool   `json:"PermissionsViewAllRecords"`
+	PermissionsModifyAllRecords bool   `json:"PermissionsModifyAllRecords"`
+}
+
+func (o salesforceObjectPermission) permissions() []string {
+	ret := []string{}
+	if o.PermissionsCreate {
+		ret = append(ret, "Create")
+	}
+
+	if o.PermissionsRead {
+		ret = append(ret, "Read")
+	}
+
+	if o.PermissionsEdit {
+		ret = append(ret, "Edit")
+	}
+
+	if o.PermissionsDelete {
+		ret = append(ret, "Delete")
+	}
+
+	if o.PermissionsViewAllRecords {
+		ret = append(ret, "View All")
+	}
+
+	if o.PermissionsModifyAllRecords {
+		ret = append(ret, "Modify All")
+	}
+	return ret
+}
+
+type salesforcePermissionSetAssignment struct {
+	AssigneeId      string `json:"AssigneeId"`
+	PermissionSetId string `json:"PermissionSetId"`
+}
+
+func objectName(sobject string) string {
+	return fmt.Sprintf("object::%s", sobject)
+}
+
+func (c *EtlSalesforceConnectorUser) getProfiles() ([]salesforceProfile, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextRecordsUrl string              `json:"nextRecordsUrl"`
+		Records        []salesforceProfile `json:"records"`
+	}
+
+	responses := []ResponseBody{}
+	source, err := salesforceQuery(c.opts, "SELECT Id, Name, UserType, UserLicense.Name FROM Profile", &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retProfiles := []salesforceProfile{}
+	for _, resp := range responses {
+		retProfiles = append(retProfiles, resp.Records...)
+	}
+	return retProfiles, source, nil
+}
+
+func (c *EtlSalesforceConnectorUser) getPermissionSets() ([]salesforcePermissionSet, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextRecordsUrl string                    `json:"nextRecordsUrl"`
+		Records        []salesforcePermissionSet `json:"records"`
+	}
+
+	fields := []string{"Id", "Name", "Label", "IsOwnedByProfile", "ProfileId"}
+	for _, perm := range salesforceSystemPermissions {
+		fields = append(fields, perm.Field)
+	}
+
+	responses := []ResponseBody{}
+	source, err := salesforceQuery(c.opts, fmt.Sprintf("SELECT %s FROM PermissionSet", strings.Join(fields, ", ")), &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retSets := []salesforcePermissionSet{}
+	for _, resp := range responses {
+		retSets = append(retSets, resp.Records...)
+	}
+	return retSets, source, nil
+}
+
+func (c *EtlSalesforceConnectorUser) getObjectPermissions() ([]salesforceObjectPermission, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextRecordsUrl string                       `json:"nextRecordsUrl"`
+		Records        []salesforceObjectPermission `json:"records"`
+	}
+
+	responses := []ResponseBody{}
+	source, err := salesforceQuery(c.opts, "SELECT ParentId, SobjectType, PermissionsCreate, PermissionsRead, PermissionsEdit, PermissionsDelete, PermissionsViewAllRecords, PermissionsModifyAllRecords FROM ObjectPermissions", &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retPermissions := []salesforceObjectPermission{}
+	for _, resp := range responses {
+		retPermissions = append(retPermissions, resp.Records...)
+	}
+	return retPermissions, source, nil
+}
+
+func (c *EtlSalesforceConnectorUser) getPermissionSetAssignments() ([]salesforcePermissionSetAssignment, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextRecordsUrl string                              `json:"nextRecordsUrl"`
+		Records        []salesforcePermissionSetAssignment `json:"records"`
+	}
+
+	responses := []ResponseBody{}
+	source, err := salesforceQuery(c.opts, "SELECT AssigneeId, PermissionSetId FROM PermissionSetAssignment", &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retAssignments := []salesforcePermissionSetAssignment{}
+	for _, resp := range responses {
+		retAssignments = append(retAssignments, resp.Records...)
+	}
+	return retAssignments, source, nil
+}
+
+// getPermissionSetRoles returns a role for every permission set keyed by the permission set ID. A profile's
+// permissions live in a permission set that the profile owns so profiles become roles the same way.
+func (c *EtlSalesforceConnectorUser) getPermissionSetRoles(profiles map[string]salesforceProfile) (map[string]*types.EtlRole, map[string]string, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	sets, src, err := c.getPermissionSets()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	objectPermissions, src, err := c.getObjectPermissions()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	roles := map[string]*types.EtlRole{}
+	// The ID of the permission set owned by each profile, keyed by the profile ID.
+	profileSets := map[string]string{}
+	for _, s := range sets {
+		name := fmt.Sprintf("Permission Set: %s", s.Name)
+		if s.IsOwnedByProfile {
+			profileName := s.ProfileId
+			if p, ok := profiles[s.ProfileId]; ok {
+				profileName = p.Name
+			}
+			name = fmt.Sprintf("Profile: %s", profileName)
+			profileSets[s.ProfileId] = s.Id
+		}
+
+		role := &types.EtlRole{
+			Name:        name,
+			Permissions: map[string][]string{},
+		}
+
+		if len(s.SystemPermissions) > 0 {
+			role.Permissions[salesforceSystemObject] = s.SystemPermissions
+		}
+		roles[s.Id] = role
+	}
+
+	for _, o := range objectPermissions {
+		role, ok := roles[o.ParentId]
+		if !ok {
+			continue
+		}
+
+		perms := o.permissions()
+		if len(perms) == 0 {
+			continue
+		}
+		role.Permissions[objectName(o.SobjectType)] = perms
+	}
+
+	return roles, profileSets, finalSource, nil
+}
@@ -0,0 +1,161 @@
+package salesforce
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strconv"
+	"time"
+)
+
+const (
+	SalesforceAttributeUserId    = "user_id"
+	SalesforceAttributeUserType  = "user_type"
+	SalesforceAttributeActive    = "active"
+	SalesforceAttributeProfile   = "profile"
+	SalesforceAttributeLicense   = "license"
+	SalesforceAttributeLastLogin = "last_login"
+)
+
+// The format SOQL returns datetime fields in.
+const salesforceTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+type salesforceUser struct {
+	Id               string `json:"Id"`
+	Username         string `json:"Username"`
+	Name             string `json:"Name"`
+	Email            string `json:"Email"`
+	IsActive         bool   `json:"IsActive"`
+	UserType         string `json:"UserType"`
+	ProfileId        string `json:"ProfileId"`
+	CreatedDate      string `json:"CreatedDate"`
+	LastModifiedDate string `json:"LastModifiedDate"`
+	LastLoginDate    string `json:"LastLoginDate"`
+}
+
+func parseSalesforceTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(salesforceTimeFormat, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+type EtlSalesforceConnectorUser struct {
+	opts *EtlSalesforceOptions
+}
+
+func createSalesforceConnectorUser(opts *EtlSalesforceOptions) (*EtlSalesforceConnectorUser, error) {
+	return &EtlSalesforceConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlSalesforceConnectorUser) getUsers() ([]salesforceUser, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		NextRecordsUrl string           `json:"nextRecordsUrl"`
+		Records        []salesforceUser `json:"records"`
+	}
+
+	responses := []ResponseBody{}
+	source, err := salesforceQuery(c.opts, "SELECT Id, Username, Name, Email, IsActive, UserType, ProfileId, CreatedDate, LastModifiedDate, LastLoginDate FROM User", &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []salesforceUser{}
+	for _, resp := range responses {
+		retUsers = append(retUsers, resp.Records...)
+	}
+	return retUsers, source, nil
+}
+
+func (c *EtlSalesforceConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	// Step 1: Users and profiles. Every user has exactly one profile.
+	users, src, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	profileList, src, err := c.getProfiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	profiles := map[string]salesforceProfile{}
+	for _, p := range profileList {
+		profiles[p.Id] = p
+	}
+
+	// Step 2: What each profile and permission set grants.
+	roles, profileSets, src, err := c.getPermissionSetRoles(profiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	// Step 3: Which permission sets are assigned to each user. This also includes the permission
+	// set owned by the user's profile.
+	assignments, src, err := c.getPermissionSetAssignments()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.MergeWith(src)
+
+	userAssignments := map[string][]string{}
+	for _, a := range assignments {
+		userAssignments[a.AssigneeId] = append(userAssignments[a.AssigneeId], a.PermissionSetId)
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range users {
+		etlUser := &types.EtlUser{
+			Username:       u.Username,
+			FullName:       u.Name,
+			Email:          u.Email,
+			CreatedTime:    parseSalesforceTime(u.CreatedDate),
+			LastChangeTime: parseSalesforceTime(u.LastModifiedDate),
+			Roles:          map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				SalesforceAttributeUserId:   u.Id,
+				SalesforceAttributeUserType: u.UserType,
+				SalesforceAttributeActive:   strconv.FormatBool(u.IsActive),
+			},
+		}
+
+		if lastLogin := parseSalesforceTime(u.LastLoginDate); lastLogin != nil {
+			etlUser.Attributes[SalesforceAttributeLastLogin] = lastLogin.UTC().Format(time.RFC3339)
+		}
+
+		setIds := userAssignments[u.Id]
+		if profile, ok := profiles[u.ProfileId]; ok {
+			etlUser.Attributes[SalesforceAttributeProfile] = profile.Name
+			if profile.UserLicense != nil {
+				etlUser.Attributes[SalesforceAttributeLicense] = profile.UserLicense.Name
+			}
+		}
+
+		if setId, ok := profileSets[u.ProfileId]; ok {
+			setIds = append(setIds, setId)
+		}
+
+		for _, id := range setIds {
+			role, ok := roles[id]
+			if !ok {
+				continue
+			}
+			etlUser.Roles[role.Name] = role
+		}
+
+		retUsers = append(retUsers, etlUser)
+	}
+
+	return retUsers, finalSource, nil
+}
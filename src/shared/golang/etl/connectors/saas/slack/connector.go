@@ -0,0 +1,35 @@
+package slack
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+type EtlSlackOptions struct {
+	Client http_utility.HttpClient
+}
+
+type EtlSlackConnector struct {
+	opts  *EtlSlackOptions
+	users *EtlSlackConnectorUser
+}
+
+const baseUrl string = "https://slack.com/api"
+
+func (c *EtlSlackConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateSlackConnector(opts *EtlSlackOptions) (*EtlSlackConnector, error) {
+	var err error
+	ret := EtlSlackConnector{
+		opts: opts,
+	}
+	ret.users, err = createSlackConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
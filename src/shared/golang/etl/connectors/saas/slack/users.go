@@ -0,0 +1,191 @@
+package slack
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"strconv"
+	"time"
+)
+
+const (
+	SlackAttributeUserId        = "user_id"
+	SlackAttributeTeamId        = "team_id"
+	SlackAttributeDeleted       = "deleted"
+	SlackAttributeBot           = "bot"
+	SlackAttributeGuestType     = "guest_type"
+	SlackAttributeHas2fa        = "has_2fa"
+	SlackAttributeTwoFactorType = "two_factor_type"
+)
+
+const (
+	SlackGuestTypeMultiChannel  = "multi_channel"
+	SlackGuestTypeSingleChannel = "single_channel"
+)
+
+// Role names match what the Slack admin pages call each type of account.
+const (
+	slackPrimaryOwnerRole       = "Primary Owner"
+	slackOwnerRole              = "Workspace Owner"
+	slackAdminRole              = "Workspace Admin"
+	slackMemberRole             = "Member"
+	slackMultiChannelGuestRole  = "Multi-Channel Guest"
+	slackSingleChannelGuestRole = "Single-Channel Guest"
+	slackBotRole                = "Bot"
+)
+
+var slackRolePermissions = map[string][]string{
+	slackPrimaryOwnerRole:       []string{"primary_owner", "owner", "admin", "member"},
+	slackOwnerRole:              []string{"owner", "admin", "member"},
+	slackAdminRole:              []string{"admin", "member"},
+	slackMemberRole:             []string{"member"},
+	slackMultiChannelGuestRole:  []string{"guest"},
+	slackSingleChannelGuestRole: []string{"single_channel_guest"},
+	slackBotRole:                []string{"bot"},
+}
+
+// Slackbot is built into every workspace but isn't marked as a bot.
+const slackbotUserId = "USLACKBOT"
+
+type slackUser struct {
+	Id       string `json:"id"`
+	TeamId   string `json:"team_id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	Deleted  bool   `json:"deleted"`
+	Profile  struct {
+		Email    string `json:"email"`
+		RealName string `json:"real_name"`
+	} `json:"profile"`
+	IsAdmin           bool   `json:"is_admin"`
+	IsOwner           bool   `json:"is_owner"`
+	IsPrimaryOwner    bool   `json:"is_primary_owner"`
+	IsRestricted      bool   `json:"is_restricted"`
+	IsUltraRestricted bool   `json:"is_ultra_restricted"`
+	IsBot             bool   `json:"is_bot"`
+	Has2fa            bool   `json:"has_2fa"`
+	TwoFactorType     string `json:"two_factor_type"`
+	Updated           int64  `json:"updated"`
+}
+
+func (u slackUser) isBot() bool {
+	return u.IsBot || u.Id == slackbotUserId
+}
+
+func (u slackUser) guestType() string {
+	if u.IsUltraRestricted {
+		return SlackGuestTypeSingleChannel
+	} else if u.IsRestricted {
+		return SlackGuestTypeMultiChannel
+	}
+	return ""
+}
+
+// workspaceRole returns the single account type the user has in the workspace. The flags Slack
+// returns are cumulative (e.g. owners are admins too) so the most privileged one wins.
+func (u slackUser) workspaceRole() string {
+	switch {
+	case u.isBot():
+		return slackBotRole
+	case u.IsPrimaryOwner:
+		return slackPrimaryOwnerRole
+	case u.IsOwner:
+		return slackOwnerRole
+	case u.IsAdmin:
+		return slackAdminRole
+	case u.IsUltraRestricted:
+		return slackSingleChannelGuestRole
+	case u.IsRestricted:
+		return slackMultiChannelGuestRole
+	}
+	return slackMemberRole
+}
+
+func (u slackUser) toEtlUser() *types.EtlUser {
+	user := &types.EtlUser{
+		Username: u.Name,
+		FullName: u.RealName,
+		Email:    u.Profile.Email,
+		Roles:    map[string]*types.EtlRole{},
+		Attributes: map[string]string{
+			SlackAttributeUserId:    u.Id,
+			SlackAttributeTeamId:    u.TeamId,
+			SlackAttributeDeleted:   strconv.FormatBool(u.Deleted),
+			SlackAttributeBot:       strconv.FormatBool(u.isBot()),
+			SlackAttributeGuestType: u.guestType(),
+			SlackAttributeHas2fa:    strconv.FormatBool(u.Has2fa),
+		},
+	}
+
+	if u.Profile.Email != "" {
+		user.Username = u.Profile.Email
+	}
+
+	if user.FullName == "" {
+		user.FullName = u.Profile.RealName
+	}
+
+	if u.TwoFactorType != "" {
+		user.Attributes[SlackAttributeTwoFactorType] = u.TwoFactorType
+	}
+
+	if u.Updated != 0 {
+		updated := time.Unix(u.Updated, 0).UTC()
+		user.LastChangeTime = &updated
+	}
+
+	// Deactivated accounts can't sign in so they don't hold a role in the workspace anymore.
+	if !u.Deleted {
+		name := u.workspaceRole()
+		user.Roles[name] = &types.EtlRole{
+			Name: name,
+			Permissions: map[string][]string{
+				fmt.Sprintf("workspace::%s", u.TeamId): append([]string{}, slackRolePermissions[name]...),
+			},
+		}
+	}
+	return user
+}
+
+type EtlSlackConnectorUser struct {
+	opts *EtlSlackOptions
+}
+
+func createSlackConnectorUser(opts *EtlSlackOptions) (*EtlSlackConnectorUser, error) {
+	return &EtlSlackConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+func (c *EtlSlackConnectorUser) getUsers() ([]slackUser, *connectors.EtlSourceInfo, error) {
+	type ResponseBody struct {
+		ResponseMetadata slackResponseMetadata `json:"response_metadata"`
+		Members          []slackUser           `json:"members"`
+	}
+
+	endpoint := fmt.Sprintf("%s/users.list", baseUrl)
+	responses := []ResponseBody{}
+	source, err := slackPaginatedGet(c.opts.Client, endpoint, &responses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []slackUser{}
+	for _, resp := range responses {
+		retUsers = append(retUsers, resp.Members...)
+	}
+	return retUsers, source, nil
+}
+
+func (c *EtlSlackConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	users, source, err := c.getUsers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, u := range users {
+		retUsers = append(retUsers, u.toEtlUser())
+	}
+	return retUsers, source, nil
+}
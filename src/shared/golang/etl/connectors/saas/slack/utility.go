@@ -0,0 +1,135 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const slackPageSize int = 200
+
+type slackResponseMetadata struct {
+	NextCursor string `json:"next_cursor"`
+}
+
+// slackStatus is the part of the response shared by every Slack Web API call. Slack returns a 200
+// even when the call fails so ok needs to be checked as well.
+type slackStatus struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func slackGet(client http_utility.HttpClient, endpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	ctx := context.Background()
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Slack API Error: " + string(bodyData))
+	}
+
+	status := slackStatus{}
+	err = json.Unmarshal(bodyData, &status)
+	if err != nil {
+		return nil, err
+	}
+
+	if !status.Ok {
+		return nil, errors.New("Slack API Error: " + status.Error)
+	}
+
+	err = json.Unmarshal(bodyData, reflectOutPtr.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &connectors.EtlCommandInfo{
+		Command: endpoint,
+		RawData: string(bodyData),
+	}
+	source.AddCommand(cmd)
+	return source, nil
+}
+
+// slackPaginatedGet expects output to be a pointer to a slice of structs that each have a
+// ResponseMetadata field of type slackResponseMetadata. Each page's response is appended to the slice.
+func slackPaginatedGet(client http_utility.HttpClient, baseEndpoint string, output interface{}) (*connectors.EtlSourceInfo, error) {
+	source := connectors.CreateSourceInfo()
+
+	if reflect.TypeOf(output).Kind() != reflect.Ptr {
+		return nil, errors.New("output must be a pointer to a slice.")
+	}
+
+	reflectOutPtr := reflect.ValueOf(output)
+	reflectOutSlice := reflectOutPtr.Elem()
+
+	reflectBaseType := reflect.TypeOf(output).Elem().Elem()
+
+	separator := "?"
+	if strings.Contains(baseEndpoint, "?") {
+		separator = "&"
+	}
+
+	cursor := ""
+	for {
+		endpoint := fmt.Sprintf("%s%slimit=%d", baseEndpoint, separator, slackPageSize)
+		if cursor != "" {
+			endpoint = fmt.Sprintf("%s&cursor=%s", endpoint, url.QueryEscape(cursor))
+		}
+
+		responseBodyValue := reflect.New(reflectBaseType)
+		cmdSrc, err := slackGet(client, endpoint, responseBodyValue.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		reflectOutSlice = reflect.Append(reflectOutSlice, responseBodyValue.Elem())
+		source.MergeWith(cmdSrc)
+
+		result := responseBodyValue.Elem()
+		metadata := result.FieldByName("ResponseMetadata").Interface().(slackResponseMetadata)
+
+		if metadata.NextCursor == "" {
+			break
+		}
+
+		cursor = metadata.NextCursor
+	}
+
+	reflectOutPtr.Elem().Set(reflectOutSlice)
+	return source, nil
+}
@@ -0,0 +1,81 @@
+package spreadsheet
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+)
+
+const (
+	FormatCsv  = "csv"
+	FormatXlsx = "xlsx"
+)
+
+// EtlSpreadsheetMapping says which columns (by their header) hold what. Only the username is required.
+type EtlSpreadsheetMapping struct {
+	Username       string
+	FullName       string
+	Email          string
+	CreatedTime    string
+	LastChangeTime string
+	// The Go layout of the times. Defaults to RFC 3339. Dates formatted as dates in XLSX files are read
+	// regardless of the layout.
+	TimeFormat string
+
+	// The roles of the user. Users can be listed on multiple rows (e.g. one per role) in which case the
+	// rows are merged.
+	Roles string
+	// The object (e.g. a resource) and the permissions on it. The permissions are granted through the
+	// roles on the same row or directly (the Self role) if there are none.
+	PermissionObject string
+	Permissions      string
+	// Separates multiple values in the roles and permissions columns. Defaults to ;.
+	Separator string
+
+	// Attribute names keyed by the column.
+	Attributes map[string]string
+}
+
+func (m EtlSpreadsheetMapping) separator() string {
+	if m.Separator == "" {
+		return ";"
+	}
+	return m.Separator
+}
+
+type EtlSpreadsheetOptions struct {
+	// The file to read. The format is taken from the extension unless it's set.
+	Filename string
+	// The contents of the file if it isn't on disk (e.g. an upload). The filename is only used for the format
+	// and recorded as is.
+	Data   []byte
+	Format string
+	// The sheet of an XLSX file. Defaults to the first one.
+	Sheet string
+	// The delimiter of a CSV file. Defaults to ,.
+	Delimiter rune
+	Mapping   EtlSpreadsheetMapping
+	// Invalid rows fail the import unless they're skipped in which case they're reported in the source info.
+	SkipInvalidRows bool
+}
+
+type EtlSpreadsheetConnector struct {
+	opts  *EtlSpreadsheetOptions
+	users *EtlSpreadsheetConnectorUser
+}
+
+func (c *EtlSpreadsheetConnector) GetUserInterface() (connectors.EtlConnectorUserInterface, error) {
+	return c.users, nil
+}
+
+func CreateSpreadsheetConnector(opts *EtlSpreadsheetOptions) (*EtlSpreadsheetConnector, error) {
+	var err error
+	ret := EtlSpreadsheetConnector{
+		opts: opts,
+	}
+	ret.users, err = createSpreadsheetConnectorUser(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
@@ -0,0 +1,186 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// spreadsheetRow is a row of the file along with its line number. That's the line the row starts on for CSV
+// files (a quoted cell can span several lines) and the row number for XLSX files.
+type spreadsheetRow struct {
+	Line  int
+	Cells []string
+}
+
+func (o EtlSpreadsheetOptions) format() (string, error) {
+	format := strings.ToLower(o.Format)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(o.Filename)), ".")
+	}
+
+	switch format {
+	case FormatCsv, FormatXlsx:
+		return format, nil
+	}
+	return "", errors.New("Unsupported spreadsheet format: " + format)
+}
+
+// csvLineReader hands the CSV reader one line of the data per Read so that the line each record starts on is
+// known (csv.Reader.FieldPos needs Go 1.17). The CSV reader buffers its input with bufio, which only reads more
+// once it runs out of complete lines.
+type csvLineReader struct {
+	data []byte
+	// The number of lines handed out so far.
+	line    int
+	midLine bool
+	// The first line handed out since the last reset that isn't empty. The CSV reader skips empty lines.
+	start int
+}
+
+func (r *csvLineReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+
+	n := bytes.IndexByte(r.data, '\n') + 1
+	if n == 0 || n > len(p) {
+		n = len(p)
+		if n > len(r.data) {
+			n = len(r.data)
+		}
+	}
+
+	chunk := r.data[:n]
+	r.data = r.data[n:]
+
+	if !r.midLine {
+		r.line++
+		if r.start == 0 && string(chunk) != "\n" && string(chunk) != "\r\n" {
+			r.start = r.line
+		}
+	}
+	r.midLine = chunk[n-1] != '\n'
+
+	return copy(p, chunk), nil
+}
+
+// readCsvRows reads the rows of the file. Rows that can't be parsed are reported along with their line
+// instead of failing the whole file.
+func readCsvRows(data []byte, delimiter rune) ([]spreadsheetRow, []EtlSpreadsheetRowError, error) {
+	lines := &csvLineReader{data: data}
+	reader := csv.NewReader(lines)
+	if delimiter != 0 {
+		reader.Comma = delimiter
+	}
+
+	// Rows with the wrong number of columns are reported along with the other problems.
+	reader.FieldsPerRecord = -1
+
+	rows := []spreadsheetRow{}
+	errs := []EtlSpreadsheetRowError{}
+	for {
+		lines.start = 0
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			errs = append(errs, EtlSpreadsheetRowError{
+				Line:    parseErr.Line,
+				Message: fmt.Sprintf("The row isn't valid CSV: %s.", parseErr.Err),
+			})
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		rows = append(rows, spreadsheetRow{
+			Line:  lines.start,
+			Cells: record,
+		})
+	}
+
+	return rows, errs, nil
+}
+
+// readRows reads the rows of the file and records the file's hash and metadata. Rows that can't be parsed are
+// returned as row errors.
+func (o EtlSpreadsheetOptions) readRows() ([]spreadsheetRow, []EtlSpreadsheetRowError, *connectors.EtlCommandInfo, error) {
+	format, err := o.format()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	params := map[string]interface{}{
+		"filename": o.Filename,
+		"format":   format,
+	}
+
+	data := o.Data
+	if data == nil {
+		info, err := os.Stat(o.Filename)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		params["modified_time"] = info.ModTime().UTC().Format(time.RFC3339)
+
+		data, err = ioutil.ReadFile(o.Filename)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	hash := sha256.Sum256(data)
+	params["sha256"] = hex.EncodeToString(hash[:])
+	params["size"] = len(data)
+
+	cmd := &connectors.EtlCommandInfo{
+		Command:    "READ " + o.Filename,
+		Parameters: params,
+	}
+
+	var rows []spreadsheetRow
+	var rowErrs []EtlSpreadsheetRowError
+	if format == FormatCsv {
+		rows, rowErrs, err = readCsvRows(data, o.Delimiter)
+		cmd.RawData = string(data)
+	} else {
+		params["sheet"] = o.Sheet
+		rows, err = readXlsxSheet(data, o.Sheet)
+		if err == nil {
+			// The raw data is the sheet as CSV since the file itself is binary. The hash is of the file.
+			cmd.RawData, err = rowsToCsv(rows)
+		}
+	}
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return rows, rowErrs, cmd, nil
+}
+
+func rowsToCsv(rows []spreadsheetRow) (string, error) {
+	buffer := bytes.Buffer{}
+	writer := csv.NewWriter(&buffer)
+	for _, r := range rows {
+		err := writer.Write(r.Cells)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	return buffer.String(), writer.Error()
+}
@@ -0,0 +1,401 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EtlSpreadsheetRowError is a problem with a row of the file.
+type EtlSpreadsheetRowError struct {
+	Line int
+	// The header of the column with the problem if there's one.
+	Column  string
+	Message string
+}
+
+func (e EtlSpreadsheetRowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("Line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("Line %d (%s): %s", e.Line, e.Column, e.Message)
+}
+
+// EtlSpreadsheetValidationError lists every problem with the file so that they can all be fixed at once.
+type EtlSpreadsheetValidationError struct {
+	Errors []EtlSpreadsheetRowError
+}
+
+func (e *EtlSpreadsheetValidationError) Error() string {
+	lines := []string{fmt.Sprintf("%d problem(s) with the spreadsheet:", len(e.Errors))}
+	for _, err := range e.Errors {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+type EtlSpreadsheetConnectorUser struct {
+	opts *EtlSpreadsheetOptions
+}
+
+func createSpreadsheetConnectorUser(opts *EtlSpreadsheetOptions) (*EtlSpreadsheetConnectorUser, error) {
+	return &EtlSpreadsheetConnectorUser{
+		opts: opts,
+	}, nil
+}
+
+// excelEpoch is day 0 of the serial dates that XLSX files store dates as (accounting for Excel treating
+// 1900 as a leap year).
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func excelSerialTime(value string) (time.Time, bool) {
+	days, err := strconv.ParseFloat(value, 64)
+	if err != nil || days < 0 {
+		return time.Time{}, false
+	}
+
+	// Rounded to the second since the fraction of the day is stored as a float.
+	return excelEpoch.Add(time.Duration(days * float64(24*time.Hour))).Round(time.Second), true
+}
+
+// spreadsheetColumns maps the mapped fields to the index of their column.
+type spreadsheetColumns struct {
+	header  []string
+	indices map[string]int
+}
+
+func (c spreadsheetColumns) get(row spreadsheetRow, column string) string {
+	idx, ok := c.indices[column]
+	if !ok || idx >= len(row.Cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.Cells[idx])
+}
+
+func (c *EtlSpreadsheetConnectorUser) mappedColumns() []string {
+	m := c.opts.Mapping
+	columns := []string{}
+	for _, col := range []string{m.Username, m.FullName, m.Email, m.CreatedTime, m.LastChangeTime, m.Roles, m.PermissionObject, m.Permissions} {
+		if col != "" {
+			columns = append(columns, col)
+		}
+	}
+
+	for col := range m.Attributes {
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// readHeader finds the mapped columns in the header (ignoring case and surrounding spaces).
+func (c *EtlSpreadsheetConnectorUser) readHeader(header spreadsheetRow) (*spreadsheetColumns, []EtlSpreadsheetRowError) {
+	errs := []EtlSpreadsheetRowError{}
+	columns := &spreadsheetColumns{
+		header:  header.Cells,
+		indices: map[string]int{},
+	}
+
+	if c.opts.Mapping.Username == "" {
+		errs = append(errs, EtlSpreadsheetRowError{Line: header.Line, Message: "No column is mapped to the username."})
+	}
+
+	found := map[string]int{}
+	for idx, cell := range header.Cells {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		if name == "" {
+			continue
+		}
+
+		if _, ok := found[name]; ok {
+			errs = append(errs, EtlSpreadsheetRowError{Line: header.Line, Column: cell, Message: "The column is in the header more than once."})
+			continue
+		}
+		found[name] = idx
+	}
+
+	for _, col := range c.mappedColumns() {
+		idx, ok := found[strings.ToLower(strings.TrimSpace(col))]
+		if !ok {
+			errs = append(errs, EtlSpreadsheetRowError{Line: header.Line, Column: col, Message: "The column is missing from the header."})
+			continue
+		}
+		columns.indices[col] = idx
+	}
+
+	return columns, errs
+}
+
+func (c *EtlSpreadsheetConnectorUser) parseTime(format string, column string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	layout := c.opts.Mapping.TimeFormat
+	if layout == "" {
+		layout = time.RFC3339
+	}
+
+	tm, err := time.Parse(layout, value)
+	if err == nil {
+		return &tm, nil
+	}
+
+	if format == FormatXlsx {
+		if tm, ok := excelSerialTime(value); ok {
+			return &tm, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s isn't a time in the %s format.", value, layout)
+}
+
+func splitValues(value string, separator string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, separator) {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func addPermissions(role *types.EtlRole, object string, permissions []string) {
+	existing := role.Permissions[object]
+
+PERM:
+	for _, p := range permissions {
+		for _, e := range existing {
+			if e == p {
+				continue PERM
+			}
+		}
+		existing = append(existing, p)
+	}
+	role.Permissions[object] = existing
+}
+
+// spreadsheetUser is a user along with the line it was first seen on to report conflicts with.
+type spreadsheetUser struct {
+	user *types.EtlUser
+	line int
+}
+
+// applyRow validates the row and adds it to the users. Nothing is added if the row is invalid.
+func (c *EtlSpreadsheetConnectorUser) applyRow(format string, columns *spreadsheetColumns, row spreadsheetRow, users map[string]*spreadsheetUser, order *[]string) []EtlSpreadsheetRowError {
+	m := c.opts.Mapping
+	errs := []EtlSpreadsheetRowError{}
+	rowError := func(column string, message string) {
+		errs = append(errs, EtlSpreadsheetRowError{Line: row.Line, Column: column, Message: message})
+	}
+
+	for idx := len(columns.header); idx < len(row.Cells); idx++ {
+		if strings.TrimSpace(row.Cells[idx]) != "" {
+			rowError("", fmt.Sprintf("The row has %d columns but the header only has %d.", len(row.Cells), len(columns.header)))
+			break
+		}
+	}
+
+	if format == FormatCsv && len(row.Cells) < len(columns.header) {
+		rowError("", fmt.Sprintf("The row has %d columns but the header has %d.", len(row.Cells), len(columns.header)))
+	}
+
+	username := columns.get(row, m.Username)
+	if username == "" {
+		rowError(m.Username, "The username is empty.")
+	}
+
+	email := columns.get(row, m.Email)
+	if email != "" {
+		addr, err := mail.ParseAddress(email)
+		if err != nil {
+			rowError(m.Email, email+" isn't a valid email.")
+		} else {
+			email = addr.Address
+		}
+	}
+
+	createdTime, err := c.parseTime(format, m.CreatedTime, columns.get(row, m.CreatedTime))
+	if err != nil {
+		rowError(m.CreatedTime, err.Error())
+	}
+
+	lastChangeTime, err := c.parseTime(format, m.LastChangeTime, columns.get(row, m.LastChangeTime))
+	if err != nil {
+		rowError(m.LastChangeTime, err.Error())
+	}
+
+	roles := splitValues(columns.get(row, m.Roles), m.separator())
+	object := columns.get(row, m.PermissionObject)
+	permissions := splitValues(columns.get(row, m.Permissions), m.separator())
+
+	if object != "" && len(permissions) == 0 {
+		rowError(m.Permissions, "There are no permissions for "+object+".")
+	} else if object == "" && len(permissions) > 0 {
+		rowError(m.PermissionObject, "There's no object for the permissions.")
+	}
+
+	fullName := columns.get(row, m.FullName)
+	existing, ok := users[username]
+	if ok {
+		// The same user on another row can't say something else about the user.
+		for _, f := range []struct {
+			column   string
+			value    string
+			existing string
+		}{
+			{m.FullName, fullName, existing.user.FullName},
+			{m.Email, email, existing.user.Email},
+		} {
+			if f.value != "" && f.existing != "" && f.value != f.existing {
+				rowError(f.column, fmt.Sprintf("%s conflicts with %s on line %d.", f.value, f.existing, existing.line))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if !ok {
+		existing = &spreadsheetUser{
+			user: &types.EtlUser{
+				Username:   username,
+				Roles:      map[string]*types.EtlRole{},
+				Attributes: map[string]string{},
+			},
+			line: row.Line,
+		}
+		users[username] = existing
+		*order = append(*order, username)
+	}
+
+	user := existing.user
+	if user.FullName == "" {
+		user.FullName = fullName
+	}
+
+	if user.Email == "" {
+		user.Email = email
+	}
+
+	if user.CreatedTime == nil {
+		user.CreatedTime = createdTime
+	}
+
+	if user.LastChangeTime == nil {
+		user.LastChangeTime = lastChangeTime
+	}
+
+	for column, name := range m.Attributes {
+		value := columns.get(row, column)
+		if _, ok := user.Attributes[name]; !ok && value != "" {
+			user.Attributes[name] = value
+		}
+	}
+
+	if len(roles) == 0 && object != "" {
+		roles = []string{"Self"}
+	}
+
+	for _, r := range roles {
+		role, ok := user.Roles[r]
+		if !ok {
+			role = &types.EtlRole{
+				Name:        r,
+				Permissions: map[string][]string{},
+			}
+			user.Roles[r] = role
+		}
+
+		if object != "" {
+			addPermissions(role, object, permissions)
+		}
+	}
+
+	return nil
+}
+
+// Obtain users and their roles from the file.
+// The first row is the header. Every other row is validated and every problem is reported with its line
+// (row) number. Rows for the same user are merged. The file's hash and metadata are recorded in the source.
+func (c *EtlSpreadsheetConnectorUser) GetUserListing() ([]*types.EtlUser, *connectors.EtlSourceInfo, error) {
+	finalSource := connectors.CreateSourceInfo()
+
+	format, err := c.opts.format()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, parseErrs, cmd, err := c.opts.readRows()
+	if err != nil {
+		return nil, nil, err
+	}
+	finalSource.AddCommand(cmd)
+
+	// The other rows can't be validated without the header.
+	if len(parseErrs) > 0 && (len(rows) == 0 || parseErrs[0].Line < rows[0].Line) {
+		return nil, nil, &EtlSpreadsheetValidationError{Errors: parseErrs}
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, &EtlSpreadsheetValidationError{
+			Errors: []EtlSpreadsheetRowError{
+				EtlSpreadsheetRowError{Line: 1, Message: "The spreadsheet is empty."},
+			},
+		}
+	}
+
+	columns, headerErrs := c.readHeader(rows[0])
+	if len(headerErrs) > 0 {
+		return nil, nil, &EtlSpreadsheetValidationError{Errors: headerErrs}
+	}
+
+	users := map[string]*spreadsheetUser{}
+	order := []string{}
+	invalid := parseErrs
+
+	for _, row := range rows[1:] {
+		empty := true
+		for _, cell := range row.Cells {
+			if strings.TrimSpace(cell) != "" {
+				empty = false
+				break
+			}
+		}
+
+		if empty {
+			continue
+		}
+
+		invalid = append(invalid, c.applyRow(format, columns, row, users, &order)...)
+	}
+
+	if len(invalid) > 0 {
+		sort.SliceStable(invalid, func(i, j int) bool {
+			return invalid[i].Line < invalid[j].Line
+		})
+
+		if !c.opts.SkipInvalidRows {
+			return nil, nil, &EtlSpreadsheetValidationError{Errors: invalid}
+		}
+
+		skipped := []string{}
+		for _, e := range invalid {
+			skipped = append(skipped, e.Error())
+		}
+		cmd.Parameters.(map[string]interface{})["invalid_rows"] = skipped
+	}
+
+	retUsers := []*types.EtlUser{}
+	for _, username := range order {
+		retUsers = append(retUsers, users[username].user)
+	}
+
+	return retUsers, finalSource, nil
+}
@@ -0,0 +1,261 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// A minimal reader for the cell values of XLSX (Office Open XML) workbooks. Formatting is ignored so dates
+// are read as serial numbers (see excelSerialTime).
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		Id   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		Id     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is either plain text or rich text made of runs.
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+
+	text := strings.Builder{}
+	for _, r := range t.Runs {
+		text.WriteString(r.Text)
+	}
+	return text.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxCell struct {
+	Ref    string    `xml:"r,attr"`
+	Type   string    `xml:"t,attr"`
+	Value  string    `xml:"v"`
+	Inline *xlsxText `xml:"is"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int        `xml:"r,attr"`
+		Cells  []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func hasZipFile(archive *zip.Reader, name string) bool {
+	for _, f := range archive.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func readZipFile(archive *zip.Reader, name string) ([]byte, error) {
+	for _, f := range archive.File {
+		if f.Name != name {
+			continue
+		}
+
+		reader, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
+	}
+
+	return nil, errors.New("Missing from XLSX: " + name)
+}
+
+func readZipXml(archive *zip.Reader, name string, output interface{}) error {
+	data, err := readZipFile(archive, name)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, output)
+}
+
+// xlsxMaxColumns is the number of columns in a worksheet (A to XFD).
+const xlsxMaxColumns = 16384
+
+// columnIndex returns the 0-based column of a cell reference (e.g. 2 for C7).
+func columnIndex(ref string) (int, error) {
+	col := 0
+	letters := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		letters++
+
+		if letters > 3 || col > xlsxMaxColumns {
+			return 0, errors.New("Invalid cell reference: " + ref)
+		}
+	}
+
+	if letters == 0 {
+		return 0, errors.New("Invalid cell reference: " + ref)
+	}
+	return col - 1, nil
+}
+
+func (c xlsxCell) text(sharedStrings []xlsxText) (string, error) {
+	switch c.Type {
+	case "s":
+		idx, err := strconv.Atoi(c.Value)
+		if err != nil || idx < 0 || idx >= len(sharedStrings) {
+			return "", errors.New("Invalid shared string in cell " + c.Ref)
+		}
+		return sharedStrings[idx].String(), nil
+	case "inlineStr":
+		if c.Inline == nil {
+			return "", nil
+		}
+		return c.Inline.String(), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+
+	// Numbers, formula results (str) and errors (e.g. #N/A) are stored as is.
+	return c.Value, nil
+}
+
+// readXlsxSheet returns the rows of the sheet (the first one if the name is empty).
+func readXlsxSheet(data []byte, sheet string) ([]spreadsheetRow, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	workbook := xlsxWorkbook{}
+	err = readZipXml(archive, "xl/workbook.xml", &workbook)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workbook.Sheets) == 0 {
+		return nil, errors.New("The workbook has no sheets.")
+	}
+
+	sheetId := ""
+	for _, s := range workbook.Sheets {
+		if sheet == "" || s.Name == sheet {
+			sheetId = s.Id
+			break
+		}
+	}
+
+	if sheetId == "" {
+		return nil, errors.New("The workbook has no sheet named " + sheet)
+	}
+
+	rels := xlsxRelationships{}
+	err = readZipXml(archive, "xl/_rels/workbook.xml.rels", &rels)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetPath := ""
+	for _, r := range rels.Relationships {
+		if r.Id != sheetId {
+			continue
+		}
+
+		// Targets are relative to xl/ unless they're absolute.
+		if strings.HasPrefix(r.Target, "/") {
+			sheetPath = strings.TrimPrefix(r.Target, "/")
+		} else {
+			sheetPath = path.Join("xl", r.Target)
+		}
+	}
+
+	if sheetPath == "" {
+		return nil, errors.New("Failed to find the worksheet of " + sheet)
+	}
+
+	// Workbooks without any text don't have shared strings.
+	sharedStrings := xlsxSharedStrings{}
+	if hasZipFile(archive, "xl/sharedStrings.xml") {
+		err = readZipXml(archive, "xl/sharedStrings.xml", &sharedStrings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	worksheet := xlsxWorksheet{}
+	err = readZipXml(archive, sheetPath, &worksheet)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []spreadsheetRow{}
+	for _, r := range worksheet.Rows {
+		row := spreadsheetRow{
+			Line:  r.Number,
+			Cells: []string{},
+		}
+
+		// The row number is optional in which case rows follow each other.
+		if row.Line == 0 {
+			row.Line = 1
+			if len(rows) > 0 {
+				row.Line = rows[len(rows)-1].Line + 1
+			}
+		}
+
+		col := -1
+		for _, c := range r.Cells {
+			// Empty cells are left out so the reference says where the cell goes. Cells without a reference
+			// follow the previous cell.
+			col++
+			if c.Ref != "" {
+				col, err = columnIndex(c.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			for len(row.Cells) <= col {
+				row.Cells = append(row.Cells, "")
+			}
+
+			row.Cells[col], err = c.text(sharedStrings.Items)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -12,6 +12,13 @@ type EtlRole struct {
 	Denied      PermissionMap
 }
 
+// EtlFinding is a potentially risky configuration that a connector noticed about a user (e.g. an account
+// that can log in without a password).
+type EtlFinding struct {
+	Code        string
+	Description string
+}
+
 type EtlUser struct {
 	Username       string
 	FullName       string
@@ -20,4 +27,8 @@ type EtlUser struct {
 	LastChangeTime *time.Time
 	Roles          map[string]*EtlRole
 	NestedUsers    map[string]*EtlUser
+
+	// Source specific attributes of the account (e.g. whether it's locked or how it authenticates).
+	Attributes map[string]string
+	Findings   []*EtlFinding
 }
@@ -44,6 +44,25 @@ USER:
 			g.Expect(*u.LastChangeTime).To(gomega.BeTemporally("~", *refU.LastChangeTime, time.Second))
 		}
 
+		// Attributes and findings are only checked when the reference user specifies them.
+		for key, value := range refU.Attributes {
+			g.Expect(u.Attributes).To(gomega.HaveKeyWithValue(key, value), "Finding attribute: "+key)
+		}
+
+		if refU.Findings != nil {
+			codes := []string{}
+			for _, f := range u.Findings {
+				codes = append(codes, f.Code)
+			}
+
+			refCodes := []string{}
+			for _, f := range refU.Findings {
+				refCodes = append(refCodes, f.Code)
+			}
+
+			g.Expect(codes).To(gomega.ConsistOf(refCodes), "Findings for: "+u.Username)
+		}
+
 		g.Expect(len(u.Roles)).To(gomega.Equal(len(refU.Roles)))
 
 		// Need to do this instead of just doing g.Expect.To(Equal) because we don't want the time
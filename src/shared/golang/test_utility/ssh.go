@@ -0,0 +1,224 @@
+package test_utility
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io"
+	"net"
+	"strconv"
+)
+
+// FakeSshCommandHandler returns the output and exit status of a command run on a FakeSshServer.
+type FakeSshCommandHandler func(command string) (stdout string, stderr string, status int)
+
+// FakeSshServer is an in-process SSH server that supports opening TCP connections (direct-tcpip
+// channels) to other hosts and, if Exec is set, running commands.
+type FakeSshServer struct {
+	HostKey  ssh.Signer
+	Exec     FakeSshCommandHandler
+	listener net.Listener
+}
+
+// NewFakeSshServer starts a server that accepts the username with the password or authorized key.
+// Either may be empty/nil to disable that method.
+func NewFakeSshServer(username string, password string, authorizedKey ssh.PublicKey) (*FakeSshServer, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if password != "" && conn.User() == username && string(pass) == password {
+				return nil, nil
+			}
+			return nil, errors.New("Invalid password.")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if authorizedKey != nil && conn.User() == username && bytes.Equal(key.Marshal(), authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("Invalid key.")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &FakeSshServer{
+		HostKey:  hostKey,
+		listener: listener,
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(conn, config)
+		}
+	}()
+
+	return server, nil
+}
+
+type directTcpipPayload struct {
+	Host       string
+	Port       uint32
+	OriginHost string
+	OriginPort uint32
+}
+
+func (s *FakeSshServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() == "session" && s.Exec != nil {
+			go s.handleSession(newChannel)
+			continue
+		}
+
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "Only direct-tcpip is supported.")
+			continue
+		}
+
+		payload := directTcpipPayload{}
+		err = ssh.Unmarshal(newChannel.ExtraData(), &payload)
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, channelReqs, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+
+		go ssh.DiscardRequests(channelReqs)
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(target, channel)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(channel, target)
+				done <- struct{}{}
+			}()
+			<-done
+		}()
+	}
+}
+
+type execPayload struct {
+	Command string
+}
+
+type exitStatusPayload struct {
+	Status uint32
+}
+
+func (s *FakeSshServer) handleSession(newChannel ssh.NewChannel) {
+	channel, reqs, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	for req := range reqs {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+
+		payload := execPayload{}
+		err = ssh.Unmarshal(req.Payload, &payload)
+		if err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+
+		stdout, stderr, status := s.Exec(payload.Command)
+		io.WriteString(channel, stdout)
+		io.WriteString(channel.Stderr(), stderr)
+		channel.SendRequest("exit-status", false, ssh.Marshal(exitStatusPayload{Status: uint32(status)}))
+		return
+	}
+}
+
+func (s *FakeSshServer) Host() string {
+	host, _, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host
+}
+
+func (s *FakeSshServer) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *FakeSshServer) Address() string {
+	return s.listener.Addr().String()
+}
+
+// KnownHostsLine returns the known_hosts entry for the server.
+func (s *FakeSshServer) KnownHostsLine() string {
+	return knownhosts.Line([]string{knownhosts.Normalize(s.Address())}, s.HostKey.PublicKey())
+}
+
+func (s *FakeSshServer) Close() error {
+	return s.listener.Close()
+}
+
+// NewEchoServer starts a TCP server that writes back everything it reads.
+func NewEchoServer() (net.Listener, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener, nil
+}
@@ -0,0 +1,10 @@
+package auth_utility
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+// CreateAtlassianHttpClient authenticates as an Atlassian account using one of its API tokens.
+func CreateAtlassianHttpClient(email string, apiToken string) http_utility.HttpClient {
+	return CreateBasicAuthHttpClient(email, apiToken)
+}
@@ -11,6 +11,7 @@ import (
 
 const AzureGraphResource = "graph.microsoft.com"
 const AzureManagementResource = "management.core.windows.net"
+const AzureExchangeResource = "outlook.office365.com"
 
 type AzureOAuthSetup struct {
 	Tenant       string
@@ -0,0 +1,10 @@
+package auth_utility
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+// CreateDigitalOceanHttpClient uses a personal access token. A read only token is enough to list the team.
+func CreateDigitalOceanHttpClient(token string) http_utility.HttpClient {
+	return CreateBearerTokenHttpClient(token)
+}
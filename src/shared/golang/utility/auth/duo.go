@@ -0,0 +1,87 @@
+package auth_utility
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/utility/crypto"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"gitlab.com/grchive/grchive-v3/shared/utility/time"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Duo's Admin API signs every request with the integration's secret key.
+// See https://duo.com/docs/adminapi#authentication.
+
+const duoDateFormat = "Mon, 02 Jan 2006 15:04:05 -0700"
+
+type duoRoundTripper struct {
+	clock          time_utility.Clock
+	integrationKey string
+	secretKey      string
+}
+
+// duoEscape URL encodes the string the way Duo expects: spaces are %20 and ~ is left alone.
+func duoEscape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(url.QueryEscape(s), "+", "%20"), "%7E", "~")
+}
+
+func canonicalDuoParams(query url.Values) string {
+	keys := []string{}
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := []string{}
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			params = append(params, duoEscape(k)+"="+duoEscape(v))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func createDuoCanonicalRequest(date string, req *http.Request) string {
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	return strings.Join([]string{
+		date,
+		strings.ToUpper(method),
+		strings.ToLower(req.URL.Host),
+		req.URL.Path,
+		canonicalDuoParams(req.URL.Query()),
+	}, "\n")
+}
+
+func (t *duoRoundTripper) createDuoAuthorization(date string, req *http.Request) string {
+	sig := hex.EncodeToString(crypto_utility.Sha1HMAC([]byte(t.secretKey), []byte(createDuoCanonicalRequest(date, req))))
+	return fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(t.integrationKey+":"+sig)))
+}
+
+func (t *duoRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	date := t.clock.Now().Format(duoDateFormat)
+	req.Header.Set("Date", date)
+	req.Header.Set("Authorization", t.createDuoAuthorization(date, req))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// CreateDuoHttpClient uses the integration and secret key of an Admin API application. Only GET requests
+// (where the parameters are in the query string) are signed correctly.
+func CreateDuoHttpClient(clock time_utility.Clock, integrationKey string, secretKey string) http_utility.HttpClient {
+	return &http.Client{
+		Transport: &duoRoundTripper{
+			clock:          clock,
+			integrationKey: integrationKey,
+			secretKey:      secretKey,
+		},
+	}
+}
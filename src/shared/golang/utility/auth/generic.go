@@ -0,0 +1,43 @@
+package auth_utility
+
+import (
+	"encoding/base64"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Clients for APIs that don't need anything specific to authenticate.
+
+func CreateBearerTokenHttpClient(token string) http_utility.HttpClient {
+	return http_utility.CreateHeaderInjectionClient(map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", token),
+	}, nil)
+}
+
+// CreateApiKeyHttpClient sends the key in a header (e.g. X-API-Key) with an optional prefix (e.g. "Token ").
+func CreateApiKeyHttpClient(header string, prefix string, key string) http_utility.HttpClient {
+	return http_utility.CreateHeaderInjectionClient(map[string]string{
+		header: prefix + key,
+	}, nil)
+}
+
+func CreateBasicAuthHttpClient(username string, password string) http_utility.HttpClient {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return http_utility.CreateHeaderInjectionClient(map[string]string{
+		"Authorization": fmt.Sprintf("Basic %s", credentials),
+	}, nil)
+}
+
+// CreateClientCredentialsTokenSource uses the OAuth2 client credentials grant.
+func CreateClientCredentialsTokenSource(tokenUrl string, clientId string, clientSecret string, scopes ...string) oauth2.TokenSource {
+	config := clientcredentials.Config{
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenUrl,
+		Scopes:       scopes,
+	}
+	return config.TokenSource(context.Background())
+}
@@ -0,0 +1,18 @@
+package auth_utility
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+// CreateJumpCloudHttpClient uses an administrator's API key. The organization ID is only needed for
+// multi-tenant (MSP) administrators and can be left empty otherwise.
+func CreateJumpCloudHttpClient(apiKey string, orgId string) http_utility.HttpClient {
+	headers := map[string]string{
+		"x-api-key": apiKey,
+	}
+
+	if orgId != "" {
+		headers["x-org-id"] = orgId
+	}
+	return http_utility.CreateHeaderInjectionClient(headers, nil)
+}
@@ -0,0 +1,31 @@
+package auth_utility
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"net/http"
+)
+
+// CreateKubernetesHttpClient creates a client that authenticates with a bearer token (e.g. of a service
+// account). API servers usually have a certificate signed by the cluster's own CA which needs to be passed
+// in (PEM encoded); the system's CAs are used if it's empty.
+func CreateKubernetesHttpClient(token string, caCertPem []byte) (http_utility.HttpClient, error) {
+	tlsConfig := &tls.Config{}
+	if len(caCertPem) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCertPem) {
+			return nil, errors.New("Failed to parse the Kubernetes CA certificate.")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return http_utility.CreateHeaderInjectionClient(map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", token),
+	}, &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}), nil
+}
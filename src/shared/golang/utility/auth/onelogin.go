@@ -0,0 +1,26 @@
+package auth_utility
+
+import (
+	"fmt"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/oauth2"
+	"strings"
+)
+
+// OneLoginApiBaseUrl returns the API URL for the subdomain (e.g. "grchive" for grchive.onelogin.com).
+func OneLoginApiBaseUrl(subdomain string) string {
+	if strings.Contains(subdomain, ".") {
+		return fmt.Sprintf("https://%s", subdomain)
+	}
+	return fmt.Sprintf("https://%s.onelogin.com", subdomain)
+}
+
+// CreateOneLoginOAuthTokenSource uses the client credentials of an API credential pair. The connector
+// reads roles, apps and MFA devices as well as users so the credentials need the "Read All" scope.
+func CreateOneLoginOAuthTokenSource(subdomain string, clientId string, clientSecret string) oauth2.TokenSource {
+	return CreateClientCredentialsTokenSource(OneLoginApiBaseUrl(subdomain)+"/auth/oauth2/v2/token", clientId, clientSecret)
+}
+
+func CreateOneLoginHttpClient(ts oauth2.TokenSource) http_utility.HttpClient {
+	return http_utility.CreateOAuth2AuthorizedClient(ts)
+}
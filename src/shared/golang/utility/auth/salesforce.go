@@ -0,0 +1,60 @@
+package auth_utility
+
+import (
+	"errors"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+const (
+	SalesforceProductionLoginUrl = "https://login.salesforce.com"
+	SalesforceSandboxLoginUrl    = "https://test.salesforce.com"
+)
+
+// CreateSalesforceJWTConfig sets up the OAuth 2.0 JWT bearer flow for a connected app. The consumer key
+// is the connected app's client ID and the key file is the private key for the certificate uploaded to
+// the connected app. Salesforce only accepts assertions that expire within 3 minutes.
+func CreateSalesforceJWTConfig(loginUrl string, consumerKey string, username string, keyFname string) (*jwt.Config, error) {
+	key, err := ioutil.ReadFile(keyFname)
+	if err != nil {
+		return nil, err
+	}
+
+	loginUrl = strings.TrimSuffix(loginUrl, "/")
+	return &jwt.Config{
+		Email:      consumerKey,
+		Subject:    username,
+		PrivateKey: key,
+		TokenURL:   loginUrl + "/services/oauth2/token",
+		Audience:   loginUrl,
+		Expires:    3 * time.Minute,
+	}, nil
+}
+
+func CreateSalesforceOAuthTokenSource(config *jwt.Config) oauth2.TokenSource {
+	return config.TokenSource(context.Background())
+}
+
+// GetSalesforceInstanceUrl returns the URL of the org the token is for (e.g. https://grchive.my.salesforce.com).
+// Every API call needs to go there instead of the login URL.
+func GetSalesforceInstanceUrl(ts oauth2.TokenSource) (string, error) {
+	token, err := ts.Token()
+	if err != nil {
+		return "", err
+	}
+
+	instanceUrl, ok := token.Extra("instance_url").(string)
+	if !ok || instanceUrl == "" {
+		return "", errors.New("Salesforce token does not have an instance URL.")
+	}
+	return instanceUrl, nil
+}
+
+func CreateSalesforceHttpClient(ts oauth2.TokenSource) http_utility.HttpClient {
+	return http_utility.CreateOAuth2AuthorizedClient(ts)
+}
@@ -0,0 +1,10 @@
+package auth_utility
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+// CreateSlackHttpClient uses a bot or user token that has the users:read and users:read.email scopes.
+func CreateSlackHttpClient(token string) http_utility.HttpClient {
+	return CreateBearerTokenHttpClient(token)
+}
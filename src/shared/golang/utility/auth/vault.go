@@ -0,0 +1,18 @@
+package auth_utility
+
+import (
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+)
+
+// CreateVaultHttpClient authenticates with a Vault token. The namespace is only needed for Vault
+// Enterprise and is left out if empty.
+func CreateVaultHttpClient(token string, namespace string) http_utility.HttpClient {
+	headers := map[string]string{
+		"X-Vault-Token": token,
+	}
+
+	if namespace != "" {
+		headers["X-Vault-Namespace"] = namespace
+	}
+	return http_utility.CreateHeaderInjectionClient(headers, nil)
+}
@@ -2,6 +2,7 @@ package crypto_utility
 
 import (
 	"crypto/hmac"
+	"crypto/sha1"
 	"crypto/sha256"
 )
 
@@ -10,3 +11,9 @@ func Sha256HMAC(key []byte, data []byte) []byte {
 	h.Write(data)
 	return h.Sum(nil)
 }
+
+func Sha1HMAC(key []byte, data []byte) []byte {
+	h := hmac.New(sha1.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
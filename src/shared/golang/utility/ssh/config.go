@@ -0,0 +1,128 @@
+package ssh_utility
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+)
+
+const defaultSshPort = 22
+
+type SshHostConfig struct {
+	Host string `json:"host"`
+	// Uses port 22 if 0.
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+
+	// Password authentication is attempted if set (including keyboard-interactive for hosts that
+	// only prompt for the password).
+	Password string `json:"password"`
+	// Path to a PEM encoded private key (OpenSSH, PKCS#1, PKCS#8 or EC).
+	PrivateKeyFile string `json:"privateKeyFile"`
+	// Decrypts PrivateKeyFile if it's encrypted.
+	PrivateKeyPassphrase string `json:"privateKeyPassphrase"`
+
+	// Path to an OpenSSH known_hosts file used to verify the host's key.
+	KnownHostsFile string `json:"knownHostsFile"`
+	// Skips verifying the host's key. Only meant for testing since it allows the connection
+	// (and the credentials sent over it) to be intercepted.
+	InsecureIgnoreHostKey bool `json:"insecureIgnoreHostKey"`
+}
+
+func (c SshHostConfig) Address() string {
+	port := c.Port
+	if port == 0 {
+		port = defaultSshPort
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+func (c SshHostConfig) authMethods() ([]ssh.AuthMethod, error) {
+	methods := []ssh.AuthMethod{}
+
+	if c.PrivateKeyFile != "" {
+		raw, err := ioutil.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var signer ssh.Signer
+		if c.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(raw, []byte(c.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(raw)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if c.Password != "" {
+		password := c.Password
+		methods = append(methods, ssh.Password(password))
+		methods = append(methods, ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range answers {
+				answers[i] = password
+			}
+			return answers, nil
+		}))
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("No password or private key for SSH host %s.", c.Address())
+	}
+	return methods, nil
+}
+
+func (c SshHostConfig) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if c.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	if c.KnownHostsFile == "" {
+		return nil, fmt.Errorf("No known_hosts file for SSH host %s.", c.Address())
+	}
+
+	// The configured address is preferred over the remote address when looking the host up so this
+	// also works for hops that are reached through another hop.
+	return knownhosts.New(c.KnownHostsFile)
+}
+
+func (c SshHostConfig) clientConfig(timeout time.Duration) (*ssh.ClientConfig, error) {
+	if c.Host == "" {
+		return nil, errors.New("SSH host is empty.")
+	}
+
+	auth, err := c.authMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback, err := c.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}, nil
+}
+
+type SshTunnelConfig struct {
+	// The hosts to connect through in order. Every host after the first is reached through the
+	// one before it (i.e. ProxyJump) and the last one opens the connections to the target.
+	Hops []SshHostConfig `json:"hops"`
+	// Limits how long establishing the connection to each hop may take. No limit if 0.
+	ConnectTimeout time.Duration `json:"connectTimeout"`
+}
@@ -0,0 +1,46 @@
+package ssh_utility
+
+import (
+	"bytes"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"strings"
+)
+
+// SshCommandError is returned by RunCommand when the command exits with a non-zero status.
+type SshCommandError struct {
+	Command    string
+	ExitStatus int
+	Stderr     string
+}
+
+func (e *SshCommandError) Error() string {
+	return fmt.Sprintf("SSH command '%s' exited with status %d: %s", e.Command, e.ExitStatus, strings.TrimSpace(e.Stderr))
+}
+
+// RunCommand runs the command on the last hop and returns what it wrote to stdout.
+func (t *SshTunnel) RunCommand(command string) ([]byte, error) {
+	session, err := t.last().NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	err = session.Run(command)
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		return nil, &SshCommandError{
+			Command:    command,
+			ExitStatus: exitErr.ExitStatus(),
+			Stderr:     stderr.String(),
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
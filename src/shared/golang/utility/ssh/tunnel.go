@@ -0,0 +1,204 @@
+package ssh_utility
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// SshTunnel opens TCP connections from the last hop of an SSH jump chain.
+type SshTunnel struct {
+	clients []*ssh.Client
+
+	mutex     sync.Mutex
+	listeners []net.Listener
+	closed    bool
+}
+
+func dialHop(prev *ssh.Client, hop SshHostConfig, timeout time.Duration) (*ssh.Client, error) {
+	config, err := hop.clientConfig(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if prev == nil {
+		return ssh.Dial("tcp", hop.Address(), config)
+	}
+
+	conn, err := dialWithTimeout(prev, "tcp", hop.Address(), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, hop.Address(), config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// OpenSshTunnel connects to every hop in the config in order.
+func OpenSshTunnel(cfg SshTunnelConfig) (*SshTunnel, error) {
+	if len(cfg.Hops) == 0 {
+		return nil, errors.New("SSH tunnel has no hops.")
+	}
+
+	tunnel := &SshTunnel{
+		clients: []*ssh.Client{},
+	}
+
+	var prev *ssh.Client
+	for _, hop := range cfg.Hops {
+		client, err := dialHop(prev, hop, cfg.ConnectTimeout)
+		if err != nil {
+			tunnel.Close()
+			return nil, err
+		}
+
+		tunnel.clients = append(tunnel.clients, client)
+		prev = client
+	}
+
+	return tunnel, nil
+}
+
+func (t *SshTunnel) last() *ssh.Client {
+	return t.clients[len(t.clients)-1]
+}
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// dialWithContext works around ssh.Client.Dial not taking a context. A connection that's
+// established after the context is done is closed.
+func dialWithContext(ctx context.Context, client *ssh.Client, network string, address string) (net.Conn, error) {
+	results := make(chan dialResult, 1)
+	go func() {
+		conn, err := client.Dial(network, address)
+		results <- dialResult{conn, err}
+	}()
+
+	select {
+	case res := <-results:
+		return res.conn, res.err
+	case <-ctx.Done():
+		go func() {
+			res := <-results
+			if res.conn != nil {
+				res.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+func dialWithTimeout(client *ssh.Client, network string, address string, timeout time.Duration) (net.Conn, error) {
+	if timeout <= 0 {
+		return client.Dial(network, address)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return dialWithContext(ctx, client, network, address)
+}
+
+// Dial opens a connection to the address from the last hop.
+func (t *SshTunnel) Dial(network string, address string) (net.Conn, error) {
+	return t.last().Dial(network, address)
+}
+
+func (t *SshTunnel) DialTimeout(network string, address string, timeout time.Duration) (net.Conn, error) {
+	return dialWithTimeout(t.last(), network, address, timeout)
+}
+
+func (t *SshTunnel) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	return dialWithContext(ctx, t.last(), network, address)
+}
+
+// Forward listens on a random local port and forwards every connection to it to the address
+// through the tunnel. This is for clients that can't be given a dialer (e.g. ones that are
+// implemented in C). The listener is closed along with the tunnel.
+func (t *SshTunnel) Forward(address string) (net.Listener, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return nil, errors.New("SSH tunnel is closed.")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	t.listeners = append(t.listeners, listener)
+	go t.acceptForwards(listener, address)
+	return listener, nil
+}
+
+func (t *SshTunnel) acceptForwards(listener net.Listener, address string) {
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func(local net.Conn) {
+			defer local.Close()
+
+			remote, err := t.Dial("tcp", address)
+			if err != nil {
+				return
+			}
+			defer remote.Close()
+
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(remote, local)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(local, remote)
+				done <- struct{}{}
+			}()
+
+			// Once either side is done the other one can't make progress either.
+			<-done
+		}(local)
+	}
+}
+
+// Close closes all forwarded ports and the connections to the hops in reverse order.
+func (t *SshTunnel) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+
+	var firstErr error
+	for _, l := range t.listeners {
+		err := l.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for i := len(t.clients) - 1; i >= 0; i-- {
+		err := t.clients[i].Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,99 @@
+package connection
+
+import (
+	"github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestResolvePassword(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	password, err := Credentials{Username: "user", Password: "plain"}.resolvePassword()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(password).To(gomega.Equal("plain"))
+
+	password, err = Credentials{Username: "user", Source: CredentialSourcePlain, Password: "plain"}.resolvePassword()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(password).To(gomega.Equal("plain"))
+
+	os.Setenv("GRCHIVE_TEST_DB_PASSWORD", "env")
+	defer os.Unsetenv("GRCHIVE_TEST_DB_PASSWORD")
+
+	password, err = Credentials{Username: "user", Source: CredentialSourceEnv, Password: "GRCHIVE_TEST_DB_PASSWORD"}.resolvePassword()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(password).To(gomega.Equal("env"))
+
+	_, err = Credentials{Username: "user", Source: CredentialSourceEnv, Password: "GRCHIVE_TEST_DB_PASSWORD_MISSING"}.resolvePassword()
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	f, err := ioutil.TempFile("", "password")
+	g.Expect(err).To(gomega.BeNil())
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("file\n")
+	g.Expect(err).To(gomega.BeNil())
+	f.Close()
+
+	password, err = Credentials{Username: "user", Source: CredentialSourceFile, Password: f.Name()}.resolvePassword()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(password).To(gomega.Equal("file"))
+
+	_, err = Credentials{Username: "user", Source: CredentialSourceFile, Password: f.Name() + ".missing"}.resolvePassword()
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = Credentials{Username: "user", Source: "vault", Password: "secret"}.resolvePassword()
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestConfigWithDatabase(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cfg := Config{
+		Type:     DatabaseTypePostgres,
+		Host:     "localhost",
+		Database: "postgres",
+	}
+
+	other := cfg.WithDatabase("other")
+	g.Expect(other.Database).To(gomega.Equal("other"))
+	g.Expect(other.Host).To(gomega.Equal("localhost"))
+	g.Expect(cfg.Database).To(gomega.Equal("postgres"))
+}
+
+func TestConnectErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := Connect(Config{
+		Type: "sqlite",
+		Host: "localhost",
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = Connect(Config{
+		Type: DatabaseTypePostgres,
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = Connect(Config{
+		Type: DatabaseTypePostgres,
+		Host: "localhost",
+		Credentials: Credentials{
+			Username: "user",
+			Source:   CredentialSourceEnv,
+			Password: "GRCHIVE_TEST_DB_PASSWORD_MISSING",
+		},
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = Connect(Config{
+		Type: DatabaseTypeMysql,
+		Host: "localhost",
+		Tls: TlsConfig{
+			Mode:   TlsModeVerifyFull,
+			CaFile: "/does/not/exist.pem",
+		},
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
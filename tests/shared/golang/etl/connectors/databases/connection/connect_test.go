@@ -0,0 +1,59 @@
+package connection
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"testing"
+	"time"
+)
+
+func TestQueryRows(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	conn := &Connection{
+		DB:           sqlx.NewDb(db, "sqlmock"),
+		queryTimeout: time.Second,
+	}
+
+	mock.ExpectQuery(`SELECT 1`).WillReturnRows(sqlmock.NewRows([]string{"Value"}).AddRow(1))
+	rows, err := databases.Query(conn, `SELECT 1`)
+	g.Expect(err).To(gomega.BeNil())
+
+	timeoutRows, ok := rows.(timeoutRows)
+	g.Expect(ok).To(gomega.BeTrue())
+
+	cancelled := false
+	cancel := timeoutRows.cancel
+	timeoutRows.cancel = func() {
+		cancelled = true
+		cancel()
+	}
+
+	value := 0
+	g.Expect(timeoutRows.Next()).To(gomega.BeTrue())
+	g.Expect(timeoutRows.Scan(&value)).To(gomega.BeNil())
+	g.Expect(value).To(gomega.Equal(1))
+	g.Expect(timeoutRows.Close()).To(gomega.BeNil())
+	g.Expect(cancelled).To(gomega.BeTrue())
+
+	// The query fails once the timeout elapses.
+	conn.queryTimeout = 10 * time.Millisecond
+	mock.ExpectQuery(`SELECT 2`).WillDelayFor(time.Second).WillReturnRows(sqlmock.NewRows([]string{"Value"}).AddRow(2))
+	_, err = databases.Query(conn, `SELECT 2`)
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	// No timeout means the rows are returned as is.
+	conn.queryTimeout = 0
+	mock.ExpectQuery(`SELECT 3`).WillReturnRows(sqlmock.NewRows([]string{"Value"}).AddRow(3))
+	rows, err = databases.Query(conn, `SELECT 3`)
+	g.Expect(err).To(gomega.BeNil())
+	_, ok = rows.(*sqlx.Rows)
+	g.Expect(ok).To(gomega.BeTrue())
+	rows.Close()
+}
@@ -0,0 +1,133 @@
+package connection
+
+import (
+	"context"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/shared/utility/ssh"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestForwardThroughTunnel(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	echo, err := test_utility.NewEchoServer()
+	g.Expect(err).To(gomega.BeNil())
+	defer echo.Close()
+
+	server, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer server.Close()
+
+	tunnel, err := ssh_utility.OpenSshTunnel(ssh_utility.SshTunnelConfig{
+		Hops: []ssh_utility.SshHostConfig{
+			{
+				Host:                  server.Host(),
+				Port:                  server.Port(),
+				Username:              "bastion",
+				Password:              "password",
+				InsecureIgnoreHostKey: true,
+			},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer tunnel.Close()
+
+	host, port, err := net.SplitHostPort(echo.Addr().String())
+	g.Expect(err).To(gomega.BeNil())
+
+	cfg := Config{
+		Type:     DatabaseTypeDb2,
+		Host:     host,
+		Database: "test",
+		Tls:      TlsConfig{Mode: TlsModeVerifyCa},
+	}
+	cfg.Port, err = strconv.Atoi(port)
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(usesForwardedPort(cfg.Type)).To(gomega.BeTrue())
+	forwarded, err := forwardThroughTunnel(cfg, tunnel)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(forwarded.Host).To(gomega.Equal("127.0.0.1"))
+	g.Expect(forwarded.Port).NotTo(gomega.Equal(cfg.Port))
+	g.Expect(forwarded.Database).To(gomega.Equal("test"))
+
+	conn, err := net.Dial("tcp", forwarded.address())
+	g.Expect(err).To(gomega.BeNil())
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello"))
+	g.Expect(err).To(gomega.BeNil())
+
+	buffer := make([]byte, 5)
+	_, err = io.ReadFull(conn, buffer)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(string(buffer)).To(gomega.Equal("hello"))
+
+	// The client library would check the certificate against the forwarded address.
+	cfg.Type = DatabaseTypeOracle
+	cfg.Tls.Mode = TlsModeVerifyFull
+	_, err = forwardThroughTunnel(cfg, tunnel)
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	for _, typ := range []DatabaseType{DatabaseTypePostgres, DatabaseTypeMysql, DatabaseTypeMariadb, DatabaseTypeMssql} {
+		g.Expect(usesForwardedPort(typ)).To(gomega.BeFalse())
+	}
+}
+
+func TestMysqlTunnelRegistry(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	echo, err := test_utility.NewEchoServer()
+	g.Expect(err).To(gomega.BeNil())
+	defer echo.Close()
+
+	server, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer server.Close()
+
+	tunnel, err := ssh_utility.OpenSshTunnel(ssh_utility.SshTunnelConfig{
+		Hops: []ssh_utility.SshHostConfig{
+			{
+				Host:                  server.Host(),
+				Port:                  server.Port(),
+				Username:              "bastion",
+				Password:              "password",
+				InsecureIgnoreHostKey: true,
+			},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer tunnel.Close()
+
+	first := mysqlTunnels.register(tunnel)
+	second := mysqlTunnels.register(tunnel)
+	g.Expect(second).NotTo(gomega.Equal(first))
+
+	conn, err := mysqlTunnels.dial(context.Background(), first, echo.Addr().String())
+	g.Expect(err).To(gomega.BeNil())
+	conn.Close()
+
+	// Released networks can't be dialed and are reused instead of registering new ones.
+	mysqlTunnels.release(first)
+	_, err = mysqlTunnels.dial(context.Background(), first, echo.Addr().String())
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	g.Expect(mysqlTunnels.register(tunnel)).To(gomega.Equal(first))
+	mysqlTunnels.release(first)
+	mysqlTunnels.release(second)
+}
+
+func TestConnectTunnelErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := Connect(Config{
+		Type: DatabaseTypePostgres,
+		Host: "db.internal",
+		Ssh:  &ssh_utility.SshTunnelConfig{},
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
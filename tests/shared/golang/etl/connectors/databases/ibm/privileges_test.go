@@ -0,0 +1,35 @@
+package ibm
+
+import (
+	"github.com/onsi/gomega"
+	"strings"
+	"testing"
+)
+
+func TestUnpivotAuthColumns(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	query := unpivotAuthColumns("SYSCAT.SCHEMAAUTH", "'SCHEMA::' CONCAT a.SCHEMANAME", ibmSchemaAuthColumns)
+	g.Expect(query).To(gomega.ContainSubstring("a.GRANTEE AS AUTHID"))
+	g.Expect(query).To(gomega.ContainSubstring("a.GRANTEETYPE AS AUTHIDTYPE"))
+	g.Expect(query).To(gomega.ContainSubstring("FROM SYSCAT.SCHEMAAUTH a"))
+	g.Expect(query).To(gomega.ContainSubstring("TABLE (VALUES ('ALTERIN', a.ALTERINAUTH), ('CREATEIN', a.CREATEINAUTH), ('DROPIN', a.DROPINAUTH)) AS p (PRIVILEGE, AUTH)"))
+	g.Expect(query).To(gomega.ContainSubstring("p.PRIVILEGE CONCAT CASE WHEN p.AUTH = 'G' THEN ' WITH GRANT OPTION' ELSE '' END AS PRIVILEGE"))
+	g.Expect(query).To(gomega.ContainSubstring("'SCHEMA::' CONCAT a.SCHEMANAME AS OBJECT"))
+	g.Expect(query).To(gomega.ContainSubstring("WHERE p.AUTH IN ('Y', 'G')"))
+}
+
+// Every part of the CTE must return the type of the auth ID so that it can be joined on both columns.
+func TestPrivilegesCteColumns(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	parts := strings.Split(ibmPrivilegesCte, "\n\t\t\tUNION\n")
+	g.Expect(parts).To(gomega.HaveLen(14))
+
+	for _, p := range parts {
+		g.Expect(p).To(gomega.ContainSubstring("AUTHID"), p)
+		g.Expect(p).To(gomega.ContainSubstring("AUTHIDTYPE"), p)
+		g.Expect(p).To(gomega.ContainSubstring("AS PRIVILEGE"), p)
+		g.Expect(p).To(gomega.ContainSubstring("AS OBJECT"), p)
+	}
+}
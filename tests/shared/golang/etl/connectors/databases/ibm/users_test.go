@@ -0,0 +1,130 @@
+package ibm
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"testing"
+)
+
+var authIdColumns = []string{"AUTHID", "AUTHIDTYPE", "PRIVILEGE", "OBJECT"}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	joinOnType := `ON privs.AUTHID = auth.AUTHID AND privs.AUTHIDTYPE = auth.AUTHIDTYPE\s+WHERE auth.AUTHIDTYPE IN `
+
+	mock.ExpectQuery(joinOnType + `\('U'\)`).WillReturnRows(
+		sqlmock.NewRows(authIdColumns).
+			AddRow("ALICE", "U", "CONNECT", "DATABASE").
+			AddRow("ALICE", "U", "SELECT WITH GRANT OPTION", "TABLE::FINANCE.LEDGER").
+			AddRow("BOB", "U", "CONNECT", "DATABASE").
+			AddRow("CAROL", "U", nil, nil),
+	)
+
+	// AUDIT is both a group and a role. Each only gets its own privileges.
+	mock.ExpectQuery(joinOnType + `\('R','G'\)`).WillReturnRows(
+		sqlmock.NewRows(authIdColumns).
+			AddRow("AUDIT", "G", "SELECT", "TABLE::FINANCE.LEDGER").
+			AddRow("AUDIT", "R", "DBADM", "DATABASE").
+			AddRow("AUDIT", "R", "SECADM", "DATABASE").
+			AddRow("EMPTY", "R", nil, nil),
+	)
+
+	parentColumns := []string{"AUTHID", "AUTHIDTYPE"}
+	mock.ExpectQuery(`AUTH_LIST_GROUPS_FOR_AUTHID\('ALICE'\)`).WillReturnRows(
+		sqlmock.NewRows(parentColumns).
+			AddRow("AUDIT", "G"),
+	)
+	mock.ExpectQuery(`AUTH_LIST_GROUPS_FOR_AUTHID\('BOB'\)`).WillReturnRows(
+		sqlmock.NewRows(parentColumns).
+			AddRow("AUDIT", "R").
+			AddRow("EMPTY", "R"),
+	)
+	mock.ExpectQuery(`AUTH_LIST_GROUPS_FOR_AUTHID\('CAROL'\)`).WillReturnRows(
+		sqlmock.NewRows(parentColumns),
+	)
+
+	conn, err := CreateIBMConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(5))
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"ALICE": &types.EtlUser{
+			Username: "ALICE",
+			Roles: map[string]*types.EtlRole{
+				"ALICE": &types.EtlRole{
+					Name: "ALICE",
+					Permissions: map[string][]string{
+						"DATABASE":              []string{"CONNECT"},
+						"TABLE::FINANCE.LEDGER": []string{"SELECT WITH GRANT OPTION"},
+					},
+				},
+				"AUDIT": &types.EtlRole{
+					Name: "AUDIT",
+					Permissions: map[string][]string{
+						"TABLE::FINANCE.LEDGER": []string{"SELECT"},
+					},
+				},
+			},
+		},
+		"BOB": &types.EtlUser{
+			Username: "BOB",
+			Roles: map[string]*types.EtlRole{
+				"BOB": &types.EtlRole{
+					Name: "BOB",
+					Permissions: map[string][]string{
+						"DATABASE": []string{"CONNECT"},
+					},
+				},
+				"AUDIT": &types.EtlRole{
+					Name: "AUDIT",
+					Permissions: map[string][]string{
+						"DATABASE": []string{"DBADM", "SECADM"},
+					},
+				},
+				"EMPTY": &types.EtlRole{
+					Name:        "EMPTY",
+					Permissions: map[string][]string{},
+				},
+			},
+		},
+		"CAROL": &types.EtlUser{
+			Username: "CAROL",
+			Roles: map[string]*types.EtlRole{
+				"CAROL": &types.EtlRole{
+					Name:        "CAROL",
+					Permissions: map[string][]string{},
+				},
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM SYSIBMADM.AUTHORIZATIONIDS`).WillReturnError(errors.New("SQL0551N"))
+
+	conn, err := CreateIBMConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/onsi/gomega"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/account"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
 	"gitlab.com/grchive/grchive-v3/shared/test_utility"
 	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/databases/mariadb_utility"
@@ -335,6 +336,7 @@ func TestGetUserListing(t *testing.T) {
 		// 4) Test column level privileges
 		// 5) Test process level privileges
 		// 6) Test granting roles to users
+		// 7) Test account attributes and findings
 		container, db := mariadb_utility.SetupMariadbDatabase(t, version, func(db *sqlx.DB) error {
 			tx := db.MustBegin()
 
@@ -669,6 +671,54 @@ func TestGetUserListing(t *testing.T) {
 				},
 			}
 
+			// Setup test users for account attributes.
+			if _, err := tx.Exec(`
+				CREATE USER ''@'localhost'
+			`); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := tx.Exec(`
+				CREATE USER 'test_password'@'%' IDENTIFIED BY 'password'
+			`); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			refUsers["@localhost"] = &types.EtlUser{
+				Username: "@localhost",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name:        "Self",
+						Permissions: map[string][]string{},
+					},
+				},
+				Attributes: map[string]string{
+					account.AttributeHost: "localhost",
+				},
+				Findings: []*types.EtlFinding{
+					&types.EtlFinding{Code: account.FindingAnonymousUser},
+					&types.EtlFinding{Code: account.FindingEmptyPassword},
+				},
+			}
+
+			refUsers["test_password@%"] = &types.EtlUser{
+				Username: "test_password@%",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name:        "Self",
+						Permissions: map[string][]string{},
+					},
+				},
+				Attributes: map[string]string{
+					account.AttributeHost: "%",
+				},
+				Findings: []*types.EtlFinding{
+					&types.EtlFinding{Code: account.FindingWildcardHost},
+				},
+			}
+
 			return tx.Commit()
 		})
 		defer container.Terminate(ctx)
@@ -678,7 +728,7 @@ func TestGetUserListing(t *testing.T) {
 		}}
 		users, source, err := connector.GetUserListing()
 		g.Expect(err).To(gomega.BeNil())
-		g.Expect(len(source.Commands)).To(gomega.Equal(2))
+		g.Expect(len(source.Commands)).To(gomega.Equal(4))
 
 		if strings.HasPrefix(version, "10.1") || strings.HasPrefix(version, "10.2") || strings.HasPrefix(version, "10.3") {
 			test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{
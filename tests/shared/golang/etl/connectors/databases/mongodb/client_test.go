@@ -0,0 +1,77 @@
+package mongodb
+
+import (
+	"github.com/onsi/gomega"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestToBsonCommand(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cmd := toBsonCommand(MongoCommand{
+		{Key: "rolesInfo", Value: 1},
+		{Key: "showPrivileges", Value: true},
+		{Key: "filter", Value: MongoCommand{
+			{Key: "db", Value: "admin"},
+			{Key: "role", Value: "root"},
+		}},
+	})
+
+	g.Expect(cmd).To(gomega.Equal(bson.D{
+		{Key: "rolesInfo", Value: 1},
+		{Key: "showPrivileges", Value: true},
+		{Key: "filter", Value: bson.D{
+			{Key: "db", Value: "admin"},
+			{Key: "role", Value: "root"},
+		}},
+	}))
+}
+
+// The runner returns relaxed extended JSON so the reply must still decode like regular JSON.
+func TestExtJsonReply(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	reply, err := bson.Marshal(bson.D{
+		{Key: "users", Value: bson.A{
+			bson.D{
+				{Key: "_id", Value: "admin.root"},
+				{Key: "user", Value: "root"},
+				{Key: "db", Value: "admin"},
+				{Key: "roles", Value: bson.A{
+					bson.D{{Key: "role", Value: "root"}, {Key: "db", Value: "admin"}},
+				}},
+				{Key: "mechanisms", Value: bson.A{"SCRAM-SHA-1", "SCRAM-SHA-256"}},
+			},
+		}},
+		{Key: "ok", Value: 1.0},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	raw, err := bson.MarshalExtJSON(bson.Raw(reply), false, false)
+	g.Expect(err).To(gomega.BeNil())
+
+	runner := &fakeRunner{
+		replies: map[string]string{
+			"admin:usersInfo": string(raw),
+		},
+	}
+
+	out := struct {
+		Users []mongoUser `json:"users"`
+	}{}
+	source, err := runCommand(runner, "admin", MongoCommand{
+		{Key: "usersInfo", Value: 1},
+	}, &out)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(source.Commands).To(gomega.HaveLen(1))
+	g.Expect(out.Users).To(gomega.Equal([]mongoUser{
+		{
+			Id:         "admin.root",
+			User:       "root",
+			Db:         "admin",
+			Roles:      []mongoRoleRef{{Role: "root", Db: "admin"}},
+			Mechanisms: []string{"SCRAM-SHA-1", "SCRAM-SHA-256"},
+		},
+	}))
+}
@@ -0,0 +1,64 @@
+//go:build !unit
+// +build !unit
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"github.com/onsi/gomega"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"testing"
+	"time"
+)
+
+func TestDialMongo(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "mongo:4.4",
+			ExposedPorts: []string{"27017"},
+			Env: map[string]string{
+				"MONGO_INITDB_ROOT_USERNAME": "root",
+				"MONGO_INITDB_ROOT_PASSWORD": "password",
+			},
+			// The server is restarted once the root user is created.
+			WaitingFor: wait.ForLog("Waiting for connections").WithOccurrence(2).WithStartupTimeout(1 * time.Minute),
+		},
+		Started: true,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer container.Terminate(ctx)
+
+	endpoint, err := container.Endpoint(ctx, "")
+	g.Expect(err).To(gomega.BeNil())
+
+	client, err := DialMongo(MongoOptions{
+		Uri:     fmt.Sprintf("mongodb://root:password@%s/?authSource=admin", endpoint),
+		Timeout: 30 * time.Second,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer client.Close()
+
+	conn, err := CreateMongodbConnector(client)
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.BeNumerically(">", 0))
+
+	usernames := []string{}
+	for _, u := range users {
+		usernames = append(usernames, u.Username)
+	}
+	g.Expect(usernames).To(gomega.ContainElement("admin.root"))
+
+	_, err = DialMongo(MongoOptions{
+		Uri:     fmt.Sprintf("mongodb://root:wrong@%s/?authSource=admin", endpoint),
+		Timeout: 30 * time.Second,
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
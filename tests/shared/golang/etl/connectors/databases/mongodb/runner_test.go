@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"strings"
+)
+
+type fakeRunner struct {
+	// Replies keyed by the database and the fields of the command (e.g. "admin:usersInfo,showPrivileges").
+	replies  map[string]string
+	commands []MongoCommand
+}
+
+func (r *fakeRunner) RunCommand(database string, command MongoCommand) ([]byte, error) {
+	r.commands = append(r.commands, command)
+
+	keys := []string{}
+	for _, f := range command {
+		keys = append(keys, f.Key)
+	}
+
+	reply, ok := r.replies[database+":"+strings.Join(keys, ",")]
+	if !ok {
+		return []byte(`{"ok": 0, "errmsg": "not authorized"}`), nil
+	}
+	return []byte(reply), nil
+}
@@ -0,0 +1,186 @@
+package mongodb
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"testing"
+)
+
+var recordedReplies = map[string]string{
+	"admin:usersInfo": `{
+		"users": [
+			{"_id": "admin.root", "userId": {"$binary": {"base64": "AAAA", "subType": "04"}}, "user": "root", "db": "admin", "roles": [{"role": "root", "db": "admin"}], "mechanisms": ["SCRAM-SHA-1", "SCRAM-SHA-256"]},
+			{"_id": "admin.reporting", "user": "reporting", "db": "admin", "roles": [{"role": "read", "db": "reporting"}, {"role": "auditRole", "db": "admin"}], "mechanisms": ["SCRAM-SHA-256"]},
+			{"_id": "reporting.app", "user": "app", "db": "reporting", "roles": [{"role": "readWrite", "db": "reporting"}], "mechanisms": ["SCRAM-SHA-256"]}
+		],
+		"ok": 1
+	}`,
+	"admin:usersInfo,showPrivileges": `{
+		"users": [
+			{
+				"_id": "admin.root", "user": "root", "db": "admin",
+				"roles": [{"role": "root", "db": "admin"}],
+				"inheritedRoles": [{"role": "root", "db": "admin"}],
+				"inheritedPrivileges": [{"resource": {"anyResource": true}, "actions": ["anyAction"]}],
+				"mechanisms": ["SCRAM-SHA-1", "SCRAM-SHA-256"]
+			},
+			{
+				"_id": "admin.reporting", "user": "reporting", "db": "admin",
+				"roles": [{"role": "read", "db": "reporting"}, {"role": "auditRole", "db": "admin"}],
+				"inheritedRoles": [{"role": "read", "db": "reporting"}, {"role": "auditRole", "db": "admin"}, {"role": "clusterMonitor", "db": "admin"}],
+				"mechanisms": ["SCRAM-SHA-256"]
+			}
+		],
+		"ok": 1
+	}`,
+	"reporting:usersInfo,showPrivileges": `{
+		"users": [
+			{
+				"_id": "reporting.app", "user": "app", "db": "reporting",
+				"roles": [{"role": "readWrite", "db": "reporting"}],
+				"inheritedRoles": [{"role": "readWrite", "db": "reporting"}],
+				"mechanisms": ["SCRAM-SHA-256"]
+			}
+		],
+		"ok": 1
+	}`,
+	"admin:rolesInfo,showPrivileges,showBuiltinRoles": `{
+		"roles": [
+			{
+				"role": "root", "db": "admin", "isBuiltin": true, "roles": [], "inheritedRoles": [],
+				"privileges": [{"resource": {"anyResource": true}, "actions": ["anyAction"]}]
+			},
+			{
+				"role": "auditRole", "db": "admin", "isBuiltin": false,
+				"roles": [{"role": "clusterMonitor", "db": "admin"}],
+				"privileges": [{"resource": {"db": "", "collection": "system.profile"}, "actions": ["find"]}]
+			},
+			{
+				"role": "clusterMonitor", "db": "admin", "isBuiltin": true, "roles": [],
+				"privileges": [
+					{"resource": {"cluster": true}, "actions": ["serverStatus", "top"]},
+					{"resource": {"db": "config", "collection": ""}, "actions": ["find"]}
+				]
+			}
+		],
+		"ok": 1
+	}`,
+	"reporting:rolesInfo,showPrivileges,showBuiltinRoles": `{
+		"roles": [
+			{
+				"role": "read", "db": "reporting", "isBuiltin": true, "roles": [],
+				"privileges": [{"resource": {"db": "reporting", "collection": ""}, "actions": ["find", "listCollections"]}]
+			},
+			{
+				"role": "readWrite", "db": "reporting", "isBuiltin": true, "roles": [],
+				"privileges": [{"resource": {"db": "reporting", "collection": ""}, "actions": ["find", "insert", "remove", "update"]}]
+			}
+		],
+		"ok": 1
+	}`,
+}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	runner := &fakeRunner{
+		replies: recordedReplies,
+	}
+
+	conn, err := CreateMongodbConnector(runner)
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(5))
+	g.Expect(source.Commands[0].Command).To(gomega.Equal("usersInfo"))
+	g.Expect(source.Commands[0].RawData).To(gomega.Equal(recordedReplies["admin:usersInfo"]))
+
+	readWriteRole := &types.EtlRole{
+		Name: "reporting.readWrite",
+		Permissions: map[string][]string{
+			"COLLECTION::reporting.*": []string{"find", "insert", "remove", "update"},
+		},
+	}
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"admin.root": &types.EtlUser{
+			Username: "admin.root",
+			Attributes: map[string]string{
+				MongoAttributeDatabase:   "admin",
+				MongoAttributeMechanisms: "SCRAM-SHA-1,SCRAM-SHA-256",
+			},
+			Roles: map[string]*types.EtlRole{
+				"admin.root": &types.EtlRole{
+					Name: "admin.root",
+					Permissions: map[string][]string{
+						MongoAnyResource: []string{"anyAction"},
+					},
+				},
+			},
+		},
+		"admin.reporting": &types.EtlUser{
+			Username: "admin.reporting",
+			Attributes: map[string]string{
+				MongoAttributeDatabase: "admin",
+			},
+			Roles: map[string]*types.EtlRole{
+				"reporting.read": &types.EtlRole{
+					Name: "reporting.read",
+					Permissions: map[string][]string{
+						"COLLECTION::reporting.*": []string{"find", "listCollections"},
+					},
+				},
+				"admin.auditRole": &types.EtlRole{
+					Name: "admin.auditRole",
+					Permissions: map[string][]string{
+						"COLLECTION::*.system.profile": []string{"find"},
+					},
+				},
+				"admin.clusterMonitor": &types.EtlRole{
+					Name: "admin.clusterMonitor",
+					Permissions: map[string][]string{
+						MongoClusterResource:   []string{"serverStatus", "top"},
+						"COLLECTION::config.*": []string{"find"},
+					},
+				},
+			},
+		},
+		"reporting.app": &types.EtlUser{
+			Username: "reporting.app",
+			Attributes: map[string]string{
+				MongoAttributeDatabase: "reporting",
+			},
+			Roles: map[string]*types.EtlRole{
+				"reporting.readWrite": readWriteRole,
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+
+	// The users are listed explicitly since privileges can't be shown when listing all users.
+	g.Expect(runner.commands[1]).To(gomega.Equal(MongoCommand{
+		{Key: "usersInfo", Value: []map[string]string{
+			{"user": "root", "db": "admin"},
+			{"user": "reporting", "db": "admin"},
+		}},
+		{Key: "showPrivileges", Value: true},
+	}))
+}
+
+func TestGetUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	runner := &fakeRunner{
+		replies: map[string]string{
+			"admin:usersInfo": recordedReplies["admin:usersInfo"],
+		},
+	}
+
+	conn, err := CreateMongodbConnector(runner)
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("not authorized"))
+}
@@ -1,3 +1,4 @@
+//go:build !unit
 // +build !unit
 
 package mssql
@@ -10,6 +11,7 @@ import (
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
 	"gitlab.com/grchive/grchive-v3/shared/test_utility"
 	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/databases/mssql_utility"
+	"strings"
 	"testing"
 	"time"
 )
@@ -31,12 +33,15 @@ func TestGetUserListing(t *testing.T) {
 		// 5) Grant test permissions to a database user (table level)
 		// 6) Role inheritance for database users
 		// 7) Role inheritance for server logins
+		// 8) Orphaned database user (the server login was dropped)
+		// 9) Database user in a database other than master with schema level permissions
+		// 10) Contained database user
 		container, db := mssql_utility.SetupMssqlDatabase(t, version, func(db *sqlx.DB) error {
 			tx := db.MustBegin()
 
 			gstNow := time.Date(2003, 4, 8, 9, 10, 19, 647000000, time.UTC)
 			guestUser := &types.EtlUser{
-				Username:    "guest",
+				Username:    "master::guest",
 				CreatedTime: &gstNow,
 				Roles: map[string]*types.EtlRole{
 					"guest": &types.EtlRole{
@@ -71,7 +76,7 @@ func TestGetUserListing(t *testing.T) {
 					},
 					CreatedTime: &nw,
 					NestedUsers: map[string]*types.EtlUser{
-						"guest": guestUser,
+						"master::guest": guestUser,
 					},
 				}
 			}
@@ -103,9 +108,9 @@ func TestGetUserListing(t *testing.T) {
 					},
 					CreatedTime: &nw,
 					NestedUsers: map[string]*types.EtlUser{
-						"guest": guestUser,
-						"test_2_user": &types.EtlUser{
-							Username:    "test_2_user",
+						"master::guest": guestUser,
+						"master::test_2_user": &types.EtlUser{
+							Username:    "master::test_2_user",
 							CreatedTime: &nw,
 							Roles: map[string]*types.EtlRole{
 								"test_2_user": &types.EtlRole{
@@ -149,7 +154,7 @@ func TestGetUserListing(t *testing.T) {
 					},
 					CreatedTime: &nw,
 					NestedUsers: map[string]*types.EtlUser{
-						"guest": guestUser,
+						"master::guest": guestUser,
 					},
 				}
 			}
@@ -187,9 +192,9 @@ func TestGetUserListing(t *testing.T) {
 					},
 					CreatedTime: &nw,
 					NestedUsers: map[string]*types.EtlUser{
-						"guest": guestUser,
-						"test_4_user": &types.EtlUser{
-							Username:    "test_4_user",
+						"master::guest": guestUser,
+						"master::test_4_user": &types.EtlUser{
+							Username:    "master::test_4_user",
 							CreatedTime: &nw,
 							Roles: map[string]*types.EtlRole{
 								"test_4_user": &types.EtlRole{
@@ -245,9 +250,9 @@ func TestGetUserListing(t *testing.T) {
 					},
 					CreatedTime: &nw,
 					NestedUsers: map[string]*types.EtlUser{
-						"guest": guestUser,
-						"test_5_user": &types.EtlUser{
-							Username:    "test_5_user",
+						"master::guest": guestUser,
+						"master::test_5_user": &types.EtlUser{
+							Username:    "master::test_5_user",
 							CreatedTime: &nw,
 							Roles: map[string]*types.EtlRole{
 								"test_5_user": &types.EtlRole{
@@ -316,9 +321,9 @@ func TestGetUserListing(t *testing.T) {
 					},
 					CreatedTime: &nw,
 					NestedUsers: map[string]*types.EtlUser{
-						"guest": guestUser,
-						"test_6_user": &types.EtlUser{
-							Username:    "test_6_user",
+						"master::guest": guestUser,
+						"master::test_6_user": &types.EtlUser{
+							Username:    "master::test_6_user",
 							CreatedTime: &nw,
 							Roles: map[string]*types.EtlRole{
 								"test_6_user": &types.EtlRole{
@@ -396,12 +401,153 @@ func TestGetUserListing(t *testing.T) {
 					},
 					CreatedTime: &nw,
 					NestedUsers: map[string]*types.EtlUser{
-						"guest": guestUser,
+						"master::guest": guestUser,
 					},
 				}
 			}
 
+			// Test 8
+			{
+				nw := time.Now()
+				_, err := tx.Exec(`CREATE LOGIN test_8 WITH PASSWORD = 'qpwoeiru0A!'`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`CREATE USER test_8_user FOR LOGIN test_8`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`DROP LOGIN test_8`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				expectedUsers["master::test_8_user"] = &types.EtlUser{
+					Username:    "master::test_8_user",
+					CreatedTime: &nw,
+					Roles: map[string]*types.EtlRole{
+						"test_8_user": &types.EtlRole{
+							Name: "test_8_user",
+							Permissions: types.PermissionMap{
+								"DATABASE::::.": []string{"CONNECT"},
+							},
+							Denied: types.PermissionMap{},
+						},
+						ORPHANED_USER_ROLE: &types.EtlRole{
+							Name:        ORPHANED_USER_ROLE,
+							Permissions: types.PermissionMap{},
+						},
+					},
+					NestedUsers: map[string]*types.EtlUser{},
+				}
+			}
+
 			return tx.Commit()
+		}, func(db *sqlx.DB) error {
+			// CREATE DATABASE can't be run inside of a transaction.
+			gstNow := time.Date(2003, 4, 8, 9, 10, 19, 647000000, time.UTC)
+
+			// Test 9
+			{
+				nw := time.Now()
+				for _, stmt := range []string{
+					`CREATE DATABASE test_db`,
+					`CREATE LOGIN test_9 WITH PASSWORD = 'qpwoeiru0A!'`,
+					`EXEC test_db.sys.sp_executesql N'CREATE USER test_9_user FOR LOGIN test_9'`,
+					`EXEC test_db.sys.sp_executesql N'GRANT SELECT ON SCHEMA::dbo TO test_9_user'`,
+				} {
+					_, err := db.Exec(stmt)
+					if err != nil {
+						return err
+					}
+				}
+
+				expectedUsers["test_9"] = &types.EtlUser{
+					Username: "test_9",
+					Roles: map[string]*types.EtlRole{
+						"test_9": &types.EtlRole{
+							Name: "test_9",
+							Permissions: map[string][]string{
+								"SERVER::::.": []string{"CONNECT SQL"},
+							},
+						},
+					},
+					CreatedTime: &nw,
+					NestedUsers: map[string]*types.EtlUser{
+						"master::guest": &types.EtlUser{
+							Username:    "master::guest",
+							CreatedTime: &gstNow,
+							Roles: map[string]*types.EtlRole{
+								"guest": &types.EtlRole{
+									Name: "guest",
+									Permissions: types.PermissionMap{
+										"DATABASE::::.": []string{"CONNECT"},
+									},
+									Denied: types.PermissionMap{},
+								},
+							},
+							NestedUsers: map[string]*types.EtlUser{},
+						},
+						"test_db::test_9_user": &types.EtlUser{
+							Username:    "test_db::test_9_user",
+							CreatedTime: &nw,
+							Roles: map[string]*types.EtlRole{
+								"test_9_user": &types.EtlRole{
+									Name: "test_9_user",
+									Permissions: types.PermissionMap{
+										"DATABASE::::.":  []string{"CONNECT"},
+										"SCHEMA::::dbo.": []string{"SELECT"},
+									},
+									Denied: types.PermissionMap{},
+								},
+							},
+							NestedUsers: map[string]*types.EtlUser{},
+						},
+					},
+				}
+			}
+
+			// Test 10
+			{
+				nw := time.Now()
+				for _, stmt := range []string{
+					`EXEC sp_configure 'contained database authentication', 1`,
+					`RECONFIGURE`,
+					`CREATE DATABASE test_contained CONTAINMENT = PARTIAL`,
+					`EXEC test_contained.sys.sp_executesql N'CREATE USER test_10_user WITH PASSWORD = ''qpwoeiru0A!'''`,
+				} {
+					_, err := db.Exec(stmt)
+					if err != nil {
+						return err
+					}
+				}
+
+				expectedUsers["test_contained::test_10_user"] = &types.EtlUser{
+					Username:    "test_contained::test_10_user",
+					CreatedTime: &nw,
+					Roles: map[string]*types.EtlRole{
+						"test_10_user": &types.EtlRole{
+							Name: "test_10_user",
+							Permissions: types.PermissionMap{
+								"DATABASE::::.": []string{"CONNECT"},
+							},
+							Denied: types.PermissionMap{},
+						},
+						CONTAINED_USER_ROLE: &types.EtlRole{
+							Name:        CONTAINED_USER_ROLE,
+							Permissions: types.PermissionMap{},
+						},
+					},
+					NestedUsers: map[string]*types.EtlUser{},
+				}
+			}
+
+			return nil
 		})
 
 		defer container.Terminate(ctx)
@@ -415,6 +561,16 @@ func TestGetUserListing(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
+
+		// The guest user is also enabled in the system databases (msdb, tempdb) and the permissions it has there depend on the
+		// version of SQL Server so only the guest user in master is checked.
+		for _, u := range users {
+			for name := range u.NestedUsers {
+				if strings.HasSuffix(name, "::guest") && name != "master::guest" {
+					delete(u.NestedUsers, name)
+				}
+			}
+		}
 		test_utility.CompareUserListing(g, users, expectedUsers, test_utility.CompareUserListingOptions{
 			UsersToIgnore: []string{
 				"sa",
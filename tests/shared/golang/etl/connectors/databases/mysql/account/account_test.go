@@ -0,0 +1,104 @@
+package account
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func TestMysqlAccountFindings(t *testing.T) {
+	for _, test := range []struct {
+		account MysqlAccount
+		codes   []string
+	}{
+		{
+			account: MysqlAccount{Host: "localhost", User: "test", Plugin: "mysql_native_password"},
+			codes:   []string{},
+		},
+		{
+			account: MysqlAccount{Host: "%", User: "test", Plugin: "mysql_native_password"},
+			codes:   []string{FindingWildcardHost},
+		},
+		{
+			account: MysqlAccount{Host: "10.0.%", User: "test", Plugin: "caching_sha2_password"},
+			codes:   []string{FindingWildcardHost},
+		},
+		{
+			account: MysqlAccount{Host: "localhost", User: "", Plugin: "mysql_native_password", EmptyPassword: true},
+			codes:   []string{FindingAnonymousUser, FindingEmptyPassword},
+		},
+		{
+			account: MysqlAccount{Host: "", User: "", Plugin: "", EmptyPassword: true},
+			codes:   []string{FindingAnonymousUser, FindingWildcardHost, FindingEmptyPassword},
+		},
+		{
+			// Socket authentication doesn't use the stored password.
+			account: MysqlAccount{Host: "localhost", User: "test", Plugin: "auth_socket", EmptyPassword: true},
+			codes:   []string{},
+		},
+		{
+			account: MysqlAccount{Host: "%", User: "test", EmptyPassword: true, AccountLocked: boolPtr(true)},
+			codes:   []string{},
+		},
+		{
+			account: MysqlAccount{Host: "", User: "role", EmptyPassword: true, IsRole: true},
+			codes:   []string{},
+		},
+		{
+			account: MysqlAccount{Host: "%", User: "test", EmptyPassword: true, AccountLocked: boolPtr(false)},
+			codes:   []string{FindingWildcardHost, FindingEmptyPassword},
+		},
+	} {
+		g := gomega.NewGomegaWithT(t)
+
+		codes := []string{}
+		for _, f := range test.account.Findings() {
+			codes = append(codes, f.Code)
+		}
+		g.Expect(codes).To(gomega.Equal(test.codes), "%+v", test.account)
+	}
+}
+
+func TestMysqlAccountAttributes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	lastChanged := time.Date(2020, 10, 1, 12, 30, 0, 0, time.UTC)
+	account := MysqlAccount{
+		Host:                "%",
+		User:                "test",
+		AccountLocked:       boolPtr(false),
+		PasswordExpired:     boolPtr(true),
+		PasswordLastChanged: &lastChanged,
+		PasswordLifetime:    int64Ptr(90),
+		Plugin:              "mysql_native_password",
+	}
+
+	g.Expect(account.Attributes()).To(gomega.Equal(map[string]string{
+		AttributeHost:                "%",
+		AttributeAccountLocked:       "false",
+		AttributePasswordExpired:     "true",
+		AttributePasswordLastChanged: "2020-10-01T12:30:00Z",
+		AttributePasswordLifetime:    "90",
+		AttributeAuthPlugin:          "mysql_native_password",
+	}))
+
+	// Attributes the server doesn't have (e.g. MySQL 5.6) are left out.
+	account = MysqlAccount{
+		Host:   "localhost",
+		User:   "test",
+		Plugin: "mysql_native_password",
+	}
+
+	g.Expect(account.Attributes()).To(gomega.Equal(map[string]string{
+		AttributeHost:       "localhost",
+		AttributeAuthPlugin: "mysql_native_password",
+	}))
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mariadb"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/v5"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/v8"
 	"gitlab.com/grchive/grchive-v3/shared/test_utility"
@@ -18,7 +19,7 @@ func TestCreateMysqlConnectorv8(t *testing.T) {
 	g.Expect(conn).NotTo(gomega.BeNil())
 	g.Expect(conn.db).To(gomega.Equal(db))
 
-	_, ok := conn.users.(*v8.EtlMysqlV8ConnectorUser)
+	_, ok := conn.users.users.(*v8.EtlMysqlV8ConnectorUser)
 	g.Expect(ok).To(gomega.BeTrue())
 
 	g.Expect(conn.users).NotTo(gomega.BeNil())
@@ -35,10 +36,74 @@ func TestCreateMysqlConnectorv5(t *testing.T) {
 	g.Expect(conn).NotTo(gomega.BeNil())
 	g.Expect(conn.db).To(gomega.Equal(db))
 
-	_, ok := conn.users.(*v5.EtlMysqlV5ConnectorUser)
+	_, ok := conn.users.users.(*v5.EtlMysqlV5ConnectorUser)
 	g.Expect(ok).To(gomega.BeTrue())
 
 	g.Expect(conn.users).NotTo(gomega.BeNil())
 	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
+
+func TestCreateMysqlConnectorFlavors(t *testing.T) {
+	for _, test := range []struct {
+		version MysqlVersion
+		check   func(interface{}) bool
+	}{
+		{
+			version: MysqlVersion{MajorVersion: 10, MinorVersion: 5, Flavor: MysqlFlavorMariadb},
+			check: func(u interface{}) bool {
+				_, ok := u.(*mariadb.EtlMariadbConnectorUser)
+				return ok
+			},
+		},
+		{
+			version: MysqlVersion{MajorVersion: 5, MinorVersion: 5, Flavor: MysqlFlavorMariadb},
+			check: func(u interface{}) bool {
+				_, ok := u.(*mariadb.EtlMariadbConnectorUser)
+				return ok
+			},
+		},
+		{
+			version: MysqlVersion{MajorVersion: 5, MinorVersion: 6, Flavor: MysqlFlavorMysql},
+			check: func(u interface{}) bool {
+				_, ok := u.(*v5.EtlMysqlV5ConnectorUser)
+				return ok
+			},
+		},
+		{
+			version: MysqlVersion{MajorVersion: 5, MinorVersion: 7, Flavor: MysqlFlavorAurora},
+			check: func(u interface{}) bool {
+				_, ok := u.(*v5.EtlMysqlV5ConnectorUser)
+				return ok
+			},
+		},
+		{
+			version: MysqlVersion{MajorVersion: 8, MinorVersion: 0, Flavor: MysqlFlavorPercona},
+			check: func(u interface{}) bool {
+				_, ok := u.(*v8.EtlMysqlV8ConnectorUser)
+				return ok
+			},
+		},
+	} {
+		g := gomega.NewGomegaWithT(t)
+		db := &test_utility.FakeSqlx{}
+		conn, err := CreateMysqlConnector(db, test.version)
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(conn).NotTo(gomega.BeNil())
+		g.Expect(conn.users.version).To(gomega.Equal(test.version))
+		g.Expect(test.check(conn.users.users)).To(gomega.BeTrue(), "%+v", test.version)
+	}
+}
 
+func TestCreateMysqlConnectorUnsupported(t *testing.T) {
+	for _, version := range []MysqlVersion{
+		MysqlVersion{MajorVersion: 4, MinorVersion: 1, Flavor: MysqlFlavorMysql},
+		MysqlVersion{MajorVersion: 6, MinorVersion: 0, Flavor: MysqlFlavorMysql},
+		MysqlVersion{MajorVersion: 9, MinorVersion: 0, Flavor: MysqlFlavorMysql},
+	} {
+		g := gomega.NewGomegaWithT(t)
+		db := &test_utility.FakeSqlx{}
+		conn, err := CreateMysqlConnector(db, version)
+		g.Expect(err).NotTo(gomega.BeNil())
+		g.Expect(conn).To(gomega.BeNil())
+	}
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/onsi/gomega"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/account"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
 	"gitlab.com/grchive/grchive-v3/shared/test_utility"
 	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/databases/mysql_utility"
@@ -334,6 +335,7 @@ func TestGetUserListing(t *testing.T) {
 		// 3) Test table level privileges
 		// 4) Test column level privileges
 		// 5) Test process level privileges
+		// 6) Test account attributes and findings
 		container, db := mysql_utility.SetupMySQLDatabase(t, version, func(db *sqlx.DB) error {
 			tx := db.MustBegin()
 
@@ -568,6 +570,54 @@ func TestGetUserListing(t *testing.T) {
 				userCount += 1
 			}
 
+			// Setup test users for account attributes.
+			if _, err := tx.Exec(`
+				CREATE USER ''@'localhost'
+			`); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := tx.Exec(`
+				CREATE USER 'test_password'@'%' IDENTIFIED BY 'password'
+			`); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			refUsers["@localhost"] = &types.EtlUser{
+				Username: "@localhost",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name:        "Self",
+						Permissions: map[string][]string{},
+					},
+				},
+				Attributes: map[string]string{
+					account.AttributeHost: "localhost",
+				},
+				Findings: []*types.EtlFinding{
+					&types.EtlFinding{Code: account.FindingAnonymousUser},
+					&types.EtlFinding{Code: account.FindingEmptyPassword},
+				},
+			}
+
+			refUsers["test_password@%"] = &types.EtlUser{
+				Username: "test_password@%",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name:        "Self",
+						Permissions: map[string][]string{},
+					},
+				},
+				Attributes: map[string]string{
+					account.AttributeHost: "%",
+				},
+				Findings: []*types.EtlFinding{
+					&types.EtlFinding{Code: account.FindingWildcardHost},
+				},
+			}
+
 			return tx.Commit()
 		})
 		defer container.Terminate(ctx)
@@ -577,7 +627,7 @@ func TestGetUserListing(t *testing.T) {
 		}}
 		users, source, err := connector.GetUserListing()
 		g.Expect(err).To(gomega.BeNil())
-		g.Expect(len(source.Commands)).To(gomega.Equal(1))
+		g.Expect(len(source.Commands)).To(gomega.Equal(3))
 
 		opts := test_utility.CompareUserListingOptions{
 			PermissionObjectsToIgnore: []string{},
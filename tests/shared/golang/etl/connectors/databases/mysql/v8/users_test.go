@@ -7,6 +7,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/onsi/gomega"
 	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases/mysql/account"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
 	"gitlab.com/grchive/grchive-v3/shared/test_utility"
 	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/databases/mysql_utility"
@@ -371,6 +372,7 @@ func TestGetUserListing(t *testing.T) {
 		// 5) Test column level privileges
 		// 6) Test process level privileges
 		// 7) Test granting roles to users
+		// 8) Test account attributes and findings
 		container, db := mysql_utility.SetupMySQLDatabase(t, version, func(db *sqlx.DB) error {
 			tx := db.MustBegin()
 
@@ -732,6 +734,79 @@ func TestGetUserListing(t *testing.T) {
 				},
 			}
 
+			// Setup test users for account attributes.
+			if _, err := tx.Exec(`
+				CREATE USER ''@'localhost'
+			`); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := tx.Exec(`
+				CREATE USER 'test_password'@'%' IDENTIFIED BY 'password'
+			`); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			if _, err := tx.Exec(`
+				CREATE USER 'test_locked'@'localhost' IDENTIFIED BY 'password' PASSWORD EXPIRE INTERVAL 90 DAY ACCOUNT LOCK
+			`); err != nil {
+				tx.Rollback()
+				return err
+			}
+
+			refUsers["@localhost"] = &types.EtlUser{
+				Username: "@localhost",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name:        "Self",
+						Permissions: map[string][]string{},
+					},
+				},
+				Attributes: map[string]string{
+					account.AttributeHost: "localhost",
+				},
+				Findings: []*types.EtlFinding{
+					&types.EtlFinding{Code: account.FindingAnonymousUser},
+					&types.EtlFinding{Code: account.FindingEmptyPassword},
+				},
+			}
+
+			refUsers["test_password@%"] = &types.EtlUser{
+				Username: "test_password@%",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name:        "Self",
+						Permissions: map[string][]string{},
+					},
+				},
+				Attributes: map[string]string{
+					account.AttributeHost: "%",
+				},
+				Findings: []*types.EtlFinding{
+					&types.EtlFinding{Code: account.FindingWildcardHost},
+				},
+			}
+
+			refUsers["test_locked@localhost"] = &types.EtlUser{
+				Username: "test_locked@localhost",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name:        "Self",
+						Permissions: map[string][]string{},
+					},
+				},
+				Attributes: map[string]string{
+					account.AttributeHost:             "localhost",
+					account.AttributeAccountLocked:    "true",
+					account.AttributePasswordExpired:  "false",
+					account.AttributePasswordLifetime: "90",
+					account.AttributeAuthPlugin:       "caching_sha2_password",
+				},
+				Findings: []*types.EtlFinding{},
+			}
+
 			return tx.Commit()
 		})
 		defer container.Terminate(ctx)
@@ -741,7 +816,7 @@ func TestGetUserListing(t *testing.T) {
 		}}
 		users, source, err := connector.GetUserListing()
 		g.Expect(err).To(gomega.BeNil())
-		g.Expect(len(source.Commands)).To(gomega.Equal(2))
+		g.Expect(len(source.Commands)).To(gomega.Equal(4))
 
 		test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{
 			UsersToIgnore:             []string{"root@%", "root@localhost", "mysql.sys@localhost", "mysql.infoschema@localhost", "mysql.session@localhost"},
@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestParseMysqlVersion(t *testing.T) {
+	for _, test := range []struct {
+		raw           string
+		comment       string
+		auroraVersion string
+		ref           MysqlVersion
+	}{
+		{
+			raw:     "5.6.49",
+			comment: "MySQL Community Server (GPL)",
+			ref:     MysqlVersion{MajorVersion: 5, MinorVersion: 6, AuxVersion: "49", Flavor: MysqlFlavorMysql},
+		},
+		{
+			raw:     "5.7.31-log",
+			comment: "MySQL Community Server (GPL)",
+			ref:     MysqlVersion{MajorVersion: 5, MinorVersion: 7, AuxVersion: "31-log", Flavor: MysqlFlavorMysql},
+		},
+		{
+			raw:     "8.0.21",
+			comment: "MySQL Community Server - GPL",
+			ref:     MysqlVersion{MajorVersion: 8, MinorVersion: 0, AuxVersion: "21", Flavor: MysqlFlavorMysql},
+		},
+		{
+			raw:     "10.5.8-MariaDB-1:10.5.8+maria~focal",
+			comment: "mariadb.org binary distribution",
+			ref:     MysqlVersion{MajorVersion: 10, MinorVersion: 5, AuxVersion: "8-MariaDB-1:10.5.8+maria~focal", Flavor: MysqlFlavorMariadb},
+		},
+		{
+			raw:     "5.5.5-10.3.27-MariaDB-log",
+			comment: "Source distribution",
+			ref:     MysqlVersion{MajorVersion: 10, MinorVersion: 3, AuxVersion: "27-MariaDB-log", Flavor: MysqlFlavorMariadb},
+		},
+		{
+			raw:     "5.7.mysql_aurora.2.10.2",
+			comment: "Source distribution",
+			ref:     MysqlVersion{MajorVersion: 5, MinorVersion: 7, AuxVersion: "mysql_aurora.2.10.2", Flavor: MysqlFlavorAurora},
+		},
+		{
+			raw:           "8.0.23",
+			comment:       "Source distribution",
+			auroraVersion: "3.02.0",
+			ref:           MysqlVersion{MajorVersion: 8, MinorVersion: 0, AuxVersion: "23", Flavor: MysqlFlavorAurora},
+		},
+		{
+			raw:     "8.0.22-13",
+			comment: "Percona Server (GPL), Release 13, Revision 6f7822f",
+			ref:     MysqlVersion{MajorVersion: 8, MinorVersion: 0, AuxVersion: "22-13", Flavor: MysqlFlavorPercona},
+		},
+		{
+			raw:     "5.6",
+			comment: "",
+			ref:     MysqlVersion{MajorVersion: 5, MinorVersion: 6, AuxVersion: "", Flavor: MysqlFlavorMysql},
+		},
+	} {
+		g := gomega.NewGomegaWithT(t)
+		version, err := ParseMysqlVersion(test.raw, test.comment, test.auroraVersion)
+		g.Expect(err).To(gomega.BeNil())
+
+		test.ref.Raw = test.raw
+		test.ref.Comment = test.comment
+		test.ref.AuroraVersion = test.auroraVersion
+		g.Expect(*version).To(gomega.Equal(test.ref))
+	}
+}
+
+func TestParseMysqlVersionInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"abc",
+		"8",
+	} {
+		g := gomega.NewGomegaWithT(t)
+		_, err := ParseMysqlVersion(raw, "", "")
+		g.Expect(err).NotTo(gomega.BeNil())
+	}
+}
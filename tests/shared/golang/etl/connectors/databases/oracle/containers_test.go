@@ -0,0 +1,95 @@
+package oracle
+
+import (
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+// fakeOraErr mimics the *OraErr errors returned by godror.
+type fakeOraErr struct {
+	code int
+}
+
+func (e fakeOraErr) Code() int { return e.code }
+func (e fakeOraErr) Error() string {
+	return fmt.Sprintf("ORA-%05d", e.code)
+}
+
+func TestDetectContainers(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	mock.ExpectQuery(`SYS_CONTEXT\('USERENV', 'CON_ID'\)`).WillReturnRows(
+		sqlmock.NewRows([]string{"CON_ID", "NAME"}).AddRow("1", "CDB$ROOT"),
+	)
+	mock.ExpectQuery(`FROM V\$CONTAINERS`).WithArgs(pdbSeedName).WillReturnRows(
+		sqlmock.NewRows([]string{"CON_ID", "NAME"}).
+			AddRow(3, "ORCLPDB1").
+			AddRow(1, "CDB$ROOT"),
+	)
+
+	conn, err := CreateOracleConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	views, containers, source, err := conn.users.detectContainers()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+	g.Expect(views).To(gomega.Equal(oracleViews{
+		cdb:          true,
+		currentConId: 1,
+		multitenant:  true,
+	}))
+	g.Expect(containers).To(gomega.Equal([]oracleContainer{
+		{Id: 1, Name: "CDB$ROOT"},
+		{Id: 3, Name: "ORCLPDB1"},
+	}))
+	g.Expect(len(source.Commands)).To(gomega.Equal(2))
+}
+
+func TestDetectContainersPre12c(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	mock.ExpectQuery(`SYS_CONTEXT\('USERENV', 'CON_ID'\)`).WillReturnError(fakeOraErr{code: 2003})
+
+	conn, err := CreateOracleConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	views, containers, _, err := conn.users.detectContainers()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+	g.Expect(views).To(gomega.Equal(oracleViews{}))
+	g.Expect(containers).To(gomega.Equal([]oracleContainer{{}}))
+}
+
+func TestDetectContainersError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	for _, queryErr := range []error{
+		// ORA-03113: end-of-file on communication channel
+		fakeOraErr{code: 3113},
+		errors.New("driver: bad connection"),
+	} {
+		db, mock, err := sqlmock.New()
+		g.Expect(err).To(gomega.BeNil())
+
+		mock.ExpectQuery(`SYS_CONTEXT\('USERENV', 'CON_ID'\)`).WillReturnError(queryErr)
+
+		conn, err := CreateOracleConnector(sqlx.NewDb(db, "sqlmock"))
+		g.Expect(err).To(gomega.BeNil())
+
+		_, _, _, err = conn.users.detectContainers()
+		g.Expect(err).NotTo(gomega.BeNil())
+		g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+		db.Close()
+	}
+}
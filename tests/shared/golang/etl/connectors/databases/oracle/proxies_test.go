@@ -0,0 +1,96 @@
+package oracle
+
+import (
+	"database/sql"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"testing"
+)
+
+func TestCreateProxyRoles(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	roles := createProxyRoles([]oracleProxy{
+		{
+			Proxy:                   "PROXY1",
+			Client:                  "CLIENT1",
+			AuthorizationConstraint: sql.NullString{String: proxyMayActivateAllRoles, Valid: true},
+		},
+		{
+			Proxy:                   "PROXY1",
+			Client:                  "CLIENT2",
+			AuthorizationConstraint: sql.NullString{String: proxyMayActivateRole, Valid: true},
+			Role:                    sql.NullString{String: "ROLE1", Valid: true},
+		},
+		{
+			Proxy:                   "PROXY1",
+			Client:                  "CLIENT2",
+			AuthorizationConstraint: sql.NullString{String: proxyMayActivateRole, Valid: true},
+			Role:                    sql.NullString{String: "ROLE2", Valid: true},
+		},
+		{
+			Proxy:                   "PROXY2",
+			Client:                  "CLIENT1",
+			AuthorizationConstraint: sql.NullString{String: proxyMayNotActivateRole, Valid: true},
+			Role:                    sql.NullString{String: "ROLE3", Valid: true},
+		},
+		{
+			Proxy:                   "PROXY3",
+			Client:                  "CLIENT1",
+			AuthorizationConstraint: sql.NullString{String: "NO CLIENT ROLES MAY BE ACTIVATED", Valid: true},
+		},
+	})
+
+	g.Expect(roles).To(gomega.Equal(map[string]*types.EtlRole{
+		"PROXY1": &types.EtlRole{
+			Name: proxyRoleName,
+			Permissions: types.PermissionMap{
+				"USER::CLIENT1": []string{"CONNECT THROUGH", "ACTIVATE ALL ROLES"},
+				"USER::CLIENT2": []string{"CONNECT THROUGH", "ACTIVATE ROLE ROLE1", "ACTIVATE ROLE ROLE2"},
+			},
+			Denied: types.PermissionMap{},
+		},
+		"PROXY2": &types.EtlRole{
+			Name: proxyRoleName,
+			Permissions: types.PermissionMap{
+				"USER::CLIENT1": []string{"CONNECT THROUGH"},
+			},
+			Denied: types.PermissionMap{
+				"USER::CLIENT1": []string{"ACTIVATE ROLE ROLE3"},
+			},
+		},
+		"PROXY3": &types.EtlRole{
+			Name: proxyRoleName,
+			Permissions: types.PermissionMap{
+				"USER::CLIENT1": []string{"CONNECT THROUGH"},
+			},
+			Denied: types.PermissionMap{},
+		},
+	}))
+}
+
+func TestProfileLimits(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	listing := oracleContainerListing{
+		profiles: map[string]map[string]string{
+			"DEFAULT": map[string]string{
+				"FAILED_LOGIN_ATTEMPTS": "10",
+				"PASSWORD_LIFE_TIME":    "180",
+				"PASSWORD_REUSE_MAX":    "UNLIMITED",
+			},
+			"TEST": map[string]string{
+				"FAILED_LOGIN_ATTEMPTS": "3",
+				"PASSWORD_LIFE_TIME":    "DEFAULT",
+				"PASSWORD_REUSE_MAX":    "DEFAULT",
+			},
+		},
+	}
+
+	g.Expect(listing.profileLimits("TEST")).To(gomega.Equal(map[string]string{
+		"FAILED_LOGIN_ATTEMPTS": "3",
+		"PASSWORD_LIFE_TIME":    "180",
+		"PASSWORD_REUSE_MAX":    "UNLIMITED",
+	}))
+	g.Expect(listing.profileLimits("MISSING")).To(gomega.BeEmpty())
+}
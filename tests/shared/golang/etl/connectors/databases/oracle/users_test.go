@@ -130,6 +130,30 @@ var v12cUsers = []string{
 	"SPATIAL_CSW_ADMIN_USR",
 }
 
+const testPdb = "ORCLPDB1"
+
+// Local users created in the pluggable database by the image.
+var pdbUsers = []string{
+	testPdb + "::PDBADMIN",
+}
+
+// commonUserInPdb returns how a common user created in the root shows up in the pluggable database.
+func commonUserInPdb(username string, created *time.Time) map[string]*types.EtlUser {
+	pdbUsername := testPdb + "::" + username
+	return map[string]*types.EtlUser{
+		pdbUsername: &types.EtlUser{
+			Username:    pdbUsername,
+			CreatedTime: created,
+			Roles: map[string]*types.EtlRole{
+				pdbUsername: &types.EtlRole{
+					Name:        pdbUsername,
+					Permissions: types.PermissionMap{},
+				},
+			},
+		},
+	}
+}
+
 func TestGetUserListing(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 	for _, test := range []struct {
@@ -159,6 +183,9 @@ func TestGetUserListing(t *testing.T) {
 		// 4) User/Role: System privileges
 		// 5) User/Role: Table privileges
 		// 6) User/Role: Column privileges
+		// 7) Profile password limits
+		// 8) Proxy users
+		// 9) Local user in a pluggable database
 		container, db := oracle_utility.SetupOracleDatabase(t, test.Version, func(db *sqlx.DB) error {
 			tx := db.MustBegin()
 
@@ -173,6 +200,7 @@ func TestGetUserListing(t *testing.T) {
 				expectedUsers["C##TEST1"] = &types.EtlUser{
 					Username:    "C##TEST1",
 					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST1", &now),
 					Roles: map[string]*types.EtlRole{
 						"C##TEST1": &types.EtlRole{
 							Name:        "C##TEST1",
@@ -205,6 +233,7 @@ func TestGetUserListing(t *testing.T) {
 				expectedUsers["C##TEST2"] = &types.EtlUser{
 					Username:    "C##TEST2",
 					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST2", &now),
 					Roles: map[string]*types.EtlRole{
 						"C##TEST2": &types.EtlRole{
 							Name:        "C##TEST2",
@@ -253,6 +282,7 @@ func TestGetUserListing(t *testing.T) {
 				expectedUsers["C##TEST3"] = &types.EtlUser{
 					Username:    "C##TEST3",
 					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST3", &now),
 					Roles: map[string]*types.EtlRole{
 						"C##TEST3": &types.EtlRole{
 							Name:        "C##TEST3",
@@ -305,6 +335,7 @@ func TestGetUserListing(t *testing.T) {
 				expectedUsers["C##TEST4"] = &types.EtlUser{
 					Username:    "C##TEST4",
 					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST4", &now),
 					Roles: map[string]*types.EtlRole{
 						"C##TEST4": &types.EtlRole{
 							Name: "C##TEST4",
@@ -363,6 +394,7 @@ func TestGetUserListing(t *testing.T) {
 				expectedUsers["C##TEST5"] = &types.EtlUser{
 					Username:    "C##TEST5",
 					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST5", &now),
 					Roles: map[string]*types.EtlRole{
 						"C##TEST5": &types.EtlRole{
 							Name: "C##TEST5",
@@ -421,6 +453,7 @@ func TestGetUserListing(t *testing.T) {
 				expectedUsers["C##TEST6"] = &types.EtlUser{
 					Username:    "C##TEST6",
 					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST6", &now),
 					Roles: map[string]*types.EtlRole{
 						"C##TEST6": &types.EtlRole{
 							Name: "C##TEST6",
@@ -438,6 +471,133 @@ func TestGetUserListing(t *testing.T) {
 				}
 			}
 
+			{
+				now := time.Now()
+				_, err := tx.Exec(`CREATE PROFILE C##TESTPROFILE7 LIMIT FAILED_LOGIN_ATTEMPTS 3 PASSWORD_LIFE_TIME 30`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`CREATE USER C##TEST7 IDENTIFIED BY password1 PROFILE C##TESTPROFILE7`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`ALTER PROFILE DEFAULT LIMIT PASSWORD_REUSE_MAX 5`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				expectedUsers["C##TEST7"] = &types.EtlUser{
+					Username:    "C##TEST7",
+					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST7", &now),
+					Roles: map[string]*types.EtlRole{
+						"C##TEST7": &types.EtlRole{
+							Name:        "C##TEST7",
+							Permissions: types.PermissionMap{},
+						},
+					},
+					Attributes: map[string]string{
+						AttributeConId:          "1",
+						AttributeContainer:      "CDB$ROOT",
+						AttributeCommon:         "true",
+						AttributeAccountStatus:  "OPEN",
+						AttributeProfile:        "C##TESTPROFILE7",
+						"failed_login_attempts": "3",
+						"password_life_time":    "30",
+						"password_reuse_max":    "5",
+					},
+				}
+			}
+
+			{
+				now := time.Now()
+				_, err := tx.Exec(`CREATE USER C##TEST8 IDENTIFIED BY password1`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`ALTER USER C##TEST1 GRANT CONNECT THROUGH C##TEST8`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`ALTER USER C##TEST2 GRANT CONNECT THROUGH C##TEST8 WITH ROLE C##TESTROLE1`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				expectedUsers["C##TEST8"] = &types.EtlUser{
+					Username:    "C##TEST8",
+					CreatedTime: &now,
+					NestedUsers: commonUserInPdb("C##TEST8", &now),
+					Roles: map[string]*types.EtlRole{
+						"C##TEST8": &types.EtlRole{
+							Name:        "C##TEST8",
+							Permissions: types.PermissionMap{},
+						},
+						"Proxy Authentication": &types.EtlRole{
+							Name: "Proxy Authentication",
+							Permissions: types.PermissionMap{
+								"USER::C##TEST1": []string{"CONNECT THROUGH", "ACTIVATE ALL ROLES"},
+								"USER::C##TEST2": []string{"CONNECT THROUGH", "ACTIVATE ROLE C##TESTROLE1"},
+							},
+						},
+					},
+				}
+			}
+
+			{
+				now := time.Now()
+				_, err := tx.Exec(`ALTER SESSION SET CONTAINER = ` + testPdb)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`CREATE USER TEST9 IDENTIFIED BY password1`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`GRANT CREATE SESSION TO TEST9`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				_, err = tx.Exec(`ALTER SESSION SET CONTAINER = CDB$ROOT`)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				expectedUsers[testPdb+"::TEST9"] = &types.EtlUser{
+					Username:    testPdb + "::TEST9",
+					CreatedTime: &now,
+					Roles: map[string]*types.EtlRole{
+						testPdb + "::TEST9": &types.EtlRole{
+							Name: testPdb + "::TEST9",
+							Permissions: types.PermissionMap{
+								"SYSTEM": []string{"CREATE SESSION"},
+							},
+						},
+					},
+					Attributes: map[string]string{
+						AttributeContainer: testPdb,
+						AttributeCommon:    "false",
+					},
+				}
+			}
+
 			return tx.Commit()
 		})
 
@@ -453,7 +613,7 @@ func TestGetUserListing(t *testing.T) {
 			t.Error(err)
 		}
 		test_utility.CompareUserListing(g, users, expectedUsers, test_utility.CompareUserListingOptions{
-			UsersToIgnore:             test.SystemUsers,
+			UsersToIgnore:             append(test.SystemUsers, pdbUsers...),
 			PermissionObjectsToIgnore: []string{},
 		})
 	}
@@ -2,6 +2,7 @@ package psql
 
 import (
 	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/connectors/databases"
 	"gitlab.com/grchive/grchive-v3/shared/test_utility"
 	"testing"
 )
@@ -18,3 +19,25 @@ func TestCreatePsqlConnector(t *testing.T) {
 	g.Expect(conn.users.db.SqlxLike).To(gomega.Equal(db))
 	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
 }
+
+func TestCreatePsqlClusterConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	db := &test_utility.FakeSqlx{}
+	connected := []string{}
+	conn, err := CreatePsqlClusterConnector(db, func(database string) (databases.SqlxLike, error) {
+		connected = append(connected, database)
+		return db, nil
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.db).To(gomega.Equal(db))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.db.SqlxLike).To(gomega.Equal(db))
+	g.Expect(conn.users.connect).NotTo(gomega.BeNil())
+
+	other, err := conn.users.connect("test")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(other).To(gomega.Equal(db))
+	g.Expect(connected).To(gomega.Equal([]string{"test"}))
+}
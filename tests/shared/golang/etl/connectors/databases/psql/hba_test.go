@@ -0,0 +1,77 @@
+package psql
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestGetHbaRules(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM pg_hba_file_rules`).WillReturnRows(
+		sqlmock.NewRows([]string{"LineNumber", "Type", "Databases", "Users", "Address", "Netmask", "AuthMethod"}).
+			AddRow(84, "local", "all", "postgres", "", "", "peer").
+			AddRow(89, "host", "all", "all", "10.0.0.0", "255.0.0.0", "scram-sha-256"),
+	)
+
+	conn, err := CreatePsqlConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	rules, source, err := conn.users.getHbaRules()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+	g.Expect(source.Commands).To(gomega.HaveLen(1))
+	g.Expect(rules).To(gomega.HaveLen(2))
+	g.Expect(rules[1].AuthMethod).To(gomega.Equal("scram-sha-256"))
+}
+
+func TestGetHbaRulesUnavailable(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, code := range []pq.ErrorCode{"42501", "42P01"} {
+		db, mock, err := sqlmock.New()
+		g.Expect(err).To(gomega.BeNil())
+
+		mock.ExpectQuery(`FROM pg_hba_file_rules`).WillReturnError(&pq.Error{Code: code})
+
+		conn, err := CreatePsqlConnector(sqlx.NewDb(db, "sqlmock"))
+		g.Expect(err).To(gomega.BeNil())
+
+		rules, source, err := conn.users.getHbaRules()
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+		g.Expect(source.Commands).To(gomega.BeEmpty())
+		g.Expect(rules).To(gomega.BeEmpty())
+		db.Close()
+	}
+}
+
+func TestGetHbaRulesError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, queryErr := range []error{
+		errors.New("driver: bad connection"),
+		&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"},
+	} {
+		db, mock, err := sqlmock.New()
+		g.Expect(err).To(gomega.BeNil())
+
+		mock.ExpectQuery(`FROM pg_hba_file_rules`).WillReturnError(queryErr)
+
+		conn, err := CreatePsqlConnector(sqlx.NewDb(db, "sqlmock"))
+		g.Expect(err).To(gomega.BeNil())
+
+		_, _, err = conn.users.getHbaRules()
+		g.Expect(err).To(gomega.Equal(queryErr))
+		g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+		db.Close()
+	}
+}
@@ -35,15 +35,10 @@ func SetupPostgreSQLDatabase(t *testing.T, version string, fns ...InitFunction)
 		t.Error(err)
 	}
 
-	endpoint, err := container.Endpoint(ctx, "")
-	if err != nil {
-		t.Error(err)
-	}
-
 	var db *sqlx.DB
 
 	for {
-		db, err = sqlx.Connect("postgres", fmt.Sprintf("postgres://postgres:password@%s?sslmode=disable", endpoint))
+		db, err = ConnectPostgreSQLDatabase(container, "postgres")
 		if err == nil {
 			break
 		}
@@ -59,3 +54,13 @@ func SetupPostgreSQLDatabase(t *testing.T, version string, fns ...InitFunction)
 
 	return container, db
 }
+
+// ConnectPostgreSQLDatabase opens a new connection to the given database in the container.
+func ConnectPostgreSQLDatabase(container testcontainers.Container, database string) (*sqlx.DB, error) {
+	endpoint, err := container.Endpoint(context.Background(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlx.Connect("postgres", fmt.Sprintf("postgres://postgres:password@%s/%s?sslmode=disable", endpoint, database))
+}
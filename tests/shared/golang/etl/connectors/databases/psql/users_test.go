@@ -1,3 +1,4 @@
+//go:build !unit
 // +build !unit
 
 package psql
@@ -10,6 +11,7 @@ import (
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
 	"gitlab.com/grchive/grchive-v3/shared/test_utility"
 	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/databases/psql_utility"
+	"strings"
 	"testing"
 )
 
@@ -35,6 +37,13 @@ func TestGetUserListing(t *testing.T) {
 		// 	6) Test granting USAGE privileges to COLLATION/DOMAIN/FOREIGN DATA WRAPPER/FOREIGN SERVER/SEQUENCE.
 		// 	7) Test being able to identify and flatten multi-level inheritance (e.g. A -> B -> C should in our case should that C
 		// 	   inherits from C and C also inherits from A).
+		// 	8) Test granting CONNECT/CREATE/TEMPORARY privileges to databases.
+		// 	9) Test granting USAGE/CREATE privileges to schemas.
+		// 	10) Test ALTER DEFAULT PRIVILEGES.
+		// 	11) Test row level security policies.
+		// 	12) Test object ownership.
+		// 	13) Test grants in a database other than the one we're connected to.
+		// 	Also test that CREATE ROLE ... BYPASSRLS results in the proper flag being set.
 		container, db := psql_utility.SetupPostgreSQLDatabase(t, version, func(db *sqlx.DB) error {
 			tx := db.MustBegin()
 
@@ -77,6 +86,7 @@ func TestGetUserListing(t *testing.T) {
 						CREATEDB
 						CREATEROLE
 						REPLICATION
+						BYPASSRLS
 				`); err != nil {
 					tx.Rollback()
 					return err
@@ -187,10 +197,158 @@ func TestGetUserListing(t *testing.T) {
 				}
 			}
 
+			// Test Case #8
+			{
+				if _, err := tx.Exec(`
+					CREATE ROLE test_user_8 LOGIN PASSWORD NULL
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					GRANT CONNECT, CREATE, TEMPORARY ON DATABASE postgres TO test_user_8
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
+			// Test Case #9
+			{
+				if _, err := tx.Exec(`
+					CREATE ROLE test_user_9 LOGIN PASSWORD NULL
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					CREATE SCHEMA test_schema_9
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					GRANT USAGE, CREATE ON SCHEMA test_schema_9 TO test_user_9
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
+			// Test Case #10
+			{
+				if _, err := tx.Exec(`
+					CREATE ROLE test_user_10 LOGIN PASSWORD NULL
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					ALTER DEFAULT PRIVILEGES IN SCHEMA test_schema_9 GRANT SELECT ON TABLES TO test_user_10
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
+			// Test Case #11
+			{
+				if _, err := tx.Exec(`
+					CREATE ROLE test_user_11 LOGIN PASSWORD NULL
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					CREATE TABLE test_rls (
+						id BIGINT
+					)
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					ALTER TABLE test_rls ENABLE ROW LEVEL SECURITY
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					CREATE POLICY test_policy ON test_rls FOR SELECT TO test_user_11 USING (true)
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
+			// Test Case #12
+			{
+				if _, err := tx.Exec(`
+					CREATE ROLE test_user_12 LOGIN PASSWORD NULL
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					CREATE TABLE test_owned (
+						id BIGINT
+					)
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+
+				if _, err := tx.Exec(`
+					ALTER TABLE test_owned OWNER TO test_user_12
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
+			// Test Case #13 - The grant is done in another database after the transaction.
+			{
+				if _, err := tx.Exec(`
+					CREATE ROLE test_user_13 LOGIN PASSWORD NULL
+				`); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
 			return tx.Commit()
+		}, func(db *sqlx.DB) error {
+			// CREATE DATABASE can't be run inside of a transaction.
+			_, err := db.Exec(`CREATE DATABASE test_db_13`)
+			return err
 		})
 		defer container.Terminate(ctx)
 
+		{
+			otherDb, err := psql_utility.ConnectPostgreSQLDatabase(container, "test_db_13")
+			if err != nil {
+				t.Error(err)
+			}
+
+			for _, stmt := range []string{
+				`CREATE TABLE test_table_13 ( id BIGINT )`,
+				`GRANT SELECT ON test_table_13 TO test_user_13`,
+			} {
+				if _, err := otherDb.Exec(stmt); err != nil {
+					t.Error(err)
+				}
+			}
+			otherDb.Close()
+		}
+
 		expectedUsers := map[string]*types.EtlUser{
 			"test_user_1": &types.EtlUser{
 				Username: "test_user_1",
@@ -224,7 +382,7 @@ func TestGetUserListing(t *testing.T) {
 					"Self": &types.EtlRole{
 						Name: "Self",
 						Permissions: map[string][]string{
-							"Self": []string{PsqlSuperPermission, PsqlCreateRolePermission, PsqlCreateDbPermission, PsqlReplicationPermission},
+							"Self": []string{PsqlSuperPermission, PsqlCreateRolePermission, PsqlCreateDbPermission, PsqlReplicationPermission, PsqlBypassRlsPermission},
 						},
 					},
 				},
@@ -306,6 +464,80 @@ func TestGetUserListing(t *testing.T) {
 					},
 				},
 			},
+			"test_user_8": &types.EtlUser{
+				Username: "test_user_8",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name: "Self",
+						Permissions: map[string][]string{
+							"Self":               []string{},
+							"DATABASE::postgres": []string{"CONNECT", "CREATE", "TEMPORARY"},
+						},
+					},
+				},
+			},
+			"test_user_9": &types.EtlUser{
+				Username: "test_user_9",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name: "Self",
+						Permissions: map[string][]string{
+							"Self":                           []string{},
+							"SCHEMA::postgres.test_schema_9": []string{"USAGE", "CREATE"},
+						},
+					},
+				},
+			},
+			"test_user_10": &types.EtlUser{
+				Username: "test_user_10",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name: "Self",
+						Permissions: map[string][]string{
+							"Self": []string{},
+							"DEFAULT::postgres.test_schema_9.TABLES::postgres": []string{"SELECT"},
+						},
+					},
+				},
+			},
+			"test_user_11": &types.EtlUser{
+				Username: "test_user_11",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name: "Self",
+						Permissions: map[string][]string{
+							"Self": []string{},
+							"POLICY::postgres.public.test_rls.test_policy": []string{"SELECT"},
+						},
+					},
+				},
+			},
+			"test_user_12": &types.EtlUser{
+				Username: "test_user_12",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name: "Self",
+						Permissions: map[string][]string{
+							"Self":                                  []string{},
+							"TBL::postgres.public.test_owned":       []string{PsqlOwnerPermission, "SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+							"COLUMN::postgres.public.test_owned.id": []string{"SELECT", "INSERT", "UPDATE", "REFERENCES"},
+						},
+					},
+				},
+			},
+			"test_user_13": &types.EtlUser{
+				Username: "test_user_13",
+				Roles: map[string]*types.EtlRole{
+					"Self": &types.EtlRole{
+						Name: "Self",
+						Permissions: map[string][]string{
+							"Self":                                 []string{},
+							"TBL::test_db_13.public.test_table_13": []string{"SELECT"},
+							"COLUMN::test_db_13.public.test_table_13.id": []string{"SELECT"},
+						},
+					},
+				},
+			},
 		}
 
 		connector, err := createPsqlConnectorUser(&databases.DB{SqlxLike: db})
@@ -313,10 +545,25 @@ func TestGetUserListing(t *testing.T) {
 			t.Error(err)
 		}
 
+		connector.connect = func(database string) (databases.SqlxLike, error) {
+			return psql_utility.ConnectPostgreSQLDatabase(container, database)
+		}
+
 		users, _, err := connector.GetUserListing()
 		if err != nil {
 			t.Error(err)
 		}
+
+		// The pg_hba.conf rules are only readable in PostgreSQL 10+ and depend on the image's configuration so only
+		// check that they're there.
+		for _, u := range users {
+			if u.Username == "test_user_1" && !strings.HasPrefix(version, "9.") {
+				g.Expect(u.Roles).To(gomega.HaveKey(PsqlHbaRole))
+				g.Expect(len(u.Roles[PsqlHbaRole].Permissions)).To(gomega.BeNumerically(">", 0))
+			}
+			delete(u.Roles, PsqlHbaRole)
+		}
+
 		test_utility.CompareUserListing(g, users, expectedUsers, test_utility.CompareUserListingOptions{
 			UsersToIgnore:             []string{"postgres"},
 			PermissionObjectsToIgnore: []string{"information_schema", "pg_system"},
@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSplitAclRules(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	rules, err := splitAclRules("user alice on  #abc ~app:* (~cache:* +get) -@all")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(rules).To(gomega.Equal([]string{"user", "alice", "on", "#abc", "~app:*", "(~cache:* +get)", "-@all"}))
+
+	_, err = splitAclRules("user alice on (~cache:* +get")
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = splitAclRules("user alice on ~cache:*)")
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestParseAclListLine(t *testing.T) {
+	for _, test := range []struct {
+		line          string
+		name          string
+		enabled       bool
+		noPass        bool
+		passwordCount int
+		permissions   map[string][]string
+		denied        map[string][]string
+		selectors     []map[string][]string
+	}{
+		{
+			line:    "user default on nopass ~* &* +@all",
+			name:    "default",
+			enabled: true,
+			noPass:  true,
+			permissions: map[string][]string{
+				"KEY::*":        []string{RedisReadPermission, RedisWritePermission},
+				"CHANNEL::*":    []string{RedisChannelPermission},
+				"COMMAND::@all": []string{RedisExecutePermission},
+			},
+			denied: map[string][]string{},
+		},
+		{
+			line:          "user app on #5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8 #6b86b273ff34fce19673b1a2bd4d7a4f3e6d4e5d ~app:* resetchannels -@all +@read +set -keys",
+			name:          "app",
+			enabled:       true,
+			passwordCount: 2,
+			permissions: map[string][]string{
+				"KEY::app:*":     []string{RedisReadPermission, RedisWritePermission},
+				"COMMAND::@read": []string{RedisExecutePermission},
+				"COMMAND::set":   []string{RedisExecutePermission},
+			},
+			denied: map[string][]string{
+				"COMMAND::@all": []string{RedisExecutePermission},
+				"COMMAND::keys": []string{RedisExecutePermission},
+			},
+		},
+		{
+			// Later rules override earlier ones and +@all drops every earlier command rule.
+			line: "user ops off resetpass allkeys resetkeys %R~logs:* %W~queue:* %RW~tmp:* allchannels resetchannels &events:* -flushall +@all -flushall +flushall +FLUSHDB",
+			name: "ops",
+			permissions: map[string][]string{
+				"KEY::logs:*":       []string{RedisReadPermission},
+				"KEY::queue:*":      []string{RedisWritePermission},
+				"KEY::tmp:*":        []string{RedisReadPermission, RedisWritePermission},
+				"CHANNEL::events:*": []string{RedisChannelPermission},
+				"COMMAND::@all":     []string{RedisExecutePermission},
+				"COMMAND::flushall": []string{RedisExecutePermission},
+				"COMMAND::flushdb":  []string{RedisExecutePermission},
+			},
+			denied: map[string][]string{},
+		},
+		{
+			line:          "user reader on #abc ~reports:* resetchannels -@all +get (~cache:* resetchannels -@all +get +mget) (%R~audit:* -@all +@read)",
+			name:          "reader",
+			enabled:       true,
+			passwordCount: 1,
+			permissions: map[string][]string{
+				"KEY::reports:*": []string{RedisReadPermission, RedisWritePermission},
+				"COMMAND::get":   []string{RedisExecutePermission},
+			},
+			denied: map[string][]string{
+				"COMMAND::@all": []string{RedisExecutePermission},
+			},
+			selectors: []map[string][]string{
+				{
+					"KEY::cache:*":  []string{RedisReadPermission, RedisWritePermission},
+					"COMMAND::get":  []string{RedisExecutePermission},
+					"COMMAND::mget": []string{RedisExecutePermission},
+				},
+				{
+					"KEY::audit:*":   []string{RedisReadPermission},
+					"COMMAND::@read": []string{RedisExecutePermission},
+				},
+			},
+		},
+	} {
+		g := gomega.NewGomegaWithT(t)
+
+		user, err := parseAclListLine(test.line)
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(user.Name).To(gomega.Equal(test.name))
+		g.Expect(user.Enabled).To(gomega.Equal(test.enabled))
+		g.Expect(user.NoPass).To(gomega.Equal(test.noPass))
+		g.Expect(user.PasswordCount).To(gomega.Equal(test.passwordCount))
+		g.Expect(user.Rules.role.Name).To(gomega.Equal("Self"))
+		g.Expect(user.Rules.role.Permissions).To(gomega.Equal(test.permissions))
+		g.Expect(user.Rules.role.Denied).To(gomega.Equal(test.denied))
+
+		g.Expect(len(user.Selectors)).To(gomega.Equal(len(test.selectors)))
+		for i, s := range test.selectors {
+			g.Expect(user.Selectors[i].role.Permissions).To(gomega.Equal(s))
+		}
+	}
+}
+
+func TestParseAclListLineErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := parseAclListLine("default on nopass")
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = parseAclListLine("user")
+	g.Expect(err).NotTo(gomega.BeNil())
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"bufio"
+	"github.com/onsi/gomega"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedisServer replies to every command it receives with the next reply.
+func fakeRedisServer(g *gomega.GomegaWithT, replies []string) (net.Listener, chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(gomega.BeNil())
+
+	commands := make(chan []string, len(replies))
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for _, reply := range replies {
+			command, err := readRespReply(reader)
+			if err != nil {
+				return
+			}
+
+			args := []string{}
+			for _, a := range command.([]interface{}) {
+				args = append(args, a.(string))
+			}
+			commands <- args
+
+			conn.Write([]byte(reply))
+		}
+	}()
+
+	return listener, commands
+}
+
+func TestRedisClient(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	listener, commands := fakeRedisServer(g, []string{
+		"+OK\r\n",
+		"*2\r\n$31\r\nuser default on nopass ~* +@all\r\n$15\r\nuser app off ~*\r\n",
+		"*4\r\n$5\r\nflags\r\n*1\r\n$2\r\non\r\n$9\r\npasswords\r\n*0\r\n",
+		"$-1\r\n",
+		":3\r\n",
+		"-NOPERM this user has no permissions to run the 'acl' command\r\n",
+	})
+	defer listener.Close()
+
+	client, err := DialRedis(RedisOptions{
+		Address:  listener.Addr().String(),
+		Username: "auditor",
+		Password: "p@ss word",
+		Timeout:  5 * time.Second,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer client.Close()
+	g.Expect(<-commands).To(gomega.Equal([]string{"AUTH", "auditor", "p@ss word"}))
+
+	reply, err := client.Do("ACL", "LIST")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(<-commands).To(gomega.Equal([]string{"ACL", "LIST"}))
+	g.Expect(reply).To(gomega.Equal([]interface{}{"user default on nopass ~* +@all", "user app off ~*"}))
+
+	reply, err = client.Do("ACL", "GETUSER", "app")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(reply).To(gomega.Equal([]interface{}{"flags", []interface{}{"on"}, "passwords", []interface{}{}}))
+
+	reply, err = client.Do("ACL", "GETUSER", "missing")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(reply).To(gomega.BeNil())
+
+	reply, err = client.Do("DBSIZE")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(reply).To(gomega.Equal(int64(3)))
+
+	_, err = client.Do("ACL", "LIST")
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(strings.HasPrefix(err.Error(), "NOPERM")).To(gomega.BeTrue())
+}
+
+func TestRedisClientAuthError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	listener, commands := fakeRedisServer(g, []string{
+		"-WRONGPASS invalid username-password pair or user is disabled.\r\n",
+	})
+	defer listener.Close()
+
+	_, err := DialRedis(RedisOptions{
+		Address:  listener.Addr().String(),
+		Password: "wrong",
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(<-commands).To(gomega.Equal([]string{"AUTH", "wrong"}))
+}
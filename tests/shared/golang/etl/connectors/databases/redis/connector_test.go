@@ -0,0 +1,19 @@
+package redis
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestCreateRedisConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	runner := &fakeRunner{}
+	conn, err := CreateRedisConnector(runner)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.runner).To(gomega.Equal(runner))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.runner).To(gomega.Equal(runner))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
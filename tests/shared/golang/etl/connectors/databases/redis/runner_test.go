@@ -0,0 +1,23 @@
+package redis
+
+import (
+	"errors"
+	"strings"
+)
+
+type fakeRunner struct {
+	// Replies keyed by the command (e.g. "ACL GETUSER default").
+	replies  map[string]interface{}
+	commands []string
+}
+
+func (r *fakeRunner) Do(args ...string) (interface{}, error) {
+	command := strings.Join(args, " ")
+	r.commands = append(r.commands, command)
+
+	reply, ok := r.replies[command]
+	if !ok {
+		return nil, errors.New("NOPERM this user has no permissions to run the '" + strings.ToLower(args[0]) + "' command")
+	}
+	return reply, nil
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"testing"
+)
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	runner := &fakeRunner{
+		replies: map[string]interface{}{
+			"ACL LIST": []interface{}{
+				"user app on #5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8 ~app:* resetchannels -@all +@read +set",
+				"user default on nopass sanitize-payload ~* &* +@all",
+				"user legacy off nopass ~* +@all",
+			},
+			"ACL GETUSER app": []interface{}{
+				"flags", []interface{}{"on", "sanitize-payload"},
+				"passwords", []interface{}{"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"},
+				"commands", "-@all +@read +set",
+				"keys", "~app:*",
+				"channels", "",
+				"selectors", []interface{}{},
+			},
+			"ACL GETUSER default": []interface{}{
+				"flags", []interface{}{"on", "nopass", "sanitize-payload"},
+				"passwords", []interface{}{},
+				"commands", "+@all",
+				"keys", "~*",
+				"channels", "&*",
+				"selectors", []interface{}{},
+			},
+			// Deleted after it was listed.
+			"ACL GETUSER legacy": nil,
+		},
+	}
+
+	conn, err := CreateRedisConnector(runner)
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(runner.commands).To(gomega.Equal([]string{"ACL LIST", "ACL GETUSER app", "ACL GETUSER default", "ACL GETUSER legacy"}))
+	g.Expect(len(source.Commands)).To(gomega.Equal(4))
+	g.Expect(source.Commands[1].Command).To(gomega.Equal("ACL GETUSER app"))
+	g.Expect(source.Commands[1].Parameters).To(gomega.Equal(map[string]interface{}{"1": "GETUSER", "2": "app"}))
+
+	// The password hashes must not be stored with the evidence.
+	for _, cmd := range source.Commands {
+		g.Expect(cmd.RawData).NotTo(gomega.ContainSubstring("5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"))
+	}
+	g.Expect(source.Commands[0].RawData).To(gomega.Equal(`["user app on ~app:* resetchannels -@all +@read +set","user default on nopass sanitize-payload ~* \u0026* +@all","user legacy off nopass ~* +@all"]`))
+	g.Expect(source.Commands[1].RawData).To(gomega.ContainSubstring(`"passwords",[]`))
+
+	allAccess := &types.EtlRole{
+		Name: "Self",
+		Permissions: map[string][]string{
+			"KEY::*":        []string{RedisReadPermission, RedisWritePermission},
+			"COMMAND::@all": []string{RedisExecutePermission},
+		},
+	}
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"app": &types.EtlUser{
+			Username: "app",
+			Attributes: map[string]string{
+				RedisAttributeEnabled:       "true",
+				RedisAttributeNoPass:        "false",
+				RedisAttributePasswordCount: "1",
+				RedisAttributeFlags:         "on,sanitize-payload",
+			},
+			Findings: []*types.EtlFinding{},
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"KEY::app:*":     []string{RedisReadPermission, RedisWritePermission},
+						"COMMAND::@read": []string{RedisExecutePermission},
+						"COMMAND::set":   []string{RedisExecutePermission},
+					},
+					Denied: map[string][]string{
+						"COMMAND::@all": []string{RedisExecutePermission},
+					},
+				},
+			},
+		},
+		"default": &types.EtlUser{
+			Username: "default",
+			Attributes: map[string]string{
+				RedisAttributeEnabled: "true",
+				RedisAttributeNoPass:  "true",
+				RedisAttributeFlags:   "on,nopass,sanitize-payload",
+			},
+			Findings: []*types.EtlFinding{
+				&types.EtlFinding{Code: FindingNoPassword},
+			},
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"KEY::*":        []string{RedisReadPermission, RedisWritePermission},
+						"CHANNEL::*":    []string{RedisChannelPermission},
+						"COMMAND::@all": []string{RedisExecutePermission},
+					},
+				},
+			},
+		},
+		"legacy": &types.EtlUser{
+			Username: "legacy",
+			Attributes: map[string]string{
+				RedisAttributeEnabled: "false",
+				RedisAttributeFlags:   "",
+			},
+			// Disabled users can't log in at all.
+			Findings: []*types.EtlFinding{},
+			Roles: map[string]*types.EtlRole{
+				"Self": allAccess,
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateRedisConnector(&fakeRunner{})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+}
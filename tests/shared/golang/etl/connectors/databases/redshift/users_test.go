@@ -0,0 +1,207 @@
+package redshift
+
+import (
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"testing"
+)
+
+var privilegeColumns = []string{"IdentityType", "IdentityName", "Object", "Permission", "AdminOption"}
+
+func expectUsersAndPrivileges(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`FROM pg_user\s+ORDER BY usename`).WillReturnRows(
+		sqlmock.NewRows([]string{"Username", "UserId", "Super", "CreateDb", "ValidUntil"}).
+			AddRow("admin", 100, true, true, nil).
+			AddRow("analyst", 101, false, false, "2021-01-01 00:00:00").
+			AddRow("etl", 102, false, true, nil),
+	)
+
+	mock.ExpectQuery(`FROM pg_group AS g`).WillReturnRows(
+		sqlmock.NewRows([]string{"Member", "Parent"}).
+			AddRow("analyst", "readers").
+			AddRow("etl", "readers"),
+	)
+
+	mock.ExpectQuery(`FROM svv_database_privileges`).WillReturnRows(
+		sqlmock.NewRows(privilegeColumns).
+			AddRow("public", "public", "DATABASE::dev", "TEMP", false).
+			AddRow("group", "readers", "SCHEMA::dev.finance", "USAGE", false).
+			AddRow("group", "readers", "TBL::dev.finance.ledger", "SELECT", false).
+			AddRow("user", "etl", "TBL::dev.finance.ledger", "INSERT", true).
+			AddRow("role", "finance_admin", "TBL::dev.finance.ledger", "DELETE", false).
+			AddRow("role", "auditor", "ROUTINE::dev.finance.balance(integer)", "EXECUTE", false),
+	)
+
+	mock.ExpectQuery(`FROM pg_namespace AS n`).WillReturnRows(
+		sqlmock.NewRows(privilegeColumns).
+			AddRow("user", "admin", "SCHEMA::dev.finance", "OWNER", false).
+			AddRow("user", "etl", "TBL::dev.finance.ledger", "OWNER", false),
+	)
+}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	expectUsersAndPrivileges(mock)
+
+	mock.ExpectQuery(`FROM svv_system_privileges`).WillReturnRows(
+		sqlmock.NewRows(privilegeColumns).
+			AddRow("role", "finance_admin", "SYSTEM", "CREATE USER", false),
+	)
+
+	mock.ExpectQuery(`FROM svv_user_grants`).WillReturnRows(
+		sqlmock.NewRows([]string{"Member", "Parent"}).
+			AddRow("analyst", "finance_admin"),
+	)
+
+	mock.ExpectQuery(`FROM svv_role_grants`).WillReturnRows(
+		sqlmock.NewRows([]string{"Member", "Parent"}).
+			AddRow("finance_admin", "auditor"),
+	)
+
+	conn, err := CreateRedshiftConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(7))
+
+	publicRole := &types.EtlRole{
+		Name: RedshiftPublicRole,
+		Permissions: map[string][]string{
+			"DATABASE::dev": []string{"TEMP"},
+		},
+	}
+
+	readersRole := &types.EtlRole{
+		Name: "GROUP::readers",
+		Permissions: map[string][]string{
+			"SCHEMA::dev.finance":     []string{"USAGE"},
+			"TBL::dev.finance.ledger": []string{"SELECT"},
+		},
+	}
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"admin": &types.EtlUser{
+			Username: "admin",
+			Attributes: map[string]string{
+				RedshiftAttributeUserId: "100",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"Self":                []string{RedshiftSuperPermission, RedshiftCreateDbPermission},
+						"SCHEMA::dev.finance": []string{RedshiftOwnerPermission},
+					},
+				},
+				RedshiftPublicRole: publicRole,
+			},
+		},
+		"analyst": &types.EtlUser{
+			Username: "analyst",
+			Attributes: map[string]string{
+				RedshiftAttributeUserId:     "101",
+				RedshiftAttributeValidUntil: "2021-01-01 00:00:00",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"Self": []string{},
+					},
+				},
+				RedshiftPublicRole: publicRole,
+				"GROUP::readers":   readersRole,
+				"finance_admin": &types.EtlRole{
+					Name: "finance_admin",
+					Permissions: map[string][]string{
+						"TBL::dev.finance.ledger": []string{"DELETE"},
+						RedshiftSystemObject:      []string{"CREATE USER"},
+					},
+				},
+				"auditor": &types.EtlRole{
+					Name: "auditor",
+					Permissions: map[string][]string{
+						"ROUTINE::dev.finance.balance(integer)": []string{"EXECUTE"},
+					},
+				},
+			},
+		},
+		"etl": &types.EtlUser{
+			Username: "etl",
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"Self":                    []string{RedshiftCreateDbPermission},
+						"TBL::dev.finance.ledger": []string{"INSERT WITH GRANT OPTION", RedshiftOwnerPermission},
+					},
+				},
+				RedshiftPublicRole: publicRole,
+				"GROUP::readers":   readersRole,
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingWithoutRbac(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// Clusters without role-based access control have neither the privilege views nor the system privileges.
+	for _, missing := range []string{"svv_database_privileges", "svv_system_privileges"} {
+		db, mock, err := sqlmock.New()
+		g.Expect(err).To(gomega.BeNil())
+
+		missingErr := &pq.Error{
+			Code:    "42P01",
+			Message: fmt.Sprintf(`relation "%s" does not exist`, missing),
+		}
+
+		if missing == "svv_system_privileges" {
+			expectUsersAndPrivileges(mock)
+		} else {
+			mock.ExpectQuery(`FROM pg_user\s+ORDER BY usename`).WillReturnRows(
+				sqlmock.NewRows([]string{"Username", "UserId", "Super", "CreateDb", "ValidUntil"}),
+			)
+			mock.ExpectQuery(`FROM pg_group AS g`).WillReturnRows(sqlmock.NewRows([]string{"Member", "Parent"}))
+		}
+		mock.ExpectQuery(`FROM ` + missing).WillReturnError(missingErr)
+
+		conn, err := CreateRedshiftConnector(sqlx.NewDb(db, "sqlmock"))
+		g.Expect(err).To(gomega.BeNil())
+
+		_, _, err = conn.users.GetUserListing()
+		g.Expect(err).To(gomega.Equal(missingErr))
+		g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+		db.Close()
+	}
+}
+
+func TestGetUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New()
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM pg_user`).WillReturnError(errors.New("permission denied"))
+
+	conn, err := CreateRedshiftConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+}
@@ -0,0 +1,175 @@
+package snowflake
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"testing"
+	"time"
+)
+
+var grantColumns = []string{"created_on", "privilege", "granted_on", "name", "granted_to", "grantee_name", "grant_option", "granted_by"}
+
+func TestQuoteIdentifier(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(quoteIdentifier("ANALYST")).To(gomega.Equal(`"ANALYST"`))
+	g.Expect(quoteIdentifier(`my "role"`)).To(gomega.Equal(`"my ""role"""`))
+}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	aliceCreated := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	bobCreated := time.Date(2020, 1, 2, 11, 4, 5, 0, time.UTC)
+
+	mock.ExpectQuery("SHOW USERS").WillReturnRows(
+		sqlmock.NewRows([]string{"name", "created_on", "login_name", "display_name", "email", "disabled", "default_role", "has_password", "has_rsa_public_key", "ext_authn_duo"}).
+			AddRow("ALICE", aliceCreated, "alice", "Alice Smith", "alice@example.com", "false", "ANALYST", "true", "false", "true").
+			AddRow("BOB", "2020-01-02 03:04:05.000 -0800", "bob", "", "", "true", "", "false", "true", "false"),
+	)
+
+	mock.ExpectQuery("SHOW ROLES").WillReturnRows(
+		sqlmock.NewRows([]string{"created_on", "name", "is_default", "is_current", "is_inherited", "assigned_to_users", "granted_to_roles", "granted_roles", "owner", "comment"}).
+			AddRow(aliceCreated, "SYSADMIN", "N", "N", "N", 0, 1, 0, "", "").
+			AddRow(aliceCreated, "ACCOUNTADMIN", "N", "N", "N", 1, 0, 1, "", "").
+			AddRow(aliceCreated, "PUBLIC", "N", "N", "N", 0, 0, 0, "", "").
+			AddRow(aliceCreated, "ANALYST", "N", "N", "N", 1, 0, 1, "ACCOUNTADMIN", "").
+			AddRow(aliceCreated, "READER", "N", "N", "N", 0, 1, 0, "ACCOUNTADMIN", ""),
+	)
+
+	mock.ExpectQuery(`SHOW GRANTS TO ROLE "ACCOUNTADMIN"`).WillReturnRows(
+		sqlmock.NewRows(grantColumns).
+			AddRow(aliceCreated, "USAGE", "ROLE", "SYSADMIN", "ROLE", "ACCOUNTADMIN", "false", "").
+			AddRow(aliceCreated, "MANAGE GRANTS", "ACCOUNT", "ACCT", "ROLE", "ACCOUNTADMIN", "false", ""),
+	)
+
+	mock.ExpectQuery(`SHOW GRANTS TO ROLE "ANALYST"`).WillReturnRows(
+		sqlmock.NewRows(grantColumns).
+			AddRow(aliceCreated, "USAGE", "ROLE", "READER", "ROLE", "ANALYST", "false", "ACCOUNTADMIN").
+			AddRow(aliceCreated, "USAGE", "WAREHOUSE", "ANALYTICS_WH", "ROLE", "ANALYST", "true", "ACCOUNTADMIN"),
+	)
+
+	mock.ExpectQuery(`SHOW GRANTS TO ROLE "PUBLIC"`).WillReturnRows(
+		sqlmock.NewRows(grantColumns),
+	)
+
+	mock.ExpectQuery(`SHOW GRANTS TO ROLE "READER"`).WillReturnRows(
+		sqlmock.NewRows(grantColumns).
+			AddRow(aliceCreated, "SELECT", "TABLE", "FINANCE.PUBLIC.LEDGER", "ROLE", "READER", "false", "ACCOUNTADMIN").
+			AddRow(aliceCreated, "USAGE", "DATABASE", "FINANCE", "ROLE", "READER", "false", "ACCOUNTADMIN").
+			AddRow(aliceCreated, "OWNERSHIP", "TABLE", "FINANCE.PUBLIC.BUDGET", "ROLE", "READER", "true", "ACCOUNTADMIN"),
+	)
+
+	mock.ExpectQuery(`SHOW GRANTS TO ROLE "SYSADMIN"`).WillReturnRows(
+		sqlmock.NewRows(grantColumns).
+			AddRow(aliceCreated, "CREATE DATABASE", "ACCOUNT", "ACCT", "ROLE", "SYSADMIN", "false", ""),
+	)
+
+	userGrantColumns := []string{"created_on", "role", "granted_to", "grantee_name", "granted_by"}
+	mock.ExpectQuery(`SHOW GRANTS TO USER "ALICE"`).WillReturnRows(
+		sqlmock.NewRows(userGrantColumns).
+			AddRow(aliceCreated, "ANALYST", "USER", "ALICE", "ACCOUNTADMIN"),
+	)
+
+	mock.ExpectQuery(`SHOW GRANTS TO USER "BOB"`).WillReturnRows(
+		sqlmock.NewRows(userGrantColumns).
+			AddRow(aliceCreated, "ACCOUNTADMIN", "USER", "BOB", ""),
+	)
+
+	conn, err := CreateSnowflakeConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(9))
+
+	publicRole := &types.EtlRole{
+		Name:        "PUBLIC",
+		Permissions: map[string][]string{},
+	}
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"ALICE": &types.EtlUser{
+			Username:    "ALICE",
+			FullName:    "Alice Smith",
+			Email:       "alice@example.com",
+			CreatedTime: &aliceCreated,
+			Attributes: map[string]string{
+				SnowflakeAttributeLoginName:   "alice",
+				SnowflakeAttributeDisabled:    "false",
+				SnowflakeAttributeDefaultRole: "ANALYST",
+				SnowflakeAttributeHasPassword: "true",
+				SnowflakeAttributeMfa:         "true",
+			},
+			Roles: map[string]*types.EtlRole{
+				"ANALYST": &types.EtlRole{
+					Name: "ANALYST",
+					Permissions: map[string][]string{
+						"WAREHOUSE::ANALYTICS_WH": []string{"USAGE WITH GRANT OPTION"},
+					},
+				},
+				"READER": &types.EtlRole{
+					Name: "READER",
+					Permissions: map[string][]string{
+						"TABLE::FINANCE.PUBLIC.LEDGER": []string{"SELECT"},
+						"TABLE::FINANCE.PUBLIC.BUDGET": []string{"OWNERSHIP WITH GRANT OPTION"},
+						"DATABASE::FINANCE":            []string{"USAGE"},
+					},
+				},
+				"PUBLIC": publicRole,
+			},
+		},
+		"BOB": &types.EtlUser{
+			Username:    "BOB",
+			CreatedTime: &bobCreated,
+			Attributes: map[string]string{
+				SnowflakeAttributeLoginName:       "bob",
+				SnowflakeAttributeDisabled:        "true",
+				SnowflakeAttributeHasRsaPublicKey: "true",
+			},
+			Roles: map[string]*types.EtlRole{
+				"ACCOUNTADMIN": &types.EtlRole{
+					Name: "ACCOUNTADMIN",
+					Permissions: map[string][]string{
+						"ACCOUNT::ACCT": []string{"MANAGE GRANTS"},
+					},
+				},
+				"SYSADMIN": &types.EtlRole{
+					Name: "SYSADMIN",
+					Permissions: map[string][]string{
+						"ACCOUNT::ACCT": []string{"CREATE DATABASE"},
+					},
+				},
+				"PUBLIC": publicRole,
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	g.Expect(err).To(gomega.BeNil())
+	defer db.Close()
+
+	mock.ExpectQuery("SHOW USERS").WillReturnRows(
+		sqlmock.NewRows([]string{"name"}).AddRow("ALICE"),
+	)
+	mock.ExpectQuery("SHOW ROLES").WillReturnError(errors.New("Insufficient privileges."))
+
+	conn, err := CreateSnowflakeConnector(sqlx.NewDb(db, "sqlmock"))
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(mock.ExpectationsWereMet()).To(gomega.BeNil())
+}
@@ -0,0 +1,80 @@
+package declarative
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCreateDeclarativeConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	spec, err := ParseDeclarativeSpec([]byte(`
+baseUrl: https://api.example.com/
+users:
+  path: /users
+  fields: {username: $.id}
+`))
+	g.Expect(err).To(gomega.BeNil())
+
+	client := &http.Client{}
+	conn, err := CreateDeclarativeConnector(&EtlDeclarativeOptions{
+		Spec:   spec,
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.apiBaseUrl()).To(gomega.Equal("https://api.example.com"))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+
+	_, err = CreateDeclarativeConnector(&EtlDeclarativeOptions{})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestCreateDeclarativeHttpClient(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		spec     EtlDeclarativeAuthSpec
+		header   string
+		expected string
+	}{
+		{EtlDeclarativeAuthSpec{Type: AuthTypeNone}, "Authorization", ""},
+		{EtlDeclarativeAuthSpec{Type: AuthTypeBearer}, "Authorization", "Bearer secret"},
+		{EtlDeclarativeAuthSpec{Type: AuthTypeApiKey, Header: "X-API-Key"}, "X-API-Key", "secret"},
+		{EtlDeclarativeAuthSpec{Type: AuthTypeApiKey, Header: "Authorization", Prefix: "Token "}, "Authorization", "Token secret"},
+		// user:pass
+		{EtlDeclarativeAuthSpec{Type: AuthTypeBasic}, "Authorization", "Basic dXNlcjpwYXNz"},
+	} {
+		client, err := createDeclarativeHttpClient(test.spec, EtlDeclarativeCredentials{
+			Token:    "secret",
+			Username: "user",
+			Password: "pass",
+		})
+		g.Expect(err).To(gomega.BeNil())
+
+		req, err := http.NewRequest("GET", server.URL, nil)
+		g.Expect(err).To(gomega.BeNil())
+
+		resp, err := client.Do(req)
+		g.Expect(err).To(gomega.BeNil())
+		resp.Body.Close()
+
+		g.Expect((<-headers).Get(test.header)).To(gomega.Equal(test.expected), test.spec.Type)
+	}
+
+	_, err := createDeclarativeHttpClient(EtlDeclarativeAuthSpec{Type: "kerberos"}, EtlDeclarativeCredentials{})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
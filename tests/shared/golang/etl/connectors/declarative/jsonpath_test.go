@@ -0,0 +1,72 @@
+package declarative
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func decodeTestJson(g *gomega.GomegaWithT, data string) interface{} {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	g.Expect(decoder.Decode(&value)).To(gomega.BeNil())
+	return value
+}
+
+func TestJsonPath(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	data := decodeTestJson(g, `{
+		"id": 12345678901234567890,
+		"name": "mike",
+		"active": true,
+		"manager": null,
+		"emails": ["mike@grchive.com", "mike@personal.com"],
+		"roles": [{"name": "admin", "scopes": ["read", "write"]}, {"name": "billing", "scopes": ["read"]}],
+		"odd key": {"b": 2, "a": 1}
+	}`)
+
+	for _, test := range []struct {
+		expr     string
+		expected []string
+	}{
+		{"$.id", []string{"12345678901234567890"}},
+		{"$.name", []string{"mike"}},
+		{"$['name']", []string{"mike"}},
+		{"$.active", []string{"true"}},
+		{"$.manager", []string{}},
+		{"$.missing", []string{}},
+		{"$.emails[0]", []string{"mike@grchive.com"}},
+		{"$.emails[-1]", []string{"mike@personal.com"}},
+		{"$.emails[5]", []string{}},
+		{"$.emails", []string{"mike@grchive.com,mike@personal.com"}},
+		{"$.emails[*]", []string{"mike@grchive.com", "mike@personal.com"}},
+		{"$.roles[*].name", []string{"admin", "billing"}},
+		{"$.roles[*].scopes[*]", []string{"read", "write", "read"}},
+		{`$["odd key"].*`, []string{"1", "2"}},
+		{"$.roles[0]", []string{`{"name":"admin","scopes":["read","write"]}`}},
+		{"literal", []string{"literal"}},
+		{"", []string{}},
+	} {
+		values, err := resolveStrings(test.expr, data)
+		g.Expect(err).To(gomega.BeNil(), test.expr)
+		g.Expect(values).To(gomega.Equal(test.expected), test.expr)
+	}
+}
+
+func TestJsonPathErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, expr := range []string{
+		"$..name",
+		"$.",
+		"$.roles[",
+		"$.roles[abc]",
+		"$name",
+	} {
+		_, err := compileJsonPath(expr)
+		g.Expect(err).NotTo(gomega.BeNil(), expr)
+	}
+}
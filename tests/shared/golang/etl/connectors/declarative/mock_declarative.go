@@ -0,0 +1,56 @@
+package declarative_utility
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type MockDeclarativeResponse struct {
+	Body   string
+	Header http.Header
+}
+
+// MockDeclarativeClient replies to requests with the response for the path and query (e.g. /users?page=2).
+type MockDeclarativeClient struct {
+	Responses map[string]MockDeclarativeResponse
+
+	mutex    sync.Mutex
+	Requests []*http.Request
+}
+
+func (c *MockDeclarativeClient) Do(req *http.Request) (*http.Response, error) {
+	c.mutex.Lock()
+	c.Requests = append(c.Requests, req)
+	c.mutex.Unlock()
+
+	resp, ok := c.Responses[req.URL.RequestURI()]
+	if !ok {
+		return nil, errors.New("Invalid path: " + req.URL.RequestURI())
+	}
+
+	header := resp.Header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(resp.Body)),
+	}, nil
+}
+
+// RequestedUris returns the path and query of every request in the order they were made.
+func (c *MockDeclarativeClient) RequestedUris() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	uris := []string{}
+	for _, r := range c.Requests {
+		uris = append(uris, r.URL.RequestURI())
+	}
+	return uris
+}
@@ -0,0 +1,56 @@
+package declarative
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestParseDeclarativeSpecJson(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	spec, err := ParseDeclarativeSpec([]byte(`{
+		"name": "Example",
+		"baseUrl": "https://api.example.com",
+		"auth": {"type": "api_key", "header": "X-API-Key"},
+		"users": {
+			"path": "/users",
+			"pagination": {"type": "page", "param": "page", "start": 0, "total": "$.pages"},
+			"fields": {"username": "$.email", "attributes": {"status": "$.status"}},
+			"roles": [{"items": "$.roles[*]", "name": "$"}]
+		}
+	}`))
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(spec.Name).To(gomega.Equal("Example"))
+	g.Expect(spec.Auth.Header).To(gomega.Equal("X-API-Key"))
+	g.Expect(spec.Users.Path).To(gomega.Equal("/users"))
+	g.Expect(*spec.Users.Pagination.Start).To(gomega.Equal(0))
+	g.Expect(spec.Users.Fields.Attributes).To(gomega.Equal(map[string]string{"status": "$.status"}))
+	g.Expect(len(spec.Users.Roles)).To(gomega.Equal(1))
+}
+
+func TestParseDeclarativeSpecErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, spec := range []string{
+		// Unknown field.
+		`{baseUrl: https://api.example.com, users: {path: /users, fields: {username: $.id}}, extra: 1}`,
+		`{users: {path: /users, fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, users: {fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, fields: {email: $.email}}}`,
+		`{baseUrl: https://api.example.com, auth: {type: kerberos}, users: {path: /users, fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, auth: {type: api_key}, users: {path: /users, fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, auth: {type: oauth2_client_credentials}, users: {path: /users, fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, pagination: {type: scroll}, fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, pagination: {type: cursor, param: cursor}, fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, pagination: {type: page, param: page}, fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, fields: {username: "$..id"}}}`,
+		`{baseUrl: https://api.example.com, users: {path: "/users/{$..id}", fields: {username: $.id}}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, fields: {username: $.id}, roles: [{name: $}]}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, fields: {username: $.id}, roles: [{items: "$.roles[*]"}]}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, fields: {username: $.id}, roles: [{items: "$.roles[*]", name: $, permissions: [{object: Self}]}]}}`,
+		`{baseUrl: https://api.example.com, users: {path: /users, fields: {username: $.id}, roles: [{endpoint: {path: /roles, pagination: {type: offset}}, name: $}]}}`,
+	} {
+		_, err := ParseDeclarativeSpec([]byte(spec))
+		g.Expect(err).NotTo(gomega.BeNil(), spec)
+	}
+}
@@ -0,0 +1,274 @@
+package declarative
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/declarative_utility"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var refTime1 = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+const refSpec = `
+name: Example
+baseUrl: https://api.example.com/v1/
+headers:
+  X-Api-Version: "2"
+auth:
+  type: bearer
+users:
+  path: /users
+  query:
+    status: all
+  items: $.data
+  pagination:
+    type: link
+  fields:
+    username: $.login
+    fullName: $.profile.name
+    email: $.emails[0]
+    createdTime: $.created_at
+    attributes:
+      status: $.status
+      teams: $.teams[*].slug
+  roles:
+    - items: $.roles[*]
+      name: $
+    - items: $
+      name: Self
+      permissions:
+        - object: Self
+          actions: $.scopes[*]
+    - endpoint:
+        path: /users/{$.id}/grants
+        items: $.grants
+        pagination:
+          type: cursor
+          param: cursor
+          cursor: $.next
+      name: $.role
+      permissions:
+        - items: $.rules[*]
+          object: $.resources[*]
+          actions: $.verbs[*]
+        - items: $.deny[*]
+          object: $.resource
+          actions: $.verbs[*]
+          denied: true
+`
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	spec, err := ParseDeclarativeSpec([]byte(refSpec))
+	g.Expect(err).To(gomega.BeNil())
+
+	client := &declarative_utility.MockDeclarativeClient{
+		Responses: map[string]declarative_utility.MockDeclarativeResponse{
+			"/v1/users?status=all": {
+				Body: `{"data": [{"id": 1, "login": "mike", "profile": {"name": "Michael Bao"}, "emails": ["mike@grchive.com"], "created_at": "2020-01-02T03:04:05Z", "status": "active", "teams": [{"slug": "eng"}, {"slug": "ops"}], "roles": ["owner", "billing"], "scopes": ["repo", "admin:org"]}]}`,
+				Header: http.Header{
+					"Link": []string{`<https://api.example.com/v1/users?status=all&after=1>; rel="next"`},
+				},
+			},
+			"/v1/users?status=all&after=1": {
+				Body: `{"data": [{"id": "a b", "login": "bot", "status": "suspended", "roles": [], "scopes": []}]}`,
+			},
+			"/v1/users/1/grants": {
+				Body: `{"grants": [{"role": "deployer", "rules": [{"resources": ["apps/prod", "apps/dev"], "verbs": ["deploy"]}]}], "next": "c2"}`,
+			},
+			"/v1/users/1/grants?cursor=c2": {
+				Body: `{"grants": [{"role": "deployer", "rules": [{"resources": ["apps/dev"], "verbs": ["deploy", "rollback"]}], "deny": [{"resource": "apps/prod", "verbs": ["delete"]}]}], "next": null}`,
+			},
+			"/v1/users/a%20b/grants": {
+				Body: `{"grants": []}`,
+			},
+		},
+	}
+
+	conn, err := CreateDeclarativeConnector(&EtlDeclarativeOptions{
+		Spec:   spec,
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(len(source.Commands)).To(gomega.Equal(5))
+	g.Expect(source.Commands[0].Command).To(gomega.Equal("https://api.example.com/v1/users?status=all"))
+	g.Expect(source.Commands[2].Command).To(gomega.Equal("https://api.example.com/v1/users/1/grants"))
+	g.Expect(source.Commands[3].Command).To(gomega.Equal("https://api.example.com/v1/users/1/grants?cursor=c2"))
+	g.Expect(client.Requests[0].Header.Get("X-Api-Version")).To(gomega.Equal("2"))
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"mike": &types.EtlUser{
+			Username:    "mike",
+			FullName:    "Michael Bao",
+			Email:       "mike@grchive.com",
+			CreatedTime: &refTime1,
+			Attributes: map[string]string{
+				"status": "active",
+				"teams":  "eng,ops",
+			},
+			Roles: map[string]*types.EtlRole{
+				"owner":   &types.EtlRole{Name: "owner"},
+				"billing": &types.EtlRole{Name: "billing"},
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"Self": []string{"repo", "admin:org"},
+					},
+				},
+				"deployer": &types.EtlRole{
+					Name: "deployer",
+					Permissions: map[string][]string{
+						"apps/prod": []string{"deploy"},
+						"apps/dev":  []string{"deploy", "rollback"},
+					},
+					Denied: map[string][]string{
+						"apps/prod": []string{"delete"},
+					},
+				},
+			},
+		},
+		"bot": &types.EtlUser{
+			Username: "bot",
+			Attributes: map[string]string{
+				"status": "suspended",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{Name: "Self"},
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingPagePagination(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	spec, err := ParseDeclarativeSpec([]byte(`{
+		"baseUrl": "https://api.example.com",
+		"users": {
+			"path": "/accounts",
+			"items": "$.accounts[*]",
+			"pagination": {"type": "page", "param": "page", "sizeParam": "per_page", "size": 2, "total": "$.pages"},
+			"fields": {"username": "$.email", "email": "$.email", "lastChangeTime": "$.updated", "timeFormat": "unix"}
+		}
+	}`))
+	g.Expect(err).To(gomega.BeNil())
+
+	client := &declarative_utility.MockDeclarativeClient{
+		Responses: map[string]declarative_utility.MockDeclarativeResponse{
+			"/accounts?page=1&per_page=2": {Body: `{"accounts": [{"email": "a@grchive.com", "updated": 1577934245}, {"email": "b@grchive.com"}], "pages": 2}`},
+			"/accounts?page=2&per_page=2": {Body: `{"accounts": [{"email": "c@grchive.com"}, {"email": "d@grchive.com"}], "pages": 2}`},
+		},
+	}
+
+	conn, err := CreateDeclarativeConnector(&EtlDeclarativeOptions{
+		Spec:   spec,
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, _, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(client.RequestedUris()).To(gomega.Equal([]string{"/accounts?page=1&per_page=2", "/accounts?page=2&per_page=2"}))
+	g.Expect(len(users)).To(gomega.Equal(4))
+	g.Expect(*users[0].LastChangeTime).To(gomega.Equal(refTime1))
+	g.Expect(users[1].LastChangeTime).To(gomega.BeNil())
+}
+
+func TestGetUserListingOffsetPagination(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	spec, err := ParseDeclarativeSpec([]byte(`
+baseUrl: https://api.example.com
+users:
+  path: /members
+  pagination: {type: offset, param: offset, sizeParam: limit, size: 2}
+  fields: {username: $.name}
+`))
+	g.Expect(err).To(gomega.BeNil())
+
+	client := &declarative_utility.MockDeclarativeClient{
+		Responses: map[string]declarative_utility.MockDeclarativeResponse{
+			"/members?limit=2&offset=0": {Body: `[{"name": "a"}, {"name": "b"}]`},
+			"/members?limit=2&offset=2": {Body: `[{"name": "c"}]`},
+		},
+	}
+
+	conn, err := CreateDeclarativeConnector(&EtlDeclarativeOptions{
+		Spec:   spec,
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, _, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(client.RequestedUris()).To(gomega.Equal([]string{"/members?limit=2&offset=0", "/members?limit=2&offset=2"}))
+	g.Expect(len(users)).To(gomega.Equal(3))
+}
+
+func TestGetUserListingMaxPages(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	spec, err := ParseDeclarativeSpec([]byte(`
+baseUrl: https://api.example.com
+users:
+  path: /members
+  pagination: {type: cursor, param: cursor, cursor: $.next, maxPages: 2}
+  items: $.members
+  fields: {username: $.name}
+`))
+	g.Expect(err).To(gomega.BeNil())
+
+	client := &declarative_utility.MockDeclarativeClient{
+		Responses: map[string]declarative_utility.MockDeclarativeResponse{
+			"/members":          {Body: `{"members": [{"name": "a"}], "next": "1"}`},
+			"/members?cursor=1": {Body: `{"members": [{"name": "b"}], "next": "2"}`},
+			"/members?cursor=2": {Body: `{"members": [{"name": "c"}], "next": "3"}`},
+		},
+	}
+
+	conn, err := CreateDeclarativeConnector(&EtlDeclarativeOptions{
+		Spec:   spec,
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(len(client.Requests)).To(gomega.Equal(2))
+}
+
+func TestGetUserListingMissingUsername(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	spec, err := ParseDeclarativeSpec([]byte(`
+baseUrl: https://api.example.com
+users:
+  path: /members
+  fields: {username: $.name}
+`))
+	g.Expect(err).To(gomega.BeNil())
+
+	conn, err := CreateDeclarativeConnector(&EtlDeclarativeOptions{
+		Spec: spec,
+		Client: &declarative_utility.MockDeclarativeClient{
+			Responses: map[string]declarative_utility.MockDeclarativeResponse{
+				"/members": {Body: `[{"id": 1}]`},
+			},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+}
@@ -0,0 +1,22 @@
+package linux
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestCreateLinuxConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateLinuxConnector(&EtlLinuxOptions{
+		Host: LinuxFilesystemHost{Root: "/mnt/host"},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.authorizedKeysFiles()).To(gomega.Equal(defaultAuthorizedKeysFiles))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
@@ -0,0 +1,113 @@
+package linux
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestParsePasswd(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	entries, err := parsePasswd("root:x:0:0:root:/root:/bin/bash\n# comment\n\n+@netgroup::::::\nalice:x:1000:1000:Alice Liddell,,,:/home/alice:/bin/zsh\r\n")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entries).To(gomega.Equal([]*passwdEntry{
+		{Name: "root", Password: "x", Uid: 0, Gid: 0, Gecos: "root", Home: "/root", Shell: "/bin/bash"},
+		{Name: "alice", Password: "x", Uid: 1000, Gid: 1000, Gecos: "Alice Liddell,,,", Home: "/home/alice", Shell: "/bin/zsh"},
+	}))
+
+	for _, data := range []string{
+		"root:x:0:0:root:/root",
+		"root:x:zero:0:root:/root:/bin/bash",
+		"root:x:0:zero:root:/root:/bin/bash",
+	} {
+		_, err = parsePasswd(data)
+		g.Expect(err).NotTo(gomega.BeNil(), data)
+	}
+}
+
+func TestParseShadow(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	entries, err := parseShadow("root:$6$abc:18000:0:99999:7:::\nalice:!$6$def:18262::90::30:18628:\n")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entries).To(gomega.HaveLen(2))
+
+	g.Expect(*entries["root"].LastChange).To(gomega.Equal(18000))
+	g.Expect(*entries["root"].MaxDays).To(gomega.Equal(99999))
+	g.Expect(entries["root"].Expire).To(gomega.BeNil())
+
+	g.Expect(entries["alice"].Password).To(gomega.Equal("!$6$def"))
+	g.Expect(entries["alice"].MinDays).To(gomega.BeNil())
+	g.Expect(*entries["alice"].Inactive).To(gomega.Equal(30))
+	g.Expect(*entries["alice"].Expire).To(gomega.Equal(18628))
+
+	_, err = parseShadow("root:$6$abc:soon:0:99999:7:::")
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestParseGroup(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	entries, err := parseGroup("root:x:0:\nsudo:x:27:alice, bob\n")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(entries).To(gomega.Equal([]*groupEntry{
+		{Name: "root", Gid: 0, Members: []string{}},
+		{Name: "sudo", Gid: 27, Members: []string{"alice", "bob"}},
+	}))
+
+	_, err = parseGroup("sudo:x:27")
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestPasswordStatus(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, test := range []struct {
+		passwd string
+		shadow *shadowEntry
+		status string
+	}{
+		{"x", &shadowEntry{Password: "$6$abc"}, PasswordStatusSet},
+		{"x", &shadowEntry{Password: "!$6$abc"}, PasswordStatusLocked},
+		{"x", &shadowEntry{Password: "!!"}, PasswordStatusDisabled},
+		{"x", &shadowEntry{Password: "!"}, PasswordStatusDisabled},
+		{"x", &shadowEntry{Password: "*"}, PasswordStatusDisabled},
+		{"x", &shadowEntry{Password: ""}, PasswordStatusEmpty},
+		{"x", nil, PasswordStatusUnknown},
+		{"", nil, PasswordStatusEmpty},
+		{"$1$legacy", nil, PasswordStatusSet},
+	} {
+		g.Expect(passwordStatus(&passwdEntry{Password: test.passwd}, test.shadow)).To(gomega.Equal(test.status), test.passwd)
+	}
+}
+
+func TestRedactPasswordHashes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(redactPasswordHashes(`# comment:$6$abc
+root:x:0:0:root:/root:/bin/bash
+legacy:$1$abc:1000:1000::/home/legacy:/bin/sh
+locked:!$1$def:1001:1001::/home/locked:/bin/sh
+nologin:*:1002:1002::/:/usr/sbin/nologin
+empty::1003:1003::/home/empty:/bin/sh
+`)).To(gomega.Equal(`# comment:$6$abc
+root:x:0:0:root:/root:/bin/bash
+legacy:*REDACTED*:1000:1000::/home/legacy:/bin/sh
+locked:!*REDACTED*:1001:1001::/home/locked:/bin/sh
+nologin:*:1002:1002::/:/usr/sbin/nologin
+empty::1003:1003::/home/empty:/bin/sh
+`))
+}
+
+func TestParseAuthorizedKeys(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	keys := parseAuthorizedKeys(refAuthorizedKeys)
+	g.Expect(keys).To(gomega.HaveLen(2))
+	g.Expect(keys[0].String()).To(gomega.Equal("ssh-ed25519 " + refKeyFingerprint + " alice@laptop"))
+	g.Expect(keys[1].String()).To(gomega.Equal(`ssh-ed25519 ` + refKeyFingerprint + ` deploy [from="10.0.0.0/8",no-pty]`))
+
+	g.Expect(authorizedKeysPath(".ssh/authorized_keys", &passwdEntry{Name: "alice", Home: "/home/alice"})).To(gomega.Equal("/home/alice/.ssh/authorized_keys"))
+	g.Expect(authorizedKeysPath("/etc/ssh/keys/%u%%", &passwdEntry{Name: "alice", Home: "/home/alice"})).To(gomega.Equal("/etc/ssh/keys/alice%"))
+	g.Expect(authorizedKeysPath("%h/keys", &passwdEntry{Name: "alice", Home: "/home/alice"})).To(gomega.Equal("/home/alice/keys"))
+}
@@ -0,0 +1,198 @@
+package linux
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const refKeyFingerprint = "SHA256:3M2gfg5qoMyoXPE0Z3TkAqXex25IXxLLjXk4HvyobXw"
+
+const refAuthorizedKeys = `# Keys for alice
+ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIG09HT6iLpx1KFQGy6ynt9aSuzzJFl/bkvrM8yzA5xdt alice@laptop
+not a key
+from="10.0.0.0/8",no-pty ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIG09HT6iLpx1KFQGy6ynt9aSuzzJFl/bkvrM8yzA5xdt deploy
+`
+
+// refHostFiles is the content of the files on the test host keyed by their absolute path.
+var refHostFiles = map[string]string{
+	"/etc/passwd": `root:x:0:0:root:/root:/bin/bash
+daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin
+alice:x:1000:1000:Alice Liddell,,,:/home/alice:/bin/bash
+bob:x:1001:1001:Bob:/home/bob:/bin/bash
+toor:x:0:0::/root:/bin/sh
+guest:x:1002:1002::/home/guest:/bin/bash
+`,
+	"/etc/shadow": `root:$6$abc:18000:0:99999:7:::
+daemon:*:18000:0:99999:7:::
+alice:$6$def:18262:0:90:7:::
+bob:!$6$ghi:18262:0:99999:7::18628:
+toor::18000:0:99999:7:::
+guest:!!:18300::::::
+`,
+	"/etc/group": `root:x:0:
+daemon:x:1:
+sudo:x:27:alice
+alice:x:1000:
+bob:x:1001:
+guest:x:1002:
+ops:x:2000:bob
+`,
+	"/etc/sudoers": `Defaults	env_reset
+
+root	ALL=(ALL:ALL) ALL
+%sudo	ALL=(ALL:ALL) ALL
+
+#includedir /etc/sudoers.d
+`,
+	"/etc/sudoers.d/ops":        "%ops ALL=(root) NOPASSWD: /usr/bin/systemctl restart nginx, !/usr/bin/systemctl stop nginx\n",
+	"/etc/sudoers.d/alice":      "alice ALL = NOPASSWD: ALL\n",
+	"/etc/sudoers.d/README.bak": "Not a sudoers file.\n",
+	"/etc/sudoers.d/alice~":     "Not a sudoers file either.\n",
+
+	"/home/alice/.ssh/authorized_keys": refAuthorizedKeys,
+	"/root/.ssh/authorized_keys2":      "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIG09HT6iLpx1KFQGy6ynt9aSuzzJFl/bkvrM8yzA5xdt\n",
+}
+
+func writeTestHost(g *gomega.GomegaWithT, files map[string]string) string {
+	root, err := ioutil.TempDir("", "linux")
+	g.Expect(err).To(gomega.BeNil())
+
+	for fname, data := range files {
+		full := filepath.Join(root, filepath.FromSlash(fname))
+		g.Expect(os.MkdirAll(filepath.Dir(full), 0700)).To(gomega.BeNil())
+		g.Expect(ioutil.WriteFile(full, []byte(data), 0600)).To(gomega.BeNil())
+	}
+	return root
+}
+
+func dayTime(days int) *time.Time {
+	t := time.Unix(int64(days)*24*60*60, 0).UTC()
+	return &t
+}
+
+func refUsers() map[string]*types.EtlUser {
+	return map[string]*types.EtlUser{
+		"root": &types.EtlUser{
+			Username:       "root",
+			FullName:       "root",
+			LastChangeTime: dayTime(18000),
+			Roles: map[string]*types.EtlRole{
+				"root": &types.EtlRole{Name: "root"},
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"sudo::ALL::ALL:ALL": []string{"ALL"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				LinuxAttributeUid:                "0",
+				LinuxAttributeHome:               "/root",
+				LinuxAttributePasswordStatus:     PasswordStatusSet,
+				LinuxAttributeAuthorizedKeyCount: "1",
+				LinuxAttributeAuthorizedKeys:     "ssh-ed25519 " + refKeyFingerprint,
+			},
+			Findings: []*types.EtlFinding{},
+		},
+		"daemon": &types.EtlUser{
+			Username:       "daemon",
+			FullName:       "daemon",
+			LastChangeTime: dayTime(18000),
+			Roles: map[string]*types.EtlRole{
+				"daemon": &types.EtlRole{Name: "daemon"},
+			},
+			Attributes: map[string]string{
+				LinuxAttributeShell:              "/usr/sbin/nologin",
+				LinuxAttributePasswordStatus:     PasswordStatusDisabled,
+				LinuxAttributeAuthorizedKeyCount: "0",
+			},
+			Findings: []*types.EtlFinding{},
+		},
+		"alice": &types.EtlUser{
+			Username:       "alice",
+			FullName:       "Alice Liddell",
+			LastChangeTime: dayTime(18262),
+			Roles: map[string]*types.EtlRole{
+				"alice": &types.EtlRole{Name: "alice"},
+				"sudo": &types.EtlRole{
+					Name: "sudo",
+					Permissions: map[string][]string{
+						"sudo::ALL::ALL:ALL": []string{"ALL"},
+					},
+				},
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"sudo::ALL::root": []string{"NOPASSWD: ALL"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				LinuxAttributeUid:                "1000",
+				LinuxAttributeGid:                "1000",
+				LinuxAttributePasswordStatus:     PasswordStatusSet,
+				LinuxAttributePasswordExpires:    "2020-03-31",
+				LinuxAttributeAuthorizedKeyCount: "2",
+				LinuxAttributeAuthorizedKeys:     "ssh-ed25519 " + refKeyFingerprint + ` alice@laptop; ssh-ed25519 ` + refKeyFingerprint + ` deploy [from="10.0.0.0/8",no-pty]`,
+			},
+			Findings: []*types.EtlFinding{
+				&types.EtlFinding{Code: FindingPasswordlessSudo},
+			},
+		},
+		"bob": &types.EtlUser{
+			Username:       "bob",
+			FullName:       "Bob",
+			LastChangeTime: dayTime(18262),
+			Roles: map[string]*types.EtlRole{
+				"bob": &types.EtlRole{Name: "bob"},
+				"ops": &types.EtlRole{
+					Name: "ops",
+					Permissions: map[string][]string{
+						"sudo::ALL::root": []string{"NOPASSWD: /usr/bin/systemctl restart nginx"},
+					},
+					Denied: map[string][]string{
+						"sudo::ALL::root": []string{"/usr/bin/systemctl stop nginx"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				LinuxAttributePasswordStatus:     PasswordStatusLocked,
+				LinuxAttributeAccountExpires:     "2021-01-01",
+				LinuxAttributeAccountExpired:     "true",
+				LinuxAttributeAuthorizedKeyCount: "0",
+			},
+			Findings: []*types.EtlFinding{},
+		},
+		"toor": &types.EtlUser{
+			Username:       "toor",
+			LastChangeTime: dayTime(18000),
+			Roles: map[string]*types.EtlRole{
+				"root": &types.EtlRole{Name: "root"},
+			},
+			Attributes: map[string]string{
+				LinuxAttributeShell:              "/bin/sh",
+				LinuxAttributePasswordStatus:     PasswordStatusEmpty,
+				LinuxAttributeAuthorizedKeyCount: "1",
+			},
+			Findings: []*types.EtlFinding{
+				&types.EtlFinding{Code: FindingEmptyPassword},
+				&types.EtlFinding{Code: FindingNonRootUidZero},
+			},
+		},
+		"guest": &types.EtlUser{
+			Username:       "guest",
+			LastChangeTime: dayTime(18300),
+			Roles: map[string]*types.EtlRole{
+				"guest": &types.EtlRole{Name: "guest"},
+			},
+			Attributes: map[string]string{
+				LinuxAttributePasswordStatus: PasswordStatusDisabled,
+			},
+			Findings: []*types.EtlFinding{},
+		},
+	}
+}
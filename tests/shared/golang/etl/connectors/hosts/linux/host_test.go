@@ -0,0 +1,142 @@
+package linux
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/shared/utility/ssh"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Files with this content can't be read even with sudo (e.g. on root squashed NFS).
+const unreadableFile = "\x00unreadable"
+
+// fakeShell implements the commands LinuxSshHost runs against a map of files.
+func fakeShell(files map[string]string, commands *[]string) test_utility.FakeSshCommandHandler {
+	return func(command string) (string, string, int) {
+		*commands = append(*commands, command)
+		if !strings.HasPrefix(command, "sudo -n ") {
+			return "", "Permission denied\n", 1
+		}
+		command = strings.TrimPrefix(command, "sudo -n ")
+
+		unquote := func(s string) string {
+			return strings.Replace(strings.Trim(s, "'"), `'\''`, "'", -1)
+		}
+
+		switch {
+		case strings.HasPrefix(command, "cat -- "):
+			p := unquote(strings.TrimPrefix(command, "cat -- "))
+			data, ok := files[p]
+			if !ok {
+				return "", "cat: " + p + ": No such file or directory\n", 1
+			} else if data == unreadableFile {
+				return "", "cat: " + p + ": Permission denied\n", 1
+			}
+			return data, "", 0
+		case strings.HasPrefix(command, "ls -1A -- "):
+			p := unquote(strings.TrimPrefix(command, "ls -1A -- ")) + "/"
+			names := []string{}
+			for fname := range files {
+				if strings.HasPrefix(fname, p) && !strings.Contains(fname[len(p):], "/") {
+					names = append(names, fname[len(p):])
+				}
+			}
+
+			if len(names) == 0 {
+				return "", "ls: cannot access '" + p + "': No such file or directory\n", 2
+			}
+			sort.Strings(names)
+			return strings.Join(names, "\n") + "\n", "", 0
+		}
+		return "", "sh: command not found\n", 127
+	}
+}
+
+func TestLinuxSshHost(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	files := map[string]string{}
+	for k, v := range refHostFiles {
+		files[k] = v
+	}
+	files["/home/it's/.ssh/authorized_keys"] = refAuthorizedKeys
+	files["/home/bob/.ssh/authorized_keys"] = unreadableFile
+
+	commands := []string{}
+	server, err := test_utility.NewFakeSshServer("audit", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer server.Close()
+	server.Exec = fakeShell(files, &commands)
+
+	cfg := ssh_utility.SshTunnelConfig{
+		Hops: []ssh_utility.SshHostConfig{
+			{
+				Host:                  server.Host(),
+				Port:                  server.Port(),
+				Username:              "audit",
+				Password:              "password",
+				InsecureIgnoreHostKey: true,
+			},
+		},
+	}
+
+	host, err := OpenLinuxSshHost(cfg, true)
+	g.Expect(err).To(gomega.BeNil())
+	defer host.Close()
+
+	data, cmd, err := host.ReadFile("/home/it's/.ssh/authorized_keys")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(data).To(gomega.Equal(refAuthorizedKeys))
+	g.Expect(cmd.Command).To(gomega.Equal(`sudo -n cat -- '/home/it'\''s/.ssh/authorized_keys'`))
+	g.Expect(cmd.RawData).To(gomega.Equal(refAuthorizedKeys))
+
+	_, _, err = host.ReadFile("/etc/missing")
+	g.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+
+	_, _, err = host.ReadFile("/home/bob/.ssh/authorized_keys")
+	g.Expect(os.IsPermission(err)).To(gomega.BeTrue())
+
+	names, cmd, err := host.ListDir("/etc/sudoers.d")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(names).To(gomega.Equal([]string{"README.bak", "alice", "alice~", "ops"}))
+	g.Expect(cmd.Command).To(gomega.Equal("sudo -n ls -1A -- '/etc/sudoers.d'"))
+
+	_, _, err = host.ListDir("/etc/missing.d")
+	g.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+
+	conn, err := CreateLinuxConnector(&EtlLinuxOptions{
+		Host: host,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	// Keys that can't be read are a finding on the user rather than an error.
+	expected := refUsers()
+	expected["bob"].Findings = append(expected["bob"].Findings, &types.EtlFinding{Code: FindingUnreadableAuthorizedKeys})
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	test_utility.CompareUserListing(g, users, expected, test_utility.CompareUserListingOptions{})
+	g.Expect(source.Commands[0].Command).To(gomega.Equal("sudo -n cat -- '/etc/passwd'"))
+
+	// Other files that can't be read (e.g. sudo not being allowed) fail the listing.
+	unprivileged, err := OpenLinuxSshHost(cfg, false)
+	g.Expect(err).To(gomega.BeNil())
+	defer unprivileged.Close()
+
+	_, _, err = unprivileged.ReadFile("/etc/shadow")
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(os.IsNotExist(err)).To(gomega.BeFalse())
+	g.Expect(os.IsPermission(err)).To(gomega.BeTrue())
+
+	conn, err = CreateLinuxConnector(&EtlLinuxOptions{
+		Host: unprivileged,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(os.IsPermission(err)).To(gomega.BeTrue())
+}
@@ -0,0 +1,139 @@
+package linux
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+const refSudoers = `#
+# This file MUST be edited with the 'visudo' command as root.
+#
+Defaults	env_reset
+Defaults	secure_path="/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin"
+
+User_Alias	ADMINS = alice, %ops : DBAS = bob, #1005
+Runas_Alias	DB = postgres, mysql
+Host_Alias	WEB = web1, web2
+Cmnd_Alias	SERVICES = /usr/bin/systemctl restart nginx, \
+		/usr/bin/systemctl reload nginx
+Cmnd_Alias	SHELLS = /bin/sh, /bin/bash
+
+root	ALL=(ALL:ALL) ALL
+ADMINS	ALL = (ALL) NOPASSWD: ALL, !SHELLS # no shells
+DBAS	ALL = (DB) /usr/bin/psql, PASSWD: /usr/bin/mysql : WEB = SERVICES
+%#2000	ALL = (:adm) /usr/bin/tail -n 100 /var/log/syslog
+ALL, !carol	WEB = sha256:0123abcd /usr/local/bin/deploy --env=prod
+
+#includedir /etc/sudoers.d
+@include local
+`
+
+func TestParseSudoers(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := createSudoers()
+	includes, err := s.Parse(refSudoers, "/etc/sudoers")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(includes).To(gomega.Equal([]sudoInclude{
+		{Path: "/etc/sudoers.d", Dir: true},
+		{Path: "/etc/local", Dir: false},
+	}))
+
+	g.Expect(s.userAliases).To(gomega.Equal(map[string][]sudoItem{
+		"ADMINS": {{Value: "alice"}, {Value: "%ops"}},
+		"DBAS":   {{Value: "bob"}, {Value: "#1005"}},
+	}))
+	g.Expect(s.cmndAliases["SERVICES"]).To(gomega.Equal([]sudoItem{
+		{Value: "/usr/bin/systemctl restart nginx"},
+		{Value: "/usr/bin/systemctl reload nginx"},
+	}))
+
+	type flatRule struct {
+		Hosts    string
+		Runas    string
+		Command  sudoItem
+		NoPasswd bool
+		Source   string
+	}
+
+	rules := []flatRule{}
+	for _, r := range s.rules {
+		rules = append(rules, flatRule{
+			Hosts:    s.hosts(r),
+			Runas:    s.runas(r),
+			Command:  r.Command,
+			NoPasswd: r.NoPasswd,
+			Source:   r.Source,
+		})
+	}
+
+	g.Expect(rules).To(gomega.Equal([]flatRule{
+		{"ALL", "ALL:ALL", sudoItem{Value: "ALL"}, false, "/etc/sudoers:14"},
+		{"ALL", "ALL", sudoItem{Value: "ALL"}, true, "/etc/sudoers:15"},
+		{"ALL", "ALL", sudoItem{Negated: true, Value: "SHELLS"}, true, "/etc/sudoers:15"},
+		{"ALL", "postgres,mysql", sudoItem{Value: "/usr/bin/psql"}, false, "/etc/sudoers:16"},
+		{"ALL", "postgres,mysql", sudoItem{Value: "/usr/bin/mysql"}, false, "/etc/sudoers:16"},
+		{"web1,web2", "root", sudoItem{Value: "SERVICES"}, false, "/etc/sudoers:16"},
+		{"ALL", ":adm", sudoItem{Value: "/usr/bin/tail -n 100 /var/log/syslog"}, false, "/etc/sudoers:17"},
+		{"web1,web2", "root", sudoItem{Value: "/usr/local/bin/deploy --env=prod"}, false, "/etc/sudoers:18"},
+	}))
+
+	g.Expect(s.commands(s.rules[2])).To(gomega.Equal([]sudoItem{
+		{Negated: true, Value: "/bin/sh"},
+		{Negated: true, Value: "/bin/bash"},
+	}))
+}
+
+func TestMatchSudoUser(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := createSudoers()
+	_, err := s.Parse(refSudoers, "/etc/sudoers")
+	g.Expect(err).To(gomega.BeNil())
+
+	for _, test := range []struct {
+		subject sudoSubject
+		rule    int
+		matched bool
+		via     string
+	}{
+		{sudoSubject{Name: "alice"}, 1, true, ""},
+		{sudoSubject{Name: "dave", Groups: map[string]bool{"ops": true}}, 1, true, "ops"},
+		{sudoSubject{Name: "dave"}, 1, false, ""},
+		{sudoSubject{Name: "eve", Uid: 1005}, 3, true, ""},
+		{sudoSubject{Name: "eve", Uid: 1006}, 3, false, ""},
+		{sudoSubject{Name: "frank", Gids: map[int]string{2000: "logs"}}, 6, true, "logs"},
+		{sudoSubject{Name: "frank"}, 7, true, ""},
+		{sudoSubject{Name: "carol"}, 7, false, ""},
+	} {
+		matched, via := s.matchUser(s.rules[test.rule].Users, &test.subject, map[string]bool{})
+		g.Expect(matched).To(gomega.Equal(test.matched), test.subject.Name)
+		g.Expect(via).To(gomega.Equal(test.via), test.subject.Name)
+	}
+}
+
+func TestParseSudoersErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, data := range []string{
+		"alice ALL",
+		"alice ALL = (ALL",
+		"alice ALL = (ALL) NOPASSWD:",
+		"User_Alias ADMINS alice",
+	} {
+		_, err := createSudoers().Parse(data, "/etc/sudoers")
+		g.Expect(err).NotTo(gomega.BeNil(), data)
+	}
+}
+
+func TestSudoAliasesAreNotRecursive(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := createSudoers()
+	_, err := s.Parse("User_Alias A = B, alice\nUser_Alias B = A\nCmnd_Alias C = !C\nA ALL = C", "/etc/sudoers")
+	g.Expect(err).To(gomega.BeNil())
+
+	matched, _ := s.matchUser(s.rules[0].Users, &sudoSubject{Name: "alice"}, map[string]bool{})
+	g.Expect(matched).To(gomega.BeTrue())
+	g.Expect(s.commands(s.rules[0])).To(gomega.Equal([]sudoItem{{Negated: true, Value: "C"}}))
+}
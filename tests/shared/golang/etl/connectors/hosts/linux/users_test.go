@@ -0,0 +1,114 @@
+package linux
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"os"
+	"testing"
+)
+
+func TestGetUserListingFilesystem(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	root := writeTestHost(g, refHostFiles)
+	defer os.RemoveAll(root)
+
+	conn, err := CreateLinuxConnector(&EtlLinuxOptions{
+		Host: LinuxFilesystemHost{Root: root},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	test_utility.CompareUserListing(g, users, refUsers(), test_utility.CompareUserListingOptions{})
+
+	commands := []string{}
+	for _, cmd := range source.Commands {
+		commands = append(commands, cmd.Command)
+	}
+
+	g.Expect(commands).To(gomega.Equal([]string{
+		"READ " + root + "/etc/passwd",
+		"READ " + root + "/etc/shadow",
+		"READ " + root + "/etc/group",
+		"READ " + root + "/etc/sudoers",
+		"LIST " + root + "/etc/sudoers.d",
+		"READ " + root + "/etc/sudoers.d/alice",
+		"READ " + root + "/etc/sudoers.d/ops",
+		"READ " + root + "/root/.ssh/authorized_keys2",
+		"READ " + root + "/home/alice/.ssh/authorized_keys",
+		"READ " + root + "/root/.ssh/authorized_keys2",
+	}))
+	g.Expect(source.Commands[0].RawData).To(gomega.Equal(refHostFiles["/etc/passwd"]))
+	g.Expect(source.Commands[0].Parameters).To(gomega.HaveKeyWithValue("path", "/etc/passwd"))
+
+	// The password hashes must not be stored with the evidence.
+	g.Expect(source.Commands[1].RawData).NotTo(gomega.ContainSubstring("$6$"))
+	g.Expect(source.Commands[1].RawData).To(gomega.Equal(`root:*REDACTED*:18000:0:99999:7:::
+daemon:*:18000:0:99999:7:::
+alice:*REDACTED*:18262:0:90:7:::
+bob:!*REDACTED*:18262:0:99999:7::18628:
+toor::18000:0:99999:7:::
+guest:!!:18300::::::
+`))
+}
+
+func TestGetUserListingAuthorizedKeysFiles(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	root := writeTestHost(g, map[string]string{
+		"/etc/passwd":           "alice:x:1000:1000::/home/alice:/bin/bash\n",
+		"/etc/shadow":           "alice:$6$def:18262:0:99999:7:::\n",
+		"/etc/group":            "alice:x:1000:\n",
+		"/etc/ssh/keys/alice":   refAuthorizedKeys,
+		"/home/alice/.ssh/keys": "not a key\n",
+	})
+	defer os.RemoveAll(root)
+
+	conn, err := CreateLinuxConnector(&EtlLinuxOptions{
+		Host:                LinuxFilesystemHost{Root: root},
+		AuthorizedKeysFiles: []string{"/etc/ssh/keys/%u", ".ssh/keys"},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, _, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(users).To(gomega.HaveLen(1))
+	g.Expect(users[0].Attributes).To(gomega.HaveKeyWithValue(LinuxAttributeAuthorizedKeyCount, "2"))
+	g.Expect(users[0].Roles).To(gomega.HaveLen(1))
+}
+
+func TestGetUserListingErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, files := range []map[string]string{
+		// Shadow is required for the password state.
+		{
+			"/etc/passwd": "alice:x:1000:1000::/home/alice:/bin/bash\n",
+			"/etc/group":  "alice:x:1000:\n",
+		},
+		{
+			"/etc/passwd":  "alice:x:1000:1000::/home/alice:/bin/bash\n",
+			"/etc/shadow":  "alice:$6$def:18262:0:99999:7:::\n",
+			"/etc/group":   "alice:x:1000:\n",
+			"/etc/sudoers": "alice ALL = (ALL\n",
+		},
+		{
+			"/etc/passwd":  "alice:x:1000:1000::/home/alice:/bin/bash\n",
+			"/etc/shadow":  "alice:$6$def:18262:0:99999:7:::\n",
+			"/etc/group":   "alice:x:1000:\n",
+			"/etc/sudoers": "@include /etc/sudoers\n",
+		},
+	} {
+		root := writeTestHost(g, files)
+		defer os.RemoveAll(root)
+
+		conn, err := CreateLinuxConnector(&EtlLinuxOptions{
+			Host: LinuxFilesystemHost{Root: root},
+		})
+		g.Expect(err).To(gomega.BeNil())
+
+		_, _, err = conn.users.GetUserListing()
+		g.Expect(err).NotTo(gomega.BeNil())
+	}
+}
@@ -0,0 +1,19 @@
+package digitalocean_utility
+
+import (
+	"errors"
+	"net/http"
+)
+
+type MockDigitalOceanFn func() (*http.Response, error)
+
+type MockDigitalOceanClient struct {
+	GetAccountInfo MockDigitalOceanFn
+}
+
+func (c *MockDigitalOceanClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/v2/account" {
+		return c.GetAccountInfo()
+	}
+	return nil, errors.New("Invalid path.")
+}
@@ -0,0 +1,74 @@
+package digitalocean
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/iaas/digitalocean_utility"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func createDigitalOceanClient() *digitalocean_utility.MockDigitalOceanClient {
+	return &digitalocean_utility.MockDigitalOceanClient{
+		GetAccountInfo: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"account": {"droplet_limit": 25, "floating_ip_limit": 3, "volume_limit": 100, "email": "mike@grchive.com", "uuid": "b6fr89dbf6d9156cace5f3c78dc9851d957381ef", "email_verified": true, "status": "active", "status_message": "", "team": {"uuid": "5df3e3004a17e242b7c20ca6c9fc25b701a47ece", "name": "GRCHive"}}}
+`), nil
+		},
+	}
+}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateDigitalOceanConnector(&EtlDigitalOceanOptions{
+		Client: createDigitalOceanClient(),
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+
+	refUsers := map[string]*types.EtlUser{
+		"mike@grchive.com": &types.EtlUser{
+			Username: "mike@grchive.com",
+			Email:    "mike@grchive.com",
+			Roles:    map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				DigitalOceanAttributeUuid:          "b6fr89dbf6d9156cace5f3c78dc9851d957381ef",
+				DigitalOceanAttributeStatus:        "active",
+				DigitalOceanAttributeEmailVerified: "true",
+				DigitalOceanAttributeTeam:          "GRCHive",
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := createDigitalOceanClient()
+	client.GetAccountInfo = func() (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id":"Unauthorized","message":"Unable to authenticate you."}`)),
+		}, nil
+	}
+
+	conn, err := CreateDigitalOceanConnector(&EtlDigitalOceanOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.HavePrefix("DigitalOcean API Error"))
+}
@@ -0,0 +1,27 @@
+package kubernetes
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"testing"
+)
+
+func TestCreateKubernetesConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &http.Client{}
+
+	conn, err := CreateKubernetesConnector(&EtlKubernetesOptions{
+		Client:    client,
+		ApiServer: "https://10.0.0.1:6443",
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.apiBaseUrl()).To(gomega.Equal("https://10.0.0.1:6443/apis/rbac.authorization.k8s.io/v1"))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
@@ -0,0 +1,105 @@
+package kubernetes_utility
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const rbacApiPrefix = "/apis/rbac.authorization.k8s.io/v1/"
+
+// FakeKubernetesApiServer serves lists of RBAC resources (clusterroles, roles, etc.) the way the API server
+// does: paginated with a continue token and only to clients with the right bearer token.
+type FakeKubernetesApiServer struct {
+	Server *httptest.Server
+
+	// The JSON of every item keyed by the resource (e.g. clusterroles).
+	Items map[string][]string
+	Token string
+	// Overrides the limit the client requests if set.
+	PageSize int
+
+	mutex    sync.Mutex
+	requests []string
+}
+
+func NewFakeKubernetesApiServer(token string, items map[string][]string) *FakeKubernetesApiServer {
+	fake := &FakeKubernetesApiServer{
+		Items: items,
+		Token: token,
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	return fake
+}
+
+func (f *FakeKubernetesApiServer) URL() string {
+	return f.Server.URL
+}
+
+func (f *FakeKubernetesApiServer) Close() {
+	f.Server.Close()
+}
+
+// Requests returns the path and query of every request that was made.
+func (f *FakeKubernetesApiServer) Requests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.requests...)
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"kind":"Status","apiVersion":"v1","metadata":{},"status":"Failure","message":%s,"reason":"%s","code":%d}`, strconv.Quote(message), reason, code)
+}
+
+func (f *FakeKubernetesApiServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	f.requests = append(f.requests, r.URL.RequestURI())
+	f.mutex.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+f.Token {
+		writeStatus(w, http.StatusUnauthorized, "Unauthorized", "Unauthorized")
+		return
+	}
+
+	resource := strings.TrimPrefix(r.URL.Path, rbacApiPrefix)
+	items, ok := f.Items[resource]
+	if !strings.HasPrefix(r.URL.Path, rbacApiPrefix) || !ok {
+		writeStatus(w, http.StatusNotFound, "NotFound", "the server could not find the requested resource")
+		return
+	}
+
+	limit := len(items)
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	if f.PageSize > 0 {
+		limit = f.PageSize
+	}
+
+	start := 0
+	if c := r.URL.Query().Get("continue"); c != "" {
+		var err error
+		start, err = strconv.Atoi(c)
+		if err != nil || start > len(items) {
+			writeStatus(w, http.StatusGone, "Expired", "the provided continue parameter is invalid")
+			return
+		}
+	}
+
+	end := start + limit
+	cont := ""
+	if end < len(items) {
+		cont = strconv.Itoa(end)
+	} else {
+		end = len(items)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"kind":"List","apiVersion":"rbac.authorization.k8s.io/v1","metadata":{"resourceVersion":"1","continue":"%s"},"items":[%s]}`, cont, strings.Join(items[start:end], ","))
+}
@@ -0,0 +1,117 @@
+package kubernetes
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{
+		"app":  "web",
+		"tier": "frontend",
+	}
+
+	for _, test := range []struct {
+		selector kubernetesLabelSelector
+		matches  bool
+	}{
+		{
+			selector: kubernetesLabelSelector{},
+			matches:  false,
+		},
+		{
+			selector: kubernetesLabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			matches:  true,
+		},
+		{
+			selector: kubernetesLabelSelector{MatchLabels: map[string]string{"app": "web", "tier": "backend"}},
+			matches:  false,
+		},
+		{
+			selector: kubernetesLabelSelector{MatchExpressions: []kubernetesLabelSelectorRequirement{
+				{Key: "tier", Operator: "In", Values: []string{"backend", "frontend"}},
+				{Key: "env", Operator: "DoesNotExist"},
+			}},
+			matches: true,
+		},
+		{
+			selector: kubernetesLabelSelector{MatchExpressions: []kubernetesLabelSelectorRequirement{
+				{Key: "app", Operator: "NotIn", Values: []string{"web"}},
+			}},
+			matches: false,
+		},
+		{
+			selector: kubernetesLabelSelector{
+				MatchLabels: map[string]string{"app": "web"},
+				MatchExpressions: []kubernetesLabelSelectorRequirement{
+					{Key: "env", Operator: "Exists"},
+				},
+			},
+			matches: false,
+		},
+	} {
+		g := gomega.NewGomegaWithT(t)
+		g.Expect(test.selector.matches(labels)).To(gomega.Equal(test.matches))
+	}
+}
+
+func TestPolicyRuleObjects(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	rule := kubernetesPolicyRule{
+		ApiGroups:       []string{"", "apps"},
+		Resources:       []string{"deployments", "deployments/scale"},
+		ResourceNames:   []string{"web"},
+		NonResourceUrls: []string{"/healthz"},
+	}
+
+	g.Expect(rule.objects("dev")).To(gomega.Equal([]string{
+		"dev/deployments/web",
+		"dev/deployments/scale/web",
+		"dev/deployments.apps/web",
+		"dev/deployments.apps/scale/web",
+	}))
+
+	g.Expect(rule.objects(KubernetesClusterNamespace)).To(gomega.ContainElement("NONRESOURCE::/healthz"))
+}
+
+func TestResolveAggregatedClusterRoles(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	aggregate := func(label string) *kubernetesAggregationRule {
+		return &kubernetesAggregationRule{
+			ClusterRoleSelectors: []kubernetesLabelSelector{
+				{MatchLabels: map[string]string{label: "true"}},
+			},
+		}
+	}
+
+	getPods := kubernetesPolicyRule{ApiGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}
+	listNodes := kubernetesPolicyRule{ApiGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list"}}
+
+	// a and b select each other.
+	resolved := resolveAggregatedClusterRoles([]kubernetesRole{
+		{
+			Metadata:        kubernetesObjectMeta{Name: "a", Labels: map[string]string{"to-b": "true"}},
+			Rules:           []kubernetesPolicyRule{getPods},
+			AggregationRule: aggregate("to-a"),
+		},
+		{
+			Metadata:        kubernetesObjectMeta{Name: "b", Labels: map[string]string{"to-a": "true"}},
+			Rules:           []kubernetesPolicyRule{listNodes},
+			AggregationRule: aggregate("to-b"),
+		},
+		{
+			Metadata: kubernetesObjectMeta{Name: "c"},
+			Rules:    []kubernetesPolicyRule{getPods},
+		},
+	})
+
+	for _, name := range []string{"a", "b"} {
+		g.Expect(createRole(name, KubernetesClusterNamespace, resolved[name]).Permissions).To(gomega.Equal(map[string][]string{
+			"*/pods":  []string{"get"},
+			"*/nodes": []string{"list"},
+		}))
+	}
+	g.Expect(resolved["c"]).To(gomega.Equal([]kubernetesPolicyRule{getPods}))
+}
@@ -0,0 +1,176 @@
+package kubernetes
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/iaas/kubernetes_utility"
+	"testing"
+)
+
+const refToken = "eyJhbGciOiJSUzI1NiJ9.test"
+
+func createTestServer() *kubernetes_utility.FakeKubernetesApiServer {
+	return kubernetes_utility.NewFakeKubernetesApiServer(refToken, map[string][]string{
+		"clusterroles": []string{
+			`{"metadata":{"name":"cluster-admin"},"rules":[{"apiGroups":["*"],"resources":["*"],"verbs":["*"]},{"nonResourceURLs":["*"],"verbs":["*"]}]}`,
+			`{"metadata":{"name":"deploy-reader","labels":{"rbac.grchive.com/aggregate-to-view":"true"}},"rules":[{"apiGroups":["apps"],"resources":["deployments"],"verbs":["get","list","watch"]}]}`,
+			`{"metadata":{"name":"edit"},"aggregationRule":{"clusterRoleSelectors":[{"matchExpressions":[{"key":"rbac.grchive.com/aggregate-to-edit","operator":"Exists"}]}]},"rules":[]}`,
+			`{"metadata":{"name":"pod-reader","labels":{"rbac.grchive.com/aggregate-to-view":"true"}},"rules":[{"apiGroups":[""],"resources":["pods","pods/log"],"verbs":["get","list"]}]}`,
+			`{"metadata":{"name":"secret-editor","labels":{"rbac.grchive.com/aggregate-to-edit":"yes"}},"rules":[{"apiGroups":[""],"resources":["secrets"],"resourceNames":["app-config"],"verbs":["get","update"]}]}`,
+			`{"metadata":{"name":"view","labels":{"rbac.grchive.com/aggregate-to-edit":"true"}},"aggregationRule":{"clusterRoleSelectors":[{"matchLabels":{"rbac.grchive.com/aggregate-to-view":"true"}}]},"rules":[{"apiGroups":[""],"resources":["pods"],"verbs":["get"]}]}`,
+		},
+		"roles": []string{
+			`{"metadata":{"name":"configmap-writer","namespace":"dev"},"rules":[{"apiGroups":[""],"resources":["configmaps"],"verbs":["create","update"]}]}`,
+		},
+		"clusterrolebindings": []string{
+			`{"metadata":{"name":"admins"},"roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"cluster-admin"},"subjects":[{"apiGroup":"rbac.authorization.k8s.io","kind":"Group","name":"system:masters"},{"apiGroup":"rbac.authorization.k8s.io","kind":"User","name":"alice@grchive.com"}]}`,
+			`{"metadata":{"name":"viewers"},"roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"view"},"subjects":[{"apiGroup":"rbac.authorization.k8s.io","kind":"User","name":"bob@grchive.com"}]}`,
+		},
+		"rolebindings": []string{
+			`{"metadata":{"name":"editors","namespace":"dev"},"roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"edit"},"subjects":[{"apiGroup":"rbac.authorization.k8s.io","kind":"User","name":"alice@grchive.com"},{"kind":"ServiceAccount","name":"deployer"}]}`,
+			`{"metadata":{"name":"config","namespace":"dev"},"roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"Role","name":"configmap-writer"},"subjects":[{"kind":"ServiceAccount","name":"deployer","namespace":"dev"},{"apiGroup":"rbac.authorization.k8s.io","kind":"Group","name":"devs"}]}`,
+			`{"metadata":{"name":"stale","namespace":"prod"},"roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"Role","name":"deleted"},"subjects":[{"apiGroup":"rbac.authorization.k8s.io","kind":"User","name":"carol@grchive.com"}]}`,
+		},
+	})
+}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := createTestServer()
+	defer server.Close()
+	server.PageSize = 2
+
+	conn, err := CreateKubernetesConnector(&EtlKubernetesOptions{
+		Client: http_utility.CreateHeaderInjectionClient(map[string]string{
+			"Authorization": "Bearer " + refToken,
+		}, nil),
+		ApiServer: server.URL(),
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(server.Requests()).To(gomega.Equal([]string{
+		"/apis/rbac.authorization.k8s.io/v1/clusterroles?limit=500",
+		"/apis/rbac.authorization.k8s.io/v1/clusterroles?continue=2&limit=500",
+		"/apis/rbac.authorization.k8s.io/v1/clusterroles?continue=4&limit=500",
+		"/apis/rbac.authorization.k8s.io/v1/roles?limit=500",
+		"/apis/rbac.authorization.k8s.io/v1/clusterrolebindings?limit=500",
+		"/apis/rbac.authorization.k8s.io/v1/rolebindings?limit=500",
+		"/apis/rbac.authorization.k8s.io/v1/rolebindings?continue=2&limit=500",
+	}))
+	g.Expect(len(source.Commands)).To(gomega.Equal(7))
+	g.Expect(source.Commands[0].Command).To(gomega.Equal(server.URL() + "/apis/rbac.authorization.k8s.io/v1/clusterroles?limit=500"))
+
+	clusterAdmin := &types.EtlRole{
+		Name: "ClusterRole::cluster-admin",
+		Permissions: map[string][]string{
+			"*/*.*":          []string{"*"},
+			"NONRESOURCE::*": []string{"*"},
+		},
+	}
+
+	// Edit aggregates view (which aggregates pod-reader and deploy-reader) and secret-editor.
+	devEdit := &types.EtlRole{
+		Name: "ClusterRole::dev/edit",
+		Permissions: map[string][]string{
+			"dev/pods":               []string{"get", "list"},
+			"dev/pods/log":           []string{"get", "list"},
+			"dev/deployments.apps":   []string{"get", "list", "watch"},
+			"dev/secrets/app-config": []string{"get", "update"},
+		},
+	}
+
+	configmapWriter := &types.EtlRole{
+		Name: "Role::dev/configmap-writer",
+		Permissions: map[string][]string{
+			"dev/configmaps": []string{"create", "update"},
+		},
+	}
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"alice@grchive.com": &types.EtlUser{
+			Username: "alice@grchive.com",
+			Attributes: map[string]string{
+				KubernetesAttributeKind: KubernetesSubjectUser,
+			},
+			Roles: map[string]*types.EtlRole{
+				clusterAdmin.Name: clusterAdmin,
+				devEdit.Name:      devEdit,
+			},
+		},
+		"bob@grchive.com": &types.EtlUser{
+			Username: "bob@grchive.com",
+			Roles: map[string]*types.EtlRole{
+				"ClusterRole::view": &types.EtlRole{
+					Name: "ClusterRole::view",
+					Permissions: map[string][]string{
+						"*/pods":             []string{"get", "list"},
+						"*/pods/log":         []string{"get", "list"},
+						"*/deployments.apps": []string{"get", "list", "watch"},
+					},
+				},
+			},
+		},
+		"carol@grchive.com": &types.EtlUser{
+			Username: "carol@grchive.com",
+			Roles: map[string]*types.EtlRole{
+				"Role::prod/deleted": &types.EtlRole{
+					Name: "Role::prod/deleted",
+				},
+			},
+		},
+		"Group::system:masters": &types.EtlUser{
+			Username: "Group::system:masters",
+			Attributes: map[string]string{
+				KubernetesAttributeKind: KubernetesSubjectGroup,
+			},
+			Roles: map[string]*types.EtlRole{
+				clusterAdmin.Name: clusterAdmin,
+			},
+		},
+		"Group::devs": &types.EtlUser{
+			Username: "Group::devs",
+			Roles: map[string]*types.EtlRole{
+				configmapWriter.Name: configmapWriter,
+			},
+		},
+		"system:serviceaccount:dev:deployer": &types.EtlUser{
+			Username: "system:serviceaccount:dev:deployer",
+			Attributes: map[string]string{
+				KubernetesAttributeKind:      KubernetesSubjectServiceAccount,
+				KubernetesAttributeNamespace: "dev",
+			},
+			Roles: map[string]*types.EtlRole{
+				devEdit.Name:         devEdit,
+				configmapWriter.Name: configmapWriter,
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingUnauthorized(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := createTestServer()
+	defer server.Close()
+
+	conn, err := CreateKubernetesConnector(&EtlKubernetesOptions{
+		Client:    http_utility.CreateHeaderInjectionClient(map[string]string{}, nil),
+		ApiServer: server.URL() + "/",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(server.Requests()).To(gomega.Equal([]string{
+		"/apis/rbac.authorization.k8s.io/v1/clusterroles?limit=500",
+	}))
+}
@@ -10,7 +10,9 @@ type MockLinodeFn func() (*http.Response, error)
 
 type MockLinodeClient struct {
 	AccountUsers MockLinodeFn
-	UserGrants   map[string]MockLinodeFn
+	// Pages of users after the first one keyed by the page number.
+	AccountUserPages map[string]MockLinodeFn
+	UserGrants       map[string]MockLinodeFn
 }
 
 func (c *MockLinodeClient) Do(req *http.Request) (*http.Response, error) {
@@ -19,6 +21,8 @@ func (c *MockLinodeClient) Do(req *http.Request) (*http.Response, error) {
 			splitData := strings.Split(req.URL.Path, "/")
 			username := splitData[len(splitData)-2]
 			return c.UserGrants[username]()
+		} else if page := req.URL.Query().Get("page"); page != "" {
+			return c.AccountUserPages[page]()
 		} else {
 			return c.AccountUsers()
 		}
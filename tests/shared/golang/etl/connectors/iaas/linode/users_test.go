@@ -80,3 +80,31 @@ func TestGetUserListing(t *testing.T) {
 	}
 	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
 }
+
+func TestGetUserListingMultiplePages(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := createLinodeClient()
+	client.AccountUsers = func() (*http.Response, error) {
+		return test_utility.WrapHttpResponse(`
+{"data": [{"username": "mike-grchive", "email": "mike@grchive.com", "restricted": false, "ssh_keys": [], "tfa_enabled": false}], "page": 1, "pages": 2, "results": 2}
+`), nil
+	}
+	client.AccountUserPages = map[string]linode_utility.MockLinodeFn{
+		"2": func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"data": [{"username": "mike-test", "email": "mike+test@grchive.com", "restricted": true, "ssh_keys": [], "tfa_enabled": false}], "page": 2, "pages": 2, "results": 2}
+`), nil
+		},
+	}
+
+	conn, err := CreateLinodeConnector(&EtlLinodeOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(3))
+	g.Expect(len(users)).To(gomega.Equal(2))
+}
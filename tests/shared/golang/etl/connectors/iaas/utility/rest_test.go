@@ -0,0 +1,117 @@
+package iaas_utility
+
+import (
+	"errors"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type fakeRestClient struct {
+	Responses map[string]string
+	Requests  []string
+}
+
+func (c *fakeRestClient) Do(req *http.Request) (*http.Response, error) {
+	c.Requests = append(c.Requests, req.URL.String())
+	body, ok := c.Responses[req.URL.String()]
+	if !ok {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"message":"not found"}`)),
+		}, nil
+	}
+	return test_utility.WrapHttpResponse(body), nil
+}
+
+type testPage struct {
+	Pages int64    `json:"pages"`
+	Data  []string `json:"data"`
+}
+
+func pagesNextPage(endpoint string, page int, response reflect.Value) string {
+	if int64(page) >= response.FieldByName("Pages").Int() {
+		return ""
+	}
+	return SetQueryParameter(endpoint, "page", strconv.Itoa(page+1))
+}
+
+func TestRestPaginatedGet(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &fakeRestClient{
+		Responses: map[string]string{
+			"https://api.test.com/v1/users?per_page=2":        `{"pages": 3, "data": ["a", "b"]}`,
+			"https://api.test.com/v1/users?page=2&per_page=2": `{"pages": 3, "data": ["c", "d"]}`,
+			"https://api.test.com/v1/users?page=3&per_page=2": `{"pages": 3, "data": ["e"]}`,
+		},
+	}
+
+	pages := []testPage{}
+	source, err := RestPaginatedGet(client, "Test", "https://api.test.com/v1/users?per_page=2", &pages, pagesNextPage)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(3))
+	g.Expect(client.Requests).To(gomega.Equal([]string{
+		"https://api.test.com/v1/users?per_page=2",
+		"https://api.test.com/v1/users?page=2&per_page=2",
+		"https://api.test.com/v1/users?page=3&per_page=2",
+	}))
+
+	data := []string{}
+	for _, p := range pages {
+		data = append(data, p.Data...)
+	}
+	g.Expect(data).To(gomega.Equal([]string{"a", "b", "c", "d", "e"}))
+}
+
+func TestRestPaginatedGetRepeatedPage(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &fakeRestClient{
+		Responses: map[string]string{
+			"https://api.test.com/v1/users": `{"pages": 2, "data": ["a"]}`,
+		},
+	}
+
+	pages := []testPage{}
+	_, err := RestPaginatedGet(client, "Test", "https://api.test.com/v1/users", &pages, func(endpoint string, page int, response reflect.Value) string {
+		return endpoint
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(pages)).To(gomega.Equal(1))
+	g.Expect(len(client.Requests)).To(gomega.Equal(1))
+}
+
+func TestRestGetError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &fakeRestClient{}
+	page := testPage{}
+	_, err := RestGet(client, "Test", "https://api.test.com/v1/missing", &page)
+	g.Expect(err).To(gomega.Equal(errors.New(`Test API Error: {"message":"not found"}`)))
+
+	_, err = RestGet(client, "Test", "https://api.test.com/v1/missing", page)
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestSetQueryParameter(t *testing.T) {
+	for _, test := range []struct {
+		endpoint string
+		key      string
+		value    string
+		ref      string
+	}{
+		{"https://api.test.com/v1/users", "page", "2", "https://api.test.com/v1/users?page=2"},
+		{"https://api.test.com/v1/users?per_page=10", "page", "2", "https://api.test.com/v1/users?page=2&per_page=10"},
+		{"https://api.test.com/v1/users?page=2", "page", "3", "https://api.test.com/v1/users?page=3"},
+		{"https://api.test.com/v1/users", "cursor", "bmV4dA==", "https://api.test.com/v1/users?cursor=bmV4dA%3D%3D"},
+	} {
+		g := gomega.NewGomegaWithT(t)
+		g.Expect(SetQueryParameter(test.endpoint, test.key, test.value)).To(gomega.Equal(test.ref))
+	}
+}
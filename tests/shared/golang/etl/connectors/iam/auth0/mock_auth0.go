@@ -8,20 +8,62 @@ import (
 )
 
 type MockAuth0Fn func() (*http.Response, error)
+type MockAuth0IdFn func(id string) (*http.Response, error)
 
 type MockAuth0Client struct {
-	Users MockAuth0Fn
+	Users               MockAuth0Fn
+	UserPermissions     MockAuth0IdFn
+	Roles               MockAuth0Fn
+	RolePermissions     MockAuth0IdFn
+	RoleUsers           MockAuth0IdFn
+	Organizations       MockAuth0Fn
+	OrganizationMembers MockAuth0IdFn
+}
+
+func emptyPage() (*http.Response, error) {
+	return test_utility.WrapHttpResponse(`[]`), nil
+}
+
+func callFn(fn MockAuth0Fn) (*http.Response, error) {
+	if fn == nil {
+		return emptyPage()
+	}
+	return fn()
+}
+
+func callIdFn(fn MockAuth0IdFn, id string) (*http.Response, error) {
+	if fn == nil {
+		return emptyPage()
+	}
+	return fn(id)
 }
 
 func (c *MockAuth0Client) Do(req *http.Request) (*http.Response, error) {
-	if strings.HasPrefix(req.URL.Path, "/api/v2/users") {
-		page := req.URL.Query().Get("page")
-		if page == "0" {
-			return c.Users()
-		} else {
-			return test_utility.WrapHttpResponse(`[]`), nil
-
-		}
+	if !strings.HasPrefix(req.URL.Path, "/api/v2/") {
+		return nil, errors.New("Invalid path.")
+	}
+
+	page := req.URL.Query().Get("page")
+	if page != "0" {
+		return emptyPage()
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/v2/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "users":
+		return callFn(c.Users)
+	case len(parts) == 3 && parts[0] == "users" && parts[2] == "permissions":
+		return callIdFn(c.UserPermissions, parts[1])
+	case len(parts) == 1 && parts[0] == "roles":
+		return callFn(c.Roles)
+	case len(parts) == 3 && parts[0] == "roles" && parts[2] == "permissions":
+		return callIdFn(c.RolePermissions, parts[1])
+	case len(parts) == 3 && parts[0] == "roles" && parts[2] == "users":
+		return callIdFn(c.RoleUsers, parts[1])
+	case len(parts) == 1 && parts[0] == "organizations":
+		return callFn(c.Organizations)
+	case len(parts) == 3 && parts[0] == "organizations" && parts[2] == "members":
+		return callIdFn(c.OrganizationMembers, parts[1])
 	}
 	return nil, errors.New("Invalid path.")
 }
@@ -61,3 +61,130 @@ func TestGetUserListing(t *testing.T) {
 	}
 	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
 }
+
+func TestGetUserListingRoles(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := createAuth0Client()
+	client.UserPermissions = func(id string) (*http.Response, error) {
+		g.Expect(id).To(gomega.Equal("auth0|5f4d3020146161006d256bce"))
+		return test_utility.WrapHttpResponse(`
+[
+	{"permission_name":"read:reports","description":"Read reports","resource_server_name":"Reports","resource_server_identifier":"https://api.grchive.com","sources":[{"source_id":"","source_name":"","source_type":"DIRECT"}]},
+	{"permission_name":"read:users","description":"Read users","resource_server_name":"Reports","resource_server_identifier":"https://api.grchive.com","sources":[{"source_id":"rol_1","source_name":"Admin","source_type":"ROLE"}]}
+]
+`), nil
+	}
+	client.Roles = func() (*http.Response, error) {
+		return test_utility.WrapHttpResponse(`
+[{"id":"rol_1","name":"Admin","description":"Administrator"},{"id":"rol_2","name":"Viewer","description":"Viewer"}]
+`), nil
+	}
+	client.RolePermissions = func(id string) (*http.Response, error) {
+		if id == "rol_1" {
+			return test_utility.WrapHttpResponse(`
+[
+	{"permission_name":"read:users","description":"Read users","resource_server_name":"Reports","resource_server_identifier":"https://api.grchive.com"},
+	{"permission_name":"write:users","description":"Write users","resource_server_name":"Reports","resource_server_identifier":"https://api.grchive.com"}
+]
+`), nil
+		}
+		return test_utility.WrapHttpResponse(`
+[{"permission_name":"read:reports","description":"Read reports","resource_server_name":"Reports","resource_server_identifier":"https://api.grchive.com"}]
+`), nil
+	}
+	client.RoleUsers = func(id string) (*http.Response, error) {
+		if id == "rol_1" {
+			return test_utility.WrapHttpResponse(`
+[{"user_id":"auth0|5f4d3020146161006d256bce","email":"mike+test@grchive.com","picture":"","name":"Mike Bao"}]
+`), nil
+		}
+		return test_utility.WrapHttpResponse(`[]`), nil
+	}
+	client.Organizations = func() (*http.Response, error) {
+		return test_utility.WrapHttpResponse(`
+[{"id":"org_1","name":"acme","display_name":"Acme Inc."}]
+`), nil
+	}
+	client.OrganizationMembers = func(id string) (*http.Response, error) {
+		g.Expect(id).To(gomega.Equal("org_1"))
+		return test_utility.WrapHttpResponse(`
+[{"user_id":"auth0|5f4d3020146161006d256bce","email":"mike+test@grchive.com","picture":"","name":"Mike Bao","roles":[{"id":"rol_2","name":"Viewer"}]}]
+`), nil
+	}
+
+	conn, err := CreateAuth0Connector(&EtlAuth0Options{
+		Client: client,
+		Domain: "test",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(8))
+
+	refUsers := map[string]*types.EtlUser{
+		"mike+test@grchive.com": &types.EtlUser{
+			Username:    "mike+test@grchive.com",
+			FullName:    "Mike Bao",
+			Email:       "mike+test@grchive.com",
+			CreatedTime: &refTime1,
+			Roles: map[string]*types.EtlRole{
+				"Admin": &types.EtlRole{
+					Name: "Admin",
+					Permissions: map[string][]string{
+						"https://api.grchive.com": []string{"read:users", "write:users"},
+					},
+				},
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"https://api.grchive.com": []string{"read:reports"},
+					},
+				},
+				"Organization: acme": &types.EtlRole{
+					Name:        "Organization: acme",
+					Permissions: map[string][]string{},
+				},
+				"Organization: acme / Viewer": &types.EtlRole{
+					Name: "Organization: acme / Viewer",
+					Permissions: map[string][]string{
+						"https://api.grchive.com": []string{"read:reports"},
+					},
+				},
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingOrganizationsUnavailable(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, status := range []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError} {
+		client := createAuth0Client()
+		client.Organizations = func() (*http.Response, error) {
+			resp := test_utility.WrapHttpResponse(fmt.Sprintf(`{"statusCode":%d,"error":"%s"}`, status, http.StatusText(status)))
+			resp.StatusCode = status
+			return resp, nil
+		}
+
+		conn, err := CreateAuth0Connector(&EtlAuth0Options{
+			Client: client,
+			Domain: "test",
+		})
+		g.Expect(err).To(gomega.BeNil())
+
+		users, _, err := conn.users.GetUserListing()
+		if status == http.StatusInternalServerError {
+			g.Expect(err).NotTo(gomega.BeNil())
+			continue
+		}
+
+		g.Expect(err).To(gomega.BeNil(), http.StatusText(status))
+		g.Expect(len(users)).To(gomega.Equal(1))
+	}
+}
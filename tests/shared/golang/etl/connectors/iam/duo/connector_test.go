@@ -0,0 +1,42 @@
+package duo
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"testing"
+)
+
+func TestCreateDuoConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &http.Client{}
+
+	conn, err := CreateDuoConnector(&EtlDuoOptions{
+		Client: client,
+		Host:   "api-1234abcd.duosecurity.com",
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.Host).To(gomega.Equal("api-1234abcd.duosecurity.com"))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
+
+func TestDuoApiBaseUrl(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, host := range []string{
+		"api-1234abcd.duosecurity.com",
+		"https://api-1234abcd.duosecurity.com",
+		"https://api-1234abcd.duosecurity.com/",
+	} {
+		opts := EtlDuoOptions{
+			Host: host,
+		}
+		g.Expect(opts.apiBaseUrl()).To(gomega.Equal("https://api-1234abcd.duosecurity.com/admin/v1"))
+	}
+}
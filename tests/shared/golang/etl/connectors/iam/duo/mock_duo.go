@@ -0,0 +1,28 @@
+package duo_utility
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+type MockDuoOffsetFn func(offset int) (*http.Response, error)
+
+type MockDuoClient struct {
+	Users        MockDuoOffsetFn
+	Integrations MockDuoOffsetFn
+}
+
+func (c *MockDuoClient) Do(req *http.Request) (*http.Response, error) {
+	offset, err := strconv.Atoi(req.URL.Query().Get("offset"))
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL.Path == "/admin/v1/users" {
+		return c.Users(offset)
+	} else if req.URL.Path == "/admin/v1/integrations" {
+		return c.Integrations(offset)
+	}
+	return nil, errors.New("Invalid path.")
+}
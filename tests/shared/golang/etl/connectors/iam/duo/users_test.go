@@ -0,0 +1,172 @@
+package duo
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/iam/duo_utility"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	userOffsets := []int{}
+	client := &duo_utility.MockDuoClient{
+		Users: func(offset int) (*http.Response, error) {
+			userOffsets = append(userOffsets, offset)
+			if offset == 0 {
+				return test_utility.WrapHttpResponse(`{"stat":"OK","metadata":{"next_offset":300,"total_objects":3},"response":[{"user_id":"DU3RP9I2WOC59VZX672N","username":"mike","realname":"Mike Bao","email":"mike@grchive.com","status":"active","is_enrolled":true,"created":1598961600,"last_login":1602083045,"groups":[{"group_id":"DGXXXXXXXXXXXXXXXXX1","name":"Engineering","desc":"","mobile_otp_enabled":false,"push_enabled":false,"sms_enabled":false,"status":"Active","voice_enabled":false}],"phones":[{"phone_id":"DPFZRS9FB0D46QFTM891","number":"+15555550100","platform":"Apple iOS","type":"Mobile","activated":true,"capabilities":["auto","push","sms","phone","mobile_otp"]}],"tokens":[],"u2ftokens":[],"webauthncredentials":[{"credential_name":"YubiKey 5","date_added":1600000000,"label":"Security Key","webauthnkey":"WABFEOE007ZMV1QAZTRB"}]},{"user_id":"DU3RP9I2WOC59VZX672O","username":"jane","realname":"Jane Doe","email":"jane@grchive.com","status":"bypass","is_enrolled":false,"created":1599048000,"last_login":null,"groups":[{"group_id":"DGXXXXXXXXXXXXXXXXX2","name":"Contractors"}],"phones":[],"tokens":[],"u2ftokens":[],"webauthncredentials":[]}]}`), nil
+			}
+			return test_utility.WrapHttpResponse(`{"stat":"OK","metadata":{"total_objects":3},"response":[{"user_id":"DU3RP9I2WOC59VZX672P","username":"bob","realname":"Bob Smith","email":"bob@grchive.com","status":"disabled","is_enrolled":true,"created":1599134400,"last_login":1600000000,"groups":[],"phones":[],"tokens":[{"serial":"123456","token_id":"DHEKH0JJIYC1LX3AZWO4","type":"d1"}],"u2ftokens":[],"webauthncredentials":[]}]}`), nil
+		},
+		Integrations: func(offset int) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"stat":"OK","metadata":{"total_objects":3},"response":[{"integration_key":"DIRWIH0ZZPV4G88B37VQ","name":"AWS Console","type":"sso-generic","secret_key":"QO4ZLqQVRIOZYkHfdPDORfcNf8LeXIbCWwHazY7o","groups_allowed":[{"group_id":"DGXXXXXXXXXXXXXXXXX1","name":"Engineering"}]},{"integration_key":"DIRWIH0ZZPV4G88B37VR","name":"VPN","type":"radius","secret_key":"Zm9vYmFyYmF6cXV4Zm9vYmFyYmF6cXV4Zm9vYmFy","groups_allowed":[]},{"integration_key":"DIRWIH0ZZPV4G88B37VS","name":"Admin API","type":"adminapi","secret_key":"YmF6cXV4Zm9vYmFyYmF6cXV4Zm9vYmFyYmF6cXV4","groups_allowed":[{"group_id":"DGXXXXXXXXXXXXXXXXX3","name":"Security"}]}]}`), nil
+		},
+	}
+
+	conn, err := CreateDuoConnector(&EtlDuoOptions{
+		Client: client,
+		Host:   "api-1234abcd.duosecurity.com",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(3))
+	g.Expect(userOffsets).To(gomega.Equal([]int{0, 300}))
+
+	// The integrations' secret keys must not be kept around.
+	for _, cmd := range source.Commands {
+		g.Expect(strings.Contains(cmd.RawData, "secret_key")).To(gomega.BeFalse())
+	}
+	g.Expect(strings.Contains(source.Commands[2].RawData, "DIRWIH0ZZPV4G88B37VQ")).To(gomega.BeTrue())
+
+	timePtr := func(sec int64) *time.Time {
+		t := time.Unix(sec, 0)
+		return &t
+	}
+
+	allUsersRole := &types.EtlRole{
+		Name: "All Users",
+		Permissions: map[string][]string{
+			"app::VPN": []string{"access"},
+		},
+	}
+
+	refUsers := map[string]*types.EtlUser{
+		"mike": &types.EtlUser{
+			Username:    "mike",
+			FullName:    "Mike Bao",
+			Email:       "mike@grchive.com",
+			CreatedTime: timePtr(1598961600),
+			Roles: map[string]*types.EtlRole{
+				"Engineering": &types.EtlRole{
+					Name: "Engineering",
+					Permissions: map[string][]string{
+						"app::AWS Console": []string{"access"},
+					},
+				},
+				"All Users": allUsersRole,
+			},
+			Attributes: map[string]string{
+				DuoAttributeUserId:     "DU3RP9I2WOC59VZX672N",
+				DuoAttributeStatus:     "active",
+				DuoAttributeIsEnrolled: "true",
+				DuoAttributeMfaFactors: "phone,webauthn",
+				DuoAttributeLastLogin:  "2020-10-07T15:04:05Z",
+			},
+		},
+		"jane": &types.EtlUser{
+			Username:    "jane",
+			FullName:    "Jane Doe",
+			Email:       "jane@grchive.com",
+			CreatedTime: timePtr(1599048000),
+			Roles: map[string]*types.EtlRole{
+				"Contractors": &types.EtlRole{
+					Name:        "Contractors",
+					Permissions: map[string][]string{},
+				},
+				"All Users": allUsersRole,
+			},
+			Attributes: map[string]string{
+				DuoAttributeUserId:     "DU3RP9I2WOC59VZX672O",
+				DuoAttributeStatus:     "bypass",
+				DuoAttributeIsEnrolled: "false",
+				DuoAttributeMfaFactors: "",
+			},
+		},
+		"bob": &types.EtlUser{
+			Username:    "bob",
+			FullName:    "Bob Smith",
+			Email:       "bob@grchive.com",
+			CreatedTime: timePtr(1599134400),
+			Roles: map[string]*types.EtlRole{
+				"All Users": allUsersRole,
+			},
+			Attributes: map[string]string{
+				DuoAttributeStatus:     "disabled",
+				DuoAttributeMfaFactors: "token",
+				DuoAttributeLastLogin:  "2020-09-13T12:26:40Z",
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+
+	for _, u := range users {
+		if u.Username == "jane" {
+			_, ok := u.Attributes[DuoAttributeLastLogin]
+			g.Expect(ok).To(gomega.BeFalse())
+		}
+	}
+}
+
+func TestUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &duo_utility.MockDuoClient{
+		Users: func(offset int) (*http.Response, error) {
+			resp := test_utility.WrapHttpResponse(`{"stat":"FAIL","code":40301,"message":"Access forbidden","message_detail":"You do not have permission to access this resource."}`)
+			resp.StatusCode = http.StatusForbidden
+			return resp, nil
+		},
+	}
+
+	conn, err := CreateDuoConnector(&EtlDuoOptions{
+		Client: client,
+		Host:   "api-1234abcd.duosecurity.com",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.Equal(`Duo API Error: {"stat":"FAIL","code":40301,"message":"Access forbidden","message_detail":"You do not have permission to access this resource."}`))
+}
+
+func TestUserListingFailStat(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &duo_utility.MockDuoClient{
+		Users: func(offset int) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"stat":"FAIL","code":40002,"message":"Invalid request parameters","message_detail":"limit"}`), nil
+		},
+	}
+
+	conn, err := CreateDuoConnector(&EtlDuoOptions{
+		Client: client,
+		Host:   "api-1234abcd.duosecurity.com",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.Equal("Duo API Error: 40002 Invalid request parameters limit"))
+}
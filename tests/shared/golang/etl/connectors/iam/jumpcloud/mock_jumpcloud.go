@@ -0,0 +1,42 @@
+package jumpcloud_utility
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type MockJumpCloudSkipFn func(skip int) (*http.Response, error)
+type MockJumpCloudIdFn func(id string) (*http.Response, error)
+
+type MockJumpCloudClient struct {
+	SystemUsers       MockJumpCloudSkipFn
+	Applications      MockJumpCloudSkipFn
+	UserGroups        MockJumpCloudSkipFn
+	GroupMembers      MockJumpCloudIdFn
+	GroupApplications MockJumpCloudIdFn
+}
+
+func (c *MockJumpCloudClient) Do(req *http.Request) (*http.Response, error) {
+	skip, err := strconv.Atoi(req.URL.Query().Get("skip"))
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL.Path == "/api/systemusers" {
+		return c.SystemUsers(skip)
+	} else if req.URL.Path == "/api/applications" {
+		return c.Applications(skip)
+	} else if req.URL.Path == "/api/v2/usergroups" {
+		return c.UserGroups(skip)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/v2/usergroups/"), "/")
+	if len(parts) == 2 && parts[1] == "members" {
+		return c.GroupMembers(parts[0])
+	} else if len(parts) == 2 && parts[1] == "associations" && req.URL.Query().Get("targets") == "application" {
+		return c.GroupApplications(parts[0])
+	}
+	return nil, errors.New("Invalid path.")
+}
@@ -0,0 +1,185 @@
+package jumpcloud
+
+import (
+	"fmt"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/iam/jumpcloud_utility"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &jumpcloud_utility.MockJumpCloudClient{
+		SystemUsers: func(skip int) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"totalCount":3,"results":[{"_id":"5f7e1a2b3c4d5e6f7a8b9c01","username":"mike","email":"mike@grchive.com","firstname":"Mike","lastname":"Bao","displayname":"Mike Bao","state":"ACTIVATED","activated":true,"suspended":false,"account_locked":false,"totp_enabled":true,"sudo":true,"created":"2020-09-01T12:00:00.000Z","mfa":{"configured":true,"exclusion":false}},{"_id":"5f7e1a2b3c4d5e6f7a8b9c02","username":"jane","email":"jane@grchive.com","firstname":"Jane","lastname":"Doe","displayname":"","state":"SUSPENDED","activated":true,"suspended":true,"account_locked":true,"totp_enabled":false,"sudo":false,"created":"2020-09-02T12:00:00.000Z","mfa":{"configured":false,"exclusion":true,"exclusionUntil":"2020-11-01T00:00:00.000Z"}},{"_id":"5f7e1a2b3c4d5e6f7a8b9c03","username":"bob","email":"bob@grchive.com","firstname":"Bob","lastname":"Smith","state":"STAGED","activated":false,"suspended":false,"account_locked":false,"totp_enabled":false,"created":"2020-09-03T12:00:00.000Z","mfa":{"configured":false}}]}`), nil
+		},
+		Applications: func(skip int) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"totalCount":2,"results":[{"_id":"5f7e2a000000000000000001","name":"aws","displayName":"Amazon Web Services (IAM)","displayLabel":"AWS Production","ssoUrl":"https://sso.jumpcloud.com/saml2/aws"},{"_id":"5f7e2a000000000000000002","name":"github","displayName":"GitHub","displayLabel":"","ssoUrl":"https://sso.jumpcloud.com/saml2/github"}]}`), nil
+		},
+		UserGroups: func(skip int) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`[{"id":"5f7e3b000000000000000001","name":"Engineering","type":"user_group"},{"id":"5f7e3b000000000000000002","name":"All Staff","type":"user_group"}]`), nil
+		},
+		GroupMembers: func(id string) (*http.Response, error) {
+			if id == "5f7e3b000000000000000001" {
+				return test_utility.WrapHttpResponse(`[{"attributes":null,"to":{"attributes":null,"id":"5f7e1a2b3c4d5e6f7a8b9c01","type":"user"}}]`), nil
+			}
+			return test_utility.WrapHttpResponse(`[{"attributes":null,"to":{"attributes":null,"id":"5f7e1a2b3c4d5e6f7a8b9c01","type":"user"}},{"attributes":null,"to":{"attributes":null,"id":"5f7e1a2b3c4d5e6f7a8b9c02","type":"user"}}]`), nil
+		},
+		GroupApplications: func(id string) (*http.Response, error) {
+			if id == "5f7e3b000000000000000001" {
+				return test_utility.WrapHttpResponse(`[{"attributes":null,"to":{"attributes":null,"id":"5f7e2a000000000000000001","type":"application"}},{"attributes":null,"to":{"attributes":null,"id":"5f7e2a000000000000000002","type":"application"}}]`), nil
+			}
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+	}
+
+	conn, err := CreateJumpCloudConnector(&EtlJumpCloudOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	// Users, applications and groups, then the members and applications of both groups.
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(7))
+
+	timePtr := func(v string) *time.Time {
+		t, err := time.Parse(time.RFC3339, v)
+		g.Expect(err).To(gomega.BeNil())
+		return &t
+	}
+
+	engineeringRole := &types.EtlRole{
+		Name: "Engineering",
+		Permissions: map[string][]string{
+			"app::AWS Production": []string{"access"},
+			"app::github":         []string{"access"},
+		},
+	}
+
+	allStaffRole := &types.EtlRole{
+		Name:        "All Staff",
+		Permissions: map[string][]string{},
+	}
+
+	refUsers := map[string]*types.EtlUser{
+		"mike": &types.EtlUser{
+			Username:    "mike",
+			FullName:    "Mike Bao",
+			Email:       "mike@grchive.com",
+			CreatedTime: timePtr("2020-09-01T12:00:00Z"),
+			Roles: map[string]*types.EtlRole{
+				"Engineering": engineeringRole,
+				"All Staff":   allStaffRole,
+			},
+			Attributes: map[string]string{
+				JumpCloudAttributeUserId:        "5f7e1a2b3c4d5e6f7a8b9c01",
+				JumpCloudAttributeState:         "ACTIVATED",
+				JumpCloudAttributeActivated:     "true",
+				JumpCloudAttributeSuspended:     "false",
+				JumpCloudAttributeAccountLocked: "false",
+				JumpCloudAttributeMfaConfigured: "true",
+				JumpCloudAttributeTotpEnabled:   "true",
+				JumpCloudAttributeSudo:          "true",
+			},
+		},
+		"jane": &types.EtlUser{
+			Username:    "jane",
+			FullName:    "Jane Doe",
+			Email:       "jane@grchive.com",
+			CreatedTime: timePtr("2020-09-02T12:00:00Z"),
+			Roles: map[string]*types.EtlRole{
+				"All Staff": allStaffRole,
+			},
+			Attributes: map[string]string{
+				JumpCloudAttributeUserId:        "5f7e1a2b3c4d5e6f7a8b9c02",
+				JumpCloudAttributeState:         "SUSPENDED",
+				JumpCloudAttributeSuspended:     "true",
+				JumpCloudAttributeAccountLocked: "true",
+				JumpCloudAttributeMfaConfigured: "false",
+				JumpCloudAttributeTotpEnabled:   "false",
+			},
+		},
+		"bob": &types.EtlUser{
+			Username:    "bob",
+			FullName:    "Bob Smith",
+			Email:       "bob@grchive.com",
+			CreatedTime: timePtr("2020-09-03T12:00:00Z"),
+			Roles:       map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				JumpCloudAttributeUserId:    "5f7e1a2b3c4d5e6f7a8b9c03",
+				JumpCloudAttributeState:     "STAGED",
+				JumpCloudAttributeActivated: "false",
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
+
+func TestUserListingMultiplePages(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	const totalUsers = 150
+
+	skips := []int{}
+	client := &jumpcloud_utility.MockJumpCloudClient{
+		SystemUsers: func(skip int) (*http.Response, error) {
+			skips = append(skips, skip)
+
+			results := []string{}
+			for i := skip; i < totalUsers && i < skip+100; i++ {
+				results = append(results, fmt.Sprintf(`{"_id":"user%d","username":"user%d","created":"2020-09-01T12:00:00.000Z"}`, i, i))
+			}
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`{"totalCount":%d,"results":[%s]}`, totalUsers, strings.Join(results, ","))), nil
+		},
+		Applications: func(skip int) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"totalCount":0,"results":[]}`), nil
+		},
+		UserGroups: func(skip int) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+	}
+
+	conn, err := CreateJumpCloudConnector(&EtlJumpCloudOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(users)).To(gomega.Equal(totalUsers))
+	g.Expect(len(source.Commands)).To(gomega.Equal(4))
+	g.Expect(skips).To(gomega.Equal([]int{0, 100}))
+}
+
+func TestUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &jumpcloud_utility.MockJumpCloudClient{
+		SystemUsers: func(skip int) (*http.Response, error) {
+			resp := test_utility.WrapHttpResponse(`{"message":"Unauthorized"}`)
+			resp.StatusCode = http.StatusUnauthorized
+			return resp, nil
+		},
+	}
+
+	conn, err := CreateJumpCloudConnector(&EtlJumpCloudOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.Equal(`JumpCloud API Error: {"message":"Unauthorized"}`))
+}
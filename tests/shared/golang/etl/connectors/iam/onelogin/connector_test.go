@@ -0,0 +1,54 @@
+package onelogin
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"testing"
+)
+
+func TestCreateOneLoginConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &http.Client{}
+
+	conn, err := CreateOneLoginConnector(&EtlOneLoginOptions{
+		Client:    client,
+		Subdomain: "grchive",
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.Subdomain).To(gomega.Equal("grchive"))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
+
+func TestOneLoginApiBaseUrl(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, test := range []struct {
+		subdomain string
+		refUrl    string
+	}{
+		{
+			subdomain: "grchive",
+			refUrl:    "https://grchive.onelogin.com/api/2",
+		},
+		{
+			subdomain: "grchive.onelogin.com",
+			refUrl:    "https://grchive.onelogin.com/api/2",
+		},
+		{
+			subdomain: "api.eu.onelogin.com",
+			refUrl:    "https://api.eu.onelogin.com/api/2",
+		},
+	} {
+		opts := EtlOneLoginOptions{
+			Subdomain: test.subdomain,
+		}
+		g.Expect(opts.apiBaseUrl()).To(gomega.Equal(test.refUrl))
+	}
+}
@@ -0,0 +1,67 @@
+package onelogin_utility
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type MockOneLoginCursorFn func(cursor string) (*http.Response, error)
+type MockOneLoginIdCursorFn func(id int64, cursor string) (*http.Response, error)
+type MockOneLoginIdFn func(id int64) (*http.Response, error)
+
+type MockOneLoginClient struct {
+	Users       MockOneLoginCursorFn
+	Roles       MockOneLoginCursorFn
+	RoleUsers   MockOneLoginIdCursorFn
+	RoleApps    MockOneLoginIdCursorFn
+	UserApps    MockOneLoginIdFn
+	UserDevices MockOneLoginIdFn
+}
+
+// WithAfterCursor sets the header OneLogin uses to point to the next page of results.
+func WithAfterCursor(resp *http.Response, cursor string) *http.Response {
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	resp.Header.Set("After-Cursor", cursor)
+	return resp
+}
+
+func (c *MockOneLoginClient) Do(req *http.Request) (*http.Response, error) {
+	cursor := req.URL.Query().Get("cursor")
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/2/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "users":
+		return c.Users(cursor)
+	case len(parts) == 1 && parts[0] == "roles":
+		return c.Roles(cursor)
+	case len(parts) == 3 && parts[0] == "roles" && parts[2] == "users":
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return c.RoleUsers(id, cursor)
+	case len(parts) == 3 && parts[0] == "roles" && parts[2] == "apps":
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return c.RoleApps(id, cursor)
+	case len(parts) == 3 && parts[0] == "users" && parts[2] == "apps":
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return c.UserApps(id)
+	case len(parts) == 4 && parts[0] == "mfa" && parts[1] == "users" && parts[3] == "devices":
+		id, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return c.UserDevices(id)
+	}
+	return nil, errors.New("Invalid path.")
+}
@@ -0,0 +1,188 @@
+package onelogin
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/iam/onelogin_utility"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUserListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	userCursors := []string{}
+	client := &onelogin_utility.MockOneLoginClient{
+		Users: func(cursor string) (*http.Response, error) {
+			userCursors = append(userCursors, cursor)
+			if cursor == "" {
+				return onelogin_utility.WithAfterCursor(test_utility.WrapHttpResponse(`[{"id":1001,"username":"mike","email":"mike@grchive.com","firstname":"Mike","lastname":"Bao","status":1,"state":1,"created_at":"2020-09-01T12:00:00.000Z","updated_at":"2020-10-01T12:00:00.000Z","last_login":"2020-10-07T15:04:05.000Z","directory_id":null,"role_ids":[10,11]},{"id":1002,"username":"","email":"jane@grchive.com","firstname":"Jane","lastname":"Doe","status":2,"state":1,"created_at":"2020-09-02T12:00:00.000Z","updated_at":"2020-10-02T12:00:00.000Z","last_login":null}]`), "cGFnZTI="), nil
+			}
+			return test_utility.WrapHttpResponse(`[{"id":1003,"username":"bob","email":"bob@grchive.com","firstname":"Bob","lastname":"Smith","status":0,"state":0,"created_at":"2020-09-03T12:00:00.000Z","updated_at":"2020-10-03T12:00:00.000Z","last_login":null}]`), nil
+		},
+		Roles: func(cursor string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`[{"id":10,"name":"Administrators"},{"id":11,"name":"Engineering"}]`), nil
+		},
+		RoleUsers: func(id int64, cursor string) (*http.Response, error) {
+			switch id {
+			case 10:
+				return test_utility.WrapHttpResponse(`[{"id":1001,"name":"Mike Bao"}]`), nil
+			case 11:
+				return test_utility.WrapHttpResponse(`[{"id":1001,"name":"Mike Bao"},{"id":1002,"name":"Jane Doe"}]`), nil
+			}
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+		RoleApps: func(id int64, cursor string) (*http.Response, error) {
+			switch id {
+			case 10:
+				return test_utility.WrapHttpResponse(`[{"id":501,"connector_id":50001,"name":"Amazon Web Services","description":"","visible":true,"auth_method":2,"auth_method_description":"SAML2.0"}]`), nil
+			case 11:
+				return test_utility.WrapHttpResponse(`[{"id":502,"connector_id":50002,"name":"GitHub","description":"","visible":true,"auth_method":2,"auth_method_description":"SAML2.0"}]`), nil
+			}
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+		UserApps: func(id int64) (*http.Response, error) {
+			switch id {
+			case 1001:
+				return test_utility.WrapHttpResponse(`[{"id":501,"icon_url":"https://cdn.onelogin.com/images/icons/square/aws.png","login_id":90001,"name":"Amazon Web Services","provisioning_status":"enabled","provisioning_state":"enabled","provisioning_enabled":true},{"id":502,"icon_url":"https://cdn.onelogin.com/images/icons/square/github.png","login_id":90002,"name":"GitHub","provisioning_status":null,"provisioning_state":null,"provisioning_enabled":false},{"id":503,"icon_url":"https://cdn.onelogin.com/images/icons/square/slack.png","login_id":90004,"name":"Slack","provisioning_status":null,"provisioning_state":null,"provisioning_enabled":false}]`), nil
+			case 1002:
+				return test_utility.WrapHttpResponse(`[{"id":502,"icon_url":"https://cdn.onelogin.com/images/icons/square/github.png","login_id":90003,"name":"GitHub","provisioning_status":null,"provisioning_state":null,"provisioning_enabled":false}]`), nil
+			}
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+		UserDevices: func(id int64) (*http.Response, error) {
+			if id == 1001 {
+				return test_utility.WrapHttpResponse(`[{"device_id":"8f4a1c2e","user_display_name":"Mike's Phone","type_display_name":"OneLogin Protect","auth_factor_name":"OneLogin","default":true},{"device_id":"9b2d3e4f","user_display_name":"YubiKey","type_display_name":"YubiKey","auth_factor_name":"Yubico","default":false},{"device_id":"a1b2c3d4","user_display_name":"Backup Phone","type_display_name":"OneLogin Protect","auth_factor_name":"OneLogin","default":false}]`), nil
+			}
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+	}
+
+	conn, err := CreateOneLoginConnector(&EtlOneLoginOptions{
+		Client:    client,
+		Subdomain: "grchive",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	// 2 user pages, 1 role page, 2 requests for each of the 2 roles, 2 requests for each of the 3 users.
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(13))
+	g.Expect(userCursors).To(gomega.Equal([]string{"", "cGFnZTI="}))
+
+	timePtr := func(v string) *time.Time {
+		t, err := time.Parse(time.RFC3339, v)
+		g.Expect(err).To(gomega.BeNil())
+		return &t
+	}
+
+	adminRole := &types.EtlRole{
+		Name: "Administrators",
+		Permissions: map[string][]string{
+			"app::Amazon Web Services": []string{"access"},
+		},
+	}
+
+	engineeringRole := &types.EtlRole{
+		Name: "Engineering",
+		Permissions: map[string][]string{
+			"app::GitHub": []string{"access"},
+		},
+	}
+
+	refUsers := map[string]*types.EtlUser{
+		"mike": &types.EtlUser{
+			Username:       "mike",
+			FullName:       "Mike Bao",
+			Email:          "mike@grchive.com",
+			CreatedTime:    timePtr("2020-09-01T12:00:00Z"),
+			LastChangeTime: timePtr("2020-10-01T12:00:00Z"),
+			Roles: map[string]*types.EtlRole{
+				"Administrators": adminRole,
+				"Engineering":    engineeringRole,
+				"Direct App Assignments": &types.EtlRole{
+					Name: "Direct App Assignments",
+					Permissions: map[string][]string{
+						"app::Slack": []string{"access"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				OneLoginAttributeUserId:      "1001",
+				OneLoginAttributeStatus:      "active",
+				OneLoginAttributeState:       "approved",
+				OneLoginAttributeMfaEnrolled: "true",
+				OneLoginAttributeMfaFactors:  "OneLogin,Yubico",
+				OneLoginAttributeLastLogin:   "2020-10-07T15:04:05.000Z",
+			},
+		},
+		"jane@grchive.com": &types.EtlUser{
+			Username:       "jane@grchive.com",
+			FullName:       "Jane Doe",
+			Email:          "jane@grchive.com",
+			CreatedTime:    timePtr("2020-09-02T12:00:00Z"),
+			LastChangeTime: timePtr("2020-10-02T12:00:00Z"),
+			Roles: map[string]*types.EtlRole{
+				"Engineering": engineeringRole,
+			},
+			Attributes: map[string]string{
+				OneLoginAttributeUserId:      "1002",
+				OneLoginAttributeStatus:      "suspended",
+				OneLoginAttributeState:       "approved",
+				OneLoginAttributeMfaEnrolled: "false",
+				OneLoginAttributeMfaFactors:  "",
+			},
+		},
+		"bob": &types.EtlUser{
+			Username:       "bob",
+			FullName:       "Bob Smith",
+			Email:          "bob@grchive.com",
+			CreatedTime:    timePtr("2020-09-03T12:00:00Z"),
+			LastChangeTime: timePtr("2020-10-03T12:00:00Z"),
+			Roles:          map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				OneLoginAttributeUserId:      "1003",
+				OneLoginAttributeStatus:      "unactivated",
+				OneLoginAttributeState:       "unapproved",
+				OneLoginAttributeMfaEnrolled: "false",
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+
+	for _, u := range users {
+		if u.Username == "jane@grchive.com" || u.Username == "bob" {
+			_, ok := u.Attributes[OneLoginAttributeLastLogin]
+			g.Expect(ok).To(gomega.BeFalse())
+		}
+	}
+}
+
+func TestUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &onelogin_utility.MockOneLoginClient{
+		Users: func(cursor string) (*http.Response, error) {
+			resp := test_utility.WrapHttpResponse(`{"statusCode":401,"name":"Unauthorized","message":"Authentication Failure"}`)
+			resp.StatusCode = http.StatusUnauthorized
+			return resp, nil
+		},
+	}
+
+	conn, err := CreateOneLoginConnector(&EtlOneLoginOptions{
+		Client:    client,
+		Subdomain: "grchive",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.Equal(`OneLogin API Error: {"statusCode":401,"name":"Unauthorized","message":"Authentication Failure"}`))
+}
@@ -0,0 +1,28 @@
+package scim
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"testing"
+)
+
+func TestCreateScimConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &http.Client{}
+
+	conn, err := CreateScimConnector(&EtlScimOptions{
+		Client:  client,
+		BaseUrl: "https://api.slack.com/scim/v2/",
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.apiBaseUrl()).To(gomega.Equal("https://api.slack.com/scim/v2"))
+	g.Expect(conn.opts.pageSize()).To(gomega.Equal(scimDefaultPageSize))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
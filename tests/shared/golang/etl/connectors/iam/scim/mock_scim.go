@@ -0,0 +1,23 @@
+package scim_utility
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+type MockScimFn func(query url.Values) (*http.Response, error)
+
+type MockScimClient struct {
+	Users  MockScimFn
+	Groups MockScimFn
+}
+
+func (c *MockScimClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/scim/v2/Users" && c.Users != nil {
+		return c.Users(req.URL.Query())
+	} else if req.URL.Path == "/scim/v2/Groups" && c.Groups != nil {
+		return c.Groups(req.URL.Query())
+	}
+	return nil, errors.New("Invalid path.")
+}
@@ -0,0 +1,223 @@
+package scim
+
+import (
+	"fmt"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/iam/scim_utility"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var refTime1 = time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+var refTime2 = time.Date(2021, 8, 9, 10, 11, 12, 0, time.UTC)
+
+const refUser1 = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"],
+	"id": "U01",
+	"externalId": "00u1abc",
+	"userName": "mike@grchive.com",
+	"name": {"givenName": "Michael", "familyName": "Bao"},
+	"displayName": "mike",
+	"userType": "Employee",
+	"active": true,
+	"emails": [{"value": "mike@personal.com", "type": "home"}, {"value": "mike@grchive.com", "type": "work", "primary": true}],
+	"groups": [{"value": "G01", "display": "Engineering"}],
+	"roles": [{"value": "admin"}],
+	"entitlements": [{"value": "billing"}],
+	"meta": {"resourceType": "User", "created": "%s", "lastModified": "%s"},
+	"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "R&D", "employeeNumber": 42, "manager": {"value": "U02", "displayName": "Boss"}}
+}`
+
+const refUser2 = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	"id": "U02",
+	"userName": "boss@grchive.com",
+	"name": {"formatted": "The Boss"},
+	"active": false,
+	"emails": [{"value": "boss@grchive.com"}],
+	"urn:scim:schemas:extension:slack:guest:1.0:User": {"type": "multi", "expiration": "2030-01-01T00:00:00Z"}
+}`
+
+const refUser3 = `{
+	"id": "U03",
+	"userName": "contractor",
+	"displayName": "Contractor"
+}`
+
+func listResponse(total int, startIndex int, resources ...string) *http.Response {
+	items := ""
+	for i, r := range resources {
+		if i > 0 {
+			items += ","
+		}
+		items += r
+	}
+
+	return test_utility.WrapHttpResponse(fmt.Sprintf(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:ListResponse"],
+		"totalResults": %d,
+		"startIndex": %d,
+		"itemsPerPage": %d,
+		"Resources": [%s]
+	}`, total, startIndex, len(resources), items))
+}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	userQueries := []url.Values{}
+	groupQueries := []url.Values{}
+
+	client := &scim_utility.MockScimClient{
+		Users: func(query url.Values) (*http.Response, error) {
+			userQueries = append(userQueries, query)
+			if query.Get("startIndex") == "1" {
+				return listResponse(3, 1, fmt.Sprintf(refUser1, refTime1.Format(time.RFC3339), refTime2.Format(time.RFC3339)), refUser2), nil
+			}
+			return listResponse(3, 3, refUser3), nil
+		},
+		Groups: func(query url.Values) (*http.Response, error) {
+			groupQueries = append(groupQueries, query)
+			// Ignores paging.
+			return listResponse(3, 1,
+				`{"id": "G01", "displayName": "Engineering", "members": [{"value": "U01", "type": "User"}]}`,
+				`{"id": "G02", "displayName": "Everyone", "members": [{"value": "G01", "type": "Group"}, {"value": "U02", "type": "User"}]}`,
+				`{"id": "G03", "displayName": "Contractors", "members": [{"value": "U03", "type": "User"}]}`,
+			), nil
+		},
+	}
+
+	conn, err := CreateScimConnector(&EtlScimOptions{
+		Client:     client,
+		BaseUrl:    "https://api.slack.com/scim/v2",
+		UserFilter: `userName ne "bot"`,
+		PageSize:   2,
+		ExtensionAttributes: []EtlScimExtensionAttribute{
+			{Path: "department"},
+			{Path: "employeeNumber", Name: "employee_number"},
+			{Path: "manager.value", Name: "manager"},
+			{Schema: "urn:scim:schemas:extension:slack:guest:1.0:User", Path: "type", Name: "guest_type"},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(3))
+	g.Expect(source.Commands[0].Command).To(gomega.Equal("https://api.slack.com/scim/v2/Users?count=2&filter=userName+ne+%22bot%22&startIndex=1"))
+
+	g.Expect(userQueries).To(gomega.Equal([]url.Values{
+		url.Values{"startIndex": []string{"1"}, "count": []string{"2"}, "filter": []string{`userName ne "bot"`}},
+		url.Values{"startIndex": []string{"3"}, "count": []string{"2"}, "filter": []string{`userName ne "bot"`}},
+	}))
+	g.Expect(groupQueries).To(gomega.Equal([]url.Values{
+		url.Values{"startIndex": []string{"1"}, "count": []string{"2"}},
+	}))
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"mike@grchive.com": &types.EtlUser{
+			Username:       "mike@grchive.com",
+			FullName:       "mike",
+			Email:          "mike@grchive.com",
+			CreatedTime:    &refTime1,
+			LastChangeTime: &refTime2,
+			Attributes: map[string]string{
+				ScimAttributeId:         "U01",
+				ScimAttributeExternalId: "00u1abc",
+				ScimAttributeActive:     "true",
+				ScimAttributeUserType:   "Employee",
+				"department":            "R&D",
+				"employee_number":       "42",
+				"manager":               "U02",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						ScimRolesObject:        []string{"admin"},
+						ScimEntitlementsObject: []string{"billing"},
+					},
+				},
+				"Engineering": &types.EtlRole{Name: "Engineering"},
+				"Everyone":    &types.EtlRole{Name: "Everyone"},
+			},
+		},
+		"boss@grchive.com": &types.EtlUser{
+			Username: "boss@grchive.com",
+			FullName: "The Boss",
+			Email:    "boss@grchive.com",
+			Attributes: map[string]string{
+				ScimAttributeActive: "false",
+				"guest_type":        "multi",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Everyone": &types.EtlRole{Name: "Everyone"},
+			},
+		},
+		"contractor": &types.EtlUser{
+			Username: "contractor",
+			FullName: "Contractor",
+			Attributes: map[string]string{
+				ScimAttributeActive: "true",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Contractors": &types.EtlRole{Name: "Contractors"},
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+
+	g.Expect(users[1].Attributes).NotTo(gomega.HaveKey("department"))
+}
+
+func TestGetUserListingSkipGroups(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &scim_utility.MockScimClient{
+		Users: func(query url.Values) (*http.Response, error) {
+			return listResponse(1, 1, fmt.Sprintf(refUser1, refTime1.Format(time.RFC3339), refTime2.Format(time.RFC3339))), nil
+		},
+	}
+
+	conn, err := CreateScimConnector(&EtlScimOptions{
+		Client:     client,
+		BaseUrl:    "https://api.slack.com/scim/v2",
+		SkipGroups: true,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+	g.Expect(len(users)).To(gomega.Equal(1))
+	g.Expect(users[0].Roles).To(gomega.HaveKey("Engineering"))
+	g.Expect(users[0].Roles).NotTo(gomega.HaveKey("Everyone"))
+}
+
+func TestGetUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &scim_utility.MockScimClient{
+		Users: func(query url.Values) (*http.Response, error) {
+			resp := test_utility.WrapHttpResponse(`{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"detail":"invalid_authentication","status":"401"}`)
+			resp.StatusCode = http.StatusUnauthorized
+			return resp, nil
+		},
+	}
+
+	conn, err := CreateScimConnector(&EtlScimOptions{
+		Client:  client,
+		BaseUrl: "https://api.slack.com/scim/v2",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("invalid_authentication"))
+}
@@ -0,0 +1,22 @@
+package vault
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestCreateVaultConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateVaultConnector(&EtlVaultOptions{
+		Address: "https://vault.grchive.com:8200/",
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.apiBaseUrl()).To(gomega.Equal("https://vault.grchive.com:8200/v1"))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
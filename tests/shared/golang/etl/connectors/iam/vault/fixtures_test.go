@@ -0,0 +1,14 @@
+package vault
+
+const refAdminPolicy = `# Admins manage everything except the audit log.
+path "*" {
+  capabilities = ["create", "read", "update", "delete", "list", "sudo"]
+}
+
+/*
+ * Nobody should be able to turn off auditing.
+ */
+path "sys/audit*" {
+  capabilities = ["deny"]
+}
+`
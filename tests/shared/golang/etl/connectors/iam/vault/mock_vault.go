@@ -0,0 +1,70 @@
+package vault_utility
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// FakeVaultServer responds with recorded Vault API responses to clients with the right token.
+type FakeVaultServer struct {
+	Server *httptest.Server
+
+	// Response bodies keyed by the path (e.g. /v1/sys/auth). LIST requests are keyed by the path
+	// followed by ?list=true. Paths without a response return a 404 like Vault does for empty lists.
+	Responses map[string]string
+	Token     string
+
+	mutex    sync.Mutex
+	requests []string
+}
+
+func NewFakeVaultServer(token string, responses map[string]string) *FakeVaultServer {
+	fake := &FakeVaultServer{
+		Responses: responses,
+		Token:     token,
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	return fake
+}
+
+func (f *FakeVaultServer) URL() string {
+	return f.Server.URL
+}
+
+func (f *FakeVaultServer) Close() {
+	f.Server.Close()
+}
+
+// Requests returns the path and query of every request that was made.
+func (f *FakeVaultServer) Requests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.requests...)
+}
+
+func (f *FakeVaultServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	f.requests = append(f.requests, r.URL.RequestURI())
+	f.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Header.Get("X-Vault-Token") != f.Token {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+
+	key := r.URL.Path
+	if r.URL.Query().Get("list") == "true" {
+		key += "?list=true"
+	}
+
+	body, ok := f.Responses[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+		return
+	}
+	w.Write([]byte(body))
+}
@@ -0,0 +1,117 @@
+package vault
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"testing"
+)
+
+func TestParseVaultPolicy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, test := range []struct {
+		policy string
+		role   types.EtlRole
+	}{
+		{
+			refAdminPolicy,
+			types.EtlRole{
+				Permissions: map[string][]string{
+					"*": []string{"create", "read", "update", "delete", "list", "sudo"},
+				},
+				Denied: map[string][]string{
+					"sys/audit*": []string{"deny"},
+				},
+			},
+		},
+		{
+			// Rules for the same path are merged and deny wins over everything else.
+			`path "secret/data/+/config" {
+  capabilities = ["read",]
+  allowed_parameters = {
+    "ttl" = []
+    "*"   = ["a", "b"]
+  }
+  min_wrapping_ttl = "1s"
+  max_wrapping_ttl = 90
+}
+
+// Later rules add to the earlier ones.
+path "secret/data/+/config" { capabilities = ["list"] }
+path "secret/data/team" { capabilities = ["read"] }
+path "secret/data/team" { capabilities = ["deny"] }
+name = "ignored"
+`,
+			types.EtlRole{
+				Permissions: map[string][]string{
+					"secret/data/+/config": []string{"read", "list"},
+				},
+				Denied: map[string][]string{
+					"secret/data/team": []string{"deny"},
+				},
+			},
+		},
+		{
+			`path "secret/legacy/*" { policy = "write" }
+path "secret/legacy/ro/*" { policy = "read" }
+path "secret/legacy/no/*" { policy = "deny" }`,
+			types.EtlRole{
+				Permissions: map[string][]string{
+					"secret/legacy/*":    []string{"create", "read", "update", "delete", "list"},
+					"secret/legacy/ro/*": []string{"read", "list"},
+				},
+				Denied: map[string][]string{
+					"secret/legacy/no/*": []string{"deny"},
+				},
+			},
+		},
+		{
+			` {"path": {"secret/metadata/*": {"capabilities": ["list"]}, "secret/data/*": {"capabilities": ["read"]}, "sys/*": {"capabilities": ["deny"]}}}`,
+			types.EtlRole{
+				Permissions: map[string][]string{
+					"secret/data/*":     []string{"read"},
+					"secret/metadata/*": []string{"list"},
+				},
+				Denied: map[string][]string{
+					"sys/*": []string{"deny"},
+				},
+			},
+		},
+		{
+			"",
+			types.EtlRole{
+				Permissions: map[string][]string{},
+				Denied:      map[string][]string{},
+			},
+		},
+	} {
+		role, err := parseVaultPolicy("test", test.policy)
+		g.Expect(err).To(gomega.BeNil(), test.policy)
+
+		test.role.Name = "test"
+		g.Expect(*role).To(gomega.Equal(test.role), test.policy)
+	}
+
+	root, err := parseVaultPolicy("root", "")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(root.Permissions).To(gomega.HaveKeyWithValue("*", gomega.ContainElement("sudo")))
+}
+
+func TestParseVaultPolicyErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, policy := range []string{
+		`path "secret/*" { capabilities = ["read"]`,
+		`path "secret/*" { capabilities = ["read" "list"] }`,
+		`path "secret/* { capabilities = ["read"] }`,
+		`path "secret/*" { capabilities = "read" }`,
+		`path "secret/*" "other" { capabilities = ["read"] }`,
+		`path "secret/*" capabilities`,
+		`path "secret/*" { capabilities = <<EOF }`,
+		`/* path "secret/*" { capabilities = ["read"] }`,
+		`{"path": []}`,
+	} {
+		_, err := parseVaultPolicy("test", policy)
+		g.Expect(err).NotTo(gomega.BeNil(), policy)
+	}
+}
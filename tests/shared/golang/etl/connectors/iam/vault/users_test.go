@@ -0,0 +1,256 @@
+package vault
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/shared/utility/http"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/iam/vault_utility"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const refToken = "s.FakeVaultToken"
+
+func createTestServer() *vault_utility.FakeVaultServer {
+	return vault_utility.NewFakeVaultServer(refToken, map[string]string{
+		"/v1/sys/auth": `{"request_id":"1","data":{
+			"approle/":{"type":"approle","accessor":"auth_approle_4","description":""},
+			"ldap/":{"type":"ldap","accessor":"auth_ldap_2","description":"Corporate directory"},
+			"oidc/":{"type":"oidc","accessor":"auth_oidc_3","description":""},
+			"token/":{"type":"token","accessor":"auth_token_5","description":"token based credentials"},
+			"userpass/":{"type":"userpass","accessor":"auth_userpass_1","description":""}
+		}}`,
+
+		"/v1/sys/policies/acl?list=true": `{"data":{"keys":["admin","default","kv-read","legacy","root"]}}`,
+		"/v1/sys/policies/acl/admin":     `{"data":{"name":"admin","policy":` + strconv.Quote(refAdminPolicy) + `}}`,
+		"/v1/sys/policies/acl/default":   `{"data":{"name":"default","policy":"path \"auth/token/lookup-self\" {\n  capabilities = [\"read\"]\n}\n"}}`,
+		"/v1/sys/policies/acl/kv-read":   `{"data":{"name":"kv-read","policy":"{\"path\":{\"secret/data/*\":{\"capabilities\":[\"read\"]},\"secret/metadata/*\":{\"capabilities\":[\"list\"]}}}"}}`,
+		"/v1/sys/policies/acl/legacy":    `{"data":{"name":"legacy","policy":"path \"secret/legacy/*\" { policy = \"write\" }"}}`,
+
+		"/v1/identity/group/id?list=true": `{"data":{"keys":["g1","g2"]}}`,
+		"/v1/identity/group/id/g1":        `{"data":{"id":"g1","name":"engineering","type":"internal","policies":["kv-read"],"member_entity_ids":["e1"],"member_group_ids":null,"parent_group_ids":["g2"]}}`,
+		"/v1/identity/group/id/g2":        `{"data":{"id":"g2","name":"platform","type":"internal","policies":["admin"],"member_entity_ids":[],"member_group_ids":["g1"]}}`,
+
+		"/v1/identity/entity/id?list=true": `{"data":{"keys":["e1","e2","e3","e4"],"key_info":{"e1":{"name":"alice"},"e2":{"name":"ci"},"e3":{"name":"carol"},"e4":{"name":"deleted"}}}}`,
+		"/v1/identity/entity/id/e1": `{"data":{"id":"e1","name":"alice","disabled":false,"policies":["missing"],
+			"group_ids":["g1"],"direct_group_ids":["g1"],"inherited_group_ids":["g2"],
+			"metadata":{"email":"alice@grchive.com"},
+			"creation_time":"2020-01-01T00:00:00.123456Z","last_update_time":"2020-06-01T12:00:00Z",
+			"aliases":[
+				{"id":"a1","name":"alice","mount_accessor":"auth_userpass_1","mount_path":"userpass/","mount_type":"userpass"},
+				{"id":"a2","name":"Alice","mount_accessor":"auth_ldap_2","mount_path":"ldap/","mount_type":"ldap"}
+			]}}`,
+		"/v1/identity/entity/id/e2": `{"data":{"id":"e2","name":"ci","disabled":true,"policies":null,"group_ids":null,"metadata":null,
+			"creation_time":"2020-01-01T00:00:00Z","last_update_time":"2020-01-01T00:00:00Z",
+			"aliases":[{"id":"a3","name":"11111111-2222-3333-4444-555555555555","mount_accessor":"auth_approle_4","mount_path":"approle/","mount_type":"approle"}]}}`,
+		"/v1/identity/entity/id/e3": `{"data":{"id":"e3","name":"carol","disabled":false,"policies":[],"group_ids":[],
+			"creation_time":"2020-01-01T00:00:00Z","last_update_time":"2020-01-01T00:00:00Z",
+			"aliases":[{"id":"a4","name":"carol@grchive.com","mount_accessor":"auth_oidc_3","mount_path":"oidc/","mount_type":"oidc"}]}}`,
+
+		"/v1/auth/userpass/users?list=true": `{"data":{"keys":["alice"]}}`,
+		"/v1/auth/userpass/users/alice":     `{"data":{"token_policies":["legacy"],"policies":["legacy"],"token_no_default_policy":false,"token_bound_cidrs":[]}}`,
+
+		"/v1/auth/ldap/users?list=true":  `{"data":{"keys":["alice","bob"]}}`,
+		"/v1/auth/ldap/users/alice":      `{"data":{"policies":[],"groups":"ops"}}`,
+		"/v1/auth/ldap/users/bob":        `{"data":{"policies":"kv-read","groups":""}}`,
+		"/v1/auth/ldap/groups?list=true": `{"data":{"keys":["ops"]}}`,
+		"/v1/auth/ldap/groups/ops":       `{"data":{"policies":["admin"]}}`,
+
+		"/v1/auth/oidc/role?list=true": `{"data":{"keys":["default"]}}`,
+		"/v1/auth/oidc/role/default":   `{"data":{"role_type":"oidc","token_policies":["kv-read"],"bound_claims":{"groups":["eng"]},"user_claim":"email","token_bound_cidrs":["10.0.0.0/8"]}}`,
+
+		"/v1/auth/approle/role?list=true":      `{"data":{"keys":["ci","deploy"]}}`,
+		"/v1/auth/approle/role/ci":             `{"data":{"token_policies":["kv-read"],"token_no_default_policy":true,"bind_secret_id":true}}`,
+		"/v1/auth/approle/role/ci/role-id":     `{"data":{"role_id":"11111111-2222-3333-4444-555555555555"}}`,
+		"/v1/auth/approle/role/deploy":         `{"data":{"token_policies":["admin"],"secret_id_bound_cidrs":["10.1.0.0/16"]}}`,
+		"/v1/auth/approle/role/deploy/role-id": `{"data":{"role_id":"66666666-7777-8888-9999-000000000000"}}`,
+	})
+}
+
+func refRoles() map[string]*types.EtlRole {
+	return map[string]*types.EtlRole{
+		"admin": &types.EtlRole{
+			Name: "admin",
+			Permissions: map[string][]string{
+				"*": []string{"create", "read", "update", "delete", "list", "sudo"},
+			},
+			Denied: map[string][]string{
+				"sys/audit*": []string{"deny"},
+			},
+		},
+		"default": &types.EtlRole{
+			Name: "default",
+			Permissions: map[string][]string{
+				"auth/token/lookup-self": []string{"read"},
+			},
+		},
+		"kv-read": &types.EtlRole{
+			Name: "kv-read",
+			Permissions: map[string][]string{
+				"secret/data/*":     []string{"read"},
+				"secret/metadata/*": []string{"list"},
+			},
+		},
+		"legacy": &types.EtlRole{
+			Name: "legacy",
+			Permissions: map[string][]string{
+				"secret/legacy/*": []string{"create", "read", "update", "delete", "list"},
+			},
+		},
+		"missing": &types.EtlRole{
+			Name: "missing",
+		},
+	}
+}
+
+func pickRoles(names ...string) map[string]*types.EtlRole {
+	all := refRoles()
+	ret := map[string]*types.EtlRole{}
+	for _, n := range names {
+		ret[n] = all[n]
+	}
+	return ret
+}
+
+func TestGetUserListing(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := createTestServer()
+	defer server.Close()
+
+	conn, err := CreateVaultConnector(&EtlVaultOptions{
+		Client:  http_utility.CreateHeaderInjectionClient(map[string]string{"X-Vault-Token": refToken}, nil),
+		Address: server.URL() + "/",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"alice": &types.EtlUser{
+			Username:       "alice",
+			Email:          "alice@grchive.com",
+			CreatedTime:    &created,
+			LastChangeTime: &updated,
+			Roles:          pickRoles("missing", "kv-read", "admin", "legacy", "default"),
+			Attributes: map[string]string{
+				VaultAttributeEntityId: "e1",
+				VaultAttributeDisabled: "false",
+				VaultAttributeGroups:   "engineering,platform",
+				VaultAttributeAliases:  "userpass/alice,ldap/Alice",
+			},
+		},
+		"ci": &types.EtlUser{
+			Username:       "ci",
+			CreatedTime:    &created,
+			LastChangeTime: &created,
+			Roles:          pickRoles("kv-read"),
+			Attributes: map[string]string{
+				VaultAttributeDisabled: "true",
+				VaultAttributeAliases:  "approle/11111111-2222-3333-4444-555555555555",
+			},
+		},
+		"carol": &types.EtlUser{
+			Username:       "carol",
+			CreatedTime:    &created,
+			LastChangeTime: &created,
+			Roles:          pickRoles(),
+			Attributes: map[string]string{
+				VaultAttributeAliases: "oidc/carol@grchive.com",
+			},
+		},
+		"auth/ldap/users/bob": &types.EtlUser{
+			Username: "auth/ldap/users/bob",
+			Roles:    pickRoles("kv-read", "default"),
+			Attributes: map[string]string{
+				VaultAttributeAuthType:  "ldap",
+				VaultAttributeAuthMount: "ldap/",
+			},
+		},
+		"auth/ldap/groups/ops": &types.EtlUser{
+			Username: "auth/ldap/groups/ops",
+			Roles:    pickRoles("admin"),
+		},
+		"auth/oidc/role/default": &types.EtlUser{
+			Username: "auth/oidc/role/default",
+			Roles:    pickRoles("kv-read", "default"),
+			Attributes: map[string]string{
+				VaultAttributeAuthType:    "oidc",
+				VaultAttributeBoundClaims: `{"groups":["eng"]}`,
+				VaultAttributeUserClaim:   "email",
+				VaultAttributeBoundCidrs:  "10.0.0.0/8",
+			},
+		},
+		"auth/approle/role/deploy": &types.EtlUser{
+			Username: "auth/approle/role/deploy",
+			Roles:    pickRoles("admin", "default"),
+			Attributes: map[string]string{
+				VaultAttributeBoundCidrs: "10.1.0.0/16",
+			},
+		},
+	}, test_utility.CompareUserListingOptions{})
+
+	commands := []string{}
+	for _, cmd := range source.Commands {
+		commands = append(commands, cmd.Command)
+	}
+
+	base := server.URL() + "/v1"
+	g.Expect(commands).To(gomega.ContainElement(base + "/sys/auth"))
+	g.Expect(commands).To(gomega.ContainElement(base + "/sys/policies/acl/admin"))
+	g.Expect(commands).To(gomega.ContainElement(base + "/auth/approle/role/ci/role-id"))
+	// Root can't be read and the deleted entity doesn't respond.
+	g.Expect(commands).NotTo(gomega.ContainElement(base + "/sys/policies/acl/root"))
+	g.Expect(commands).NotTo(gomega.ContainElement(base + "/identity/entity/id/e4"))
+	g.Expect(server.Requests()).To(gomega.ContainElement("/v1/identity/entity/id/e4"))
+	// Auth methods without users or roles aren't listed.
+	g.Expect(server.Requests()).NotTo(gomega.ContainElement(gomega.HavePrefix("/v1/auth/token/")))
+}
+
+func TestGetUserListingEmpty(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := vault_utility.NewFakeVaultServer(refToken, map[string]string{
+		"/v1/sys/auth": `{"data":{"token/":{"type":"token","accessor":"auth_token_1"}}}`,
+	})
+	defer server.Close()
+
+	conn, err := CreateVaultConnector(&EtlVaultOptions{
+		Client:  http_utility.CreateHeaderInjectionClient(map[string]string{"X-Vault-Token": refToken}, nil),
+		Address: server.URL(),
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, _, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(users).To(gomega.BeEmpty())
+}
+
+func TestGetUserListingErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server := createTestServer()
+	defer server.Close()
+
+	conn, err := CreateVaultConnector(&EtlVaultOptions{
+		Client:  http_utility.CreateHeaderInjectionClient(map[string]string{"X-Vault-Token": "wrong"}, nil),
+		Address: server.URL(),
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("permission denied"))
+
+	server.Responses["/v1/sys/policies/acl/legacy"] = `{"data":{"name":"legacy","policy":"path \"secret/*\" {"}}`
+	conn.opts.Client = http_utility.CreateHeaderInjectionClient(map[string]string{"X-Vault-Token": refToken}, nil)
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("legacy"))
+}
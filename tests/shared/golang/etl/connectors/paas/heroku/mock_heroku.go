@@ -3,17 +3,31 @@ package heroku_utility
 import (
 	"errors"
 	"net/http"
+	"strings"
 )
 
 type MockHerokuFn func() (*http.Response, error)
+type MockHerokuAppFn func(app string) (*http.Response, error)
 
 type MockHerokuClient struct {
-	TeamMembers MockHerokuFn
+	TeamMembers              MockHerokuFn
+	TeamApps                 MockHerokuFn
+	Apps                     MockHerokuFn
+	TeamAppCollaborators     MockHerokuAppFn
+	PersonalAppCollaborators MockHerokuAppFn
 }
 
 func (c *MockHerokuClient) Do(req *http.Request) (*http.Response, error) {
 	if req.URL.Path == "/teams/test/members" {
 		return c.TeamMembers()
+	} else if req.URL.Path == "/teams/test/apps" {
+		return c.TeamApps()
+	} else if req.URL.Path == "/apps" {
+		return c.Apps()
+	} else if strings.HasPrefix(req.URL.Path, "/teams/apps/") && strings.HasSuffix(req.URL.Path, "/collaborators") {
+		return c.TeamAppCollaborators(strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/teams/apps/"), "/collaborators"))
+	} else if strings.HasPrefix(req.URL.Path, "/apps/") && strings.HasSuffix(req.URL.Path, "/collaborators") {
+		return c.PersonalAppCollaborators(strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/apps/"), "/collaborators"))
 	}
 	return nil, errors.New("Invalid path.")
 }
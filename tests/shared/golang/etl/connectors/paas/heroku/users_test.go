@@ -22,6 +22,12 @@ func TestGetUserListing(t *testing.T) {
 [{"id":"a15ac3ce-fad5-4ea8-8e5e-7b352e6b3c28","created_at":"%s","email":"mike@grchive.com","federated":false,"identity_provider":null,"role":"admin","updated_at":"2020-08-25T19:17:20Z","user":{"id":"c6943e34-95ec-4d6c-87bd-90bbeed025dc","email":"mike@grchive.com","name":"Michael Bao"}}]
 `, refTime1.Format(time.RFC3339))), nil
 		},
+		TeamApps: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+		Apps: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
 	}
 	conn, err := CreateHerokuConnector(&EtlHerokuOptions{
 		Client:   client,
@@ -36,7 +42,7 @@ func TestGetUserListing(t *testing.T) {
 	g.Expect(err).To(gomega.BeNil())
 
 	g.Expect(source).NotTo(gomega.BeNil())
-	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+	g.Expect(len(source.Commands)).To(gomega.Equal(3))
 
 	refUsers := map[string]*types.EtlUser{
 		"mike@grchive.com": &types.EtlUser{
@@ -54,3 +60,93 @@ func TestGetUserListing(t *testing.T) {
 
 	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
 }
+
+func TestGetUserListingWithApps(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &heroku_utility.MockHerokuClient{
+		TeamMembers: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+[{"id":"a15ac3ce-fad5-4ea8-8e5e-7b352e6b3c28","created_at":"%s","email":"mike@grchive.com","federated":false,"identity_provider":null,"role":"admin","updated_at":"2020-08-25T19:17:20Z","user":{"id":"c6943e34-95ec-4d6c-87bd-90bbeed025dc","email":"mike@grchive.com","name":"Michael Bao"}}]
+`, refTime1.Format(time.RFC3339))), nil
+		},
+		TeamApps: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+[{"id":"01234567-89ab-cdef-0123-456789abcdef","name":"grchive-prod","owner":{"id":"11234567-89ab-cdef-0123-456789abcdef","email":"test@herokumanager.com"},"team":{"id":"21234567-89ab-cdef-0123-456789abcdef","name":"test"}}]
+`), nil
+		},
+		Apps: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+[
+	{"id":"01234567-89ab-cdef-0123-456789abcdef","name":"grchive-prod","owner":{"id":"11234567-89ab-cdef-0123-456789abcdef","email":"test@herokumanager.com"},"team":{"id":"21234567-89ab-cdef-0123-456789abcdef","name":"test"}},
+	{"id":"31234567-89ab-cdef-0123-456789abcdef","name":"mike-sandbox","owner":{"id":"c6943e34-95ec-4d6c-87bd-90bbeed025dc","email":"mike@grchive.com"},"team":null}
+]
+`), nil
+		},
+		TeamAppCollaborators: func(app string) (*http.Response, error) {
+			g.Expect(app).To(gomega.Equal("grchive-prod"))
+			return test_utility.WrapHttpResponse(`
+[
+	{"app":{"name":"grchive-prod","id":"01234567-89ab-cdef-0123-456789abcdef"},"created_at":"2012-01-01T12:00:00Z","id":"01234567-89ab-cdef-0123-456789abcdea","permissions":[{"name":"view","description":"View"},{"name":"deploy","description":"Deploy"}],"role":"member","updated_at":"2012-01-01T12:00:00Z","user":{"email":"mike@grchive.com","federated":false,"id":"c6943e34-95ec-4d6c-87bd-90bbeed025dc"}},
+	{"app":{"name":"grchive-prod","id":"01234567-89ab-cdef-0123-456789abcdef"},"created_at":"2012-01-01T12:00:00Z","id":"01234567-89ab-cdef-0123-456789abcdeb","permissions":[{"name":"view","description":"View"}],"role":"collaborator","updated_at":"2012-01-01T12:00:00Z","user":{"email":"contractor@example.com","federated":false,"id":"41234567-89ab-cdef-0123-456789abcdef"}}
+]
+`), nil
+		},
+		PersonalAppCollaborators: func(app string) (*http.Response, error) {
+			g.Expect(app).To(gomega.Equal("mike-sandbox"))
+			return test_utility.WrapHttpResponse(`
+[{"app":{"name":"mike-sandbox","id":"31234567-89ab-cdef-0123-456789abcdef"},"created_at":"2012-01-01T12:00:00Z","id":"01234567-89ab-cdef-0123-456789abcdec","permissions":[],"role":"collaborator","updated_at":"2012-01-01T12:00:00Z","user":{"email":"contractor@example.com","federated":false,"id":"41234567-89ab-cdef-0123-456789abcdef"}}]
+`), nil
+		},
+	}
+	conn, err := CreateHerokuConnector(&EtlHerokuOptions{
+		Client:   client,
+		TeamName: "test",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(5))
+
+	refUsers := map[string]*types.EtlUser{
+		"mike@grchive.com": &types.EtlUser{
+			Username:    "mike@grchive.com",
+			Email:       "mike@grchive.com",
+			FullName:    "Michael Bao",
+			CreatedTime: &refTime1,
+			Roles: map[string]*types.EtlRole{
+				"admin": &types.EtlRole{
+					Name: "admin",
+				},
+				"Apps": &types.EtlRole{
+					Name: "Apps",
+					Permissions: map[string][]string{
+						"grchive-prod": []string{"view", "deploy"},
+						"mike-sandbox": []string{"owner"},
+					},
+				},
+			},
+		},
+		"contractor@example.com": &types.EtlUser{
+			Username: "contractor@example.com",
+			Email:    "contractor@example.com",
+			Roles: map[string]*types.EtlRole{
+				"Apps": &types.EtlRole{
+					Name: "Apps",
+					Permissions: map[string][]string{
+						"grchive-prod": []string{"view"},
+						"mike-sandbox": []string{"collaborator"},
+					},
+				},
+			},
+		},
+	}
+
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
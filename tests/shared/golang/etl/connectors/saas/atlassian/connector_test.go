@@ -0,0 +1,43 @@
+package atlassian
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"testing"
+)
+
+func TestCreateAtlassianConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &http.Client{}
+	refSite := "grchive"
+
+	conn, err := CreateAtlassianConnector(&EtlAtlassianOptions{
+		Client: client,
+		Site:   refSite,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.Site).To(gomega.Equal(refSite))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
+
+func TestAtlassianApiBaseUrl(t *testing.T) {
+	for _, test := range []struct {
+		site string
+		ref  string
+	}{
+		{"grchive", "https://grchive.atlassian.net/rest/api/3"},
+		{"grchive.atlassian.net", "https://grchive.atlassian.net/rest/api/3"},
+		{"https://jira.grchive.com/", "https://jira.grchive.com/rest/api/3"},
+	} {
+		g := gomega.NewGomegaWithT(t)
+		opts := EtlAtlassianOptions{Site: test.site}
+		g.Expect(opts.apiBaseUrl()).To(gomega.Equal(test.ref), test.site)
+	}
+}
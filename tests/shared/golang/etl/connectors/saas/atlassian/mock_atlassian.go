@@ -0,0 +1,52 @@
+package atlassian_utility
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type MockAtlassianFn func() (*http.Response, error)
+type MockAtlassianPageFn func(startAt int) (*http.Response, error)
+type MockAtlassianGroupFn func(groupId string) (*http.Response, error)
+type MockAtlassianProjectFn func(projectKey string) (*http.Response, error)
+type MockAtlassianProjectRoleFn func(projectKey string, roleId string) (*http.Response, error)
+
+type MockAtlassianClient struct {
+	Users            MockAtlassianPageFn
+	Groups           MockAtlassianFn
+	GroupMembers     MockAtlassianGroupFn
+	ApplicationRoles MockAtlassianFn
+	Projects         MockAtlassianFn
+	ProjectRoles     MockAtlassianProjectFn
+	ProjectRole      MockAtlassianProjectRoleFn
+}
+
+func (c *MockAtlassianClient) Do(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.Path, "/rest/api/3/") {
+		return nil, errors.New("Invalid path.")
+	}
+
+	query := req.URL.Query()
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/rest/api/3/"), "/")
+	resource := strings.Join(parts, "/")
+
+	if resource == "users/search" {
+		startAt, _ := strconv.Atoi(query.Get("startAt"))
+		return c.Users(startAt)
+	} else if resource == "group/bulk" {
+		return c.Groups()
+	} else if resource == "group/member" {
+		return c.GroupMembers(query.Get("groupId"))
+	} else if resource == "applicationrole" {
+		return c.ApplicationRoles()
+	} else if resource == "project/search" {
+		return c.Projects()
+	} else if len(parts) == 3 && parts[0] == "project" && parts[2] == "role" {
+		return c.ProjectRoles(parts[1])
+	} else if len(parts) == 4 && parts[0] == "project" && parts[2] == "role" {
+		return c.ProjectRole(parts[1], parts[3])
+	}
+	return nil, errors.New("Invalid path.")
+}
@@ -0,0 +1,207 @@
+package atlassian
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/saas/atlassian_utility"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var refUserPages = []string{
+	`[{"self":"https://grchive.atlassian.net/rest/api/3/user?accountId=5b10a2844c20165700ede21g","accountId":"5b10a2844c20165700ede21g","accountType":"atlassian","emailAddress":"mike@grchive.com","avatarUrls":{},"displayName":"Mike Bao","active":true,"timeZone":"America/New_York","locale":"en_US"},{"self":"https://grchive.atlassian.net/rest/api/3/user?accountId=5b10a2844c20165700ede22g","accountId":"5b10a2844c20165700ede22g","accountType":"atlassian","avatarUrls":{},"displayName":"Jane Doe","active":true,"locale":"en_US"}]`,
+	`[{"self":"https://grchive.atlassian.net/rest/api/3/user?accountId=5b10a2844c20165700ede23g","accountId":"5b10a2844c20165700ede23g","accountType":"atlassian","emailAddress":"bob@grchive.com","avatarUrls":{},"displayName":"Bob Smith","active":false,"locale":"en_US"},{"self":"https://grchive.atlassian.net/rest/api/3/user?accountId=557058:f58131cb-b67d-43c7-b30d-6b58d40bd077","accountId":"557058:f58131cb-b67d-43c7-b30d-6b58d40bd077","accountType":"app","avatarUrls":{},"displayName":"Automation for Jira","active":true},{"self":"https://grchive.atlassian.net/rest/api/3/user?accountId=qm:a1b2c3d4","accountId":"qm:a1b2c3d4","accountType":"customer","emailAddress":"customer@example.com","avatarUrls":{},"displayName":"Portal Customer","active":true}]`,
+}
+
+var refGroupMembers = map[string]string{
+	"gid-site-admins":   `{"self":"","maxResults":50,"startAt":0,"total":1,"isLast":true,"values":[{"accountId":"5b10a2844c20165700ede21g","accountType":"atlassian","emailAddress":"mike@grchive.com","displayName":"Mike Bao","active":true}]}`,
+	"gid-jira-software": `{"self":"","maxResults":50,"startAt":0,"total":3,"isLast":true,"values":[{"accountId":"5b10a2844c20165700ede21g","accountType":"atlassian","displayName":"Mike Bao","active":true},{"accountId":"5b10a2844c20165700ede22g","accountType":"atlassian","displayName":"Jane Doe","active":true},{"accountId":"5b10a2844c20165700ede23g","accountType":"atlassian","displayName":"Bob Smith","active":false}]}`,
+	"gid-developers":    `{"self":"","maxResults":50,"startAt":0,"total":1,"isLast":true,"values":[{"accountId":"5b10a2844c20165700ede22g","accountType":"atlassian","displayName":"Jane Doe","active":true}]}`,
+	"gid-addons-admin":  `{"self":"","maxResults":50,"startAt":0,"total":1,"isLast":true,"values":[{"accountId":"557058:f58131cb-b67d-43c7-b30d-6b58d40bd077","accountType":"app","displayName":"Automation for Jira","active":true}]}`,
+	"gid-service-desk":  `{"self":"","maxResults":50,"startAt":0,"total":0,"isLast":true,"values":[]}`,
+}
+
+func createRefClient() *atlassian_utility.MockAtlassianClient {
+	return &atlassian_utility.MockAtlassianClient{
+		Users: func(startAt int) (*http.Response, error) {
+			switch startAt {
+			case 0:
+				return test_utility.WrapHttpResponse(refUserPages[0]), nil
+			case 2:
+				return test_utility.WrapHttpResponse(refUserPages[1]), nil
+			}
+			return test_utility.WrapHttpResponse(`[]`), nil
+		},
+		Groups: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"maxResults":50,"startAt":0,"total":5,"isLast":true,"values":[{"name":"site-admins","groupId":"gid-site-admins"},{"name":"jira-software-users","groupId":"gid-jira-software"},{"name":"developers","groupId":"gid-developers"},{"name":"atlassian-addons-admin","groupId":"gid-addons-admin"},{"name":"jira-servicemanagement-users","groupId":"gid-service-desk"}]}`), nil
+		},
+		GroupMembers: func(groupId string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(refGroupMembers[groupId]), nil
+		},
+		ApplicationRoles: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`[{"key":"jira-software","groups":["jira-software-users"],"groupDetails":[{"name":"jira-software-users","groupId":"gid-jira-software"}],"name":"Jira Software","defaultGroups":["jira-software-users"],"selectedByDefault":false,"defined":true,"numberOfSeats":35000,"remainingSeats":34997,"userCount":3,"userCountDescription":"users","hasUnlimitedSeats":false,"platform":false},{"key":"jira-servicedesk","groups":["jira-servicemanagement-users"],"name":"Jira Service Management","defaultGroups":["jira-servicemanagement-users"],"selectedByDefault":false,"defined":true,"numberOfSeats":3,"remainingSeats":3,"userCount":0,"userCountDescription":"agents","hasUnlimitedSeats":false,"platform":false}]`), nil
+		},
+		Projects: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"self":"https://grchive.atlassian.net/rest/api/3/project/search?maxResults=50&startAt=0","maxResults":50,"startAt":0,"total":1,"isLast":true,"values":[{"expand":"description,lead,issueTypes,url,projectKeys,permissions,insight","self":"https://grchive.atlassian.net/rest/api/3/project/10000","id":"10000","key":"GRC","name":"GRCHive","projectTypeKey":"software","simplified":false,"style":"classic","isPrivate":false}]}`), nil
+		},
+		ProjectRoles: func(projectKey string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"Administrators":"https://grchive.atlassian.net/rest/api/3/project/10000/role/10002","Developers":"https://grchive.atlassian.net/rest/api/3/project/10000/role/10001"}`), nil
+		},
+		ProjectRole: func(projectKey string, roleId string) (*http.Response, error) {
+			if roleId == "10002" {
+				return test_utility.WrapHttpResponse(`{"self":"https://grchive.atlassian.net/rest/api/3/project/10000/role/10002","name":"Administrators","id":10002,"description":"A project role that represents administrators in a project","actors":[{"id":10010,"displayName":"Mike Bao","type":"atlassian-user-role-actor","actorUser":{"accountId":"5b10a2844c20165700ede21g"}}],"scope":{"type":"PROJECT","project":{"id":"10000"}}}`), nil
+			}
+			return test_utility.WrapHttpResponse(`{"self":"https://grchive.atlassian.net/rest/api/3/project/10000/role/10001","name":"Developers","id":10001,"description":"A project role that represents developers in a project","actors":[{"id":10011,"displayName":"developers","type":"atlassian-group-role-actor","name":"developers","actorGroup":{"name":"developers","displayName":"developers","groupId":"gid-developers"}}]}`), nil
+		},
+	}
+}
+
+func TestUserListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateAtlassianConnector(&EtlAtlassianOptions{
+		Client: createRefClient(),
+		Site:   "grchive",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	// 3 user pages, group listing, 5 group members, application roles, projects, project roles + 2 roles.
+	g.Expect(len(source.Commands)).To(gomega.Equal(14))
+
+	softwareUsers := &types.EtlRole{
+		Name: "jira-software-users",
+		Permissions: map[string][]string{
+			"product::jira-software": []string{"access"},
+		},
+	}
+
+	refUsers := map[string]*types.EtlUser{
+		"mike@grchive.com": &types.EtlUser{
+			Username: "mike@grchive.com",
+			Email:    "mike@grchive.com",
+			FullName: "Mike Bao",
+			Roles: map[string]*types.EtlRole{
+				"site-admins": &types.EtlRole{
+					Name: "site-admins",
+					Permissions: map[string][]string{
+						"site": []string{"admin"},
+					},
+				},
+				"jira-software-users": softwareUsers,
+				"GRC / Administrators": &types.EtlRole{
+					Name: "GRC / Administrators",
+					Permissions: map[string][]string{
+						"project::GRC": []string{"Administrators"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				AtlassianAttributeAccountId:   "5b10a2844c20165700ede21g",
+				AtlassianAttributeAccountType: "atlassian",
+				AtlassianAttributeActive:      "true",
+				AtlassianAttributeSiteAdmin:   "true",
+				AtlassianAttributeProducts:    "jira-software",
+			},
+		},
+		"5b10a2844c20165700ede22g": &types.EtlUser{
+			Username: "5b10a2844c20165700ede22g",
+			FullName: "Jane Doe",
+			Roles: map[string]*types.EtlRole{
+				"jira-software-users": softwareUsers,
+				"developers": &types.EtlRole{
+					Name: "developers",
+					Permissions: map[string][]string{
+						"project::GRC": []string{"Developers"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				AtlassianAttributeActive:    "true",
+				AtlassianAttributeSiteAdmin: "false",
+				AtlassianAttributeProducts:  "jira-software",
+			},
+		},
+		"bob@grchive.com": &types.EtlUser{
+			Username: "bob@grchive.com",
+			Email:    "bob@grchive.com",
+			FullName: "Bob Smith",
+			Roles: map[string]*types.EtlRole{
+				"jira-software-users": softwareUsers,
+			},
+			Attributes: map[string]string{
+				AtlassianAttributeActive:    "false",
+				AtlassianAttributeSiteAdmin: "false",
+			},
+		},
+		"557058:f58131cb-b67d-43c7-b30d-6b58d40bd077": &types.EtlUser{
+			Username: "557058:f58131cb-b67d-43c7-b30d-6b58d40bd077",
+			FullName: "Automation for Jira",
+			Roles: map[string]*types.EtlRole{
+				"atlassian-addons-admin": &types.EtlRole{
+					Name:        "atlassian-addons-admin",
+					Permissions: map[string][]string{},
+				},
+			},
+			Attributes: map[string]string{
+				AtlassianAttributeAccountType: "app",
+				AtlassianAttributeProducts:    "",
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
+
+func TestUserListingCustomAdminGroups(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateAtlassianConnector(&EtlAtlassianOptions{
+		Client:      createRefClient(),
+		Site:        "grchive",
+		AdminGroups: []string{"developers"},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, _, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	for _, u := range users {
+		_, isDeveloper := u.Roles["developers"]
+		g.Expect(u.Attributes[AtlassianAttributeSiteAdmin]).To(gomega.Equal(map[bool]string{true: "true", false: "false"}[isDeveloper]), u.Username)
+
+		if isDeveloper {
+			g.Expect(u.Roles["developers"].Permissions).To(gomega.HaveKeyWithValue("site", []string{"admin"}))
+		}
+	}
+}
+
+func TestUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := createRefClient()
+	client.Groups = func() (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"errorMessages":["You are not authorized to perform this operation."],"errors":{}}`)),
+		}, nil
+	}
+
+	conn, err := CreateAtlassianConnector(&EtlAtlassianOptions{
+		Client: client,
+		Site:   "grchive",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("Atlassian API Error"))
+}
@@ -3,17 +3,46 @@ package cloudflare_utility
 import (
 	"errors"
 	"net/http"
+	"strings"
 )
 
 type MockCloudflareFn func() (*http.Response, error)
+type MockCloudflareAccountFn func(accountId string) (*http.Response, error)
+type MockCloudflareAppFn func(accountId string, appId string) (*http.Response, error)
 
 type MockCloudflareClient struct {
-	AccountMembers MockCloudflareFn
+	Accounts       MockCloudflareFn
+	AccountMembers MockCloudflareAccountFn
+	AccountTokens  MockCloudflareAccountFn
+	AccessApps     MockCloudflareAccountFn
+	AccessGroups   MockCloudflareAccountFn
+	AccessPolicies MockCloudflareAppFn
 }
 
 func (c *MockCloudflareClient) Do(req *http.Request) (*http.Response, error) {
-	if req.URL.Path == "/client/v4/accounts/test/members" {
-		return c.AccountMembers()
+	if req.URL.Path == "/client/v4/accounts" {
+		return c.Accounts()
+	}
+
+	if !strings.HasPrefix(req.URL.Path, "/client/v4/accounts/") {
+		return nil, errors.New("Invalid path.")
+	}
+
+	// /client/v4/accounts/{account}/{resource...}
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/client/v4/accounts/"), "/")
+	accountId := parts[0]
+	resource := strings.Join(parts[1:], "/")
+
+	if resource == "members" {
+		return c.AccountMembers(accountId)
+	} else if resource == "tokens" {
+		return c.AccountTokens(accountId)
+	} else if resource == "access/apps" {
+		return c.AccessApps(accountId)
+	} else if resource == "access/groups" {
+		return c.AccessGroups(accountId)
+	} else if len(parts) == 5 && parts[1] == "access" && parts[2] == "apps" && parts[4] == "policies" {
+		return c.AccessPolicies(accountId, parts[3])
 	}
 	return nil, errors.New("Invalid path.")
 }
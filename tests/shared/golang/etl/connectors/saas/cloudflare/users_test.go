@@ -12,11 +12,17 @@ import (
 	"testing"
 )
 
+const emptyResponse = `{"result":[],"result_info":{"page":1,"per_page":50,"total_pages":0,"count":0,"total_count":0},"success":true,"errors":[],"messages":[]}`
+
+func emptyAccountFn(accountId string) (*http.Response, error) {
+	return test_utility.WrapHttpResponse(emptyResponse), nil
+}
+
 func TestUserListingParse(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
 	client := &cloudflare_utility.MockCloudflareClient{
-		AccountMembers: func() (*http.Response, error) {
+		AccountMembers: func(accountId string) (*http.Response, error) {
 			data := fmt.Sprintf(`
 {"result":[{"id":"4f40b6057e64fb5cbcaee5762c324c86","user":{"id":"3acc33b57bfe3699f622ae416de1b5fa","first_name":null,"last_name":null,"email":"mike@grchive.com","two_factor_authentication_enabled":false},"status":"accepted","roles":[{"id":"33666b9c79b9a5273fc7344ff42f953d","name":"Super Administrator - All Privileges","description":"Can edit any Cloudflare setting, make purchases, update billing, and manage memberships. Super Administrators can revoke the access of other Super Administrators.","permissions":{"organization":{"read":true,"edit":true},"zone":{"read":true,"edit":false},"ssl":{"read":false,"edit":true}, "waf":{"read": false, "edit": false}}}]}],"result_info":{"page":1,"per_page":50,"total_pages":1,"count":1,"total_count":1},"success":true,"errors":[],"messages":[]}
 		`)
@@ -26,6 +32,9 @@ func TestUserListingParse(t *testing.T) {
 				Body:       body,
 			}, nil
 		},
+		AccountTokens: emptyAccountFn,
+		AccessApps:    emptyAccountFn,
+		AccessGroups:  emptyAccountFn,
 	}
 	conn, err := CreateCloudflareConnector(&EtlCloudflareOptions{
 		Client:    client,
@@ -40,7 +49,7 @@ func TestUserListingParse(t *testing.T) {
 	g.Expect(err).To(gomega.BeNil())
 
 	g.Expect(source).NotTo(gomega.BeNil())
-	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+	g.Expect(len(source.Commands)).To(gomega.Equal(4))
 
 	refUsers := map[string]*types.EtlUser{
 		"mike@grchive.com": &types.EtlUser{
@@ -62,3 +71,219 @@ func TestUserListingParse(t *testing.T) {
 	}
 	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
 }
+
+func TestUserListingMultipleAccounts(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &cloudflare_utility.MockCloudflareClient{
+		Accounts: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"result":[{"id":"acct1","name":"Production"},{"id":"acct2","name":"Staging"}],"result_info":{"page":1,"per_page":50,"total_pages":1,"count":2,"total_count":2},"success":true,"errors":[],"messages":[]}
+			`), nil
+		},
+		AccountMembers: func(accountId string) (*http.Response, error) {
+			role := "Administrator Read Only"
+			if accountId == "acct1" {
+				role = "Administrator"
+			}
+
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"result":[{"id":"1","user":{"first_name":"Mike","last_name":"Bao","email":"mike@grchive.com"},"status":"accepted","roles":[{"id":"2","name":"%s","permissions":{"zone":{"read":true,"edit":%t}}}]}],"result_info":{"page":1,"per_page":50,"total_pages":1,"count":1,"total_count":1},"success":true,"errors":[],"messages":[]}
+			`, role, accountId == "acct1")), nil
+		},
+		AccountTokens: emptyAccountFn,
+		AccessApps:    emptyAccountFn,
+		AccessGroups:  emptyAccountFn,
+	}
+	conn, err := CreateCloudflareConnector(&EtlCloudflareOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(9))
+
+	refUsers := map[string]*types.EtlUser{
+		"mike@grchive.com": &types.EtlUser{
+			Username: "mike@grchive.com",
+			Email:    "mike@grchive.com",
+			FullName: "Mike Bao",
+			Roles: map[string]*types.EtlRole{
+				"Production / Administrator": &types.EtlRole{
+					Name: "Production / Administrator",
+					Permissions: map[string][]string{
+						"zone": []string{"Read", "Edit"},
+					},
+				},
+				"Staging / Administrator Read Only": &types.EtlRole{
+					Name: "Staging / Administrator Read Only",
+					Permissions: map[string][]string{
+						"zone": []string{"Read"},
+					},
+				},
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
+
+func TestApiTokenListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &cloudflare_utility.MockCloudflareClient{
+		AccountMembers: emptyAccountFn,
+		AccountTokens: func(accountId string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"result":[
+	{"id":"ed17574386854bf78a67040be0a770b0","name":"readonly token","status":"active","policies":[
+		{"id":"f267e341f3dd4697bd3b9f71dd96247f","effect":"allow","resources":{"com.cloudflare.api.account.zone.eb78d65290b24279ba6f44721b3ea3c4":"*","com.cloudflare.api.account.zone.22b1de5f1c0e4b3ea97bb1e963b06a43":"*"},"permission_groups":[{"id":"c8fed203ed3043cba015a93ad1616f1f","name":"Zone Read"},{"id":"82e64a83756745bbbb1c9c2701bf816b","name":"DNS Read"}]},
+		{"id":"a267e341f3dd4697bd3b9f71dd96247f","effect":"deny","resources":{"com.cloudflare.api.account.eb78d65290b24279ba6f44721b3ea3c4":{"com.cloudflare.api.account.zone.*":"*"}},"permission_groups":[{"id":"82e64a83756745bbbb1c9c2701bf816b","name":"DNS Write"}]}
+	]},
+	{"id":"0f1d8c7b0fd54c8aa1a7fa0a69d1e9f1","name":"old token","status":"disabled","policies":[]}
+],"result_info":{"page":1,"per_page":50,"total_pages":1,"count":2,"total_count":2},"success":true,"errors":[],"messages":[]}
+			`), nil
+		},
+		AccessApps:   emptyAccountFn,
+		AccessGroups: emptyAccountFn,
+	}
+	conn, err := CreateCloudflareConnector(&EtlCloudflareOptions{
+		Client:    client,
+		AccountId: "test",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(4))
+
+	refUsers := map[string]*types.EtlUser{
+		"ed17574386854bf78a67040be0a770b0": &types.EtlUser{
+			Username: "ed17574386854bf78a67040be0a770b0",
+			FullName: "readonly token",
+			Roles: map[string]*types.EtlRole{
+				"API Token": &types.EtlRole{
+					Name: "API Token",
+					Permissions: map[string][]string{
+						"com.cloudflare.api.account.zone.eb78d65290b24279ba6f44721b3ea3c4": []string{"Zone Read", "DNS Read"},
+						"com.cloudflare.api.account.zone.22b1de5f1c0e4b3ea97bb1e963b06a43": []string{"Zone Read", "DNS Read"},
+					},
+					Denied: map[string][]string{
+						"com.cloudflare.api.account.eb78d65290b24279ba6f44721b3ea3c4/com.cloudflare.api.account.zone.*": []string{"DNS Write"},
+					},
+				},
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
+
+func TestAccessPolicyListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &cloudflare_utility.MockCloudflareClient{
+		AccountMembers: emptyAccountFn,
+		AccountTokens:  emptyAccountFn,
+		AccessApps: func(accountId string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"result":[{"id":"app1","name":"Grafana","domain":"grafana.grchive.com","type":"self_hosted"}],"result_info":{"page":1,"per_page":50,"total_pages":1,"count":1,"total_count":1},"success":true,"errors":[],"messages":[]}
+			`), nil
+		},
+		AccessGroups: func(accountId string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"result":[{"id":"group1","name":"Engineering","include":[{"email":{"email":"eng1@grchive.com"}},{"email_domain":{"domain":"contractor.com"}}]}],"result_info":{"page":1,"per_page":50,"total_pages":1,"count":1,"total_count":1},"success":true,"errors":[],"messages":[]}
+			`), nil
+		},
+		AccessPolicies: func(accountId string, appId string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"result":[
+	{"id":"policy1","name":"Allow engineering","decision":"allow","include":[{"group":{"id":"group1"}},{"email":{"email":"mike@grchive.com"}}],"exclude":[{"email":{"email":"eng1@grchive.com"}}],"require":[{"ip":{"ip":"10.0.0.0/8"}}]},
+	{"id":"policy2","name":"Block everyone","decision":"deny","include":[{"everyone":{}}],"exclude":[],"require":[]}
+],"result_info":{"page":1,"per_page":50,"total_pages":1,"count":2,"total_count":2},"success":true,"errors":[],"messages":[]}
+			`), nil
+		},
+	}
+	conn, err := CreateCloudflareConnector(&EtlCloudflareOptions{
+		Client:    client,
+		AccountId: "test",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(5))
+
+	refUsers := map[string]*types.EtlUser{
+		"group:Engineering": &types.EtlUser{
+			Username: "group:Engineering",
+			Roles: map[string]*types.EtlRole{
+				"Grafana": &types.EtlRole{
+					Name: "Grafana",
+					Permissions: map[string][]string{
+						"grafana.grchive.com": []string{"allow"},
+					},
+				},
+			},
+			NestedUsers: map[string]*types.EtlUser{
+				"eng1@grchive.com": &types.EtlUser{
+					Username: "eng1@grchive.com",
+					Email:    "eng1@grchive.com",
+				},
+				"email_domain:contractor.com": &types.EtlUser{
+					Username: "email_domain:contractor.com",
+				},
+			},
+		},
+		"mike@grchive.com": &types.EtlUser{
+			Username: "mike@grchive.com",
+			Email:    "mike@grchive.com",
+			Roles: map[string]*types.EtlRole{
+				"Grafana": &types.EtlRole{
+					Name: "Grafana",
+					Permissions: map[string][]string{
+						"grafana.grchive.com": []string{"allow"},
+					},
+				},
+			},
+		},
+		"eng1@grchive.com": &types.EtlUser{
+			Username: "eng1@grchive.com",
+			Email:    "eng1@grchive.com",
+			Roles: map[string]*types.EtlRole{
+				"Grafana": &types.EtlRole{
+					Name: "Grafana",
+					Denied: map[string][]string{
+						"grafana.grchive.com": []string{"allow"},
+					},
+				},
+			},
+		},
+		"everyone": &types.EtlUser{
+			Username: "everyone",
+			Roles: map[string]*types.EtlRole{
+				"Grafana": &types.EtlRole{
+					Name: "Grafana",
+					Denied: map[string][]string{
+						"grafana.grchive.com": []string{"deny"},
+					},
+				},
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
@@ -2,18 +2,57 @@ package gsuite_utility
 
 import (
 	"errors"
+	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
 type MockGSuiteFn func() (*http.Response, error)
+type MockGSuiteIdFn func(id string) (*http.Response, error)
 
 type MockGSuiteClient struct {
-	DirectoryUsersList MockGSuiteFn
+	DirectoryUsersList           MockGSuiteFn
+	DirectoryRolesList           MockGSuiteFn
+	DirectoryRoleAssignmentsList MockGSuiteFn
+	DirectoryGroupsList          MockGSuiteFn
+	DirectoryGroupMembersList    MockGSuiteIdFn
+	DirectoryTokensList          MockGSuiteIdFn
+}
+
+func emptyResponse() (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+	}, nil
+}
+
+func callFn(fn MockGSuiteFn) (*http.Response, error) {
+	if fn == nil {
+		return emptyResponse()
+	}
+	return fn()
+}
+
+func callIdFn(fn MockGSuiteIdFn, id string) (*http.Response, error) {
+	if fn == nil {
+		return emptyResponse()
+	}
+	return fn(id)
 }
 
 func (c *MockGSuiteClient) Do(req *http.Request) (*http.Response, error) {
 	if req.URL.Path == "/admin/directory/v1/users" {
 		return c.DirectoryUsersList()
+	} else if strings.HasPrefix(req.URL.Path, "/admin/directory/v1/customer/") && strings.HasSuffix(req.URL.Path, "/roles") {
+		return callFn(c.DirectoryRolesList)
+	} else if strings.HasPrefix(req.URL.Path, "/admin/directory/v1/customer/") && strings.HasSuffix(req.URL.Path, "/roleassignments") {
+		return callFn(c.DirectoryRoleAssignmentsList)
+	} else if req.URL.Path == "/admin/directory/v1/groups" {
+		return callFn(c.DirectoryGroupsList)
+	} else if strings.HasPrefix(req.URL.Path, "/admin/directory/v1/groups/") && strings.HasSuffix(req.URL.Path, "/members") {
+		return callIdFn(c.DirectoryGroupMembersList, strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/admin/directory/v1/groups/"), "/members"))
+	} else if strings.HasPrefix(req.URL.Path, "/admin/directory/v1/users/") && strings.HasSuffix(req.URL.Path, "/tokens") {
+		return callIdFn(c.DirectoryTokensList, strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/admin/directory/v1/users/"), "/tokens"))
 	}
 	return nil, errors.New("Invalid path.")
 }
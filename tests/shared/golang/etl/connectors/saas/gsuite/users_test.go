@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/onsi/gomega"
 	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
 	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/saas/gsuite_utility"
 	"io/ioutil"
 	"net/http"
@@ -75,7 +76,7 @@ func TestUserListingParse(t *testing.T) {
 	g.Expect(err).To(gomega.BeNil())
 
 	g.Expect(source).NotTo(gomega.BeNil())
-	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+	g.Expect(len(source.Commands)).To(gomega.Equal(6))
 
 	refUsers := map[string]*types.EtlUser{
 		"derek@grchive.com": &types.EtlUser{
@@ -120,3 +121,201 @@ func TestUserListingParse(t *testing.T) {
 		}
 	}
 }
+
+func TestUserListingRolesGroupsAndTokens(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	refTime := time.Date(2000, 12, 10, 12, 23, 43, 0, time.UTC)
+
+	client := &gsuite_utility.MockGSuiteClient{
+		DirectoryUsersList: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{
+	"kind": "admin#directory#users",
+	"users": [
+		{"kind": "admin#directory#user", "id": "1", "primaryEmail": "derek@grchive.com", "name": {"fullName": "Derek Chin"}, "isAdmin": false, "isDelegatedAdmin": false, "creationTime": "%s"},
+		{"kind": "admin#directory#user", "id": "2", "primaryEmail": "mike@grchive.com", "name": {"fullName": "Michael Bao"}, "isAdmin": true, "isDelegatedAdmin": false, "creationTime": "%s"}
+	]
+}
+`, refTime.Format(time.RFC3339), refTime.Format(time.RFC3339))), nil
+		},
+		DirectoryRolesList: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{
+	"kind": "admin#directory#roles",
+	"items": [
+		{"kind": "admin#directory#role", "roleId": "10", "roleName": "_SEED_ADMIN_ROLE", "isSystemRole": true, "isSuperAdminRole": true, "rolePrivileges": [{"privilegeName": "SUPER_ADMIN", "serviceId": "01ci93xb3tmzyin"}]},
+		{"kind": "admin#directory#role", "roleId": "11", "roleName": "_HELP_DESK_ADMIN_ROLE", "isSystemRole": true, "rolePrivileges": [{"privilegeName": "USERS_RETRIEVE", "serviceId": "00haapch16h1ysv"}, {"privilegeName": "USERS_RESET_PASSWORD", "serviceId": "00haapch16h1ysv"}]}
+	]
+}
+`), nil
+		},
+		DirectoryRoleAssignmentsList: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{
+	"kind": "admin#directory#roleAssignments",
+	"items": [
+		{"roleAssignmentId": "100", "roleId": "10", "assignedTo": "2", "scopeType": "CUSTOMER"},
+		{"roleAssignmentId": "101", "roleId": "11", "assignedTo": "1", "scopeType": "ORG_UNIT", "orgUnitId": "03ph8a2z1enx4lx"},
+		{"roleAssignmentId": "102", "roleId": "11", "assignedTo": "g2", "assigneeType": "group", "scopeType": "CUSTOMER"}
+	]
+}
+`), nil
+		},
+		DirectoryGroupsList: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{
+	"kind": "admin#directory#groups",
+	"groups": [
+		{"id": "g1", "email": "engineering@grchive.com", "name": "Engineering"},
+		{"id": "g2", "email": "everyone@grchive.com", "name": "Everyone"},
+		{"id": "g3", "email": "all@grchive.com", "name": "All"}
+	]
+}
+`), nil
+		},
+		DirectoryGroupMembersList: func(id string) (*http.Response, error) {
+			switch id {
+			case "g1":
+				return test_utility.WrapHttpResponse(`
+{"members": [{"id": "1", "email": "derek@grchive.com", "role": "OWNER", "type": "USER"}, {"id": "3", "email": "contractor@example.com", "role": "MEMBER", "type": "USER"}]}
+`), nil
+			case "g2":
+				return test_utility.WrapHttpResponse(`
+{"members": [{"id": "g1", "email": "engineering@grchive.com", "role": "MEMBER", "type": "GROUP"}, {"id": "2", "email": "mike@grchive.com", "role": "MANAGER", "type": "USER"}]}
+`), nil
+			}
+			return test_utility.WrapHttpResponse(`
+{"members": [{"id": "C01", "role": "MEMBER", "type": "CUSTOMER"}]}
+`), nil
+		},
+		DirectoryTokensList: func(id string) (*http.Response, error) {
+			if id != "2" {
+				return test_utility.WrapHttpResponse(`{"kind": "admin#directory#tokenList"}`), nil
+			}
+			return test_utility.WrapHttpResponse(`
+{
+	"kind": "admin#directory#tokenList",
+	"items": [
+		{"clientId": "123.apps.googleusercontent.com", "displayText": "Slack", "scopes": ["openid", "https://www.googleapis.com/auth/userinfo.email"], "anonymous": false, "nativeApp": false}
+	]
+}
+`), nil
+		},
+	}
+	conn, err := CreateGSuiteConnector(&EtlGSuiteOptions{
+		Client:     client,
+		CustomerId: "12345",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(9))
+
+	refUsers := map[string]*types.EtlUser{
+		"derek@grchive.com": &types.EtlUser{
+			Username:    "derek@grchive.com",
+			FullName:    "Derek Chin",
+			Email:       "derek@grchive.com",
+			CreatedTime: &refTime,
+			Roles: map[string]*types.EtlRole{
+				"_HELP_DESK_ADMIN_ROLE (03ph8a2z1enx4lx)": &types.EtlRole{
+					Name: "_HELP_DESK_ADMIN_ROLE (03ph8a2z1enx4lx)",
+					Permissions: map[string][]string{
+						"00haapch16h1ysv": []string{"USERS_RETRIEVE", "USERS_RESET_PASSWORD"},
+					},
+				},
+				"_HELP_DESK_ADMIN_ROLE (via everyone@grchive.com)": &types.EtlRole{
+					Name: "_HELP_DESK_ADMIN_ROLE (via everyone@grchive.com)",
+					Permissions: map[string][]string{
+						"00haapch16h1ysv": []string{"USERS_RETRIEVE", "USERS_RESET_PASSWORD"},
+					},
+				},
+				"engineering@grchive.com": &types.EtlRole{
+					Name: "engineering@grchive.com",
+					Permissions: map[string][]string{
+						"engineering@grchive.com": []string{"OWNER"},
+					},
+				},
+				"everyone@grchive.com": &types.EtlRole{
+					Name: "everyone@grchive.com",
+					Permissions: map[string][]string{
+						"everyone@grchive.com": []string{"MEMBER (via engineering@grchive.com)"},
+					},
+				},
+				"all@grchive.com": &types.EtlRole{
+					Name: "all@grchive.com",
+					Permissions: map[string][]string{
+						"all@grchive.com": []string{"MEMBER (via CUSTOMER)"},
+					},
+				},
+			},
+		},
+		"mike@grchive.com": &types.EtlUser{
+			Username:    "mike@grchive.com",
+			FullName:    "Michael Bao",
+			Email:       "mike@grchive.com",
+			CreatedTime: &refTime,
+			Roles: map[string]*types.EtlRole{
+				"admin": &types.EtlRole{
+					Name: "admin",
+				},
+				"_SEED_ADMIN_ROLE": &types.EtlRole{
+					Name: "_SEED_ADMIN_ROLE",
+					Permissions: map[string][]string{
+						"01ci93xb3tmzyin": []string{"SUPER_ADMIN"},
+					},
+				},
+				"_HELP_DESK_ADMIN_ROLE (via everyone@grchive.com)": &types.EtlRole{
+					Name: "_HELP_DESK_ADMIN_ROLE (via everyone@grchive.com)",
+					Permissions: map[string][]string{
+						"00haapch16h1ysv": []string{"USERS_RETRIEVE", "USERS_RESET_PASSWORD"},
+					},
+				},
+				"everyone@grchive.com": &types.EtlRole{
+					Name: "everyone@grchive.com",
+					Permissions: map[string][]string{
+						"everyone@grchive.com": []string{"MANAGER"},
+					},
+				},
+				"all@grchive.com": &types.EtlRole{
+					Name: "all@grchive.com",
+					Permissions: map[string][]string{
+						"all@grchive.com": []string{"MEMBER (via CUSTOMER)"},
+					},
+				},
+				"OAuth Tokens": &types.EtlRole{
+					Name: "OAuth Tokens",
+					Permissions: map[string][]string{
+						"Slack (123.apps.googleusercontent.com)": []string{"openid", "https://www.googleapis.com/auth/userinfo.email"},
+					},
+				},
+			},
+		},
+		"contractor@example.com": &types.EtlUser{
+			Username: "contractor@example.com",
+			Email:    "contractor@example.com",
+			Roles: map[string]*types.EtlRole{
+				"engineering@grchive.com": &types.EtlRole{
+					Name: "engineering@grchive.com",
+					Permissions: map[string][]string{
+						"engineering@grchive.com": []string{"MEMBER"},
+					},
+				},
+				"everyone@grchive.com": &types.EtlRole{
+					Name: "everyone@grchive.com",
+					Permissions: map[string][]string{
+						"everyone@grchive.com": []string{"MEMBER (via engineering@grchive.com)"},
+					},
+				},
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
@@ -0,0 +1,32 @@
+package office365
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"testing"
+)
+
+func TestCreateOffice365Connector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &http.Client{}
+	exchangeClient := &http.Client{}
+	refTenantId := "contoso.onmicrosoft.com"
+
+	conn, err := CreateOffice365Connector(&EtlOffice365Options{
+		Client:         client,
+		ExchangeClient: exchangeClient,
+		TenantId:       refTenantId,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.ExchangeClient).To(gomega.Equal(exchangeClient))
+	g.Expect(conn.opts.TenantId).To(gomega.Equal(refTenantId))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.users.azure).NotTo(gomega.BeNil())
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
@@ -0,0 +1,112 @@
+package office365_utility
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type MockOffice365Fn func() (*http.Response, error)
+type MockOffice365IdFn func(id string) (*http.Response, error)
+
+type MockOffice365GraphClient struct {
+	UsersList            MockOffice365Fn
+	DirectoryRoles       MockOffice365Fn
+	DirectoryRoleMembers MockOffice365IdFn
+	UserLicenseDetails   MockOffice365IdFn
+	UserDrivePermissions MockOffice365IdFn
+	SitesList            MockOffice365Fn
+	SiteDrivePermissions MockOffice365IdFn
+	GroupsList           MockOffice365Fn
+	GroupOwners          MockOffice365IdFn
+	GroupMembers         MockOffice365IdFn
+}
+
+func emptyResponse() (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"value":[]}`)),
+	}, nil
+}
+
+func NotFoundResponse() (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":"itemNotFound","message":"Item not found"}}`)),
+	}, nil
+}
+
+func callFn(fn MockOffice365Fn) (*http.Response, error) {
+	if fn == nil {
+		return emptyResponse()
+	}
+	return fn()
+}
+
+func callIdFn(fn MockOffice365IdFn, id string) (*http.Response, error) {
+	if fn == nil {
+		return emptyResponse()
+	}
+	return fn(id)
+}
+
+func (c *MockOffice365GraphClient) Do(req *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/v1.0")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if path == "/users" {
+		return callFn(c.UsersList)
+	} else if path == "/directoryRoles" {
+		return callFn(c.DirectoryRoles)
+	} else if len(parts) == 3 && parts[0] == "directoryRoles" && parts[2] == "members" {
+		return callIdFn(c.DirectoryRoleMembers, parts[1])
+	} else if len(parts) == 3 && parts[0] == "users" && parts[2] == "licenseDetails" {
+		return callIdFn(c.UserLicenseDetails, parts[1])
+	} else if len(parts) == 5 && parts[0] == "users" && parts[2] == "drive" {
+		return callIdFn(c.UserDrivePermissions, parts[1])
+	} else if path == "/sites" {
+		return callFn(c.SitesList)
+	} else if len(parts) == 5 && parts[0] == "sites" && parts[2] == "drive" {
+		return callIdFn(c.SiteDrivePermissions, parts[1])
+	} else if path == "/groups" {
+		return callFn(c.GroupsList)
+	} else if len(parts) == 3 && parts[0] == "groups" && parts[2] == "owners" {
+		return callIdFn(c.GroupOwners, parts[1])
+	} else if len(parts) == 3 && parts[0] == "groups" && parts[2] == "members" {
+		return callIdFn(c.GroupMembers, parts[1])
+	}
+	return nil, errors.New("Invalid path.")
+}
+
+// MockOffice365ExchangeClient routes InvokeCommand calls based on the cmdlet name and is passed the
+// identity of the mailbox the cmdlet is run against.
+type MockOffice365ExchangeClient struct {
+	Cmdlets map[string]MockOffice365IdFn
+}
+
+func (c *MockOffice365ExchangeClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != "POST" || !strings.HasSuffix(req.URL.Path, "/InvokeCommand") {
+		return nil, errors.New("Invalid path.")
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	input := struct {
+		CmdletInput struct {
+			CmdletName string            `json:"CmdletName"`
+			Parameters map[string]string `json:"Parameters"`
+		} `json:"CmdletInput"`
+	}{}
+
+	err = json.Unmarshal(body, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return callIdFn(c.Cmdlets[input.CmdletInput.CmdletName], input.CmdletInput.Parameters["Identity"])
+}
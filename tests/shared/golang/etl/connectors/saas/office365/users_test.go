@@ -0,0 +1,432 @@
+package office365
+
+import (
+	"fmt"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/saas/office365_utility"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var refTime1 = time.Date(2010, 10, 12, 15, 34, 33, 0, time.UTC)
+var refTime2 = time.Date(2010, 12, 10, 3, 5, 6, 0, time.UTC)
+
+const aliceId = "1e7ef588-4893-486c-a879-00af5d017734"
+const bobId = "6a2d3f41-5f2e-4c1b-9d0a-3f8e1c2b7a90"
+const siteId = "contoso.sharepoint.com,2c0b1d5e-7c2b-4d3c-9a3e-8f1d2b3c4d5e,6f7a8b9c-0d1e-4f2a-8b3c-4d5e6f7a8b9c"
+const teamId = "02bd9fd6-8f93-4758-87c3-1fb73740a315"
+
+func createGraphClient() *office365_utility.MockOffice365GraphClient {
+	return &office365_utility.MockOffice365GraphClient{
+		UsersList: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"@odata.context":"https://graph.microsoft.com/v1.0/$metadata#users","value":[
+	{"displayName":"Alice Smith","userPrincipalName":"alice@contoso.com","mail":"alice@contoso.com","otherMails":[],"createdDateTime":"%s","id":"%s"},
+	{"displayName":"Bob Jones","userPrincipalName":"bob@contoso.com","mail":"bob@contoso.com","otherMails":[],"createdDateTime":"%s","id":"%s"}
+]}
+`, refTime1.Format(time.RFC3339), aliceId, refTime2.Format(time.RFC3339), bobId)), nil
+		},
+		DirectoryRoles: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`
+{"@odata.context":"https://graph.microsoft.com/v1.0/$metadata#directoryRoles","value":[{"id":"01b64ea3-a49a-4254-9d71-69094919d3a3","deletedDateTime":null,"description":"Can manage all aspects of the Exchange product.","displayName":"Exchange Service Administrator","roleTemplateId":"29232cdf-9323-42fd-ade2-1d097af3e4de"}]}
+`), nil
+		},
+		DirectoryRoleMembers: func(id string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"@odata.context":"https://graph.microsoft.com/v1.0/$metadata#directoryObjects","value":[{"@odata.type":"#microsoft.graph.user","id":"%s"}]}
+`, aliceId)), nil
+		},
+		UserLicenseDetails: func(id string) (*http.Response, error) {
+			if id != aliceId {
+				return test_utility.WrapHttpResponse(`{"value":[]}`), nil
+			}
+
+			return test_utility.WrapHttpResponse(`
+{"@odata.context":"https://graph.microsoft.com/v1.0/$metadata#users('alice%40contoso.com')/licenseDetails","value":[{"id":"lic1","skuId":"6fd2c87f-b296-42f0-b197-1e91e994b900","skuPartNumber":"ENTERPRISEPACK","servicePlans":[
+	{"servicePlanId":"efb87545-963c-4e0d-99df-69c6916d9eb0","servicePlanName":"EXCHANGE_S_ENTERPRISE","provisioningStatus":"Success","appliesTo":"User"},
+	{"servicePlanId":"5dbe027f-2339-4123-9542-606e4d348a72","servicePlanName":"SHAREPOINTENTERPRISE","provisioningStatus":"PendingProvisioning","appliesTo":"User"},
+	{"servicePlanId":"7547a3fe-08ee-4ccb-b430-5077c5041653","servicePlanName":"YAMMER_ENTERPRISE","provisioningStatus":"Disabled","appliesTo":"User"}
+]}]}
+`), nil
+		},
+		UserDrivePermissions: func(id string) (*http.Response, error) {
+			if id != aliceId {
+				return office365_utility.NotFoundResponse()
+			}
+
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"value":[
+	{"id":"p1","roles":["owner"],"grantedTo":{"user":{"id":"%s","displayName":"Alice Smith","email":"alice@contoso.com"}}},
+	{"id":"p2","roles":["write"],"grantedToIdentities":[{"user":{"id":"%s","displayName":"Bob Jones","email":"bob@contoso.com"}}],"link":{"scope":"users","type":"edit"}}
+]}
+`, aliceId, bobId)), nil
+		},
+		SitesList: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"value":[{"id":"%s","name":"finance","displayName":"Finance","webUrl":"https://contoso.sharepoint.com/sites/finance"}]}
+`, siteId)), nil
+		},
+		SiteDrivePermissions: func(id string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"value":[
+	{"id":"s1","roles":["read"],"grantedTo":{"user":{"id":"%s","displayName":"Bob Jones","email":"bob@contoso.com"}}},
+	{"id":"s2","roles":["read"],"link":{"scope":"anonymous","type":"view"}},
+	{"id":"s3","roles":["write"],"grantedToIdentities":[{"user":{"id":"8c1f0a2e-guest","displayName":"Partner Guest","email":"guest@partner.com"}}]}
+]}
+`, bobId)), nil
+		},
+		GroupsList: func() (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"value":[{"id":"%s","displayName":"Finance"}]}
+`, teamId)), nil
+		},
+		GroupOwners: func(id string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"value":[{"@odata.type":"#microsoft.graph.user","id":"%s","displayName":"Alice Smith","userPrincipalName":"alice@contoso.com","mail":"alice@contoso.com"}]}
+`, aliceId)), nil
+		},
+		GroupMembers: func(id string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"value":[
+	{"@odata.type":"#microsoft.graph.user","id":"%s","displayName":"Alice Smith","userPrincipalName":"alice@contoso.com","mail":"alice@contoso.com"},
+	{"@odata.type":"#microsoft.graph.user","id":"%s","displayName":"Bob Jones","userPrincipalName":"bob@contoso.com","mail":"bob@contoso.com"}
+]}
+`, aliceId, bobId)), nil
+		},
+	}
+}
+
+func createExchangeClient() *office365_utility.MockOffice365ExchangeClient {
+	return &office365_utility.MockOffice365ExchangeClient{
+		Cmdlets: map[string]office365_utility.MockOffice365IdFn{
+			"Get-MailboxPermission": func(identity string) (*http.Response, error) {
+				if identity == "alice@contoso.com" {
+					return test_utility.WrapHttpResponse(`
+{"value":[
+	{"Identity":"Alice Smith","User":"NT AUTHORITY\\SELF","AccessRights":["FullAccess","ReadPermission"],"IsInherited":false,"Deny":false},
+	{"Identity":"Alice Smith","User":"bob@contoso.com","AccessRights":["FullAccess"],"IsInherited":false,"Deny":false},
+	{"Identity":"Alice Smith","User":"Bob Jones","AccessRights":["ChangeOwner"],"IsInherited":false,"Deny":false},
+	{"Identity":"Alice Smith","User":"NAMPR01A001\\Organization Management","AccessRights":["FullAccess"],"IsInherited":true,"Deny":false}
+]}
+`), nil
+				}
+
+				return test_utility.WrapHttpResponse(`
+{"value":[
+	{"Identity":"Bob Jones","User":"alice@contoso.com","AccessRights":["ReadPermission"],"IsInherited":false,"Deny":true}
+]}
+`), nil
+			},
+			"Get-RecipientPermission": func(identity string) (*http.Response, error) {
+				if identity != "alice@contoso.com" {
+					return test_utility.WrapHttpResponse(`{"value":[]}`), nil
+				}
+
+				return test_utility.WrapHttpResponse(fmt.Sprintf(`
+{"value":[
+	{"Identity":"Alice Smith","Trustee":"NT AUTHORITY\\SELF","AccessRights":["SendAs"],"IsInherited":false},
+	{"Identity":"Alice Smith","Trustee":"%s","AccessRights":["SendAs"],"IsInherited":false}
+]}
+`, bobId)), nil
+			},
+		},
+	}
+}
+
+func createConnector(g *gomega.GomegaWithT, withExchange bool) *EtlOffice365Connector {
+	opts := &EtlOffice365Options{
+		Client: createGraphClient(),
+	}
+
+	if withExchange {
+		opts.ExchangeClient = createExchangeClient()
+		opts.TenantId = "contoso.onmicrosoft.com"
+	}
+
+	conn, err := CreateOffice365Connector(opts)
+	g.Expect(err).To(gomega.BeNil())
+	return conn
+}
+
+func TestEnabledServicePlans(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	conn := createConnector(g, false)
+
+	licenses, source, err := conn.users.getUserLicenses(aliceId)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+	g.Expect(len(licenses)).To(gomega.Equal(1))
+	g.Expect(licenseRoleName(licenses[0])).To(gomega.Equal("License: ENTERPRISEPACK"))
+	g.Expect(licenses[0].enabledServicePlans()).To(gomega.Equal([]string{"EXCHANGE_S_ENTERPRISE", "SHAREPOINTENTERPRISE"}))
+}
+
+func TestDrivePermissionPrincipals(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	conn := createConnector(g, false)
+
+	permissions, source, err := conn.users.getSitePermissions(siteId)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+	g.Expect(len(permissions)).To(gomega.Equal(3))
+
+	g.Expect(permissions[0].principals()).To(gomega.Equal([]office365Identity{
+		office365Identity{Id: bobId, DisplayName: "Bob Jones", Email: "bob@contoso.com"},
+	}))
+	g.Expect(permissions[1].principals()).To(gomega.Equal([]office365Identity{
+		office365Identity{Id: "link:anonymous", DisplayName: "Sharing Link (anonymous)"},
+	}))
+	g.Expect(permissions[2].principals()).To(gomega.Equal([]office365Identity{
+		office365Identity{Id: "8c1f0a2e-guest", DisplayName: "Partner Guest", Email: "guest@partner.com"},
+	}))
+
+	// Users without a OneDrive shouldn't be an error.
+	permissions, source, err = conn.users.getOneDrivePermissions(bobId)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(0))
+	g.Expect(len(permissions)).To(gomega.Equal(0))
+}
+
+func TestGetMailboxPermissions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	conn := createConnector(g, true)
+
+	permissions, source, err := conn.users.getMailboxPermissions(office365User{
+		Id:                aliceId,
+		UserPrincipalName: "alice@contoso.com",
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(2))
+	g.Expect(permissions).To(gomega.Equal([]exchangeMailboxPermission{
+		exchangeMailboxPermission{
+			Identity:     "Alice Smith",
+			User:         "bob@contoso.com",
+			AccessRights: []string{"FullAccess"},
+		},
+		exchangeMailboxPermission{
+			Identity:     "Alice Smith",
+			User:         "Bob Jones",
+			AccessRights: []string{"ChangeOwner"},
+		},
+		exchangeMailboxPermission{
+			Identity:     "Alice Smith",
+			Trustee:      bobId,
+			AccessRights: []string{"SendAs"},
+		},
+	}))
+}
+
+func TestGetUserListing(t *testing.T) {
+	siteUrl := "https://contoso.sharepoint.com/sites/finance"
+
+	for _, test := range []struct {
+		WithExchange bool
+		NumCommands  int
+		RefUsers     map[string]*types.EtlUser
+	}{
+		{
+			WithExchange: false,
+			NumCommands:  12,
+			RefUsers: map[string]*types.EtlUser{
+				"alice@contoso.com": &types.EtlUser{
+					Username:    "alice@contoso.com",
+					Email:       "alice@contoso.com",
+					FullName:    "Alice Smith",
+					CreatedTime: &refTime1,
+					Roles: map[string]*types.EtlRole{
+						"Exchange Service Administrator": &types.EtlRole{
+							Name:        "Exchange Service Administrator",
+							Permissions: map[string][]string{},
+						},
+						"License: ENTERPRISEPACK": &types.EtlRole{
+							Name: "License: ENTERPRISEPACK",
+							Permissions: map[string][]string{
+								"ENTERPRISEPACK": []string{"EXCHANGE_S_ENTERPRISE", "SHAREPOINTENTERPRISE"},
+							},
+						},
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								"OneDrive (alice@contoso.com)": []string{"owner"},
+							},
+						},
+						teamRoleName: &types.EtlRole{
+							Name: teamRoleName,
+							Permissions: map[string][]string{
+								"Finance": []string{"Member", "Owner"},
+							},
+						},
+					},
+				},
+				"bob@contoso.com": &types.EtlUser{
+					Username:    "bob@contoso.com",
+					Email:       "bob@contoso.com",
+					FullName:    "Bob Jones",
+					CreatedTime: &refTime2,
+					Roles: map[string]*types.EtlRole{
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								"OneDrive (alice@contoso.com)": []string{"write"},
+								siteUrl:                        []string{"read"},
+							},
+						},
+						teamRoleName: &types.EtlRole{
+							Name: teamRoleName,
+							Permissions: map[string][]string{
+								"Finance": []string{"Member"},
+							},
+						},
+					},
+				},
+				"link:anonymous": &types.EtlUser{
+					Username: "link:anonymous",
+					FullName: "Sharing Link (anonymous)",
+					Roles: map[string]*types.EtlRole{
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								siteUrl: []string{"read"},
+							},
+						},
+					},
+				},
+				"guest@partner.com": &types.EtlUser{
+					Username: "guest@partner.com",
+					Email:    "guest@partner.com",
+					FullName: "Partner Guest",
+					Roles: map[string]*types.EtlRole{
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								siteUrl: []string{"write"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			WithExchange: true,
+			NumCommands:  16,
+			RefUsers: map[string]*types.EtlUser{
+				"alice@contoso.com": &types.EtlUser{
+					Username:    "alice@contoso.com",
+					Email:       "alice@contoso.com",
+					FullName:    "Alice Smith",
+					CreatedTime: &refTime1,
+					Roles: map[string]*types.EtlRole{
+						"Exchange Service Administrator": &types.EtlRole{
+							Name:        "Exchange Service Administrator",
+							Permissions: map[string][]string{},
+						},
+						"License: ENTERPRISEPACK": &types.EtlRole{
+							Name: "License: ENTERPRISEPACK",
+							Permissions: map[string][]string{
+								"ENTERPRISEPACK": []string{"EXCHANGE_S_ENTERPRISE", "SHAREPOINTENTERPRISE"},
+							},
+						},
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								"OneDrive (alice@contoso.com)": []string{"owner"},
+							},
+						},
+						teamRoleName: &types.EtlRole{
+							Name: teamRoleName,
+							Permissions: map[string][]string{
+								"Finance": []string{"Member", "Owner"},
+							},
+						},
+						mailboxRoleName: &types.EtlRole{
+							Name:        mailboxRoleName,
+							Permissions: map[string][]string{},
+							Denied: map[string][]string{
+								"bob@contoso.com": []string{"ReadPermission"},
+							},
+						},
+					},
+				},
+				"bob@contoso.com": &types.EtlUser{
+					Username:    "bob@contoso.com",
+					Email:       "bob@contoso.com",
+					FullName:    "Bob Jones",
+					CreatedTime: &refTime2,
+					Roles: map[string]*types.EtlRole{
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								"OneDrive (alice@contoso.com)": []string{"write"},
+								siteUrl:                        []string{"read"},
+							},
+						},
+						teamRoleName: &types.EtlRole{
+							Name: teamRoleName,
+							Permissions: map[string][]string{
+								"Finance": []string{"Member"},
+							},
+						},
+						mailboxRoleName: &types.EtlRole{
+							Name: mailboxRoleName,
+							Permissions: map[string][]string{
+								"alice@contoso.com": []string{"FullAccess", "SendAs"},
+							},
+						},
+					},
+				},
+				"Bob Jones": &types.EtlUser{
+					Username: "Bob Jones",
+					Roles: map[string]*types.EtlRole{
+						mailboxRoleName: &types.EtlRole{
+							Name: mailboxRoleName,
+							Permissions: map[string][]string{
+								"alice@contoso.com": []string{"ChangeOwner"},
+							},
+						},
+					},
+					Attributes: map[string]string{
+						Office365AttributeUnresolvedTrustee: "true",
+					},
+				},
+				"link:anonymous": &types.EtlUser{
+					Username: "link:anonymous",
+					FullName: "Sharing Link (anonymous)",
+					Roles: map[string]*types.EtlRole{
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								siteUrl: []string{"read"},
+							},
+						},
+					},
+				},
+				"guest@partner.com": &types.EtlUser{
+					Username: "guest@partner.com",
+					Email:    "guest@partner.com",
+					FullName: "Partner Guest",
+					Roles: map[string]*types.EtlRole{
+						siteRoleName: &types.EtlRole{
+							Name: siteRoleName,
+							Permissions: map[string][]string{
+								siteUrl: []string{"write"},
+							},
+						},
+					},
+				},
+			},
+		},
+	} {
+		g := gomega.NewGomegaWithT(t)
+		conn := createConnector(g, test.WithExchange)
+
+		itf, err := conn.GetUserInterface()
+		g.Expect(err).To(gomega.BeNil())
+
+		users, source, err := itf.GetUserListing()
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(len(source.Commands)).To(gomega.Equal(test.NumCommands))
+		test_utility.CompareUserListing(g, users, test.RefUsers, test_utility.CompareUserListingOptions{})
+	}
+}
@@ -0,0 +1,29 @@
+package salesforce
+
+import (
+	"github.com/onsi/gomega"
+	"net/http"
+	"testing"
+)
+
+func TestCreateSalesforceConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &http.Client{}
+	refInstanceUrl := "https://grchive.my.salesforce.com"
+
+	conn, err := CreateSalesforceConnector(&EtlSalesforceOptions{
+		Client:      client,
+		InstanceUrl: refInstanceUrl,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Client).To(gomega.Equal(client))
+	g.Expect(conn.opts.InstanceUrl).To(gomega.Equal(refInstanceUrl))
+	g.Expect(conn.opts.apiBaseUrl()).To(gomega.Equal("https://grchive.my.salesforce.com/services/data/v50.0"))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
@@ -0,0 +1,30 @@
+package salesforce_utility
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type MockSalesforceQueryFn func(query string) (*http.Response, error)
+type MockSalesforceQueryMoreFn func(locator string) (*http.Response, error)
+
+type MockSalesforceClient struct {
+	Query     MockSalesforceQueryFn
+	QueryMore MockSalesforceQueryMoreFn
+}
+
+func (c *MockSalesforceClient) Do(req *http.Request) (*http.Response, error) {
+	// /services/data/{version}/query[/{locator}]
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/services/data/"), "/")
+	if !strings.HasPrefix(req.URL.Path, "/services/data/") || len(parts) < 2 || parts[1] != "query" {
+		return nil, errors.New("Invalid path.")
+	}
+
+	if len(parts) == 2 {
+		return c.Query(req.URL.Query().Get("q"))
+	} else if len(parts) == 3 {
+		return c.QueryMore(parts[2])
+	}
+	return nil, errors.New("Invalid path.")
+}
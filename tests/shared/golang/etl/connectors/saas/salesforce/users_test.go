@@ -0,0 +1,178 @@
+package salesforce
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/saas/salesforce_utility"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var refQueryResponses = map[string]string{
+	"User":                    `{"totalSize":3,"done":false,"nextRecordsUrl":"/services/data/v50.0/query/01gD0000002HU6KIAW-2000","records":[{"attributes":{"type":"User","url":"/services/data/v50.0/sobjects/User/005D0000001KyEIIA0"},"Id":"005D0000001KyEIIA0","Username":"admin@grchive.com","Name":"Mike Bao","Email":"mike@grchive.com","IsActive":true,"UserType":"Standard","ProfileId":"00eD0000001QWkLIAW","CreatedDate":"2020-01-15T16:20:00.000+0000","LastModifiedDate":"2020-10-07T18:10:34.000+0000","LastLoginDate":"2020-10-19T09:00:00.000+0000"}]}`,
+	"Profile":                 `{"totalSize":2,"done":true,"records":[{"attributes":{"type":"Profile","url":"/services/data/v50.0/sobjects/Profile/00eD0000001QWkLIAW"},"Id":"00eD0000001QWkLIAW","Name":"System Administrator","UserType":"Standard","UserLicense":{"attributes":{"type":"UserLicense"},"Name":"Salesforce"}},{"attributes":{"type":"Profile","url":"/services/data/v50.0/sobjects/Profile/00eD0000001QWkMIAW"},"Id":"00eD0000001QWkMIAW","Name":"Standard User","UserType":"Standard","UserLicense":{"attributes":{"type":"UserLicense"},"Name":"Salesforce Platform"}}]}`,
+	"PermissionSet":           `{"totalSize":4,"done":true,"records":[{"attributes":{"type":"PermissionSet"},"Id":"0PSD0000000OwnA","Name":"X00eD0000001QWkLIAW","Label":"00eD0000001QWkLIAW","IsOwnedByProfile":true,"ProfileId":"00eD0000001QWkLIAW","PermissionsModifyAllData":true,"PermissionsViewAllData":true,"PermissionsManageUsers":true,"PermissionsResetPasswords":true,"PermissionsCustomizeApplication":true,"PermissionsAuthorApex":true,"PermissionsViewSetup":true,"PermissionsApiEnabled":true},{"attributes":{"type":"PermissionSet"},"Id":"0PSD0000000OwnB","Name":"X00eD0000001QWkMIAW","Label":"00eD0000001QWkMIAW","IsOwnedByProfile":true,"ProfileId":"00eD0000001QWkMIAW","PermissionsModifyAllData":false,"PermissionsViewAllData":false,"PermissionsManageUsers":false,"PermissionsResetPasswords":false,"PermissionsCustomizeApplication":false,"PermissionsAuthorApex":false,"PermissionsViewSetup":false,"PermissionsApiEnabled":true},{"attributes":{"type":"PermissionSet"},"Id":"0PSD0000000Fin1","Name":"Finance_Read","Label":"Finance Read","IsOwnedByProfile":false,"ProfileId":null,"PermissionsModifyAllData":false,"PermissionsViewAllData":false,"PermissionsManageUsers":false,"PermissionsResetPasswords":false,"PermissionsCustomizeApplication":false,"PermissionsAuthorApex":false,"PermissionsViewSetup":false,"PermissionsApiEnabled":false},{"attributes":{"type":"PermissionSet"},"Id":"0PSD0000000Exp1","Name":"Data_Export","Label":"Data Export","IsOwnedByProfile":false,"ProfileId":null,"PermissionsModifyAllData":false,"PermissionsViewAllData":true,"PermissionsManageUsers":false,"PermissionsResetPasswords":false,"PermissionsCustomizeApplication":false,"PermissionsAuthorApex":false,"PermissionsViewSetup":false,"PermissionsApiEnabled":true}]}`,
+	"ObjectPermissions":       `{"totalSize":6,"done":true,"records":[{"attributes":{"type":"ObjectPermissions"},"ParentId":"0PSD0000000OwnA","SobjectType":"Account","PermissionsCreate":true,"PermissionsRead":true,"PermissionsEdit":true,"PermissionsDelete":true,"PermissionsViewAllRecords":true,"PermissionsModifyAllRecords":true},{"attributes":{"type":"ObjectPermissions"},"ParentId":"0PSD0000000OwnA","SobjectType":"Invoice__c","PermissionsCreate":true,"PermissionsRead":true,"PermissionsEdit":true,"PermissionsDelete":true,"PermissionsViewAllRecords":true,"PermissionsModifyAllRecords":true},{"attributes":{"type":"ObjectPermissions"},"ParentId":"0PSD0000000OwnB","SobjectType":"Account","PermissionsCreate":true,"PermissionsRead":true,"PermissionsEdit":true,"PermissionsDelete":false,"PermissionsViewAllRecords":false,"PermissionsModifyAllRecords":false},{"attributes":{"type":"ObjectPermissions"},"ParentId":"0PSD0000000OwnB","SobjectType":"Opportunity","PermissionsCreate":false,"PermissionsRead":true,"PermissionsEdit":false,"PermissionsDelete":false,"PermissionsViewAllRecords":false,"PermissionsModifyAllRecords":false},{"attributes":{"type":"ObjectPermissions"},"ParentId":"0PSD0000000OwnB","SobjectType":"Contract","PermissionsCreate":false,"PermissionsRead":false,"PermissionsEdit":false,"PermissionsDelete":false,"PermissionsViewAllRecords":false,"PermissionsModifyAllRecords":false},{"attributes":{"type":"ObjectPermissions"},"ParentId":"0PSD0000000Fin1","SobjectType":"Invoice__c","PermissionsCreate":false,"PermissionsRead":true,"PermissionsEdit":false,"PermissionsDelete":false,"PermissionsViewAllRecords":true,"PermissionsModifyAllRecords":false}]}`,
+	"PermissionSetAssignment": `{"totalSize":6,"done":true,"records":[{"attributes":{"type":"PermissionSetAssignment"},"AssigneeId":"005D0000001KyEIIA0","PermissionSetId":"0PSD0000000OwnA"},{"attributes":{"type":"PermissionSetAssignment"},"AssigneeId":"005D0000001KyEJIA0","PermissionSetId":"0PSD0000000OwnB"},{"attributes":{"type":"PermissionSetAssignment"},"AssigneeId":"005D0000001KyEJIA0","PermissionSetId":"0PSD0000000Fin1"},{"attributes":{"type":"PermissionSetAssignment"},"AssigneeId":"005D0000001KyEJIA0","PermissionSetId":"0PSD0000000Exp1"},{"attributes":{"type":"PermissionSetAssignment"},"AssigneeId":"005D0000001KyEKIA0","PermissionSetId":"0PSD0000000OwnB"},{"attributes":{"type":"PermissionSetAssignment"},"AssigneeId":"005D0000009ZZZZZZZ","PermissionSetId":"0PSD0000000Fin1"}]}`,
+}
+
+const refUsersSecondPage = `{"totalSize":3,"done":true,"records":[{"attributes":{"type":"User"},"Id":"005D0000001KyEJIA0","Username":"jane@grchive.com","Name":"Jane Doe","Email":"jane@grchive.com","IsActive":true,"UserType":"Standard","ProfileId":"00eD0000001QWkMIAW","CreatedDate":"2020-02-01T10:00:00.000+0000","LastModifiedDate":"2020-09-01T10:00:00.000+0000","LastLoginDate":"2020-10-18T12:30:00.000-0700"},{"attributes":{"type":"User"},"Id":"005D0000001KyEKIA0","Username":"bob@grchive.com.sandbox","Name":"Bob Smith","Email":"bob@grchive.com","IsActive":false,"UserType":"Standard","ProfileId":"00eD0000001QWkMIAW","CreatedDate":"2020-02-01T10:00:00.000+0000","LastModifiedDate":"2020-03-01T10:00:00.000+0000","LastLoginDate":null}]}`
+
+func createRefClient() *salesforce_utility.MockSalesforceClient {
+	return &salesforce_utility.MockSalesforceClient{
+		Query: func(query string) (*http.Response, error) {
+			from := strings.Fields(query[strings.Index(query, " FROM ")+6:])[0]
+			return test_utility.WrapHttpResponse(refQueryResponses[from]), nil
+		},
+		QueryMore: func(locator string) (*http.Response, error) {
+			if locator != "01gD0000002HU6KIAW-2000" {
+				return test_utility.WrapHttpResponse(`{}`), nil
+			}
+			return test_utility.WrapHttpResponse(refUsersSecondPage), nil
+		},
+	}
+}
+
+func TestUserListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateSalesforceConnector(&EtlSalesforceOptions{
+		Client:      createRefClient(),
+		InstanceUrl: "https://grchive.my.salesforce.com/",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(6))
+
+	timePtr := func(value string) *time.Time {
+		t, err := time.Parse(time.RFC3339, value)
+		g.Expect(err).To(gomega.BeNil())
+		return &t
+	}
+
+	standardUser := &types.EtlRole{
+		Name: "Profile: Standard User",
+		Permissions: map[string][]string{
+			"system":              []string{"API Enabled"},
+			"object::Account":     []string{"Create", "Read", "Edit"},
+			"object::Opportunity": []string{"Read"},
+		},
+	}
+
+	refUsers := map[string]*types.EtlUser{
+		"admin@grchive.com": &types.EtlUser{
+			Username:       "admin@grchive.com",
+			FullName:       "Mike Bao",
+			Email:          "mike@grchive.com",
+			CreatedTime:    timePtr("2020-01-15T16:20:00Z"),
+			LastChangeTime: timePtr("2020-10-07T18:10:34Z"),
+			Roles: map[string]*types.EtlRole{
+				"Profile: System Administrator": &types.EtlRole{
+					Name: "Profile: System Administrator",
+					Permissions: map[string][]string{
+						"system": []string{
+							"Modify All Data",
+							"View All Data",
+							"Manage Users",
+							"Reset User Passwords and Unlock Users",
+							"Customize Application",
+							"Author Apex",
+							"View Setup and Configuration",
+							"API Enabled",
+						},
+						"object::Account":    []string{"Create", "Read", "Edit", "Delete", "View All", "Modify All"},
+						"object::Invoice__c": []string{"Create", "Read", "Edit", "Delete", "View All", "Modify All"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				SalesforceAttributeUserId:    "005D0000001KyEIIA0",
+				SalesforceAttributeUserType:  "Standard",
+				SalesforceAttributeActive:    "true",
+				SalesforceAttributeProfile:   "System Administrator",
+				SalesforceAttributeLicense:   "Salesforce",
+				SalesforceAttributeLastLogin: "2020-10-19T09:00:00Z",
+			},
+		},
+		"jane@grchive.com": &types.EtlUser{
+			Username:       "jane@grchive.com",
+			FullName:       "Jane Doe",
+			Email:          "jane@grchive.com",
+			CreatedTime:    timePtr("2020-02-01T10:00:00Z"),
+			LastChangeTime: timePtr("2020-09-01T10:00:00Z"),
+			Roles: map[string]*types.EtlRole{
+				"Profile: Standard User": standardUser,
+				"Permission Set: Finance_Read": &types.EtlRole{
+					Name: "Permission Set: Finance_Read",
+					Permissions: map[string][]string{
+						"object::Invoice__c": []string{"Read", "View All"},
+					},
+				},
+				"Permission Set: Data_Export": &types.EtlRole{
+					Name: "Permission Set: Data_Export",
+					Permissions: map[string][]string{
+						"system": []string{"View All Data", "API Enabled"},
+					},
+				},
+			},
+			Attributes: map[string]string{
+				SalesforceAttributeProfile:   "Standard User",
+				SalesforceAttributeLicense:   "Salesforce Platform",
+				SalesforceAttributeLastLogin: "2020-10-18T19:30:00Z",
+			},
+		},
+		"bob@grchive.com.sandbox": &types.EtlUser{
+			Username:       "bob@grchive.com.sandbox",
+			FullName:       "Bob Smith",
+			Email:          "bob@grchive.com",
+			CreatedTime:    timePtr("2020-02-01T10:00:00Z"),
+			LastChangeTime: timePtr("2020-03-01T10:00:00Z"),
+			Roles: map[string]*types.EtlRole{
+				"Profile: Standard User": standardUser,
+			},
+			Attributes: map[string]string{
+				SalesforceAttributeActive: "false",
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+
+	for _, u := range users {
+		if u.Username == "bob@grchive.com.sandbox" {
+			g.Expect(u.Attributes).NotTo(gomega.HaveKey(SalesforceAttributeLastLogin))
+		}
+	}
+}
+
+func TestUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := createRefClient()
+	client.Query = func(query string) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       ioutil.NopCloser(strings.NewReader(`[{"message":"sObject type 'ObjectPermissions' is not supported.","errorCode":"INVALID_TYPE"}]`)),
+		}, nil
+	}
+
+	conn, err := CreateSalesforceConnector(&EtlSalesforceOptions{
+		Client:      client,
+		InstanceUrl: "https://grchive.my.salesforce.com",
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("INVALID_TYPE"))
+}
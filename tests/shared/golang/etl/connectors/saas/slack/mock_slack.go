@@ -0,0 +1,19 @@
+package slack_utility
+
+import (
+	"errors"
+	"net/http"
+)
+
+type MockSlackCursorFn func(cursor string) (*http.Response, error)
+
+type MockSlackClient struct {
+	UsersList MockSlackCursorFn
+}
+
+func (c *MockSlackClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/api/users.list" {
+		return c.UsersList(req.URL.Query().Get("cursor"))
+	}
+	return nil, errors.New("Invalid path.")
+}
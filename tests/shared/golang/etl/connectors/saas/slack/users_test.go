@@ -0,0 +1,160 @@
+package slack
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"gitlab.com/grchive/grchive-v3/tests/shared/etl/connectors/saas/slack_utility"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUserListingParse(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cursors := []string{}
+	client := &slack_utility.MockSlackClient{
+		UsersList: func(cursor string) (*http.Response, error) {
+			cursors = append(cursors, cursor)
+			if cursor == "" {
+				return test_utility.WrapHttpResponse(`{"ok":true,"members":[{"id":"U012AB3CD","team_id":"T012AB3C4","name":"mike","deleted":false,"color":"9f69e7","real_name":"Mike Bao","tz":"America/New_York","profile":{"real_name":"Mike Bao","display_name":"mike","email":"mike@grchive.com","team":"T012AB3C4"},"is_admin":true,"is_owner":true,"is_primary_owner":true,"is_restricted":false,"is_ultra_restricted":false,"is_bot":false,"is_app_user":false,"updated":1602094234,"has_2fa":true,"two_factor_type":"app"},{"id":"U012AB3CE","team_id":"T012AB3C4","name":"jane","deleted":false,"real_name":"Jane Doe","profile":{"real_name":"Jane Doe","email":"jane@grchive.com"},"is_admin":true,"is_owner":false,"is_primary_owner":false,"is_restricted":false,"is_ultra_restricted":false,"is_bot":false,"updated":1602094235,"has_2fa":false},{"id":"U012AB3CF","team_id":"T012AB3C4","name":"bob","deleted":false,"real_name":"Bob Smith","profile":{"real_name":"Bob Smith","email":"bob@grchive.com"},"is_admin":false,"is_owner":false,"is_primary_owner":false,"is_restricted":false,"is_ultra_restricted":false,"is_bot":false,"updated":1602094236,"has_2fa":false}],"cache_ts":1602094300,"response_metadata":{"next_cursor":"dXNlcjpVMEc5V0ZYTlo="}}`), nil
+			}
+			return test_utility.WrapHttpResponse(`{"ok":true,"members":[{"id":"U012AB3CG","team_id":"T012AB3C4","name":"auditor","deleted":false,"real_name":"Outside Auditor","profile":{"real_name":"Outside Auditor","email":"auditor@example.com"},"is_admin":false,"is_owner":false,"is_primary_owner":false,"is_restricted":true,"is_ultra_restricted":false,"is_bot":false,"updated":1602094237,"has_2fa":false},{"id":"U012AB3CH","team_id":"T012AB3C4","name":"contractor","deleted":false,"real_name":"","profile":{"real_name":"Contractor","email":"contractor@example.com"},"is_admin":false,"is_owner":false,"is_primary_owner":false,"is_restricted":true,"is_ultra_restricted":true,"is_bot":false,"updated":1602094238,"has_2fa":false},{"id":"U012AB3CI","team_id":"T012AB3C4","name":"alice","deleted":true,"profile":{"real_name":"Alice Former","email":"alice@grchive.com"},"is_bot":false,"updated":1602094239},{"id":"USLACKBOT","team_id":"T012AB3C4","name":"slackbot","deleted":false,"real_name":"Slackbot","profile":{"real_name":"Slackbot","email":""},"is_admin":false,"is_owner":false,"is_primary_owner":false,"is_restricted":false,"is_ultra_restricted":false,"is_bot":false,"updated":0},{"id":"B012AB3CJ","team_id":"T012AB3C4","name":"grchive-bot","deleted":false,"real_name":"GRCHive","profile":{"real_name":"GRCHive","bot_id":"B012AB3CJ"},"is_bot":true,"updated":1602094240}],"cache_ts":1602094300,"response_metadata":{"next_cursor":""}}`), nil
+		},
+	}
+
+	conn, err := CreateSlackConnector(&EtlSlackOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	g.Expect(source).NotTo(gomega.BeNil())
+	g.Expect(len(source.Commands)).To(gomega.Equal(2))
+	g.Expect(cursors).To(gomega.Equal([]string{"", "dXNlcjpVMEc5V0ZYTlo="}))
+
+	workspaceRole := func(name string, permissions ...string) map[string]*types.EtlRole {
+		return map[string]*types.EtlRole{
+			name: &types.EtlRole{
+				Name: name,
+				Permissions: map[string][]string{
+					"workspace::T012AB3C4": permissions,
+				},
+			},
+		}
+	}
+
+	timePtr := func(sec int64) *time.Time {
+		t := time.Unix(sec, 0)
+		return &t
+	}
+
+	refUsers := map[string]*types.EtlUser{
+		"mike@grchive.com": &types.EtlUser{
+			Username:       "mike@grchive.com",
+			Email:          "mike@grchive.com",
+			FullName:       "Mike Bao",
+			LastChangeTime: timePtr(1602094234),
+			Roles:          workspaceRole("Primary Owner", "primary_owner", "owner", "admin", "member"),
+			Attributes: map[string]string{
+				SlackAttributeUserId:        "U012AB3CD",
+				SlackAttributeTeamId:        "T012AB3C4",
+				SlackAttributeDeleted:       "false",
+				SlackAttributeBot:           "false",
+				SlackAttributeGuestType:     "",
+				SlackAttributeHas2fa:        "true",
+				SlackAttributeTwoFactorType: "app",
+			},
+		},
+		"jane@grchive.com": &types.EtlUser{
+			Username:       "jane@grchive.com",
+			Email:          "jane@grchive.com",
+			FullName:       "Jane Doe",
+			LastChangeTime: timePtr(1602094235),
+			Roles:          workspaceRole("Workspace Admin", "admin", "member"),
+			Attributes: map[string]string{
+				SlackAttributeHas2fa: "false",
+			},
+		},
+		"bob@grchive.com": &types.EtlUser{
+			Username:       "bob@grchive.com",
+			Email:          "bob@grchive.com",
+			FullName:       "Bob Smith",
+			LastChangeTime: timePtr(1602094236),
+			Roles:          workspaceRole("Member", "member"),
+		},
+		"auditor@example.com": &types.EtlUser{
+			Username:       "auditor@example.com",
+			Email:          "auditor@example.com",
+			FullName:       "Outside Auditor",
+			LastChangeTime: timePtr(1602094237),
+			Roles:          workspaceRole("Multi-Channel Guest", "guest"),
+			Attributes: map[string]string{
+				SlackAttributeGuestType: SlackGuestTypeMultiChannel,
+			},
+		},
+		"contractor@example.com": &types.EtlUser{
+			Username:       "contractor@example.com",
+			Email:          "contractor@example.com",
+			FullName:       "Contractor",
+			LastChangeTime: timePtr(1602094238),
+			Roles:          workspaceRole("Single-Channel Guest", "single_channel_guest"),
+			Attributes: map[string]string{
+				SlackAttributeGuestType: SlackGuestTypeSingleChannel,
+			},
+		},
+		"alice@grchive.com": &types.EtlUser{
+			Username:       "alice@grchive.com",
+			Email:          "alice@grchive.com",
+			FullName:       "Alice Former",
+			LastChangeTime: timePtr(1602094239),
+			Roles:          map[string]*types.EtlRole{},
+			Attributes: map[string]string{
+				SlackAttributeDeleted: "true",
+			},
+		},
+		"slackbot": &types.EtlUser{
+			Username: "slackbot",
+			FullName: "Slackbot",
+			Roles:    workspaceRole("Bot", "bot"),
+			Attributes: map[string]string{
+				SlackAttributeBot: "true",
+			},
+		},
+		"grchive-bot": &types.EtlUser{
+			Username:       "grchive-bot",
+			FullName:       "GRCHive",
+			LastChangeTime: timePtr(1602094240),
+			Roles:          workspaceRole("Bot", "bot"),
+			Attributes: map[string]string{
+				SlackAttributeBot: "true",
+			},
+		},
+	}
+	test_utility.CompareUserListing(g, users, refUsers, test_utility.CompareUserListingOptions{})
+}
+
+func TestUserListingError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	client := &slack_utility.MockSlackClient{
+		UsersList: func(cursor string) (*http.Response, error) {
+			return test_utility.WrapHttpResponse(`{"ok":false,"error":"missing_scope","needed":"users:read","provided":"chat:write"}`), nil
+		},
+	}
+
+	conn, err := CreateSlackConnector(&EtlSlackOptions{
+		Client: client,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+	g.Expect(err.Error()).To(gomega.Equal("Slack API Error: missing_scope"))
+}
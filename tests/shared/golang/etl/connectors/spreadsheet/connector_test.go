@@ -0,0 +1,26 @@
+package spreadsheet
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestCreateSpreadsheetConnector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateSpreadsheetConnector(&EtlSpreadsheetOptions{
+		Filename: "users.csv",
+		Mapping: EtlSpreadsheetMapping{
+			Username: "Username",
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(conn).NotTo(gomega.BeNil())
+	g.Expect(conn.opts).NotTo(gomega.BeNil())
+	g.Expect(conn.opts.Filename).To(gomega.Equal("users.csv"))
+	g.Expect(conn.opts.Mapping.separator()).To(gomega.Equal(";"))
+
+	g.Expect(conn.users).NotTo(gomega.BeNil())
+	g.Expect(conn.users.opts).To(gomega.Equal(conn.opts))
+	g.Expect(conn.GetUserInterface()).To(gomega.Equal(conn.users))
+}
@@ -0,0 +1,39 @@
+package spreadsheet
+
+import (
+	"github.com/onsi/gomega"
+	"strings"
+	"testing"
+)
+
+func TestReadCsvRows(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	long := strings.Repeat("a", 10000)
+	rows, errs, err := readCsvRows([]byte(
+		"Username,Notes\r\n"+
+			"\r\n"+
+			"mike,\"multiple\r\nlines\"\r\n"+
+			"\n"+
+			"jane,"+long+"\n"+
+			"bob,\"bad \"quote\"\n"+
+			"carol,last",
+	), 0)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(rows).To(gomega.Equal([]spreadsheetRow{
+		{Line: 1, Cells: []string{"Username", "Notes"}},
+		{Line: 3, Cells: []string{"mike", "multiple\nlines"}},
+		{Line: 6, Cells: []string{"jane", long}},
+		{Line: 8, Cells: []string{"carol", "last"}},
+	}))
+	g.Expect(len(errs)).To(gomega.Equal(1))
+	g.Expect(errs[0].Line).To(gomega.Equal(7))
+
+	rows, errs, err = readCsvRows([]byte("Username;Roles\nmike;\"Admin;Viewer\"\n"), ';')
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(errs).To(gomega.BeEmpty())
+	g.Expect(rows).To(gomega.Equal([]spreadsheetRow{
+		{Line: 1, Cells: []string{"Username", "Roles"}},
+		{Line: 2, Cells: []string{"mike", "Admin;Viewer"}},
+	}))
+}
@@ -0,0 +1,298 @@
+package spreadsheet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/etl/types"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var refTime1 = time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)
+var refTime2 = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+var refMapping = EtlSpreadsheetMapping{
+	Username:         "User Name",
+	FullName:         "Name",
+	Email:            "E-mail",
+	CreatedTime:      "Hired",
+	TimeFormat:       "2006-01-02",
+	Roles:            "Role",
+	PermissionObject: "Resource",
+	Permissions:      "Access",
+	Attributes: map[string]string{
+		"Department": "department",
+	},
+}
+
+const refCsv = `User Name,Name,E-mail,Hired,Role,Resource,Access,Department
+mike,Michael Bao,Michael Bao <mike@grchive.com>,2019-04-01,Admin;Billing,ledger,read;write,Engineering
+mike,,,,Admin,payroll,read,
+
+jane,Jane Doe,jane@grchive.com,,,ledger,read,Finance
+bob,"Bob, Jr.",bob@grchive.com,,Viewer,,,
+`
+
+func refUsers() map[string]*types.EtlUser {
+	return map[string]*types.EtlUser{
+		"mike": &types.EtlUser{
+			Username:    "mike",
+			FullName:    "Michael Bao",
+			Email:       "mike@grchive.com",
+			CreatedTime: &refTime1,
+			Attributes: map[string]string{
+				"department": "Engineering",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Admin": &types.EtlRole{
+					Name: "Admin",
+					Permissions: map[string][]string{
+						"ledger":  []string{"read", "write"},
+						"payroll": []string{"read"},
+					},
+				},
+				"Billing": &types.EtlRole{
+					Name: "Billing",
+					Permissions: map[string][]string{
+						"ledger": []string{"read", "write"},
+					},
+				},
+			},
+		},
+		"jane": &types.EtlUser{
+			Username: "jane",
+			FullName: "Jane Doe",
+			Email:    "jane@grchive.com",
+			Attributes: map[string]string{
+				"department": "Finance",
+			},
+			Roles: map[string]*types.EtlRole{
+				"Self": &types.EtlRole{
+					Name: "Self",
+					Permissions: map[string][]string{
+						"ledger": []string{"read"},
+					},
+				},
+			},
+		},
+		"bob": &types.EtlUser{
+			Username: "bob",
+			FullName: "Bob, Jr.",
+			Email:    "bob@grchive.com",
+			Roles: map[string]*types.EtlRole{
+				"Viewer": &types.EtlRole{Name: "Viewer"},
+			},
+		},
+	}
+}
+
+func TestGetUserListingCsv(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "spreadsheet")
+	g.Expect(err).To(gomega.BeNil())
+	defer os.RemoveAll(dir)
+
+	fname := filepath.Join(dir, "users.CSV")
+	g.Expect(ioutil.WriteFile(fname, []byte(refCsv), 0600)).To(gomega.BeNil())
+
+	conn, err := CreateSpreadsheetConnector(&EtlSpreadsheetOptions{
+		Filename: fname,
+		Mapping:  refMapping,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	itf, err := conn.GetUserInterface()
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := itf.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	hash := sha256.Sum256([]byte(refCsv))
+	g.Expect(len(source.Commands)).To(gomega.Equal(1))
+	g.Expect(source.Commands[0].Command).To(gomega.Equal("READ " + fname))
+	g.Expect(source.Commands[0].RawData).To(gomega.Equal(refCsv))
+
+	params := source.Commands[0].Parameters.(map[string]interface{})
+	g.Expect(params).To(gomega.HaveKeyWithValue("filename", fname))
+	g.Expect(params).To(gomega.HaveKeyWithValue("format", FormatCsv))
+	g.Expect(params).To(gomega.HaveKeyWithValue("sha256", hex.EncodeToString(hash[:])))
+	g.Expect(params).To(gomega.HaveKeyWithValue("size", len(refCsv)))
+	g.Expect(params).To(gomega.HaveKey("modified_time"))
+
+	g.Expect(users[0].Username).To(gomega.Equal("mike"))
+	test_utility.CompareUserListing(g, users, refUsers(), test_utility.CompareUserListingOptions{})
+}
+
+func TestGetUserListingXlsx(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	data := createTestXlsx(g,
+		`<si><t>User Name</t></si><si><t>Name</t></si><si><t>E-mail</t></si><si><t>Hired</t></si><si><t>Role</t></si><si><t>Resource</t></si><si><t>Access</t></si><si><t>Department</t></si>`,
+		testXlsxSheet{
+			Name: "Users",
+			SheetData: `
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c><c r="F1" t="s"><v>5</v></c><c r="G1" t="s"><v>6</v></c><c r="H1" t="s"><v>7</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>mike</t></is></c><c r="D2"><v>43831.5</v></c><c r="E2" t="inlineStr"><is><t>Admin</t></is></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>jane</t></is></c><c r="D4" t="inlineStr"><is><t>2019-04-01</t></is></c></row>`,
+		},
+	)
+
+	conn, err := CreateSpreadsheetConnector(&EtlSpreadsheetOptions{
+		Filename: "upload.xlsx",
+		Data:     data,
+		Mapping:  refMapping,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+
+	hash := sha256.Sum256(data)
+	params := source.Commands[0].Parameters.(map[string]interface{})
+	g.Expect(params).To(gomega.HaveKeyWithValue("sha256", hex.EncodeToString(hash[:])))
+	g.Expect(params).To(gomega.HaveKeyWithValue("format", FormatXlsx))
+	g.Expect(params).NotTo(gomega.HaveKey("modified_time"))
+	g.Expect(source.Commands[0].RawData).To(gomega.HavePrefix("User Name,Name,E-mail,Hired"))
+
+	test_utility.CompareUserListing(g, users, map[string]*types.EtlUser{
+		"mike": &types.EtlUser{
+			Username:    "mike",
+			CreatedTime: &refTime2,
+			Roles: map[string]*types.EtlRole{
+				"Admin": &types.EtlRole{Name: "Admin"},
+			},
+		},
+		"jane": &types.EtlUser{
+			Username:    "jane",
+			CreatedTime: &refTime1,
+			Roles:       map[string]*types.EtlRole{},
+		},
+	}, test_utility.CompareUserListingOptions{})
+}
+
+const refInvalidCsv = `User Name,Name,E-mail,Hired,Role,Resource,Access,Department
+mike,Michael Bao,mike@grchive.com,2019-04-01,Admin,ledger,read,Engineering
+,Nobody,nobody@grchive.com,,,,,
+jane,Jane Doe,not an email,04/01/2019,,ledger,,
+mike,Mike B,,,,,,
+bob,Bob,bob@grchive.com,,Viewer,,,,extra
+carol,Carol
+`
+
+func TestGetUserListingValidation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateSpreadsheetConnector(&EtlSpreadsheetOptions{
+		Filename: "users.csv",
+		Data:     []byte(refInvalidCsv),
+		Mapping:  refMapping,
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	validationErr, ok := err.(*EtlSpreadsheetValidationError)
+	g.Expect(ok).To(gomega.BeTrue())
+
+	lines := []int{}
+	columns := []string{}
+	for _, e := range validationErr.Errors {
+		lines = append(lines, e.Line)
+		columns = append(columns, e.Column)
+	}
+
+	g.Expect(lines).To(gomega.Equal([]int{3, 4, 4, 4, 5, 6, 7}))
+	g.Expect(columns).To(gomega.Equal([]string{"User Name", "E-mail", "Hired", "Access", "Name", "", ""}))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("Line 5 (Name): Mike B conflicts with Michael Bao on line 2."))
+
+	conn.opts.SkipInvalidRows = true
+	users, source, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(users)).To(gomega.Equal(1))
+	g.Expect(users[0].Username).To(gomega.Equal("mike"))
+	g.Expect(source.Commands[0].Parameters).To(gomega.HaveKeyWithValue("invalid_rows", gomega.HaveLen(7)))
+}
+
+func TestGetUserListingParseErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	conn, err := CreateSpreadsheetConnector(&EtlSpreadsheetOptions{
+		Filename: "users.csv",
+		Data: []byte(`Username,Notes
+mike,"first
+second"
+jane,"bad "quote"
+,no username
+bob,ok
+`),
+		Mapping: EtlSpreadsheetMapping{Username: "Username"},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	validationErr, ok := err.(*EtlSpreadsheetValidationError)
+	g.Expect(ok).To(gomega.BeTrue())
+
+	lines := []int{}
+	for _, e := range validationErr.Errors {
+		lines = append(lines, e.Line)
+	}
+	g.Expect(lines).To(gomega.Equal([]int{4, 5}))
+
+	conn.opts.SkipInvalidRows = true
+	users, _, err := conn.users.GetUserListing()
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(len(users)).To(gomega.Equal(2))
+	g.Expect(users[0].Username).To(gomega.Equal("mike"))
+	g.Expect(users[1].Username).To(gomega.Equal("bob"))
+}
+
+func TestGetUserListingHeaderErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for _, test := range []struct {
+		data    string
+		mapping EtlSpreadsheetMapping
+	}{
+		{"", refMapping},
+		{"Username;Email\nmike;mike@grchive.com\n", EtlSpreadsheetMapping{Username: "Login"}},
+		{"Username;Email\nmike;mike@grchive.com\n", EtlSpreadsheetMapping{Email: "Email"}},
+		{"Username;username\nmike;mike\n", EtlSpreadsheetMapping{Username: "Username"}},
+		{"User\"name;Email\nmike;mike@grchive.com\n", EtlSpreadsheetMapping{Username: "Username"}},
+	} {
+		conn, err := CreateSpreadsheetConnector(&EtlSpreadsheetOptions{
+			Filename:  "users.txt",
+			Format:    FormatCsv,
+			Delimiter: ';',
+			Data:      []byte(test.data),
+			Mapping:   test.mapping,
+		})
+		g.Expect(err).To(gomega.BeNil())
+
+		_, _, err = conn.users.GetUserListing()
+		g.Expect(err).NotTo(gomega.BeNil(), test.data)
+
+		validationErr, ok := err.(*EtlSpreadsheetValidationError)
+		g.Expect(ok).To(gomega.BeTrue())
+		g.Expect(validationErr.Errors[0].Line).To(gomega.Equal(1))
+	}
+
+	conn, err := CreateSpreadsheetConnector(&EtlSpreadsheetOptions{
+		Filename: "users.txt",
+		Data:     []byte("Username\nmike\n"),
+		Mapping:  EtlSpreadsheetMapping{Username: "Username"},
+	})
+	g.Expect(err).To(gomega.BeNil())
+
+	_, _, err = conn.users.GetUserListing()
+	g.Expect(err).NotTo(gomega.BeNil())
+}
@@ -0,0 +1,68 @@
+package spreadsheet
+
+import (
+	"github.com/onsi/gomega"
+	"testing"
+)
+
+func TestReadXlsxSheet(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	data := createTestXlsx(g,
+		`<si><t>Username</t></si><si><r><t>Ro</t></r><r><rPr><b/></rPr><t>les</t></r></si><si><t>mike</t></si>`,
+		testXlsxSheet{
+			Name:      "Summary",
+			SheetData: `<row r="1"><c r="A1" t="inlineStr"><is><t>Ignored</t></is></c></row>`,
+		},
+		testXlsxSheet{
+			Name: "Users",
+			SheetData: `
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="C3" t="b"><v>1</v></c><c r="D3"><v>43831.5</v></c></row>
+<row><c t="inlineStr"><is><t>inline</t></is></c><c t="str"><v>formula</v></c></row>
+<row r="6"><c r="B6"><v>1</v></c><c><v>2</v></c><c r="E6"><v>3</v></c><c><v>4</v></c></row>`,
+		},
+	)
+
+	rows, err := readXlsxSheet(data, "Users")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(rows).To(gomega.Equal([]spreadsheetRow{
+		{Line: 1, Cells: []string{"Username", "Roles"}},
+		{Line: 3, Cells: []string{"mike", "", "TRUE", "43831.5"}},
+		{Line: 4, Cells: []string{"inline", "formula"}},
+		{Line: 6, Cells: []string{"", "1", "2", "", "3", "4"}},
+	}))
+
+	rows, err = readXlsxSheet(data, "")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(rows).To(gomega.Equal([]spreadsheetRow{
+		{Line: 1, Cells: []string{"Ignored"}},
+	}))
+
+	_, err = readXlsxSheet(data, "Missing")
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = readXlsxSheet([]byte("not a zip"), "")
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestColumnIndex(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	for ref, expected := range map[string]int{
+		"A1":    0,
+		"Z10":   25,
+		"AA2":   26,
+		"AB100": 27,
+		"XFD1":  16383,
+	} {
+		idx, err := columnIndex(ref)
+		g.Expect(err).To(gomega.BeNil())
+		g.Expect(idx).To(gomega.Equal(expected), ref)
+	}
+
+	for _, ref := range []string{"12", "XFE1", "ZZZ1", "AAAA1", "ZZZZZZZZZZZZZZZ1"} {
+		_, err := columnIndex(ref)
+		g.Expect(err).NotTo(gomega.BeNil(), ref)
+	}
+}
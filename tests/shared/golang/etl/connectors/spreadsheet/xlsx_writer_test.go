@@ -0,0 +1,54 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/onsi/gomega"
+	"strconv"
+)
+
+type testXlsxSheet struct {
+	Name string
+	// The <sheetData> of the worksheet.
+	SheetData string
+}
+
+// createTestXlsx creates a workbook the way Excel lays it out with the given shared strings and sheets.
+func createTestXlsx(g *gomega.GomegaWithT, sharedStrings string, sheets ...testXlsxSheet) []byte {
+	buffer := bytes.Buffer{}
+	archive := zip.NewWriter(&buffer)
+
+	write := func(name string, contents string) {
+		w, err := archive.Create(name)
+		g.Expect(err).To(gomega.BeNil())
+
+		_, err = w.Write([]byte(contents))
+		g.Expect(err).To(gomega.BeNil())
+	}
+
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId100" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`
+
+	for idx, s := range sheets {
+		// The ids of the relationships don't have to match the order of the sheets.
+		id := len(sheets) - idx
+		workbook += `<sheet name="` + s.Name + `" sheetId="` + strconv.Itoa(id) + `" r:id="rId` + strconv.Itoa(id) + `"/>`
+		rels += `<Relationship Id="rId` + strconv.Itoa(id) + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + strconv.Itoa(id) + `.xml"/>`
+		write("xl/worksheets/sheet"+strconv.Itoa(id)+".xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+s.SheetData+`</sheetData></worksheet>`)
+	}
+
+	write("xl/workbook.xml", workbook+`</sheets></workbook>`)
+	write("xl/_rels/workbook.xml.rels", rels+`</Relationships>`)
+
+	if sharedStrings != "" {
+		write("xl/sharedStrings.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+sharedStrings+`</sst>`)
+	}
+
+	g.Expect(archive.Close()).To(gomega.BeNil())
+	return buffer.Bytes()
+}
@@ -0,0 +1,90 @@
+package auth_utility
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCanonicalDuoParams(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	for _, test := range []struct {
+		Input  url.Values
+		Output string
+	}{
+		{
+			Input:  url.Values{},
+			Output: "",
+		},
+		{
+			Input: url.Values{
+				"offset":   []string{"300"},
+				"limit":    []string{"100"},
+				"username": []string{"mike bao"},
+			},
+			Output: "limit=100&offset=300&username=mike%20bao",
+		},
+		{
+			Input: url.Values{
+				"realname": []string{"~mike+test@grchive.com"},
+				"group":    []string{"b", "a"},
+			},
+			Output: "group=a&group=b&realname=~mike%2Btest%40grchive.com",
+		},
+	} {
+		g.Expect(canonicalDuoParams(test.Input)).To(gomega.Equal(test.Output))
+	}
+}
+
+func TestCreateDuoCanonicalRequest(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	req := http.Request{
+		URL: mustParseDuoUrl("https://API-XXXXXXXX.duosecurity.com/admin/v1/users?offset=0&limit=300"),
+	}
+	g.Expect(createDuoCanonicalRequest("Tue, 21 Aug 2012 17:29:18 -0000", &req)).To(gomega.Equal(`Tue, 21 Aug 2012 17:29:18 -0000
+GET
+api-xxxxxxxx.duosecurity.com
+/admin/v1/users
+limit=300&offset=0`))
+}
+
+func TestCreateDuoAuthorization(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tripper := duoRoundTripper{
+		clock:          test_utility.FixedClock{Time: time.Date(2012, 8, 21, 17, 29, 18, 0, time.UTC)},
+		integrationKey: "DIWJ8X6AEYOR5OMC6TQ1",
+		secretKey:      "Zh5eGmUq9zpfQnyUIu5OL9iWoMMv5ZNmk3zLJ4Ep",
+	}
+
+	req := http.Request{
+		Method: "GET",
+		URL:    mustParseDuoUrl("https://api-xxxxxxxx.duosecurity.com/admin/v1/users?limit=300&offset=0"),
+	}
+
+	date := tripper.clock.Now().Format(duoDateFormat)
+	g.Expect(date).To(gomega.Equal("Tue, 21 Aug 2012 17:29:18 +0000"))
+
+	h := hmac.New(sha1.New, []byte(tripper.secretKey))
+	h.Write([]byte(date + "\nGET\napi-xxxxxxxx.duosecurity.com\n/admin/v1/users\nlimit=300&offset=0"))
+	refSig := hex.EncodeToString(h.Sum(nil))
+
+	auth := tripper.createDuoAuthorization(date, &req)
+	g.Expect(auth).To(gomega.Equal("Basic " + base64.StdEncoding.EncodeToString([]byte("DIWJ8X6AEYOR5OMC6TQ1:"+refSig))))
+}
+
+func mustParseDuoUrl(inputUrl string) *url.URL {
+	u, err := url.Parse(inputUrl)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
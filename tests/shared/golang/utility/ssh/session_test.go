@@ -0,0 +1,44 @@
+package ssh_utility
+
+import (
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"testing"
+)
+
+func TestSshTunnelRunCommand(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer server.Close()
+
+	server.Exec = func(command string) (string, string, int) {
+		if command == "whoami" {
+			return "bastion\n", "", 0
+		}
+		return "", "sh: " + command + ": not found\n", 127
+	}
+
+	tunnel, err := OpenSshTunnel(SshTunnelConfig{
+		Hops: []SshHostConfig{
+			{Host: server.Host(), Port: server.Port(), Username: "bastion", Password: "password", InsecureIgnoreHostKey: true},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer tunnel.Close()
+
+	out, err := tunnel.RunCommand("whoami")
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(string(out)).To(gomega.Equal("bastion\n"))
+
+	_, err = tunnel.RunCommand("missing")
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	cmdErr, ok := err.(*SshCommandError)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(cmdErr.Command).To(gomega.Equal("missing"))
+	g.Expect(cmdErr.ExitStatus).To(gomega.Equal(127))
+	g.Expect(cmdErr.Stderr).To(gomega.Equal("sh: missing: not found\n"))
+	g.Expect(cmdErr.Error()).To(gomega.Equal("SSH command 'missing' exited with status 127: sh: missing: not found"))
+}
@@ -0,0 +1,246 @@
+package ssh_utility
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/onsi/gomega"
+	"gitlab.com/grchive/grchive-v3/shared/test_utility"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTempFile(g *gomega.GomegaWithT, data string) string {
+	f, err := ioutil.TempFile("", "ssh")
+	g.Expect(err).To(gomega.BeNil())
+	defer f.Close()
+
+	_, err = f.WriteString(data)
+	g.Expect(err).To(gomega.BeNil())
+	return f.Name()
+}
+
+func expectEcho(g *gomega.GomegaWithT, conn net.Conn) {
+	defer conn.Close()
+
+	_, err := conn.Write([]byte("hello"))
+	g.Expect(err).To(gomega.BeNil())
+
+	buffer := make([]byte, 5)
+	_, err = io.ReadFull(conn, buffer)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(string(buffer)).To(gomega.Equal("hello"))
+}
+
+func hostConfig(server *test_utility.FakeSshServer, knownHosts string) SshHostConfig {
+	return SshHostConfig{
+		Host:           server.Host(),
+		Port:           server.Port(),
+		Username:       "bastion",
+		Password:       "password",
+		KnownHostsFile: knownHosts,
+	}
+}
+
+func TestSshTunnelPassword(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	echo, err := test_utility.NewEchoServer()
+	g.Expect(err).To(gomega.BeNil())
+	defer echo.Close()
+
+	server, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer server.Close()
+
+	knownHosts := writeTempFile(g, server.KnownHostsLine()+"\n")
+	defer os.Remove(knownHosts)
+
+	tunnel, err := OpenSshTunnel(SshTunnelConfig{
+		Hops:           []SshHostConfig{hostConfig(server, knownHosts)},
+		ConnectTimeout: 5 * time.Second,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer tunnel.Close()
+
+	conn, err := tunnel.Dial("tcp", echo.Addr().String())
+	g.Expect(err).To(gomega.BeNil())
+	expectEcho(g, conn)
+
+	conn, err = tunnel.DialTimeout("tcp", echo.Addr().String(), 5*time.Second)
+	g.Expect(err).To(gomega.BeNil())
+	expectEcho(g, conn)
+
+	listener, err := tunnel.Forward(echo.Addr().String())
+	g.Expect(err).To(gomega.BeNil())
+
+	conn, err = net.Dial("tcp", listener.Addr().String())
+	g.Expect(err).To(gomega.BeNil())
+	expectEcho(g, conn)
+
+	g.Expect(tunnel.Close()).To(gomega.BeNil())
+
+	_, err = net.Dial("tcp", listener.Addr().String())
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	_, err = tunnel.Forward(echo.Addr().String())
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestSshTunnelPrivateKey(t *testing.T) {
+	for _, passphrase := range []string{"", "passphrase"} {
+		g := gomega.NewGomegaWithT(t)
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		g.Expect(err).To(gomega.BeNil())
+
+		block := &pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		}
+
+		if passphrase != "" {
+			block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+			g.Expect(err).To(gomega.BeNil())
+		}
+
+		keyFile := writeTempFile(g, string(pem.EncodeToMemory(block)))
+		defer os.Remove(keyFile)
+
+		publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+		g.Expect(err).To(gomega.BeNil())
+
+		echo, err := test_utility.NewEchoServer()
+		g.Expect(err).To(gomega.BeNil())
+		defer echo.Close()
+
+		server, err := test_utility.NewFakeSshServer("bastion", "", publicKey)
+		g.Expect(err).To(gomega.BeNil())
+		defer server.Close()
+
+		knownHosts := writeTempFile(g, server.KnownHostsLine()+"\n")
+		defer os.Remove(knownHosts)
+
+		hop := hostConfig(server, knownHosts)
+		hop.Password = ""
+		hop.PrivateKeyFile = keyFile
+		hop.PrivateKeyPassphrase = passphrase
+
+		tunnel, err := OpenSshTunnel(SshTunnelConfig{
+			Hops: []SshHostConfig{hop},
+		})
+		g.Expect(err).To(gomega.BeNil())
+
+		conn, err := tunnel.Dial("tcp", echo.Addr().String())
+		g.Expect(err).To(gomega.BeNil())
+		expectEcho(g, conn)
+		tunnel.Close()
+	}
+}
+
+func TestSshTunnelJumpChain(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	echo, err := test_utility.NewEchoServer()
+	g.Expect(err).To(gomega.BeNil())
+	defer echo.Close()
+
+	first, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer first.Close()
+
+	second, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer second.Close()
+
+	knownHosts := writeTempFile(g, first.KnownHostsLine()+"\n"+second.KnownHostsLine()+"\n")
+	defer os.Remove(knownHosts)
+
+	tunnel, err := OpenSshTunnel(SshTunnelConfig{
+		Hops: []SshHostConfig{
+			hostConfig(first, knownHosts),
+			hostConfig(second, knownHosts),
+		},
+		ConnectTimeout: 5 * time.Second,
+	})
+	g.Expect(err).To(gomega.BeNil())
+	defer tunnel.Close()
+
+	conn, err := tunnel.Dial("tcp", echo.Addr().String())
+	g.Expect(err).To(gomega.BeNil())
+	expectEcho(g, conn)
+
+	// The second hop's key must be verified too.
+	knownHosts = writeTempFile(g, first.KnownHostsLine()+"\n")
+	defer os.Remove(knownHosts)
+
+	_, err = OpenSshTunnel(SshTunnelConfig{
+		Hops: []SshHostConfig{
+			hostConfig(first, knownHosts),
+			hostConfig(second, knownHosts),
+		},
+	})
+	g.Expect(err).NotTo(gomega.BeNil())
+}
+
+func TestSshTunnelErrors(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	server, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer server.Close()
+
+	other, err := test_utility.NewFakeSshServer("bastion", "password", nil)
+	g.Expect(err).To(gomega.BeNil())
+	defer other.Close()
+
+	knownHosts := writeTempFile(g, server.KnownHostsLine()+"\n")
+	defer os.Remove(knownHosts)
+
+	// The key of another server under this server's address.
+	wrongKnownHosts := writeTempFile(g, strings.Replace(other.KnownHostsLine(), other.Address(), server.Address(), 1)+"\n")
+	defer os.Remove(wrongKnownHosts)
+
+	emptyKnownHosts := writeTempFile(g, "")
+	defer os.Remove(emptyKnownHosts)
+
+	for _, hop := range []SshHostConfig{
+		// Wrong password.
+		{Host: server.Host(), Port: server.Port(), Username: "bastion", Password: "wrong", KnownHostsFile: knownHosts},
+		// Wrong user.
+		{Host: server.Host(), Port: server.Port(), Username: "root", Password: "password", KnownHostsFile: knownHosts},
+		// No credentials.
+		{Host: server.Host(), Port: server.Port(), Username: "bastion", KnownHostsFile: knownHosts},
+		// No known_hosts.
+		{Host: server.Host(), Port: server.Port(), Username: "bastion", Password: "password"},
+		// Unknown host.
+		{Host: server.Host(), Port: server.Port(), Username: "bastion", Password: "password", KnownHostsFile: emptyKnownHosts},
+		// Changed host key.
+		{Host: server.Host(), Port: server.Port(), Username: "bastion", Password: "password", KnownHostsFile: wrongKnownHosts},
+		// Missing private key.
+		{Host: server.Host(), Port: server.Port(), Username: "bastion", PrivateKeyFile: "/does/not/exist", KnownHostsFile: knownHosts},
+	} {
+		_, err := OpenSshTunnel(SshTunnelConfig{
+			Hops: []SshHostConfig{hop},
+		})
+		g.Expect(err).NotTo(gomega.BeNil())
+	}
+
+	_, err = OpenSshTunnel(SshTunnelConfig{})
+	g.Expect(err).NotTo(gomega.BeNil())
+
+	tunnel, err := OpenSshTunnel(SshTunnelConfig{
+		Hops: []SshHostConfig{
+			{Host: server.Host(), Port: server.Port(), Username: "bastion", Password: "password", InsecureIgnoreHostKey: true},
+		},
+	})
+	g.Expect(err).To(gomega.BeNil())
+	tunnel.Close()
+}